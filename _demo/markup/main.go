@@ -7,6 +7,7 @@ import (
 	"github.com/gdamore/tcell/v2"
 	"github.com/malivvan/cui"
 	"github.com/malivvan/cui/markup"
+	"github.com/malivvan/cui/markup/qjs"
 )
 
 func main() {
@@ -28,10 +29,11 @@ func main() {
 </style>
 <script>
 const abc = () => {
-aaa("xxx")
 	console.log("===================================================================")
 };
 
+export default { abc };
+
 </script>
 <flex direction="row">
 	<button id="b11" size="1" style="color: red;" onclick="abc()">xxxx</button>
@@ -50,7 +52,9 @@ aaa("xxx")
 <script>
     console.log("Layout test initialized.");
 </script>
-`)
+`, markup.DocumentOptionRuntime(qjs.Option{Console: log}), markup.DocumentOptionErrorFunc(func(err error) {
+		_, _ = fmt.Fprintln(log, err)
+	}))
 	if err != nil {
 		panic(err)
 	}
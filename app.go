@@ -122,6 +122,16 @@ type App struct {
 
 	// The last mouse button state.
 	lastMouseButtons tcell.ButtonMask
+
+	// The payload dragged with the mouse, the drop target under the mouse and
+	// whether it accepts the payload.
+	drag         *DragPayload
+	dropTarget   DropTarget
+	dropAccepted bool
+
+	// Whether there was an attempt to start a drag since the left mouse
+	// button was pressed.
+	dragRefused bool
 }
 
 // New creates and returns a new application.
@@ -395,6 +405,15 @@ func (a *App) Run() error {
 				return
 			}
 
+			// Escape cancels dragging.
+			if a.drag != nil {
+				if event.Key() == tcell.KeyEscape {
+					a.endDrag(a.lastMouseX, a.lastMouseY, false)
+					a.draw()
+				}
+				return
+			}
+
 			// Pass other key events to the currently focused primitive.
 			if p != nil {
 				if handler := p.InputHandler(); handler != nil {
@@ -492,7 +511,9 @@ func (a *App) Run() error {
 
 	// Wait for the screen replacement event loop to finish.
 	wg.Wait()
+	a.mu.Lock()
 	a.screen = nil
+	a.mu.Unlock()
 
 	return nil
 }
@@ -500,6 +521,17 @@ func (a *App) Run() error {
 // fireMouseActions analyzes the provided mouse event, derives mouse actions
 // from it and then forwards them to the corresponding primitives.
 func (a *App) fireMouseActions(event *tcell.EventMouse) (consumed, isMouseDownAction bool) {
+	// Payloads dragged with the mouse are handled by the application.
+	if a.drag != nil {
+		a.fireDragActions(event)
+		return true, false
+	}
+	if event.Buttons()&tcell.ButtonPrimary != 0 && a.lastMouseButtons&tcell.ButtonPrimary != 0 &&
+		a.mouseCapturingPrimitive == nil && !a.dragRefused && a.startDrag(event) {
+		a.lastMouseX, a.lastMouseY = event.Position()
+		return true, false
+	}
+
 	// We want to relay follow-up events to the same target primitive.
 	var targetPrimitive Widget
 
@@ -563,6 +595,9 @@ func (a *App) fireMouseActions(event *tcell.EventMouse) (consumed, isMouseDownAc
 		{tcell.ButtonSecondary, MouseRightDown, MouseRightUp, MouseRightClick, MouseRightDoubleClick},
 	} {
 		if buttonChanges&buttonEvent.button != 0 {
+			if buttonEvent.button == tcell.ButtonPrimary {
+				a.dragRefused = false
+			}
 			if buttons&buttonEvent.button != 0 {
 				fire(buttonEvent.down)
 			} else {
@@ -707,6 +742,7 @@ func (a *App) draw() {
 
 	// Draw all primitives.
 	root.Draw(screen)
+	a.drawDrag(screen)
 
 	// Call after handler if there is one.
 	if after != nil {
@@ -0,0 +1,28 @@
+package cui
+
+import (
+	"github.com/atotto/clipboard"
+)
+
+// Clipboard provides access to a clipboard.
+type Clipboard interface {
+	// ReadAll returns the text of the clipboard.
+	ReadAll() (string, error)
+
+	// WriteAll replaces the text of the clipboard.
+	WriteAll(text string) error
+}
+
+// SystemClipboard is the clipboard of the operating system. It is the default
+// clipboard of widgets supporting copy and paste.
+var SystemClipboard Clipboard = systemClipboard{}
+
+type systemClipboard struct{}
+
+func (systemClipboard) ReadAll() (string, error) {
+	return clipboard.ReadAll()
+}
+
+func (systemClipboard) WriteAll(text string) error {
+	return clipboard.WriteAll(text)
+}
@@ -3,229 +3,45 @@ package main
 import (
 	"fmt"
 	"log"
-	"sync"
+	"net/http"
 
-	"errors"
-
-	"github.com/gdamore/tcell/v3"
+	"github.com/gdamore/tcell/v2"
 	"github.com/gliderlabs/ssh"
+	"github.com/malivvan/cui"
+	"github.com/malivvan/cui/service"
 )
 
-func drawText(s tcell.Screen, x1, y1, x2, y2 int, style tcell.Style, text string) {
-	row := y1
-	col := x1
-	for _, r := range text {
-		s.SetContent(col, row, r, nil, style)
-		col++
-		if col >= x2 {
-			row++
-			col = x1
-		}
-		if row > y2 {
-			break
-		}
-	}
-}
-
-func drawBox(s tcell.Screen, x1, y1, x2, y2 int, style tcell.Style, text string) {
-	if y2 < y1 {
-		y1, y2 = y2, y1
-	}
-	if x2 < x1 {
-		x1, x2 = x2, x1
-	}
-
-	// Fill background
-	for row := y1; row <= y2; row++ {
-		for col := x1; col <= x2; col++ {
-			s.SetContent(col, row, ' ', nil, style)
-		}
-	}
-
-	// Draw borders
-	for col := x1; col <= x2; col++ {
-		s.SetContent(col, y1, tcell.RuneHLine, nil, style)
-		s.SetContent(col, y2, tcell.RuneHLine, nil, style)
-	}
-	for row := y1 + 1; row < y2; row++ {
-		s.SetContent(x1, row, tcell.RuneVLine, nil, style)
-		s.SetContent(x2, row, tcell.RuneVLine, nil, style)
-	}
-
-	// Only draw corners if necessary
-	if y1 != y2 && x1 != x2 {
-		s.SetContent(x1, y1, tcell.RuneULCorner, nil, style)
-		s.SetContent(x2, y1, tcell.RuneURCorner, nil, style)
-		s.SetContent(x1, y2, tcell.RuneLLCorner, nil, style)
-		s.SetContent(x2, y2, tcell.RuneLRCorner, nil, style)
-	}
-
-	drawText(s, x1+1, y1+1, x2-1, y2-1, style, text)
-}
-
-func NewSessionScreen(s ssh.Session) (tcell.Screen, error) {
-	pi, ch, ok := s.Pty()
-	if !ok {
-		return nil, errors.New("no pty requested")
-	}
-
-	screen, err := tcell.NewTerminfoScreenFromTty(&tty{
-		Session: s,
-		size:    pi.Window,
-		ch:      ch,
+func main() {
+	server, err := service.NewServer(service.Config{
+		TCP: 2222,
+		SSH: &ssh.Server{Handler: cui.ServeSSH(func(sess ssh.Session) *cui.App {
+			return newApp(sess.User() + "@" + sess.RemoteAddr().String())
+		})},
+		HTTP: &http.Server{Handler: cui.ServeWeb(func(r *http.Request) *cui.App {
+			return newApp(r.RemoteAddr)
+		})},
+		ERR: func(err error) { log.Println(err) },
 	})
 	if err != nil {
-		return nil, err
+		log.Fatal(err)
 	}
-	return screen, nil
+	server.Wait()
 }
 
-func main() {
-	ssh.Handle(func(sess ssh.Session) {
-		s, err := NewSessionScreen(sess)
-		if err != nil {
-			fmt.Fprintln(sess.Stderr(), "unable to create screen:", err)
-			return
-		}
-		defStyle := tcell.StyleDefault.Background(tcell.ColorReset).Foreground(tcell.ColorReset)
-		boxStyle := tcell.StyleDefault.Foreground(tcell.ColorWhite).Background(tcell.ColorPurple)
-
-		// Initialize screen
-		if err := s.Init(); err != nil {
-			log.Fatalf("%+v", err)
-		}
-		s.SetStyle(defStyle)
-		s.EnableMouse()
-		s.EnablePaste()
-		s.Clear()
-
-		stty, ok := s.Tty()
-		if !ok {
-			log.Fatalf("unable to get tty from screen")
-		}
-		sttySize, err := stty.WindowSize()
-		if err != nil {
-			log.Fatalf("unable to get tty size: %+v", err)
-		}
-		cellW, cellH := sttySize.CellDimensions()
-		drawText(s, 0, 0, 50, 1, defStyle, fmt.Sprintf("(w:%d h:%d) (%dx%d cells) (%dx%d pixels)", cellW, cellH, sttySize.Width, sttySize.Height, sttySize.PixelWidth, sttySize.PixelHeight))
-
-		// Draw initial boxes
-		drawBox(s, 1, 1, 42, 7, boxStyle, "Click and drag to draw a box")
-		drawBox(s, 5, 9, 32, 14, boxStyle, "Press C to reset")
-
-		quit := func() {
-			// You have to catch panics in a defer, clean up, and
-			// re-raise them - otherwise your application can
-			// die without leaving any diagnostic trace.
-			maybePanic := recover()
-			s.Fini()
-			if maybePanic != nil {
-				panic(maybePanic)
-			}
-		}
-		defer quit()
+func newApp(title string) *cui.App {
+	app := cui.New()
+	app.EnableMouse(true)
 
-		// Here's how to get the screen size when you need it.
-		// xmax, ymax := s.Size()
-
-		// Here's an example of how to inject a keystroke where it will
-		// be picked up by the next PollEvent call.  Note that the
-		// queue is LIFO, it has a limited length, and PostEvent() can
-		// return an error.
-		// s.PostEvent(tcell.NewEventKey(tcell.KeyRune, rune('a'), 0))
-
-		// Event loop
-		ox, oy := -1, -1
-		for {
-			// Update screen
-			s.Show()
-
-			// Poll event
-			ev := <-s.EventQ()
-
-			// Process event
-			switch ev := ev.(type) {
-			case *tcell.EventResize:
-				s.Sync()
-			case *tcell.EventKey:
-				if ev.Key() == tcell.KeyEscape || ev.Key() == tcell.KeyCtrlC {
-					return
-				} else if ev.Key() == tcell.KeyCtrlL {
-					s.Sync()
-				} else if ev.Str() == "C" || ev.Str() == "c" {
-					s.Clear()
-				}
-			case *tcell.EventMouse:
-				x, y := ev.Position()
-
-				switch ev.Buttons() {
-				case tcell.Button1, tcell.Button2:
-					if ox < 0 {
-						ox, oy = x, y // record location when click started
-					}
-
-				case tcell.ButtonNone:
-					if ox >= 0 {
-						label := fmt.Sprintf("%d,%d to %d,%d", ox, oy, x, y)
-						drawBox(s, ox, oy, x, y, boxStyle, label)
-						ox, oy = -1, -1
-					}
-				}
-			}
-		}
+	text := cui.NewTextView()
+	text.SetBorder(true)
+	text.SetTitle(" " + title + " ")
+	text.SetText("Press Ctrl-C to disconnect\n")
+	app.SetAfterResizeFunc(func(width, height int) {
+		fmt.Fprintf(text, "window: %dx%d\n", width, height)
 	})
-
-	log.Fatal(ssh.ListenAndServe(":2222", nil))
-}
-
-type tty struct {
-	ssh.Session
-	size     ssh.Window
-	sizePx   ssh.Window
-	ch       <-chan ssh.Window
-	resizecb func()
-	mu       sync.Mutex
-}
-
-func (t *tty) Start() error {
-	go func() {
-		for win := range t.ch {
-			t.size = win
-			t.notifyResize()
-		}
-	}()
-	return nil
-}
-
-func (t *tty) Stop() error {
-	return nil
-}
-
-func (t *tty) Drain() error {
-	return nil
-}
-
-func (t *tty) WindowSize() (tcell.WindowSize, error) {
-	size := tcell.WindowSize{
-		Width:       t.size.Width,
-		Height:      t.size.Height,
-		PixelWidth:  t.sizePx.Width,
-		PixelHeight: t.sizePx.Height,
-	}
-	return size, nil
-}
-
-func (t *tty) NotifyResize(cb func()) {
-	t.mu.Lock()
-	defer t.mu.Unlock()
-	t.resizecb = cb
-}
-
-func (t *tty) notifyResize() {
-	t.mu.Lock()
-	defer t.mu.Unlock()
-	if t.resizecb != nil {
-		t.resizecb()
-	}
+	app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		fmt.Fprintf(text, "key: %s\n", event.Name())
+		return event
+	})
+	return app.SetRoot(text, true)
 }
@@ -144,95 +144,3 @@ type box[T Widget] interface {
 	WrapInputHandler(inputHandler func(*tcell.EventKey, func(p Widget))) func(*tcell.EventKey, func(p Widget))
 	WrapMouseHandler(mouseHandler func(MouseAction, *tcell.EventMouse, func(p Widget)) (bool, Widget)) func(action MouseAction, event *tcell.EventMouse, setFocus func(p Widget)) (consumed bool, capture Widget)
 }
-
-//////////////////////////////////////////////////////////////////////
-//
-//type View struct {
-//	doc    *etree.Document
-//	root   *Node[Widget]
-//	flex   map[string]*Flex
-//	button map[string]*Button
-//}
-//
-//func NewEditor(s string) (view *View, err error) {
-//	doc := etree.NewDocument()
-//	if err := doc.ReadFromString(s); err != nil {
-//		panic(err)
-//	}
-//	view = &View{
-//		doc:    doc,
-//		flex:   make(map[string]*Flex),
-//		button: make(map[string]*Button),
-//	}
-//	view.root, err = view.traverse(doc.Root())
-//	if err != nil {
-//		return nil, err
-//	}
-//	return view, nil
-//}
-//
-//func (view *View) Doc() *etree.Document {
-//	return view.doc
-//}
-//
-//func (view *View) Root() Widget {
-//	return view.root.widget
-//}
-//
-//func (view *View) Flex(id string) *Flex {
-//	if flex, ok := view.flex[id]; ok {
-//		return flex
-//	}
-//	return nil
-//}
-//
-//func (view *View) Button(id string) *Button {
-//	if button, ok := view.button[id]; ok {
-//		return button
-//	}
-//	return nil
-//}
-//
-//type Node[WIDGET Widget] struct {
-//	element  *etree.Element
-//	widget   WIDGET
-//	parent   *Node[Widget]
-//	children []*Node[Widget]
-//}
-//
-//func (view *View) traverse(element *etree.Element) (*Node[Widget], error) {
-//	id := element.SelectAttrValue("id", "")
-//	switch element.Tag {
-//	case "flex":
-//		flex := NewFlex().
-//			SetDirection(FlexRow)
-//		if id != "" {
-//			view.flex[id] = flex
-//		}
-//		flexNode := &Node[Widget]{
-//			element: element,
-//			widget:  flex,
-//		}
-//		for _, child := range element.ChildElements() {
-//			childNode, err := view.traverse(child)
-//			if err != nil {
-//				return nil, err
-//			}
-//			childNode.parent = flexNode
-//			flexNode.children = append(flexNode.children, childNode)
-//			flex.AddItem(childNode.widget, 1, 0, false)
-//		}
-//		return flexNode, nil
-//
-//	case "button":
-//		button := NewButton().SetLabel(element.Text())
-//		if id != "" {
-//			view.button[id] = button
-//		}
-//		return &Node[Widget]{
-//			element: element,
-//			widget:  button,
-//		}, nil
-//	}
-//	return nil, fmt.Errorf("unknown node type: %s", strings.ToLower(element.Tag))
-//}
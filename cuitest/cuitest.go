@@ -0,0 +1,494 @@
+// Package cuitest drives a cui.App on a simulation screen, so that screens
+// can be tested without a terminal.
+//
+// A Harness runs the event loop of an application in the background. Input
+// is injected with Key, Press, Type, Click, Mouse and Resize, each of which
+// waits until the application has handled it and gone idle. The screen can
+// then be inspected with Text, Line, Cell and Cursor, checked with the
+// Assert methods or compared with a golden file by AssertGolden:
+//
+//	func TestForm(t *testing.T) {
+//	    input := cui.NewInputField().SetLabel("Name: ")
+//	    h := cuitest.New(t, input)
+//	    h.Type("Ada")
+//	    h.AssertLine(0, "Name: Ada")
+//	    h.AssertGolden("form")
+//	}
+package cuitest
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/malivvan/cui"
+	"github.com/mattn/go-runewidth"
+)
+
+const (
+	// DefaultWidth and DefaultHeight are the size of the screen of a harness
+	// created by New
+	DefaultWidth  = 80
+	DefaultHeight = 24
+
+	// DefaultTimeout is how long the harness waits for the application to
+	// go idle
+	DefaultTimeout = 5 * time.Second
+
+	// resizeThrottle is the minimum time between resize events which are
+	// handled immediately by the application
+	resizeThrottle = 50*time.Millisecond + time.Millisecond
+)
+
+// screen is a simulation screen which tracks the events handled by the
+// application
+type screen struct {
+	tcell.SimulationScreen
+
+	mu sync.Mutex
+	// posted is the number of events posted to the screen
+	posted int
+	// received is the number of events returned by PollEvent
+	received int
+	// handled is the number of events the application has finished handling,
+	// which it has when it polls for the next one
+	handled int
+}
+
+func (s *screen) post(post func()) {
+	s.mu.Lock()
+	s.posted += 1
+	s.mu.Unlock()
+	post()
+}
+
+func (s *screen) PollEvent() tcell.Event {
+	s.mu.Lock()
+	s.handled = s.received
+	s.mu.Unlock()
+	event := s.SimulationScreen.PollEvent()
+	s.mu.Lock()
+	s.received += 1
+	s.mu.Unlock()
+	return event
+}
+
+func (s *screen) InjectKey(key tcell.Key, r rune, mod tcell.ModMask) {
+	s.post(func() { s.SimulationScreen.InjectKey(key, r, mod) })
+}
+
+func (s *screen) InjectMouse(x int, y int, buttons tcell.ButtonMask, mod tcell.ModMask) {
+	s.post(func() { s.SimulationScreen.InjectMouse(x, y, buttons, mod) })
+}
+
+func (s *screen) PostEvent(event tcell.Event) error {
+	var err error
+	s.post(func() { err = s.SimulationScreen.PostEvent(event) })
+	return err
+}
+
+// pending reports whether there are posted events which were not handled yet
+func (s *screen) pending() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.handled < s.posted
+}
+
+// Harness runs an application on a simulation screen for a test
+type Harness struct {
+	t       testing.TB
+	app     *cui.App
+	screen  *screen
+	timeout time.Duration
+	done    chan struct{}
+
+	// lastResize is when the last resize event was handled
+	lastResize time.Time
+
+	// cells, width and height are the contents of the screen, and cursorX
+	// and cursorY the position of the cursor, when the application was last
+	// idle
+	cells            []tcell.SimCell
+	width, height    int
+	cursorX, cursorY int
+}
+
+// New runs an application with the root widget in fullscreen on a screen of
+// the default size, with mouse events enabled. The application is stopped
+// when the test finishes
+func New(t testing.TB, root cui.Widget) *Harness {
+	t.Helper()
+	app := cui.New().EnableMouse(true).SetRoot(root, true)
+	return NewApp(t, app, DefaultWidth, DefaultHeight)
+}
+
+// NewApp runs a configured application on a screen of the given size. The
+// application must not have a screen yet. It is stopped when the test
+// finishes
+func NewApp(t testing.TB, app *cui.App, width int, height int) *Harness {
+	t.Helper()
+	sim := tcell.NewSimulationScreen("UTF-8")
+	if err := sim.Init(); err != nil {
+		t.Fatalf("failed to initialize simulation screen: %s", err)
+	}
+	sim.SetSize(width, height)
+	h := &Harness{
+		t:       t,
+		app:     app,
+		screen:  &screen{SimulationScreen: sim},
+		timeout: DefaultTimeout,
+		done:    make(chan struct{}),
+	}
+	app.SetScreen(h.screen)
+
+	// Terminals report their size on start, which sizes the application
+	h.screen.PostEvent(tcell.NewEventResize(width, height))
+	go func() {
+		defer close(h.done)
+		app.Run()
+	}()
+	t.Cleanup(h.Stop)
+	h.Idle()
+	h.lastResize = time.Now()
+	return h
+}
+
+// SetTimeout sets how long to wait for the application to go idle before
+// the test fails
+func (h *Harness) SetTimeout(timeout time.Duration) *Harness {
+	h.timeout = timeout
+	return h
+}
+
+// App returns the application
+func (h *Harness) App() *cui.App {
+	return h.app
+}
+
+// Screen returns the simulation screen of the application. Its contents
+// change while the application draws, so inspect the screen with the methods
+// of the harness instead
+func (h *Harness) Screen() tcell.SimulationScreen {
+	return h.screen
+}
+
+// capture copies the contents of the screen. It is called in the event loop
+// to not race with drawing
+func (h *Harness) capture() {
+	cells, width, height := h.screen.GetContents()
+	if width <= 0 || height <= 0 || len(cells) < width*height {
+		// The screen was finalized
+		return
+	}
+	h.cells = make([]tcell.SimCell, width*height)
+	for i, cell := range cells[:width*height] {
+		h.cells[i] = tcell.SimCell{
+			Bytes: append([]byte(nil), cell.Bytes...),
+			Style: cell.Style,
+			Runes: append([]rune(nil), cell.Runes...),
+		}
+	}
+	h.width, h.height = width, height
+	h.cursorX, h.cursorY, _ = h.screen.GetCursor()
+}
+
+// Stop stops the application and waits for it to return from Run
+func (h *Harness) Stop() {
+	if h.Stopped() {
+		return
+	}
+	h.app.Stop()
+	select {
+	case <-h.done:
+	case <-time.After(h.timeout):
+		h.t.Errorf("failed to stop application: timed out after %s", h.timeout)
+	}
+}
+
+// Stopped reports whether the application returned from Run, such as after
+// Ctrl-C was pressed
+func (h *Harness) Stopped() bool {
+	select {
+	case <-h.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Idle waits until the application has handled all injected events and
+// executed all queued updates, including those queued while doing so, and
+// captures the screen, which is then inspected by the other methods. It
+// returns immediately if the application was stopped
+func (h *Harness) Idle() {
+	h.t.Helper()
+	deadline := time.Now().Add(h.timeout)
+	for {
+		for h.screen.pending() {
+			if h.Stopped() {
+				return
+			}
+			if time.Now().After(deadline) {
+				h.t.Fatalf("failed to wait for application: events pending after %s", h.timeout)
+			}
+			time.Sleep(time.Millisecond)
+		}
+
+		// Updates are executed in order, so all updates queued before are
+		// done once this one is
+		done := make(chan struct{})
+		h.app.QueueUpdate(func() {
+			h.capture()
+			close(done)
+		})
+		select {
+		case <-done:
+		case <-h.done:
+			return
+		case <-time.After(time.Until(deadline)):
+			h.t.Fatalf("failed to wait for application: updates pending after %s", h.timeout)
+		}
+		if !h.screen.pending() {
+			return
+		}
+	}
+}
+
+// WaitFor waits until the condition holds, such as for the result of a
+// background task to be drawn. The condition is checked whenever the
+// application is idle
+func (h *Harness) WaitFor(condition func() bool) {
+	h.t.Helper()
+	deadline := time.Now().Add(h.timeout)
+	for {
+		h.Idle()
+		if condition() {
+			return
+		}
+		if time.Now().After(deadline) {
+			h.t.Fatalf("failed to wait for condition: timed out after %s\n%s", h.timeout, h.Snapshot())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// Update executes the function in the event loop, redraws the screen and
+// waits until the application is idle. Use it to modify widgets while the
+// application is running
+func (h *Harness) Update(f func()) {
+	h.t.Helper()
+	h.app.QueueUpdateDraw(f)
+	h.Idle()
+}
+
+// Key injects a key event
+func (h *Harness) Key(key tcell.Key, r rune, mod tcell.ModMask) {
+	h.t.Helper()
+	h.screen.InjectKey(key, r, mod)
+	h.Idle()
+}
+
+// Press injects the keys given by name, such as "Enter", "Ctrl+A" or "x".
+// Names are decoded with cui.BindDecode
+func (h *Harness) Press(keys ...string) {
+	h.t.Helper()
+	for _, name := range keys {
+		mod, key, r, err := cui.BindDecode(name)
+		if err != nil {
+			h.t.Fatalf("failed to decode key %q: %s", name, err)
+		}
+		h.screen.InjectKey(key, r, mod)
+	}
+	h.Idle()
+}
+
+// Type injects a key event for every rune of the text
+func (h *Harness) Type(text string) {
+	h.t.Helper()
+	for _, r := range text {
+		h.screen.InjectKey(tcell.KeyRune, r, tcell.ModNone)
+	}
+	h.Idle()
+}
+
+// Mouse injects a mouse event
+func (h *Harness) Mouse(x int, y int, buttons tcell.ButtonMask, mod tcell.ModMask) {
+	h.t.Helper()
+	h.screen.InjectMouse(x, y, buttons, mod)
+	h.Idle()
+}
+
+// Click presses and releases the primary mouse button at the given position
+func (h *Harness) Click(x int, y int) {
+	h.t.Helper()
+	h.screen.InjectMouse(x, y, tcell.Button1, tcell.ModNone)
+	h.screen.InjectMouse(x, y, tcell.ButtonNone, tcell.ModNone)
+	h.Idle()
+}
+
+// Resize resizes the screen. Resizes in quick succession are throttled by
+// the application, so Resize waits until the new size is handled immediately
+func (h *Harness) Resize(width int, height int) {
+	h.t.Helper()
+	time.Sleep(time.Until(h.lastResize.Add(resizeThrottle)))
+	h.screen.SetSize(width, height)
+	h.screen.PostEvent(tcell.NewEventResize(width, height))
+	h.Idle()
+	h.lastResize = time.Now()
+}
+
+// Size returns the size of the screen
+func (h *Harness) Size() (int, int) {
+	return h.width, h.height
+}
+
+// Cell returns the text and the style of the cell at the given position.
+// The text is empty for cells covered by a wide rune to their left
+func (h *Harness) Cell(x int, y int) (string, tcell.Style) {
+	h.t.Helper()
+	cells, width, height := h.cells, h.width, h.height
+	if x < 0 || y < 0 || x >= width || y >= height {
+		h.t.Fatalf("failed to get cell: %d,%d is outside of the %dx%d screen", x, y, width, height)
+	}
+	for col := 0; col < x; {
+		col += cellWidth(cells[y*width+col])
+		if col > x {
+			return "", cells[y*width+x].Style
+		}
+	}
+	cell := cells[y*width+x]
+	if len(cell.Runes) == 0 {
+		return " ", cell.Style
+	}
+	return string(cell.Runes), cell.Style
+}
+
+// Style returns the style of the cell at the given position
+func (h *Harness) Style(x int, y int) tcell.Style {
+	h.t.Helper()
+	_, style := h.Cell(x, y)
+	return style
+}
+
+// Line returns the text of the row of the screen with trailing spaces
+// removed
+func (h *Harness) Line(y int) string {
+	h.t.Helper()
+	cells, width, height := h.cells, h.width, h.height
+	if y < 0 || y >= height {
+		h.t.Fatalf("failed to get line: %d is outside of the %dx%d screen", y, width, height)
+	}
+	return line(cells[y*width : (y+1)*width])
+}
+
+// Text returns the text of the screen. Trailing spaces are removed from the
+// lines, and trailing empty lines are omitted
+func (h *Harness) Text() string {
+	lines := make([]string, h.height)
+	for y := range lines {
+		lines[y] = line(h.cells[y*h.width : (y+1)*h.width])
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+// Cursor returns the position of the cursor and whether it is shown
+func (h *Harness) Cursor() (int, int, bool) {
+	x, y := h.cursorX, h.cursorY
+	if x < 0 || y < 0 || x >= h.width || y >= h.height {
+		return -1, -1, false
+	}
+	return x, y, true
+}
+
+// AssertText checks the text of the screen as returned by Text
+func (h *Harness) AssertText(expected string) {
+	h.t.Helper()
+	if got := h.Text(); got != expected {
+		h.t.Errorf("failed to match screen text: (-want +got)\n%s", diff(expected, got))
+	}
+}
+
+// AssertLine checks the text of a row as returned by Line
+func (h *Harness) AssertLine(y int, expected string) {
+	h.t.Helper()
+	if got := h.Line(y); got != expected {
+		h.t.Errorf("failed to match line %d: expected %q, got %q", y, expected, got)
+	}
+}
+
+// AssertContains checks that the text of the screen contains the string
+func (h *Harness) AssertContains(s string) {
+	h.t.Helper()
+	if text := h.Text(); !strings.Contains(text, s) {
+		h.t.Errorf("failed to find %q on screen:\n%s", s, text)
+	}
+}
+
+// AssertStyle checks the style of the cell at the given position
+func (h *Harness) AssertStyle(x int, y int, expected tcell.Style) {
+	h.t.Helper()
+	if got := h.Style(x, y); got != expected {
+		h.t.Errorf("failed to match style at %d,%d: expected %s, got %s", x, y, formatStyle(expected), formatStyle(got))
+	}
+}
+
+// AssertCursor checks that the cursor is shown at the given position
+func (h *Harness) AssertCursor(x int, y int) {
+	h.t.Helper()
+	gotX, gotY, visible := h.Cursor()
+	switch {
+	case !visible:
+		h.t.Errorf("failed to match cursor: expected %d,%d, got hidden cursor", x, y)
+	case gotX != x || gotY != y:
+		h.t.Errorf("failed to match cursor: expected %d,%d, got %d,%d", x, y, gotX, gotY)
+	}
+}
+
+// AssertCursorHidden checks that the cursor is hidden
+func (h *Harness) AssertCursorHidden() {
+	h.t.Helper()
+	if x, y, visible := h.Cursor(); visible {
+		h.t.Errorf("failed to match cursor: expected hidden cursor, got %d,%d", x, y)
+	}
+}
+
+// cellWidth returns the number of columns the cell covers
+func cellWidth(cell tcell.SimCell) int {
+	if len(cell.Runes) == 0 {
+		return 1
+	}
+	return max(runewidth.RuneWidth(cell.Runes[0]), 1)
+}
+
+// line returns the text of a row of cells with trailing spaces removed
+func line(cells []tcell.SimCell) string {
+	b := strings.Builder{}
+	for x := 0; x < len(cells); {
+		cell := cells[x]
+		if len(cell.Runes) == 0 {
+			b.WriteRune(' ')
+		} else {
+			b.WriteString(string(cell.Runes))
+		}
+		x += cellWidth(cell)
+	}
+	return strings.TrimRight(b.String(), " ")
+}
+
+func formatStyle(style tcell.Style) string {
+	fg, bg, attrs := style.Decompose()
+	return fmt.Sprintf("{fg: %s, bg: %s, attrs: %d}", formatColor(fg), formatColor(bg), attrs)
+}
+
+func formatColor(color tcell.Color) string {
+	switch {
+	case color == tcell.ColorDefault:
+		return "default"
+	case color.IsRGB():
+		return fmt.Sprintf("#%06x", color.Hex())
+	}
+	return color.String()
+}
@@ -0,0 +1,109 @@
+package cuitest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/malivvan/cui"
+)
+
+func TestInput(t *testing.T) {
+	t.Parallel()
+
+	input := cui.NewInputField().SetLabel("Name: ").SetFieldWidth(10)
+	h := New(t, input)
+	h.Type("Ada")
+	h.AssertLine(0, "Name: Ada")
+	h.AssertCursor(9, 0)
+	if text := input.GetText(); text != "Ada" {
+		t.Errorf("failed to type text: expected Ada, got %s", text)
+	}
+
+	h.Press("Backspace", "Home", "x")
+	if text := input.GetText(); text != "xAd" {
+		t.Errorf("failed to press keys: expected xAd, got %s", text)
+	}
+	h.AssertCursor(7, 0)
+}
+
+func TestList(t *testing.T) {
+	t.Parallel()
+
+	var selected string
+	list := cui.NewList().ShowSecondaryText(false)
+	for _, text := range []string{"one", "two", "three"} {
+		list.AddItem(cui.NewListItem(text))
+	}
+	list.SetSelectedFunc(func(_ int, item *cui.ListItem) {
+		selected = item.GetMainText()
+	})
+	h := New(t, list)
+	h.AssertText("one\ntwo\nthree")
+
+	h.Press("Down", "Enter")
+	if selected != "two" {
+		t.Errorf("failed to select item with keys: expected two, got %s", selected)
+	}
+	h.Click(1, 2)
+	if index := list.GetCurrentItemIndex(); index != 2 {
+		t.Errorf("failed to select item with mouse: expected 2, got %d", index)
+	}
+	h.AssertStyle(0, 2, h.Style(1, 2))
+	if h.Style(0, 2) == h.Style(0, 0) {
+		t.Errorf("failed to highlight current item: expected different styles")
+	}
+	h.AssertGolden("list")
+}
+
+func TestResize(t *testing.T) {
+	t.Parallel()
+
+	text := cui.NewTextView().SetWrap(true).SetWordWrap(false)
+	text.SetText(strings.Repeat("x", 30))
+	h := New(t, text)
+	h.AssertText(strings.Repeat("x", 30))
+
+	h.Resize(10, 5)
+	if w, h := h.Size(); w != 10 || h != 5 {
+		t.Errorf("failed to resize screen: expected 10x5, got %dx%d", w, h)
+	}
+	h.AssertText("xxxxxxxxxx\nxxxxxxxxxx\nxxxxxxxxxx")
+	h.Resize(15, 5)
+	h.AssertText("xxxxxxxxxxxxxxx\nxxxxxxxxxxxxxxx")
+}
+
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+
+	text := cui.NewTextView()
+	h := New(t, text)
+	h.Update(func() { text.SetText("updated") })
+	h.AssertLine(0, "updated")
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		h.App().QueueUpdateDraw(func() { text.SetText("later") })
+	}()
+	h.WaitFor(func() bool { return h.Line(0) == "later" })
+	h.AssertContains("later")
+}
+
+func TestStop(t *testing.T) {
+	t.Parallel()
+
+	h := New(t, cui.NewBox())
+	h.Key(tcell.KeyCtrlC, 0, tcell.ModCtrl)
+	h.WaitFor(h.Stopped)
+}
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	got := diff("a\nb\nc\n", "a\nx\n")
+	expected := "   2 - \"b\"\n   2 + \"x\"\n   3 - \"c\"\n"
+	if got != expected {
+		t.Errorf("failed to diff: expected %q, got %q", expected, got)
+	}
+}
@@ -0,0 +1,94 @@
+package cuitest
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// UpdateEnv is the environment variable which makes AssertGolden write the
+// golden files instead of comparing with them when set to a non-empty value:
+//
+//	CUITEST_UPDATE=1 go test ./...
+const UpdateEnv = "CUITEST_UPDATE"
+
+// GoldenDir is the directory of the golden files, relative to the directory
+// of the package being tested
+var GoldenDir = "testdata"
+
+// Snapshot returns the text of the screen followed by a line with the cursor
+// position, which is the content of golden files
+func (h *Harness) Snapshot() string {
+	b := strings.Builder{}
+	for y := 0; y < h.height; y += 1 {
+		b.WriteString(h.Line(y))
+		b.WriteRune('\n')
+	}
+	if x, y, visible := h.Cursor(); visible {
+		fmt.Fprintf(&b, "-- cursor %d,%d --\n", x, y)
+	} else {
+		b.WriteString("-- cursor hidden --\n")
+	}
+	return b.String()
+}
+
+// AssertGolden compares the snapshot of the screen with the golden file
+// <GoldenDir>/<name>.golden. The file is written instead if UpdateEnv is set
+// or if it does not exist yet, in which case the test fails
+func (h *Harness) AssertGolden(name string) {
+	h.t.Helper()
+	path := filepath.Join(GoldenDir, name+".golden")
+	got := h.Snapshot()
+
+	data, err := os.ReadFile(path)
+	missing := errors.Is(err, os.ErrNotExist)
+	if err != nil && !missing {
+		h.t.Fatalf("failed to read golden file: %s", err)
+	}
+	if os.Getenv(UpdateEnv) != "" || missing {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			h.t.Fatalf("failed to write golden file: %s", err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			h.t.Fatalf("failed to write golden file: %s", err)
+		}
+		if missing {
+			h.t.Errorf("failed to find golden file: created %s", path)
+		}
+		return
+	}
+
+	if expected := string(data); got != expected {
+		h.t.Errorf("failed to match golden file %s: (-want +got, set %s=1 to update)\n%s", path, UpdateEnv, diff(expected, got))
+	}
+}
+
+// diff returns the lines which differ between the expected and the actual
+// text, with their line numbers. Lines are compared by position, which keeps
+// the rows of screens aligned
+func diff(expected string, got string) string {
+	want := strings.Split(strings.TrimSuffix(expected, "\n"), "\n")
+	have := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+	b := strings.Builder{}
+	for i := 0; i < max(len(want), len(have)); i += 1 {
+		var w, g string
+		if i < len(want) {
+			w = want[i]
+		}
+		if i < len(have) {
+			g = have[i]
+		}
+		if w == g && i < len(want) && i < len(have) {
+			continue
+		}
+		if i < len(want) {
+			fmt.Fprintf(&b, "%4d - %q\n", i+1, w)
+		}
+		if i < len(have) {
+			fmt.Fprintf(&b, "%4d + %q\n", i+1, g)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,188 @@
+package cui
+
+import (
+	"github.com/gdamore/tcell/v2"
+)
+
+// Kinds of the payloads dragged from the widgets of this package.
+const (
+	DragKindListItems = "cui/list-items" // Data is a []*ListItem.
+	DragKindTreeNodes = "cui/tree-nodes" // Data is a []*TreeNode.
+	DragKindTableRows = "cui/table-rows" // Data is a []int of row indices.
+	DragKindTab       = "cui/tab"        // Data is the name of the tab.
+)
+
+// DragPayload is what the user drags with the mouse from a DragSource onto a
+// DropTarget.
+type DragPayload struct {
+	// The widget the payload is dragged from. It is set by the application.
+	Source DragSource
+
+	// The kind of the data, e.g. DragKindListItems.
+	Kind string
+
+	// The dragged data.
+	Data interface{}
+
+	// The text shown next to the mouse while dragging.
+	Label string
+}
+
+// DragSource is implemented by widgets from which the user may drag payloads
+// with the mouse. Drag sources consume the MouseDragStart action and return
+// themselves as the capturing widget, which the application uses to find the
+// source under the mouse.
+type DragSource interface {
+	Widget
+
+	// DragStart is called when the user starts dragging at the given screen
+	// position. It returns the payload to drag, or nil if nothing can be
+	// dragged from there.
+	DragStart(x, y int) *DragPayload
+
+	// DragEnd is called when the drag has ended. "dropped" is true if a drop
+	// target accepted the payload.
+	DragEnd(payload *DragPayload, dropped bool)
+}
+
+// DropTarget is implemented by widgets onto which the user may drop payloads.
+// Drop targets consume the MouseDragOver action and return themselves as the
+// capturing widget, which the application uses to find the target under the
+// mouse.
+type DropTarget interface {
+	Widget
+
+	// DragOver is called while a payload is dragged over the given screen
+	// position. It returns whether the payload would be accepted there. The
+	// target may show where the payload would be dropped.
+	DragOver(payload *DragPayload, x, y int) bool
+
+	// DragLeave is called when the payload leaves the target or the drag has
+	// ended. The target removes what it showed in DragOver().
+	DragLeave()
+
+	// Drop is called when the payload is dropped at the given screen position.
+	// It returns whether the payload was accepted.
+	Drop(payload *DragPayload, x, y int) bool
+}
+
+// dragMinDistance is the distance in cells the mouse must move with the left
+// button pressed before a drag starts.
+const dragMinDistance = 1
+
+// probeMouse sends a probing mouse action to the root widget and returns the
+// widget which consumed it and returned itself as the capturing widget.
+func (a *App) probeMouse(action MouseAction, x, y int, modifiers tcell.ModMask) Widget {
+	if a.root == nil {
+		return nil
+	}
+	handler := a.root.MouseHandler()
+	if handler == nil {
+		return nil
+	}
+	event := tcell.NewEventMouse(x, y, tcell.ButtonPrimary, modifiers)
+	consumed, widget := handler(action, event, func(p Widget) {})
+	if !consumed {
+		return nil
+	}
+	return widget
+}
+
+// startDrag starts dragging the payload of the drag source found where the
+// left mouse button was pressed. It returns whether a drag was started.
+func (a *App) startDrag(event *tcell.EventMouse) bool {
+	x, y := event.Position()
+	dx, dy := x-a.mouseDownX, y-a.mouseDownY
+	if dx < 0 {
+		dx = -dx
+	}
+	if dy < 0 {
+		dy = -dy
+	}
+	if dx < dragMinDistance && dy < dragMinDistance {
+		return false
+	}
+
+	// Only one attempt per press of the button.
+	a.dragRefused = true
+	source, ok := a.probeMouse(MouseDragStart, a.mouseDownX, a.mouseDownY, event.Modifiers()).(DragSource)
+	if !ok {
+		return false
+	}
+	payload := source.DragStart(a.mouseDownX, a.mouseDownY)
+	if payload == nil {
+		return false
+	}
+	payload.Source = source
+	a.drag = payload
+	a.dragTo(x, y, event.Modifiers())
+	return true
+}
+
+// dragTo moves the dragged payload to the given screen position.
+func (a *App) dragTo(x, y int, modifiers tcell.ModMask) {
+	target, _ := a.probeMouse(MouseDragOver, x, y, modifiers).(DropTarget)
+	if target != a.dropTarget && a.dropTarget != nil {
+		a.dropTarget.DragLeave()
+	}
+	a.dropTarget = target
+	a.dropAccepted = target != nil && target.DragOver(a.drag, x, y)
+}
+
+// endDrag ends dragging. If "drop" is true, the payload is dropped onto the
+// target under the mouse, if it accepts it.
+func (a *App) endDrag(x, y int, drop bool) {
+	payload, target := a.drag, a.dropTarget
+	a.drag, a.dropTarget, a.dropAccepted = nil, nil, false
+
+	var dropped bool
+	if target != nil {
+		if drop {
+			dropped = target.DragOver(payload, x, y) && target.Drop(payload, x, y)
+		}
+		target.DragLeave()
+	}
+	payload.Source.DragEnd(payload, dropped)
+}
+
+// fireDragActions handles mouse events while a payload is dragged.
+func (a *App) fireDragActions(event *tcell.EventMouse) {
+	x, y := event.Position()
+	if event.Buttons()&tcell.ButtonPrimary == 0 {
+		a.endDrag(x, y, true)
+	} else if x != a.lastMouseX || y != a.lastMouseY {
+		a.dragTo(x, y, event.Modifiers())
+	}
+	a.lastMouseX, a.lastMouseY = x, y
+}
+
+// drawDrag draws the label of the dragged payload next to the mouse.
+func (a *App) drawDrag(screen tcell.Screen) {
+	if a.drag == nil || a.drag.Label == "" {
+		return
+	}
+	background := Styles.DragRejectColor
+	if a.dropAccepted {
+		background = Styles.DragAcceptColor
+	}
+	label := []byte(" " + Escape(a.drag.Label) + " ")
+	width, _ := screen.Size()
+	x := a.lastMouseX + 1
+	if labelWidth := TaggedTextWidth(label); x+labelWidth > width {
+		x = width - labelWidth
+	}
+	if x < 0 {
+		x = 0
+	}
+	style := tcell.StyleDefault.Foreground(Styles.DragLabelTextColor).Background(background)
+	PrintStyle(screen, label, x, a.lastMouseY, width-x, AlignLeft, style)
+}
+
+// dropFeedback colors the background of the given area of the screen to show
+// where a payload would be dropped.
+func dropFeedback(screen tcell.Screen, x, y, width int) {
+	for column := x; column < x+width; column++ {
+		m, c, style, _ := screen.GetContent(column, y)
+		screen.SetContent(column, y, m, c, style.Background(Styles.DragAcceptColor))
+	}
+}
@@ -0,0 +1,126 @@
+package cui
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// drag simulates dragging with the left mouse button from one screen position
+// to another, the way the event loop of the application does.
+func drag(app *App, fromX, fromY, toX, toY int) {
+	for _, event := range []*tcell.EventMouse{
+		tcell.NewEventMouse(fromX, fromY, tcell.ButtonPrimary, 0),
+		tcell.NewEventMouse(toX, toY, tcell.ButtonPrimary, 0),
+		tcell.NewEventMouse(toX, toY, tcell.ButtonNone, 0),
+	} {
+		app.root.Draw(app.screen)
+		_, isMouseDownAction := app.fireMouseActions(event)
+		app.lastMouseButtons = event.Buttons()
+		if isMouseDownAction {
+			app.mouseDownX, app.mouseDownY = event.Position()
+		}
+	}
+}
+
+func TestListDragDrop(t *testing.T) {
+	t.Parallel()
+
+	l := NewList()
+	for _, text := range []string{"a", "b", "c", "d"} {
+		l.AddItem(NewListItem(text))
+	}
+	l.SetDraggable(true)
+
+	app, err := newTestApp(l)
+	if err != nil {
+		t.Fatalf("failed to initialize App: %s", err)
+	}
+	l.SetRect(0, 0, 20, 10)
+
+	drag(app, 0, 0, 0, 6)
+
+	var order string
+	for _, item := range l.GetItems() {
+		order += string(item.GetMainBytes())
+	}
+	if order != "bcad" {
+		t.Errorf("failed to move item: expected bcad, got %s", order)
+	}
+	if app.drag != nil {
+		t.Error("failed to end drag: payload still dragged")
+	}
+}
+
+func TestTreeDragDrop(t *testing.T) {
+	t.Parallel()
+
+	root := NewTreeNode("root")
+	a, b := NewTreeNode("a"), NewTreeNode("b")
+	root.AddChild(a)
+	root.AddChild(b)
+	tr := NewTreeView().SetRoot(root).SetDraggable(true)
+
+	app, err := newTestApp(tr)
+	if err != nil {
+		t.Fatalf("failed to initialize App: %s", err)
+	}
+	tr.SetRect(0, 0, 20, 10)
+
+	drag(app, 2, 2, 2, 1)
+
+	if len(root.GetChildren()) != 1 || len(a.GetChildren()) != 1 || a.GetChildren()[0] != b {
+		t.Error("failed to move node into other node")
+	}
+}
+
+func TestTableDragDropOntoList(t *testing.T) {
+	t.Parallel()
+
+	table := NewTable().SetDraggable(true)
+	for row, text := range []string{"x", "y"} {
+		table.SetCellSimple(row, 0, text)
+	}
+	l := NewList()
+	l.SetDropFunc(func(payload *DragPayload, index int) bool {
+		return payload.Kind == DragKindTableRows
+	}, func(payload *DragPayload, index int) {
+		for _, row := range payload.Data.([]int) {
+			l.InsertItem(index, NewListItem(table.GetCell(row, 0).GetText()))
+		}
+	})
+
+	flex := NewFlex().AddItem(table, 0, 1, false).AddItem(l, 0, 1, false)
+	app, err := newTestApp(flex)
+	if err != nil {
+		t.Fatalf("failed to initialize App: %s", err)
+	}
+	flex.SetRect(0, 0, 40, 10)
+
+	drag(app, 0, 1, 20, 0)
+
+	if l.GetItemCount() != 1 || l.GetItem(0).GetMainText() != "y" {
+		t.Error("failed to drop table row onto list")
+	}
+	if table.GetRowCount() != 2 {
+		t.Errorf("failed to keep table rows: expected 2, got %d", table.GetRowCount())
+	}
+}
+
+func TestTabbedPanelsMoveTab(t *testing.T) {
+	t.Parallel()
+
+	tp := NewTabbedPanels()
+	for _, name := range []string{"a", "b", "c"} {
+		tp.AddTab(name, name, NewBox())
+	}
+	tp.MoveTab("a", 2)
+
+	var order string
+	for _, panel := range tp.panels.panels {
+		order += panel.Name
+	}
+	if order != "bca" {
+		t.Errorf("failed to move tab: expected bca, got %s", order)
+	}
+}
@@ -502,6 +502,26 @@ func (d *DropDown) GetCurrentOption() (int, *DropDownOption) {
 	return d.currentOption, option
 }
 
+// GetOptionCount returns the number of options in the drop-down.
+func (d *DropDown) GetOptionCount() int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return len(d.options)
+}
+
+// GetOption returns the option at the given index or nil if there is no such
+// option.
+func (d *DropDown) GetOption(index int) *DropDownOption {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if index < 0 || index >= len(d.options) {
+		return nil
+	}
+	return d.options[index]
+}
+
 // SetTextOptions sets the text to be placed before and after each drop-down
 // option (prefix/suffix), the text placed before and after the currently
 // selected option (currentPrefix/currentSuffix) as well as the text to be
@@ -2,28 +2,110 @@ package cui
 
 import (
 	"sync"
+	"time"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/malivvan/cui/editor"
 )
 
+const (
+	// The number of completions shown at once
+	editorMaxCompletions = 10
+
+	// How long the text must not change before its diagnostics are updated
+	editorDiagnosticsDelay = 300 * time.Millisecond
+)
+
 type Editor struct {
-	box  *Box
-	view *editor.View
-	mu   sync.RWMutex
+	box *Box
+
+	// The views of the buffer, arranged in splits, and the focused view.
+	layout *editorSplit
+	view   *editor.View
+
+	// The bar below the text which prompts for the input requested by the
+	// view, e.g. the pattern to find, and the message shown in its place.
+	prompt  *Input
+	message string
+
+	// The list of completions shown below the cursor and the function which
+	// replaces the word before the cursor with the chosen one.
+	completions     *List
+	completionItems []editor.Completion
+	completionDone  func(completion editor.Completion, ok bool)
+
+	// Closed to stop watching the file of the buffer.
+	stopWatching chan struct{}
+
+	// The provider of the diagnostics, which are updated in the event loop
+	// of app after the text changed, and the text they were requested for.
+	provider      editor.Provider
+	app           *App
+	diagnoseTimer *time.Timer
+	diagnosedText string
+
+	mu sync.RWMutex
 }
 
 func (e *Editor) InputHandler() func(event *tcell.EventKey, setFocus func(p Widget)) {
 	return func(event *tcell.EventKey, setFocus func(p Widget)) {
-		e.view.HandleEvent(event)
+		e.mu.Lock()
+		prompt, view, completions := e.prompt, e.view, e.completions
+		e.message = ""
+		e.mu.Unlock()
+
+		// Keys go to the prompt while it is shown.
+		if prompt != nil {
+			prompt.InputHandler()(event, setFocus)
+			return
+		}
+
+		// The list of completions is navigated with the arrow keys and Tab.
+		// Enter inserts the current completion, other keys cancel.
+		if completions != nil {
+			count := completions.GetItemCount()
+			switch event.Key() {
+			case tcell.KeyDown, tcell.KeyTab:
+				completions.SetCurrentItem((completions.GetCurrentItemIndex() + 1) % count)
+				return
+			case tcell.KeyUp, tcell.KeyBacktab:
+				completions.SetCurrentItem((completions.GetCurrentItemIndex() + count - 1) % count)
+				return
+			case tcell.KeyEnter:
+				e.hideCompletions(true)
+				return
+			case tcell.KeyEscape:
+				e.hideCompletions(false)
+				return
+			}
+			e.hideCompletions(false)
+		}
+		view.HandleEvent(event)
+		e.set(func(e *Editor) { e.diagnose() })
 	}
 }
 
 func (e *Editor) MouseHandler() func(action MouseAction, event *tcell.EventMouse, setFocus func(p Widget)) (consumed bool, capture Widget) {
 	return func(action MouseAction, event *tcell.EventMouse, setFocus func(p Widget)) (consumed bool, capture Widget) {
-		if e.view.InRect(event.Position()) {
+		if action == MouseLeftDown {
+			e.hideCompletions(false)
+		}
+
+		e.mu.Lock()
+		var view *editor.View
+		for _, v := range e.layout.views() {
+			if v.InRect(event.Position()) {
+				view = v
+				if action == MouseLeftDown {
+					e.view = v
+				}
+			}
+		}
+		e.mu.Unlock()
+
+		if view != nil {
 			setFocus(e)
-			e.view.HandleEvent(event)
+			view.HandleEvent(event)
 			return true, nil
 		}
 		return false, nil
@@ -35,15 +117,387 @@ func NewEditor() *Editor {
 		box:  NewBox(),
 		view: editor.NewView(),
 	}
+	e.layout = &editorSplit{view: e.view}
+	e.view.SetPromptFunc(e.showPrompt)
+	e.view.SetMessageFunc(func(msg string) {
+		e.set(func(e *Editor) { e.message = msg })
+	})
+	e.view.SetSplitFunc(e.doSplit)
+	e.view.SetCompleteFunc(e.showCompletions)
+	e.view.SetAsyncFunc(e.runAsync)
 	return e
 }
 
+// showPrompt shows the prompt bar for the input requested by the view. Enter
+// confirms the input, Escape cancels it.
+func (e *Editor) showPrompt(kind, message, input string, done func(input string, ok bool)) {
+	prompt := NewInputField()
+	prompt.SetLabel(message)
+	prompt.SetText(input)
+	prompt.Focus(nil)
+	prompt.SetDoneFunc(func(key tcell.Key) {
+		if key != tcell.KeyEnter && key != tcell.KeyEscape {
+			return
+		}
+		e.set(func(e *Editor) {
+			if e.prompt == prompt {
+				e.prompt = nil
+			}
+		})
+		done(prompt.GetText(), key == tcell.KeyEnter)
+	})
+	e.set(func(e *Editor) { e.prompt, e.message = prompt, "" })
+}
+
+// showCompletions shows the list of completions of the word before the
+// cursor of the focused view.
+func (e *Editor) showCompletions(completions []editor.Completion, done func(completion editor.Completion, ok bool)) {
+	l := NewList()
+	l.ShowSecondaryText(false)
+	l.SetMainTextColor(Styles.PrimitiveBackgroundColor)
+	l.SetSelectedTextColor(Styles.PrimitiveBackgroundColor)
+	l.SetSelectedBackgroundColor(Styles.PrimaryTextColor)
+	l.SetHighlightFullLine(true)
+	l.SetBackgroundColor(Styles.MoreContrastBackgroundColor)
+	for _, c := range completions {
+		text := c.Label
+		if c.Detail != "" {
+			text += "  " + c.Detail
+		}
+		l.AddItem(NewListItem(Escape(text)))
+	}
+	e.set(func(e *Editor) {
+		e.completions, e.completionItems, e.completionDone = l, completions, done
+	})
+}
+
+// hideCompletions hides the list of completions. If ok is true, the current
+// completion replaces the word before the cursor.
+func (e *Editor) hideCompletions(ok bool) {
+	e.mu.Lock()
+	completions, items, done := e.completions, e.completionItems, e.completionDone
+	e.completions, e.completionItems, e.completionDone = nil, nil, nil
+	e.mu.Unlock()
+
+	if completions == nil {
+		return
+	}
+	var completion editor.Completion
+	if ok {
+		completion = items[completions.GetCurrentItemIndex()]
+	}
+	done(completion, ok)
+	e.set(func(e *Editor) { e.diagnose() })
+}
+
+// SetProvider sets the provider of language-aware features, like completions
+// (see editor.Provider). If an application is given, the provider is called
+// in the background and its results are applied in the event loop of the
+// application, and the diagnostics of the buffer are updated shortly after
+// the text was changed. Otherwise the provider is called right away. If the
+// provider is an editor.DocumentCloser, it closes the document of the buffer
+// when the buffer or the provider is replaced.
+func (e *Editor) SetProvider(app *App, provider editor.Provider) *Editor {
+	return e.set(func(e *Editor) {
+		e.closeDocument(e.view.Buf)
+		for _, view := range e.layout.views() {
+			view.SetProvider(provider)
+		}
+		e.provider, e.app, e.diagnosedText = provider, app, ""
+		e.diagnose()
+	})
+}
+
+// runAsync calls the provider in the background for the views if there is an
+// application, see editor.AsyncFunc.
+func (e *Editor) runAsync(work func() func()) {
+	e.mu.Lock()
+	app := e.app
+	e.mu.Unlock()
+
+	if app == nil {
+		work()()
+		return
+	}
+	go func() {
+		app.QueueUpdateDraw(work())
+	}()
+}
+
+// closeDocument lets the provider release the document of a buffer which is
+// no longer shown, see editor.DocumentCloser.
+func (e *Editor) closeDocument(buf *editor.Buffer) {
+	if closer, ok := e.provider.(editor.DocumentCloser); ok && buf != nil {
+		closer.CloseDocument(buf.Path)
+	}
+}
+
+// diagnose updates the diagnostics of the buffer in the background if its
+// text changed.
+func (e *Editor) diagnose() {
+	buf := e.view.Buf
+	if e.provider == nil || e.app == nil || buf == nil {
+		return
+	}
+	text := buf.String()
+	if text == e.diagnosedText {
+		return
+	}
+	e.diagnosedText = text
+
+	if e.diagnoseTimer != nil {
+		e.diagnoseTimer.Stop()
+	}
+	provider, app, path := e.provider, e.app, buf.Path
+	e.diagnoseTimer = time.AfterFunc(editorDiagnosticsDelay, func() {
+		diagnostics, err := provider.Diagnostics(path, text)
+		if err != nil {
+			return
+		}
+		app.QueueUpdateDraw(func() {
+			// Diagnostics of an older text are discarded.
+			if buf.String() == text {
+				buf.SetDiagnostics(diagnostics)
+			}
+		})
+	})
+}
+
 func (e *Editor) SetTheme(theme string) *Editor {
-	return e.set(func(e *Editor) { e.view.SetTheme(theme) })
+	return e.set(func(e *Editor) {
+		for _, view := range e.layout.views() {
+			view.SetTheme(theme)
+		}
+	})
 }
 
+// SetBuffer shows the given buffer in the editor. Splits are closed.
 func (e *Editor) SetBuffer(buf *editor.Buffer) *Editor {
-	return e.set(func(e *Editor) { e.view.SetBuffer(buf) })
+	return e.set(func(e *Editor) {
+		for _, view := range e.layout.views() {
+			if view != e.view {
+				view.Close()
+			}
+		}
+		e.layout = &editorSplit{view: e.view}
+		if old := e.view.Buf; old != buf {
+			e.closeDocument(old)
+		}
+		e.view.SetBuffer(buf)
+		e.diagnose()
+	})
+}
+
+// HSplit shows the buffer in a new view below the focused view, or above it
+// if the "splitbottom" setting of the buffer is off. The views of the buffer
+// have their own cursors but share its text and undo history. The new view
+// is focused.
+func (e *Editor) HSplit() *Editor {
+	return e.set(func(e *Editor) { e.split(false) })
+}
+
+// VSplit shows the buffer in a new view to the right of the focused view, or
+// to its left if the "splitright" setting of the buffer is off. See HSplit.
+func (e *Editor) VSplit() *Editor {
+	return e.set(func(e *Editor) { e.split(true) })
+}
+
+// Unsplit closes the focused view, unless it is the only one.
+func (e *Editor) Unsplit() *Editor {
+	return e.set(func(e *Editor) { e.unsplit() })
+}
+
+// NextSplit focuses the next view, from the top left to the bottom right.
+func (e *Editor) NextSplit() *Editor {
+	return e.set(func(e *Editor) {
+		views := e.layout.views()
+		for i, view := range views {
+			if view == e.view {
+				e.view = views[(i+1)%len(views)]
+				break
+			}
+		}
+	})
+}
+
+// doSplit executes the split actions of the views.
+func (e *Editor) doSplit(action string) {
+	switch action {
+	case editor.ActionHSplit:
+		e.HSplit()
+	case editor.ActionVSplit:
+		e.VSplit()
+	case editor.ActionUnsplit:
+		e.Unsplit()
+	case editor.ActionNextSplit:
+		e.NextSplit()
+	}
+}
+
+// split splits the focused view.
+func (e *Editor) split(vertical bool) {
+	if e.view.Buf == nil {
+		return
+	}
+	after := e.view.Buf.Settings["splitbottom"].(bool)
+	if vertical {
+		after = e.view.Buf.Settings["splitright"].(bool)
+	}
+
+	leaf := e.layout.find(e.view)
+	if leaf.parent == nil || leaf.parent.vertical != vertical {
+		// The leaf becomes the parent of the old and the new view.
+		child := &editorSplit{view: leaf.view, parent: leaf}
+		leaf.view, leaf.vertical, leaf.children = nil, vertical, []*editorSplit{child}
+		leaf = child
+	}
+	parent := leaf.parent
+	i := parent.index(leaf)
+	if after {
+		i++
+	}
+	view := e.view.NewSplit()
+	parent.children = append(parent.children[:i], append([]*editorSplit{{view: view, parent: parent}}, parent.children[i:]...)...)
+	e.view = view
+}
+
+// unsplit closes the focused view.
+func (e *Editor) unsplit() {
+	leaf := e.layout.find(e.view)
+	parent := leaf.parent
+	if parent == nil {
+		return
+	}
+	i := parent.index(leaf)
+	parent.children = append(parent.children[:i], parent.children[i+1:]...)
+	e.view.Close()
+
+	next := parent.children[max(i-1, 0)]
+	if len(parent.children) == 1 {
+		// The parent becomes the remaining child.
+		parent.view, parent.vertical, parent.children = next.view, next.vertical, next.children
+		for _, child := range parent.children {
+			child.parent = parent
+		}
+		next = parent
+	}
+	e.view = next.views()[0]
+}
+
+// editorSplit is a node in the layout of the views of an Editor. Leaves show
+// a view. Other nodes divide their area evenly among their children, side by
+// side if vertical is true and on top of each other otherwise.
+type editorSplit struct {
+	view     *editor.View
+	vertical bool
+	parent   *editorSplit
+	children []*editorSplit
+}
+
+// views returns the views of the split from the top left to the bottom right.
+func (s *editorSplit) views() []*editor.View {
+	if s.view != nil {
+		return []*editor.View{s.view}
+	}
+	var views []*editor.View
+	for _, child := range s.children {
+		views = append(views, child.views()...)
+	}
+	return views
+}
+
+// find returns the leaf which shows the given view.
+func (s *editorSplit) find(view *editor.View) *editorSplit {
+	if s.view == view {
+		return s
+	}
+	for _, child := range s.children {
+		if leaf := child.find(view); leaf != nil {
+			return leaf
+		}
+	}
+	return nil
+}
+
+// index returns the index of the given child.
+func (s *editorSplit) index(child *editorSplit) int {
+	for i, c := range s.children {
+		if c == child {
+			return i
+		}
+	}
+	return -1
+}
+
+// setRect positions the views of the split in the given area. Views side by
+// side are separated by a line, which is drawn.
+func (s *editorSplit) setRect(screen tcell.Screen, x, y, width, height int) {
+	if s.view != nil {
+		s.view.SetRect(x, y, width, height)
+		return
+	}
+	n := len(s.children)
+	size := height
+	if s.vertical {
+		size = width - (n - 1)
+	}
+	for i, child := range s.children {
+		childSize := size / n
+		if i < size%n {
+			childSize++
+		}
+		if !s.vertical {
+			child.setRect(screen, x, y, width, childSize)
+			y += childSize
+			continue
+		}
+		child.setRect(screen, x, y, childSize, height)
+		x += childSize
+		if i < n-1 {
+			style := tcell.StyleDefault.Foreground(Styles.BorderColor).Background(Styles.PrimitiveBackgroundColor)
+			for row := y; row < y+height; row++ {
+				screen.SetContent(x, row, Borders.Vertical, nil, style)
+			}
+			x++
+		}
+	}
+}
+
+// WatchFile checks the file of the buffer every interval in the event loop of
+// the given application (see editor.View.CheckFile). When another program
+// changed the file, the prompt bar asks whether to reload it, merge the
+// changes or keep the buffer. If the "autosave" setting of the buffer is on,
+// modifications are saved. An interval of 0 stops watching.
+func (e *Editor) WatchFile(app *App, interval time.Duration) *Editor {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.stopWatching != nil {
+		close(e.stopWatching)
+		e.stopWatching = nil
+	}
+	if app == nil || interval <= 0 {
+		return e
+	}
+	stop := make(chan struct{})
+	e.stopWatching = stop
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				app.QueueUpdateDraw(func() {
+					var view *editor.View
+					e.get(func(e *Editor) { view = e.view })
+					view.CheckFile()
+				})
+			}
+		}
+	}()
+	return e
 }
 
 ///////////////////////////////////// <MUTEX> ///////////////////////////////////
@@ -284,7 +738,60 @@ func (e *Editor) Draw(screen tcell.Screen) {
 	x, y, width, height := e.box.GetInnerRect()
 
 	e.mu.Lock()
-	e.view.SetRect(x, y, width, height)
+	defer e.mu.Unlock()
+
+	// The prompt bar or the message take the last line.
+	if (e.prompt != nil || e.message != "") && height > 1 {
+		height--
+	}
+	// The focused view is drawn last, so that it shows the cursor.
+	e.layout.setRect(screen, x, y, width, height)
+	for _, view := range e.layout.views() {
+		if view != e.view {
+			view.Draw(screen)
+		}
+	}
 	e.view.Draw(screen)
-	e.mu.Unlock()
+	if e.completions != nil {
+		e.drawCompletions(screen, x, y, width, height)
+	}
+	if e.prompt != nil {
+		e.prompt.SetRect(x, y+height, width, 1)
+		e.prompt.Draw(screen)
+	} else if e.message != "" {
+		for column := x; column < x+width; column++ {
+			screen.SetContent(column, y+height, ' ', nil, tcell.StyleDefault.Background(Styles.PrimitiveBackgroundColor))
+		}
+		Print(screen, []byte(Escape(e.message)), x, y+height, width, AlignLeft, Styles.PrimaryTextColor)
+	}
+}
+
+// drawCompletions draws the list of completions below the cursor of the
+// focused view or, if there is no space, above it.
+func (e *Editor) drawCompletions(screen tcell.Screen, x, y, width, height int) {
+	cx, cy, ok := e.view.GetCursorPosition()
+	if !ok {
+		cx, cy = x, y
+	}
+
+	lheight := e.completions.GetItemCount()
+	lwidth := 0
+	for index := 0; index < lheight; index++ {
+		entry, _ := e.completions.GetItemText(index)
+		lwidth = max(lwidth, TaggedStringWidth(entry))
+	}
+	lheight = min(lheight, editorMaxCompletions)
+	if e.completions.GetItemCount() > lheight {
+		lwidth++ // Add space for scroll bar
+	}
+
+	lx, ly := cx, cy+1
+	if ly+lheight > y+height && cy-y > y+height-ly {
+		lheight = min(lheight, cy-y)
+		ly = cy - lheight
+	}
+	lheight = min(lheight, y+height-ly)
+	lx = max(x, min(lx, x+width-lwidth))
+	e.completions.SetRect(lx, ly, lwidth, lheight)
+	e.completions.Draw(screen)
 }
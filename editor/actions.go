@@ -1,6 +1,7 @@
 package editor
 
 import (
+	"regexp"
 	"strings"
 	"time"
 	"unicode/utf8"
@@ -547,49 +548,6 @@ func (v *View) InsertTab() bool {
 	return true
 }
 
-//// Find opens a prompt and searches forward for the input
-//func (v *View) Find() bool {
-//	if v.mainCursor() {
-//		searchStr := ""
-//		if v.Cursor.HasSelection() {
-//			searchStart = v.Cursor.CurSelection[1]
-//			searchStart = v.Cursor.CurSelection[1]
-//			searchStr = v.Cursor.GetSelection()
-//		} else {
-//			searchStart = v.Cursor.Loc
-//		}
-//		BeginSearch(searchStr)
-//
-//	}
-//	return true
-//}
-//
-//// FindNext searches forwards for the last used search term
-//func (v *View) FindNext() bool {
-//	if v.Cursor.HasSelection() {
-//		searchStart = v.Cursor.CurSelection[1]
-//		// lastSearch = v.Cursor.GetSelection()
-//	} else {
-//		searchStart = v.Cursor.Loc
-//	}
-//	if lastSearch == "" {
-//		return true
-//	}
-//	Search(lastSearch, v, true)
-//	return true
-//}
-//
-//// FindPrevious searches backwards for the last used search term
-//func (v *View) FindPrevious() bool {
-//	if v.Cursor.HasSelection() {
-//		searchStart = v.Cursor.CurSelection[0]
-//	} else {
-//		searchStart = v.Cursor.Loc
-//	}
-//	Search(lastSearch, v, false)
-//	return true
-//}
-
 // Undo undoes the last action
 func (v *View) Undo() bool {
 	if v.Buf.curCursor == 0 {
@@ -906,49 +864,6 @@ func (v *View) ToggleRuler() bool {
 	return false
 }
 
-//// JumpLine jumps to a line and moves the view accordingly.
-//func (v *View) JumpLine() bool {
-//
-//	// Prompt for line number
-//	message := fmt.Sprintf("Jump to line:col (1 - %v) # ", v.Buf.NumLines)
-//	input, canceled := messenger.Prompt(message, "", "LineNumber", NoCompletion)
-//	if canceled {
-//		return false
-//	}
-//	var lineInt int
-//	var colInt int
-//	var err error
-//	if strings.Contains(input, ":") {
-//		split := strings.Split(input, ":")
-//		lineInt, err = strconv.Atoi(split[0])
-//		if err != nil {
-//			messenger.Message("Invalid line number")
-//			return false
-//		}
-//		colInt, err = strconv.Atoi(split[1])
-//		if err != nil {
-//			messenger.Message("Invalid column number")
-//			return false
-//		}
-//	} else {
-//		lineInt, err = strconv.Atoi(input)
-//		if err != nil {
-//			messenger.Message("Invalid line number")
-//			return false
-//		}
-//	}
-//	lineInt--
-//	// Move cursor and view if possible.
-//	if lineInt < v.Buf.NumLines && lineInt >= 0 {
-//		v.Cursor.X = colInt
-//		v.Cursor.Y = lineInt
-//
-//		return true
-//	}
-//	messenger.Error("Only ", v.Buf.NumLines, " lines to jump")
-//	return false
-//}
-
 // ToggleOverwriteMode lets the user toggle the text overwrite mode
 func (v *View) ToggleOverwriteMode() bool {
 	if v.mainCursor() {
@@ -960,11 +875,11 @@ func (v *View) ToggleOverwriteMode() bool {
 // Escape leaves current mode
 func (v *View) Escape() bool {
 	if v.mainCursor() {
-		//		// check if user is searching, or the last search is still active
-		//		if searching || lastSearch != "" {
-		//			ExitSearch(v)
-		//			return true
-		//		}
+		// check if the last search is still highlighted
+		if v.search != nil {
+			v.ClearSearch()
+			return true
+		}
 	}
 	return false
 }
@@ -981,11 +896,13 @@ func (v *View) SpawnMultiCursor() bool {
 				buf: v.Buf,
 			}
 
-			//sel := spawner.GetSelection()
+			sel := spawner.GetSelection()
 
-			//searchStart = spawner.CurSelection[1]
 			v.Cursor = c
-			//Search(regexp.QuoteMeta(sel), v, true)
+			if !v.selectMatch(regexp.MustCompile(regexp.QuoteMeta(sel)), spawner.CurSelection[1], true) {
+				v.Cursor = spawner
+				return false
+			}
 
 			for _, cur := range v.Buf.cursors {
 				if c.Loc == cur.Loc {
@@ -1003,7 +920,7 @@ func (v *View) SpawnMultiCursor() bool {
 
 // SpawnMultiCursorSelect adds a cursor at the beginning of each line of a selection
 func (v *View) SpawnMultiCursorSelect() bool {
-	if v.Cursor == &v.Buf.Cursor {
+	if v.Cursor == v.Buf.cursors[0] {
 		// Avoid cases where multiple cursors already exist, that would create problems
 		if len(v.Buf.cursors) > 1 {
 			return false
@@ -1043,11 +960,10 @@ func (v *View) SpawnMultiCursorSelect() bool {
 func (v *View) SkipMultiCursor() bool {
 	cursor := v.Buf.cursors[len(v.Buf.cursors)-1]
 	if v.mainCursor() {
-		//sel := cursor.GetSelection()
+		sel := cursor.GetSelection()
 
-		//searchStart = cursor.CurSelection[1]
 		v.Cursor = cursor
-		//Search(regexp.QuoteMeta(sel), v, true)
+		v.selectMatch(regexp.MustCompile(regexp.QuoteMeta(sel)), cursor.CurSelection[1], true)
 		v.Relocate()
 		v.Cursor = cursor
 
@@ -76,6 +76,21 @@ const (
 	ActionSkipMultiCursor        = "SkipMultiCursor"
 	ActionJumpToMatchingBrace    = "JumpToMatchingBrace"
 	ActionInsertEnter            = "InsertEnter"
+	ActionFind                   = "Find"
+	ActionFindNext               = "FindNext"
+	ActionFindPrevious           = "FindPrevious"
+	ActionReplace                = "Replace"
+	ActionReplaceAll             = "ReplaceAll"
+	ActionJumpLine               = "JumpLine"
+	ActionSave                   = "Save"
+	ActionSaveAs                 = "SaveAs"
+	ActionHSplit                 = "HSplit"
+	ActionVSplit                 = "VSplit"
+	ActionUnsplit                = "Unsplit"
+	ActionNextSplit              = "NextSplit"
+	ActionAutocomplete           = "Autocomplete"
+	ActionHover                  = "Hover"
+	ActionGotoDefinition         = "GotoDefinition"
 	ActionUnbindKey              = "UnbindKey"
 )
 
@@ -202,6 +217,21 @@ var bindingActions = map[string]func(*View) bool{
 	ActionSkipMultiCursor:        (*View).SkipMultiCursor,
 	ActionJumpToMatchingBrace:    (*View).JumpToMatchingBrace,
 	ActionInsertEnter:            (*View).InsertNewline,
+	ActionFind:                   (*View).Find,
+	ActionFindNext:               (*View).FindNext,
+	ActionFindPrevious:           (*View).FindPrevious,
+	ActionReplace:                (*View).Replace,
+	ActionReplaceAll:             (*View).ReplaceAllAction,
+	ActionJumpLine:               (*View).JumpLine,
+	ActionSave:                   (*View).Save,
+	ActionSaveAs:                 (*View).SaveAs,
+	ActionHSplit:                 (*View).HSplit,
+	ActionVSplit:                 (*View).VSplit,
+	ActionUnsplit:                (*View).Unsplit,
+	ActionNextSplit:              (*View).NextSplit,
+	ActionAutocomplete:           (*View).Autocomplete,
+	ActionHover:                  (*View).Hover,
+	ActionGotoDefinition:         (*View).GotoDefinition,
 }
 
 var bindingKeys = map[string]tcell.Key{
@@ -400,6 +430,17 @@ func init() {
 		"Alt-p":          ActionRemoveMultiCursor,
 		"Alt-c":          ActionRemoveAllMultiCursors,
 		"Alt-x":          ActionSkipMultiCursor,
+		"CtrlF":          ActionFind,
+		"CtrlN":          ActionFindNext,
+		"CtrlP":          ActionFindPrevious,
+		"CtrlE":          ActionReplace,
+		"Alt-E":          ActionReplaceAll,
+		"CtrlL":          ActionJumpLine,
+		"CtrlS":          ActionSave,
+		"CtrlW":          ActionNextSplit,
+		"CtrlSpace":      ActionAutocomplete,
+		"Alt-i":          ActionHover,
+		"F12":            ActionGotoDefinition,
 	})
 }
 
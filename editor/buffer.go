@@ -10,12 +10,10 @@ import (
 
 const LargeFileThreshold = 50000
 
-var (
-	// 0 - no line type detected
-	// 1 - lf detected
-	// 2 - crlf detected
-	fileformat = 0
-)
+// GlobalSettings holds settings which override the defaults of
+// DefaultLocalSettings in all new buffers. The values must have the types of
+// the defaults.
+var GlobalSettings = map[string]interface{}{}
 
 // Buffer stores the text for files that are loaded into the text editor
 // It uses a rope to efficiently store the string and contains some
@@ -33,6 +31,11 @@ type Buffer struct {
 	cursors   []*Cursor // for multiple cursors
 	curCursor int       // the current cursor
 
+	// The cursors of all views of the buffer; the cursors of the active
+	// view are in cursors and curCursor
+	cursorSets []*cursorSet
+	activeSet  *cursorSet
+
 	// Name of the buffer on the status line
 	name string
 
@@ -48,6 +51,24 @@ type Buffer struct {
 	// Hash of the original buffer -- empty if fastdirty is on
 	origHash [md5.Size]byte
 
+	// The file as it was last read or written, used to notice changes
+	// by other programs, and its text, used to merge them and to mark the
+	// changed lines in the gutter
+	disk     diskFile
+	diskText string
+
+	// The number of changes of the text, and the markers of the changed
+	// lines computed after the last one
+	changes int
+	diff    struct {
+		markers []int
+		changes int
+		base    string
+	}
+
+	// The problems in the text which are marked in the gutter
+	diagnostics []Diagnostic
+
 	// Buffer local settings
 	Settings map[string]interface{}
 }
@@ -62,18 +83,16 @@ func NewBuffer(reader io.Reader, size int64, path string) *Buffer {
 	b := new(Buffer)
 	b.LineArray = NewLineArray(size, reader)
 
-	filepath.Base(path)
-
 	b.Settings = DefaultLocalSettings()
-	//	for k, v := range globalSettings {
-	//		if _, ok := b.Settings[k]; ok {
-	//			b.Settings[k] = v
-	//		}
-	//	}
+	for k, v := range GlobalSettings {
+		if _, ok := b.Settings[k]; ok {
+			b.Settings[k] = v
+		}
+	}
 
-	if fileformat == 1 {
+	if b.LineArray.fileformat == 1 {
 		b.Settings["fileformat"] = "unix"
-	} else if fileformat == 2 {
+	} else if b.LineArray.fileformat == 2 {
 		b.Settings["fileformat"] = "dos"
 	}
 
@@ -82,6 +101,7 @@ func NewBuffer(reader io.Reader, size int64, path string) *Buffer {
 	b.EventHandler = NewEventHandler(b)
 
 	b.update()
+	b.diskText = b.String()
 
 	b.Cursor = Cursor{
 		Loc: Loc{0, 0},
@@ -100,6 +120,8 @@ func NewBuffer(reader io.Reader, size int64, path string) *Buffer {
 	}
 
 	b.cursors = []*Cursor{&b.Cursor}
+	b.activeSet = &cursorSet{cursors: b.cursors}
+	b.cursorSets = []*cursorSet{b.activeSet}
 
 	return b
 }
@@ -107,7 +129,16 @@ func NewBuffer(reader io.Reader, size int64, path string) *Buffer {
 // GetName returns the Name that should be displayed in the statusline
 // for this buffer
 func (b *Buffer) GetName() string {
-	return b.name
+	if b.name != "" {
+		return b.name
+	}
+	if b.Path == "" {
+		return "No name"
+	}
+	if b.Settings["basename"].(bool) {
+		return filepath.Base(b.Path)
+	}
+	return b.Path
 }
 
 // updateRules updates the syntax rules and filetype for this buffer
@@ -251,17 +282,44 @@ func (b *Buffer) Modified() bool {
 
 func (b *Buffer) insert(pos Loc, value []byte) {
 	b.IsModified = true
+	b.changes++
 	b.LineArray.insert(pos, value)
 	b.update()
+
+	end := pos.Move(Count(string(value)), b)
+	b.moveInactiveCursors(func(loc Loc) Loc {
+		if loc.LessThan(pos) {
+			return loc
+		}
+		if loc.Y == pos.Y {
+			return Loc{end.X + loc.X - pos.X, end.Y}
+		}
+		return Loc{loc.X, loc.Y + end.Y - pos.Y}
+	})
 }
 func (b *Buffer) remove(start, end Loc) string {
 	b.IsModified = true
+	b.changes++
 	sub := b.LineArray.remove(start, end)
 	b.update()
+
+	b.moveInactiveCursors(func(loc Loc) Loc {
+		if loc.LessEqual(start) {
+			return loc
+		}
+		if loc.LessEqual(end) {
+			return start
+		}
+		if loc.Y == end.Y {
+			return Loc{start.X + loc.X - end.X, start.Y}
+		}
+		return Loc{loc.X, loc.Y - (end.Y - start.Y)}
+	})
 	return sub
 }
 func (b *Buffer) deleteToEnd(start Loc) {
 	b.IsModified = true
+	b.changes++
 	b.LineArray.DeleteToEnd(start)
 	b.update()
 }
@@ -396,7 +454,83 @@ func (b *Buffer) clearCursors() {
 	}
 	b.cursors = b.cursors[:1]
 	b.UpdateCursors()
-	b.Cursor.ResetSelection()
+	b.cursors[0].ResetSelection()
+}
+
+// A cursorSet holds the cursors of a view of a buffer. The first set holds
+// the cursor of the buffer and belongs to the view which opened it, the
+// others belong to splits made with View.NewSplit.
+type cursorSet struct {
+	cursors   []*Cursor
+	curCursor int
+}
+
+// newCursorSet adds a set with a single cursor at the given cursor.
+func (b *Buffer) newCursorSet(at *Cursor) *cursorSet {
+	c := &Cursor{buf: b}
+	c.Goto(*at)
+	set := &cursorSet{cursors: []*Cursor{c}}
+	b.cursorSets = append(b.cursorSets, set)
+	return set
+}
+
+// removeCursorSet removes a set added with newCursorSet.
+func (b *Buffer) removeCursorSet(set *cursorSet) {
+	if set == b.cursorSets[0] {
+		return
+	}
+	if set == b.activeSet {
+		b.useCursorSet(b.cursorSets[0])
+	}
+	for i, s := range b.cursorSets {
+		if s == set {
+			b.cursorSets = append(b.cursorSets[:i], b.cursorSets[i+1:]...)
+			break
+		}
+	}
+}
+
+// useCursorSet makes the given set the cursors of the buffer, which are
+// moved and used by actions, until another set is used.
+func (b *Buffer) useCursorSet(set *cursorSet) {
+	if set == b.activeSet {
+		return
+	}
+	b.activeSet.cursors, b.activeSet.curCursor = b.cursors, b.curCursor
+	b.cursors, b.curCursor = set.cursors, set.curCursor
+	b.activeSet = set
+}
+
+// moveInactiveCursors moves the cursors of the views other than the active
+// one after the text was changed, for example by an action or an undo in the
+// active view.
+func (b *Buffer) moveInactiveCursors(move func(loc Loc) Loc) {
+	for _, set := range b.cursorSets {
+		if set == b.activeSet {
+			continue
+		}
+		for _, c := range set.cursors {
+			c.Loc = move(c.Loc)
+			c.CurSelection[0] = move(c.CurSelection[0])
+			c.CurSelection[1] = move(c.CurSelection[1])
+			c.OrigSelection[0] = move(c.OrigSelection[0])
+			c.OrigSelection[1] = move(c.OrigSelection[1])
+		}
+	}
+}
+
+// allCursors returns the cursors of all views of the buffer.
+func (b *Buffer) allCursors() []*Cursor {
+	if len(b.cursorSets) == 1 {
+		return b.cursors
+	}
+	cursors := append([]*Cursor(nil), b.cursors...)
+	for _, set := range b.cursorSets {
+		if set != b.activeSet {
+			cursors = append(cursors, set.cursors...)
+		}
+	}
+	return cursors
 }
 
 var bracePairs = [][2]rune{
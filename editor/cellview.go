@@ -1,6 +1,8 @@
 package editor
 
 import (
+	"regexp"
+
 	"github.com/gdamore/tcell/v2"
 	"github.com/mattn/go-runewidth"
 )
@@ -64,11 +66,17 @@ type CellView struct {
 	lines [][]*Char
 }
 
-func (c *CellView) Draw(buf *Buffer, theme Theme, top, height, left, width int) {
+func (c *CellView) Draw(buf *Buffer, theme Theme, search *regexp.Regexp, top, height, left, width int) {
 	if width <= 0 {
 		return
 	}
 
+	// Matches of the search are highlighted
+	searchStyle := defStyle.Reverse(true)
+	if style, ok := theme["hlsearch"]; ok {
+		searchStyle = style
+	}
+
 	matchingBrace := Loc{-1, -1}
 	// bracePairs is defined in buffer.go
 	if buf.Settings["matchbrace"].(bool) {
@@ -124,6 +132,11 @@ func (c *CellView) Draw(buf *Buffer, theme Theme, top, height, left, width int)
 		lineStr := buf.Line(lineN)
 		line := []rune(lineStr)
 
+		var matches [][2]int
+		if search != nil {
+			matches = lineMatches(search, lineStr)
+		}
+
 		colN, startOffset, startStyle := visualToCharPos(left, lineN, lineStr, buf, theme, tabsize)
 		if colN < 0 {
 			colN = len(line)
@@ -156,8 +169,15 @@ func (c *CellView) Draw(buf *Buffer, theme Theme, top, height, left, width int)
 
 			char := line[colN]
 
+			charStyle := curStyle
+			for _, match := range matches {
+				if colN >= match[0] && colN < match[1] {
+					charStyle = searchStyle
+				}
+			}
+
 			if viewCol >= 0 {
-				st := curStyle
+				st := charStyle
 				if colN == matchingBrace.X && lineN == matchingBrace.Y && !buf.Cursor.HasSelection() {
 					st = curStyle.Reverse(true)
 				}
@@ -171,9 +191,9 @@ func (c *CellView) Draw(buf *Buffer, theme Theme, top, height, left, width int)
 					c.lines[viewLine][viewCol].drawChar = indentchar
 					c.lines[viewLine][viewCol].width = charWidth
 
-					indentStyle := curStyle
+					indentStyle := charStyle
 					ch := buf.Settings["indentchar"].(string)
-					if group, ok := theme["indent-char"]; ok && !IsStrWhitespace(ch) && ch != "" {
+					if group, ok := theme["indent-char"]; ok && !IsStrWhitespace(ch) && ch != "" && charStyle == curStyle {
 						indentStyle = group
 					}
 
@@ -183,7 +203,7 @@ func (c *CellView) Draw(buf *Buffer, theme Theme, top, height, left, width int)
 				for i := 1; i < charWidth; i++ {
 					viewCol++
 					if viewCol >= 0 && viewCol < lineLength && viewCol < len(c.lines[viewLine]) {
-						c.lines[viewLine][viewCol] = &Char{Loc{viewCol, viewLine}, Loc{colN, lineN}, char, ' ', curStyle, 1}
+						c.lines[viewLine][viewCol] = &Char{Loc{viewCol, viewLine}, Loc{colN, lineN}, char, ' ', charStyle, 1}
 					}
 				}
 				viewCol++
@@ -195,7 +215,7 @@ func (c *CellView) Draw(buf *Buffer, theme Theme, top, height, left, width int)
 				for i := 1; i < charWidth; i++ {
 					viewCol++
 					if viewCol >= 0 && viewCol < lineLength && viewCol < len(c.lines[viewLine]) {
-						c.lines[viewLine][viewCol] = &Char{Loc{viewCol, viewLine}, Loc{colN, lineN}, char, ' ', curStyle, 1}
+						c.lines[viewLine][viewCol] = &Char{Loc{viewCol, viewLine}, Loc{colN, lineN}, char, ' ', charStyle, 1}
 					}
 				}
 				viewCol++
@@ -0,0 +1,419 @@
+package editor
+
+import (
+	"bytes"
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	dmp "github.com/sergi/go-diff/diffmatchpatch"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// diskFile identifies the contents of a file on disk.
+type diskFile struct {
+	modTime time.Time
+	size    int64
+	hash    [md5.Size]byte
+}
+
+// newDiskFile returns the identity of a file with the given info and data.
+func newDiskFile(info os.FileInfo, data []byte) diskFile {
+	return diskFile{modTime: info.ModTime(), size: info.Size(), hash: md5.Sum(data)}
+}
+
+// lookupEncoding returns the encoding with the given name, e.g. "utf-8",
+// "utf-16le" or "windows-1252". "utf-8-bom", "utf-16le" and "utf-16be" are
+// written with a byte order mark.
+func lookupEncoding(name string) (encoding.Encoding, error) {
+	switch strings.ToLower(name) {
+	case "", "utf-8", "utf8":
+		return unicode.UTF8, nil
+	case "utf-8-bom":
+		return unicode.UTF8BOM, nil
+	case "utf-16le":
+		return unicode.UTF16(unicode.LittleEndian, unicode.UseBOM), nil
+	case "utf-16be":
+		return unicode.UTF16(unicode.BigEndian, unicode.UseBOM), nil
+	}
+	enc, err := htmlindex.Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown encoding %q", name)
+	}
+	return enc, nil
+}
+
+// detectEncoding returns the name of the encoding of the given data. A byte
+// order mark selects UTF-8 or UTF-16, valid UTF-8 is UTF-8 and anything else
+// is read with the "encoding" of GlobalSettings, or as windows-1252 if that is
+// UTF-8.
+func detectEncoding(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xef, 0xbb, 0xbf}):
+		return "utf-8-bom"
+	case bytes.HasPrefix(data, []byte{0xff, 0xfe}):
+		return "utf-16le"
+	case bytes.HasPrefix(data, []byte{0xfe, 0xff}):
+		return "utf-16be"
+	case utf8.Valid(data):
+		return "utf-8"
+	}
+	if name, ok := GlobalSettings["encoding"].(string); ok {
+		if enc, err := lookupEncoding(name); err == nil && enc != unicode.UTF8 {
+			return name
+		}
+	}
+	return "windows-1252"
+}
+
+// decode converts data in the encoding with the given name to UTF-8.
+func decode(name string, data []byte) ([]byte, error) {
+	enc, err := lookupEncoding(name)
+	if err != nil {
+		return nil, err
+	}
+	return enc.NewDecoder().Bytes(data)
+}
+
+// encode converts UTF-8 text to the encoding with the given name.
+func encode(name string, text string) ([]byte, error) {
+	enc, err := lookupEncoding(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := enc.NewEncoder().Bytes([]byte(text))
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode text as %s: %w", name, err)
+	}
+	return data, nil
+}
+
+// writeFileAtomic writes data to a temporary file in the directory of the
+// given file and renames it to the file, so that the file is either replaced
+// completely or not at all. The permissions of an existing file are kept.
+func writeFileAtomic(path string, data []byte) error {
+	perm := os.FileMode(0644)
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, perm)
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
+
+// readFile reads the file of the buffer in the encoding of the buffer. It
+// returns the lines and the identity of the file.
+func (b *Buffer) readFile() (*LineArray, diskFile, error) {
+	data, err := os.ReadFile(b.Path)
+	if err != nil {
+		return nil, diskFile{}, err
+	}
+	info, err := os.Stat(b.Path)
+	if err != nil {
+		return nil, diskFile{}, err
+	}
+	text, err := decode(b.Settings["encoding"].(string), data)
+	if err != nil {
+		return nil, diskFile{}, err
+	}
+	return NewLineArray(int64(len(text)), bytes.NewReader(text)), newDiskFile(info, data), nil
+}
+
+// NewBufferFromFile creates a new buffer with the contents of the file at the
+// given path. The encoding of the file is detected and stored in the
+// "encoding" setting, its line endings are stored in the "fileformat"
+// setting. If the file does not exist, the buffer is empty and the file is
+// created when the buffer is saved. The cursor location and the undo history
+// stored by SaveAs are restored according to the "savecursor" and "saveundo"
+// settings, unless the file was changed since.
+func NewBufferFromFile(path string) (*Buffer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	name := detectEncoding(data)
+	text, err := decode(name, data)
+	if err != nil {
+		return nil, err
+	}
+
+	b := NewBuffer(bytes.NewReader(text), int64(len(text)), path)
+	b.Settings["encoding"] = name
+	if info, err := os.Stat(path); err == nil {
+		b.disk = newDiskFile(info, data)
+	}
+	if b.Settings["savecursor"].(bool) || b.Settings["saveundo"].(bool) {
+		b.loadState()
+	}
+	return b, nil
+}
+
+// Save saves the buffer to its file. See SaveAs.
+func (b *Buffer) Save() error {
+	return b.SaveAs(b.Path)
+}
+
+// SaveAs saves the buffer to the file at the given path, which becomes the
+// file of the buffer. Before saving, trailing whitespace is removed if the
+// "rmtrailingws" setting is on and a newline is added at the end if the
+// "eofnewline" setting is on; both can be undone. The text is written with the
+// line endings of the "fileformat" setting ("unix" or "dos") in the encoding
+// of the "encoding" setting. The file is replaced atomically. If the
+// "savecursor" or "saveundo" setting is on, the cursor location and selection
+// or the undo history are stored as well.
+func (b *Buffer) SaveAs(path string) error {
+	if path == "" {
+		return errors.New("no file name")
+	}
+	if _, err := lookupEncoding(b.Settings["encoding"].(string)); err != nil {
+		return err
+	}
+
+	if b.Settings["rmtrailingws"].(bool) {
+		for y := 0; y < b.NumLines; y++ {
+			line := b.Line(y)
+			if trimmed := strings.TrimRight(line, " \t"); len(trimmed) < len(line) {
+				b.Remove(Loc{Count(trimmed), y}, Loc{Count(line), y})
+			}
+		}
+	}
+	if b.Settings["eofnewline"].(bool) {
+		if end := b.End(); end.X > 0 {
+			b.Insert(end, "\n")
+		}
+	}
+
+	data, err := encode(b.Settings["encoding"].(string), b.SaveString(b.Settings["fileformat"] == "dos"))
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path, data); err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	if path != b.Path {
+		b.Path = path
+		b.updateRules()
+	}
+	b.IsModified = false
+	calcHash(b, &b.origHash)
+	b.disk = newDiskFile(info, data)
+	b.diskText = b.String()
+	if b.Settings["savecursor"].(bool) || b.Settings["saveundo"].(bool) {
+		return b.saveState()
+	}
+	return nil
+}
+
+// ChangedOnDisk returns whether the file of the buffer was changed by another
+// program since the buffer was read, saved or reloaded.
+func (b *Buffer) ChangedOnDisk() bool {
+	if b.Path == "" {
+		return false
+	}
+	info, err := os.Stat(b.Path)
+	if err != nil || info.ModTime().Equal(b.disk.modTime) && info.Size() == b.disk.size {
+		return false
+	}
+	data, err := os.ReadFile(b.Path)
+	if err != nil {
+		return false
+	}
+	disk := newDiskFile(info, data)
+	if disk.hash == b.disk.hash {
+		b.disk = disk // Only touched.
+		return false
+	}
+	return true
+}
+
+// ignoreDiskChange makes ChangedOnDisk return false until the file is changed
+// again. The text to merge changes into stays the same.
+func (b *Buffer) ignoreDiskChange() {
+	if info, err := os.Stat(b.Path); err == nil {
+		if data, err := os.ReadFile(b.Path); err == nil {
+			b.disk = newDiskFile(info, data)
+		}
+	}
+}
+
+// Reload replaces the text of the buffer with the contents of its file, read
+// in the encoding of the "encoding" setting. The replacement can be undone.
+func (b *Buffer) Reload() error {
+	la, disk, err := b.readFile()
+	if err != nil {
+		return err
+	}
+	text := la.String()
+	b.setFileText(text, la, disk)
+	b.ApplyDiff(text)
+	b.IsModified = false
+	b.relocateCursors()
+	return nil
+}
+
+// Merge applies the changes made to the file of the buffer by another program
+// since it was read, saved or reloaded to the text of the buffer, keeping the
+// changes made in the buffer. The merge can be undone. It returns the number
+// of changes from the file which conflicted with changes in the buffer and
+// were not applied.
+func (b *Buffer) Merge() (int, error) {
+	la, disk, err := b.readFile()
+	if err != nil {
+		return 0, err
+	}
+	text := la.String()
+
+	differ := dmp.New()
+	patches := differ.PatchMake(b.diskText, text)
+	merged, applied := differ.PatchApply(patches, b.String())
+	var conflicts int
+	for _, ok := range applied {
+		if !ok {
+			conflicts++
+		}
+	}
+
+	b.setFileText(text, la, disk)
+	b.ApplyDiff(merged)
+	b.IsModified = merged != text
+	b.relocateCursors()
+	return conflicts, nil
+}
+
+// setFileText remembers the text and identity of the file of the buffer.
+func (b *Buffer) setFileText(text string, la *LineArray, disk diskFile) {
+	if la.fileformat == 1 {
+		b.Settings["fileformat"] = "unix"
+	} else if la.fileformat == 2 {
+		b.Settings["fileformat"] = "dos"
+	}
+	b.origHash = md5.Sum([]byte(text))
+	b.disk, b.diskText = disk, text
+}
+
+// relocateCursors moves the cursors of all views back into the buffer.
+func (b *Buffer) relocateCursors() {
+	for _, c := range b.allCursors() {
+		c.Relocate()
+		if !InBounds(c.CurSelection[0], b) || !InBounds(c.CurSelection[1], b) {
+			c.ResetSelection()
+		}
+	}
+}
+
+// Save saves the buffer, asking for a file name if it has none
+func (v *View) Save() bool {
+	if !v.mainCursor() {
+		return false
+	}
+	if v.Buf.Path == "" {
+		return v.SaveAs()
+	}
+	v.save(v.Buf.Path)
+	return false
+}
+
+// SaveAs asks for a file name and saves the buffer to it
+func (v *View) SaveAs() bool {
+	if v.mainCursor() && v.prompt != nil {
+		v.ask(PromptSaveAs, "Save as: ", v.Buf.Path, func(input string, ok bool) {
+			if ok && input != "" {
+				v.save(input)
+			}
+		})
+	}
+	return false
+}
+
+// save saves the buffer to the given file and reports the result.
+func (v *View) save(path string) {
+	if err := v.Buf.SaveAs(path); err != nil {
+		v.showMessage("Could not save: %s", err)
+		return
+	}
+	v.showMessage("Saved %s", v.Buf.GetName())
+}
+
+// CheckFile is meant to be called periodically by the host of the view. If
+// the file of the buffer was changed by another program, the user is asked
+// whether to reload the file, merge the changes or keep the buffer as it is.
+// Without a prompt function, unmodified buffers are reloaded. Otherwise, if
+// the "autosave" setting is on, a modified buffer is saved.
+func (v *View) CheckFile() {
+	b := v.Buf
+	if b == nil || b.Path == "" {
+		return
+	}
+	v.activate()
+
+	if b.ChangedOnDisk() {
+		b.ignoreDiskChange()
+		if v.prompt == nil {
+			if !b.Modified() {
+				b.Reload()
+			}
+			return
+		}
+		v.ask(PromptReload, "File changed on disk. Reload, merge or keep (r/m/k)? ", "", func(input string, ok bool) {
+			if !ok {
+				return
+			}
+			switch strings.ToLower(strings.TrimSpace(input)) {
+			case "r", "reload":
+				if err := b.Reload(); err != nil {
+					v.showMessage("Could not reload: %s", err)
+				}
+			case "m", "merge":
+				conflicts, err := b.Merge()
+				if err != nil {
+					v.showMessage("Could not merge: %s", err)
+				} else if conflicts > 0 {
+					v.showMessage("%d changes on disk conflicted and were not merged", conflicts)
+				}
+			}
+			v.Relocate()
+		})
+		return
+	}
+
+	if b.Settings["autosave"].(bool) && b.Modified() {
+		if err := b.Save(); err != nil {
+			v.showMessage("Could not save: %s", err)
+		}
+	}
+}
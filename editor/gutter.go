@@ -0,0 +1,181 @@
+package editor
+
+import (
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	dmp "github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// Severities of diagnostics, with the values of the language server protocol.
+const (
+	SeverityError = iota + 1
+	SeverityWarning
+	SeverityInformation
+	SeverityHint
+)
+
+// A Diagnostic is a problem in the text of a buffer, like a compile error,
+// which is underlined and marked in the gutter of its views.
+type Diagnostic struct {
+	Start    Loc
+	End      Loc
+	Severity int
+	Message  string
+}
+
+// SetDiagnostics replaces the diagnostics of the buffer. Their text is
+// underlined and, while the buffer has diagnostics, the lines of their starts
+// are marked in the gutter.
+func (b *Buffer) SetDiagnostics(diagnostics []Diagnostic) {
+	b.diagnostics = diagnostics
+}
+
+// GetDiagnostics returns the diagnostics of the buffer.
+func (b *Buffer) GetDiagnostics() []Diagnostic {
+	return b.diagnostics
+}
+
+// diagnosticAt returns the most severe diagnostic at the given location. An
+// empty diagnostic is at the character at its start.
+func (b *Buffer) diagnosticAt(loc Loc) (Diagnostic, bool) {
+	var diagnostic Diagnostic
+	found := false
+	for _, d := range b.diagnostics {
+		if (loc == d.Start || loc.GreaterEqual(d.Start) && loc.LessThan(d.End)) && (!found || d.Severity < diagnostic.Severity) {
+			diagnostic, found = d, true
+		}
+	}
+	return diagnostic, found
+}
+
+// diagnosticStyle returns the given style of a character with a diagnostic,
+// which is underlined in the color of its severity.
+func (v *View) diagnosticStyle(style tcell.Style, d Diagnostic) tcell.Style {
+	color, group := tcell.ColorYellow, "gutter-warning"
+	if d.Severity == SeverityError {
+		color, group = tcell.ColorRed, "gutter-error"
+	}
+	if s, ok := v.theme[group]; ok {
+		if fg, _, _ := s.Decompose(); fg != tcell.ColorDefault {
+			color = fg
+		}
+	}
+	return style.Underline(tcell.UnderlineStyleCurly, color)
+}
+
+// lineDiagnostics returns the most severe diagnostic starting on each line.
+func (b *Buffer) lineDiagnostics() map[int]Diagnostic {
+	lines := make(map[int]Diagnostic)
+	for _, d := range b.diagnostics {
+		if other, ok := lines[d.Start.Y]; !ok || d.Severity < other.Severity {
+			lines[d.Start.Y] = d
+		}
+	}
+	return lines
+}
+
+// Kinds of changes of lines, as marked in the gutter.
+const (
+	diffNone = iota
+	diffAdded
+	diffModified
+	diffDeletedAbove
+)
+
+// diffMarkers returns the kinds of changes of the lines of the buffer since
+// it was read or saved, or since it was created if it has no file. They are
+// computed again after the text was changed.
+func (b *Buffer) diffMarkers() []int {
+	if b.diff.markers != nil && b.diff.changes == b.changes && b.diff.base == b.diskText {
+		return b.diff.markers
+	}
+
+	d := dmp.New()
+	base, text, lines := d.DiffLinesToChars(b.diskText+"\n", b.String()+"\n")
+	markers := make([]int, b.NumLines)
+	y, deleted := 0, 0
+	for _, diff := range d.DiffCharsToLines(d.DiffMain(base, text, false), lines) {
+		n := strings.Count(diff.Text, "\n")
+		switch diff.Type {
+		case dmp.DiffEqual:
+			if deleted > 0 && y < len(markers) {
+				markers[y] = diffDeletedAbove
+			}
+			y, deleted = y+n, 0
+		case dmp.DiffDelete:
+			deleted = n
+		case dmp.DiffInsert:
+			for i := 0; i < n && y < len(markers); i, y = i+1, y+1 {
+				if i < deleted {
+					markers[y] = diffModified
+				} else {
+					markers[y] = diffAdded
+				}
+			}
+			deleted = max(deleted-n, 0)
+		}
+	}
+	if deleted > 0 && len(markers) > 0 {
+		markers[len(markers)-1] = diffDeletedAbove
+	}
+
+	b.diff.markers, b.diff.changes, b.diff.base = markers, b.changes, b.diskText
+	return markers
+}
+
+// gutterWidth returns the width of the markers in the gutter, before the
+// line numbers.
+func (v *View) gutterWidth() int {
+	width := 0
+	if len(v.Buf.diagnostics) > 0 {
+		width += 2
+	}
+	if v.Buf.Settings["diffgutter"].(bool) {
+		width++
+	}
+	return width
+}
+
+// displayGutter draws the markers of the given line in the gutter and
+// returns the screen column after them. Lines continued by soft wrapping
+// have no markers.
+func (v *View) displayGutter(screen tcell.Screen, x, y, lineN int, softwrapped bool, diagnostics map[int]Diagnostic, diffs []int) int {
+	if len(v.Buf.diagnostics) > 0 {
+		ch, style := ' ', defStyle
+		if d, ok := diagnostics[lineN]; ok && !softwrapped {
+			ch = '>'
+			group := "gutter-warning"
+			if d.Severity == SeverityError {
+				group = "gutter-error"
+			}
+			if s, ok := v.theme[group]; ok {
+				style = s
+			}
+		}
+		screen.SetContent(x, y, ch, nil, style)
+		screen.SetContent(x+1, y, ch, nil, style)
+		x += 2
+	}
+	if v.Buf.Settings["diffgutter"].(bool) {
+		ch, group := ' ', ""
+		if lineN < len(diffs) && !softwrapped {
+			switch diffs[lineN] {
+			case diffAdded:
+				ch, group = '▌', "diff-added"
+			case diffModified:
+				ch, group = '▌', "diff-modified"
+			case diffDeletedAbove:
+				ch, group = '▔', "diff-deleted"
+			}
+		}
+		style := defStyle
+		if s, ok := v.theme[group]; ok {
+			fg, _, _ := s.Decompose()
+			style = style.Foreground(fg)
+		}
+		screen.SetContent(x, y, ch, nil, style)
+		x++
+	}
+	return x
+}
@@ -41,6 +41,12 @@ type Line struct {
 // and delete in it
 type LineArray struct {
 	lines []Line
+
+	// The line endings found when the lines were read:
+	// 0 - no line type detected
+	// 1 - lf detected
+	// 2 - crlf detected
+	fileformat int
 }
 
 // Append efficiently appends lines together
@@ -75,12 +81,12 @@ func NewLineArray(size int64, reader io.Reader) *LineArray {
 		data, err := br.ReadBytes('\n')
 		if len(data) > 1 && data[len(data)-2] == '\r' {
 			data = append(data[:len(data)-2], '\n')
-			if fileformat == 0 {
-				fileformat = 2
+			if la.fileformat == 0 {
+				la.fileformat = 2
 			}
 		} else if len(data) > 0 {
-			if fileformat == 0 {
-				fileformat = 1
+			if la.fileformat == 0 {
+				la.fileformat = 1
 			}
 		}
 
@@ -0,0 +1,652 @@
+package editor
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf16"
+)
+
+// maxLSPMessageSize is the maximum size of a message of a language server in
+// bytes.
+const maxLSPMessageSize = 64 << 20
+
+// LSPProvider is a Provider which asks a language server. The server is
+// started as a child process and speaks the Language Server Protocol over its
+// standard input and output. The documents are sent to the server in full
+// when they change.
+type LSPProvider struct {
+	// The identifier of the language of the documents, like "go". If it is
+	// empty, the extension of the file is used.
+	LanguageID string
+
+	// How long to wait for the responses and the diagnostics of the server
+	Timeout time.Duration
+
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+
+	// Serializes the messages sent to the server
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	nextID  int
+	pending map[int]chan *lspMessage
+	docs    map[string]*lspDocument
+
+	// Closed when the connection to the server is lost, with the reason in err
+	done chan struct{}
+	err  error
+}
+
+// lspDocument is a document opened in the server.
+type lspDocument struct {
+	version int
+	text    string
+
+	// The last published diagnostics, the version they are about and a
+	// channel which is closed when new ones are published
+	diagnostics []lspDiagnostic
+	diagnosed   int
+	published   chan struct{}
+}
+
+// lspMessage is a request, a response or a notification of JSON-RPC.
+type lspMessage struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  json.RawMessage  `json:"result,omitempty"`
+	Error   *lspError        `json:"error,omitempty"`
+}
+
+type lspError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *lspError) Error() string {
+	return fmt.Sprintf("language server: %s (%d)", e.Message, e.Code)
+}
+
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+type lspLocation struct {
+	URI   string   `json:"uri"`
+	Range lspRange `json:"range"`
+
+	// The fields of a LocationLink
+	TargetURI            string    `json:"targetUri"`
+	TargetSelectionRange *lspRange `json:"targetSelectionRange"`
+}
+
+type lspDiagnostic struct {
+	Range    lspRange `json:"range"`
+	Severity int      `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+type lspTextDocumentPosition struct {
+	TextDocument struct {
+		URI string `json:"uri"`
+	} `json:"textDocument"`
+	Position lspPosition `json:"position"`
+}
+
+// NewLSPProvider starts the language server with the given command and
+// arguments in the given root directory of the workspace and initializes
+// it. Close stops the server.
+func NewLSPProvider(root, command string, args ...string) (*LSPProvider, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(command, args...)
+	cmd.Dir = root
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	p := &LSPProvider{
+		Timeout: 5 * time.Second,
+		cmd:     cmd,
+		stdin:   stdin,
+		pending: make(map[int]chan *lspMessage),
+		docs:    make(map[string]*lspDocument),
+		done:    make(chan struct{}),
+	}
+	go p.read(bufio.NewReader(stdout))
+
+	params := map[string]interface{}{
+		"processId": os.Getpid(),
+		"rootUri":   pathURI(root),
+		"capabilities": map[string]interface{}{
+			"textDocument": map[string]interface{}{
+				"synchronization":    map[string]interface{}{},
+				"completion":         map[string]interface{}{},
+				"hover":              map[string]interface{}{"contentFormat": []string{"plaintext"}},
+				"definition":         map[string]interface{}{"linkSupport": true},
+				"publishDiagnostics": map[string]interface{}{"versionSupport": true},
+			},
+		},
+	}
+	if err := p.call("initialize", params, nil); err != nil {
+		p.Close()
+		return nil, err
+	}
+	if err := p.notify("initialized", map[string]interface{}{}); err != nil {
+		p.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+// Close shuts the language server down.
+func (p *LSPProvider) Close() error {
+	select {
+	case <-p.done:
+	default:
+		if p.call("shutdown", nil, nil) == nil {
+			p.notify("exit", nil)
+		}
+	}
+	p.stdin.Close()
+
+	// The output is read to its end before waiting for the process.
+	select {
+	case <-p.done:
+	case <-time.After(p.Timeout):
+		p.cmd.Process.Kill()
+		<-p.done
+	}
+	return p.cmd.Wait()
+}
+
+// write sends a message to the server.
+func (p *LSPProvider) write(msg *lspMessage) error {
+	msg.JSONRPC = "2.0"
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+	_, err = fmt.Fprintf(p.stdin, "Content-Length: %d\r\n\r\n%s", len(data), data)
+	return err
+}
+
+// marshalParams encodes the parameters of a request or a notification.
+// Without parameters, they are omitted.
+func marshalParams(params interface{}) (json.RawMessage, error) {
+	if params == nil {
+		return nil, nil
+	}
+	return json.Marshal(params)
+}
+
+// notify sends a notification to the server.
+func (p *LSPProvider) notify(method string, params interface{}) error {
+	data, err := marshalParams(params)
+	if err != nil {
+		return err
+	}
+	return p.write(&lspMessage{Method: method, Params: data})
+}
+
+// call sends a request to the server and decodes the result of its response
+// into result, unless it is nil.
+func (p *LSPProvider) call(method string, params, result interface{}) error {
+	data, err := marshalParams(params)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	p.nextID++
+	id := p.nextID
+	response := make(chan *lspMessage, 1)
+	p.pending[id] = response
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		delete(p.pending, id)
+		p.mu.Unlock()
+	}()
+
+	rawID := json.RawMessage(strconv.Itoa(id))
+	if err := p.write(&lspMessage{ID: &rawID, Method: method, Params: data}); err != nil {
+		return err
+	}
+	select {
+	case msg := <-response:
+		if msg.Error != nil {
+			return msg.Error
+		}
+		if result == nil || len(msg.Result) == 0 {
+			return nil
+		}
+		return json.Unmarshal(msg.Result, result)
+	case <-p.done:
+		return p.err
+	case <-time.After(p.Timeout):
+		return fmt.Errorf("language server: no response to %s", method)
+	}
+}
+
+// read reads the messages of the server until the connection is lost.
+func (p *LSPProvider) read(r *bufio.Reader) {
+	var err error
+	for {
+		var data []byte
+		if data, err = readLSPMessage(r); err != nil {
+			break
+		}
+		msg := new(lspMessage)
+		if json.Unmarshal(data, msg) != nil {
+			// Only errors of the connection or of the framing of the
+			// messages end it, invalid messages are dropped.
+			continue
+		}
+		switch {
+		case msg.Method != "" && msg.ID != nil:
+			// Requests of the server, e.g. for its configuration, are
+			// answered with an empty result.
+			go p.write(&lspMessage{ID: msg.ID, Result: json.RawMessage("null")})
+		case msg.Method == "textDocument/publishDiagnostics":
+			p.published(msg.Params)
+		case msg.Method == "" && msg.ID != nil:
+			id, _ := strconv.Atoi(string(*msg.ID))
+			p.mu.Lock()
+			if response, ok := p.pending[id]; ok {
+				select {
+				case response <- msg:
+				default: // A duplicate response.
+				}
+			}
+			p.mu.Unlock()
+		default:
+			// Other messages, like error responses without an ID to
+			// requests the server could not parse, are dropped.
+		}
+	}
+	if err == io.EOF {
+		err = errors.New("language server: connection closed")
+	}
+	p.mu.Lock()
+	p.err = err
+	p.mu.Unlock()
+	close(p.done)
+}
+
+// readLSPMessage reads the content of a message with its header.
+func readLSPMessage(r *bufio.Reader) ([]byte, error) {
+	length := -1
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		if name, value, ok := strings.Cut(line, ":"); ok && strings.EqualFold(name, "Content-Length") {
+			if length, err = strconv.Atoi(strings.TrimSpace(value)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if length < 0 {
+		return nil, errors.New("language server: missing Content-Length")
+	}
+	if length > maxLSPMessageSize {
+		return nil, fmt.Errorf("language server: message of %d bytes is too large", length)
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// published stores the diagnostics published by the server.
+func (p *LSPProvider) published(data json.RawMessage) {
+	var params struct {
+		URI         string          `json:"uri"`
+		Version     *int            `json:"version"`
+		Diagnostics []lspDiagnostic `json:"diagnostics"`
+	}
+	if json.Unmarshal(data, &params) != nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	doc, ok := p.docs[params.URI]
+	if !ok {
+		return
+	}
+	doc.diagnostics, doc.diagnosed = params.Diagnostics, doc.version
+	if params.Version != nil {
+		doc.diagnosed = *params.Version
+	}
+	close(doc.published)
+	doc.published = make(chan struct{})
+}
+
+// sync opens the document with the given path in the server or sends its
+// changed text. It returns the URI and the version of the document.
+func (p *LSPProvider) sync(path, text string) (string, int, error) {
+	uri := pathURI(path)
+	p.mu.Lock()
+	doc, ok := p.docs[uri]
+	if ok && doc.text == text {
+		p.mu.Unlock()
+		return uri, doc.version, nil
+	}
+	if !ok {
+		doc = &lspDocument{diagnosed: -1, published: make(chan struct{})}
+		p.docs[uri] = doc
+	}
+	doc.version++
+	doc.text = text
+	version := doc.version
+	p.mu.Unlock()
+
+	if version == 1 {
+		languageID := p.LanguageID
+		if languageID == "" {
+			languageID = strings.TrimPrefix(filepath.Ext(path), ".")
+		}
+		return uri, version, p.notify("textDocument/didOpen", map[string]interface{}{
+			"textDocument": map[string]interface{}{
+				"uri":        uri,
+				"languageId": languageID,
+				"version":    version,
+				"text":       text,
+			},
+		})
+	}
+	return uri, version, p.notify("textDocument/didChange", map[string]interface{}{
+		"textDocument":   map[string]interface{}{"uri": uri, "version": version},
+		"contentChanges": []map[string]interface{}{{"text": text}},
+	})
+}
+
+// CloseDocument implements DocumentCloser. It closes the document with the
+// given path in the server, if it is open.
+func (p *LSPProvider) CloseDocument(path string) error {
+	uri := pathURI(path)
+	p.mu.Lock()
+	_, ok := p.docs[uri]
+	delete(p.docs, uri)
+	p.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return p.notify("textDocument/didClose", map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": uri},
+	})
+}
+
+// position syncs the document and returns the parameters of a request about
+// the given location in it.
+func (p *LSPProvider) position(path, text string, loc Loc) (*lspTextDocumentPosition, error) {
+	uri, _, err := p.sync(path, text)
+	if err != nil {
+		return nil, err
+	}
+	params := new(lspTextDocumentPosition)
+	params.TextDocument.URI = uri
+	params.Position = toLSPPosition(strings.Split(text, "\n"), loc)
+	return params, nil
+}
+
+// Completions implements Provider.
+func (p *LSPProvider) Completions(path, text string, loc Loc) ([]Completion, error) {
+	params, err := p.position(path, text, loc)
+	if err != nil {
+		return nil, err
+	}
+	var result json.RawMessage
+	if err := p.call("textDocument/completion", params, &result); err != nil {
+		return nil, err
+	}
+
+	type item struct {
+		Label      string `json:"label"`
+		Detail     string `json:"detail"`
+		InsertText string `json:"insertText"`
+		TextEdit   *struct {
+			NewText string `json:"newText"`
+		} `json:"textEdit"`
+	}
+	var items []item
+	if json.Unmarshal(result, &items) != nil {
+		var list struct {
+			Items []item `json:"items"`
+		}
+		json.Unmarshal(result, &list)
+		items = list.Items
+	}
+
+	completions := make([]Completion, len(items))
+	for i, item := range items {
+		completions[i] = Completion{Label: item.Label, Detail: item.Detail, Text: item.InsertText}
+		if item.TextEdit != nil {
+			completions[i].Text = item.TextEdit.NewText
+		}
+	}
+	return completions, nil
+}
+
+// Hover implements Provider.
+func (p *LSPProvider) Hover(path, text string, loc Loc) (string, error) {
+	params, err := p.position(path, text, loc)
+	if err != nil {
+		return "", err
+	}
+	var result struct {
+		Contents json.RawMessage `json:"contents"`
+	}
+	if err := p.call("textDocument/hover", params, &result); err != nil {
+		return "", err
+	}
+
+	// The contents are a string, a marked string with a language, markup
+	// content with a kind, or a list of strings or marked strings.
+	var contents []json.RawMessage
+	if json.Unmarshal(result.Contents, &contents) != nil {
+		contents = []json.RawMessage{result.Contents}
+	}
+	var texts []string
+	for _, content := range contents {
+		var s string
+		if json.Unmarshal(content, &s) != nil {
+			var marked struct {
+				Value string `json:"value"`
+			}
+			json.Unmarshal(content, &marked)
+			s = marked.Value
+		}
+		if s != "" {
+			texts = append(texts, s)
+		}
+	}
+	return strings.Join(texts, "\n"), nil
+}
+
+// Diagnostics implements Provider. It waits for the server to publish the
+// diagnostics of the text, or returns the last published ones after the
+// timeout.
+func (p *LSPProvider) Diagnostics(path, text string) ([]Diagnostic, error) {
+	uri, version, err := p.sync(path, text)
+	if err != nil {
+		return nil, err
+	}
+	timeout := time.After(p.Timeout)
+	for {
+		p.mu.Lock()
+		doc, ok := p.docs[uri]
+		if !ok {
+			// The document was closed meanwhile.
+			p.mu.Unlock()
+			return nil, nil
+		}
+		diagnostics, diagnosed, published := doc.diagnostics, doc.diagnosed, doc.published
+		p.mu.Unlock()
+		if diagnosed >= version {
+			return fromLSPDiagnostics(strings.Split(text, "\n"), diagnostics), nil
+		}
+		select {
+		case <-published:
+		case <-p.done:
+			return nil, p.err
+		case <-timeout:
+			return fromLSPDiagnostics(strings.Split(text, "\n"), diagnostics), nil
+		}
+	}
+}
+
+// Definition implements Provider.
+func (p *LSPProvider) Definition(path, text string, loc Loc) ([]Location, error) {
+	params, err := p.position(path, text, loc)
+	if err != nil {
+		return nil, err
+	}
+	var result json.RawMessage
+	if err := p.call("textDocument/definition", params, &result); err != nil {
+		return nil, err
+	}
+	var locations []lspLocation
+	if json.Unmarshal(result, &locations) != nil {
+		var location lspLocation
+		if json.Unmarshal(result, &location) == nil && (location.URI != "" || location.TargetURI != "") {
+			locations = []lspLocation{location}
+		}
+	}
+
+	var definitions []Location
+	for _, l := range locations {
+		uri, pos := l.URI, l.Range.Start
+		if l.TargetURI != "" && l.TargetSelectionRange != nil {
+			uri, pos = l.TargetURI, l.TargetSelectionRange.Start
+		}
+		defPath, lines := uriPath(uri), strings.Split(text, "\n")
+		if defPath != "" && !samePath(defPath, path) {
+			data, _ := os.ReadFile(defPath)
+			lines = strings.Split(string(data), "\n")
+		}
+		definitions = append(definitions, Location{Path: defPath, Loc: fromLSPPosition(lines, pos)})
+	}
+	return definitions, nil
+}
+
+// pathURI returns the URI of the file with the given path. Buffers without a
+// file get an untitled URI.
+func pathURI(path string) string {
+	if path == "" {
+		return "untitled:buffer"
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path // A Windows path with a drive letter.
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
+
+// uriPath returns the path of the file with the given URI, or an empty
+// string if it is not a file.
+func uriPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return ""
+	}
+	path := u.Path
+	if len(path) > 2 && path[0] == '/' && path[2] == ':' {
+		path = path[1:] // A Windows path with a drive letter.
+	}
+	return filepath.FromSlash(path)
+}
+
+// toLSPPosition converts a location in the given lines to a position of the
+// protocol, whose characters are UTF-16 code units.
+func toLSPPosition(lines []string, loc Loc) lspPosition {
+	pos := lspPosition{Line: loc.Y}
+	if loc.Y < 0 || loc.Y >= len(lines) {
+		return pos
+	}
+	for i, r := range []rune(lines[loc.Y]) {
+		if i >= loc.X {
+			break
+		}
+		pos.Character += len(utf16.Encode([]rune{r}))
+	}
+	return pos
+}
+
+// fromLSPPosition converts a position of the protocol to a location in the
+// given lines.
+func fromLSPPosition(lines []string, pos lspPosition) Loc {
+	loc := Loc{0, pos.Line}
+	if pos.Line < 0 || pos.Line >= len(lines) {
+		return loc
+	}
+	character := 0
+	for _, r := range lines[pos.Line] {
+		if character >= pos.Character {
+			break
+		}
+		character += len(utf16.Encode([]rune{r}))
+		loc.X++
+	}
+	return loc
+}
+
+// fromLSPDiagnostics converts diagnostics of the protocol to diagnostics in
+// the given lines.
+func fromLSPDiagnostics(lines []string, diagnostics []lspDiagnostic) []Diagnostic {
+	result := make([]Diagnostic, len(diagnostics))
+	for i, d := range diagnostics {
+		severity := d.Severity
+		if severity == 0 {
+			severity = SeverityError
+		}
+		result[i] = Diagnostic{
+			Start:    fromLSPPosition(lines, d.Range.Start),
+			End:      fromLSPPosition(lines, d.Range.End),
+			Severity: severity,
+			Message:  d.Message,
+		}
+	}
+	return result
+}
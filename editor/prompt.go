@@ -0,0 +1,47 @@
+package editor
+
+import "fmt"
+
+// Kinds of input a view asks its host for with the prompt function.
+const (
+	PromptFind     = "Find"
+	PromptReplace  = "Replace"
+	PromptJumpLine = "JumpLine"
+	PromptReload   = "Reload"
+	PromptSaveAs   = "SaveAs"
+)
+
+// PromptFunc asks the user for input on behalf of a view. The host shows the
+// message and lets the user edit the input, then calls done with the input and
+// whether it was confirmed (true) or canceled (false).
+type PromptFunc func(kind, message, input string, done func(input string, ok bool))
+
+// SetPromptFunc sets the function which asks the user for input in the Find,
+// Replace, ReplaceAll and JumpLine actions and in CheckFile. Without it, these
+// actions do nothing.
+func (v *View) SetPromptFunc(prompt PromptFunc) {
+	v.prompt = prompt
+}
+
+// SetMessageFunc sets the function which shows messages to the user, for
+// example when a search has no matches.
+func (v *View) SetMessageFunc(message func(msg string)) {
+	v.message = message
+}
+
+// showMessage shows a message with the message function, if there is one.
+func (v *View) showMessage(format string, args ...interface{}) {
+	if v.message != nil {
+		v.message(fmt.Sprintf(format, args...))
+	}
+}
+
+// ask asks the user for input with the prompt function. The cursors of the
+// view are active again when done is called, even if other views of the
+// buffer were used in the meantime.
+func (v *View) ask(kind, message, input string, done func(input string, ok bool)) {
+	v.prompt(kind, message, input, func(input string, ok bool) {
+		v.activate()
+		done(input, ok)
+	})
+}
@@ -0,0 +1,271 @@
+package editor
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// A Provider provides language-aware features for the views of a buffer,
+// like the completions of the word at the cursor. Its methods are called
+// with the path and the text of the buffer and, if they are about a
+// location, with the location of the cursor. They may block, for example
+// while asking a language server (see LSPProvider).
+type Provider interface {
+	// Completions returns the completions of the word before the location.
+	Completions(path, text string, loc Loc) ([]Completion, error)
+
+	// Hover returns a description of the symbol at the location, or an
+	// empty string if there is none.
+	Hover(path, text string, loc Loc) (string, error)
+
+	// Diagnostics returns the problems in the text.
+	Diagnostics(path, text string) ([]Diagnostic, error)
+
+	// Definition returns the locations where the symbol at the location is
+	// defined.
+	Definition(path, text string, loc Loc) ([]Location, error)
+}
+
+// A DocumentCloser is a Provider which keeps the texts it is called with, like
+// LSPProvider. The host of the views calls CloseDocument with the path of a
+// buffer when the buffer is no longer shown, so that the provider can release
+// its text.
+type DocumentCloser interface {
+	CloseDocument(path string) error
+}
+
+// A Completion is a text which can replace the word before the cursor.
+type Completion struct {
+	// The text shown in the list of completions, and inserted if Text is
+	// empty
+	Label string
+	// More information, like the type, shown next to the label
+	Detail string
+	// The text which replaces the word before the cursor
+	Text string
+}
+
+// GetText returns the text inserted by the completion.
+func (c Completion) GetText() string {
+	if c.Text != "" {
+		return c.Text
+	}
+	return c.Label
+}
+
+// A Location is a location in a file.
+type Location struct {
+	Path string
+	Loc  Loc
+}
+
+// CompleteFunc lets the user choose one of the completions of the word before
+// the cursor on behalf of a view. The host shows the completions, then calls
+// done with the chosen completion and whether one was chosen (true) or the
+// completion was canceled (false).
+type CompleteFunc func(completions []Completion, done func(completion Completion, ok bool))
+
+// AsyncFunc runs work, which calls the provider, in the background on behalf
+// of a view, so that a slow provider does not block the host. Then it runs
+// the function returned by work, if any, where the view may be changed again,
+// e.g. in the event loop of the host.
+type AsyncFunc func(work func() func())
+
+// SetProvider sets the provider of the Autocomplete, Hover and
+// GotoDefinition actions and of UpdateDiagnostics.
+func (v *View) SetProvider(provider Provider) {
+	v.provider = provider
+}
+
+// GetProvider returns the provider set with SetProvider.
+func (v *View) GetProvider() Provider {
+	return v.provider
+}
+
+// SetCompleteFunc sets the function which lets the user choose one of the
+// completions in the Autocomplete action. Without it, the longest common
+// prefix of the completions is inserted.
+func (v *View) SetCompleteFunc(complete CompleteFunc) {
+	v.complete = complete
+}
+
+// SetAsyncFunc sets the function which calls the provider in the background
+// in the Autocomplete, Hover and GotoDefinition actions. Without it, the
+// provider is called right away.
+func (v *View) SetAsyncFunc(async AsyncFunc) {
+	v.async = async
+}
+
+// request calls the provider with the path and the text of the buffer and the
+// location of the cursor, using the async function if set. The result is
+// applied by the function returned by call, unless the text or the cursor
+// changed meanwhile.
+func (v *View) request(call func(path, text string, loc Loc) func()) {
+	path, text, loc := v.Buf.Path, v.Buf.String(), v.Cursor.Loc
+	buf, cursor := v.Buf, v.Cursor
+	work := func() func() {
+		apply := call(path, text, loc)
+		return func() {
+			v.activate()
+			if v.Buf == buf && v.Cursor == cursor && cursor.Loc == loc && buf.String() == text {
+				apply()
+			}
+		}
+	}
+	if v.async == nil {
+		work()()
+		return
+	}
+	v.async(work)
+}
+
+// wordStart returns the location of the start of the word before the cursor.
+func (v *View) wordStart() Loc {
+	line := []rune(v.Buf.Line(v.Cursor.Y))
+	x := Min(v.Cursor.X, len(line))
+	for x > 0 && IsWordChar(string(line[x-1])) {
+		x--
+	}
+	return Loc{x, v.Cursor.Y}
+}
+
+// Complete asks the provider for the completions of the word before the
+// cursor. A single completion replaces the word, otherwise the user chooses
+// one with the complete function. It returns false if there is no provider.
+func (v *View) Complete() bool {
+	v.activate()
+	if v.provider == nil {
+		return false
+	}
+	provider := v.provider
+	v.request(func(path, text string, loc Loc) func() {
+		completions, err := provider.Completions(path, text, loc)
+		return func() { v.completed(completions, err) }
+	})
+	return true
+}
+
+// completed lets the user choose one of the completions of the word before
+// the cursor.
+func (v *View) completed(completions []Completion, err error) {
+	if err != nil {
+		v.showMessage("Completion failed: %s", err)
+		return
+	}
+	if len(completions) == 0 {
+		v.showMessage("No completions")
+		return
+	}
+
+	start := v.wordStart()
+	replace := func(text string) {
+		v.Cursor.ResetSelection()
+		v.Buf.Replace(start, v.Cursor.Loc, text)
+		v.Relocate()
+	}
+	if len(completions) == 1 {
+		replace(completions[0].GetText())
+		return
+	}
+	if v.complete == nil {
+		texts := make([]string, len(completions))
+		for i, c := range completions {
+			texts[i] = c.GetText()
+		}
+		if prefix := CommonSubstring(texts...); len(prefix) > len(v.Buf.Substr(start, v.Cursor.Loc)) {
+			replace(prefix)
+		}
+		return
+	}
+	v.complete(completions, func(completion Completion, ok bool) {
+		v.activate()
+		if ok {
+			replace(completion.GetText())
+		}
+	})
+}
+
+// UpdateDiagnostics replaces the diagnostics of the buffer with the
+// diagnostics of the provider.
+func (v *View) UpdateDiagnostics() error {
+	if v.provider == nil {
+		return nil
+	}
+	diagnostics, err := v.provider.Diagnostics(v.Buf.Path, v.Buf.String())
+	if err != nil {
+		return err
+	}
+	v.Buf.SetDiagnostics(diagnostics)
+	return nil
+}
+
+// Autocomplete shows the completions of the word before the cursor
+func (v *View) Autocomplete() bool {
+	if v.mainCursor() {
+		v.Complete()
+	}
+	return false
+}
+
+// Hover shows the description of the symbol at the cursor, or the diagnostic
+// there
+func (v *View) Hover() bool {
+	if !v.mainCursor() {
+		return false
+	}
+	if d, ok := v.Buf.diagnosticAt(v.Cursor.Loc); ok {
+		v.showMessage("%s", d.Message)
+		return false
+	}
+	if v.provider == nil {
+		return false
+	}
+	provider := v.provider
+	v.request(func(path, text string, loc Loc) func() {
+		hover, err := provider.Hover(path, text, loc)
+		return func() {
+			if err != nil {
+				v.showMessage("Hover failed: %s", err)
+			} else if hover = strings.Join(strings.Fields(hover), " "); hover != "" {
+				v.showMessage("%s", hover)
+			}
+		}
+	})
+	return false
+}
+
+// GotoDefinition moves the cursor to the definition of the symbol at the
+// cursor, or shows where it is if it is in another file
+func (v *View) GotoDefinition() bool {
+	if !v.mainCursor() || v.provider == nil {
+		return false
+	}
+	provider := v.provider
+	v.request(func(path, text string, loc Loc) func() {
+		locations, err := provider.Definition(path, text, loc)
+		return func() {
+			switch {
+			case err != nil:
+				v.showMessage("Definition failed: %s", err)
+			case len(locations) == 0:
+				v.showMessage("No definition found")
+			case locations[0].Path != "" && !samePath(locations[0].Path, v.Buf.Path):
+				l := locations[0]
+				v.showMessage("Definition at %s:%d:%d", l.Path, l.Loc.Y+1, l.Loc.X+1)
+			default:
+				v.GotoLine(locations[0].Loc.Y+1, locations[0].Loc.X+1)
+			}
+		}
+	})
+	return false
+}
+
+// samePath returns whether the given paths are the same file.
+func samePath(a, b string) bool {
+	if b == "" {
+		return false
+	}
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
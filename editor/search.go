@@ -0,0 +1,389 @@
+package editor
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// search holds the pattern searched in a view and its compiled form.
+type search struct {
+	pattern    string
+	regex      bool
+	ignoreCase bool
+	re         *regexp.Regexp
+}
+
+// SetSearch sets the pattern searched by FindNext, FindPrevious and the
+// replace functions and highlights its matches. The pattern is a regular
+// expression if regex is true and matched literally otherwise. The search is
+// case-insensitive if the "ignorecase" setting of the buffer is true. Matches
+// do not span lines. An empty pattern clears the search.
+func (v *View) SetSearch(pattern string, regex bool) error {
+	if pattern == "" {
+		v.ClearSearch()
+		return nil
+	}
+	s := &search{pattern: pattern, regex: regex}
+	if err := s.compile(false); err != nil {
+		return err
+	}
+	v.search = s
+	return nil
+}
+
+// GetSearch returns the pattern set with SetSearch and whether it is a
+// regular expression.
+func (v *View) GetSearch() (pattern string, regex bool) {
+	if v.search == nil {
+		return "", false
+	}
+	return v.search.pattern, v.search.regex
+}
+
+// ClearSearch clears the search and the highlighting of its matches.
+func (v *View) ClearSearch() {
+	v.search = nil
+}
+
+// compile compiles the pattern of the search.
+func (s *search) compile(ignoreCase bool) error {
+	expr := s.pattern
+	if !s.regex {
+		expr = regexp.QuoteMeta(expr)
+	}
+	if ignoreCase {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return err
+	}
+	s.re, s.ignoreCase = re, ignoreCase
+	return nil
+}
+
+// searchRegexp returns the compiled pattern of the search, or nil if there is
+// no search. It is recompiled when the "ignorecase" setting changes.
+func (v *View) searchRegexp() *regexp.Regexp {
+	if v.search == nil || v.Buf == nil {
+		return nil
+	}
+	ignoreCase, _ := v.Buf.Settings["ignorecase"].(bool)
+	if ignoreCase != v.search.ignoreCase || v.search.re == nil {
+		if err := v.search.compile(ignoreCase); err != nil {
+			return nil
+		}
+	}
+	return v.search.re
+}
+
+// lineMatches returns the non-empty matches of re in the given line as rune
+// ranges.
+func lineMatches(re *regexp.Regexp, line string) [][2]int {
+	var matches [][2]int
+	for _, match := range re.FindAllStringIndex(line, -1) {
+		if match[0] == match[1] {
+			continue
+		}
+		start := utf8.RuneCountInString(line[:match[0]])
+		matches = append(matches, [2]int{start, start + utf8.RuneCountInString(line[match[0]:match[1]])})
+	}
+	return matches
+}
+
+// findMatch returns the start and end of the first match of re after "from"
+// if forward is true, or of the last match before "from" otherwise. The search
+// wraps around the buffer.
+func (v *View) findMatch(re *regexp.Regexp, from Loc, forward bool) ([2]Loc, bool) {
+	numLines := v.Buf.NumLines
+	for i := 0; i <= numLines; i++ {
+		y := from.Y + i
+		if !forward {
+			y = from.Y - i
+		}
+		y = (y%numLines + numLines) % numLines
+		matches := lineMatches(re, v.Buf.Line(y))
+		if !forward {
+			for j, k := 0, len(matches)-1; j < k; j, k = j+1, k-1 {
+				matches[j], matches[k] = matches[k], matches[j]
+			}
+		}
+		for _, match := range matches {
+			// Only the line we started on is searched twice: first from
+			// "from", then up to it after wrapping around.
+			if i == 0 && (forward && match[0] < from.X || !forward && match[0] >= from.X) ||
+				i == numLines && (forward && match[0] >= from.X || !forward && match[0] < from.X) {
+				continue
+			}
+			return [2]Loc{{match[0], y}, {match[1], y}}, true
+		}
+	}
+	return [2]Loc{}, false
+}
+
+// selectMatch selects the next (or previous) match of re from "from" with the
+// current cursor. It returns false if there is no match.
+func (v *View) selectMatch(re *regexp.Regexp, from Loc, forward bool) bool {
+	match, ok := v.findMatch(re, from, forward)
+	if !ok {
+		return false
+	}
+	v.Cursor.SetSelectionStart(match[0])
+	v.Cursor.SetSelectionEnd(match[1])
+	v.Cursor.OrigSelection = v.Cursor.CurSelection
+	v.Cursor.GotoLoc(match[1])
+	return true
+}
+
+// Search selects the next match of the search after the cursor if forward is
+// true, or the previous match before it otherwise. The search wraps around the
+// buffer. It returns false if there is no search or no match.
+func (v *View) Search(forward bool) bool {
+	v.activate()
+	re := v.searchRegexp()
+	if re == nil {
+		return false
+	}
+	from := v.Cursor.Loc
+	if v.Cursor.HasSelection() {
+		from = v.Cursor.CurSelection[1]
+		if !forward {
+			from = v.Cursor.CurSelection[0]
+		}
+	}
+	if !v.selectMatch(re, from, forward) {
+		v.showMessage("No matches for %s", v.search.pattern)
+		return false
+	}
+	v.Relocate()
+	return true
+}
+
+// expand returns the replacement of the given match in the given line. For
+// regular expressions, $1 and ${name} in the replacement are expanded to the
+// submatches.
+func (v *View) expand(re *regexp.Regexp, line string, match []int, replacement string) string {
+	if !v.search.regex {
+		return replacement
+	}
+	return string(re.ExpandString(nil, replacement, line, match))
+}
+
+// ReplaceNext replaces the next match of the search at or after the cursor
+// and selects the match after it. A selected match is replaced. It returns
+// false if there is no search or no match.
+func (v *View) ReplaceNext(replacement string) bool {
+	v.activate()
+	re := v.searchRegexp()
+	if re == nil {
+		return false
+	}
+	from := v.Cursor.Loc
+	if v.Cursor.HasSelection() {
+		from = v.Cursor.CurSelection[0]
+		if v.Cursor.CurSelection[1].LessThan(from) {
+			from = v.Cursor.CurSelection[1]
+		}
+	}
+	match, ok := v.findMatch(re, from, true)
+	if !ok {
+		v.showMessage("No matches for %s", v.search.pattern)
+		return false
+	}
+
+	line := v.Buf.Line(match[0].Y)
+	start := len(string([]rune(line)[:match[0].X]))
+	var text string
+	for _, submatch := range re.FindAllStringSubmatchIndex(line, -1) {
+		if submatch[0] == start && submatch[1] > start {
+			text = v.expand(re, line, submatch, replacement)
+			break
+		}
+	}
+
+	v.Cursor.ResetSelection()
+	v.Buf.Replace(match[0], match[1], text)
+	v.Cursor.GotoLoc(match[0].Move(Count(text), v.Buf))
+	v.selectMatch(re, v.Cursor.Loc, true)
+	v.Relocate()
+	return true
+}
+
+// ReplaceAll replaces all matches of the search in the buffer as a single
+// undoable event. It returns the number of replaced matches.
+func (v *View) ReplaceAll(replacement string) int {
+	v.activate()
+	re := v.searchRegexp()
+	if re == nil {
+		return 0
+	}
+
+	// The deltas are executed from the end of the buffer so that their
+	// locations stay valid.
+	var deltas []Delta
+	for y := v.Buf.NumLines - 1; y >= 0; y-- {
+		line := v.Buf.Line(y)
+		matches := re.FindAllStringSubmatchIndex(line, -1)
+		for i := len(matches) - 1; i >= 0; i-- {
+			match := matches[i]
+			if match[0] == match[1] {
+				continue
+			}
+			start := utf8.RuneCountInString(line[:match[0]])
+			deltas = append(deltas, Delta{
+				Text:  v.expand(re, line, match, replacement),
+				Start: Loc{start, y},
+				End:   Loc{start + utf8.RuneCountInString(line[match[0]:match[1]]), y},
+			})
+		}
+	}
+	if len(deltas) == 0 {
+		return 0
+	}
+
+	v.Buf.clearCursors()
+	v.Buf.MultipleReplace(deltas)
+	v.Cursor.Relocate()
+	v.Relocate()
+	return len(deltas)
+}
+
+// GotoLine moves the cursor to the given line and column, both starting at 1.
+// The column is limited to the length of the line. It returns false if the
+// line does not exist.
+func (v *View) GotoLine(line, col int) bool {
+	v.activate()
+	if line < 1 || line > v.Buf.NumLines {
+		return false
+	}
+	if col < 1 {
+		col = 1
+	}
+	if length := utf8.RuneCount(v.Buf.LineBytes(line - 1)); col > length+1 {
+		col = length + 1
+	}
+	v.Cursor.ResetSelection()
+	v.Cursor.GotoLoc(Loc{col - 1, line - 1})
+	v.Relocate()
+	return true
+}
+
+// promptSearch asks the user for the pattern of the search, then calls next.
+func (v *View) promptSearch(message string, next func()) {
+	input, _ := v.GetSearch()
+	if v.Cursor.HasSelection() && !strings.Contains(v.Cursor.GetSelection(), "\n") {
+		input = v.Cursor.GetSelection()
+		if v.Buf.Settings["searchregex"].(bool) {
+			input = regexp.QuoteMeta(input)
+		}
+	}
+	v.ask(PromptFind, message, input, func(input string, ok bool) {
+		if !ok {
+			return
+		}
+		if err := v.SetSearch(input, v.Buf.Settings["searchregex"].(bool)); err != nil {
+			v.showMessage("Invalid regular expression: %s", err)
+			return
+		}
+		next()
+	})
+}
+
+// promptReplace asks the user for the pattern of the search and its
+// replacement, then calls next with the replacement.
+func (v *View) promptReplace(next func(replacement string)) {
+	v.promptSearch("Replace: ", func() {
+		v.ask(PromptReplace, "Replace with: ", v.replacement, func(input string, ok bool) {
+			if ok {
+				v.replacement = input
+				next(input)
+			}
+		})
+	})
+}
+
+// Find asks for a pattern and searches forward for it
+func (v *View) Find() bool {
+	if v.mainCursor() && v.prompt != nil {
+		from := v.Cursor.Loc
+		if v.Cursor.HasSelection() {
+			from = v.Cursor.CurSelection[0]
+		}
+		v.promptSearch("Find: ", func() {
+			v.Cursor.ResetSelection()
+			v.Cursor.GotoLoc(from)
+			v.Search(true)
+		})
+	}
+	return false
+}
+
+// FindNext searches forwards for the last used search term
+func (v *View) FindNext() bool {
+	if v.mainCursor() {
+		return v.Search(true)
+	}
+	return false
+}
+
+// FindPrevious searches backwards for the last used search term
+func (v *View) FindPrevious() bool {
+	if v.mainCursor() {
+		return v.Search(false)
+	}
+	return false
+}
+
+// Replace asks for a pattern and a replacement and replaces the next match
+func (v *View) Replace() bool {
+	if v.mainCursor() && v.prompt != nil {
+		v.promptReplace(func(replacement string) {
+			v.ReplaceNext(replacement)
+		})
+	}
+	return false
+}
+
+// ReplaceAllAction asks for a pattern and a replacement and replaces all
+// matches
+func (v *View) ReplaceAllAction() bool {
+	if v.mainCursor() && v.prompt != nil {
+		v.promptReplace(func(replacement string) {
+			v.showMessage("Replaced %d occurrences of %s", v.ReplaceAll(replacement), v.search.pattern)
+		})
+	}
+	return false
+}
+
+// JumpLine asks for a line and column and moves the cursor there
+func (v *View) JumpLine() bool {
+	if !v.mainCursor() || v.prompt == nil {
+		return false
+	}
+	message := fmt.Sprintf("Jump to line:col (1 - %d): ", v.Buf.NumLines)
+	v.ask(PromptJumpLine, message, "", func(input string, ok bool) {
+		if !ok {
+			return
+		}
+		lineStr, colStr, hasCol := strings.Cut(strings.TrimSpace(input), ":")
+		line, err := strconv.Atoi(lineStr)
+		if err != nil {
+			v.showMessage("Invalid line number")
+			return
+		}
+		col := 1
+		if hasCol {
+			if col, err = strconv.Atoi(colStr); err != nil {
+				v.showMessage("Invalid column number")
+				return
+			}
+		}
+		if !v.GotoLine(line, col) {
+			v.showMessage("Only %d lines to jump", v.Buf.NumLines)
+		}
+	})
+	return false
+}
@@ -9,6 +9,8 @@ func DefaultLocalSettings() map[string]interface{} {
 		"basename":       false,
 		"colorcolumn":    float64(0),
 		"cursorline":     true,
+		"diffgutter":     false,
+		"encoding":       "utf-8",
 		"eofnewline":     false,
 		"fastdirty":      true,
 		"fileformat":     "unix",
@@ -20,12 +22,14 @@ func DefaultLocalSettings() map[string]interface{} {
 		"matchbrace":     false,
 		"matchbraceleft": false,
 		"rmtrailingws":   false,
+		"relativeruler":  false,
 		"ruler":          true,
 		"savecursor":     false,
 		"saveundo":       false,
 		"scrollbar":      false,
 		"scrollmargin":   float64(3),
 		"scrollspeed":    float64(2),
+		"searchregex":    false,
 		"softwrap":       false,
 		"smartpaste":     true,
 		"splitbottom":    true,
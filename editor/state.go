@@ -0,0 +1,178 @@
+package editor
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/gob"
+	"os"
+	"path/filepath"
+)
+
+// StateDir is the directory in which the state of buffers, like the cursor
+// location and the undo history, is stored between sessions, in files named
+// after the escaped absolute paths of the buffers. If it is empty, the state
+// of a buffer is stored next to its file, in a hidden file with the suffix
+// ".cui".
+var StateDir string
+
+// bufferState is the state of a buffer which is stored between sessions. It
+// is only restored if the buffer has the text it had when the state was
+// stored, as identified by its hash.
+type bufferState struct {
+	Hash      [md5.Size]byte
+	Cursor    Loc
+	Selection [2]Loc
+	Undo      []*TextEvent
+	Redo      []*TextEvent
+}
+
+// statePath returns the path of the file which stores the state of the
+// buffer with the given file.
+func statePath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	if StateDir != "" {
+		return filepath.Join(StateDir, EscapePath(abs)), nil
+	}
+	return filepath.Join(filepath.Dir(abs), "."+filepath.Base(abs)+".cui"), nil
+}
+
+// stackEvents returns the events of the stack from the bottom to the top.
+func stackEvents(s *Stack) []*TextEvent {
+	events := make([]*TextEvent, s.Len())
+	i := len(events) - 1
+	for e := s.Top; e != nil; e = e.Next {
+		events[i] = e.Value
+		i--
+	}
+	return events
+}
+
+// eventsStack returns a stack with the given events from the bottom to the
+// top.
+func eventsStack(events []*TextEvent) *Stack {
+	s := new(Stack)
+	for _, t := range events {
+		s.Push(t)
+	}
+	return s
+}
+
+// saveState stores the cursor location and selection of the buffer if the
+// "savecursor" setting is on, and its undo history if the "saveundo" setting
+// is on.
+func (b *Buffer) saveState() error {
+	path, err := statePath(b.Path)
+	if err != nil {
+		return err
+	}
+	var state bufferState
+	calcHash(b, &state.Hash)
+	if b.Settings["savecursor"].(bool) {
+		state.Cursor = b.Cursor.Loc
+		state.Selection = b.Cursor.CurSelection
+	}
+	if b.Settings["saveundo"].(bool) {
+		state.Undo = stackEvents(b.UndoStack)
+		state.Redo = stackEvents(b.RedoStack)
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(state); err != nil {
+		return err
+	}
+	if StateDir != "" {
+		if err := os.MkdirAll(StateDir, 0700); err != nil {
+			return err
+		}
+	}
+	return writeFileAtomic(path, buf.Bytes())
+}
+
+// loadState restores the state of the buffer, if it was stored for the
+// current text of the buffer. A state stored for another text, for example
+// because the file was changed by another program, or with locations outside
+// of the text is discarded.
+func (b *Buffer) loadState() {
+	path, err := statePath(b.Path)
+	if err != nil {
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	var state bufferState
+	if gob.NewDecoder(bytes.NewReader(data)).Decode(&state) != nil {
+		return
+	}
+	var hash [md5.Size]byte
+	if calcHash(b, &hash); hash != state.Hash || !b.validState(&state) {
+		return
+	}
+
+	if b.Settings["savecursor"].(bool) {
+		b.Cursor.Loc = state.Cursor
+		b.Cursor.CurSelection = state.Selection
+		b.Cursor.OrigSelection = state.Selection
+		b.Cursor.Relocate()
+		b.Cursor.StoreVisualX()
+	}
+	if b.Settings["saveundo"].(bool) {
+		b.UndoStack = eventsStack(state.Undo)
+		b.RedoStack = eventsStack(state.Redo)
+	}
+}
+
+// validState returns whether the selection of the state is within the buffer
+// and whether undoing and redoing its events only changes text within the
+// buffer. The cursor is not checked as it is relocated when it is restored.
+func (b *Buffer) validState(state *bufferState) bool {
+	for _, loc := range state.Selection {
+		if !InBounds(loc, b) {
+			return false
+		}
+	}
+	return validEvents(b, state.Undo) && validEvents(b, state.Redo)
+}
+
+// validEvents returns whether the events of an undo or redo stack, given from
+// the bottom to the top, can be applied to the buffer. Each event is checked
+// against the text it applies to by applying the events from the top down to
+// a copy of the buffer.
+func validEvents(b *Buffer, events []*TextEvent) bool {
+	if len(events) == 0 {
+		return true
+	}
+	scratch := NewBufferFromString(b.String(), "")
+	for i := len(events) - 1; i >= 0; i-- {
+		t := events[i]
+		if t == nil {
+			return false
+		}
+		for _, d := range t.Deltas {
+			// Insertions and replacements are undone by removing the text
+			// from Start to End, removals by inserting the text at Start.
+			switch t.EventType {
+			case TextEventInsert, TextEventReplace:
+				if !InBounds(d.Start, scratch) || !InBounds(d.End, scratch) || d.End.LessThan(d.Start) {
+					return false
+				}
+			case TextEventRemove:
+				if !InBounds(d.Start, scratch) {
+					return false
+				}
+			default:
+				return false
+			}
+			UndoTextEvent(&TextEvent{EventType: t.EventType, Deltas: []Delta{d}}, scratch)
+		}
+		for _, loc := range [...]Loc{t.C.Loc, t.C.CurSelection[0], t.C.CurSelection[1]} {
+			if !InBounds(loc, scratch) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,52 @@
+package editor
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+)
+
+// StatusLine returns the texts shown on the left and the right of the status
+// line of the view. The left shows the name of the buffer, a "+" if it was
+// modified and the line and column of the cursor, the right shows the file
+// type, the encoding and the line endings of the buffer.
+func (v *View) StatusLine() (left, right string) {
+	left = v.Buf.GetName()
+	if v.Buf.Modified() {
+		left += " +"
+	}
+	left += fmt.Sprintf(" (%d,%d)", v.Cursor.Y+1, v.Cursor.X+1)
+	right = fmt.Sprintf("%s | %s | %s", v.Buf.FileType(), v.Buf.Settings["encoding"], v.Buf.Settings["fileformat"])
+	return left, right
+}
+
+// displayStatusLine draws the status line below the text.
+func (v *View) displayStatusLine(screen tcell.Screen) {
+	style := defStyle.Reverse(true)
+	if s, ok := v.theme["statusline"]; ok {
+		style = s
+	}
+
+	y := v.y + v.height
+	for x := v.x; x < v.x+v.width; x++ {
+		screen.SetContent(x, y, ' ', nil, style)
+	}
+	left, right := v.StatusLine()
+	x := v.x + 1
+	for _, r := range left {
+		if x >= v.x+v.width {
+			return
+		}
+		screen.SetContent(x, y, r, nil, style)
+		x += runewidth.RuneWidth(r)
+	}
+	x = v.x + v.width - 1 - StringWidth(right, 1)
+	if x <= v.x+1+StringWidth(left, 1) {
+		return
+	}
+	for _, r := range right {
+		screen.SetContent(x, y, r, nil, style)
+		x += runewidth.RuneWidth(r)
+	}
+}
@@ -17,6 +17,9 @@ type View struct {
 	// A pointer to the buffer's cursor for ease of access
 	Cursor *Cursor
 
+	// The cursors of this view in the buffer
+	cursorSet *cursorSet
+
 	// The topmost line, used for vertical scrolling
 	Topline int
 	// The leftmost column, used for horizontal scrolling
@@ -25,16 +28,23 @@ type View struct {
 	// Specifies whether or not this view is readonly
 	Readonly bool
 
-	// Actual width and height
+	// Actual width and height, without the status line
 	width  int
 	height int
 
+	// Whether the status line is shown below the text
+	statusLine bool
+
 	// Where this view is located
 	x, y int
 
 	// How much to offset because of line numbers
 	lineNumOffset int
 
+	// Where the main cursor was drawn on the screen
+	cursorX, cursorY int
+	cursorShown      bool
+
 	// The buffer
 	Buf *Buffer
 
@@ -61,11 +71,29 @@ type View struct {
 	// The theme
 	theme Theme
 
+	// The searched pattern and the last replacement
+	search      *search
+	replacement string
+
+	// The functions which prompt the user for input and show messages
+	prompt  PromptFunc
+	message func(msg string)
+
+	// The function which splits the view in its host
+	split func(action string)
+
+	// The language-aware features, the function which lets the user choose
+	// a completion and the function which calls the provider in the
+	// background
+	provider Provider
+	complete CompleteFunc
+	async    AsyncFunc
+
 	sync.RWMutex
 }
 
 func (v *View) GetRect() (int, int, int, int) {
-	return v.x, v.y, v.width, v.height
+	return v.x, v.y, v.width, v.fullHeight()
 }
 
 func (v *View) SetBuffer(buf *Buffer) {
@@ -74,6 +102,74 @@ func (v *View) SetBuffer(buf *Buffer) {
 
 }
 
+// NewSplit returns a new view of the buffer of this view, e.g. for showing it
+// in a split. The views share the text, settings and undo history of the
+// buffer, but the new view has its own cursors, starting at the cursor of
+// this view. Close the new view when it is no longer shown.
+func (v *View) NewSplit() *View {
+	s := NewView()
+	s.theme, s.bindings, s.Readonly = v.theme, v.bindings, v.Readonly
+	s.prompt, s.message, s.split = v.prompt, v.message, v.split
+	s.provider, s.complete, s.async = v.provider, v.complete, v.async
+	s.Buf = v.Buf
+	s.cursorSet = v.Buf.newCursorSet(v.Cursor)
+	s.activate()
+	s.Cursor = s.Buf.cursors[0]
+	s.Topline, s.leftCol = v.Topline, v.leftCol
+	return s
+}
+
+// SetSplitFunc sets the function which is called with the name of the
+// action (ActionHSplit, ActionVSplit, ActionUnsplit or ActionNextSplit) when
+// one of the split actions is executed. The host of the view arranges the
+// views made with NewSplit. Without it, these actions do nothing.
+func (v *View) SetSplitFunc(split func(action string)) {
+	v.split = split
+}
+
+// doSplit calls the split function with the given action.
+func (v *View) doSplit(action string) bool {
+	if v.mainCursor() && v.split != nil {
+		v.split(action)
+	}
+	return false
+}
+
+// HSplit shows the buffer in another view below (or above) this view
+func (v *View) HSplit() bool {
+	return v.doSplit(ActionHSplit)
+}
+
+// VSplit shows the buffer in another view to the right (or left) of this view
+func (v *View) VSplit() bool {
+	return v.doSplit(ActionVSplit)
+}
+
+// Unsplit closes this view if the buffer is shown in other views
+func (v *View) Unsplit() bool {
+	return v.doSplit(ActionUnsplit)
+}
+
+// NextSplit moves the focus to the next view
+func (v *View) NextSplit() bool {
+	return v.doSplit(ActionNextSplit)
+}
+
+// Close removes the cursors of a view made with NewSplit from its buffer.
+func (v *View) Close() {
+	if v.Buf != nil && v.cursorSet != nil {
+		v.Buf.removeCursorSet(v.cursorSet)
+	}
+}
+
+// activate makes the cursors of this view the cursors of the buffer, which
+// are used by its actions. The buffer may be shown in other views as well.
+func (v *View) activate() {
+	if v.Buf != nil && v.cursorSet != nil {
+		v.Buf.useCursorSet(v.cursorSet)
+	}
+}
+
 // NewView returns a new view with the specified buffer.
 func NewView() *View {
 	v := new(View)
@@ -91,13 +187,35 @@ func NewView() *View {
 	return v
 }
 
-// SetRect sets a new position for the view.
+// SetRect sets a new position for the view. If the "statusline" setting is
+// on, the status line takes the last line.
 func (v *View) SetRect(x, y, width, height int) {
 	v.x, v.y, v.width, v.height = x, y, width, height
+	v.statusLine = false
+	v.updateStatusLine()
 }
 
 func (v *View) InRect(x, y int) bool {
-	return x >= v.x && x < v.x+v.width && y >= v.y && y < v.y+v.height
+	return x >= v.x && x < v.x+v.width && y >= v.y && y < v.y+v.fullHeight()
+}
+
+// fullHeight returns the height of the view with the status line.
+func (v *View) fullHeight() int {
+	if v.statusLine {
+		return v.height + 1
+	}
+	return v.height
+}
+
+// updateStatusLine shows or hides the status line according to the
+// "statusline" setting. It is not shown if the view has only one line.
+func (v *View) updateStatusLine() {
+	height := v.fullHeight()
+	v.statusLine = v.Buf != nil && v.Buf.Settings["statusline"].(bool) && height > 1
+	v.height = height
+	if v.statusLine {
+		v.height--
+	}
 }
 
 // GetKeyBindings gets the keybindings for this view.
@@ -163,8 +281,12 @@ func (v *View) ScrollDown(n int) {
 // OpenBuffer opens a new buffer in this view.
 // This resets the topline, event handler and cursor.
 func (v *View) OpenBuffer(buf *Buffer) {
+	v.Close()
 	v.Buf = buf
+	v.cursorSet = buf.cursorSets[0]
+	v.activate()
 	v.Cursor = &buf.Cursor
+	v.updateStatusLine()
 	v.Topline = 0
 	v.leftCol = 0
 	v.Cursor.ResetSelection()
@@ -254,7 +376,7 @@ func (v *View) Relocate() bool {
 // Execute actions executes the supplied actions
 func (v *View) ExecuteActions(actions []func(*View) bool) bool {
 	relocate := false
-	readonlyBindingsList := []string{"Delete", "Insert", "Backspace", "Cut", "Play", "Paste", "Move", "Add", "DuplicateLine", "Macro"}
+	readonlyBindingsList := []string{"Delete", "Insert", "Backspace", "Cut", "Play", "Paste", "Move", "Add", "DuplicateLine", "Macro", "Replace"}
 	for _, action := range actions {
 		readonlyBindingsResult := false
 		funcName := ShortFuncName(action)
@@ -291,6 +413,7 @@ func (v *View) HandleEvent(event tcell.Event) {
 	// This bool determines whether the view is relocated at the end of the function
 	// By default it's true because most events should cause a relocate
 	relocate := true
+	v.activate()
 
 	switch e := event.(type) {
 	case *tcell.EventKey:
@@ -314,7 +437,7 @@ func (v *View) HandleEvent(event tcell.Event) {
 						isBinding = true
 						relocate = v.ExecuteActions(actions) || relocate
 					}
-					v.SetCursor(&v.Buf.Cursor)
+					v.SetCursor(v.Buf.cursors[0])
 					v.Buf.MergeCursors()
 					break
 				}
@@ -341,7 +464,7 @@ func (v *View) HandleEvent(event tcell.Event) {
 						v.Buf.Insert(v.Cursor.Loc, string(e.Rune()))
 					}
 				}
-				v.SetCursor(&v.Buf.Cursor)
+				v.SetCursor(v.Buf.cursors[0])
 			}
 		}
 	}
@@ -363,6 +486,7 @@ func (v *View) mainCursor() bool {
 
 // displayView draws the view to the screen
 func (v *View) displayView(screen tcell.Screen) {
+	v.cursorShown = false
 	if v.Buf.Settings["softwrap"].(bool) && v.leftCol != 0 {
 		v.leftCol = 0
 	}
@@ -371,11 +495,18 @@ func (v *View) displayView(screen tcell.Screen) {
 	// so we can pad appropriately when displaying line numbers
 	maxLineNumLength := len(strconv.Itoa(v.Buf.NumLines))
 
+	gutterWidth := v.gutterWidth()
 	if v.Buf.Settings["ruler"] == true {
 		// + 1 for the little space after the line number
-		v.lineNumOffset = maxLineNumLength + 1
+		v.lineNumOffset = gutterWidth + maxLineNumLength + 1
 	} else {
-		v.lineNumOffset = 0
+		v.lineNumOffset = gutterWidth
+	}
+
+	diagnostics := v.Buf.lineDiagnostics()
+	var diffs []int
+	if v.Buf.Settings["diffgutter"].(bool) {
+		diffs = v.Buf.diffMarkers()
 	}
 
 	xOffset := v.x + v.lineNumOffset
@@ -386,7 +517,7 @@ func (v *View) displayView(screen tcell.Screen) {
 	left := v.leftCol
 	top := v.Topline
 
-	v.cellview.Draw(v.Buf, v.theme, top, height, left, width-v.lineNumOffset)
+	v.cellview.Draw(v.Buf, v.theme, v.searchRegexp(), top, height, left, width-v.lineNumOffset)
 
 	screenX := v.x
 	realLineN := top - 1
@@ -416,7 +547,7 @@ func (v *View) displayView(screen tcell.Screen) {
 			screen.SetContent(xOffset+colorcolumn-v.leftCol, yOffset+visualLineN, ' ', nil, st)
 		}
 
-		screenX = v.x
+		screenX = v.displayGutter(screen, v.x, yOffset+visualLineN, realLineN, softwrapped && visualLineN != 0, diagnostics, diffs)
 
 		lineNumStyle := defStyle
 		if v.Buf.Settings["ruler"] == true {
@@ -431,6 +562,9 @@ func (v *View) displayView(screen tcell.Screen) {
 			}
 
 			lineNum := strconv.Itoa(realLineN + 1)
+			if v.Buf.Settings["relativeruler"] == true && realLineN != v.Cursor.Y {
+				lineNum = strconv.Itoa(Abs(realLineN - v.Cursor.Y))
+			}
 
 			// Write the spaces before the line number if necessary
 			for i := 0; i < maxLineNumLength-len(lineNum); i++ {
@@ -483,7 +617,7 @@ func (v *View) displayView(screen tcell.Screen) {
 						}
 					}
 				}
-				v.SetCursor(&v.Buf.Cursor)
+				v.SetCursor(v.Buf.cursors[0])
 
 				if v.Buf.Settings["cursorline"].(bool) &&
 					!v.Cursor.HasSelection() && v.Cursor.Y == realLineN {
@@ -492,17 +626,21 @@ func (v *View) displayView(screen tcell.Screen) {
 					lineStyle = lineStyle.Background(fg)
 				}
 
+				if d, ok := v.Buf.diagnosticAt(charLoc); ok {
+					lineStyle = v.diagnosticStyle(lineStyle, d)
+				}
+
 				screen.SetContent(xOffset+char.visualLoc.X, yOffset+char.visualLoc.Y, char.drawChar, nil, lineStyle)
 
 				for i, c := range v.Buf.cursors {
 					v.SetCursor(c)
 					if !v.Cursor.HasSelection() &&
 						v.Cursor.Y == char.realLoc.Y && v.Cursor.X == char.realLoc.X && (!cursorSet || i != 0) {
-						ShowMultiCursor(screen, xOffset+char.visualLoc.X, yOffset+char.visualLoc.Y, i)
+						v.showCursor(screen, xOffset+char.visualLoc.X, yOffset+char.visualLoc.Y, i)
 						cursorSet = true
 					}
 				}
-				v.SetCursor(&v.Buf.Cursor)
+				v.SetCursor(v.Buf.cursors[0])
 
 				lastChar = char
 			}
@@ -518,11 +656,11 @@ func (v *View) displayView(screen tcell.Screen) {
 				v.SetCursor(c)
 				if !v.Cursor.HasSelection() &&
 					v.Cursor.Y == lastChar.realLoc.Y && v.Cursor.X == lastChar.realLoc.X+1 {
-					ShowMultiCursor(screen, lastX, yOffset+lastChar.visualLoc.Y, i)
+					v.showCursor(screen, lastX, yOffset+lastChar.visualLoc.Y, i)
 					cx, cy = lastX, yOffset+lastChar.visualLoc.Y
 				}
 			}
-			v.SetCursor(&v.Buf.Cursor)
+			v.SetCursor(v.Buf.cursors[0])
 			realLoc = Loc{lastChar.realLoc.X + 1, realLineN}
 			visualLoc = Loc{lastX - xOffset, lastChar.visualLoc.Y}
 		} else if len(line) == 0 {
@@ -530,11 +668,11 @@ func (v *View) displayView(screen tcell.Screen) {
 				v.SetCursor(c)
 				if !v.Cursor.HasSelection() &&
 					v.Cursor.Y == realLineN {
-					ShowMultiCursor(screen, xOffset, yOffset+visualLineN, i)
+					v.showCursor(screen, xOffset, yOffset+visualLineN, i)
 					cx, cy = xOffset, yOffset+visualLineN
 				}
 			}
-			v.SetCursor(&v.Buf.Cursor)
+			v.SetCursor(v.Buf.cursors[0])
 			lastX = xOffset
 			realLoc = Loc{0, realLineN}
 			visualLoc = Loc{0, visualLineN}
@@ -566,6 +704,22 @@ func (v *View) displayView(screen tcell.Screen) {
 	}
 }
 
+// showCursor displays a cursor with ShowMultiCursor and remembers the
+// location of the main cursor on the screen.
+func (v *View) showCursor(screen tcell.Screen, x, y, i int) {
+	ShowMultiCursor(screen, x, y, i)
+	if i == 0 {
+		v.cursorX, v.cursorY, v.cursorShown = x, y, true
+	}
+}
+
+// GetCursorPosition returns the location of the main cursor on the screen
+// when the view was last drawn. It returns false if the cursor was not
+// shown, e.g. because it has a selection.
+func (v *View) GetCursorPosition() (x, y int, ok bool) {
+	return v.cursorX, v.cursorY, v.cursorShown
+}
+
 // ShowMultiCursor will display a cursor at a location
 // If i == 0 then the terminal cursor will be used
 // Otherwise a fake cursor will be drawn at the position
@@ -584,7 +738,8 @@ func (v *View) Draw(screen tcell.Screen) {
 	v.Lock()
 	defer v.Unlock()
 
-	v.width, v.height = screen.Size()
+	v.activate()
+	v.updateStatusLine()
 
 	// TODO(pdg): just clear from the last line down.
 	for y := v.y; y < v.y+v.height; y++ {
@@ -603,4 +758,7 @@ func (v *View) Draw(screen tcell.Screen) {
 	if v.Buf.Settings["scrollbar"].(bool) {
 		v.scrollbar.Display(screen)
 	}
+	if v.statusLine {
+		v.displayStatusLine(screen)
+	}
 }
@@ -0,0 +1,867 @@
+package cui
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"encoding/gob"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/malivvan/cui/editor"
+)
+
+func TestEditorFindReplace(t *testing.T) {
+	t.Parallel()
+
+	e := NewEditor()
+	e.SetBuffer(editor.NewBufferFromString("foo bar\nbar Foo\nfoo", ""))
+	v := e.view
+
+	key := func(key tcell.Key, r rune, mod tcell.ModMask) {
+		e.InputHandler()(tcell.NewEventKey(key, r, mod), func(p Widget) {})
+	}
+	typeText := func(text string) {
+		for _, r := range text {
+			key(tcell.KeyRune, r, 0)
+		}
+		key(tcell.KeyEnter, 0, 0)
+	}
+	expectSelection := func(action string, start, end editor.Loc) {
+		t.Helper()
+		if sel := v.Cursor.CurSelection; sel[0] != start || sel[1] != end {
+			t.Errorf("failed to %s: expected selection %v, got %v", action, [2]editor.Loc{start, end}, sel)
+		}
+	}
+
+	// Find.
+	key(tcell.KeyCtrlF, 0, tcell.ModCtrl)
+	if e.prompt == nil {
+		t.Fatal("failed to show prompt")
+	}
+	typeText("bar")
+	if e.prompt != nil {
+		t.Error("failed to hide prompt")
+	}
+	expectSelection("find", editor.Loc{X: 4, Y: 0}, editor.Loc{X: 7, Y: 0})
+	key(tcell.KeyCtrlN, 0, tcell.ModCtrl)
+	expectSelection("find next", editor.Loc{X: 0, Y: 1}, editor.Loc{X: 3, Y: 1})
+	key(tcell.KeyCtrlN, 0, tcell.ModCtrl)
+	expectSelection("wrap around", editor.Loc{X: 4, Y: 0}, editor.Loc{X: 7, Y: 0})
+	key(tcell.KeyCtrlP, 0, tcell.ModCtrl)
+	expectSelection("find previous", editor.Loc{X: 0, Y: 1}, editor.Loc{X: 3, Y: 1})
+
+	// No matches.
+	v.SetSearch("qux", false)
+	if v.Search(true) || e.message == "" {
+		t.Error("failed to report missing match")
+	}
+
+	// Replace all, case-insensitive.
+	v.Buf.Settings["ignorecase"] = true
+	v.SetSearch("foo", false)
+	if n := v.ReplaceAll("baz"); n != 3 {
+		t.Errorf("failed to replace all: expected 3 replacements, got %d", n)
+	}
+	if text := v.Buf.String(); text != "baz bar\nbar baz\nbaz" {
+		t.Errorf("failed to replace all: got %q", text)
+	}
+	key(tcell.KeyCtrlZ, 0, tcell.ModCtrl)
+	if text := v.Buf.String(); text != "foo bar\nbar Foo\nfoo" {
+		t.Errorf("failed to undo replace all in one step: got %q", text)
+	}
+
+	// Replace with submatches.
+	v.Buf.Settings["ignorecase"] = false
+	if err := v.SetSearch(`(\w+) (\w+)`, true); err != nil {
+		t.Fatalf("failed to set search: %s", err)
+	}
+	v.Cursor.GotoLoc(editor.Loc{X: 0, Y: 1})
+	if !v.ReplaceNext("$2 $1") {
+		t.Error("failed to replace next")
+	}
+	if text := v.Buf.String(); text != "foo bar\nFoo bar\nfoo" {
+		t.Errorf("failed to replace next: got %q", text)
+	}
+	if err := v.SetSearch("(", true); err == nil {
+		t.Error("failed to reject invalid regular expression")
+	}
+
+	// Jump to line:col.
+	key(tcell.KeyCtrlL, 0, tcell.ModCtrl)
+	typeText("3:2")
+	if v.Cursor.Loc != (editor.Loc{X: 1, Y: 2}) {
+		t.Errorf("failed to jump to line: expected 3:2, got %d:%d", v.Cursor.Y+1, v.Cursor.X+1)
+	}
+	key(tcell.KeyCtrlL, 0, tcell.ModCtrl)
+	typeText("9")
+	if e.message == "" {
+		t.Error("failed to report invalid line")
+	}
+}
+
+func TestEditorFile(t *testing.T) {
+	// Not parallel: the state is restored with GlobalSettings.
+	editor.GlobalSettings["savecursor"] = true
+	defer delete(editor.GlobalSettings, "savecursor")
+
+	path := filepath.Join(t.TempDir(), "file.txt")
+	if err := os.WriteFile(path, []byte("caf\xe9\r\nline  \r\nend"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Open.
+	b, err := editor.NewBufferFromFile(path)
+	if err != nil {
+		t.Fatalf("failed to open file: %s", err)
+	}
+	if encoding := b.Settings["encoding"]; encoding != "windows-1252" {
+		t.Errorf("failed to detect encoding: expected windows-1252, got %s", encoding)
+	}
+	if fileformat := b.Settings["fileformat"]; fileformat != "dos" {
+		t.Errorf("failed to detect line endings: expected dos, got %s", fileformat)
+	}
+	if text := b.String(); text != "café\nline  \nend" {
+		t.Errorf("failed to decode file: got %q", text)
+	}
+
+	// Save.
+	b.Settings["rmtrailingws"] = true
+	b.Settings["eofnewline"] = true
+	b.Cursor.GotoLoc(editor.Loc{X: 2, Y: 1})
+	if err := b.Save(); err != nil {
+		t.Fatalf("failed to save file: %s", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "caf\xe9\r\nline\r\nend\r\n" {
+		t.Errorf("failed to save file: got %q", data)
+	}
+	if b.Modified() {
+		t.Error("failed to reset modified flag")
+	}
+	if reopened, err := editor.NewBufferFromFile(path); err != nil || reopened.Cursor.Loc != (editor.Loc{X: 2, Y: 1}) {
+		t.Errorf("failed to restore cursor: got %v (%v)", reopened.Cursor.Loc, err)
+	}
+	b.Settings["encoding"] = "iso-8859-5"
+	if err := b.Save(); err == nil {
+		t.Error("failed to reject unencodable text")
+	}
+	b.Settings["encoding"] = "windows-1252"
+
+	// Merge changes on disk.
+	e := NewEditor()
+	e.SetBuffer(b)
+	b.Insert(editor.Loc{X: 0, Y: 0}, "my ")
+	if b.ChangedOnDisk() {
+		t.Error("failed to detect unchanged file")
+	}
+	if err := os.WriteFile(path, []byte("caf\xe9\r\nline\r\nend\r\ntheirs\r\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	e.view.CheckFile()
+	if e.prompt == nil {
+		t.Fatal("failed to ask for reload")
+	}
+	e.prompt.SetText("m")
+	e.InputHandler()(tcell.NewEventKey(tcell.KeyEnter, 0, 0), func(p Widget) {})
+	if text := b.String(); text != "my café\nline\nend\ntheirs\n" {
+		t.Errorf("failed to merge changes: got %q", text)
+	}
+	if b.ChangedOnDisk() {
+		t.Error("failed to remember merged file")
+	}
+
+	// Reload.
+	if err := b.Reload(); err != nil {
+		t.Fatalf("failed to reload file: %s", err)
+	}
+	if text := b.String(); text != "café\nline\nend\ntheirs\n" || b.Modified() {
+		t.Errorf("failed to reload file: got %q", text)
+	}
+}
+
+func TestEditorUndoHistory(t *testing.T) {
+	// Not parallel: the state is restored with GlobalSettings and StateDir.
+	editor.GlobalSettings["savecursor"] = true
+	editor.GlobalSettings["saveundo"] = true
+	editor.StateDir = t.TempDir()
+	defer func() {
+		delete(editor.GlobalSettings, "savecursor")
+		delete(editor.GlobalSettings, "saveundo")
+		editor.StateDir = ""
+	}()
+
+	path := filepath.Join(t.TempDir(), "file.txt")
+	b, err := editor.NewBufferFromFile(path)
+	if err != nil {
+		t.Fatalf("failed to open file: %s", err)
+	}
+	b.Insert(editor.Loc{X: 0, Y: 0}, "one")
+	b.UndoStack.Peek().Time = time.Time{} // Undo the insertions separately.
+	b.Insert(editor.Loc{X: 3, Y: 0}, " two")
+	b.Cursor.SetSelectionStart(editor.Loc{X: 0, Y: 0})
+	b.Cursor.SetSelectionEnd(editor.Loc{X: 3, Y: 0})
+	if err := b.Save(); err != nil {
+		t.Fatalf("failed to save file: %s", err)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("failed to store state in state directory: got %d files next to the file", len(entries))
+	}
+
+	// Restore.
+	b, err = editor.NewBufferFromFile(path)
+	if err != nil {
+		t.Fatalf("failed to reopen file: %s", err)
+	}
+	if sel := b.Cursor.CurSelection; sel != [2]editor.Loc{{X: 0, Y: 0}, {X: 3, Y: 0}} {
+		t.Errorf("failed to restore selection: got %v", sel)
+	}
+	if n := b.UndoStack.Len(); n != 2 {
+		t.Fatalf("failed to restore undo history: expected 2 events, got %d", n)
+	}
+	b.Undo()
+	if text := b.String(); text != "one" {
+		t.Errorf("failed to undo restored event: expected one, got %q", text)
+	}
+	b.Undo()
+	b.Redo()
+	if text := b.String(); text != "one" {
+		t.Errorf("failed to redo restored event: expected one, got %q", text)
+	}
+
+	// Events may refer to text which was removed afterwards.
+	b.Redo()
+	b.Remove(editor.Loc{X: 3, Y: 0}, editor.Loc{X: 7, Y: 0})
+	if err := b.Save(); err != nil {
+		t.Fatalf("failed to save file: %s", err)
+	}
+	b, err = editor.NewBufferFromFile(path)
+	if err != nil {
+		t.Fatalf("failed to reopen file: %s", err)
+	}
+	if n := b.UndoStack.Len(); n != 3 {
+		t.Fatalf("failed to restore undo history: expected 3 events, got %d", n)
+	}
+	for i := 0; i < 3; i++ {
+		b.Undo()
+	}
+	if text := b.String(); text != "" {
+		t.Errorf("failed to undo restored events: expected empty text, got %q", text)
+	}
+	b.Redo()
+	if err := b.Save(); err != nil {
+		t.Fatalf("failed to save file: %s", err)
+	}
+
+	// Corrupt.
+	statePath := filepath.Join(editor.StateDir, editor.EscapePath(path))
+	data, err := os.ReadFile(statePath)
+	if err != nil {
+		t.Fatalf("failed to read state: %s", err)
+	}
+	corrupt := func(change func(state *testBufferState)) {
+		t.Helper()
+		var state testBufferState
+		if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&state); err != nil {
+			t.Fatalf("failed to decode state: %s", err)
+		}
+		change(&state)
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(state); err != nil {
+			t.Fatalf("failed to encode state: %s", err)
+		}
+		if err := os.WriteFile(statePath, buf.Bytes(), 0600); err != nil {
+			t.Fatal(err)
+		}
+		b, err := editor.NewBufferFromFile(path)
+		if err != nil {
+			t.Fatalf("failed to reopen file: %s", err)
+		}
+		if b.UndoStack.Len() != 0 || b.RedoStack.Len() != 0 || b.Cursor.CurSelection != [2]editor.Loc{} {
+			t.Error("failed to discard corrupt state")
+		}
+	}
+	corrupt(func(state *testBufferState) { state.Selection[1] = editor.Loc{X: 100, Y: 0} })
+	corrupt(func(state *testBufferState) { state.Undo[0].Deltas[0].End = editor.Loc{X: 0, Y: 5} })
+	corrupt(func(state *testBufferState) { state.Undo[0].Deltas[0].Start = editor.Loc{X: -1, Y: 0} })
+
+	// Invalidate.
+	if err := os.WriteFile(path, []byte("changed"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	b, err = editor.NewBufferFromFile(path)
+	if err != nil {
+		t.Fatalf("failed to reopen file: %s", err)
+	}
+	if b.UndoStack.Len() != 0 || b.Cursor.Loc != (editor.Loc{}) {
+		t.Error("failed to discard state of changed file")
+	}
+}
+
+// testBufferState mirrors the state of a buffer which is stored between
+// sessions.
+type testBufferState struct {
+	Hash      [md5.Size]byte
+	Cursor    editor.Loc
+	Selection [2]editor.Loc
+	Undo      []*editor.TextEvent
+	Redo      []*editor.TextEvent
+}
+
+// screenLine returns the text of the given line of the screen.
+func screenLine(screen tcell.Screen, y int) string {
+	width, _ := screen.Size()
+	var line []rune
+	for x := 0; x < width; x++ {
+		r, _, _, _ := screen.GetContent(x, y)
+		line = append(line, r)
+	}
+	return string(line)
+}
+
+func TestEditorStatusLineGutter(t *testing.T) {
+	t.Parallel()
+
+	e := NewEditor()
+	b := editor.NewBufferFromString("package main\n\nfunc main() {\n}", "main.go")
+	e.SetBuffer(b)
+	app, err := newTestApp(e)
+	if err != nil {
+		t.Fatalf("failed to initialize App: %s", err)
+	}
+	e.SetRect(0, 0, 40, 6)
+	v := e.view
+
+	// Status line.
+	b.Insert(editor.Loc{X: 0, Y: 1}, "// x")
+	v.Cursor.GotoLoc(editor.Loc{X: 2, Y: 2})
+	left, right := v.StatusLine()
+	if left != "main.go + (3,3)" || right != "go | utf-8 | unix" {
+		t.Errorf("failed to format status line: got %q and %q", left, right)
+	}
+	app.root.Draw(app.screen)
+	if line := screenLine(app.screen, 5); !strings.HasPrefix(line, " main.go + (3,3)") || !strings.Contains(line, "go | utf-8 | unix ") {
+		t.Errorf("failed to draw status line: got %q", line)
+	}
+	b.Settings["statusline"] = false
+	app.root.Draw(app.screen)
+	if line := screenLine(app.screen, 5); strings.Contains(line, "main.go") {
+		t.Errorf("failed to hide status line: got %q", line)
+	}
+
+	// Relative line numbers.
+	b.Settings["relativeruler"] = true
+	app.root.Draw(app.screen)
+	var numbers string
+	for y := 0; y < 4; y++ {
+		numbers += screenLine(app.screen, y)[:1]
+	}
+	if numbers != "2131" {
+		t.Errorf("failed to draw relative line numbers: expected 2131, got %s", numbers)
+	}
+
+	// Diff and diagnostic markers.
+	b.Settings["ruler"] = false
+	b.Settings["diffgutter"] = true
+	b.SetDiagnostics([]editor.Diagnostic{{Start: editor.Loc{X: 5, Y: 2}, End: editor.Loc{X: 9, Y: 2}, Severity: editor.SeverityError, Message: "error"}})
+	b.Remove(editor.Loc{X: 0, Y: 2}, editor.Loc{X: 0, Y: 3})
+	app.root.Draw(app.screen)
+	var gutter []string
+	for y := 0; y < 3; y++ {
+		gutter = append(gutter, string([]rune(screenLine(app.screen, y))[:3]))
+	}
+	if expected := []string{"   ", "  ▌", ">>▔"}; strings.Join(gutter, "|") != strings.Join(expected, "|") {
+		t.Errorf("failed to draw gutter markers: expected %q, got %q", expected, gutter)
+	}
+}
+
+func TestEditorSplits(t *testing.T) {
+	t.Parallel()
+
+	e := NewEditor()
+	b := editor.NewBufferFromString("one\ntwo", "")
+	e.SetBuffer(b)
+	app, err := newTestApp(e)
+	if err != nil {
+		t.Fatalf("failed to initialize App: %s", err)
+	}
+	e.SetRect(0, 0, 41, 10)
+	key := func(key tcell.Key, r rune, mod tcell.ModMask) {
+		e.InputHandler()(tcell.NewEventKey(key, r, mod), func(p Widget) {})
+	}
+
+	// Horizontal split.
+	first := e.view
+	first.Cursor.GotoLoc(editor.Loc{X: 3, Y: 1})
+	e.HSplit()
+	second := e.view
+	if views := e.layout.views(); len(views) != 2 || views[0] != first || views[1] != second {
+		t.Fatal("failed to split view below")
+	}
+	app.root.Draw(app.screen)
+	if x, y, _, height := second.GetRect(); x != 0 || y != 5 || height != 5 {
+		t.Errorf("failed to position split: expected 0,5 with height 5, got %d,%d with height %d", x, y, height)
+	}
+
+	// Independent cursors, shared undo history.
+	key(tcell.KeyUp, 0, tcell.ModCtrl)
+	key(tcell.KeyRune, '1', 0)
+	if second.Cursor.Loc != (editor.Loc{X: 1, Y: 0}) || first.Cursor.Loc != (editor.Loc{X: 3, Y: 1}) {
+		t.Errorf("failed to move cursors independently: got %v and %v", first.Cursor.Loc, second.Cursor.Loc)
+	}
+	b.UndoStack.Peek().Time = time.Time{} // Undo the newline alone.
+	key(tcell.KeyEnter, 0, 0)
+	if first.Cursor.Loc != (editor.Loc{X: 3, Y: 2}) {
+		t.Errorf("failed to move cursor of other split: expected 3,2, got %v", first.Cursor.Loc)
+	}
+	key(tcell.KeyCtrlW, 0, tcell.ModCtrl)
+	if e.view != first {
+		t.Fatal("failed to focus next split")
+	}
+	key(tcell.KeyCtrlZ, 0, tcell.ModCtrl)
+	if text := b.String(); text != "1one\ntwo" {
+		t.Errorf("failed to undo in other split: got %q", text)
+	}
+
+	// Vertical split.
+	e.VSplit()
+	third := e.view
+	app.root.Draw(app.screen)
+	if x, _, width, _ := third.GetRect(); x != 21 || width != 20 {
+		t.Errorf("failed to split view to the right: expected x 21 and width 20, got %d and %d", x, width)
+	}
+	if r, _, _, _ := app.screen.GetContent(20, 0); r != Borders.Vertical {
+		t.Errorf("failed to draw split separator: got %q", r)
+	}
+
+	// Unsplit.
+	e.Unsplit()
+	if views := e.layout.views(); len(views) != 2 || e.view != first || e.layout.find(first).parent != e.layout {
+		t.Error("failed to close split")
+	}
+	e.Unsplit()
+	if views := e.layout.views(); len(views) != 1 || e.view != second || e.layout.view != second {
+		t.Error("failed to close split")
+	}
+	key(tcell.KeyRune, '2', 0)
+	if text := b.String(); text != "12one\ntwo" {
+		t.Errorf("failed to edit after closing splits: got %q", text)
+	}
+}
+
+// testProvider completes "Pr" and reports each "bad" word.
+type testProvider struct{}
+
+func (testProvider) Completions(path, text string, loc editor.Loc) ([]editor.Completion, error) {
+	return []editor.Completion{{Label: "Printf", Detail: "func"}, {Label: "Println", Detail: "func"}}, nil
+}
+
+func (testProvider) Hover(path, text string, loc editor.Loc) (string, error) {
+	return fmt.Sprintf("hover\n%d:%d", loc.Y, loc.X), nil
+}
+
+func (testProvider) Diagnostics(path, text string) ([]editor.Diagnostic, error) {
+	var diagnostics []editor.Diagnostic
+	for y, line := range strings.Split(text, "\n") {
+		if x := strings.Index(line, "bad"); x >= 0 {
+			diagnostics = append(diagnostics, editor.Diagnostic{Start: editor.Loc{X: x, Y: y}, End: editor.Loc{X: x + 3, Y: y}, Severity: editor.SeverityError, Message: "bad word"})
+		}
+	}
+	return diagnostics, nil
+}
+
+func (testProvider) Definition(path, text string, loc editor.Loc) ([]editor.Location, error) {
+	return []editor.Location{{Loc: editor.Loc{X: 4, Y: 0}}}, nil
+}
+
+func TestEditorProvider(t *testing.T) {
+	t.Parallel()
+
+	e := NewEditor()
+	b := editor.NewBufferFromString("bad fmt.Pr\nok", "")
+	b.Settings["ruler"] = false
+	e.SetBuffer(b)
+	e.SetProvider(nil, testProvider{})
+	app, err := newTestApp(e)
+	if err != nil {
+		t.Fatalf("failed to initialize App: %s", err)
+	}
+	e.SetRect(0, 0, 40, 10)
+	v := e.view
+	key := func(key tcell.Key, r rune, mod tcell.ModMask) {
+		e.InputHandler()(tcell.NewEventKey(key, r, mod), func(p Widget) {})
+	}
+
+	// Completion popup.
+	v.Cursor.GotoLoc(editor.Loc{X: 10, Y: 0})
+	key(tcell.KeyCtrlSpace, 0, tcell.ModCtrl)
+	if e.completions == nil {
+		t.Fatal("failed to show completions")
+	}
+	app.root.Draw(app.screen)
+	if line := screenLine(app.screen, 2); !strings.Contains(line, "Println  func") {
+		t.Errorf("failed to draw completions below the cursor: got %q", line)
+	}
+	key(tcell.KeyDown, 0, 0)
+	key(tcell.KeyEnter, 0, 0)
+	if text := b.String(); e.completions != nil || text != "bad fmt.Println\nok" {
+		t.Errorf("failed to insert completion: got %q", text)
+	}
+	key(tcell.KeyCtrlSpace, 0, tcell.ModCtrl)
+	key(tcell.KeyEscape, 0, 0)
+	if text := b.String(); e.completions != nil || text != "bad fmt.Println\nok" {
+		t.Errorf("failed to cancel completion: got %q", text)
+	}
+
+	// Diagnostics.
+	if err := v.UpdateDiagnostics(); err != nil {
+		t.Fatalf("failed to update diagnostics: %s", err)
+	}
+	app.root.Draw(app.screen)
+	for x, underlined := range []bool{true, true, true, false} {
+		// Text starts after the two columns of diagnostic markers.
+		_, _, style, _ := app.screen.GetContent(2+x, 0)
+		if (style.GetUnderlineStyle() == tcell.UnderlineStyleCurly) != underlined {
+			t.Errorf("failed to underline diagnostic: column %d has underline %d", x, style.GetUnderlineStyle())
+		}
+	}
+
+	// Hover.
+	v.Cursor.GotoLoc(editor.Loc{X: 1, Y: 0})
+	key(tcell.KeyRune, 'i', tcell.ModAlt)
+	if e.message != "bad word" {
+		t.Errorf("failed to show diagnostic: got %q", e.message)
+	}
+	v.Cursor.GotoLoc(editor.Loc{X: 1, Y: 1})
+	key(tcell.KeyRune, 'i', tcell.ModAlt)
+	if e.message != "hover 1:1" {
+		t.Errorf("failed to show hover: got %q", e.message)
+	}
+
+	// Go to definition.
+	key(tcell.KeyF12, 0, 0)
+	if v.Cursor.Loc != (editor.Loc{X: 4, Y: 0}) {
+		t.Errorf("failed to go to definition: expected 4,0, got %v", v.Cursor.Loc)
+	}
+
+	// Documents are closed when the buffer or the provider is replaced.
+	var closed []string
+	e.SetBuffer(editor.NewBufferFromString("", "a.go"))
+	e.SetProvider(nil, closingProvider{closed: &closed})
+	e.SetBuffer(editor.NewBufferFromString("", "b.go"))
+	e.SetBuffer(e.view.Buf)
+	e.SetProvider(nil, testProvider{})
+	if fmt.Sprint(closed) != "[a.go b.go]" {
+		t.Errorf("failed to close documents: expected [a.go b.go], got %v", closed)
+	}
+}
+
+// closingProvider records the paths of closed documents.
+type closingProvider struct {
+	testProvider
+	closed *[]string
+}
+
+func (p closingProvider) CloseDocument(path string) error {
+	*p.closed = append(*p.closed, path)
+	return nil
+}
+
+// blockingProvider answers hovers when released.
+type blockingProvider struct {
+	testProvider
+	release chan struct{}
+}
+
+func (p blockingProvider) Hover(path, text string, loc editor.Loc) (string, error) {
+	select {
+	case <-p.release:
+		return "released", nil
+	case <-time.After(5 * time.Second):
+		return "", fmt.Errorf("not released")
+	}
+}
+
+func TestEditorProviderAsync(t *testing.T) {
+	t.Parallel()
+
+	e := NewEditor()
+	e.SetBuffer(editor.NewBufferFromString("one two", ""))
+	app, err := newTestApp(e)
+	if err != nil {
+		t.Fatalf("failed to initialize App: %s", err)
+	}
+	if err := app.screen.Init(); err != nil {
+		t.Fatalf("failed to initialize screen: %s", err)
+	}
+	provider := blockingProvider{release: make(chan struct{})}
+	e.SetProvider(app, provider)
+	key := func(key tcell.Key, r rune, mod tcell.ModMask) {
+		e.InputHandler()(tcell.NewEventKey(key, r, mod), func(p Widget) {})
+	}
+	// runUpdates runs the updates queued in the application until the
+	// condition is met.
+	runUpdates := func(condition func() bool) bool {
+		timeout := time.After(5 * time.Second)
+		for !condition() {
+			select {
+			case update := <-app.updates:
+				update()
+			case <-timeout:
+				return false
+			}
+		}
+		return true
+	}
+
+	// The provider is called in the background and the result is applied
+	// in the event loop.
+	key(tcell.KeyRune, 'i', tcell.ModAlt)
+	if e.message != "" {
+		t.Errorf("failed to call provider in the background: got message %q", e.message)
+	}
+	close(provider.release)
+	if !runUpdates(func() bool { return e.message == "released" }) {
+		t.Errorf("failed to apply hover: got message %q", e.message)
+	}
+
+	// Results are discarded if the cursor moved meanwhile.
+	e.message = ""
+	var applied bool
+	e.view.SetAsyncFunc(func(work func() func()) {
+		apply := work()
+		key(tcell.KeyRight, 0, 0)
+		apply()
+		applied = true
+	})
+	key(tcell.KeyRune, 'i', tcell.ModAlt)
+	if !applied || e.message != "" {
+		t.Errorf("failed to discard outdated hover: got message %q", e.message)
+	}
+}
+
+func TestEditorLSPProvider(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	p, err := editor.NewLSPProvider(dir, os.Args[0], "-test.run=^TestEditorFakeLanguageServer$", "--", "fake-language-server")
+	if err != nil {
+		t.Fatalf("failed to start language server: %s", err)
+	}
+	defer p.Close()
+
+	// The emoji takes two UTF-16 code units.
+	text := "😀 bad\nfoo"
+	diagnostics, err := p.Diagnostics(path, text)
+	if err != nil {
+		t.Fatalf("failed to get diagnostics: %s", err)
+	}
+	expected := []editor.Diagnostic{{Start: editor.Loc{X: 2, Y: 0}, End: editor.Loc{X: 5, Y: 0}, Severity: editor.SeverityWarning, Message: "bad word"}}
+	if fmt.Sprint(diagnostics) != fmt.Sprint(expected) {
+		t.Errorf("failed to get diagnostics: expected %v, got %v", expected, diagnostics)
+	}
+	text = "😀 ok\nfoo"
+	if diagnostics, err = p.Diagnostics(path, text); err != nil || len(diagnostics) != 0 {
+		t.Errorf("failed to get diagnostics of changed text: got %v (%v)", diagnostics, err)
+	}
+
+	completions, err := p.Completions(path, text, editor.Loc{X: 4, Y: 0})
+	if err != nil {
+		t.Fatalf("failed to get completions: %s", err)
+	}
+	if fmt.Sprint(completions) != "[{Println func Println} {Printf  Printf(}]" {
+		t.Errorf("failed to get completions: got %v", completions)
+	}
+
+	hover, err := p.Hover(path, text, editor.Loc{X: 4, Y: 0})
+	if err != nil || hover != "0:5" {
+		t.Errorf("failed to get hover: expected 0:5, got %q (%v)", hover, err)
+	}
+
+	locations, err := p.Definition(path, text, editor.Loc{X: 1, Y: 1})
+	if err != nil {
+		t.Fatalf("failed to get definition: %s", err)
+	}
+	if len(locations) != 1 || locations[0].Path != path || locations[0].Loc != (editor.Loc{X: 2, Y: 0}) {
+		t.Errorf("failed to get definition: got %v", locations)
+	}
+
+	// Closed documents are opened again when they are used. The server
+	// answers the closing with invalid messages, which are dropped.
+	if err := p.CloseDocument(path); err != nil {
+		t.Fatalf("failed to close document: %s", err)
+	}
+	if hover, err := p.Hover(path, text, editor.Loc{X: 4, Y: 0}); err != nil || hover != "0:5" {
+		t.Errorf("failed to get hover of reopened document: expected 0:5, got %q (%v)", hover, err)
+	}
+
+	if err := p.Close(); err != nil {
+		t.Errorf("failed to stop language server: %s", err)
+	}
+
+	// Huge messages are rejected.
+	p, err = editor.NewLSPProvider(dir, os.Args[0], "-test.run=^TestEditorFakeLanguageServer$", "--", "fake-language-server")
+	if err != nil {
+		t.Fatalf("failed to start language server: %s", err)
+	}
+	if _, err := p.Completions(path, "huge", editor.Loc{}); err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Errorf("failed to reject huge message: got %v", err)
+	}
+}
+
+// TestEditorFakeLanguageServer is the language server of TestEditorLSPProvider.
+// It reports each "bad" word, completes "Print", echoes the position of hovers
+// and defines everything at the start of the first "ok" or "bad". It exits
+// when documents are opened, changed or closed out of order.
+func TestEditorFakeLanguageServer(t *testing.T) {
+	if args := flag.Args(); len(args) != 1 || args[0] != "fake-language-server" {
+		t.Skip("started by TestEditorLSPProvider")
+	}
+
+	r, w := bufio.NewReader(os.Stdin), os.Stdout
+	send := func(msg map[string]interface{}) {
+		msg["jsonrpc"] = "2.0"
+		data, _ := json.Marshal(msg)
+		fmt.Fprintf(w, "Content-Length: %d\r\n\r\n%s", len(data), data)
+	}
+	position := func(line string, column int) int {
+		// Columns are counted in UTF-16 code units.
+		x := 0
+		for _, r := range line[:column] {
+			x++
+			if r > 0xffff {
+				x++
+			}
+		}
+		return x
+	}
+	texts := make(map[string]string)
+	for {
+		length := 0
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				os.Exit(1)
+			}
+			if line = strings.TrimSpace(line); line == "" {
+				break
+			}
+			if value, ok := strings.CutPrefix(line, "Content-Length: "); ok {
+				length, _ = strconv.Atoi(value)
+			}
+		}
+		data := make([]byte, length)
+		if _, err := io.ReadFull(r, data); err != nil {
+			os.Exit(1)
+		}
+		var msg struct {
+			ID     *json.RawMessage `json:"id"`
+			Method string           `json:"method"`
+			Params struct {
+				TextDocument struct {
+					URI     string `json:"uri"`
+					Version int    `json:"version"`
+					Text    string `json:"text"`
+				} `json:"textDocument"`
+				ContentChanges []struct {
+					Text string `json:"text"`
+				} `json:"contentChanges"`
+				Position struct {
+					Line      int `json:"line"`
+					Character int `json:"character"`
+				} `json:"position"`
+			} `json:"params"`
+		}
+		if err := json.Unmarshal(data, &msg); err != nil {
+			os.Exit(1)
+		}
+		doc := msg.Params.TextDocument
+		reply := func(result interface{}) {
+			send(map[string]interface{}{"id": msg.ID, "result": result})
+		}
+
+		switch msg.Method {
+		case "initialize":
+			reply(map[string]interface{}{"capabilities": map[string]interface{}{}})
+		case "textDocument/didOpen", "textDocument/didChange":
+			if _, open := texts[doc.URI]; open != (msg.Method == "textDocument/didChange") {
+				os.Exit(1)
+			}
+			text := doc.Text
+			if len(msg.Params.ContentChanges) > 0 {
+				text = msg.Params.ContentChanges[0].Text
+			}
+			texts[doc.URI] = text
+
+			// Requests of the server are answered by the client.
+			send(map[string]interface{}{"id": "config", "method": "workspace/configuration", "params": map[string]interface{}{}})
+
+			diagnostics := []interface{}{}
+			for y, line := range strings.Split(text, "\n") {
+				if x := strings.Index(line, "bad"); x >= 0 {
+					diagnostics = append(diagnostics, map[string]interface{}{
+						"range": map[string]interface{}{
+							"start": map[string]int{"line": y, "character": position(line, x)},
+							"end":   map[string]int{"line": y, "character": position(line, x+3)},
+						},
+						"severity": 2,
+						"message":  "bad word",
+					})
+				}
+			}
+			send(map[string]interface{}{"method": "textDocument/publishDiagnostics", "params": map[string]interface{}{
+				"uri": doc.URI, "version": doc.Version, "diagnostics": diagnostics,
+			}})
+		case "textDocument/didClose":
+			if _, open := texts[doc.URI]; !open {
+				os.Exit(1)
+			}
+			delete(texts, doc.URI)
+
+			// Answer with an error response without an ID and a message
+			// which is not JSON.
+			send(map[string]interface{}{"id": nil, "error": map[string]interface{}{"code": -32700, "message": "parse error"}})
+			fmt.Fprint(w, "Content-Length: 3\r\n\r\n{x}")
+		case "textDocument/completion":
+			if strings.Contains(texts[doc.URI], "huge") {
+				fmt.Fprintf(w, "Content-Length: %d\r\n\r\n", 1<<30)
+				break
+			}
+			reply(map[string]interface{}{"isIncomplete": false, "items": []interface{}{
+				map[string]interface{}{"label": "Println", "detail": "func", "textEdit": map[string]interface{}{"newText": "Println"}},
+				map[string]interface{}{"label": "Printf", "insertText": "Printf("},
+			}})
+		case "textDocument/hover":
+			if _, open := texts[doc.URI]; !open {
+				os.Exit(1)
+			}
+			reply(map[string]interface{}{"contents": map[string]interface{}{
+				"kind": "plaintext", "value": fmt.Sprintf("%d:%d", msg.Params.Position.Line, msg.Params.Position.Character),
+			}})
+		case "textDocument/definition":
+			line := strings.Split(texts[doc.URI], "\n")[0]
+			x := max(strings.Index(line, "ok"), strings.Index(line, "bad"))
+			reply([]interface{}{map[string]interface{}{"uri": doc.URI, "range": map[string]interface{}{
+				"start": map[string]int{"line": 0, "character": position(line, x)},
+				"end":   map[string]int{"line": 0, "character": position(line, x)},
+			}}})
+		case "shutdown":
+			reply(nil)
+		case "exit":
+			os.Exit(0)
+		default:
+			if msg.ID != nil && msg.Method != "" {
+				send(map[string]interface{}{"id": msg.ID, "error": map[string]interface{}{"code": -32601, "message": "method not found"}})
+			}
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package cui
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Filter input actions.
+const (
+	filterNone int = iota
+	filterChanged
+	filterAccept
+	filterCancel
+)
+
+// FuzzyMatch reports whether all characters of the pattern appear in the text
+// in the same order. The match is case-insensitive unless the pattern contains
+// upper case characters. The returned score ranks matches against each other:
+// consecutive characters and characters at the start of words score higher,
+// gaps between matched characters lower. The returned positions are the rune
+// indices of the matched characters in the text.
+func FuzzyMatch(pattern, text string) (score int, positions []int, ok bool) {
+	patternRunes, textRunes := []rune(pattern), []rune(text)
+	if len(patternRunes) == 0 {
+		return 0, nil, true
+	}
+	caseSensitive := strings.ToLower(pattern) != pattern
+	equal := func(a, b rune) bool {
+		if caseSensitive {
+			return a == b
+		}
+		return unicode.ToLower(a) == unicode.ToLower(b)
+	}
+
+	// Find the end of the first match.
+	end, next := -1, 0
+	for index, r := range textRunes {
+		if equal(r, patternRunes[next]) {
+			next++
+			if next == len(patternRunes) {
+				end = index
+				break
+			}
+		}
+	}
+	if end < 0 {
+		return 0, nil, false
+	}
+
+	// Scan backwards for the shortest match with that end.
+	start, next := 0, len(patternRunes)-1
+	for index := end; index >= 0; index-- {
+		if equal(textRunes[index], patternRunes[next]) {
+			next--
+			if next < 0 {
+				start = index
+				break
+			}
+		}
+	}
+
+	// Collect and score the matched characters.
+	positions = make([]int, 0, len(patternRunes))
+	for index := start; index <= end && len(positions) < len(patternRunes); index++ {
+		if !equal(textRunes[index], patternRunes[len(positions)]) {
+			continue
+		}
+		score += 16
+		if len(positions) > 0 && positions[len(positions)-1] == index-1 {
+			score += 8
+		}
+		if index == 0 || !isWordRune(textRunes[index-1]) || unicode.IsLower(textRunes[index-1]) && unicode.IsUpper(textRunes[index]) {
+			score += 8
+		}
+		positions = append(positions, index)
+	}
+	score -= end - start + 1 - len(patternRunes)
+	return score, positions, true
+}
+
+// handleFilterKey edits the query of an inline filter with the given key event
+// and returns the resulting action. Keys which do not edit the query, e.g.
+// navigation keys, return filterNone. Backspace on an empty query cancels the
+// filter.
+func handleFilterKey(event *tcell.EventKey, query *[]rune) int {
+	if HitShortcut(event, Keys.Cancel) {
+		return filterCancel
+	} else if HitShortcut(event, Keys.Select) {
+		return filterAccept
+	}
+
+	switch event.Key() {
+	case tcell.KeyRune:
+		*query = append(*query, event.Rune())
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if len(*query) == 0 {
+			return filterCancel
+		}
+		*query = (*query)[:len(*query)-1]
+	case tcell.KeyCtrlU:
+		*query = nil
+	case tcell.KeyCtrlW:
+		length := len(*query)
+		for length > 0 && !isWordRune((*query)[length-1]) {
+			length--
+		}
+		for length > 0 && isWordRune((*query)[length-1]) {
+			length--
+		}
+		*query = (*query)[:length]
+	default:
+		return filterNone
+	}
+	return filterChanged
+}
+
+// drawFilterQuery draws the query line of an inline filter, followed by the
+// number of shown and total items.
+func drawFilterQuery(screen tcell.Screen, x, y, width int, query []rune, count, total int, hasFocus bool) {
+	style := tcell.StyleDefault.Background(Styles.MoreContrastBackgroundColor).Foreground(Styles.PrimaryTextColor)
+	for index := 0; index < width; index++ {
+		screen.SetContent(x+index, y, ' ', nil, style)
+	}
+
+	status := fmt.Sprintf("%d/%d", count, total)
+	PrintStyle(screen, []byte(status), x, y, width, AlignRight, style.Foreground(Styles.ContrastSecondaryTextColor))
+	_, queryWidth := PrintStyle(screen, EscapeBytes([]byte("/"+string(query))), x, y, width-len(status)-1, AlignLeft, style)
+
+	if hasFocus && queryWidth < width {
+		screen.ShowCursor(x+queryWidth, y)
+	}
+}
+
+// shiftMatches returns the match positions of a text after removing its
+// first n runes.
+func shiftMatches(positions []int, n int) []int {
+	var shifted []int
+	for _, position := range positions {
+		if position >= n {
+			shifted = append(shifted, position-n)
+		}
+	}
+	return shifted
+}
+
+// highlightMatches returns the given text, which must not contain tags, with
+// the characters at the given rune positions highlighted in
+// Styles.FilterMatchColor.
+func highlightMatches(text []byte, positions []int) []byte {
+	var (
+		highlighted bytes.Buffer
+		segment     []rune
+		matched     bool
+	)
+	tag := fmt.Sprintf("[%s::b]", ColorHex(Styles.FilterMatchColor))
+	flush := func() {
+		if len(segment) == 0 {
+			return
+		}
+		if matched {
+			highlighted.WriteString(tag)
+		}
+		highlighted.WriteString(Escape(string(segment)))
+		if matched {
+			highlighted.WriteString("[-::-]")
+		}
+		segment = segment[:0]
+	}
+
+	var next int
+	for index, r := range []rune(string(text)) {
+		isMatch := next < len(positions) && positions[next] == index
+		if isMatch {
+			next++
+		}
+		if isMatch != matched {
+			flush()
+			matched = isMatch
+		}
+		segment = append(segment, r)
+	}
+	flush()
+
+	return highlighted.Bytes()
+}
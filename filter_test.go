@@ -0,0 +1,42 @@
+package cui
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range []struct {
+		pattern, text string
+		positions     []int
+		ok            bool
+	}{
+		{"", "anything", nil, true},
+		{"fb", "foo bar", []int{0, 4}, true},
+		{"bar", "foo bar", []int{4, 5, 6}, true},
+		{"oba", "foo bar", []int{2, 4, 5}, true},
+		{"FB", "foo bar", nil, false},
+		{"Fb", "FooBar", nil, false},
+		{"fb", "FooBar", []int{0, 3}, true},
+		{"xyz", "foo bar", nil, false},
+	} {
+		_, positions, ok := FuzzyMatch(test.pattern, test.text)
+		if ok != test.ok || !reflect.DeepEqual(positions, test.positions) && len(test.positions) > 0 {
+			t.Errorf("failed to match %q in %q: expected %v %v, got %v %v", test.pattern, test.text, test.ok, test.positions, ok, positions)
+		}
+	}
+
+	// Consecutive characters and word starts rank higher than scattered ones
+	consecutive, _, _ := FuzzyMatch("bar", "foo bar")
+	scattered, _, _ := FuzzyMatch("bar", "bxaxr")
+	if consecutive <= scattered {
+		t.Errorf("failed to rank matches: expected %d > %d", consecutive, scattered)
+	}
+	wordStart, _, _ := FuzzyMatch("fb", "foo_bar")
+	inner, _, _ := FuzzyMatch("fb", "xfxxbx")
+	if wordStart <= inner {
+		t.Errorf("failed to rank matches: expected %d > %d", wordStart, inner)
+	}
+}
@@ -1,7 +1,13 @@
 package cui
 
 import (
+	"errors"
+	"fmt"
+	"math"
 	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/gdamore/tcell/v2"
@@ -79,6 +85,21 @@ type Form struct {
 	// An optional function which is called when the user hits Escape.
 	cancel func()
 
+	// The validators of the items.
+	validators map[Widget][]FormValidator
+
+	// The errors of the invalid items as of the last time they were validated.
+	errors map[Widget]error
+
+	// The color of the labels and field notes of invalid items.
+	invalidColor tcell.Color
+
+	// An optional function which is called when the form was submitted.
+	submit func()
+
+	// The struct fields bound to items.
+	bindings []*formBinding
+
 	mu sync.RWMutex
 }
 
@@ -100,6 +121,7 @@ func NewForm() *Form {
 		buttonTextColor:              Styles.PrimaryTextColor,
 		buttonTextColorFocused:       Styles.PrimaryTextColor,
 		labelColorFocused:            ColorUnset,
+		invalidColor:                 Styles.FormInvalidColor,
 	}
 
 	f.box.focus = f
@@ -333,7 +355,7 @@ func (f *Form) Focus(delegate func(p Widget)) {
 		// We're selecting an item.
 		item := f.items[f.focusedElement]
 
-		attributes := f.getAttributes()
+		attributes := f.getItemAttributes(item)
 		attributes.FinishedFunc = f.formItemInputHandler(delegate)
 
 		f.mu.Unlock()
@@ -561,6 +583,30 @@ func (f *Form) AddInputField(label, value string, fieldWidth int, accept func(te
 	return f
 }
 
+// AddTextArea adds a multi-line text area to the form. It has a label, an
+// optional initial value, a field width (a value of 0 extends it as far as
+// possible), a field height (the number of lines, a value of 0 uses the
+// default), a maximum number of characters (a value of 0 means no limit), and
+// an (optional) callback function which is invoked when the text has changed.
+func (f *Form) AddTextArea(label, value string, fieldWidth, fieldHeight, maxLength int, changed func(text string)) *Form {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	textArea := NewTextArea()
+	textArea.SetLabel(label)
+	textArea.SetText(value)
+	textArea.SetFieldWidth(fieldWidth)
+	if fieldHeight > 0 {
+		textArea.SetFieldHeight(fieldHeight)
+	}
+	textArea.SetMaxLength(maxLength)
+	textArea.SetChangedFunc(changed)
+
+	f.items = append(f.items, textArea)
+
+	return f
+}
+
 // AddPasswordField adds a password field to the form. This is similar to an
 // input field except that the user's input not shown. Instead, a "mask"
 // character is displayed. The password field has a label, an optional initial
@@ -716,6 +762,8 @@ func (f *Form) Clear(includeButtons bool) *Form {
 		f.buttons = nil
 	}
 	f.focusedElement = 0
+	f.errors = nil
+	f.bindings = nil
 
 	return f
 }
@@ -863,6 +911,393 @@ func (f *Form) SetCancelFunc(callback func()) {
 	f.cancel = callback
 }
 
+// FormValidator checks the value of a form item and returns an error which
+// describes why the value is invalid, or nil. The value of an Input is its
+// text, of a DropDown the text of the current option, of a CheckBox "true" if
+// it is checked and "" otherwise, and of a Slider its progress.
+type FormValidator func(value string) error
+
+// ValidateRequired returns a validator which rejects empty values, including
+// unchecked check boxes.
+func ValidateRequired() FormValidator {
+	return func(value string) error {
+		if strings.TrimSpace(value) == "" {
+			return errors.New("required")
+		}
+		return nil
+	}
+}
+
+// ValidateRegexp returns a validator which rejects non-empty values not
+// matching the regular expression with the given message.
+func ValidateRegexp(pattern *regexp.Regexp, message string) FormValidator {
+	return func(value string) error {
+		if value != "" && !pattern.MatchString(value) {
+			return errors.New(message)
+		}
+		return nil
+	}
+}
+
+// ValidateRange returns a validator which rejects non-empty values which are
+// no numbers between min and max, inclusive.
+func ValidateRange(min, max float64) FormValidator {
+	return func(value string) error {
+		if value == "" {
+			return nil
+		}
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return errors.New("not a number")
+		}
+		if number < min || number > max {
+			return fmt.Errorf("must be between %g and %g", min, max)
+		}
+		return nil
+	}
+}
+
+// SetValidators sets the validators of a form item. The item is validated
+// when the user leaves it and when the form is submitted. While it is
+// invalid, its label is drawn in the invalid color and an Input shows the
+// error as its field note.
+func (f *Form) SetValidators(item Widget, validators ...FormValidator) *Form {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.validators == nil {
+		f.validators = make(map[Widget][]FormValidator)
+	}
+	f.validators[item] = validators
+	return f
+}
+
+// SetInvalidColor sets the color of the labels and field notes of invalid
+// items.
+func (f *Form) SetInvalidColor(color tcell.Color) *Form {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.invalidColor = color
+	return f
+}
+
+// Validate validates all items of the form and returns whether they are
+// valid.
+func (f *Form) Validate() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.validate()
+}
+
+func (f *Form) validate() bool {
+	valid := true
+	for _, item := range f.items {
+		if f.validateItem(item) != nil {
+			valid = false
+		}
+	}
+	return valid
+}
+
+// GetError returns the error of the form item at the given index as of the
+// last time it was validated, or nil if it is valid.
+func (f *Form) GetError(index int) error {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if index < 0 || index >= len(f.items) {
+		return nil
+	}
+	return f.errors[f.items[index]]
+}
+
+// validateItem validates a form item, shows the result and returns the error.
+func (f *Form) validateItem(item Widget) error {
+	validators := f.validators[item]
+	if len(validators) == 0 {
+		return nil
+	}
+
+	var err error
+	value := getFormItemValue(item)
+	for _, validator := range validators {
+		if err = validator(value); err != nil {
+			break
+		}
+	}
+
+	_, wasInvalid := f.errors[item]
+	if err == nil {
+		delete(f.errors, item)
+	} else {
+		if f.errors == nil {
+			f.errors = make(map[Widget]error)
+		}
+		f.errors[item] = err
+	}
+	if input, ok := item.(*Input); ok {
+		if err != nil {
+			input.SetFieldNoteTextColor(f.invalidColor)
+			input.SetFieldNote(err.Error())
+		} else if wasInvalid {
+			input.ResetFieldNote()
+		}
+	}
+	return err
+}
+
+// SetSubmitFunc sets a handler which is called when the form is submitted and
+// all items are valid. Bound structs are updated before.
+func (f *Form) SetSubmitFunc(handler func()) *Form {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.submit = handler
+	return f
+}
+
+// AddSubmitButton adds a button to the form which submits it.
+func (f *Form) AddSubmitButton(label string) *Form {
+	return f.AddButton(label, func() { f.Submit() })
+}
+
+// Submit validates the form. If all items are valid, the values of the items
+// are written to the bound structs and the submit handler is called. Submit
+// returns whether the form was submitted.
+func (f *Form) Submit() bool {
+	f.mu.Lock()
+	if !f.validate() {
+		// Focus the first invalid item the next time the form is focused.
+		for index, item := range f.items {
+			if f.errors[item] != nil {
+				f.focusedElement = index
+				break
+			}
+		}
+		f.mu.Unlock()
+		return false
+	}
+	for _, binding := range f.bindings {
+		binding.store()
+	}
+	submit := f.submit
+	f.mu.Unlock()
+
+	if submit != nil {
+		submit()
+	}
+	return true
+}
+
+// Bind adds a form item for each field of the struct pointed to by value
+// which has a "cui" tag, in the order of the fields, and shows the values of
+// the fields. The fields are updated when the form is submitted. The tag is a
+// comma-separated list of options:
+//
+//   - label=Text: The label of the item. Defaults to the field name.
+//   - width=N: The field width of the item.
+//   - required: The item must not be empty.
+//   - min=N, max=N: The number must be in this range.
+//   - regexp=Pattern: The text must match this pattern, which cannot contain
+//     commas.
+//   - options=A|B|C: A string is chosen from these options with a DropDown.
+//   - password: A string is entered with a masked Input.
+//
+// Strings and numbers are edited with an Input, booleans with a CheckBox.
+// Fields of other types cause an error.
+//
+//	type Settings struct {
+//		Name  string `cui:"label=Name,required"`
+//		Port  int    `cui:"label=Port,min=1,max=65535"`
+//		Debug bool   `cui:"label=Debug"`
+//	}
+func (f *Form) Bind(value interface{}) error {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("cannot bind %T: expected pointer to struct", value)
+	}
+	v = v.Elem()
+
+	var bindings []*formBinding
+	for index := 0; index < v.NumField(); index++ {
+		field := v.Type().Field(index)
+		tag, ok := field.Tag.Lookup("cui")
+		if !ok || tag == "-" || !field.IsExported() {
+			continue
+		}
+		binding, err := newFormBinding(field, v.Field(index), tag)
+		if err != nil {
+			return err
+		}
+		bindings = append(bindings, binding)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, binding := range bindings {
+		f.items = append(f.items, binding.item)
+		if len(binding.validators) > 0 {
+			if f.validators == nil {
+				f.validators = make(map[Widget][]FormValidator)
+			}
+			f.validators[binding.item] = binding.validators
+		}
+	}
+	f.bindings = append(f.bindings, bindings...)
+	return nil
+}
+
+// formBinding connects a form item with a struct field.
+type formBinding struct {
+	item       Widget
+	field      reflect.Value
+	validators []FormValidator
+}
+
+// newFormBinding creates the form item of a struct field from its tag.
+func newFormBinding(field reflect.StructField, value reflect.Value, tag string) (*formBinding, error) {
+	label, width, password := field.Name, 0, false
+	var options []string
+	var validators []FormValidator
+	min, max := math.Inf(-1), math.Inf(1)
+	for _, option := range strings.Split(tag, ",") {
+		key, arg, _ := strings.Cut(strings.TrimSpace(option), "=")
+		var err error
+		switch key {
+		case "":
+		case "label":
+			label = arg
+		case "width":
+			width, err = strconv.Atoi(arg)
+		case "required":
+			validators = append(validators, ValidateRequired())
+		case "min":
+			min, err = strconv.ParseFloat(arg, 64)
+		case "max":
+			max, err = strconv.ParseFloat(arg, 64)
+		case "regexp":
+			var pattern *regexp.Regexp
+			if pattern, err = regexp.Compile(arg); err == nil {
+				validators = append(validators, ValidateRegexp(pattern, "must match "+arg))
+			}
+		case "options":
+			options = strings.Split(arg, "|")
+		case "password":
+			password = true
+		default:
+			err = errors.New("unknown option")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot bind field %s: invalid tag option %q: %w", field.Name, option, err)
+		}
+	}
+
+	b := &formBinding{field: value}
+	switch value.Kind() {
+	case reflect.String:
+		if options != nil {
+			dropDown := NewDropDown().SetLabel(label).SetOptionsSimple(nil, options...)
+			dropDown.SetFieldWidth(width)
+			dropDown.SetCurrentOption(-1)
+			for index, option := range options {
+				if option == value.String() {
+					dropDown.SetCurrentOption(index)
+				}
+			}
+			b.item = dropDown
+			break
+		}
+		input := NewInputField().SetLabel(label).SetFieldWidth(width).SetText(value.String())
+		if password {
+			input.SetMaskCharacter('*')
+		}
+		b.item = input
+	case reflect.Bool:
+		b.item = NewCheckBox().SetLabel(label).SetChecked(value.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		b.item = NewInputField().SetLabel(label).SetFieldWidth(width).
+			SetText(strconv.FormatInt(value.Int(), 10)).
+			SetAcceptanceFunc(InputFieldInteger)
+		bits := value.Type().Bits()
+		min = math.Max(min, -math.Ldexp(1, bits-1))
+		max = math.Min(max, math.Ldexp(1, bits-1)-1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		b.item = NewInputField().SetLabel(label).SetFieldWidth(width).
+			SetText(strconv.FormatUint(value.Uint(), 10)).
+			SetAcceptanceFunc(InputFieldInteger)
+		min = math.Max(min, 0)
+		max = math.Min(max, math.Ldexp(1, value.Type().Bits())-1)
+	case reflect.Float32, reflect.Float64:
+		b.item = NewInputField().SetLabel(label).SetFieldWidth(width).
+			SetText(strconv.FormatFloat(value.Float(), 'g', -1, value.Type().Bits())).
+			SetAcceptanceFunc(InputFieldFloat)
+		if value.Kind() == reflect.Float32 {
+			min = math.Max(min, -math.MaxFloat32)
+			max = math.Min(max, math.MaxFloat32)
+		}
+	default:
+		return nil, fmt.Errorf("cannot bind field %s: unsupported type %s", field.Name, field.Type)
+	}
+	if number := value.Kind() != reflect.String && value.Kind() != reflect.Bool; number {
+		// The range defaults to the values of the type of the field.
+		validators = append(validators, ValidateRange(min, max), b.validateNumber(min, max))
+	}
+	b.validators = validators
+	return b, nil
+}
+
+// validateNumber returns a validator which checks that the value fits into
+// the struct field, which the range may not ensure at the precision of a
+// float64, e.g. at the limits of int64.
+func (b *formBinding) validateNumber(min, max float64) FormValidator {
+	kind, bits := b.field.Kind(), b.field.Type().Bits()
+	return func(value string) error {
+		if value == "" {
+			return nil
+		}
+		var err error
+		switch kind {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			_, err = strconv.ParseInt(value, 10, bits)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			_, err = strconv.ParseUint(value, 10, bits)
+		default:
+			_, err = strconv.ParseFloat(value, bits)
+		}
+		if err != nil {
+			return fmt.Errorf("must be between %g and %g", min, max)
+		}
+		return nil
+	}
+}
+
+// store writes the value of the form item to the struct field. The value was
+// validated before.
+func (b *formBinding) store() {
+	value := getFormItemValue(b.item)
+	switch b.field.Kind() {
+	case reflect.String:
+		b.field.SetString(value)
+	case reflect.Bool:
+		b.field.SetBool(value != "")
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if number, err := strconv.ParseInt(value, 10, b.field.Type().Bits()); err == nil || value == "" {
+			b.field.SetInt(number)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if number, err := strconv.ParseUint(value, 10, b.field.Type().Bits()); err == nil || value == "" {
+			b.field.SetUint(number)
+		}
+	case reflect.Float32, reflect.Float64:
+		if number, err := strconv.ParseFloat(value, b.field.Type().Bits()); err == nil || value == "" {
+			b.field.SetFloat(number)
+		}
+	}
+}
+
 // GetAttributes returns the current attribute settings of a form.
 func (f *Form) GetAttributes() *FormItemAttributes {
 	f.mu.Lock()
@@ -896,6 +1331,16 @@ func (f *Form) getAttributes() *FormItemAttributes {
 	return attrs
 }
 
+// getItemAttributes returns the attributes of a form item, which differ for
+// invalid items.
+func (f *Form) getItemAttributes(item Widget) *FormItemAttributes {
+	attrs := f.getAttributes()
+	if f.errors[item] != nil {
+		attrs.LabelColor, attrs.LabelColorFocused = f.invalidColor, f.invalidColor
+	}
+	return attrs
+}
+
 // Draw draws this primitive onto the screen.
 func (f *Form) Draw(screen tcell.Screen) {
 	if !f.GetVisible() {
@@ -964,7 +1409,7 @@ func (f *Form) Draw(screen tcell.Screen) {
 			itemWidth = rightLimit - x
 		}
 
-		attributes := f.getAttributes()
+		attributes := f.getItemAttributes(item)
 		attributes.LabelWidth = labelWidth
 		attributes.Apply(item)
 
@@ -975,6 +1420,9 @@ func (f *Form) Draw(screen tcell.Screen) {
 		positions[index].y = y
 		positions[index].width = itemWidth
 		positions[index].height = 1
+		if height := getFormItemFieldHeight(item); height > 1 {
+			positions[index].height = height
+		}
 		if item.GetFocusable().HasFocus() {
 			focusedPosition = positions[index]
 		}
@@ -1150,6 +1598,11 @@ func (f *Form) formItemInputHandler(delegate func(p Widget)) func(key tcell.Key)
 	return func(key tcell.Key) {
 		f.mu.Lock()
 
+		// Validate the item the user leaves.
+		if f.focusedElement >= 0 && f.focusedElement < len(f.items) && key != tcell.KeyEscape {
+			f.validateItem(f.items[f.focusedElement])
+		}
+
 		switch key {
 		case tcell.KeyTab, tcell.KeyEnter:
 			f.focusedElement++
@@ -1249,6 +1702,8 @@ func (attrs *FormItemAttributes) Apply(item Widget) {
 		attrs.applyToCheckBox(item.(*CheckBox))
 	case *Slider:
 		attrs.applyToSlider(item.(*Slider))
+	case *TextArea:
+		attrs.applyToTextArea(item.(*TextArea))
 	}
 }
 
@@ -1305,6 +1760,20 @@ func (attrs *FormItemAttributes) applyToCheckBox(item *CheckBox) {
 	}
 }
 
+func (attrs *FormItemAttributes) applyToTextArea(item *TextArea) {
+	item.SetLabelWidth(attrs.LabelWidth)
+	item.SetBackgroundColor(attrs.BackgroundColor)
+	item.SetLabelColor(attrs.LabelColor)
+	item.SetLabelColorFocused(attrs.LabelColorFocused)
+	item.SetFieldTextColor(attrs.FieldTextColor)
+	item.SetFieldTextColorFocused(attrs.FieldTextColorFocused)
+	item.SetFieldBackgroundColor(attrs.FieldBackgroundColor)
+	item.SetFieldBackgroundColorFocused(attrs.FieldBackgroundColorFocused)
+	if attrs.FinishedFunc != nil {
+		item.SetFinishedFunc(attrs.FinishedFunc)
+	}
+}
+
 // getFormItemLabel returns the item's label text.
 func getFormItemLabel[T Widget](widget T) string {
 	if getter, ok := Widget(widget).(interface{ GetLabel() string }); ok {
@@ -1331,3 +1800,24 @@ func getFormItemFieldHeight[T Widget](widget T) int {
 	}
 	return 0
 }
+
+// getFormItemValue returns the value of a form item which is validated.
+func getFormItemValue(item Widget) string {
+	switch item := item.(type) {
+	case *Input:
+		return item.GetText()
+	case *TextArea:
+		return item.GetText()
+	case *DropDown:
+		if _, option := item.GetCurrentOption(); option != nil {
+			return option.GetText()
+		}
+	case *CheckBox:
+		if item.IsChecked() {
+			return "true"
+		}
+	case *Slider:
+		return strconv.Itoa(item.GetProgress())
+	}
+	return ""
+}
@@ -0,0 +1,151 @@
+package cui
+
+import (
+	"math"
+	"regexp"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestFormValidation(t *testing.T) {
+	t.Parallel()
+
+	name := NewInputField().SetLabel("Name")
+	code := NewInputField().SetLabel("Code").SetText("ab1")
+	terms := NewCheckBox().SetLabel("Terms")
+	f := NewForm().AddFormItem(name).AddFormItem(code).AddFormItem(terms)
+	f.SetValidators(name, ValidateRequired())
+	f.SetValidators(code, ValidateRegexp(regexp.MustCompile(`^[a-z]+$`), "letters only"))
+	f.SetValidators(terms, ValidateRequired())
+
+	submitted := 0
+	f.SetSubmitFunc(func() { submitted++ })
+	if f.Submit() || submitted != 0 {
+		t.Fatalf("failed to block submission: expected invalid form")
+	}
+	for index, expected := range []string{"required", "letters only", "required"} {
+		if err := f.GetError(index); err == nil || err.Error() != expected {
+			t.Errorf("failed to validate item %d: expected %s, got %v", index, expected, err)
+		}
+	}
+	if formItem, _ := f.GetFocusedItemIndex(); formItem != -1 {
+		t.Errorf("failed to keep focus: expected -1, got %d", formItem)
+	}
+
+	// Invalid inputs show the error as their note, in the invalid color
+	screen := tcell.NewSimulationScreen("UTF-8")
+	if err := screen.Init(); err != nil {
+		t.Fatalf("failed to initialize screen: %s", err)
+	}
+	screen.SetSize(30, 10)
+	f.SetRect(0, 0, 30, 10)
+	f.Draw(screen)
+	if r, _, style, _ := screen.GetContent(1, 1); r != 'N' {
+		t.Errorf("failed to draw label: expected N, got %c", r)
+	} else if fg, _, _ := style.Decompose(); fg != Styles.FormInvalidColor {
+		t.Errorf("failed to draw invalid label: expected %v, got %v", Styles.FormInvalidColor, fg)
+	}
+	if r, _, _, _ := screen.GetContent(7, 2); r != 'r' {
+		t.Errorf("failed to draw error note: expected r, got %c", r)
+	}
+
+	// Leaving a corrected item validates it again
+	var focus Widget
+	f.Focus(func(p Widget) { focus = p })
+	if focus != name {
+		t.Fatalf("failed to focus first item: expected name input, got %T", focus)
+	}
+	name.SetText("Ada")
+	name.InputHandler()(tcell.NewEventKey(tcell.KeyTab, 0, tcell.ModNone), func(p Widget) {})
+	if err := f.GetError(0); err != nil {
+		t.Errorf("failed to validate item when leaving: expected nil, got %s", err)
+	}
+
+	code.SetText("abc")
+	terms.SetChecked(true)
+	if !f.Submit() || submitted != 1 {
+		t.Errorf("failed to submit valid form: expected 1 submission, got %d", submitted)
+	}
+}
+
+func TestFormBind(t *testing.T) {
+	t.Parallel()
+
+	type settings struct {
+		Name    string  `cui:"label=Name,required"`
+		Port    int     `cui:"label=Port,min=1,max=65535"`
+		Ratio   float64 `cui:"label=Ratio"`
+		Level   string  `cui:"label=Level,options=low|high"`
+		Debug   bool    `cui:"label=Debug"`
+		Ignored string
+	}
+	s := settings{Name: "server", Port: 80, Ratio: 0.5, Level: "high"}
+
+	f := NewForm()
+	if err := f.Bind(&s); err != nil {
+		t.Fatalf("failed to bind struct: %s", err)
+	}
+	if count := f.GetFormItemCount(); count != 5 {
+		t.Fatalf("failed to add items: expected 5, got %d", count)
+	}
+	port := f.GetFormItemByLabel("Port").(*Input)
+	if text := port.GetText(); text != "80" {
+		t.Errorf("failed to read field: expected 80, got %s", text)
+	}
+	if index, _ := f.GetFormItemByLabel("Level").(*DropDown).GetCurrentOption(); index != 1 {
+		t.Errorf("failed to read option: expected 1, got %d", index)
+	}
+
+	port.SetText("70000")
+	if f.Submit() || s.Port != 80 {
+		t.Errorf("failed to block invalid port: expected 80, got %d", s.Port)
+	}
+	port.SetText("8080")
+	f.GetFormItemByLabel("Name").(*Input).SetText("client")
+	f.GetFormItemByLabel("Debug").(*CheckBox).SetChecked(true)
+	if !f.Submit() {
+		t.Fatalf("failed to submit form: %v", f.GetError(1))
+	}
+	expected := settings{Name: "client", Port: 8080, Ratio: 0.5, Level: "high", Debug: true}
+	if s != expected {
+		t.Errorf("failed to write fields: expected %+v, got %+v", expected, s)
+	}
+
+	// Values must fit into the type of the field.
+	var sized struct {
+		Small int8    `cui:"label=Small"`
+		Count uint16  `cui:"label=Count"`
+		Big   int64   `cui:"label=Big"`
+		Scale float32 `cui:"label=Scale"`
+	}
+	f = NewForm()
+	if err := f.Bind(&sized); err != nil {
+		t.Fatalf("failed to bind struct: %s", err)
+	}
+	for label, value := range map[string]string{"Small": "300", "Count": "-1", "Big": "9223372036854775808", "Scale": "1e39"} {
+		input := f.GetFormItemByLabel(label).(*Input)
+		input.SetText(value)
+		if f.Submit() {
+			t.Errorf("failed to block value out of range of %s: submitted %s", label, value)
+		}
+		input.SetText("")
+	}
+	f.GetFormItemByLabel("Small").(*Input).SetText("-128")
+	f.GetFormItemByLabel("Big").(*Input).SetText("9223372036854775807")
+	if !f.Submit() || sized.Small != -128 || sized.Big != math.MaxInt64 {
+		t.Errorf("failed to write limits of types: got %d and %d", sized.Small, sized.Big)
+	}
+	if err := f.GetError(0); err != nil {
+		t.Errorf("failed to validate limit of type: got %s", err)
+	}
+
+	if err := f.Bind(s); err == nil {
+		t.Errorf("failed to reject struct value: expected error")
+	}
+	if err := f.Bind(&struct {
+		Values []string `cui:"label=Values"`
+	}{}); err == nil {
+		t.Errorf("failed to reject unsupported type: expected error")
+	}
+}
@@ -897,7 +897,7 @@ func (i *Input) InputHandler() func(event *tcell.EventKey, setFocus func(p Widge
 		// Add character function. Returns whether the rune character is
 		// accepted.
 		add := func(r rune) bool {
-			newText := append(append(i.text[:i.cursorPos], []byte(string(r))...), i.text[i.cursorPos:]...)
+			newText := append(append(append([]byte(nil), i.text[:i.cursorPos]...), string(r)...), i.text[i.cursorPos:]...)
 			if i.accept != nil && !i.accept(string(newText), r) {
 				return false
 			}
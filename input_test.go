@@ -0,0 +1,26 @@
+package cui
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestInputInsert(t *testing.T) {
+	t.Parallel()
+
+	i := NewInputField()
+	i.SetText("xd")
+	press := func(key tcell.Key, r rune) {
+		i.InputHandler()(tcell.NewEventKey(key, r, tcell.ModNone), func(p Widget) {})
+	}
+
+	// Typing in the middle keeps the text after the cursor
+	press(tcell.KeyHome, 0)
+	press(tcell.KeyRight, 0)
+	press(tcell.KeyRune, 'A')
+	press(tcell.KeyRune, 'B')
+	if text := i.GetText(); text != "xABd" {
+		t.Errorf("failed to insert text: expected xABd, got %s", text)
+	}
+}
@@ -32,6 +32,39 @@ type Key struct {
 	MoveNextPage      []string
 
 	ShowContextMenu []string
+
+	Filter []string
+
+	SelectToggle []string
+	SelectAll    []string
+	SelectUp     []string
+	SelectDown   []string
+
+	TableMoveColumnLeft  []string
+	TableMoveColumnRight []string
+
+	TextAreaUndo  []string
+	TextAreaRedo  []string
+	TextAreaCopy  []string
+	TextAreaCut   []string
+	TextAreaPaste []string
+
+	TerminalScrollBack     []string
+	TerminalScrollForward  []string
+	TerminalCopyMode       []string
+	TerminalSearch         []string
+	TerminalSearchBackward []string
+	TerminalSearchNext     []string
+	TerminalSearchPrevious []string
+	TerminalPreviousPrompt []string
+	TerminalNextPrompt     []string
+
+	PlayerPlayPause    []string
+	PlayerSeekBackward []string
+	PlayerSeekForward  []string
+	PlayerRestart      []string
+	PlayerSpeedUp      []string
+	PlayerSlowDown     []string
 }
 
 // Keys defines the keyboard shortcuts of an application.
@@ -62,6 +95,39 @@ var Keys = Key{
 	MoveNextPage:      []string{"PageDown", "Ctrl+F"},
 
 	ShowContextMenu: []string{"Alt+Enter"},
+
+	Filter: []string{"/"},
+
+	SelectToggle: []string{"Space"},
+	SelectAll:    []string{"Ctrl+A"},
+	SelectUp:     []string{"Shift+Up"},
+	SelectDown:   []string{"Shift+Down"},
+
+	TableMoveColumnLeft:  []string{"Alt+Left"},
+	TableMoveColumnRight: []string{"Alt+Right"},
+
+	TextAreaUndo:  []string{"Ctrl+Z"},
+	TextAreaRedo:  []string{"Ctrl+Y"},
+	TextAreaCopy:  []string{"Alt+c", "Ctrl+Insert"}, // Ctrl+C stops the application
+	TextAreaCut:   []string{"Ctrl+X"},
+	TextAreaPaste: []string{"Ctrl+V", "Shift+Insert"},
+
+	TerminalScrollBack:     []string{"Shift+PageUp"},
+	TerminalScrollForward:  []string{"Shift+PageDown"},
+	TerminalCopyMode:       []string{"Alt+Space"},
+	TerminalSearch:         []string{"/"},
+	TerminalSearchBackward: []string{"?"},
+	TerminalSearchNext:     []string{"n"},
+	TerminalSearchPrevious: []string{"N"},
+	TerminalPreviousPrompt: []string{"Ctrl+Shift+Up"},
+	TerminalNextPrompt:     []string{"Ctrl+Shift+Down"},
+
+	PlayerPlayPause:    []string{"Space", "p"},
+	PlayerSeekBackward: []string{"Left", "h"},
+	PlayerSeekForward:  []string{"Right", "l"},
+	PlayerRestart:      []string{"Home", "0"},
+	PlayerSpeedUp:      []string{"+", "]"},
+	PlayerSlowDown:     []string{"-", "["},
 }
 
 // HitShortcut returns whether the EventKey provided is present in one or more
@@ -3,8 +3,10 @@ package cui
 import (
 	"bytes"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
+	"unicode/utf8"
 
 	"github.com/gdamore/tcell/v2"
 )
@@ -203,6 +205,51 @@ type List struct {
 	// Maximum prefix and suffix width.
 	prefixWidth, suffixWidth int
 
+	// Whether the user may filter the items.
+	filterable bool
+
+	// Whether the items are being filtered.
+	filtering bool
+
+	// The filter query.
+	query []rune
+
+	// All items and the index of the selected item before filtering. While
+	// filtering, "items" holds the matching items only.
+	unfiltered     []*ListItem
+	unfilteredItem int
+
+	// The positions of the matched characters of the filtered items.
+	matches map[*ListItem][]int
+
+	// Whether the user may select several items.
+	multiSelect bool
+
+	// The items selected by the user if multi-selection is enabled.
+	selection map[*ListItem]bool
+
+	// The item at which ranges selected with the Shift key start, and the
+	// selection before the range was selected.
+	anchor         *ListItem
+	rangeSelection map[*ListItem]bool
+
+	// An optional function which is called when the user has changed the
+	// selected items.
+	selectionChanged func(items []*ListItem)
+
+	// Whether the user may drag items with the mouse.
+	draggable bool
+
+	// Optional functions which decide whether a payload may be dropped before
+	// the item with the given index and drop it. Without them, items dragged
+	// from this list are moved.
+	acceptDrop func(payload *DragPayload, index int) bool
+	drop       func(payload *DragPayload, index int)
+
+	// The index of the item before which a dragged payload would be dropped,
+	// or -1.
+	dropIndex int
+
 	mu sync.RWMutex
 }
 
@@ -218,6 +265,7 @@ func NewList() *List {
 		selectedTextColor:       Styles.PrimitiveBackgroundColor,
 		scrollBarColor:          Styles.ScrollBarColor,
 		selectedBackgroundColor: Styles.PrimaryTextColor,
+		dropIndex:               -1,
 	}
 
 	l.ContextMenu = NewContextMenu(l)
@@ -534,6 +582,7 @@ func (l *List) GetItems() (items []*ListItem) {
 // removed, a "changed" event is fired.
 func (l *List) RemoveItem(index int) *List {
 	l.mu.Lock()
+	l.endFilter(true)
 
 	if len(l.items) == 0 {
 		l.mu.Unlock()
@@ -552,6 +601,7 @@ func (l *List) RemoveItem(index int) *List {
 	}
 
 	// Remove item.
+	delete(l.selection, l.items[index])
 	l.items = append(l.items[:index], l.items[index+1:]...)
 
 	// If there is nothing left, we're done.
@@ -749,6 +799,7 @@ func (l *List) AddItem(item *ListItem) *List {
 // selected.
 func (l *List) InsertItem(index int, item *ListItem) *List {
 	l.mu.Lock()
+	l.endFilter(true)
 
 	// Shift index to range.
 	if index < 0 {
@@ -891,10 +942,463 @@ func (l *List) FindItems(mainSearch, secondarySearch string, mustContainBoth, ig
 	return
 }
 
+// SetFilterable sets whether the user may filter the items. If enabled,
+// pressing one of the Keys.Filter shortcuts ("/" by default) opens a query line
+// at the bottom of the list. Only items whose main text fuzzy-matches the query
+// are shown, best matches first, with the matched characters highlighted. Enter
+// ends filtering and selects the current item, Escape ends filtering and
+// restores the previous selection.
+//
+// While filtering, item indices (e.g. those passed to callbacks or accepted by
+// SetCurrentItem()) refer to the items shown. Adding, inserting or removing
+// items ends filtering.
+func (l *List) SetFilterable(filterable bool) *List {
+	return l.set(func(l *List) {
+		l.filterable = filterable
+		if !filterable {
+			l.endFilter(true)
+		}
+	})
+}
+
+// SetFilter filters the items with the given query as if the user had typed
+// it, see SetFilterable().
+func (l *List) SetFilter(query string) *List {
+	return l.set(func(l *List) {
+		if !l.filtering {
+			l.startFilter()
+		}
+		l.query = []rune(query)
+		l.applyFilter()
+	})
+}
+
+// GetFilter returns the filter query and whether the items are being
+// filtered.
+func (l *List) GetFilter() (query string, filtering bool) {
+	l.get(func(l *List) { query, filtering = string(l.query), l.filtering })
+	return
+}
+
+// ClearFilter ends filtering and shows all items. The current item remains
+// selected.
+func (l *List) ClearFilter() *List {
+	return l.set(func(l *List) { l.endFilter(true) })
+}
+
+// startFilter starts filtering the items.
+func (l *List) startFilter() {
+	l.filtering = true
+	l.query = nil
+	l.unfiltered = l.items
+	l.unfilteredItem = l.currentItem
+	l.applyFilter()
+}
+
+// applyFilter shows the items matching the query, best matches first.
+func (l *List) applyFilter() {
+	type match struct {
+		item  *ListItem
+		score int
+	}
+	var matches []match
+	l.matches = make(map[*ListItem][]int)
+	query := string(l.query)
+	for _, item := range l.unfiltered {
+		if query == "" {
+			matches = append(matches, match{item: item})
+			continue
+		}
+		if score, positions, ok := FuzzyMatch(query, string(StripTags(item.mainText, true, false))); ok {
+			matches = append(matches, match{item: item, score: score})
+			l.matches[item] = positions
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+
+	l.items = make([]*ListItem, len(matches))
+	for index, match := range matches {
+		l.items[index] = match.item
+	}
+	if query == "" {
+		l.currentItem = l.unfilteredItem
+		l.updateOffset()
+	} else {
+		l.transform(TransformFirstItem)
+	}
+}
+
+// endFilter ends filtering and shows all items. If "keep" is true, the current
+// item remains selected. Otherwise, the selection before filtering is restored.
+func (l *List) endFilter(keep bool) {
+	if !l.filtering {
+		return
+	}
+	current := l.unfilteredItem
+	if keep && l.currentItem >= 0 && l.currentItem < len(l.items) {
+		item := l.items[l.currentItem]
+		for index, unfiltered := range l.unfiltered {
+			if unfiltered == item {
+				current = index
+				break
+			}
+		}
+	}
+	l.items, l.unfiltered = l.unfiltered, nil
+	l.filtering, l.query, l.matches = false, nil, nil
+	l.currentItem = current
+	l.updateOffset()
+}
+
+// SetMultiSelect sets whether the user may select several items. If enabled,
+// the following keys change the selected items:
+//
+//   - Space: Toggle the selection of the current item
+//   - Shift+Up, Shift+Down: Select the items between the current item and the
+//     item last toggled or moved to
+//   - Ctrl+A: Select all items
+//
+// Clicking an item with the Ctrl key pressed toggles its selection, clicking
+// it with the Shift key pressed selects a range of items. Disabling
+// multi-selection clears the selected items.
+func (l *List) SetMultiSelect(multiSelect bool) *List {
+	return l.set(func(l *List) {
+		l.multiSelect = multiSelect
+		if !multiSelect {
+			l.selection, l.anchor, l.rangeSelection = nil, nil, nil
+		}
+	})
+}
+
+// SetItemSelected sets whether the item with the given index is selected.
+// Panics if the index is out of range.
+func (l *List) SetItemSelected(index int, selected bool) *List {
+	return l.set(func(l *List) {
+		l.selectItem(l.items[index], selected)
+		l.anchor, l.rangeSelection = l.items[index], nil
+	})
+}
+
+// IsItemSelected returns whether the item with the given index is selected.
+func (l *List) IsItemSelected(index int) (selected bool) {
+	l.get(func(l *List) {
+		if index >= 0 && index < len(l.items) {
+			selected = l.selection[l.items[index]]
+		}
+	})
+	return
+}
+
+// GetSelectedItems returns the selected items in the order of the list. This
+// includes selected items hidden by the filter.
+func (l *List) GetSelectedItems() (items []*ListItem) {
+	l.get(func(l *List) { items = l.selectedItems() })
+	return
+}
+
+// ClearSelection deselects all items.
+func (l *List) ClearSelection() *List {
+	return l.set(func(l *List) {
+		l.selection, l.anchor, l.rangeSelection = nil, nil, nil
+	})
+}
+
+// SetSelectedItemsChangedFunc sets a function which is called when the user
+// changes the selected items (see SetMultiSelect()). The function receives the
+// selected items in the order of the list.
+func (l *List) SetSelectedItemsChangedFunc(handler func(items []*ListItem)) *List {
+	return l.set(func(l *List) { l.selectionChanged = handler })
+}
+
+// selectedItems returns the selected items in the order of the list.
+func (l *List) selectedItems() (items []*ListItem) {
+	all := l.items
+	if l.filtering {
+		all = l.unfiltered
+	}
+	for _, item := range all {
+		if l.selection[item] {
+			items = append(items, item)
+		}
+	}
+	return
+}
+
+// selectItem selects or deselects an item.
+func (l *List) selectItem(item *ListItem, selected bool) {
+	if !selected {
+		delete(l.selection, item)
+		return
+	}
+	if l.selection == nil {
+		l.selection = make(map[*ListItem]bool)
+	}
+	l.selection[item] = true
+}
+
+// selectable returns whether an item may be selected, i.e. if it is neither
+// disabled nor a divider.
+func (l *List) selectable(item *ListItem) bool {
+	return !item.disabled && (item.shortcut != 0 || len(item.mainText) > 0 || len(item.secondaryText) > 0)
+}
+
+// selectRange selects the items between the anchor and the current item in
+// addition to the items selected before the range.
+func (l *List) selectRange() {
+	if l.rangeSelection == nil {
+		l.rangeSelection = make(map[*ListItem]bool)
+		for item := range l.selection {
+			l.rangeSelection[item] = true
+		}
+	}
+	from := l.currentItem
+	for index, item := range l.items {
+		if item == l.anchor {
+			from = index
+			break
+		}
+	}
+	to := l.currentItem
+	if from > to {
+		from, to = to, from
+	}
+
+	l.selection = make(map[*ListItem]bool)
+	for item := range l.rangeSelection {
+		l.selection[item] = true
+	}
+	for index := from; index <= to && index < len(l.items); index++ {
+		if item := l.items[index]; l.selectable(item) {
+			l.selection[item] = true
+		}
+	}
+}
+
+// handleMultiSelect changes the selected items according to the key event and
+// returns whether the event was handled. Must be called with the list locked.
+func (l *List) handleMultiSelect(event *tcell.EventKey) bool {
+	if len(l.items) == 0 || l.currentItem >= len(l.items) {
+		return false
+	}
+
+	previousItem := l.currentItem
+	switch {
+	case HitShortcut(event, Keys.SelectToggle):
+		item := l.items[l.currentItem]
+		if !l.selectable(item) {
+			return true
+		}
+		l.selectItem(item, !l.selection[item])
+		l.anchor, l.rangeSelection = item, nil
+	case HitShortcut(event, Keys.SelectAll):
+		for _, item := range l.items {
+			if l.selectable(item) {
+				l.selectItem(item, true)
+			}
+		}
+		l.rangeSelection = nil
+	case HitShortcut(event, Keys.SelectUp, Keys.SelectDown):
+		if l.anchor == nil {
+			l.anchor = l.items[l.currentItem]
+		}
+		if HitShortcut(event, Keys.SelectUp) {
+			l.transform(TransformPreviousItem)
+		} else {
+			l.transform(TransformNextItem)
+		}
+		l.selectRange()
+	default:
+		return false
+	}
+
+	if l.currentItem != previousItem && l.currentItem < len(l.items) && l.changed != nil {
+		item := l.items[l.currentItem]
+		l.mu.Unlock()
+		l.changed(l.currentItem, item)
+		l.mu.Lock()
+	}
+	if l.selectionChanged != nil {
+		items := l.selectedItems()
+		l.mu.Unlock()
+		l.selectionChanged(items)
+		l.mu.Lock()
+	}
+	return true
+}
+
+// MoveItem moves the item at index "from" to index "to". Panics if an index is
+// out of range.
+func (l *List) MoveItem(from, to int) *List {
+	return l.set(func(l *List) {
+		l.endFilter(true)
+		before := to
+		if to > from {
+			before++
+		}
+		l.moveItems([]*ListItem{l.items[from]}, before)
+	})
+}
+
+// moveItems moves the given items before the item with the given index,
+// keeping their order. The current item remains current.
+func (l *List) moveItems(items []*ListItem, before int) {
+	var current *ListItem
+	if l.currentItem >= 0 && l.currentItem < len(l.items) {
+		current = l.items[l.currentItem]
+	}
+	moved := make(map[*ListItem]bool, len(items))
+	for _, item := range items {
+		moved[item] = true
+	}
+
+	remaining := make([]*ListItem, 0, len(l.items))
+	insert := 0
+	for index, item := range l.items {
+		if moved[item] {
+			continue
+		}
+		if index < before {
+			insert++
+		}
+		remaining = append(remaining, item)
+	}
+	var kept []*ListItem
+	for _, item := range l.items {
+		if moved[item] {
+			kept = append(kept, item)
+		}
+	}
+	l.items = append(remaining[:insert], append(kept, remaining[insert:]...)...)
+
+	for index, item := range l.items {
+		if item == current {
+			l.currentItem = index
+			break
+		}
+	}
+	l.updateOffset()
+}
+
+// SetDraggable sets whether the user may drag items with the mouse. The
+// payload holds the dragged item, or the selected items if the dragged item
+// is one of them (see SetMultiSelect()). Unless a drop function is set with
+// SetDropFunc(), dropping the items onto the list moves them.
+func (l *List) SetDraggable(draggable bool) *List {
+	return l.set(func(l *List) { l.draggable = draggable })
+}
+
+// SetDropFunc sets the functions which decide whether a payload dragged with
+// the mouse may be dropped before the item with the given index and drop it.
+// An index of GetItemCount() drops the payload after the last item. Without
+// them, the list accepts the items dragged from itself and moves them.
+func (l *List) SetDropFunc(accept func(payload *DragPayload, index int) bool, drop func(payload *DragPayload, index int)) *List {
+	return l.set(func(l *List) {
+		l.acceptDrop, l.drop = accept, drop
+	})
+}
+
+// DragStart returns the payload of the item at the given screen position. It
+// implements DragSource.
+func (l *List) DragStart(x, y int) *DragPayload {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.draggable || l.filtering {
+		return nil
+	}
+	index := l.indexAtPoint(x, y)
+	if index < 0 || !l.selectable(l.items[index]) {
+		return nil
+	}
+	item := l.items[index]
+	items := []*ListItem{item}
+	if l.selection[item] {
+		items = l.selectedItems()
+	}
+	label := string(StripTags(item.mainText, true, false))
+	if len(items) > 1 {
+		label = fmt.Sprintf("%d items", len(items))
+	}
+	return &DragPayload{Kind: DragKindListItems, Data: items, Label: label}
+}
+
+// DragEnd is called when dragging items of the list has ended. It implements
+// DragSource.
+func (l *List) DragEnd(payload *DragPayload, dropped bool) {}
+
+// dropIndexAt returns the index of the item before which a payload would be
+// dropped at the given screen position, or -1 if there is none.
+func (l *List) dropIndexAt(x, y int) int {
+	if l.filtering || !l.InRect(x, y) {
+		return -1
+	}
+	if index := l.indexAtY(y); index >= 0 {
+		return index
+	}
+	_, rectY, _, height := l.GetInnerRect()
+	if y >= rectY && y < rectY+height {
+		return len(l.items) // Below the last item.
+	}
+	return -1
+}
+
+// acceptsDrop returns whether the payload may be dropped before the item with
+// the given index. Must be called with the list locked.
+func (l *List) acceptsDrop(payload *DragPayload, index int) bool {
+	if accept := l.acceptDrop; accept != nil {
+		l.mu.Unlock()
+		defer l.mu.Lock()
+		return accept(payload, index)
+	}
+	return payload.Source == l && payload.Kind == DragKindListItems
+}
+
+// DragOver shows where the payload would be dropped. It implements
+// DropTarget.
+func (l *List) DragOver(payload *DragPayload, x, y int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.dropIndex = l.dropIndexAt(x, y)
+	if l.dropIndex >= 0 && !l.acceptsDrop(payload, l.dropIndex) {
+		l.dropIndex = -1
+	}
+	return l.dropIndex >= 0
+}
+
+// DragLeave removes what was shown by DragOver(). It implements DropTarget.
+func (l *List) DragLeave() {
+	l.set(func(l *List) { l.dropIndex = -1 })
+}
+
+// Drop drops the payload before the item at the given screen position. It
+// implements DropTarget.
+func (l *List) Drop(payload *DragPayload, x, y int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	index := l.dropIndexAt(x, y)
+	if index < 0 || !l.acceptsDrop(payload, index) {
+		return false
+	}
+	if drop := l.drop; drop != nil {
+		l.mu.Unlock()
+		drop(payload, index)
+		l.mu.Lock()
+	} else if items, ok := payload.Data.([]*ListItem); ok && l.acceptDrop == nil {
+		l.moveItems(items, index)
+	}
+	return true
+}
+
 // Clear removes all items from the list.
 func (l *List) Clear() *List {
 	return l.set(func(l *List) {
+		l.endFilter(false)
 		l.items = nil
+		l.selection, l.anchor, l.rangeSelection = nil, nil, nil
 		l.currentItem = 0
 		l.itemOffset = 0
 		l.columnOffset = 0
@@ -986,6 +1490,9 @@ func (l *List) transform(tr Transformation) {
 
 func (l *List) updateOffset() {
 	_, _, _, l.height = l.GetInnerRect()
+	if l.filtering {
+		l.height--
+	}
 
 	h := l.height
 	if l.selectedAlwaysCentered {
@@ -1068,6 +1575,12 @@ func (l *List) Draw(screen tcell.Screen) {
 	x, y, width, height := l.GetInnerRect()
 	leftEdge := x
 	fullWidth := width + l.box.paddingLeft + l.box.paddingRight + l.prefixWidth + l.suffixWidth
+
+	// Draw the filter query in the last line.
+	if l.filtering && height > 0 {
+		height--
+		drawFilterQuery(screen, x, y+height, width, l.query, len(l.items), len(l.unfiltered), hasFocus && !l.open)
+	}
 	bottomLimit := y + height
 
 	l.height = height
@@ -1102,6 +1615,9 @@ func (l *List) Draw(screen tcell.Screen) {
 
 	scrollBarCursor := int(float64(len(l.items)) * (float64(l.itemOffset) / float64(len(l.items)-height)))
 
+	// The row showing where a dragged payload would be dropped.
+	dropY := -1
+
 	// Draw the list items.
 	for index, item := range l.items {
 		if index < l.itemOffset {
@@ -1112,12 +1628,23 @@ func (l *List) Draw(screen tcell.Screen) {
 			break
 		}
 
+		if index == l.dropIndex {
+			dropY = y
+		}
+
 		mainText := item.mainText
 		secondaryText := item.secondaryText
+		positions := l.matches[item]
+		if len(positions) > 0 {
+			// Matches are highlighted after scrolling the plain text.
+			mainText = StripTags(mainText, true, false)
+		}
 		if l.columnOffset > 0 {
 			if l.columnOffset < len(mainText) {
+				positions = shiftMatches(positions, utf8.RuneCount(mainText[:l.columnOffset]))
 				mainText = mainText[l.columnOffset:]
 			} else {
+				positions = nil
 				mainText = nil
 			}
 			if l.columnOffset < len(secondaryText) {
@@ -1126,6 +1653,9 @@ func (l *List) Draw(screen tcell.Screen) {
 				secondaryText = nil
 			}
 		}
+		if len(positions) > 0 {
+			mainText = highlightMatches(mainText, positions)
+		}
 
 		if len(item.mainText) == 0 && len(item.secondaryText) == 0 && item.shortcut == 0 { // Divider
 			Print(screen, []byte(string(tcell.RuneLTee)), leftEdge-2, y, 1, AlignLeft, l.mainTextColor)
@@ -1175,6 +1705,14 @@ func (l *List) Draw(screen tcell.Screen) {
 		// Main text.
 		Print(screen, mainText, x, y, width, AlignLeft, l.mainTextColor)
 
+		// Background color of items selected with multi-selection.
+		if l.selection[item] {
+			for bx := 0; bx < width; bx++ {
+				m, c, style, _ := screen.GetContent(x+bx, y)
+				screen.SetContent(x+bx, y, m, c, style.Background(Styles.MultiSelectBackgroundColor))
+			}
+		}
+
 		// Background color of selected text.
 		if index == l.currentItem && (!l.selectedFocusOnly || hasFocus) {
 			textWidth := width
@@ -1213,6 +1751,10 @@ func (l *List) Draw(screen tcell.Screen) {
 		}
 	}
 
+	if l.dropIndex == len(l.items) && y < bottomLimit {
+		dropY = y
+	}
+
 	// Overdraw scroll bar when necessary.
 	for y < bottomLimit {
 		RenderScrollBar(screen, l.scrollBarVisibility, scrollBarX, y, scrollBarHeight, len(l.items), scrollBarCursor, bottomLimit-y, l.box.hasFocus, l.scrollBarColor)
@@ -1220,6 +1762,11 @@ func (l *List) Draw(screen tcell.Screen) {
 		y++
 	}
 
+	// Show where a dragged payload would be dropped.
+	if dropY >= 0 {
+		dropFeedback(screen, x, dropY, width)
+	}
+
 	// Draw context menu.
 	if hasFocus && l.open {
 		ctx := l.ContextMenuList()
@@ -1292,6 +1839,45 @@ func (l *List) InputHandler() func(event *tcell.EventKey, setFocus func(p Widget
 	return l.WrapInputHandler(func(event *tcell.EventKey, setFocus func(p Widget)) {
 		l.mu.Lock()
 
+		// Edit the filter query.
+		if l.filtering && !l.open {
+			var previousItem *ListItem
+			if l.currentItem < len(l.items) {
+				previousItem = l.items[l.currentItem]
+			}
+
+			action := handleFilterKey(event, &l.query)
+			switch action {
+			case filterChanged:
+				l.applyFilter()
+			case filterCancel:
+				l.endFilter(false)
+			case filterAccept:
+				l.endFilter(true)
+			}
+
+			if action == filterChanged || action == filterCancel {
+				if l.currentItem < len(l.items) && l.items[l.currentItem] != previousItem && l.changed != nil {
+					index, item := l.currentItem, l.items[l.currentItem]
+					l.mu.Unlock()
+					l.changed(index, item)
+				} else {
+					l.mu.Unlock()
+				}
+				return
+			}
+		} else if l.filterable && !l.open && HitShortcut(event, Keys.Filter) {
+			l.startFilter()
+			l.mu.Unlock()
+			return
+		}
+
+		// Change the selected items.
+		if l.multiSelect && !l.open && l.handleMultiSelect(event) {
+			l.mu.Unlock()
+			return
+		}
+
 		if HitShortcut(event, Keys.Cancel) {
 			if l.open {
 				l.mu.Unlock()
@@ -1380,6 +1966,11 @@ func (l *List) InputHandler() func(event *tcell.EventKey, setFocus func(p Widget
 			l.transform(TransformNextPage)
 		}
 
+		// Ranges are selected from the item moved to last.
+		if l.currentItem != previousItem && l.currentItem < len(l.items) {
+			l.anchor, l.rangeSelection = l.items[l.currentItem], nil
+		}
+
 		if l.currentItem != previousItem && l.currentItem < len(l.items) && l.changed != nil {
 			item := l.items[l.currentItem]
 			l.mu.Unlock()
@@ -1394,6 +1985,9 @@ func (l *List) InputHandler() func(event *tcell.EventKey, setFocus func(p Widget
 // or a negative value if there is no such list item.
 func (l *List) indexAtY(y int) int {
 	_, rectY, _, height := l.GetInnerRect()
+	if l.filtering {
+		height--
+	}
 	if y < rectY || y >= rectY+height {
 		return -1
 	}
@@ -1414,6 +2008,9 @@ func (l *List) indexAtY(y int) int {
 // or a negative value if there is no such list item.
 func (l *List) indexAtPoint(x, y int) int {
 	rectX, rectY, width, height := l.GetInnerRect()
+	if l.filtering {
+		height--
+	}
 	if x < rectX || x >= rectX+width || y < rectY || y >= rectY+height {
 		return -1
 	}
@@ -1433,6 +2030,16 @@ func (l *List) indexAtPoint(x, y int) int {
 // MouseHandler returns the mouse handler for this primitive.
 func (l *List) MouseHandler() func(action MouseAction, event *tcell.EventMouse, setFocus func(p Widget)) (consumed bool, capture Widget) {
 	return l.WrapMouseHandler(func(action MouseAction, event *tcell.EventMouse, setFocus func(p Widget)) (consumed bool, capture Widget) {
+		// Report the list as drag source or drop target.
+		if action == MouseDragStart || action == MouseDragOver {
+			l.mu.RLock()
+			defer l.mu.RUnlock()
+			if l.InRect(event.Position()) && (l.draggable || action == MouseDragOver && l.acceptDrop != nil) {
+				return true, l
+			}
+			return false, nil
+		}
+
 		l.mu.Lock()
 
 		// Pass events to context menu.
@@ -1463,9 +2070,44 @@ func (l *List) MouseHandler() func(action MouseAction, event *tcell.EventMouse,
 			l.mu.Lock()
 
 			index := l.indexAtPoint(event.Position())
-			if index != -1 {
+			if index != -1 && l.multiSelect && event.Modifiers()&(tcell.ModShift|tcell.ModCtrl) != 0 {
+				// Select a range or toggle the item.
+				item := l.items[index]
+				if l.selectable(item) {
+					previousItem := l.currentItem
+					l.currentItem = index
+					if event.Modifiers()&tcell.ModShift != 0 {
+						if l.anchor == nil && previousItem < len(l.items) {
+							l.anchor = l.items[previousItem]
+						}
+						l.selectRange()
+					} else {
+						l.selectItem(item, !l.selection[item])
+						l.anchor, l.rangeSelection = item, nil
+					}
+					if index != previousItem && l.changed != nil {
+						l.mu.Unlock()
+						l.changed(index, item)
+						l.mu.Lock()
+					}
+					if l.selectionChanged != nil {
+						items := l.selectedItems()
+						l.mu.Unlock()
+						l.selectionChanged(items)
+						l.mu.Lock()
+					}
+				}
+			} else if index != -1 {
 				item := l.items[index]
 				if !item.disabled {
+					l.anchor, l.rangeSelection = item, nil
+
+					// Clicking an item ends filtering.
+					if l.filtering {
+						l.currentItem = index
+						l.endFilter(true)
+						index = l.currentItem
+					}
 					l.currentItem = index
 					if item.selected != nil {
 						l.mu.Unlock()
@@ -1,7 +1,10 @@
 package cui
 
 import (
+	"strings"
 	"testing"
+
+	"github.com/gdamore/tcell/v2"
 )
 
 const (
@@ -72,3 +75,157 @@ func TestList(t *testing.T) {
 
 	l.Draw(app.screen)
 }
+
+func TestListFilter(t *testing.T) {
+	t.Parallel()
+
+	l := NewList().ShowSecondaryText(false).SetFilterable(true)
+	for _, text := range []string{"apple", "banana", "cherry", "blueberry", "grape"} {
+		l.AddItem(NewListItem(text))
+	}
+	l.SetCurrentItem(2)
+	l.SetRect(0, 0, 20, 6)
+
+	var changed []string
+	l.SetChangedFunc(func(index int, item *ListItem) { changed = append(changed, item.GetMainText()) })
+	handler := l.InputHandler()
+	press := func(key tcell.Key, r rune) {
+		handler(tcell.NewEventKey(key, r, tcell.ModNone), func(p Widget) {})
+	}
+
+	// Typing "/" opens the query line and typing filters the items
+	press(tcell.KeyRune, '/')
+	if _, filtering := l.GetFilter(); !filtering {
+		t.Fatalf("failed to start filtering: expected filtering")
+	}
+	for _, r := range "be" {
+		press(tcell.KeyRune, r)
+	}
+	if count := l.GetItemCount(); count != 1 {
+		t.Errorf("failed to filter items: expected 1, got %d", count)
+	} else if text := l.GetCurrentItem().GetMainText(); text != "blueberry" {
+		t.Errorf("failed to select best match: expected blueberry, got %s", text)
+	}
+
+	// Matched characters are highlighted and the query is shown
+	screen := tcell.NewSimulationScreen("UTF-8")
+	if err := screen.Init(); err != nil {
+		t.Fatalf("failed to initialize screen: %s", err)
+	}
+	screen.SetSize(20, 6)
+	l.Draw(screen)
+	_, _, style, _ := screen.GetContent(0, 0)
+	if fg, _, _ := style.Decompose(); fg != Styles.FilterMatchColor {
+		t.Errorf("failed to highlight match: expected %v, got %v", Styles.FilterMatchColor, fg)
+	}
+	for x, expected := range "/be" {
+		if r, _, _, _ := screen.GetContent(x, 5); r != expected {
+			t.Errorf("failed to draw query: expected %c at %d, got %c", expected, x, r)
+		}
+	}
+
+	// Scrolling to the right keeps the highlighted matches in place
+	l.SetRect(0, 0, 6, 6)
+	l.SetOffset(0, 2)
+	screen.Clear()
+	l.Draw(screen)
+	for x, expected := range "ueberr" {
+		r, _, style, _ := screen.GetContent(x, 0)
+		fg, _, _ := style.Decompose()
+		if r != expected {
+			t.Errorf("failed to scroll item: expected %c at %d, got %c", expected, x, r)
+		} else if matched := x == 1; matched != (fg == Styles.FilterMatchColor) {
+			t.Errorf("failed to highlight scrolled match at %d: expected %t", x, matched)
+		}
+	}
+	l.SetRect(0, 0, 20, 6)
+
+	// Escape restores all items and the previous selection
+	press(tcell.KeyEscape, 0)
+	if count := l.GetItemCount(); count != 5 {
+		t.Errorf("failed to restore items: expected 5, got %d", count)
+	} else if index := l.GetCurrentItemIndex(); index != 2 {
+		t.Errorf("failed to restore selection: expected 2, got %d", index)
+	}
+
+	// Enter selects the current match
+	var selected int
+	l.SetSelectedFunc(func(index int, item *ListItem) { selected = index })
+	l.SetFilter("an")
+	press(tcell.KeyEnter, 0)
+	if _, filtering := l.GetFilter(); filtering {
+		t.Errorf("failed to end filtering: expected all items")
+	} else if selected != 1 {
+		t.Errorf("failed to select match: expected 1, got %d", selected)
+	}
+	if len(changed) != 3 || changed[0] != "banana" || changed[1] != "blueberry" || changed[2] != "cherry" {
+		t.Errorf("failed to notify changes: expected [banana blueberry cherry], got %v", changed)
+	}
+}
+
+func TestListMultiSelect(t *testing.T) {
+	t.Parallel()
+
+	l := NewList().ShowSecondaryText(false).SetMultiSelect(true)
+	for _, text := range []string{"apple", "banana", "cherry", "date", "elderberry"} {
+		l.AddItem(NewListItem(text))
+	}
+	l.SetRect(0, 0, 20, 6)
+
+	var selected []string
+	l.SetSelectedItemsChangedFunc(func(items []*ListItem) {
+		selected = selected[:0]
+		for _, item := range items {
+			selected = append(selected, item.GetMainText())
+		}
+	})
+	handler := l.InputHandler()
+	press := func(key tcell.Key, r rune, mod tcell.ModMask) {
+		handler(tcell.NewEventKey(key, r, mod), func(p Widget) {})
+	}
+	expect := func(action string, expected string) {
+		t.Helper()
+		if got := strings.Join(selected, ","); got != expected {
+			t.Errorf("failed to %s: expected %s, got %s", action, expected, got)
+		}
+	}
+
+	// Space toggles the current item
+	press(tcell.KeyRune, ' ', tcell.ModNone)
+	expect("toggle item", "apple")
+
+	// Shift+Down selects a range from the item last toggled
+	press(tcell.KeyDown, 0, tcell.ModNone)
+	press(tcell.KeyDown, 0, tcell.ModNone)
+	press(tcell.KeyRune, ' ', tcell.ModNone)
+	press(tcell.KeyDown, 0, tcell.ModShift)
+	press(tcell.KeyDown, 0, tcell.ModShift)
+	expect("select range", "apple,cherry,date,elderberry")
+	press(tcell.KeyUp, 0, tcell.ModShift)
+	expect("shrink range", "apple,cherry,date")
+	if index := l.GetCurrentItemIndex(); index != 3 {
+		t.Errorf("failed to move with range: expected 3, got %d", index)
+	}
+
+	// The selection follows the items
+	l.Transform(TransformFirstItem)
+	l.RemoveItem(0)
+	if items := l.GetSelectedItems(); len(items) != 2 || items[0].GetMainText() != "cherry" {
+		t.Errorf("failed to keep selection: expected cherry,date, got %d items", len(items))
+	}
+	if !l.IsItemSelected(1) || l.IsItemSelected(0) {
+		t.Errorf("failed to shift selection: expected second item selected")
+	}
+
+	// Ctrl+A selects all items
+	press(tcell.KeyCtrlA, 0, tcell.ModCtrl)
+	expect("select all", "banana,cherry,date,elderberry")
+
+	// Without multi-selection, Space selects the item
+	var chosen string
+	l.SetMultiSelect(false).SetSelectedFunc(func(index int, item *ListItem) { chosen = item.GetMainText() })
+	press(tcell.KeyRune, ' ', tcell.ModNone)
+	if chosen != "banana" || len(l.GetSelectedItems()) != 0 {
+		t.Errorf("failed to disable multi-selection: expected banana, got %s", chosen)
+	}
+}
@@ -0,0 +1,377 @@
+package markup
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/malivvan/cui"
+	"github.com/malivvan/cui/markup/atom"
+)
+
+// bind builds the widget for n and, recursively, for its children.
+func (d *Document) bind(n *Node, parent *Element) (*Element, error) {
+	e := &Element{doc: d, node: n, parent: parent}
+	var err error
+	switch n.Tag {
+	case "box":
+		e.widget = bindBox[*cui.Box](cui.NewBox(), n)
+	case "button":
+		e.widget = bindBox[*cui.Button](cui.NewButton().SetLabel(textContent(n)), n)
+	case "checkbox":
+		e.widget = bindBox[*cui.CheckBox](cui.NewCheckBox().
+			SetLabel(n.GetAttr("label", "")).
+			SetMessage(textContent(n)).
+			SetChecked(attrBool(n, "checked")), n)
+	case "dropdown":
+		e.widget = bindBox[*cui.DropDown](bindDropDown(n), n)
+	case "flex":
+		e.widget, err = d.bindFlex(e)
+	case "form":
+		e.widget, err = d.bindForm(e)
+	case "grid":
+		e.widget, err = d.bindGrid(e)
+	case "input":
+		e.widget = bindBox[*cui.Input](bindInput(n), n)
+	case "list":
+		e.widget = bindBox[*cui.List](bindList(n), n)
+	case "panels":
+		e.widget, err = d.bindPanels(e)
+	case "progress":
+		e.widget = bindBox[*cui.Progress](cui.NewProgressBar().
+			SetMax(attrInt(n, "max", 100)).
+			SetProgress(attrInt(n, "value", 0)).
+			SetVertical(attrBool(n, "vertical")), n)
+	case "table":
+		e.widget = bindBox[*cui.Table](bindTable(n), n)
+	case "text":
+		e.widget = bindBox[*cui.Text](bindText(n), n)
+	case "tree":
+		e.widget = bindBox[*cui.Tree](bindTree(n), n)
+	default:
+		return nil, fmt.Errorf("unknown element <%s>", n.Tag)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if id := e.ID(); id != "" {
+		if _, ok := d.byID[id]; ok {
+			return nil, fmt.Errorf("duplicate id %q", id)
+		}
+		d.byID[id] = e
+	}
+	if attrBool(n, "disabled") {
+		e.SetDisabled(true)
+	}
+	d.byNode[n] = e
+	return e, nil
+}
+
+// bindChildren binds all widget children of e in document order and calls add
+// for each of them.
+func (d *Document) bindChildren(e *Element, add func(c *Element) error) error {
+	for c := range e.node.ChildNodes() {
+		if !isWidgetNode(c) {
+			continue
+		}
+		ce, err := d.bind(c, e)
+		if err != nil {
+			return err
+		}
+		e.children = append(e.children, ce)
+		if err := add(ce); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Document) bindFlex(e *Element) (cui.Widget, error) {
+	f := cui.NewFlex().SetFullScreen(attrBool(e.node, "fullscreen"))
+	switch dir := e.node.GetAttr("direction", "column"); dir {
+	case "row":
+		f.SetDirection(cui.FlexRow)
+	case "column":
+		f.SetDirection(cui.FlexColumn)
+	default:
+		return nil, fmt.Errorf("<flex>: invalid direction %q", dir)
+	}
+	err := d.bindChildren(e, func(c *Element) error {
+		size := attrInt(c.node, "size", 0)
+		grow := 0
+		if size == 0 {
+			grow = 1
+		}
+		f.AddItem(c.widget, size, attrInt(c.node, "grow", grow), attrBool(c.node, "focus"))
+		return nil
+	})
+	return bindBox[*cui.Flex](f, e.node), err
+}
+
+func (d *Document) bindGrid(e *Element) (cui.Widget, error) {
+	g := cui.NewGrid().
+		SetRows(attrInts(e.node, "rows")...).
+		SetColumns(attrInts(e.node, "columns")...).
+		SetBorders(attrBool(e.node, "borders"))
+	if gap := attrInts(e.node, "gap"); len(gap) == 2 {
+		g.SetGap(gap[0], gap[1])
+	}
+	err := d.bindChildren(e, func(c *Element) error {
+		g.AddItem(c.widget,
+			attrInt(c.node, "row", 0), attrInt(c.node, "column", 0),
+			attrInt(c.node, "rowspan", 1), attrInt(c.node, "colspan", 1),
+			attrInt(c.node, "minheight", 0), attrInt(c.node, "minwidth", 0),
+			attrBool(c.node, "focus"))
+		return nil
+	})
+	return bindBox[*cui.Grid](g, e.node), err
+}
+
+func (d *Document) bindForm(e *Element) (cui.Widget, error) {
+	f := cui.NewForm().SetHorizontal(attrBool(e.node, "horizontal"))
+	err := d.bindChildren(e, func(c *Element) error {
+		switch w := c.widget.(type) {
+		case *cui.Button:
+			// Form buttons are created by the form itself, rebind the element
+			// to that button so lookups by id return what is on screen.
+			f.AddButton(w.GetLabel(), nil)
+			c.widget = bindBox[*cui.Button](f.GetButton(f.GetButtonCount()-1), c.node)
+		case *cui.Input, *cui.CheckBox, *cui.DropDown:
+			f.AddFormItem(w)
+		default:
+			return fmt.Errorf("<form>: unsupported item <%s>", c.node.Tag)
+		}
+		return nil
+	})
+	return bindBox[*cui.Form](f, e.node), err
+}
+
+func (d *Document) bindPanels(e *Element) (cui.Widget, error) {
+	p := cui.NewPanels()
+	current := ""
+	err := d.bindChildren(e, func(c *Element) error {
+		name := c.ID()
+		if name == "" {
+			name = strconv.Itoa(len(e.children) - 1)
+		}
+		// Hidden panels are hidden by the panels, their widgets stay visible
+		// so that showing the panel shows them.
+		visible := !attrBool(c.node, "hidden")
+		c.widget.SetVisible(true)
+		p.AddPanel(name, c.widget, true, visible)
+		if visible && current == "" {
+			current = name
+		}
+		return nil
+	})
+	if current != "" {
+		p.SetCurrentPanel(current)
+	}
+	return bindBox[*cui.Panels](p, e.node), err
+}
+
+func bindDropDown(n *Node) *cui.DropDown {
+	dd := cui.NewDropDown().SetLabel(n.GetAttr("label", ""))
+	selected, count := -1, 0
+	for c := range n.ChildNodes() {
+		if c.Type != ElementNode || c.Atom != atom.Option {
+			continue
+		}
+		if attrBool(c, "selected") {
+			selected = count
+		}
+		dd.AddOptionsSimple(textContent(c))
+		count++
+	}
+	if selected >= 0 {
+		dd.SetCurrentOption(selected)
+	}
+	return dd
+}
+
+func bindInput(n *Node) *cui.Input {
+	i := cui.NewInputField().
+		SetLabel(n.GetAttr("label", "")).
+		SetText(n.GetAttr("value", "")).
+		SetPlaceholder(n.GetAttr("placeholder", "")).
+		SetFieldWidth(attrInt(n, "width", 0))
+	if mask := []rune(n.GetAttr("mask", "")); len(mask) > 0 {
+		i.SetMaskCharacter(mask[0])
+	}
+	return i
+}
+
+func bindList(n *Node) *cui.List {
+	l := cui.NewList()
+	for c := range n.ChildNodes() {
+		if c.Type != ElementNode || c.Atom != atom.Li {
+			continue
+		}
+		item := cui.NewListItem(textContent(c))
+		item.SetSecondaryText(c.GetAttr("secondary", ""))
+		l.AddItem(item)
+	}
+	return l
+}
+
+func bindTable(n *Node) *cui.Table {
+	t := cui.NewTable().SetBorders(attrBool(n, "borders"))
+	if fixed := attrInts(n, "fixed"); len(fixed) == 2 {
+		t.SetFixed(fixed[0], fixed[1])
+	}
+	row := 0
+	for tr := range n.Descendants() {
+		if tr.Type != ElementNode || tr.Atom != atom.Tr {
+			continue
+		}
+		column := 0
+		for td := range tr.ChildNodes() {
+			if td.Type != ElementNode || (td.Atom != atom.Td && td.Atom != atom.Th) {
+				continue
+			}
+			cell := cui.NewTableCell(textContent(td)).
+				SetAlign(attrAlign(td, cui.AlignLeft)).
+				SetExpansion(attrInt(td, "expand", 0))
+			if td.Atom == atom.Th {
+				cell.SetAttributes(tcell.AttrBold).SetSelectable(false)
+			}
+			t.SetCell(row, column, cell)
+			column++
+		}
+		row++
+	}
+	return t
+}
+
+func bindText(n *Node) *cui.Text {
+	return cui.NewTextView().
+		SetDynamicColors(attrBool(n, "dynamic")).
+		SetScrollable(!attrBool(n, "static")).
+		SetWrap(!attrBool(n, "nowrap")).
+		SetTextAlign(attrAlign(n, cui.AlignLeft)).
+		SetText(textContent(n))
+}
+
+func bindTree(n *Node) *cui.Tree {
+	t := cui.NewTreeView()
+	var walk func(n *Node) *cui.TreeNode
+	walk = func(n *Node) *cui.TreeNode {
+		var text strings.Builder
+		for c := range n.ChildNodes() {
+			if c.Type == TextNode {
+				text.WriteString(c.Tag)
+			}
+		}
+		tn := cui.NewTreeNode(strings.TrimSpace(text.String())).
+			SetExpanded(!attrBool(n, "collapsed"))
+		for c := range n.ChildNodes() {
+			if c.Type == ElementNode && c.Tag == "node" {
+				tn.AddChild(walk(c))
+			}
+		}
+		return tn
+	}
+	for c := range n.ChildNodes() {
+		if c.Type == ElementNode && c.Tag == "node" {
+			root := walk(c)
+			t.SetRoot(root).SetCurrentNode(root)
+			break
+		}
+	}
+	return t
+}
+
+// boxed is the part of the box API every widget shares.
+type boxed[T any] interface {
+	cui.Widget
+	SetTitle(title string) T
+	SetBorder(show bool) T
+	SetPadding(top, bottom, left, right int) T
+}
+
+// bindBox applies the attributes common to all widgets.
+func bindBox[T any](w boxed[T], n *Node) cui.Widget {
+	if title := n.GetAttr("title", ""); title != "" {
+		w.SetTitle(title)
+	}
+	if attrBool(n, "border") {
+		w.SetBorder(true)
+	}
+	switch p := attrInts(n, "padding"); len(p) {
+	case 1:
+		w.SetPadding(p[0], p[0], p[0], p[0])
+	case 2:
+		w.SetPadding(p[0], p[0], p[1], p[1])
+	case 4:
+		w.SetPadding(p[0], p[1], p[2], p[3])
+	}
+	if attrBool(n, "hidden") {
+		w.SetVisible(false)
+	}
+	return w
+}
+
+// isWidgetNode reports whether n is an element that is bound to a widget.
+func isWidgetNode(n *Node) bool {
+	return n.Type == ElementNode && n.Atom != atom.Style && n.Atom != atom.Script
+}
+
+// textContent returns the concatenated, trimmed text of all descendants of n.
+func textContent(n *Node) string {
+	var b strings.Builder
+	for c := range n.Descendants() {
+		if c.Type == TextNode {
+			b.WriteString(c.Tag)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// attrBool reports whether the boolean attribute key is set. Like in HTML the
+// mere presence of the attribute enables it unless its value is "false".
+func attrBool(n *Node, key string) bool {
+	for _, a := range n.Attrs {
+		if a.Key == key {
+			return a.Val != "false"
+		}
+	}
+	return false
+}
+
+// attrInt returns the integer value of the attribute key or def if the
+// attribute is missing or invalid.
+func attrInt(n *Node, key string, def int) int {
+	v, err := strconv.Atoi(strings.TrimSpace(n.GetAttr(key, "")))
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// attrInts returns the whitespace or comma separated integers of the
+// attribute key. Invalid entries are skipped.
+func attrInts(n *Node, key string) []int {
+	var ints []int
+	for _, f := range strings.FieldsFunc(n.GetAttr(key, ""), func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n'
+	}) {
+		if v, err := strconv.Atoi(f); err == nil {
+			ints = append(ints, v)
+		}
+	}
+	return ints
+}
+
+// attrAlign returns the alignment given by the align attribute.
+func attrAlign(n *Node, def int) int {
+	switch n.GetAttr("align", "") {
+	case "left":
+		return cui.AlignLeft
+	case "center":
+		return cui.AlignCenter
+	case "right":
+		return cui.AlignRight
+	}
+	return def
+}
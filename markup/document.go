@@ -0,0 +1,244 @@
+package markup
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/malivvan/cui"
+	"github.com/malivvan/cui/markup/atom"
+	"github.com/malivvan/cui/markup/qjs"
+)
+
+// Document is a parsed CML document whose elements have been bound to cui
+// widgets. Every element child of the document body (except <style> and
+// <script>) becomes a root, so a single file may describe several screens.
+type Document struct {
+	name   string
+	node   *Node
+	roots  []*Element
+	byID   map[string]*Element
+	byNode map[*Node]*Element
+
+	scripting     bool
+	runtimeOption qjs.Option
+	errorFunc     func(err error)
+	runtime       *qjs.Runtime
+	objects       map[*Element]*qjs.Value
+}
+
+// Element links a node of a Document to the widget that was built from it.
+type Element struct {
+	doc      *Document
+	node     *Node
+	widget   cui.Widget
+	parent   *Element
+	children []*Element
+
+	// The style setters of the widget and the values they had before styles
+	// were applied for the first time.
+	target  *styleTarget
+	initial styleValues
+
+	// The compiled event attributes and the listeners added by scripts,
+	// keyed by event type.
+	handlers  map[string][]byte
+	listeners map[string][]*qjs.Value
+}
+
+// NewFile parses src as a CML document and binds it to widgets. The name is
+// only used to identify the document, e.g. in error messages.
+func NewFile(name, src string, opts ...DocumentOption) (*Document, error) {
+	n, err := Parse(strings.NewReader(src))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return NewDocument(name, n, opts...)
+}
+
+// NewDocument binds an already parsed document tree to widgets, applies its
+// styles and runs its scripts.
+func NewDocument(name string, n *Node, opts ...DocumentOption) (*Document, error) {
+	d := &Document{
+		name:      name,
+		node:      n,
+		byID:      make(map[string]*Element),
+		byNode:    make(map[*Node]*Element),
+		scripting: true,
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	body := n
+	for c := range n.Descendants() {
+		if c.Type == ElementNode && c.Atom == atom.Body {
+			body = c
+			break
+		}
+	}
+	for c := range body.ChildNodes() {
+		if !isWidgetNode(c) {
+			continue
+		}
+		e, err := d.bind(c, nil)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		d.roots = append(d.roots, e)
+	}
+	if err := d.ApplyStyles(); err != nil {
+		return nil, err
+	}
+	if err := d.runScripts(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Name returns the name the document was created with.
+func (d *Document) Name() string {
+	return d.name
+}
+
+// Node returns the parsed document tree.
+func (d *Document) Node() *Node {
+	return d.node
+}
+
+// RootCount returns the number of root elements.
+func (d *Document) RootCount() int {
+	return len(d.roots)
+}
+
+// Root returns the root element at the given index or nil if there is none.
+func (d *Document) Root(index int) *Element {
+	if index < 0 || index >= len(d.roots) {
+		return nil
+	}
+	return d.roots[index]
+}
+
+// Roots returns all root elements in document order.
+func (d *Document) Roots() []*Element {
+	return d.roots
+}
+
+// Widget returns the widget of the first root element, suitable for
+// App.SetRoot, or nil if the document has no roots.
+func (d *Document) Widget() cui.Widget {
+	if len(d.roots) == 0 {
+		return nil
+	}
+	return d.roots[0].widget
+}
+
+// Element returns the element with the given id or nil if there is none.
+func (d *Document) Element(id string) *Element {
+	return d.byID[id]
+}
+
+// ElementOf returns the element bound to the given node or nil if the node
+// was not bound to a widget.
+func (d *Document) ElementOf(n *Node) *Element {
+	return d.byNode[n]
+}
+
+// Query returns all bound elements matching the given CSS selector in
+// document order.
+func (d *Document) Query(selector string) []*Element {
+	var elements []*Element
+	for _, n := range d.node.Query(selector) {
+		if e, ok := d.byNode[n]; ok {
+			elements = append(elements, e)
+		}
+	}
+	return elements
+}
+
+// Box returns the box with the given id or nil.
+func (d *Document) Box(id string) *cui.Box { return lookup[*cui.Box](d, id) }
+
+// Button returns the button with the given id or nil.
+func (d *Document) Button(id string) *cui.Button { return lookup[*cui.Button](d, id) }
+
+// CheckBox returns the checkbox with the given id or nil.
+func (d *Document) CheckBox(id string) *cui.CheckBox { return lookup[*cui.CheckBox](d, id) }
+
+// DropDown returns the dropdown with the given id or nil.
+func (d *Document) DropDown(id string) *cui.DropDown { return lookup[*cui.DropDown](d, id) }
+
+// Flex returns the flex container with the given id or nil.
+func (d *Document) Flex(id string) *cui.Flex { return lookup[*cui.Flex](d, id) }
+
+// Form returns the form with the given id or nil.
+func (d *Document) Form(id string) *cui.Form { return lookup[*cui.Form](d, id) }
+
+// Grid returns the grid with the given id or nil.
+func (d *Document) Grid(id string) *cui.Grid { return lookup[*cui.Grid](d, id) }
+
+// Input returns the input field with the given id or nil.
+func (d *Document) Input(id string) *cui.Input { return lookup[*cui.Input](d, id) }
+
+// List returns the list with the given id or nil.
+func (d *Document) List(id string) *cui.List { return lookup[*cui.List](d, id) }
+
+// Panels returns the panels container with the given id or nil.
+func (d *Document) Panels(id string) *cui.Panels { return lookup[*cui.Panels](d, id) }
+
+// Progress returns the progress bar with the given id or nil.
+func (d *Document) Progress(id string) *cui.Progress { return lookup[*cui.Progress](d, id) }
+
+// Table returns the table with the given id or nil.
+func (d *Document) Table(id string) *cui.Table { return lookup[*cui.Table](d, id) }
+
+// Text returns the text view with the given id or nil.
+func (d *Document) Text(id string) *cui.Text { return lookup[*cui.Text](d, id) }
+
+// Tree returns the tree view with the given id or nil.
+func (d *Document) Tree(id string) *cui.Tree { return lookup[*cui.Tree](d, id) }
+
+func lookup[T cui.Widget](d *Document, id string) (w T) {
+	if e, ok := d.byID[id]; ok {
+		w, _ = e.widget.(T)
+	}
+	return
+}
+
+// ID returns the id attribute of the element.
+func (e *Element) ID() string {
+	return e.node.GetAttr("id", "")
+}
+
+// Tag returns the tag name of the element.
+func (e *Element) Tag() string {
+	return e.node.Tag
+}
+
+// Classes returns the classes listed in the class attribute of the element.
+func (e *Element) Classes() []string {
+	return strings.Fields(e.node.GetAttr("class", ""))
+}
+
+// Node returns the document node the element was built from.
+func (e *Element) Node() *Node {
+	return e.node
+}
+
+// Widget returns the widget bound to the element.
+func (e *Element) Widget() cui.Widget {
+	return e.widget
+}
+
+// Document returns the document the element belongs to.
+func (e *Element) Document() *Document {
+	return e.doc
+}
+
+// Parent returns the parent element or nil for a root element.
+func (e *Element) Parent() *Element {
+	return e.parent
+}
+
+// Children returns the child elements in document order.
+func (e *Element) Children() []*Element {
+	return e.children
+}
@@ -0,0 +1,126 @@
+package markup
+
+import (
+	"strings"
+	"testing"
+)
+
+const testDocument = `<style>
+	button {color: black;}
+</style>
+<flex direction="row" id="top">
+	<button id="b11" size="1">first</button>
+	<button id="b12" class="xxx">second</button>
+	<flex direction="column" grow="1">
+		<text id="t1" align="center">hello</text>
+		<input id="i1" label="Name:" value="joe"/>
+		<list id="l1"><li secondary="one">a</li><li>b</li></list>
+	</flex>
+</flex>
+<grid id="g" rows="1 0" columns="10 0" borders>
+	<table id="tbl" row="1" colspan="2">
+		<tr><th>key</th><th>value</th></tr>
+		<tr><td>a</td><td>1</td></tr>
+	</table>
+	<dropdown id="dd" label="Pick:"><option>x</option><option selected>y</option></dropdown>
+</grid>
+<script>console.log("ok")</script>`
+
+func TestNewFile(t *testing.T) {
+	t.Parallel()
+
+	doc, err := NewFile("test.cml", testDocument)
+	if err != nil {
+		t.Fatalf("failed to bind document: %s", err)
+	}
+
+	if doc.RootCount() != 2 {
+		t.Fatalf("expected 2 roots, got %d", doc.RootCount())
+	}
+	if doc.Root(0).ID() != "top" || doc.Widget() != doc.Root(0).Widget() {
+		t.Errorf("unexpected first root %q", doc.Root(0).ID())
+	}
+	if doc.Root(2) != nil {
+		t.Errorf("expected nil for out of range root")
+	}
+	if doc.Flex("top") == nil || doc.Grid("g") == nil {
+		t.Errorf("failed to look up containers")
+	}
+	if got := doc.Button("b12").GetLabel(); got != "second" {
+		t.Errorf("incorrect button label: expected second, got %s", got)
+	}
+	if doc.Button("t1") != nil {
+		t.Errorf("expected nil when looking up a widget of another type")
+	}
+	if got := doc.Text("t1").GetText(true); got != "hello" {
+		t.Errorf("incorrect text: expected hello, got %s", got)
+	}
+	if got := doc.Input("i1").GetText(); got != "joe" {
+		t.Errorf("incorrect input value: expected joe, got %s", got)
+	}
+	if got := doc.List("l1").GetItemCount(); got != 2 {
+		t.Errorf("incorrect list item count: expected 2, got %d", got)
+	}
+	if got := doc.Table("tbl").GetRowCount(); got != 2 {
+		t.Errorf("incorrect table row count: expected 2, got %d", got)
+	}
+	if got := doc.Table("tbl").GetCell(1, 1).GetText(); got != "1" {
+		t.Errorf("incorrect table cell: expected 1, got %s", got)
+	}
+	if index, _ := doc.DropDown("dd").GetCurrentOption(); index != 1 {
+		t.Errorf("incorrect dropdown option: expected 1, got %d", index)
+	}
+
+	e := doc.Element("b11")
+	if e.Parent() != doc.Root(0) || len(e.Parent().Children()) != 3 {
+		t.Errorf("unexpected element hierarchy")
+	}
+	if got := doc.Query(".xxx"); len(got) != 1 || got[0].ID() != "b12" {
+		t.Errorf("unexpected query result %v", got)
+	}
+}
+
+func TestNewFileErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, src := range []string{
+		`<unknown></unknown>`,
+		`<flex direction="diagonal"></flex>`,
+		`<flex><button id="a">a</button><button id="a">b</button></flex>`,
+	} {
+		if _, err := NewFile("test.cml", src); err == nil {
+			t.Errorf("expected error for %s", src)
+		} else if !strings.HasPrefix(err.Error(), "test.cml: ") {
+			t.Errorf("expected error to be prefixed with the document name, got %s", err)
+		}
+	}
+}
+
+func TestNewFilePanelsForm(t *testing.T) {
+	t.Parallel()
+
+	doc, err := NewFile("test.cml", `<panels id="p">
+	<text id="first">first</text>
+	<text id="second" hidden>second</text>
+</panels>
+<form id="f"><button id="ok" title="Save" border>OK</button></form>`)
+	if err != nil {
+		t.Fatalf("failed to bind document: %s", err)
+	}
+
+	// Hidden panels show their widget when shown
+	p := doc.Panels("p")
+	if name, _ := p.GetFrontPanel(); name != "first" {
+		t.Errorf("incorrect front panel: expected first, got %s", name)
+	}
+	p.ShowPanel("second")
+	if !doc.Text("second").GetVisible() {
+		t.Errorf("failed to show hidden panel: expected visible widget")
+	}
+
+	// Form buttons keep their attributes
+	b := doc.Button("ok")
+	if b != doc.Form("f").GetButton(0) || b.GetTitle() != "Save" || !b.GetBorder() {
+		t.Errorf("failed to bind form button: got title %q and border %t", b.GetTitle(), b.GetBorder())
+	}
+}
@@ -52,6 +52,7 @@ type Node struct {
 	Atom        atom.Atom
 	Attrs       []Attribute
 	Style       []Property
+	State       NodeState
 	Parent      *Node
 	FirstChild  *Node
 	LastChild   *Node
@@ -59,6 +60,15 @@ type Node struct {
 	NextSibling *Node
 }
 
+// NodeState holds the dynamic state of an element which is matched by the
+// :focus and :disabled pseudo-classes.
+type NodeState uint8
+
+const (
+	StateFocus NodeState = 1 << iota
+	StateDisabled
+)
+
 type Property struct {
 	Key string
 	Val string
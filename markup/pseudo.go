@@ -385,7 +385,7 @@ type enabledPseudoClassSelector struct {
 }
 
 func (c enabledPseudoClassSelector) Match(n *Node) bool {
-	if n.Type != ElementNode {
+	if n.Type != ElementNode || n.State&StateDisabled != 0 {
 		return false
 	}
 	switch n.Atom {
@@ -407,6 +407,9 @@ func (c disabledPseudoClassSelector) Match(n *Node) bool {
 	if n.Type != ElementNode {
 		return false
 	}
+	if n.State&StateDisabled != 0 {
+		return true
+	}
 	switch n.Atom {
 	case atom.Optgroup, atom.Menuitem, atom.Fieldset:
 		return hasAttr(n, "disabled")
@@ -416,6 +419,15 @@ func (c disabledPseudoClassSelector) Match(n *Node) bool {
 	return false
 }
 
+type focusPseudoClassSelector struct {
+	abstractPseudoClass
+}
+
+// Match implements :focus
+func (c focusPseudoClassSelector) Match(n *Node) bool {
+	return n.Type == ElementNode && n.State&StateFocus != 0
+}
+
 func hasLegendInPreviousSiblings(n *Node) bool {
 	for s := n.PrevSibling; s != nil; s = s.PrevSibling {
 		if s.Atom == atom.Legend {
@@ -0,0 +1,392 @@
+package qjs
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+)
+
+// The syntax tree is what Compile serializes as bytecode, which is why all
+// node fields are exported.
+
+// Node is a node of the syntax tree.
+type Node interface{}
+
+// Program is a compiled script.
+type Program struct {
+	File   string
+	Module bool
+	Body   []Node
+}
+
+type (
+	// NumberLit is a numeric literal.
+	NumberLit struct{ Value float64 }
+	// StringLit is a string literal.
+	StringLit struct{ Value string }
+	// TemplateLit is a template literal; Quasis has one more entry than Exprs.
+	TemplateLit struct {
+		Quasis []string
+		Exprs  []Node
+	}
+	// BoolLit is true or false.
+	BoolLit struct{ Value bool }
+	// NullLit is null.
+	NullLit struct{}
+	// Ident is a reference to a binding.
+	Ident struct{ Name string }
+	// This is the this keyword.
+	This struct{}
+	// ArrayLit is an array literal.
+	ArrayLit struct{ Elems []Node }
+	// ObjectLit is an object literal.
+	ObjectLit struct{ Props []Prop }
+	// Prop is a property of an object literal.
+	Prop struct {
+		Key   string
+		Value Node
+	}
+	// FuncLit is a function expression, declaration or arrow function.
+	FuncLit struct {
+		Name   string
+		Params []string
+		Body   []Node
+		Arrow  bool
+		Expr   Node // body of a concise arrow function
+	}
+	// Unary is a unary operation.
+	Unary struct {
+		Op string
+		X  Node
+	}
+	// Update is an increment or decrement.
+	Update struct {
+		Op     string
+		Prefix bool
+		X      Node
+	}
+	// Binary is a binary operation, including the logical operators.
+	Binary struct {
+		Op   string
+		L, R Node
+	}
+	// Assign is an assignment, Op is "=" or a compound operator.
+	Assign struct {
+		Op     string
+		Target Node
+		Value  Node
+	}
+	// Cond is a conditional expression.
+	Cond struct{ Test, Then, Else Node }
+	// Call is a function call.
+	Call struct {
+		Fn   Node
+		Args []Node
+	}
+	// NewExpr is a constructor call.
+	NewExpr struct {
+		Fn   Node
+		Args []Node
+	}
+	// Member is a property access, Index is set for computed access.
+	Member struct {
+		X     Node
+		Name  string
+		Index Node
+	}
+)
+
+type (
+	// VarDecl declares variables with var, let or const.
+	VarDecl struct {
+		Kind  string
+		Decls []Declarator
+	}
+	// Declarator is a single declared variable.
+	Declarator struct {
+		Name string
+		Init Node
+	}
+	// FuncDecl is a function declaration.
+	FuncDecl struct{ Fn *FuncLit }
+	// ExprStmt is an expression statement.
+	ExprStmt struct{ X Node }
+	// Return is a return statement.
+	Return struct{ X Node }
+	// If is an if statement.
+	If struct{ Test, Then, Else Node }
+	// For is a classic for loop.
+	For struct{ Init, Test, Update, Body Node }
+	// ForOf is a for...of loop.
+	ForOf struct {
+		Kind string
+		Name string
+		X    Node
+		Body Node
+	}
+	// While is a while loop.
+	While struct{ Test, Body Node }
+	// Break is a break statement.
+	Break struct{}
+	// Continue is a continue statement.
+	Continue struct{}
+	// Block is a block statement.
+	Block struct{ Body []Node }
+	// Throw is a throw statement.
+	Throw struct{ X Node }
+	// Try is a try statement.
+	Try struct {
+		Body    *Block
+		Param   string
+		Catch   *Block
+		Finally *Block
+	}
+	// Export is an export statement. Default exports have X set, named
+	// exports Decl.
+	Export struct {
+		X    Node
+		Decl Node
+	}
+	// Empty is an empty statement.
+	Empty struct{}
+)
+
+func init() {
+	for _, n := range []Node{
+		&NumberLit{}, &StringLit{}, &TemplateLit{}, &BoolLit{}, &NullLit{}, &Ident{}, &This{},
+		&ArrayLit{}, &ObjectLit{}, &FuncLit{}, &Unary{}, &Update{}, &Binary{}, &Assign{},
+		&Cond{}, &Call{}, &NewExpr{}, &Member{},
+		&VarDecl{}, &FuncDecl{}, &ExprStmt{}, &Return{}, &If{}, &For{}, &ForOf{}, &While{},
+		&Break{}, &Continue{}, &Block{}, &Throw{}, &Try{}, &Export{}, &Empty{},
+	} {
+		gob.Register(n)
+	}
+}
+
+var (
+	unaryOps  = map[string]bool{"!": true, "-": true, "+": true, "typeof": true}
+	updateOps = map[string]bool{"++": true, "--": true}
+	varKinds  = map[string]bool{"var": true, "let": true, "const": true}
+)
+
+// check verifies a decoded syntax tree. Compile only produces valid trees,
+// but bytecode may come from a cache file or the caller, so Eval checks it
+// before running it.
+func (prog *Program) check() error {
+	for _, stmt := range prog.Body {
+		if e, ok := stmt.(*Export); ok && prog.Module {
+			if e.X != nil && e.Decl == nil {
+				stmt = &ExprStmt{X: e.X}
+			} else if d, ok := e.Decl.(*VarDecl); ok && e.X == nil {
+				stmt = d
+			} else if d, ok := e.Decl.(*FuncDecl); ok && e.X == nil {
+				stmt = d
+			} else {
+				return errors.New("invalid export")
+			}
+		}
+		if err := checkStmt(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkStmts(list []Node) error {
+	for _, stmt := range list {
+		if err := checkStmt(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkOptional checks a statement or expression which may be missing.
+func checkOptional(n Node, check func(Node) error) error {
+	if n == nil {
+		return nil
+	}
+	return check(n)
+}
+
+func checkStmt(n Node) error {
+	switch s := n.(type) {
+	case *Empty, *Break, *Continue:
+		return nil
+	case *Block:
+		return checkStmts(s.Body)
+	case *ExprStmt:
+		return checkExpr(s.X)
+	case *VarDecl:
+		if !varKinds[s.Kind] || len(s.Decls) == 0 {
+			return errors.New("invalid variable declaration")
+		}
+		for _, d := range s.Decls {
+			if d.Name == "" {
+				return errors.New("invalid variable declaration")
+			}
+			if err := checkOptional(d.Init, checkExpr); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *FuncDecl:
+		if s.Fn == nil || s.Fn.Name == "" || s.Fn.Arrow {
+			return errors.New("invalid function declaration")
+		}
+		return checkFunc(s.Fn)
+	case *Return:
+		return checkOptional(s.X, checkExpr)
+	case *Throw:
+		return checkExpr(s.X)
+	case *If:
+		if err := checkExpr(s.Test); err != nil {
+			return err
+		}
+		if err := checkStmt(s.Then); err != nil {
+			return err
+		}
+		return checkOptional(s.Else, checkStmt)
+	case *For:
+		if err := checkOptional(s.Init, checkStmt); err != nil {
+			return err
+		}
+		if err := checkOptional(s.Test, checkExpr); err != nil {
+			return err
+		}
+		if err := checkOptional(s.Update, checkExpr); err != nil {
+			return err
+		}
+		return checkStmt(s.Body)
+	case *ForOf:
+		if s.Name == "" || s.Kind != "" && !varKinds[s.Kind] {
+			return errors.New("invalid for...of loop")
+		}
+		if err := checkExpr(s.X); err != nil {
+			return err
+		}
+		return checkStmt(s.Body)
+	case *While:
+		if err := checkExpr(s.Test); err != nil {
+			return err
+		}
+		return checkStmt(s.Body)
+	case *Try:
+		if s.Body == nil || s.Catch == nil && s.Finally == nil {
+			return errors.New("invalid try statement")
+		}
+		for _, b := range []*Block{s.Body, s.Catch, s.Finally} {
+			if b != nil {
+				if err := checkStmts(b.Body); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unexpected statement %T", n)
+}
+
+func checkExprs(list []Node) error {
+	for _, x := range list {
+		if err := checkExpr(x); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkFunc(fn *FuncLit) error {
+	for _, p := range fn.Params {
+		if p == "" {
+			return errors.New("invalid parameter")
+		}
+	}
+	if fn.Expr != nil {
+		if !fn.Arrow || len(fn.Body) > 0 {
+			return errors.New("invalid function")
+		}
+		return checkExpr(fn.Expr)
+	}
+	return checkStmts(fn.Body)
+}
+
+func checkExpr(n Node) error {
+	switch x := n.(type) {
+	case *NumberLit, *StringLit, *BoolLit, *NullLit, *This:
+		return nil
+	case *Ident:
+		if x.Name == "" {
+			return errors.New("invalid identifier")
+		}
+		return nil
+	case *TemplateLit:
+		if len(x.Quasis) != len(x.Exprs)+1 {
+			return errors.New("invalid template literal")
+		}
+		return checkExprs(x.Exprs)
+	case *ArrayLit:
+		return checkExprs(x.Elems)
+	case *ObjectLit:
+		for _, p := range x.Props {
+			if err := checkExpr(p.Value); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *FuncLit:
+		return checkFunc(x)
+	case *Unary:
+		if !unaryOps[x.Op] {
+			return fmt.Errorf("invalid operator %q", x.Op)
+		}
+		return checkExpr(x.X)
+	case *Update:
+		if !updateOps[x.Op] {
+			return fmt.Errorf("invalid operator %q", x.Op)
+		}
+		return checkTarget(x.X)
+	case *Binary:
+		if _, ok := binaryPrecedence[x.Op]; !ok {
+			return fmt.Errorf("invalid operator %q", x.Op)
+		}
+		if err := checkExpr(x.L); err != nil {
+			return err
+		}
+		return checkExpr(x.R)
+	case *Assign:
+		if !assignOps[x.Op] {
+			return fmt.Errorf("invalid operator %q", x.Op)
+		}
+		if err := checkTarget(x.Target); err != nil {
+			return err
+		}
+		return checkExpr(x.Value)
+	case *Cond:
+		return checkExprs([]Node{x.Test, x.Then, x.Else})
+	case *Call:
+		if err := checkExpr(x.Fn); err != nil {
+			return err
+		}
+		return checkExprs(x.Args)
+	case *NewExpr:
+		if err := checkExpr(x.Fn); err != nil {
+			return err
+		}
+		return checkExprs(x.Args)
+	case *Member:
+		if err := checkExpr(x.X); err != nil {
+			return err
+		}
+		return checkOptional(x.Index, checkExpr)
+	}
+	return fmt.Errorf("unexpected expression %T", n)
+}
+
+func checkTarget(n Node) error {
+	switch n.(type) {
+	case *Ident, *Member:
+		return checkExpr(n)
+	}
+	return errors.New("invalid assignment target")
+}
@@ -0,0 +1,915 @@
+package qjs
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type nativeFunc = func(this interface{}, args []interface{}) (interface{}, error)
+
+func arg(args []interface{}, i int) interface{} {
+	if i < len(args) {
+		return args[i]
+	}
+	return undefined
+}
+
+func (in *interp) method(o *object, name string, arity int, fn nativeFunc) {
+	o.hide(name, in.newNative(name, arity, fn))
+}
+
+// constructor creates a global constructor function with the given
+// prototype.
+func (in *interp) constructor(name string, arity int, proto *object, fn nativeFunc, ctor func(args []interface{}) (interface{}, error)) *object {
+	c := in.newNative(name, arity, fn)
+	c.ctor = ctor
+	c.hide("prototype", proto)
+	proto.hide("constructor", c)
+	in.global.hide(name, c)
+	return c
+}
+
+func newInterp(console io.Writer, maxDepth int, maxSteps uint64) *interp {
+	in := &interp{
+		console:    console,
+		maxDepth:   maxDepth,
+		maxSteps:   maxSteps,
+		errorCtors: make(map[string]*object),
+		ret:        undefined,
+	}
+	in.objectProto = &object{class: classObject}
+	in.functionProto = &object{class: classFunction, proto: in.objectProto, native: func(interface{}, []interface{}) (interface{}, error) {
+		return undefined, nil
+	}}
+	in.arrayProto = &object{class: classObject, proto: in.objectProto}
+	in.stringProto = &object{class: classObject, proto: in.objectProto}
+	in.numberProto = &object{class: classObject, proto: in.objectProto}
+	in.booleanProto = &object{class: classObject, proto: in.objectProto}
+	in.errorProto = &object{class: classObject, proto: in.objectProto}
+	in.global = in.newObject()
+	in.globals = &scope{vars: make(map[string]*binding), fn: true, global: true, hasThis: true, this: in.global}
+
+	in.global.hide("globalThis", in.global)
+	in.global.hide("undefined", undefined)
+	in.global.hide("NaN", math.NaN())
+	in.global.hide("Infinity", math.Inf(1))
+	in.setupGlobals()
+	in.setupObject()
+	in.setupFunction()
+	in.setupArray()
+	in.setupString()
+	in.setupNumber()
+	in.setupErrors()
+	in.setupMath()
+	in.setupJSON()
+	in.setupConsole()
+	return in
+}
+
+func (in *interp) setupGlobals() {
+	in.global.hide("parseInt", in.newNative("parseInt", 2, func(_ interface{}, args []interface{}) (interface{}, error) {
+		s, err := in.toString(arg(args, 0))
+		if err != nil {
+			return nil, err
+		}
+		radix := toInteger(numberOf(arg(args, 1)))
+		s = strings.TrimSpace(s)
+		sign := 1.0
+		if strings.HasPrefix(s, "-") {
+			sign, s = -1, s[1:]
+		} else {
+			s = strings.TrimPrefix(s, "+")
+		}
+		if (radix == 0 || radix == 16) && (strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X")) {
+			s, radix = s[2:], 16
+		}
+		if radix == 0 {
+			radix = 10
+		}
+		if radix < 2 || radix > 36 {
+			return math.NaN(), nil
+		}
+		end := 0
+		for end < len(s) {
+			d := digitValue(s[end])
+			if d < 0 || d >= radix {
+				break
+			}
+			end++
+		}
+		if end == 0 {
+			return math.NaN(), nil
+		}
+		v := 0.0
+		for _, c := range []byte(s[:end]) {
+			v = v*float64(radix) + float64(digitValue(c))
+		}
+		return sign * v, nil
+	}))
+	in.global.hide("parseFloat", in.newNative("parseFloat", 1, func(_ interface{}, args []interface{}) (interface{}, error) {
+		s, err := in.toString(arg(args, 0))
+		if err != nil {
+			return nil, err
+		}
+		s = strings.TrimSpace(s)
+		for _, prefix := range []string{"Infinity", "+Infinity", "-Infinity"} {
+			if strings.HasPrefix(s, prefix) {
+				return parseNumber(prefix), nil
+			}
+		}
+		end := 0
+		for end < len(s) && strings.IndexByte("0123456789.eE+-", s[end]) >= 0 {
+			end++
+		}
+		for ; end > 0; end-- {
+			if v, err := strconv.ParseFloat(s[:end], 64); err == nil {
+				return v, nil
+			}
+		}
+		return math.NaN(), nil
+	}))
+	in.global.hide("isNaN", in.newNative("isNaN", 1, func(_ interface{}, args []interface{}) (interface{}, error) {
+		f, err := in.toNumber(arg(args, 0))
+		return math.IsNaN(f), err
+	}))
+	in.constructor("Boolean", 1, in.booleanProto, func(_ interface{}, args []interface{}) (interface{}, error) {
+		return toBoolean(arg(args, 0)), nil
+	}, nil)
+	in.method(in.booleanProto, "toString", 0, func(this interface{}, _ []interface{}) (interface{}, error) {
+		return stringOf(this), nil
+	})
+}
+
+func digitValue(c byte) int {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0')
+	case c >= 'a' && c <= 'z':
+		return int(c-'a') + 10
+	case c >= 'A' && c <= 'Z':
+		return int(c-'A') + 10
+	}
+	return -1
+}
+
+func (in *interp) setupObject() {
+	c := in.newObject()
+	in.global.hide("Object", c)
+	entries := func(kind string) nativeFunc {
+		return func(_ interface{}, args []interface{}) (interface{}, error) {
+			o, ok := arg(args, 0).(*object)
+			if !ok {
+				if s, ok := arg(args, 0).(string); ok {
+					o = in.newArray(nil)
+					for _, r := range s {
+						o.array = append(o.array, string(r))
+					}
+				} else {
+					return in.newArray(nil), nil
+				}
+			}
+			var items []interface{}
+			for _, k := range o.ownKeys() {
+				if kind == "keys" {
+					items = append(items, k)
+					continue
+				}
+				v, err := in.getFrom(o, k)
+				if err != nil {
+					return nil, err
+				}
+				if kind == "values" {
+					items = append(items, v)
+				} else {
+					items = append(items, in.newArray([]interface{}{k, v}))
+				}
+			}
+			return in.newArray(items), nil
+		}
+	}
+	in.method(c, "keys", 1, entries("keys"))
+	in.method(c, "values", 1, entries("values"))
+	in.method(c, "entries", 1, entries("entries"))
+	in.method(c, "assign", 2, func(_ interface{}, args []interface{}) (interface{}, error) {
+		target, ok := arg(args, 0).(*object)
+		if !ok {
+			return nil, in.throwf("TypeError", "cannot convert %s to object", typeName(arg(args, 0)))
+		}
+		for _, a := range args[1:] {
+			src, ok := a.(*object)
+			if !ok {
+				continue
+			}
+			for _, k := range src.ownKeys() {
+				v, err := in.getFrom(src, k)
+				if err != nil {
+					return nil, err
+				}
+				if err := in.set(target, k, v); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return target, nil
+	})
+	in.method(in.objectProto, "toString", 0, func(this interface{}, _ []interface{}) (interface{}, error) {
+		return "[object Object]", nil
+	})
+}
+
+func (in *interp) setupFunction() {
+	in.method(in.functionProto, "toString", 0, func(this interface{}, _ []interface{}) (interface{}, error) {
+		name, err := in.get(this, "name")
+		if err != nil {
+			return nil, err
+		}
+		return fmt.Sprintf("function %s() { [native code] }", stringOf(name)), nil
+	})
+}
+
+// thisArray returns the array a method is called on.
+func (in *interp) thisArray(this interface{}, name string) (*object, error) {
+	if o, ok := this.(*object); ok && o.class == classArray {
+		return o, nil
+	}
+	return nil, in.throwf("TypeError", "Array.prototype.%s called on non-array", name)
+}
+
+// relative resolves a relative index argument against a length.
+func relative(v interface{}, n, def int) int {
+	if _, ok := v.(undefinedType); ok {
+		return def
+	}
+	i := toInteger(numberOf(v))
+	if i < 0 {
+		return max(n+i, 0)
+	}
+	return min(i, n)
+}
+
+// callback returns the function argument of an iteration method.
+func (in *interp) callback(args []interface{}, name string) (*object, error) {
+	f, ok := arg(args, 0).(*object)
+	if !ok || !f.callable() {
+		return nil, in.throwf("TypeError", "%s is not a function", stringOf(arg(args, 0)))
+	}
+	return f, nil
+}
+
+func (in *interp) setupArray() {
+	c := in.newObject()
+	in.global.hide("Array", c)
+	in.method(c, "isArray", 1, func(_ interface{}, args []interface{}) (interface{}, error) {
+		o, ok := arg(args, 0).(*object)
+		return ok && o.class == classArray, nil
+	})
+	p := in.arrayProto
+	in.method(p, "push", 1, func(this interface{}, args []interface{}) (interface{}, error) {
+		a, err := in.thisArray(this, "push")
+		if err != nil {
+			return nil, err
+		}
+		a.array = append(a.array, args...)
+		return float64(len(a.array)), nil
+	})
+	in.method(p, "pop", 0, func(this interface{}, _ []interface{}) (interface{}, error) {
+		a, err := in.thisArray(this, "pop")
+		if err != nil || len(a.array) == 0 {
+			return undefined, err
+		}
+		v := a.array[len(a.array)-1]
+		a.array = a.array[:len(a.array)-1]
+		return v, nil
+	})
+	in.method(p, "shift", 0, func(this interface{}, _ []interface{}) (interface{}, error) {
+		a, err := in.thisArray(this, "shift")
+		if err != nil || len(a.array) == 0 {
+			return undefined, err
+		}
+		v := a.array[0]
+		a.array = append([]interface{}(nil), a.array[1:]...)
+		return v, nil
+	})
+	in.method(p, "unshift", 1, func(this interface{}, args []interface{}) (interface{}, error) {
+		a, err := in.thisArray(this, "unshift")
+		if err != nil {
+			return nil, err
+		}
+		a.array = append(append([]interface{}(nil), args...), a.array...)
+		return float64(len(a.array)), nil
+	})
+	in.method(p, "slice", 2, func(this interface{}, args []interface{}) (interface{}, error) {
+		a, err := in.thisArray(this, "slice")
+		if err != nil {
+			return nil, err
+		}
+		n := len(a.array)
+		start, end := relative(arg(args, 0), n, 0), relative(arg(args, 1), n, n)
+		if start >= end {
+			return in.newArray(nil), nil
+		}
+		return in.newArray(append([]interface{}(nil), a.array[start:end]...)), nil
+	})
+	in.method(p, "concat", 1, func(this interface{}, args []interface{}) (interface{}, error) {
+		a, err := in.thisArray(this, "concat")
+		if err != nil {
+			return nil, err
+		}
+		items := append([]interface{}(nil), a.array...)
+		for _, v := range args {
+			if o, ok := v.(*object); ok && o.class == classArray {
+				items = append(items, o.array...)
+			} else {
+				items = append(items, v)
+			}
+		}
+		return in.newArray(items), nil
+	})
+	join := func(this interface{}, args []interface{}) (interface{}, error) {
+		a, err := in.thisArray(this, "join")
+		if err != nil {
+			return nil, err
+		}
+		sep := ","
+		if s, ok := arg(args, 0).(string); ok {
+			sep = s
+		}
+		parts := make([]string, len(a.array))
+		for i, v := range a.array {
+			if isNullish(v) {
+				continue
+			}
+			if parts[i], err = in.toString(v); err != nil {
+				return nil, err
+			}
+		}
+		return strings.Join(parts, sep), nil
+	}
+	in.method(p, "join", 1, join)
+	in.method(p, "toString", 0, join)
+	in.method(p, "reverse", 0, func(this interface{}, _ []interface{}) (interface{}, error) {
+		a, err := in.thisArray(this, "reverse")
+		if err != nil {
+			return nil, err
+		}
+		for i, j := 0, len(a.array)-1; i < j; i, j = i+1, j-1 {
+			a.array[i], a.array[j] = a.array[j], a.array[i]
+		}
+		return a, nil
+	})
+	in.method(p, "indexOf", 1, func(this interface{}, args []interface{}) (interface{}, error) {
+		a, err := in.thisArray(this, "indexOf")
+		if err != nil {
+			return nil, err
+		}
+		for i := relative(arg(args, 1), len(a.array), 0); i < len(a.array); i++ {
+			if strictEquals(a.array[i], arg(args, 0)) {
+				return float64(i), nil
+			}
+		}
+		return -1.0, nil
+	})
+	in.method(p, "includes", 1, func(this interface{}, args []interface{}) (interface{}, error) {
+		a, err := in.thisArray(this, "includes")
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range a.array {
+			if sameValueZero(v, arg(args, 0)) {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	// iteration methods share the callback loop
+	iteration := func(name string, fn func(a *object, results []interface{}) (interface{}, error), stop func(v interface{}) bool) {
+		in.method(p, name, 1, func(this interface{}, args []interface{}) (interface{}, error) {
+			a, err := in.thisArray(this, name)
+			if err != nil {
+				return nil, err
+			}
+			f, err := in.callback(args, name)
+			if err != nil {
+				return nil, err
+			}
+			var results []interface{}
+			for i := 0; i < len(a.array); i++ {
+				r, err := in.call(f, arg(args, 1), []interface{}{a.array[i], float64(i), a})
+				if err != nil {
+					return nil, err
+				}
+				results = append(results, r)
+				if stop != nil && stop(r) {
+					break
+				}
+			}
+			return fn(a, results)
+		})
+	}
+	iteration("forEach", func(*object, []interface{}) (interface{}, error) {
+		return undefined, nil
+	}, nil)
+	iteration("map", func(_ *object, results []interface{}) (interface{}, error) {
+		return in.newArray(results), nil
+	}, nil)
+	iteration("filter", func(a *object, results []interface{}) (interface{}, error) {
+		var items []interface{}
+		for i, r := range results {
+			if toBoolean(r) {
+				items = append(items, a.array[i])
+			}
+		}
+		return in.newArray(items), nil
+	}, nil)
+	iteration("some", func(_ *object, results []interface{}) (interface{}, error) {
+		return len(results) > 0 && toBoolean(results[len(results)-1]), nil
+	}, toBoolean)
+	iteration("every", func(_ *object, results []interface{}) (interface{}, error) {
+		return len(results) == 0 || toBoolean(results[len(results)-1]), nil
+	}, func(v interface{}) bool { return !toBoolean(v) })
+	iteration("find", func(a *object, results []interface{}) (interface{}, error) {
+		if i := len(results) - 1; i >= 0 && toBoolean(results[i]) {
+			return a.array[i], nil
+		}
+		return undefined, nil
+	}, toBoolean)
+	iteration("findIndex", func(_ *object, results []interface{}) (interface{}, error) {
+		if i := len(results) - 1; i >= 0 && toBoolean(results[i]) {
+			return float64(i), nil
+		}
+		return -1.0, nil
+	}, toBoolean)
+	in.method(p, "reduce", 2, func(this interface{}, args []interface{}) (interface{}, error) {
+		a, err := in.thisArray(this, "reduce")
+		if err != nil {
+			return nil, err
+		}
+		f, err := in.callback(args, "reduce")
+		if err != nil {
+			return nil, err
+		}
+		i := 0
+		acc := arg(args, 1)
+		if len(args) < 2 {
+			if len(a.array) == 0 {
+				return nil, in.throwf("TypeError", "reduce of empty array with no initial value")
+			}
+			acc, i = a.array[0], 1
+		}
+		for ; i < len(a.array); i++ {
+			if acc, err = in.call(f, undefined, []interface{}{acc, a.array[i], float64(i), a}); err != nil {
+				return nil, err
+			}
+		}
+		return acc, nil
+	})
+	in.method(p, "sort", 1, func(this interface{}, args []interface{}) (interface{}, error) {
+		a, err := in.thisArray(this, "sort")
+		if err != nil {
+			return nil, err
+		}
+		cmp, _ := arg(args, 0).(*object)
+		var sortErr error
+		sort.SliceStable(a.array, func(i, j int) bool {
+			x, y := a.array[i], a.array[j]
+			if sortErr != nil {
+				return false
+			}
+			_, xu := x.(undefinedType)
+			_, yu := y.(undefinedType)
+			if xu || yu {
+				return !xu && yu
+			}
+			if cmp != nil && cmp.callable() {
+				r, err := in.call(cmp, undefined, []interface{}{x, y})
+				if err != nil {
+					sortErr = err
+					return false
+				}
+				return numberOf(r) < 0
+			}
+			xs, err := in.toString(x)
+			if err != nil {
+				sortErr = err
+				return false
+			}
+			ys, err := in.toString(y)
+			if err != nil {
+				sortErr = err
+				return false
+			}
+			return xs < ys
+		})
+		return a, sortErr
+	})
+}
+
+func (in *interp) setupString() {
+	in.constructor("String", 1, in.stringProto, func(_ interface{}, args []interface{}) (interface{}, error) {
+		if len(args) == 0 {
+			return "", nil
+		}
+		return in.toString(args[0])
+	}, nil)
+	p := in.stringProto
+	str := func(name string, arity int, fn func(s string, args []interface{}) (interface{}, error)) {
+		in.method(p, name, arity, func(this interface{}, args []interface{}) (interface{}, error) {
+			if isNullish(this) {
+				return nil, in.throwf("TypeError", "String.prototype.%s called on %s", name, typeName(this))
+			}
+			s, err := in.toString(this)
+			if err != nil {
+				return nil, err
+			}
+			return fn(s, args)
+		})
+	}
+	strArg := func(args []interface{}, i int) (string, error) {
+		return in.toString(arg(args, i))
+	}
+	str("toString", 0, func(s string, _ []interface{}) (interface{}, error) { return s, nil })
+	str("toUpperCase", 0, func(s string, _ []interface{}) (interface{}, error) { return strings.ToUpper(s), nil })
+	str("toLowerCase", 0, func(s string, _ []interface{}) (interface{}, error) { return strings.ToLower(s), nil })
+	str("trim", 0, func(s string, _ []interface{}) (interface{}, error) { return strings.TrimSpace(s), nil })
+	str("charAt", 1, func(s string, args []interface{}) (interface{}, error) {
+		r, i := []rune(s), toInteger(numberOf(arg(args, 0)))
+		if i < 0 || i >= len(r) {
+			return "", nil
+		}
+		return string(r[i]), nil
+	})
+	str("indexOf", 1, func(s string, args []interface{}) (interface{}, error) {
+		sub, err := strArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		r := []rune(s)
+		from := min(max(toInteger(numberOf(arg(args, 1))), 0), len(r))
+		i := strings.Index(string(r[from:]), sub)
+		if i < 0 {
+			return -1.0, nil
+		}
+		return float64(from + utf8.RuneCountInString(string(r[from:])[:i])), nil
+	})
+	str("includes", 1, func(s string, args []interface{}) (interface{}, error) {
+		sub, err := strArg(args, 0)
+		return strings.Contains(s, sub), err
+	})
+	str("startsWith", 1, func(s string, args []interface{}) (interface{}, error) {
+		sub, err := strArg(args, 0)
+		return strings.HasPrefix(s, sub), err
+	})
+	str("endsWith", 1, func(s string, args []interface{}) (interface{}, error) {
+		sub, err := strArg(args, 0)
+		return strings.HasSuffix(s, sub), err
+	})
+	str("slice", 2, func(s string, args []interface{}) (interface{}, error) {
+		r := []rune(s)
+		start, end := relative(arg(args, 0), len(r), 0), relative(arg(args, 1), len(r), len(r))
+		if start >= end {
+			return "", nil
+		}
+		return string(r[start:end]), nil
+	})
+	str("substring", 2, func(s string, args []interface{}) (interface{}, error) {
+		r := []rune(s)
+		clamp := func(v interface{}, def int) int {
+			if _, ok := v.(undefinedType); ok {
+				return def
+			}
+			return min(max(toInteger(numberOf(v)), 0), len(r))
+		}
+		start, end := clamp(arg(args, 0), 0), clamp(arg(args, 1), len(r))
+		if start > end {
+			start, end = end, start
+		}
+		return string(r[start:end]), nil
+	})
+	str("split", 2, func(s string, args []interface{}) (interface{}, error) {
+		var parts []string
+		if _, ok := arg(args, 0).(undefinedType); ok {
+			parts = []string{s}
+		} else {
+			sep, err := strArg(args, 0)
+			if err != nil {
+				return nil, err
+			}
+			if sep == "" {
+				for _, r := range s {
+					parts = append(parts, string(r))
+				}
+			} else {
+				parts = strings.Split(s, sep)
+			}
+		}
+		if _, ok := arg(args, 1).(undefinedType); !ok {
+			if limit := int(toUint32(numberOf(arg(args, 1)))); limit < len(parts) {
+				parts = parts[:limit]
+			}
+		}
+		items := make([]interface{}, len(parts))
+		for i, part := range parts {
+			items[i] = part
+		}
+		return in.newArray(items), nil
+	})
+	replace := func(all bool) func(s string, args []interface{}) (interface{}, error) {
+		return func(s string, args []interface{}) (interface{}, error) {
+			pattern, err := strArg(args, 0)
+			if err != nil {
+				return nil, err
+			}
+			var b strings.Builder
+			rest, offset := s, 0
+			for {
+				i := strings.Index(rest, pattern)
+				if i < 0 {
+					break
+				}
+				b.WriteString(rest[:i])
+				if f, ok := arg(args, 1).(*object); ok && f.callable() {
+					r, err := in.call(f, undefined, []interface{}{pattern, float64(utf8.RuneCountInString(s[:offset+i])), s})
+					if err != nil {
+						return nil, err
+					}
+					rs, err := in.toString(r)
+					if err != nil {
+						return nil, err
+					}
+					b.WriteString(rs)
+				} else {
+					rs, err := strArg(args, 1)
+					if err != nil {
+						return nil, err
+					}
+					b.WriteString(strings.ReplaceAll(rs, "$&", pattern))
+				}
+				next := i + len(pattern)
+				if pattern == "" {
+					if next >= len(rest) {
+						rest, offset = "", len(s)
+						break
+					}
+					_, size := utf8.DecodeRuneInString(rest[next:])
+					b.WriteString(rest[next : next+size])
+					next += size
+				}
+				rest, offset = rest[next:], offset+next
+				if !all {
+					break
+				}
+			}
+			b.WriteString(rest)
+			return b.String(), nil
+		}
+	}
+	str("replace", 2, replace(false))
+	str("replaceAll", 2, replace(true))
+	str("repeat", 1, func(s string, args []interface{}) (interface{}, error) {
+		n := toInteger(numberOf(arg(args, 0)))
+		if n < 0 || len(s)*n > maxArrayGrowth*16 {
+			return nil, in.throwf("RangeError", "invalid count value")
+		}
+		return strings.Repeat(s, n), nil
+	})
+	pad := func(start bool) func(s string, args []interface{}) (interface{}, error) {
+		return func(s string, args []interface{}) (interface{}, error) {
+			n := toInteger(numberOf(arg(args, 0)))
+			fill := " "
+			if _, ok := arg(args, 1).(undefinedType); !ok {
+				var err error
+				if fill, err = strArg(args, 1); err != nil {
+					return nil, err
+				}
+			}
+			length := utf8.RuneCountInString(s)
+			if n <= length || fill == "" || n > maxArrayGrowth*16 {
+				return s, nil
+			}
+			padding := []rune(strings.Repeat(fill, (n-length)/utf8.RuneCountInString(fill)+1))[:n-length]
+			if start {
+				return string(padding) + s, nil
+			}
+			return s + string(padding), nil
+		}
+	}
+	str("padStart", 2, pad(true))
+	str("padEnd", 2, pad(false))
+}
+
+func (in *interp) setupNumber() {
+	in.constructor("Number", 1, in.numberProto, func(_ interface{}, args []interface{}) (interface{}, error) {
+		if len(args) == 0 {
+			return 0.0, nil
+		}
+		return in.toNumber(args[0])
+	}, nil)
+	thisNumber := func(this interface{}) (float64, error) {
+		f, ok := this.(float64)
+		if !ok {
+			return 0, in.throwf("TypeError", "not a number")
+		}
+		return f, nil
+	}
+	in.method(in.numberProto, "toString", 1, func(this interface{}, args []interface{}) (interface{}, error) {
+		f, err := thisNumber(this)
+		if err != nil {
+			return nil, err
+		}
+		if radix, ok := arg(args, 0).(float64); ok && radix != 10 {
+			if radix < 2 || radix > 36 {
+				return nil, in.throwf("RangeError", "toString() radix must be between 2 and 36")
+			}
+			if f == math.Trunc(f) && math.Abs(f) < 1<<63 {
+				return strconv.FormatInt(int64(f), int(radix)), nil
+			}
+		}
+		return numberToString(f), nil
+	})
+	in.method(in.numberProto, "toFixed", 1, func(this interface{}, args []interface{}) (interface{}, error) {
+		f, err := thisNumber(this)
+		if err != nil {
+			return nil, err
+		}
+		digits := toInteger(numberOf(arg(args, 0)))
+		if digits < 0 || digits > 100 {
+			return nil, in.throwf("RangeError", "toFixed() digits argument must be between 0 and 100")
+		}
+		if math.Abs(f) >= 1e21 || math.IsNaN(f) {
+			return numberToString(f), nil
+		}
+		return strconv.FormatFloat(f, 'f', digits, 64), nil
+	})
+}
+
+var errorNames = []string{"TypeError", "RangeError", "ReferenceError", "SyntaxError"}
+
+func (in *interp) setupErrors() {
+	errorCtor := func(name string, proto *object) {
+		ctor := func(args []interface{}) (interface{}, error) {
+			o := &object{class: classError, proto: proto}
+			if _, ok := arg(args, 0).(undefinedType); !ok {
+				msg, err := in.toString(arg(args, 0))
+				if err != nil {
+					return nil, err
+				}
+				o.hide("message", msg)
+			}
+			return o, nil
+		}
+		in.errorCtors[name] = in.constructor(name, 1, proto, func(_ interface{}, args []interface{}) (interface{}, error) {
+			return ctor(args)
+		}, ctor)
+		proto.hide("name", name)
+	}
+	in.errorProto.hide("message", "")
+	in.method(in.errorProto, "toString", 0, func(this interface{}, _ []interface{}) (interface{}, error) {
+		name, err := in.get(this, "name")
+		if err != nil {
+			return nil, err
+		}
+		msg, err := in.get(this, "message")
+		if err != nil {
+			return nil, err
+		}
+		ns, ms := stringOf(name), stringOf(msg)
+		if ms == "" {
+			return ns, nil
+		}
+		return ns + ": " + ms, nil
+	})
+	errorCtor("Error", in.errorProto)
+	for _, name := range errorNames {
+		errorCtor(name, &object{class: classObject, proto: in.errorProto})
+	}
+}
+
+func (in *interp) setupMath() {
+	m := in.newObject()
+	in.global.hide("Math", m)
+	m.hide("PI", math.Pi)
+	for name, fn := range map[string]func(float64) float64{
+		"abs": math.Abs, "floor": math.Floor, "ceil": math.Ceil, "trunc": math.Trunc, "sqrt": math.Sqrt,
+		"round": func(f float64) float64 { return math.Floor(f + 0.5) },
+		"sign": func(f float64) float64 {
+			switch {
+			case f > 0:
+				return 1
+			case f < 0:
+				return -1
+			}
+			return f
+		},
+	} {
+		fn := fn
+		in.method(m, name, 1, func(_ interface{}, args []interface{}) (interface{}, error) {
+			f, err := in.toNumber(arg(args, 0))
+			return fn(f), err
+		})
+	}
+	in.method(m, "pow", 2, func(_ interface{}, args []interface{}) (interface{}, error) {
+		return math.Pow(numberOf(arg(args, 0)), numberOf(arg(args, 1))), nil
+	})
+	in.method(m, "random", 0, func(interface{}, []interface{}) (interface{}, error) {
+		return rand.Float64(), nil
+	})
+	extreme := func(name string, init float64, better func(a, b float64) bool) {
+		in.method(m, name, 2, func(_ interface{}, args []interface{}) (interface{}, error) {
+			r := init
+			for _, a := range args {
+				f, err := in.toNumber(a)
+				if err != nil {
+					return nil, err
+				}
+				if math.IsNaN(f) {
+					return f, nil
+				}
+				if better(f, r) {
+					r = f
+				}
+			}
+			return r, nil
+		})
+	}
+	extreme("max", math.Inf(-1), func(a, b float64) bool { return a > b })
+	extreme("min", math.Inf(1), func(a, b float64) bool { return a < b })
+}
+
+func (in *interp) setupConsole() {
+	c := in.newObject()
+	in.global.hide("console", c)
+	for _, name := range []string{"log", "info", "warn", "error", "debug"} {
+		in.method(c, name, 0, func(_ interface{}, args []interface{}) (interface{}, error) {
+			parts := make([]string, len(args))
+			for i, a := range args {
+				s, err := in.inspect(a, 0)
+				if err != nil {
+					return nil, err
+				}
+				parts[i] = s
+			}
+			if in.console != nil {
+				fmt.Fprintln(in.console, strings.Join(parts, " "))
+			}
+			return undefined, nil
+		})
+	}
+}
+
+// inspect formats a value for console output.
+func (in *interp) inspect(v interface{}, depth int) (string, error) {
+	o, ok := v.(*object)
+	if !ok {
+		if s, ok := v.(string); ok && depth > 0 {
+			return strconv.Quote(s), nil
+		}
+		return stringOf(v), nil
+	}
+	switch {
+	case o.callable():
+		name, _ := in.getFrom(o, "name")
+		if s := stringOf(name); s != "" {
+			return "[Function: " + s + "]", nil
+		}
+		return "[Function]", nil
+	case o.class == classError:
+		return in.toString(o)
+	case depth > 2:
+		if o.class == classArray {
+			return "[Array]", nil
+		}
+		return "[Object]", nil
+	}
+	var parts []string
+	for _, k := range o.ownKeys() {
+		pv, err := in.getFrom(o, k)
+		if err != nil {
+			return "", err
+		}
+		s, err := in.inspect(pv, depth+1)
+		if err != nil {
+			return "", err
+		}
+		if _, isIndex := arrayIndex(k); o.class == classArray && isIndex {
+			parts = append(parts, s)
+		} else {
+			parts = append(parts, k+": "+s)
+		}
+	}
+	if o.class == classArray {
+		if len(parts) == 0 {
+			return "[]", nil
+		}
+		return "[ " + strings.Join(parts, ", ") + " ]", nil
+	}
+	if len(parts) == 0 {
+		return "{}", nil
+	}
+	return "{ " + strings.Join(parts, ", ") + " }", nil
+}
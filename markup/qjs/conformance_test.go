@@ -0,0 +1,340 @@
+package qjs
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+// conformance covers every construct and builtin of the supported subset in
+// the order of the package documentation. Each script is evaluated as global
+// script and yields the value of its last expression statement.
+var conformance = []struct {
+	feature  string
+	src      string
+	expected string
+}{
+	// Literals.
+	{"number literals", `1.5e3 + 0x10 + 0b11 + 0o7 + .5 + 1_000`, "2526.5"},
+	{"number formatting", `[0.1 + 0.2, 1e21, 1e-7, -0, 1 / 0, -1 / 0, 0 / 0].join()`, "0.30000000000000004,1e+21,1e-7,0,Infinity,-Infinity,NaN"},
+	{"string literals", `"a\tb".length + 'c\'d' + "\x41B\u{43}"`, "3c'dABC"},
+	{"template literals", "`a${1 + 1}b${'}'}\n${[1, 2]}${`-${3}`}`", "a2b}\n1,2-3"},
+	{"boolean, null and undefined", `String(true) + String(false) + String(null) + String(undefined)`, "truefalsenullundefined"},
+	{"array literals", `[1, , 3].length + [[1, 2], [3]][0][1]`, "5"},
+	{"object literals", `const x = 1; const o = {x, "y z": 2, 3: 4, m() { return this.x }}; o.x + o["y z"] + o[3] + o.m()`, "8"},
+	{"object key order", `Object.keys({b: 1, a: 2, c: 3}).join()`, "b,a,c"},
+
+	// Declarations and scopes.
+	{"var hoisting", `function f() { return v; var v = 1 } typeof f()`, "undefined"},
+	{"let block scope", `let a = 1; { let a = 2 } a`, "1"},
+	{"const", `const c = [1]; c.push(2); c.length`, "2"},
+	{"function hoisting", `const r = f(); function f() { return 2 } r`, "2"},
+	{"closures", `function counter() { let n = 0; return () => ++n } const c = counter(); c(); c()`, "2"},
+	{"loop bindings", `let fs = []; for (let i = 0; i < 3; i++) fs.push(() => i); fs.map(f => f()).join()`, "0,1,2"},
+	{"function scope", `var g = 1; (function () { var g = 2 })(); g`, "1"},
+	{"global object", `var gv = 5; globalThis.gv + globalThis.Math.abs(-1)`, "6"},
+
+	// Statements.
+	{"if and else", `let r; if (0) r = "a"; else if ("") r = "b"; else r = "c"; r`, "c"},
+	{"for, break and continue", `let s = 0; for (let i = 0; i < 10; i++) { if (i % 2) continue; if (i > 6) break; s += i } s`, "12"},
+	{"for without clauses", `let n = 0; for (;;) { if (++n === 3) break } n`, "3"},
+	{"while", `let n = 0; while (n < 5) n++; n`, "5"},
+	{"for...of arrays", `let s = ""; for (const x of [1, 2, 3]) s += x; s`, "123"},
+	{"for...of strings", `const s = []; for (let c of "héllo") s.push(c); s.join("|")`, "h|é|l|l|o"},
+	{"for...of assignment", `let x; for (x of [1, 2]) {} x`, "2"},
+	{"return", `function f(x) { if (x) return "yes"; return } f(1) + f(0)`, "yesundefined"},
+	{"try, catch and finally", `let log = []; try { log.push("try"); throw new Error("x") } catch (e) { log.push(e.message) } finally { log.push("finally") } log.join()`, "try,x,finally"},
+	{"finally after return", `let log; function f() { try { return 1 } finally { log = 2 } } f() + log`, "3"},
+	{"catch without binding", `let r; try { throw 1 } catch { r = "caught" } r`, "caught"},
+	{"throw any value", `try { throw {code: 7} } catch (e) { e.code }`, "7"},
+	{"catch runtime errors", `try { null.x } catch (e) { e.name + ": " + e.message }`, "TypeError: cannot read property 'x' of null"},
+	{"rethrow", `function f() { try { throw new RangeError("r") } finally {} } try { f() } catch (e) { e.toString() }`, "RangeError: r"},
+
+	// Functions.
+	{"function forms", `const sq = x => x * x; const add = (a, b) => { return a + b }; const f = function (x) { return x + 1 }; sq(3) + add(1, 2) + f(0)`, "13"},
+	{"missing arguments", `function f(a, b) { return b } f(1) === undefined`, "true"},
+	{"named function expressions", `const fact = function f(n) { return n < 2 ? 1 : n * f(n - 1) }; fact(5)`, "120"},
+	{"methods", `const o = {n: 1, inc() { this.n++; return this }}; o.inc().inc().n`, "3"},
+	{"arrow this", `const o = {n: 2, f() { return [1].map(() => this.n)[0] }}; o.f()`, "2"},
+	{"function properties", `function foo(a, b) {} const bar = () => 1; foo.name + foo.length + bar.name`, "foo2bar"},
+
+	// Operators.
+	{"arithmetic", `1 + 2 * 3 - 4 / 2 + 7 % 3`, "6"},
+	{"unary operators", `-"3" + +"4" + !0`, "2"},
+	{"string concatenation", `"a" + 1 + 2 + "|" + (1 + 2 + "a")`, "a12|3a"},
+	{"relational operators", `[1 < 2, "a" < "b", "10" < "9", 10 < "9", null >= 0, 2 <= 2, 3 > 4].join()`, "true,true,true,false,true,true,false"},
+	{"equality operators", `[1 == "1", 1 === "1", null == undefined, null === undefined, NaN == NaN, 0 == "", 1 != 2, 1 !== 1].join()`, "true,false,true,false,false,true,true,false"},
+	{"logical operators", `[0 || "x", 1 && "y", null ?? "z", 0 ?? "w"].join()`, "x,y,z,0"},
+	{"short circuit", `let n = 0; false && n++; true || n++; "" ?? n++; n`, "0"},
+	{"conditional operator", `1 > 2 ? "a" : 2 > 1 ? "b" : "c"`, "b"},
+	{"precedence", `2 + 3 * 4 < 15 && !(1 === 2) ? "ok" : "no"`, "ok"},
+	{"compound assignment", `let a = 10; a += 5; a -= 3; a *= 2; a /= 4; a %= 4; let s = "a"; s += 1; a + s`, "2a1"},
+	{"increment and decrement", `let i = 1; const a = i++; const b = ++i; const c = i--; [a, b, c, --i].join()`, "1,3,3,1"},
+	{"member assignment", `const o = {a: [1]}; o.a[0] += 2; o.a[1] = 5; o.b = o.a.length; o.a[0]++; JSON.stringify(o)`, `{"a":[4,5],"b":2}`},
+	{"typeof", `[typeof 1, typeof "", typeof true, typeof undefined, typeof null, typeof {}, typeof [], typeof (() => 1), typeof undeclared].join()`, "number,string,boolean,undefined,object,object,object,function,undefined"},
+	{"error types", `const e = new TypeError("bad"); [e.name, e.message, Error("x").message, new RangeError().toString(), new ReferenceError("r").name, new SyntaxError("s").name].join()`, "TypeError,bad,x,RangeError,ReferenceError,SyntaxError"},
+	{"array length", `const a = [1, 2, 3]; a.length = 1; a[3] = 4; a.join()`, "1,,,4"},
+
+	// Globals.
+	{"parseInt", `[parseInt("42px"), parseInt("0x1f"), parseInt("-12"), parseInt("z", 36), parseInt("x")].join()`, "42,31,-12,35,NaN"},
+	{"parseFloat", `parseFloat("1.5e2px") + parseFloat(" -0.5")`, "149.5"},
+	{"isNaN", `[isNaN("x"), isNaN("1"), isNaN(NaN)].join()`, "true,false,true"},
+	{"Number", `[Number("  2 "), Number(""), Number("0x10"), Number("1a"), Number(true), Number(null), Number()].join()`, "2,0,16,NaN,1,0,0"},
+	{"String and Boolean", `[String(12), String([1, 2]), String({}), String(), Boolean(""), Boolean("0")].join("|")`, "12|1,2|[object Object]||false|true"},
+	{"NaN, Infinity and undefined", `[typeof NaN, Infinity > 1e308, undefined === globalThis.undefined].join()`, "number,true,true"},
+	{"Object functions", `const o = {a: 1, b: "x"}; Object.keys(o).join() + "|" + Object.values(o).join() + "|" + JSON.stringify(Object.entries(o))`, `a,b|1,x|[["a",1],["b","x"]]`},
+	{"Object.assign", `JSON.stringify(Object.assign({a: 1}, {b: 2}, null, {a: 3}))`, `{"a":3,"b":2}`},
+	{"Array.isArray", `[Array.isArray([]), Array.isArray({}), Array.isArray("a")].join()`, "true,false,false"},
+	{"Math", `[Math.abs(-2), Math.ceil(1.2), Math.floor(-1.2), Math.round(2.5), Math.round(-2.5), Math.trunc(-1.7), Math.sign(-3), Math.sqrt(16), Math.pow(2, 10), Math.max(1, 5, 3), Math.min(4, 2), Math.max()].join()`, "2,2,-2,3,-2,-1,-1,4,1024,5,2,-Infinity"},
+	{"Math constants", `Math.PI.toFixed(4)`, "3.1416"},
+	{"Math.random", `const r = Math.random(); r >= 0 && r < 1`, "true"},
+	{"JSON.stringify", `JSON.stringify({a: [1, "x", null, undefined, () => 1], b: {c: true}, u: undefined, n: NaN})`, `{"a":[1,"x",null,null,null],"b":{"c":true},"n":null}`},
+	{"JSON.stringify indent", `JSON.stringify({a: [1], b: {}}, null, 2)`, "{\n  \"a\": [\n    1\n  ],\n  \"b\": {}\n}"},
+	{"JSON.stringify strings", `JSON.stringify("a\"b<\n")`, `"a\"b<\n"`},
+	{"JSON.parse", `const v = JSON.parse('{"b": 1, "a": [2, "x", true, null]}'); Object.keys(v).join() + "|" + v.a.join()`, "b,a|2,x,true,"},
+
+	// Array methods.
+	{"push, pop, shift and unshift", `const a = [2]; const n = a.push(3, 4); a.unshift(1); const p = a.pop(); const s = a.shift(); [a.join(""), n, p, s, [].pop()].join()`, "23,3,4,1,"},
+	{"slice and concat", `[1, 2, 3, 4].slice(1, -1).concat([5], 6).join() + "|" + [1, 2].slice().length`, "2,3,5,6|2"},
+	{"join and toString", `[1, [2, 3], null].join(";") + "|" + String([1, 2]) + "|" + [1, 2].toString()`, "1;2,3;|1,2|1,2"},
+	{"reverse", `[1, 2, 3].reverse().join("")`, "321"},
+	{"indexOf and includes", `[[1, 2, 3].indexOf(2), [1].indexOf(5), [NaN].includes(NaN), [1].includes("1")].join()`, "1,-1,true,false"},
+	{"forEach, map and filter", `let s = 0; [1, 2, 3].forEach((x, i) => s += x * i); [1, 2, 3, 4].map(x => x * 2).filter(x => x > 4).join() + "|" + s`, "6,8|8"},
+	{"some, every, find and findIndex", `const a = [1, 5, 10]; [a.some(x => x > 4), a.every(x => x > 4), a.find(x => x > 4), a.findIndex(x => x > 4), a.find(x => x > 99), a.findIndex(x => x > 99)].join()`, "true,false,5,1,,-1"},
+	{"reduce", `[1, 2, 3].reduce((a, b) => a + b) + [1, 2].reduce((a, b) => a + b, 10)`, "19"},
+	{"sort", `[10, 9, 1].sort().join() + "|" + [10, 9, 1].sort((a, b) => a - b).join()`, "1,10,9|1,9,10"},
+
+	// String methods.
+	{"case conversion", `"Hello".toUpperCase() + "Hello".toLowerCase()`, "HELLOhello"},
+	{"trim, charAt and indexing", `"  x  ".trim() + "|" + "abc".charAt(1) + "abc"[2] + "héllo".length`, "x|bc5"},
+	{"searching", `["abcabc".indexOf("c"), "abcabc".indexOf("c", 3), "abc".indexOf("x"), "abc".includes("bc"), "abc".startsWith("ab"), "abc".endsWith("bc")].join()`, "2,5,-1,true,true,true"},
+	{"slice and substring", `"abcdef".slice(1, -1) + "|" + "abcdef".slice(-2) + "|" + "abcdef".substring(4, 1)`, "bcde|ef|bcd"},
+	{"split", `"a,b,,c".split(",").length + "|" + "abc".split("").join("-") + "|" + "a,b,c".split(",", 2).join()`, "4|a-b-c|a,b"},
+	{"replace and replaceAll", `"a-b-c".replace("-", "+") + "|" + "a-b-c".replaceAll("-", "+") + "|" + "abc".replace("b", "[$&]")`, "a+b-c|a+b+c|a[b]c"},
+	{"repeat and padding", `"ab".repeat(3) + "|" + "7".padStart(3, "0") + "|" + "x".padEnd(3, "ab") + "|" + "x".padStart(3)`, "ababab|007|xab|  x"},
+	{"string toString", `"abc".toString() + true.toString()`, "abctrue"},
+
+	// Number methods.
+	{"toFixed and toString", `(5).toFixed(2) + "|" + (1.005).toFixed(2) + "|" + (255).toString(16) + "|" + (255).toString(2) + "|" + (1.5).toString()`, "5.00|1.00|ff|11111111|1.5"},
+}
+
+func TestConformance(t *testing.T) {
+	t.Parallel()
+
+	for _, c := range conformance {
+		rt, _ := New(Option{MaxSteps: 100000})
+		v, err := rt.Context().Eval("conformance.js", Code(c.src))
+		if err != nil {
+			t.Errorf("failed to evaluate %s %q: %s", c.feature, c.src, err)
+			continue
+		}
+		if got := v.String(); got != c.expected {
+			t.Errorf("failed to evaluate %s %q: expected %q, got %q", c.feature, c.src, c.expected, got)
+		}
+
+		// The same script evaluated from bytecode in another runtime.
+		bytecode, err := rt.Context().Compile("conformance.js", Code(c.src))
+		if err != nil {
+			t.Errorf("failed to compile %s %q: %s", c.feature, c.src, err)
+			continue
+		}
+		other, _ := New()
+		v, err = other.Context().Eval("conformance.js", Bytecode(bytecode))
+		if err != nil || v.String() != c.expected {
+			t.Errorf("failed to evaluate bytecode of %s %q: expected %q, got %v (%v)", c.feature, c.src, c.expected, v, err)
+		}
+	}
+}
+
+func TestConsole(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	rt, _ := New(Option{Console: &out})
+	eval(t, rt, `for (const level of ["log", "info", "warn", "error", "debug"]) console[level](level, 1)`)
+	if got, expected := out.String(), "log 1\ninfo 1\nwarn 1\nerror 1\ndebug 1\n"; got != expected {
+		t.Errorf("failed to write to console: expected %q, got %q", expected, got)
+	}
+}
+
+func TestUnsupported(t *testing.T) {
+	t.Parallel()
+
+	for src, expected := range map[string]string{
+		`class A {}`:                          "class is not supported",
+		`import x from "y"`:                   "import is not supported",
+		`switch (1) {}`:                       "switch is not supported",
+		`do {} while (false)`:                 "do is not supported",
+		`for (const k in {}) {}`:              "for...in is not supported",
+		`for (k in {}) {}`:                    "for...in is not supported",
+		`[...a]`:                              "operator '...' is not supported",
+		`f(...a)`:                             "operator '...' is not supported",
+		`function f(...a) {}`:                 "operator '...' is not supported",
+		`({...a})`:                            "operator '...' is not supported",
+		`function f(a = 1) {}`:                "default parameters are not supported",
+		`(a = 1) => a`:                        "default parameters are not supported",
+		`({[k]: 1})`:                          "computed property names are not supported",
+		`a?.b`:                                "operator '?.' is not supported",
+		`2 ** 3`:                              "operator '**' is not supported",
+		`1 & 2`:                               "operator '&' is not supported",
+		`1 | 2`:                               "operator '|' is not supported",
+		`1 ^ 2`:                               "operator '^' is not supported",
+		`~1`:                                  "operator '~' is not supported",
+		`1 << 2`:                              "operator '<<' is not supported",
+		`1 >> 2`:                              "operator '>>' is not supported",
+		`1 >>> 2`:                             "operator '>>>' is not supported",
+		`a |= 1`:                              "operator '|=' is not supported",
+		`a ||= 1`:                             "operator '||=' is not supported",
+		`a &&= 1`:                             "operator '&&=' is not supported",
+		`a ??= 1`:                             "operator '??=' is not supported",
+		`"a" in {}`:                           "operator 'in' is not supported",
+		`a instanceof Error`:                  "operator 'instanceof' is not supported",
+		`delete a.b`:                          "operator 'delete' is not supported",
+		`void 0`:                              "operator 'void' is not supported",
+		`a, b`:                                "unexpected token ','",
+		`/x/.test("x")`:                       "unexpected token '/'",
+		`const {a} = {}`:                      "unexpected token '{'",
+		`label: for (;;) {}`:                  "unexpected token ':'",
+		`({get a() { return 1 }})`:            "unexpected token 'a'",
+		`async function f() {}`:               "unexpected token 'function'",
+		`function* g() {}`:                    "unexpected token '*'",
+		`export default 1`:                    "unexpected token 'export'",
+		`let x = 1 2`:                         "unexpected number",
+		`const c`:                             "missing initializer in const declaration",
+		`try {}`:                              "missing catch or finally after try",
+		`throw` + "\n" + `new Error("x")`:     "illegal newline after throw",
+		`x = "unterminated`:                   "unterminated string",
+		`/* unterminated`:                     "unterminated comment",
+		`let é = 1; #`:                        "unexpected character '#'",
+		`function () {}`:                      "unexpected token '('",
+		`1 = 2`:                               "invalid assignment target",
+		`({a: 1}).b++ = 2`:                    "invalid assignment target",
+		`let let = 1`:                         "unexpected token 'let'",
+		`({if})`:                              "unexpected token '}'",
+		`"\u{zz}"`:                            "invalid unicode escape",
+		`new`:                                 "unexpected end of input",
+		`return 1; function f() { return 1 }`: "illegal break, continue or return statement",
+	} {
+		rt, _ := New()
+		_, err := rt.Context().Eval("unsupported.js", Code(src))
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("failed to reject %q: expected %s, got %v", src, expected, err)
+		}
+	}
+}
+
+func TestExceptions(t *testing.T) {
+	t.Parallel()
+
+	for src, expected := range map[string]string{
+		`missing()`:               "ReferenceError: missing is not defined",
+		`null.x`:                  "TypeError: cannot read property 'x' of null",
+		`undefined.x = 1`:         "TypeError: cannot set property 'x' of undefined",
+		`const o = {}; o.f()`:     "TypeError: o.f is not a function",
+		`function F() {} new F()`: "TypeError: F is not a constructor",
+		`new Object()`:            "TypeError: Object is not a constructor",
+		`new String("x")`:         "TypeError: String is not a constructor",
+		`Function("return 1")`:    "ReferenceError: Function is not defined",
+		`eval("1")`:               "ReferenceError: eval is not defined",
+		`const c = 1; c = 2`:      "TypeError: assignment to constant variable 'c'",
+		`let a; let a`:            "SyntaxError: identifier 'a' has already been declared",
+		`JSON.parse("{")`:         "SyntaxError: invalid JSON",
+		`JSON.parse("1 2")`:       "SyntaxError: unexpected data after JSON value",
+		`const o = {}; o.o = o; JSON.stringify(o)`:    "TypeError: circular structure in JSON.stringify",
+		`(1).toFixed(101)`:                            "RangeError: toFixed() digits argument must be between 0 and 100",
+		`(1).toString(1)`:                             "RangeError: toString() radix must be between 2 and 36",
+		`[].reduce((a, b) => a)`:                      "TypeError: reduce of empty array with no initial value",
+		`[].map(1)`:                                   "TypeError: 1 is not a function",
+		`for (const x of 5) {}`:                       "TypeError: number is not iterable",
+		`throw new Error("boom")`:                     "Error: boom",
+		`throw "plain"`:                               "uncaught plain",
+		`const a = []; a.length = -1`:                 "RangeError: invalid array length",
+		`const a = []; a[1e9] = 1`:                    "RangeError: invalid array index",
+		`"x".repeat(-1)`:                              "RangeError: invalid count value",
+		`function f() { return f() } f()`:             "RangeError: maximum call stack size exceeded",
+		`({get x() {}})`:                              "unexpected token 'x'",
+		`({toString() { return {} }}) + ""`:           "TypeError: cannot convert object to primitive value",
+		`break`:                                       "SyntaxError: illegal break, continue or return statement",
+		`const o = {}; Object.defineProperty(o, "x")`: "TypeError: Object.defineProperty is not a function",
+	} {
+		rt, _ := New(Option{MaxCallDepth: 32})
+		_, err := rt.Context().Eval("exceptions.js", Code(src))
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("failed to throw for %q: expected %s, got %v", src, expected, err)
+		}
+	}
+}
+
+func TestInvalidBytecode(t *testing.T) {
+	t.Parallel()
+
+	rt, _ := New()
+	ctx := rt.Context()
+	bytecode, err := ctx.Compile("valid.js", Code(`let x = 1; x + 1`))
+	if err != nil {
+		t.Fatalf("failed to compile script: %s", err)
+	}
+	invalid := [][]byte{
+		{},
+		[]byte("QJSB\x01"),
+		bytecode[:len(bytecode)/2],
+		append([]byte("QJSB\x01"), bytecode[len(bytecodeMagic):]...),
+	}
+	for _, prog := range []*Program{
+		{Body: []Node{&FuncDecl{}}},
+		{Body: []Node{&FuncDecl{Fn: &FuncLit{Name: "f", Params: []string{""}}}}},
+		{Body: []Node{&ExprStmt{}}},
+		{Body: []Node{&ExprStmt{X: &Binary{Op: "**", L: &NumberLit{}, R: &NumberLit{}}}}},
+		{Body: []Node{&ExprStmt{X: &Binary{Op: "+", L: &NumberLit{}}}}},
+		{Body: []Node{&ExprStmt{X: &Unary{Op: "delete", X: &Ident{Name: "x"}}}}},
+		{Body: []Node{&ExprStmt{X: &Assign{Op: "=", Target: &NumberLit{}, Value: &NumberLit{}}}}},
+		{Body: []Node{&ExprStmt{X: &Update{Op: "++", X: &Call{Fn: &Ident{Name: "f"}}}}}},
+		{Body: []Node{&ExprStmt{X: &TemplateLit{Exprs: []Node{&NumberLit{}}}}}},
+		{Body: []Node{&ExprStmt{X: &Cond{Test: &NumberLit{}, Then: &NumberLit{}}}}},
+		{Body: []Node{&ExprStmt{X: &FuncLit{Expr: &NumberLit{}}}}},
+		{Body: []Node{&ExprStmt{X: &Ident{}}}},
+		{Body: []Node{&ExprStmt{X: &Member{Name: "x"}}}},
+		{Body: []Node{&ExprStmt{X: &Block{}}}},
+		{Body: []Node{&VarDecl{Kind: "static", Decls: []Declarator{{Name: "x"}}}}},
+		{Body: []Node{&VarDecl{Kind: "let"}}},
+		{Body: []Node{&Try{Body: &Block{}}}},
+		{Body: []Node{&Try{Catch: &Block{}}}},
+		{Body: []Node{&If{Test: &BoolLit{}}}},
+		{Body: []Node{&For{Body: &ExprStmt{X: &Ident{Name: "x"}}, Test: &Return{}}}},
+		{Body: []Node{&ForOf{Name: "x", X: &ArrayLit{}}}},
+		{Body: []Node{&While{Test: &BoolLit{}, Body: &NumberLit{}}}},
+		{Body: []Node{&Export{X: &NumberLit{}}}},
+		{Module: true, Body: []Node{&Export{X: &NumberLit{}, Decl: &VarDecl{}}}},
+		{Module: true, Body: []Node{&Block{Body: []Node{&Export{X: &NumberLit{}}}}}},
+	} {
+		b, err := encodeProgram(prog)
+		if err != nil {
+			t.Fatalf("failed to encode program: %s", err)
+		}
+		invalid = append(invalid, b)
+	}
+	for i, b := range invalid {
+		_, err := ctx.Eval("invalid.js", Bytecode(b))
+		if err == nil || !strings.Contains(err.Error(), "invalid bytecode") {
+			t.Errorf("failed to reject invalid bytecode %d: got %v", i, err)
+		}
+	}
+
+	// The cache ignores invalid cache files.
+	dir := t.TempDir()
+	cached, _ := New(Option{CacheDir: dir})
+	path := cached.Context().cachePath("cached.js", "1 + 1", false)
+	if err := os.WriteFile(path, invalid[len(invalid)-1], 0o644); err != nil {
+		t.Fatalf("failed to write cache file: %s", err)
+	}
+	v, err := cached.Context().Eval("cached.js", Code("1 + 1"))
+	if err != nil || v.Int() != 2 {
+		t.Errorf("failed to ignore invalid cache file: got %v (%v)", v, err)
+	}
+	var syntaxErr *SyntaxError
+	if errors.As(err, &syntaxErr) {
+		t.Errorf("unexpected syntax error: %s", err)
+	}
+}
@@ -0,0 +1,227 @@
+package qjs
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+func toBoolean(v interface{}) bool {
+	switch x := v.(type) {
+	case undefinedType, nil:
+		return false
+	case bool:
+		return x
+	case float64:
+		return x != 0 && !math.IsNaN(x)
+	case string:
+		return x != ""
+	}
+	return true
+}
+
+// toPrimitive converts objects to strings using their toString method.
+func (in *interp) toPrimitive(v interface{}) (interface{}, error) {
+	o, ok := v.(*object)
+	if !ok {
+		return v, nil
+	}
+	fn, err := in.getFrom(o, "toString")
+	if err != nil {
+		return nil, err
+	}
+	if f, ok := fn.(*object); ok && f.callable() {
+		r, err := in.call(f, o, nil)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := r.(*object); !ok {
+			return r, nil
+		}
+	}
+	return nil, in.throwf("TypeError", "cannot convert object to primitive value")
+}
+
+func (in *interp) toNumber(v interface{}) (float64, error) {
+	p, err := in.toPrimitive(v)
+	if err != nil {
+		return 0, err
+	}
+	return numberOf(p), nil
+}
+
+func (in *interp) toString(v interface{}) (string, error) {
+	p, err := in.toPrimitive(v)
+	if err != nil {
+		return "", err
+	}
+	return stringOf(p), nil
+}
+
+// numberOf converts a primitive to a number.
+func numberOf(v interface{}) float64 {
+	switch x := v.(type) {
+	case nil:
+		return 0
+	case bool:
+		if x {
+			return 1
+		}
+		return 0
+	case float64:
+		return x
+	case string:
+		return parseNumber(x)
+	}
+	return math.NaN()
+}
+
+func parseNumber(s string) float64 {
+	s = strings.TrimSpace(s)
+	switch s {
+	case "":
+		return 0
+	case "Infinity", "+Infinity":
+		return math.Inf(1)
+	case "-Infinity":
+		return math.Inf(-1)
+	}
+	if len(s) > 2 && s[0] == '0' && strings.ContainsRune("xXbBoO", rune(s[1])) {
+		v, err := strconv.ParseInt(s, 0, 64)
+		if err != nil {
+			return math.NaN()
+		}
+		return float64(v)
+	}
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c == '.' || c == 'e' || c == 'E' || c == '+' || c == '-') {
+			return math.NaN()
+		}
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil && !strings.Contains(err.Error(), "range") {
+		return math.NaN()
+	}
+	return v
+}
+
+// stringOf converts a primitive to a string.
+func stringOf(v interface{}) string {
+	switch x := v.(type) {
+	case undefinedType:
+		return "undefined"
+	case nil:
+		return "null"
+	case bool:
+		if x {
+			return "true"
+		}
+		return "false"
+	case float64:
+		return numberToString(x)
+	case string:
+		return x
+	}
+	return "[object Object]"
+}
+
+func numberToString(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	case f == 0:
+		return "0"
+	}
+	if a := math.Abs(f); a >= 1e21 || a < 1e-6 {
+		s := strconv.FormatFloat(f, 'e', -1, 64)
+		mant, exp, _ := strings.Cut(s, "e")
+		sign := exp[:1]
+		exp = strings.TrimLeft(exp[1:], "0")
+		return mant + "e" + sign + exp
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func toInt32(f float64) int32 {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0
+	}
+	return int32(uint32(int64(math.Mod(math.Trunc(f), 1<<32))))
+}
+
+func toUint32(f float64) uint32 {
+	return uint32(toInt32(f))
+}
+
+// toInteger converts a number to an integer index, clamping infinities.
+func toInteger(f float64) int {
+	switch {
+	case math.IsNaN(f):
+		return 0
+	case f > math.MaxInt32:
+		return math.MaxInt32
+	case f < math.MinInt32:
+		return math.MinInt32
+	}
+	return int(f)
+}
+
+func strictEquals(a, b interface{}) bool {
+	switch x := a.(type) {
+	case float64:
+		y, ok := b.(float64)
+		return ok && x == y
+	case *object:
+		y, ok := b.(*object)
+		return ok && x == y
+	}
+	return a == b
+}
+
+// sameValueZero is strict equality that considers NaN equal to itself.
+func sameValueZero(a, b interface{}) bool {
+	if x, ok := a.(float64); ok && math.IsNaN(x) {
+		y, ok := b.(float64)
+		return ok && math.IsNaN(y)
+	}
+	return strictEquals(a, b)
+}
+
+func (in *interp) looseEquals(a, b interface{}) (bool, error) {
+	isNullish := func(v interface{}) bool {
+		switch v.(type) {
+		case undefinedType, nil:
+			return true
+		}
+		return false
+	}
+	if isNullish(a) || isNullish(b) {
+		return isNullish(a) && isNullish(b), nil
+	}
+	_, ao := a.(*object)
+	_, bo := b.(*object)
+	if ao && bo {
+		return a == b, nil
+	}
+	var err error
+	if ao {
+		if a, err = in.toPrimitive(a); err != nil {
+			return false, err
+		}
+	}
+	if bo {
+		if b, err = in.toPrimitive(b); err != nil {
+			return false, err
+		}
+	}
+	as, aStr := a.(string)
+	bs, bStr := b.(string)
+	if aStr && bStr {
+		return as == bs, nil
+	}
+	return numberOf(a) == numberOf(b), nil
+}
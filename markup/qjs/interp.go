@@ -0,0 +1,999 @@
+package qjs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// ErrInterrupted is returned when a script exceeds its step limit. It cannot
+// be caught by the script.
+var ErrInterrupted = errors.New("qjs: interrupted")
+
+// throw carries a thrown JavaScript value through Go error returns.
+type throw struct {
+	value interface{}
+}
+
+func (t *throw) Error() string {
+	return "uncaught exception"
+}
+
+type completion uint8
+
+const (
+	normal completion = iota
+	breakCompletion
+	continueCompletion
+	returnCompletion
+)
+
+type binding struct {
+	value interface{}
+	konst bool
+}
+
+// scope is a lexical environment.
+type scope struct {
+	vars    map[string]*binding
+	parent  *scope
+	fn      bool // whether var declarations are hoisted to this scope
+	hasThis bool
+	this    interface{}
+	global  bool // whether var declarations become global object properties
+}
+
+func newScope(parent *scope, fn bool) *scope {
+	return &scope{vars: make(map[string]*binding), parent: parent, fn: fn}
+}
+
+// interp holds the state of a runtime.
+type interp struct {
+	global        *object
+	globals       *scope
+	objectProto   *object
+	functionProto *object
+	arrayProto    *object
+	stringProto   *object
+	numberProto   *object
+	booleanProto  *object
+	errorProto    *object
+	errorCtors    map[string]*object
+	console       io.Writer
+
+	ret      interface{}
+	last     interface{} // value of the last top-level expression statement
+	depth    int
+	steps    uint64
+	maxDepth int
+	maxSteps uint64
+}
+
+func (in *interp) throwf(name, format string, args ...interface{}) error {
+	return &throw{value: in.newError(name, fmt.Sprintf(format, args...))}
+}
+
+// step counts an evaluation step against the step limit.
+func (in *interp) step() error {
+	in.steps++
+	if in.maxSteps > 0 && in.steps > in.maxSteps {
+		return ErrInterrupted
+	}
+	return nil
+}
+
+// enter is called when control passes from Go into the interpreter.
+func (in *interp) enter() {
+	if in.depth == 0 {
+		in.steps = 0
+	}
+}
+
+// errorValue converts an error into the value seen by a catch clause.
+func (in *interp) errorValue(err error) interface{} {
+	var t *throw
+	if errors.As(err, &t) {
+		return t.value
+	}
+	return in.newError("Error", err.Error())
+}
+
+func catchable(err error) bool {
+	return !errors.Is(err, ErrInterrupted)
+}
+
+func (in *interp) lookup(sc *scope, name string) (interface{}, error) {
+	for s := sc; s != nil; s = s.parent {
+		if b, ok := s.vars[name]; ok {
+			return b.value, nil
+		}
+	}
+	if in.global.has(name) {
+		return in.getFrom(in.global, name)
+	}
+	return nil, in.throwf("ReferenceError", "%s is not defined", name)
+}
+
+func (in *interp) resolvable(sc *scope, name string) bool {
+	for s := sc; s != nil; s = s.parent {
+		if _, ok := s.vars[name]; ok {
+			return true
+		}
+	}
+	return in.global.has(name)
+}
+
+func (in *interp) assign(sc *scope, name string, v interface{}) error {
+	for s := sc; s != nil; s = s.parent {
+		if b, ok := s.vars[name]; ok {
+			if b.konst {
+				return in.throwf("TypeError", "assignment to constant variable '%s'", name)
+			}
+			b.value = v
+			return nil
+		}
+	}
+	return in.set(in.global, name, v)
+}
+
+// declare creates a binding of the given declaration kind.
+func (in *interp) declare(sc *scope, kind, name string, v interface{}) error {
+	if kind == "var" || kind == "function" {
+		for !sc.fn {
+			sc = sc.parent
+		}
+		if sc.global {
+			if _, isUndefined := v.(undefinedType); isUndefined && kind == "var" && in.global.has(name) {
+				return nil
+			}
+			in.global.put(name, v)
+			return nil
+		}
+		if b, ok := sc.vars[name]; ok {
+			if _, isUndefined := v.(undefinedType); !isUndefined || kind == "function" {
+				b.value = v
+			}
+			return nil
+		}
+		sc.vars[name] = &binding{value: v}
+		return nil
+	}
+	if _, ok := sc.vars[name]; ok {
+		return in.throwf("SyntaxError", "identifier '%s' has already been declared", name)
+	}
+	sc.vars[name] = &binding{value: v, konst: kind == "const"}
+	return nil
+}
+
+func (in *interp) this(sc *scope) interface{} {
+	for s := sc; s != nil; s = s.parent {
+		if s.hasThis {
+			return s.this
+		}
+	}
+	return undefined
+}
+
+// hoist declares the functions of a statement list and, for function bodies,
+// the var declarations nested in it.
+func (in *interp) hoist(body []Node, sc *scope, vars bool) error {
+	if vars {
+		var names []string
+		collectVars(body, &names)
+		for _, name := range names {
+			if err := in.declare(sc, "var", name, undefined); err != nil {
+				return err
+			}
+		}
+	}
+	for _, stmt := range body {
+		if e, ok := stmt.(*Export); ok {
+			stmt = e.Decl
+		}
+		if f, ok := stmt.(*FuncDecl); ok {
+			if err := in.declare(sc, "function", f.Fn.Name, in.newClosure(f.Fn, sc, nil)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func collectVars(body []Node, names *[]string) {
+	for _, stmt := range body {
+		collectVar(stmt, names)
+	}
+}
+
+func collectVar(stmt Node, names *[]string) {
+	switch s := stmt.(type) {
+	case *VarDecl:
+		if s.Kind == "var" {
+			for _, d := range s.Decls {
+				*names = append(*names, d.Name)
+			}
+		}
+	case *Export:
+		collectVar(s.Decl, names)
+	case *Block:
+		collectVars(s.Body, names)
+	case *If:
+		collectVar(s.Then, names)
+		collectVar(s.Else, names)
+	case *For:
+		collectVar(s.Init, names)
+		collectVar(s.Body, names)
+	case *ForOf:
+		if s.Kind == "var" {
+			*names = append(*names, s.Name)
+		}
+		collectVar(s.Body, names)
+	case *While:
+		collectVar(s.Body, names)
+	case *Try:
+		for _, b := range []*Block{s.Body, s.Catch, s.Finally} {
+			if b != nil {
+				collectVars(b.Body, names)
+			}
+		}
+	}
+}
+
+// run executes a program. Global programs return the value of the last
+// expression statement executed outside of functions, modules their default export or, if there is none,
+// an object holding their named exports.
+func (in *interp) run(prog *Program) (interface{}, error) {
+	in.enter()
+	sc := in.globals
+	if prog.Module {
+		sc = newScope(in.globals, true)
+		sc.hasThis = true
+		sc.this = undefined
+	}
+	if err := in.hoist(prog.Body, sc, true); err != nil {
+		return nil, err
+	}
+	in.last = undefined
+	var exports []string
+	var def interface{}
+	for _, stmt := range prog.Body {
+		switch s := stmt.(type) {
+		case *Export:
+			if s.X != nil {
+				v, err := in.eval(s.X, sc)
+				if err != nil {
+					return nil, err
+				}
+				def = v
+				continue
+			}
+			switch d := s.Decl.(type) {
+			case *VarDecl:
+				for _, decl := range d.Decls {
+					exports = append(exports, decl.Name)
+				}
+			case *FuncDecl:
+				exports = append(exports, d.Fn.Name)
+			}
+			stmt = s.Decl
+		}
+		c, err := in.exec(stmt, sc)
+		if err != nil {
+			return nil, err
+		}
+		if c != normal {
+			return nil, in.throwf("SyntaxError", "illegal break, continue or return statement")
+		}
+	}
+	if !prog.Module {
+		return in.last, nil
+	}
+	if def != nil {
+		return def, nil
+	}
+	ns := in.newObject()
+	for _, name := range exports {
+		v, err := in.lookup(sc, name)
+		if err != nil {
+			return nil, err
+		}
+		ns.put(name, v)
+	}
+	return ns, nil
+}
+
+func (in *interp) execList(body []Node, sc *scope) (completion, error) {
+	for _, stmt := range body {
+		c, err := in.exec(stmt, sc)
+		if err != nil || c != normal {
+			return c, err
+		}
+	}
+	return normal, nil
+}
+
+func (in *interp) execBlock(b *Block, sc *scope) (completion, error) {
+	inner := newScope(sc, false)
+	if err := in.hoist(b.Body, inner, false); err != nil {
+		return normal, err
+	}
+	return in.execList(b.Body, inner)
+}
+
+func (in *interp) exec(stmt Node, sc *scope) (completion, error) {
+	if err := in.step(); err != nil {
+		return normal, err
+	}
+	switch s := stmt.(type) {
+	case nil, *Empty, *FuncDecl:
+		return normal, nil
+	case *ExprStmt:
+		v, err := in.eval(s.X, sc)
+		if in.depth == 0 {
+			in.last = v
+		}
+		return normal, err
+	case *VarDecl:
+		return normal, in.execVarDecl(s, sc)
+	case *Return:
+		in.ret = undefined
+		if s.X != nil {
+			v, err := in.eval(s.X, sc)
+			if err != nil {
+				return normal, err
+			}
+			in.ret = v
+		}
+		return returnCompletion, nil
+	case *If:
+		test, err := in.eval(s.Test, sc)
+		if err != nil {
+			return normal, err
+		}
+		if toBoolean(test) {
+			return in.exec(s.Then, sc)
+		}
+		return in.exec(s.Else, sc)
+	case *Block:
+		return in.execBlock(s, sc)
+	case *For:
+		return in.execFor(s, sc)
+	case *ForOf:
+		return in.execForOf(s, sc)
+	case *While:
+		for {
+			test, err := in.eval(s.Test, sc)
+			if err != nil {
+				return normal, err
+			}
+			if !toBoolean(test) {
+				return normal, nil
+			}
+			c, err := in.exec(s.Body, sc)
+			if err != nil || c == returnCompletion {
+				return c, err
+			}
+			if c == breakCompletion {
+				return normal, nil
+			}
+		}
+	case *Break:
+		return breakCompletion, nil
+	case *Continue:
+		return continueCompletion, nil
+	case *Throw:
+		v, err := in.eval(s.X, sc)
+		if err != nil {
+			return normal, err
+		}
+		return normal, &throw{value: v}
+	case *Try:
+		return in.execTry(s, sc)
+	case *Export:
+		return normal, in.throwf("SyntaxError", "export is only allowed at the top level of a module")
+	}
+	return normal, fmt.Errorf("qjs: unknown statement %T", stmt)
+}
+
+func (in *interp) execVarDecl(s *VarDecl, sc *scope) error {
+	for _, d := range s.Decls {
+		var v interface{} = undefined
+		if d.Init != nil {
+			var err error
+			if v, err = in.eval(d.Init, sc); err != nil {
+				return err
+			}
+			if o, ok := v.(*object); ok && o.fn != nil && o.fn.lit.Name == "" {
+				o.hide("name", d.Name)
+			}
+		} else if s.Kind == "var" {
+			continue
+		}
+		if s.Kind == "var" {
+			if err := in.assign(sc, d.Name, v); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := in.declare(sc, s.Kind, d.Name, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyScope creates the scope of the next iteration of a for loop so that
+// closures capture the bindings of their own iteration.
+func copyScope(sc *scope) *scope {
+	next := newScope(sc.parent, false)
+	for name, b := range sc.vars {
+		next.vars[name] = &binding{value: b.value, konst: b.konst}
+	}
+	return next
+}
+
+func (in *interp) execFor(s *For, sc *scope) (completion, error) {
+	loop := newScope(sc, false)
+	if decl, ok := s.Init.(*VarDecl); ok {
+		if err := in.execVarDecl(decl, loop); err != nil {
+			return normal, err
+		}
+	} else if _, err := in.exec(s.Init, loop); err != nil {
+		return normal, err
+	}
+	for {
+		if s.Test != nil {
+			test, err := in.eval(s.Test, loop)
+			if err != nil {
+				return normal, err
+			}
+			if !toBoolean(test) {
+				return normal, nil
+			}
+		}
+		c, err := in.exec(s.Body, loop)
+		if err != nil || c == returnCompletion {
+			return c, err
+		}
+		if c == breakCompletion {
+			return normal, nil
+		}
+		loop = copyScope(loop)
+		if s.Update != nil {
+			if _, err := in.eval(s.Update, loop); err != nil {
+				return normal, err
+			}
+		}
+	}
+}
+
+// iterate returns the values produced by iterating over v.
+func (in *interp) iterate(v interface{}) ([]interface{}, error) {
+	switch x := v.(type) {
+	case string:
+		var items []interface{}
+		for _, r := range x {
+			items = append(items, string(r))
+		}
+		return items, nil
+	case *object:
+		if x.class == classArray {
+			return append([]interface{}(nil), x.array...), nil
+		}
+	}
+	return nil, in.throwf("TypeError", "%s is not iterable", typeName(v))
+}
+
+func (in *interp) execForOf(s *ForOf, sc *scope) (completion, error) {
+	x, err := in.eval(s.X, sc)
+	if err != nil {
+		return normal, err
+	}
+	items, err := in.iterate(x)
+	if err != nil {
+		return normal, err
+	}
+	for _, item := range items {
+		iter := newScope(sc, false)
+		switch s.Kind {
+		case "":
+			err = in.assign(sc, s.Name, item)
+		case "var":
+			err = in.assign(sc, s.Name, item)
+		default:
+			err = in.declare(iter, s.Kind, s.Name, item)
+		}
+		if err != nil {
+			return normal, err
+		}
+		c, err := in.exec(s.Body, iter)
+		if err != nil || c == returnCompletion {
+			return c, err
+		}
+		if c == breakCompletion {
+			break
+		}
+	}
+	return normal, nil
+}
+
+func (in *interp) execTry(s *Try, sc *scope) (completion, error) {
+	c, err := in.execBlock(s.Body, sc)
+	if err != nil && s.Catch != nil && catchable(err) {
+		inner := newScope(sc, false)
+		if s.Param != "" {
+			inner.vars[s.Param] = &binding{value: in.errorValue(err)}
+		}
+		c, err = in.execBlock(s.Catch, inner)
+	}
+	if s.Finally != nil && (err == nil || catchable(err)) {
+		ret := in.ret
+		fc, ferr := in.execBlock(s.Finally, sc)
+		if ferr != nil || fc != normal {
+			return fc, ferr
+		}
+		in.ret = ret
+	}
+	return c, err
+}
+
+// eval evaluates an expression.
+func (in *interp) eval(n Node, sc *scope) (interface{}, error) {
+	switch x := n.(type) {
+	case *NumberLit:
+		return x.Value, nil
+	case *StringLit:
+		return x.Value, nil
+	case *BoolLit:
+		return x.Value, nil
+	case *NullLit:
+		return nil, nil
+	case *TemplateLit:
+		var b strings.Builder
+		for i, q := range x.Quasis {
+			b.WriteString(q)
+			if i < len(x.Exprs) {
+				v, err := in.eval(x.Exprs[i], sc)
+				if err != nil {
+					return nil, err
+				}
+				s, err := in.toString(v)
+				if err != nil {
+					return nil, err
+				}
+				b.WriteString(s)
+			}
+		}
+		return b.String(), nil
+	case *Ident:
+		return in.lookup(sc, x.Name)
+	case *This:
+		return in.this(sc), nil
+	case *ArrayLit:
+		items, err := in.evalList(x.Elems, sc)
+		if err != nil {
+			return nil, err
+		}
+		return in.newArray(items), nil
+	case *ObjectLit:
+		return in.evalObject(x, sc)
+	case *FuncLit:
+		if x.Arrow {
+			return in.newClosure(x, sc, in.this(sc)), nil
+		}
+		if x.Name != "" {
+			sc = newScope(sc, false)
+			f := in.newClosure(x, sc, nil)
+			sc.vars[x.Name] = &binding{value: f, konst: true}
+			return f, nil
+		}
+		return in.newClosure(x, sc, nil), nil
+	case *Unary:
+		return in.evalUnary(x, sc)
+	case *Update:
+		return in.evalUpdate(x, sc)
+	case *Binary:
+		return in.evalBinary(x, sc)
+	case *Assign:
+		return in.evalAssign(x, sc)
+	case *Cond:
+		test, err := in.eval(x.Test, sc)
+		if err != nil {
+			return nil, err
+		}
+		if toBoolean(test) {
+			return in.eval(x.Then, sc)
+		}
+		return in.eval(x.Else, sc)
+	case *Call:
+		return in.evalCall(x, sc)
+	case *NewExpr:
+		return in.evalNew(x, sc)
+	case *Member:
+		obj, err := in.eval(x.X, sc)
+		if err != nil {
+			return nil, err
+		}
+		key, err := in.memberKey(x, sc)
+		if err != nil {
+			return nil, err
+		}
+		return in.get(obj, key)
+	}
+	return nil, fmt.Errorf("qjs: unknown expression %T", n)
+}
+
+func isNullish(v interface{}) bool {
+	switch v.(type) {
+	case undefinedType, nil:
+		return true
+	}
+	return false
+}
+
+// evalList evaluates call arguments or array elements.
+func (in *interp) evalList(list []Node, sc *scope) ([]interface{}, error) {
+	items := make([]interface{}, 0, len(list))
+	for _, e := range list {
+		v, err := in.eval(e, sc)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, v)
+	}
+	return items, nil
+}
+
+func (in *interp) evalObject(x *ObjectLit, sc *scope) (interface{}, error) {
+	o := in.newObject()
+	for _, p := range x.Props {
+		v, err := in.eval(p.Value, sc)
+		if err != nil {
+			return nil, err
+		}
+		if f, ok := v.(*object); ok && f.fn != nil && f.fn.lit.Name == "" {
+			f.hide("name", p.Key)
+		}
+		o.put(p.Key, v)
+	}
+	return o, nil
+}
+
+func (in *interp) memberKey(x *Member, sc *scope) (string, error) {
+	if x.Index == nil {
+		return x.Name, nil
+	}
+	k, err := in.eval(x.Index, sc)
+	if err != nil {
+		return "", err
+	}
+	return in.toString(k)
+}
+
+func (in *interp) evalUnary(x *Unary, sc *scope) (interface{}, error) {
+	if id, ok := x.X.(*Ident); ok && x.Op == "typeof" && !in.resolvable(sc, id.Name) {
+		return "undefined", nil
+	}
+	v, err := in.eval(x.X, sc)
+	if err != nil {
+		return nil, err
+	}
+	switch x.Op {
+	case "typeof":
+		return typeOf(v), nil
+	case "!":
+		return !toBoolean(v), nil
+	}
+	f, err := in.toNumber(v)
+	if err != nil {
+		return nil, err
+	}
+	if x.Op == "-" {
+		return -f, nil
+	}
+	return f, nil
+}
+
+// reference is an assignable location.
+type reference struct {
+	sc   *scope
+	name string
+	obj  interface{}
+	key  string
+}
+
+func (in *interp) reference(n Node, sc *scope) (*reference, error) {
+	switch x := n.(type) {
+	case *Ident:
+		return &reference{sc: sc, name: x.Name}, nil
+	case *Member:
+		obj, err := in.eval(x.X, sc)
+		if err != nil {
+			return nil, err
+		}
+		key, err := in.memberKey(x, sc)
+		if err != nil {
+			return nil, err
+		}
+		return &reference{obj: obj, key: key}, nil
+	}
+	return nil, in.throwf("SyntaxError", "invalid assignment target")
+}
+
+func (in *interp) getRef(r *reference) (interface{}, error) {
+	if r.sc != nil {
+		return in.lookup(r.sc, r.name)
+	}
+	return in.get(r.obj, r.key)
+}
+
+func (in *interp) setRef(r *reference, v interface{}) error {
+	if r.sc != nil {
+		return in.assign(r.sc, r.name, v)
+	}
+	return in.set(r.obj, r.key, v)
+}
+
+func (in *interp) evalUpdate(x *Update, sc *scope) (interface{}, error) {
+	r, err := in.reference(x.X, sc)
+	if err != nil {
+		return nil, err
+	}
+	old, err := in.getRef(r)
+	if err != nil {
+		return nil, err
+	}
+	f, err := in.toNumber(old)
+	if err != nil {
+		return nil, err
+	}
+	next := f + 1
+	if x.Op == "--" {
+		next = f - 1
+	}
+	if err := in.setRef(r, next); err != nil {
+		return nil, err
+	}
+	if x.Prefix {
+		return next, nil
+	}
+	return f, nil
+}
+
+func (in *interp) evalAssign(x *Assign, sc *scope) (interface{}, error) {
+	r, err := in.reference(x.Target, sc)
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	if x.Op == "=" {
+		if v, err = in.eval(x.Value, sc); err != nil {
+			return nil, err
+		}
+	} else {
+		old, err := in.getRef(r)
+		if err != nil {
+			return nil, err
+		}
+		rhs, err := in.eval(x.Value, sc)
+		if err != nil {
+			return nil, err
+		}
+		if v, err = in.binaryOp(strings.TrimSuffix(x.Op, "="), old, rhs); err != nil {
+			return nil, err
+		}
+	}
+	return v, in.setRef(r, v)
+}
+
+func (in *interp) evalBinary(x *Binary, sc *scope) (interface{}, error) {
+	l, err := in.eval(x.L, sc)
+	if err != nil {
+		return nil, err
+	}
+	switch x.Op {
+	case "&&":
+		if !toBoolean(l) {
+			return l, nil
+		}
+		return in.eval(x.R, sc)
+	case "||":
+		if toBoolean(l) {
+			return l, nil
+		}
+		return in.eval(x.R, sc)
+	case "??":
+		if !isNullish(l) {
+			return l, nil
+		}
+		return in.eval(x.R, sc)
+	}
+	r, err := in.eval(x.R, sc)
+	if err != nil {
+		return nil, err
+	}
+	return in.binaryOp(x.Op, l, r)
+}
+
+func (in *interp) binaryOp(op string, l, r interface{}) (interface{}, error) {
+	switch op {
+	case "===":
+		return strictEquals(l, r), nil
+	case "!==":
+		return !strictEquals(l, r), nil
+	case "==", "!=":
+		eq, err := in.looseEquals(l, r)
+		return eq == (op == "=="), err
+	}
+	l, err := in.toPrimitive(l)
+	if err != nil {
+		return nil, err
+	}
+	if r, err = in.toPrimitive(r); err != nil {
+		return nil, err
+	}
+	ls, lStr := l.(string)
+	rs, rStr := r.(string)
+	switch op {
+	case "+":
+		if lStr || rStr {
+			return stringOf(l) + stringOf(r), nil
+		}
+	case "<", ">", "<=", ">=":
+		if lStr && rStr {
+			switch op {
+			case "<":
+				return ls < rs, nil
+			case ">":
+				return ls > rs, nil
+			case "<=":
+				return ls <= rs, nil
+			}
+			return ls >= rs, nil
+		}
+	}
+	a, b := numberOf(l), numberOf(r)
+	switch op {
+	case "+":
+		return a + b, nil
+	case "-":
+		return a - b, nil
+	case "*":
+		return a * b, nil
+	case "/":
+		return a / b, nil
+	case "%":
+		return math.Mod(a, b), nil
+	case "<":
+		return a < b, nil
+	case ">":
+		return a > b, nil
+	case "<=":
+		return a <= b, nil
+	case ">=":
+		return a >= b, nil
+	}
+	return nil, fmt.Errorf("qjs: unknown operator %s", op)
+}
+
+// describe renders a callee for error messages.
+func describe(n Node) string {
+	switch x := n.(type) {
+	case *Ident:
+		return x.Name
+	case *This:
+		return "this"
+	case *Member:
+		if x.Index == nil {
+			return describe(x.X) + "." + x.Name
+		}
+		return describe(x.X) + "[...]"
+	case *Call:
+		return describe(x.Fn) + "(...)"
+	}
+	return "expression"
+}
+
+func (in *interp) evalCall(x *Call, sc *scope) (interface{}, error) {
+	var fn, this interface{} = nil, undefined
+	if m, ok := x.Fn.(*Member); ok {
+		obj, err := in.eval(m.X, sc)
+		if err != nil {
+			return nil, err
+		}
+		key, err := in.memberKey(m, sc)
+		if err != nil {
+			return nil, err
+		}
+		if fn, err = in.get(obj, key); err != nil {
+			return nil, err
+		}
+		this = obj
+	} else {
+		var err error
+		if fn, err = in.eval(x.Fn, sc); err != nil {
+			return nil, err
+		}
+	}
+	if f, ok := fn.(*object); !ok || !f.callable() {
+		return nil, in.throwf("TypeError", "%s is not a function", describe(x.Fn))
+	}
+	args, err := in.evalList(x.Args, sc)
+	if err != nil {
+		return nil, err
+	}
+	return in.call(fn, this, args)
+}
+
+func (in *interp) evalNew(x *NewExpr, sc *scope) (interface{}, error) {
+	fn, err := in.eval(x.Fn, sc)
+	if err != nil {
+		return nil, err
+	}
+	args, err := in.evalList(x.Args, sc)
+	if err != nil {
+		return nil, err
+	}
+	return in.construct(fn, args, describe(x.Fn))
+}
+
+// construct calls a constructor. Only the error types are constructors,
+// script functions cannot be called with new.
+func (in *interp) construct(fn interface{}, args []interface{}, name string) (interface{}, error) {
+	f, ok := fn.(*object)
+	if !ok || f.ctor == nil {
+		return nil, in.throwf("TypeError", "%s is not a constructor", name)
+	}
+	return f.ctor(args)
+}
+
+// call calls a function value.
+func (in *interp) call(fn interface{}, this interface{}, args []interface{}) (interface{}, error) {
+	f, ok := fn.(*object)
+	if !ok || !f.callable() {
+		return nil, in.throwf("TypeError", "%s is not a function", typeName(fn))
+	}
+	if in.depth >= in.maxDepth {
+		return nil, in.throwf("RangeError", "maximum call stack size exceeded")
+	}
+	if err := in.step(); err != nil {
+		return nil, err
+	}
+	in.depth++
+	defer func() { in.depth-- }()
+	if f.native != nil {
+		return f.native(this, args)
+	}
+	c := f.fn
+	sc := newScope(c.env, true)
+	sc.hasThis = true
+	sc.this = this
+	if c.lit.Arrow {
+		sc.this = c.this
+	}
+	for i, name := range c.lit.Params {
+		var v interface{} = undefined
+		if i < len(args) {
+			v = args[i]
+		}
+		sc.vars[name] = &binding{value: v}
+	}
+	if c.lit.Expr != nil {
+		return in.eval(c.lit.Expr, sc)
+	}
+	if err := in.hoist(c.lit.Body, sc, true); err != nil {
+		return nil, err
+	}
+	comp, err := in.execList(c.lit.Body, sc)
+	if err != nil {
+		return nil, err
+	}
+	if comp == returnCompletion {
+		r := in.ret
+		in.ret = undefined
+		return r, nil
+	}
+	return undefined, nil
+}
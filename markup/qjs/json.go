@@ -0,0 +1,193 @@
+package qjs
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"strings"
+)
+
+func (in *interp) setupJSON() {
+	j := in.newObject()
+	in.global.hide("JSON", j)
+	in.method(j, "stringify", 3, func(_ interface{}, args []interface{}) (interface{}, error) {
+		indent := ""
+		switch x := arg(args, 2).(type) {
+		case float64:
+			indent = strings.Repeat(" ", min(max(toInteger(x), 0), 10))
+		case string:
+			indent = x
+		}
+		s, ok, err := in.stringify(arg(args, 0), indent, "", nil)
+		if err != nil || !ok {
+			return undefined, err
+		}
+		return s, nil
+	})
+	in.method(j, "parse", 1, func(_ interface{}, args []interface{}) (interface{}, error) {
+		s, err := in.toString(arg(args, 0))
+		if err != nil {
+			return nil, err
+		}
+		return in.parseJSON(s)
+	})
+}
+
+// stringify serializes a value to JSON. It reports false for values that
+// have no JSON representation.
+func (in *interp) stringify(v interface{}, indent, prefix string, seen []*object) (string, bool, error) {
+	switch x := v.(type) {
+	case undefinedType:
+		return "", false, nil
+	case nil:
+		return "null", true, nil
+	case bool:
+		return stringOf(x), true, nil
+	case float64:
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			return "null", true, nil
+		}
+		return numberToString(x), true, nil
+	case string:
+		return quoteJSON(x), true, nil
+	}
+	o := v.(*object)
+	if o.callable() {
+		return "", false, nil
+	}
+	if fn, err := in.getFrom(o, "toJSON"); err != nil {
+		return "", false, err
+	} else if f, ok := fn.(*object); ok && f.callable() {
+		r, err := in.call(f, o, nil)
+		if err != nil {
+			return "", false, err
+		}
+		if _, ok := r.(*object); !ok {
+			return in.stringify(r, indent, prefix, seen)
+		}
+		o = r.(*object)
+	}
+	for _, s := range seen {
+		if s == o {
+			return "", false, in.throwf("TypeError", "circular structure in JSON.stringify")
+		}
+	}
+	seen = append(seen, o)
+	inner := prefix + indent
+	var parts []string
+	if o.class == classArray {
+		for _, item := range o.array {
+			s, ok, err := in.stringify(item, indent, inner, seen)
+			if err != nil {
+				return "", false, err
+			}
+			if !ok {
+				s = "null"
+			}
+			parts = append(parts, s)
+		}
+	} else {
+		for _, k := range o.ownKeys() {
+			pv, err := in.getFrom(o, k)
+			if err != nil {
+				return "", false, err
+			}
+			s, ok, err := in.stringify(pv, indent, inner, seen)
+			if err != nil {
+				return "", false, err
+			}
+			if !ok {
+				continue
+			}
+			sep := ":"
+			if indent != "" {
+				sep = ": "
+			}
+			parts = append(parts, quoteJSON(k)+sep+s)
+		}
+	}
+	open, end := "{", "}"
+	if o.class == classArray {
+		open, end = "[", "]"
+	}
+	if len(parts) == 0 {
+		return open + end, true, nil
+	}
+	if indent == "" {
+		return open + strings.Join(parts, ",") + end, true, nil
+	}
+	return open + "\n" + inner + strings.Join(parts, ",\n"+inner) + "\n" + prefix + end, true, nil
+}
+
+func quoteJSON(s string) string {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// parseJSON decodes JSON text, preserving the order of object keys.
+func (in *interp) parseJSON(s string) (interface{}, error) {
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	v, err := in.decodeJSON(dec)
+	if err == nil {
+		if _, err = dec.Token(); err != io.EOF {
+			err = in.throwf("SyntaxError", "unexpected data after JSON value")
+		} else {
+			err = nil
+		}
+	}
+	return v, err
+}
+
+func (in *interp) decodeJSON(dec *json.Decoder) (interface{}, error) {
+	t, err := dec.Token()
+	if err != nil {
+		return nil, in.throwf("SyntaxError", "invalid JSON: %s", err)
+	}
+	switch x := t.(type) {
+	case json.Delim:
+		switch x {
+		case '[':
+			var items []interface{}
+			for dec.More() {
+				v, err := in.decodeJSON(dec)
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, v)
+			}
+			_, err := dec.Token()
+			return in.newArray(items), err
+		case '{':
+			o := in.newObject()
+			for dec.More() {
+				k, err := dec.Token()
+				if err != nil {
+					return nil, in.throwf("SyntaxError", "invalid JSON: %s", err)
+				}
+				v, err := in.decodeJSON(dec)
+				if err != nil {
+					return nil, err
+				}
+				o.put(k.(string), v)
+			}
+			_, err := dec.Token()
+			return o, err
+		}
+	case json.Number:
+		f, err := x.Float64()
+		if err != nil {
+			return nil, in.throwf("SyntaxError", "invalid JSON number %s", x)
+		}
+		return f, nil
+	case string, bool:
+		return x, nil
+	case nil:
+		return nil, nil
+	}
+	return nil, in.throwf("SyntaxError", "invalid JSON")
+}
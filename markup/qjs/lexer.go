@@ -0,0 +1,360 @@
+package qjs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokTemplate
+	tokPunct
+)
+
+// token is a lexical token. For template literals, parts holds the cooked
+// string chunks and exprs the source of the embedded expressions.
+type token struct {
+	kind  tokenKind
+	text  string
+	num   float64
+	parts []string
+	exprs []string
+	nl    bool // whether a line break precedes the token
+	pos   int
+}
+
+// punctuators lists the operators and punctuation of the supported subset.
+var punctuators = []string{
+	"===", "!==", "=>", "==", "!=", "<=", ">=", "&&", "||", "??", "++", "--",
+	"+=", "-=", "*=", "/=", "%=",
+	"{", "}", "(", ")", "[", "]", ";", ",", ".", "<", ">", "+", "-",
+	"*", "/", "%", "!", "?", ":", "=",
+}
+
+// unsupported lists the operators of JavaScript which are outside of the
+// supported subset. They are rejected by the lexer with a SyntaxError naming
+// the operator.
+var unsupported = []string{
+	">>>=", "...", "**=", "<<=", ">>=", ">>>", "&&=", "||=", "??=",
+	"?.", "**", "<<", ">>", "&=", "|=", "^=", "&", "|", "^", "~",
+}
+
+type lexer struct {
+	name string
+	src  string
+	pos  int
+}
+
+// SyntaxError is returned when a script cannot be parsed.
+type SyntaxError struct {
+	File    string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("SyntaxError: %s (%s:%d:%d)", e.Message, e.File, e.Line, e.Column)
+}
+
+func (l *lexer) errorf(pos int, format string, args ...interface{}) error {
+	line, col := 1, 1
+	for _, r := range l.src[:min(pos, len(l.src))] {
+		if r == '\n' {
+			line++
+			col = 1
+		} else {
+			col++
+		}
+	}
+	return &SyntaxError{File: l.name, Line: line, Column: col, Message: fmt.Sprintf(format, args...)}
+}
+
+// tokenize splits the source into tokens.
+func (l *lexer) tokenize() ([]token, error) {
+	var tokens []token
+	for {
+		nl, err := l.skipSpace()
+		if err != nil {
+			return nil, err
+		}
+		t, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+		t.nl = nl
+		tokens = append(tokens, t)
+		if t.kind == tokEOF {
+			return tokens, nil
+		}
+	}
+}
+
+// skipSpace skips whitespace and comments and reports whether a line break
+// was skipped.
+func (l *lexer) skipSpace() (nl bool, err error) {
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '\n':
+			nl = true
+			l.pos++
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v':
+			l.pos++
+		case strings.HasPrefix(l.src[l.pos:], "//"):
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+		case strings.HasPrefix(l.src[l.pos:], "/*"):
+			end := strings.Index(l.src[l.pos+2:], "*/")
+			if end < 0 {
+				return nl, l.errorf(l.pos, "unterminated comment")
+			}
+			if strings.Contains(l.src[l.pos:l.pos+2+end], "\n") {
+				nl = true
+			}
+			l.pos += end + 4
+		default:
+			r, size := utf8.DecodeRuneInString(l.src[l.pos:])
+			if !unicode.IsSpace(r) {
+				return nl, nil
+			}
+			l.pos += size
+		}
+	}
+	return nl, nil
+}
+
+func isIdentStart(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r)
+}
+
+func isIdentPart(r rune) bool {
+	return isIdentStart(r) || unicode.IsDigit(r)
+}
+
+func (l *lexer) next() (token, error) {
+	start := l.pos
+	if l.pos >= len(l.src) {
+		return token{kind: tokEOF, pos: start}, nil
+	}
+	c := l.src[l.pos]
+	r, _ := utf8.DecodeRuneInString(l.src[l.pos:])
+	switch {
+	case isIdentStart(r):
+		for l.pos < len(l.src) {
+			r, size := utf8.DecodeRuneInString(l.src[l.pos:])
+			if !isIdentPart(r) {
+				break
+			}
+			l.pos += size
+		}
+		return token{kind: tokIdent, text: l.src[start:l.pos], pos: start}, nil
+	case c >= '0' && c <= '9' || c == '.' && l.pos+1 < len(l.src) && l.src[l.pos+1] >= '0' && l.src[l.pos+1] <= '9':
+		return l.number()
+	case c == '"' || c == '\'':
+		s, err := l.quoted(c)
+		return token{kind: tokString, text: s, pos: start}, err
+	case c == '`':
+		return l.template()
+	}
+	// The longest matching operator wins, so that e.g. "&&" is not taken
+	// for the unsupported "&".
+	op := ""
+	for _, p := range punctuators {
+		if len(p) > len(op) && strings.HasPrefix(l.src[l.pos:], p) {
+			op = p
+		}
+	}
+	for _, p := range unsupported {
+		if len(p) <= len(op) || !strings.HasPrefix(l.src[l.pos:], p) {
+			continue
+		}
+		// "?." followed by a digit is a conditional and a number.
+		if p == "?." && l.pos+2 < len(l.src) && l.src[l.pos+2] >= '0' && l.src[l.pos+2] <= '9' {
+			continue
+		}
+		return token{}, l.errorf(start, "operator '%s' is not supported", p)
+	}
+	if op != "" {
+		l.pos += len(op)
+		return token{kind: tokPunct, text: op, pos: start}, nil
+	}
+	return token{}, l.errorf(start, "unexpected character %q", r)
+}
+
+func (l *lexer) number() (token, error) {
+	start := l.pos
+	src := l.src
+	if strings.HasPrefix(src[l.pos:], "0x") || strings.HasPrefix(src[l.pos:], "0X") ||
+		strings.HasPrefix(src[l.pos:], "0b") || strings.HasPrefix(src[l.pos:], "0B") ||
+		strings.HasPrefix(src[l.pos:], "0o") || strings.HasPrefix(src[l.pos:], "0O") {
+		l.pos += 2
+		for l.pos < len(src) && (isHex(src[l.pos]) || src[l.pos] == '_') {
+			l.pos++
+		}
+		v, err := strconv.ParseInt(strings.ReplaceAll(src[start:l.pos], "_", ""), 0, 64)
+		if err != nil {
+			return token{}, l.errorf(start, "invalid number %s", src[start:l.pos])
+		}
+		return token{kind: tokNumber, num: float64(v), pos: start}, nil
+	}
+	for l.pos < len(src) && (src[l.pos] >= '0' && src[l.pos] <= '9' || src[l.pos] == '.' || src[l.pos] == '_') {
+		l.pos++
+	}
+	if l.pos < len(src) && (src[l.pos] == 'e' || src[l.pos] == 'E') {
+		l.pos++
+		if l.pos < len(src) && (src[l.pos] == '+' || src[l.pos] == '-') {
+			l.pos++
+		}
+		for l.pos < len(src) && src[l.pos] >= '0' && src[l.pos] <= '9' {
+			l.pos++
+		}
+	}
+	v, err := strconv.ParseFloat(strings.ReplaceAll(src[start:l.pos], "_", ""), 64)
+	if err != nil {
+		return token{}, l.errorf(start, "invalid number %s", src[start:l.pos])
+	}
+	return token{kind: tokNumber, num: v, pos: start}, nil
+}
+
+func isHex(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+// escape decodes the escape sequence following a backslash at l.pos.
+func (l *lexer) escape(b *strings.Builder) error {
+	if l.pos >= len(l.src) {
+		return l.errorf(l.pos, "unterminated string")
+	}
+	c := l.src[l.pos]
+	l.pos++
+	switch c {
+	case 'n':
+		b.WriteByte('\n')
+	case 't':
+		b.WriteByte('\t')
+	case 'r':
+		b.WriteByte('\r')
+	case 'b':
+		b.WriteByte('\b')
+	case 'f':
+		b.WriteByte('\f')
+	case 'v':
+		b.WriteByte('\v')
+	case '0':
+		b.WriteByte(0)
+	case '\n':
+	case 'x', 'u':
+		n := 2
+		if c == 'u' {
+			n = 4
+			if l.pos < len(l.src) && l.src[l.pos] == '{' {
+				end := strings.IndexByte(l.src[l.pos:], '}')
+				if end < 0 {
+					return l.errorf(l.pos, "invalid unicode escape")
+				}
+				v, err := strconv.ParseUint(l.src[l.pos+1:l.pos+end], 16, 32)
+				if err != nil {
+					return l.errorf(l.pos, "invalid unicode escape")
+				}
+				b.WriteRune(rune(v))
+				l.pos += end + 1
+				return nil
+			}
+		}
+		if l.pos+n > len(l.src) {
+			return l.errorf(l.pos, "invalid escape")
+		}
+		v, err := strconv.ParseUint(l.src[l.pos:l.pos+n], 16, 32)
+		if err != nil {
+			return l.errorf(l.pos, "invalid escape")
+		}
+		b.WriteRune(rune(v))
+		l.pos += n
+	default:
+		b.WriteByte(c)
+	}
+	return nil
+}
+
+func (l *lexer) quoted(quote byte) (string, error) {
+	start := l.pos
+	l.pos++
+	var b strings.Builder
+	for {
+		if l.pos >= len(l.src) || l.src[l.pos] == '\n' {
+			return "", l.errorf(start, "unterminated string")
+		}
+		c := l.src[l.pos]
+		l.pos++
+		switch c {
+		case quote:
+			return b.String(), nil
+		case '\\':
+			if err := l.escape(&b); err != nil {
+				return "", err
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+}
+
+func (l *lexer) template() (token, error) {
+	start := l.pos
+	l.pos++
+	t := token{kind: tokTemplate, pos: start}
+	var b strings.Builder
+	for {
+		if l.pos >= len(l.src) {
+			return token{}, l.errorf(start, "unterminated template literal")
+		}
+		c := l.src[l.pos]
+		switch {
+		case c == '`':
+			l.pos++
+			t.parts = append(t.parts, b.String())
+			return t, nil
+		case c == '\\':
+			l.pos++
+			if err := l.escape(&b); err != nil {
+				return token{}, err
+			}
+		case strings.HasPrefix(l.src[l.pos:], "${"):
+			t.parts = append(t.parts, b.String())
+			b.Reset()
+			l.pos += 2
+			exprStart, depth := l.pos, 1
+			for depth > 0 {
+				if l.pos >= len(l.src) {
+					return token{}, l.errorf(start, "unterminated template literal")
+				}
+				switch l.src[l.pos] {
+				case '{':
+					depth++
+				case '}':
+					depth--
+				case '"', '\'':
+					if _, err := l.quoted(l.src[l.pos]); err != nil {
+						return token{}, err
+					}
+					continue
+				}
+				l.pos++
+			}
+			t.exprs = append(t.exprs, l.src[exprStart:l.pos-1])
+		default:
+			b.WriteByte(c)
+			l.pos++
+		}
+	}
+}
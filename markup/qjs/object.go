@@ -0,0 +1,312 @@
+package qjs
+
+import (
+	"strconv"
+)
+
+// undefinedType is the type of the undefined value. JavaScript values are
+// represented as undefined, nil (null), bool, float64, string or *object.
+type undefinedType struct{}
+
+var undefined = undefinedType{}
+
+const (
+	classObject   = "Object"
+	classArray    = "Array"
+	classFunction = "Function"
+	classError    = "Error"
+)
+
+// maxArrayGrowth limits how far past its end an array may be extended by a
+// single assignment.
+const maxArrayGrowth = 1 << 20
+
+// property is an own property of an object. Host accessors are Go functions
+// so that bound widgets can be exposed as live properties.
+type property struct {
+	value  interface{}
+	get    func() (interface{}, error)
+	set    func(interface{}) error
+	hidden bool
+}
+
+// object is a JavaScript object, array or function.
+type object struct {
+	class  string
+	proto  *object
+	props  map[string]*property
+	keys   []string
+	array  []interface{}
+	native func(this interface{}, args []interface{}) (interface{}, error)
+	ctor   func(args []interface{}) (interface{}, error)
+	fn     *closure
+}
+
+// closure is a function defined by a script.
+type closure struct {
+	lit  *FuncLit
+	env  *scope
+	this interface{} // captured this of arrow functions
+}
+
+func (o *object) callable() bool {
+	return o.native != nil || o.fn != nil
+}
+
+func (o *object) own(key string) (*property, bool) {
+	p, ok := o.props[key]
+	return p, ok
+}
+
+// put sets an own data property, adding it if necessary.
+func (o *object) put(key string, v interface{}) {
+	if p, ok := o.props[key]; ok {
+		p.value = v
+		return
+	}
+	if o.props == nil {
+		o.props = make(map[string]*property)
+	}
+	o.props[key] = &property{value: v}
+	o.keys = append(o.keys, key)
+}
+
+// hide sets an own non-enumerable data property.
+func (o *object) hide(key string, v interface{}) {
+	o.put(key, v)
+	o.props[key].hidden = true
+}
+
+func (o *object) remove(key string) {
+	if _, ok := o.props[key]; !ok {
+		return
+	}
+	delete(o.props, key)
+	for i, k := range o.keys {
+		if k == key {
+			o.keys = append(o.keys[:i:i], o.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// ownKeys returns the enumerable own property keys in insertion order,
+// preceded by the indices of arrays.
+func (o *object) ownKeys() []string {
+	var keys []string
+	for i := range o.array {
+		keys = append(keys, strconv.Itoa(i))
+	}
+	for _, k := range o.keys {
+		if !o.props[k].hidden {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// arrayIndex reports whether key is a canonical array index.
+func arrayIndex(key string) (int, bool) {
+	if key == "" || len(key) > 10 || key[0] == '-' || key[0] == '+' || len(key) > 1 && key[0] == '0' {
+		return 0, false
+	}
+	i, err := strconv.Atoi(key)
+	if err != nil || i < 0 {
+		return 0, false
+	}
+	return i, true
+}
+
+func (in *interp) newObject() *object {
+	return &object{class: classObject, proto: in.objectProto}
+}
+
+func (in *interp) newArray(elems []interface{}) *object {
+	if elems == nil {
+		elems = []interface{}{}
+	}
+	return &object{class: classArray, proto: in.arrayProto, array: elems}
+}
+
+func (in *interp) newNative(name string, arity int, fn func(this interface{}, args []interface{}) (interface{}, error)) *object {
+	o := &object{class: classFunction, proto: in.functionProto, native: fn}
+	o.hide("name", name)
+	o.hide("length", float64(arity))
+	return o
+}
+
+func (in *interp) newClosure(lit *FuncLit, env *scope, this interface{}) *object {
+	o := &object{class: classFunction, proto: in.functionProto, fn: &closure{lit: lit, env: env, this: this}}
+	o.hide("name", lit.Name)
+	o.hide("length", float64(len(lit.Params)))
+	return o
+}
+
+// newError creates an error object of the named error type.
+func (in *interp) newError(name, message string) *object {
+	proto := in.errorProto
+	if ctor, ok := in.errorCtors[name]; ok {
+		if p, ok := ctor.own("prototype"); ok {
+			proto, _ = p.value.(*object)
+		}
+	}
+	o := &object{class: classError, proto: proto}
+	o.hide("message", message)
+	return o
+}
+
+// get reads a property of any value.
+func (in *interp) get(v interface{}, key string) (interface{}, error) {
+	switch x := v.(type) {
+	case undefinedType, nil:
+		return nil, in.throwf("TypeError", "cannot read property '%s' of %s", key, typeName(v))
+	case string:
+		if key == "length" {
+			return float64(len([]rune(x))), nil
+		}
+		if i, ok := arrayIndex(key); ok {
+			r := []rune(x)
+			if i < len(r) {
+				return string(r[i]), nil
+			}
+			return undefined, nil
+		}
+		return in.getFrom(in.stringProto, key)
+	case float64:
+		return in.getFrom(in.numberProto, key)
+	case bool:
+		return in.getFrom(in.booleanProto, key)
+	case *object:
+		return in.getFrom(x, key)
+	}
+	return undefined, nil
+}
+
+func (in *interp) getFrom(o *object, key string) (interface{}, error) {
+	for ; o != nil; o = o.proto {
+		if o.class == classArray {
+			if key == "length" {
+				return float64(len(o.array)), nil
+			}
+			if i, ok := arrayIndex(key); ok {
+				if i < len(o.array) {
+					return o.array[i], nil
+				}
+				return undefined, nil
+			}
+		}
+		if p, ok := o.props[key]; ok {
+			if p.get != nil {
+				return p.get()
+			}
+			return p.value, nil
+		}
+	}
+	return undefined, nil
+}
+
+// has reports whether the object or its prototypes have the property.
+func (o *object) has(key string) bool {
+	for ; o != nil; o = o.proto {
+		if o.class == classArray {
+			if key == "length" {
+				return true
+			}
+			if i, ok := arrayIndex(key); ok && i < len(o.array) {
+				return true
+			}
+		}
+		if _, ok := o.props[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// set writes a property. Writes to primitives are ignored.
+func (in *interp) set(v interface{}, key string, val interface{}) error {
+	o, ok := v.(*object)
+	if !ok {
+		switch v.(type) {
+		case undefinedType, nil:
+			return in.throwf("TypeError", "cannot set property '%s' of %s", key, typeName(v))
+		}
+		return nil
+	}
+	if o.class == classArray {
+		if key == "length" {
+			n, err := in.toNumber(val)
+			if err != nil {
+				return err
+			}
+			if n < 0 || n != float64(int(n)) || int(n) > len(o.array)+maxArrayGrowth {
+				return in.throwf("RangeError", "invalid array length")
+			}
+			o.resize(int(n))
+			return nil
+		}
+		if i, ok := arrayIndex(key); ok {
+			if i >= len(o.array) {
+				if i > len(o.array)+maxArrayGrowth {
+					return in.throwf("RangeError", "invalid array index")
+				}
+				o.resize(i + 1)
+			}
+			o.array[i] = val
+			return nil
+		}
+	}
+	if p, ok := o.props[key]; ok {
+		if p.set != nil {
+			return p.set(val)
+		}
+		if p.get != nil {
+			return in.throwf("TypeError", "cannot set property '%s' which has only a getter", key)
+		}
+		p.value = val
+		return nil
+	}
+	o.put(key, val)
+	return nil
+}
+
+func (o *object) resize(n int) {
+	if n <= len(o.array) {
+		o.array = o.array[:n]
+		return
+	}
+	for len(o.array) < n {
+		o.array = append(o.array, undefined)
+	}
+}
+
+// typeName returns the name used for a value in error messages.
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case undefinedType:
+		return "undefined"
+	case nil:
+		return "null"
+	}
+	return typeOf(v)
+}
+
+func typeOf(v interface{}) string {
+	switch x := v.(type) {
+	case undefinedType:
+		return "undefined"
+	case nil:
+		return "object"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case *object:
+		if x.callable() {
+			return "function"
+		}
+	}
+	return "object"
+}
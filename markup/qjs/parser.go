@@ -0,0 +1,870 @@
+package qjs
+
+type parser struct {
+	lex    *lexer
+	tokens []token
+	pos    int
+	module bool
+}
+
+// parse parses src into a program.
+func parse(name, src string, module bool) (*Program, error) {
+	p, err := newParser(name, src, module)
+	if err != nil {
+		return nil, err
+	}
+	prog := &Program{File: name, Module: module}
+	for !p.at(tokEOF, "") {
+		stmt, err := p.statement()
+		if err != nil {
+			return nil, err
+		}
+		prog.Body = append(prog.Body, stmt)
+	}
+	return prog, nil
+}
+
+func newParser(name, src string, module bool) (*parser, error) {
+	l := &lexer{name: name, src: src}
+	tokens, err := l.tokenize()
+	if err != nil {
+		return nil, err
+	}
+	return &parser{lex: l, tokens: tokens, module: module}, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) peekAt(offset int) token {
+	if p.pos+offset >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+offset]
+}
+
+func (p *parser) advance() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// at reports whether the current token is of the given kind and, unless text
+// is empty, has the given text.
+func (p *parser) at(kind tokenKind, text string) bool {
+	t := p.peek()
+	return t.kind == kind && (text == "" || t.text == text)
+}
+
+func (p *parser) atPunct(text string) bool {
+	return p.at(tokPunct, text)
+}
+
+func (p *parser) atKeyword(text string) bool {
+	return p.at(tokIdent, text)
+}
+
+func (p *parser) accept(text string) bool {
+	t := p.peek()
+	if (t.kind == tokPunct || t.kind == tokIdent) && t.text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return p.lex.errorf(p.peek().pos, format, args...)
+}
+
+func (p *parser) unexpected() error {
+	t := p.peek()
+	switch t.kind {
+	case tokEOF:
+		return p.errorf("unexpected end of input")
+	case tokString, tokTemplate:
+		return p.errorf("unexpected string")
+	case tokNumber:
+		return p.errorf("unexpected number")
+	}
+	return p.errorf("unexpected token '%s'", t.text)
+}
+
+func (p *parser) expect(text string) error {
+	if !p.accept(text) {
+		return p.unexpected()
+	}
+	return nil
+}
+
+func (p *parser) ident() (string, error) {
+	t := p.peek()
+	if t.kind != tokIdent || reserved[t.text] {
+		return "", p.unexpected()
+	}
+	p.pos++
+	return t.text, nil
+}
+
+// semicolon consumes a statement terminator, applying automatic semicolon
+// insertion.
+func (p *parser) semicolon() error {
+	if p.accept(";") {
+		return nil
+	}
+	t := p.peek()
+	if t.kind == tokEOF || t.nl || t.kind == tokPunct && t.text == "}" {
+		return nil
+	}
+	return p.unexpected()
+}
+
+var reserved = map[string]bool{
+	"break": true, "case": true, "catch": true, "const": true, "continue": true,
+	"default": true, "delete": true, "do": true, "else": true, "export": true,
+	"finally": true, "for": true, "function": true, "if": true, "in": true,
+	"instanceof": true, "let": true, "new": true, "return": true, "switch": true,
+	"this": true, "throw": true, "try": true, "typeof": true, "var": true,
+	"void": true, "while": true, "null": true, "true": true, "false": true,
+	"import": true, "class": true,
+}
+
+func (p *parser) statement() (Node, error) {
+	t := p.peek()
+	if t.kind == tokPunct {
+		switch t.text {
+		case "{":
+			return p.block()
+		case ";":
+			p.advance()
+			return &Empty{}, nil
+		}
+	}
+	if t.kind == tokIdent {
+		switch t.text {
+		case "var", "let", "const":
+			decl, err := p.varDecl()
+			if err != nil {
+				return nil, err
+			}
+			return decl, p.semicolon()
+		case "function":
+			p.advance()
+			fn, err := p.function(true)
+			if err != nil {
+				return nil, err
+			}
+			return &FuncDecl{Fn: fn}, nil
+		case "return":
+			p.advance()
+			r := &Return{}
+			if n := p.peek(); !n.nl && !(n.kind == tokPunct && (n.text == ";" || n.text == "}")) && n.kind != tokEOF {
+				x, err := p.expression()
+				if err != nil {
+					return nil, err
+				}
+				r.X = x
+			}
+			return r, p.semicolon()
+		case "if":
+			return p.ifStatement()
+		case "for":
+			return p.forStatement()
+		case "while":
+			p.advance()
+			test, err := p.paren()
+			if err != nil {
+				return nil, err
+			}
+			body, err := p.statement()
+			return &While{Test: test, Body: body}, err
+		case "break":
+			p.advance()
+			return &Break{}, p.semicolon()
+		case "continue":
+			p.advance()
+			return &Continue{}, p.semicolon()
+		case "throw":
+			p.advance()
+			if p.peek().nl {
+				return nil, p.errorf("illegal newline after throw")
+			}
+			x, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			return &Throw{X: x}, p.semicolon()
+		case "try":
+			return p.tryStatement()
+		case "export":
+			return p.exportStatement()
+		case "do", "switch", "import", "class":
+			return nil, p.errorf("%s is not supported", t.text)
+		}
+	}
+	x, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	return &ExprStmt{X: x}, p.semicolon()
+}
+
+func (p *parser) block() (*Block, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	b := &Block{}
+	for !p.accept("}") {
+		if p.at(tokEOF, "") {
+			return nil, p.unexpected()
+		}
+		stmt, err := p.statement()
+		if err != nil {
+			return nil, err
+		}
+		b.Body = append(b.Body, stmt)
+	}
+	return b, nil
+}
+
+func (p *parser) paren() (Node, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	x, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	return x, p.expect(")")
+}
+
+func (p *parser) varDecl() (*VarDecl, error) {
+	decl := &VarDecl{Kind: p.advance().text}
+	for {
+		name, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		d := Declarator{Name: name}
+		if p.accept("=") {
+			if d.Init, err = p.assignment(); err != nil {
+				return nil, err
+			}
+		} else if decl.Kind == "const" && !p.atKeyword("of") && !p.atKeyword("in") {
+			return nil, p.errorf("missing initializer in const declaration")
+		}
+		decl.Decls = append(decl.Decls, d)
+		if !p.accept(",") {
+			return decl, nil
+		}
+	}
+}
+
+func (p *parser) ifStatement() (Node, error) {
+	p.advance()
+	test, err := p.paren()
+	if err != nil {
+		return nil, err
+	}
+	then, err := p.statement()
+	if err != nil {
+		return nil, err
+	}
+	s := &If{Test: test, Then: then}
+	if p.accept("else") {
+		if s.Else, err = p.statement(); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (p *parser) forStatement() (Node, error) {
+	p.advance()
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var init Node
+	if p.atKeyword("var") || p.atKeyword("let") || p.atKeyword("const") {
+		kind := p.peek().text
+		if n := p.peekAt(2); p.peekAt(1).kind == tokIdent && n.kind == tokIdent && (n.text == "of" || n.text == "in") {
+			p.advance()
+			name, _ := p.ident()
+			return p.forOf(kind, name)
+		}
+		decl, err := p.varDecl()
+		if err != nil {
+			return nil, err
+		}
+		init = decl
+	} else if !p.atPunct(";") {
+		if n := p.peekAt(1); p.peek().kind == tokIdent && n.kind == tokIdent && (n.text == "of" || n.text == "in") {
+			name, err := p.ident()
+			if err != nil {
+				return nil, err
+			}
+			return p.forOf("", name)
+		}
+		x, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		init = &ExprStmt{X: x}
+	}
+	s := &For{Init: init}
+	var err error
+	if err := p.expect(";"); err != nil {
+		return nil, err
+	}
+	if !p.atPunct(";") {
+		if s.Test, err = p.expression(); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expect(";"); err != nil {
+		return nil, err
+	}
+	if !p.atPunct(")") {
+		if s.Update, err = p.expression(); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	s.Body, err = p.statement()
+	return s, err
+}
+
+func (p *parser) forOf(kind, name string) (Node, error) {
+	if p.atKeyword("in") {
+		return nil, p.errorf("for...in is not supported")
+	}
+	p.advance()
+	s := &ForOf{Kind: kind, Name: name}
+	var err error
+	if s.X, err = p.expression(); err != nil {
+		return nil, err
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	s.Body, err = p.statement()
+	return s, err
+}
+
+func (p *parser) tryStatement() (Node, error) {
+	p.advance()
+	s := &Try{}
+	var err error
+	if s.Body, err = p.block(); err != nil {
+		return nil, err
+	}
+	if p.accept("catch") {
+		if p.accept("(") {
+			if s.Param, err = p.ident(); err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+		}
+		if s.Catch, err = p.block(); err != nil {
+			return nil, err
+		}
+	}
+	if p.accept("finally") {
+		if s.Finally, err = p.block(); err != nil {
+			return nil, err
+		}
+	}
+	if s.Catch == nil && s.Finally == nil {
+		return nil, p.errorf("missing catch or finally after try")
+	}
+	return s, nil
+}
+
+func (p *parser) exportStatement() (Node, error) {
+	if !p.module {
+		return nil, p.errorf("unexpected token 'export'")
+	}
+	p.advance()
+	if p.accept("default") {
+		x, err := p.assignment()
+		if err != nil {
+			return nil, err
+		}
+		return &Export{X: x}, p.semicolon()
+	}
+	switch {
+	case p.atKeyword("var"), p.atKeyword("let"), p.atKeyword("const"):
+		decl, err := p.varDecl()
+		if err != nil {
+			return nil, err
+		}
+		return &Export{Decl: decl}, p.semicolon()
+	case p.atKeyword("function"):
+		p.advance()
+		fn, err := p.function(true)
+		if err != nil {
+			return nil, err
+		}
+		return &Export{Decl: &FuncDecl{Fn: fn}}, nil
+	}
+	return nil, p.unexpected()
+}
+
+// function parses the rest of a function after the function keyword.
+func (p *parser) function(requireName bool) (*FuncLit, error) {
+	fn := &FuncLit{}
+	if p.peek().kind == tokIdent && !p.atPunct("(") {
+		name, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		fn.Name = name
+	} else if requireName {
+		return nil, p.unexpected()
+	}
+	params, err := p.params()
+	if err != nil {
+		return nil, err
+	}
+	fn.Params = params
+	body, err := p.block()
+	if err != nil {
+		return nil, err
+	}
+	fn.Body = body.Body
+	return fn, nil
+}
+
+func (p *parser) params() ([]string, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var params []string
+	for !p.accept(")") {
+		name, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		if p.atPunct("=") {
+			return nil, p.errorf("default parameters are not supported")
+		}
+		params = append(params, name)
+		if !p.accept(",") {
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	return params, nil
+}
+
+// expression parses an expression. The comma operator is not supported, so
+// this is the same as an assignment expression.
+func (p *parser) expression() (Node, error) {
+	return p.assignment()
+}
+
+// isArrow reports whether an arrow function starts at the current token.
+func (p *parser) isArrow() bool {
+	t := p.peek()
+	if t.kind == tokIdent && !reserved[t.text] {
+		n := p.peekAt(1)
+		return n.kind == tokPunct && n.text == "=>" && !n.nl
+	}
+	if t.kind != tokPunct || t.text != "(" {
+		return false
+	}
+	depth := 0
+	for i := p.pos; i < len(p.tokens); i++ {
+		t := p.tokens[i]
+		if t.kind == tokEOF {
+			return false
+		}
+		if t.kind != tokPunct {
+			continue
+		}
+		switch t.text {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth--
+			if depth == 0 {
+				n := p.tokens[i+1]
+				return n.kind == tokPunct && n.text == "=>" && !n.nl
+			}
+		}
+	}
+	return false
+}
+
+func (p *parser) arrow() (Node, error) {
+	fn := &FuncLit{Arrow: true}
+	if p.atPunct("(") {
+		params, err := p.params()
+		if err != nil {
+			return nil, err
+		}
+		fn.Params = params
+	} else {
+		name, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		fn.Params = []string{name}
+	}
+	if err := p.expect("=>"); err != nil {
+		return nil, err
+	}
+	if p.atPunct("{") {
+		body, err := p.block()
+		if err != nil {
+			return nil, err
+		}
+		fn.Body = body.Body
+		return fn, nil
+	}
+	x, err := p.assignment()
+	fn.Expr = x
+	return fn, err
+}
+
+var assignOps = map[string]bool{
+	"=": true, "+=": true, "-=": true, "*=": true, "/=": true, "%=": true,
+}
+
+func (p *parser) assignment() (Node, error) {
+	if p.isArrow() {
+		return p.arrow()
+	}
+	x, err := p.conditional()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind == tokPunct && assignOps[t.text] {
+		switch x.(type) {
+		case *Ident, *Member:
+		default:
+			return nil, p.errorf("invalid assignment target")
+		}
+		p.advance()
+		value, err := p.assignment()
+		if err != nil {
+			return nil, err
+		}
+		return &Assign{Op: t.text, Target: x, Value: value}, nil
+	}
+	return x, nil
+}
+
+func (p *parser) conditional() (Node, error) {
+	test, err := p.binary(1)
+	if err != nil || !p.accept("?") {
+		return test, err
+	}
+	then, err := p.assignment()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	els, err := p.assignment()
+	return &Cond{Test: test, Then: then, Else: els}, err
+}
+
+var binaryPrecedence = map[string]int{
+	"??": 1, "||": 2, "&&": 3,
+	"==": 4, "!=": 4, "===": 4, "!==": 4,
+	"<": 5, ">": 5, "<=": 5, ">=": 5,
+	"+": 6, "-": 6, "*": 7, "/": 7, "%": 7,
+}
+
+func (p *parser) binary(minPrec int) (Node, error) {
+	x, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if t.kind == tokIdent && (t.text == "instanceof" || t.text == "in") {
+			return nil, p.errorf("operator '%s' is not supported", t.text)
+		}
+		if t.kind != tokPunct {
+			return x, nil
+		}
+		prec, ok := binaryPrecedence[t.text]
+		if !ok || prec < minPrec {
+			return x, nil
+		}
+		p.advance()
+		y, err := p.binary(prec + 1)
+		if err != nil {
+			return nil, err
+		}
+		x = &Binary{Op: t.text, L: x, R: y}
+	}
+}
+
+func (p *parser) unary() (Node, error) {
+	t := p.peek()
+	if t.kind == tokIdent && (t.text == "void" || t.text == "delete") {
+		return nil, p.errorf("operator '%s' is not supported", t.text)
+	}
+	if t.kind == tokPunct && (t.text == "!" || t.text == "-" || t.text == "+") || t.kind == tokIdent && t.text == "typeof" {
+		p.advance()
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		if t.text == "-" || t.text == "+" {
+			if n, ok := x.(*NumberLit); ok && t.text == "-" {
+				return &NumberLit{Value: -n.Value}, nil
+			}
+		}
+		return &Unary{Op: t.text, X: x}, nil
+	}
+	if t.kind == tokPunct && (t.text == "++" || t.text == "--") {
+		p.advance()
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &Update{Op: t.text, Prefix: true, X: x}, nil
+	}
+	x, err := p.postfix()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind == tokPunct && (t.text == "++" || t.text == "--") && !t.nl {
+		p.advance()
+		return &Update{Op: t.text, X: x}, nil
+	}
+	return x, nil
+}
+
+func (p *parser) arguments() ([]Node, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var args []Node
+	for !p.accept(")") {
+		arg, err := p.assignment()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		if !p.accept(",") {
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	return args, nil
+}
+
+func (p *parser) propertyName() (string, error) {
+	t := p.advance()
+	switch t.kind {
+	case tokIdent, tokString:
+		return t.text, nil
+	case tokNumber:
+		return numberToString(t.num), nil
+	}
+	p.pos--
+	return "", p.unexpected()
+}
+
+func (p *parser) postfix() (Node, error) {
+	var x Node
+	var err error
+	if p.accept("new") {
+		fn, err := p.primary()
+		if err != nil {
+			return nil, err
+		}
+		for p.accept(".") {
+			name, err := p.propertyName()
+			if err != nil {
+				return nil, err
+			}
+			fn = &Member{X: fn, Name: name}
+		}
+		n := &NewExpr{Fn: fn}
+		if p.atPunct("(") {
+			if n.Args, err = p.arguments(); err != nil {
+				return nil, err
+			}
+		}
+		x = n
+	} else if x, err = p.primary(); err != nil {
+		return nil, err
+	}
+	for {
+		switch {
+		case p.accept("."):
+			name, err := p.propertyName()
+			if err != nil {
+				return nil, err
+			}
+			x = &Member{X: x, Name: name}
+		case p.accept("["):
+			index, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			x = &Member{X: x, Index: index}
+		case p.atPunct("("):
+			args, err := p.arguments()
+			if err != nil {
+				return nil, err
+			}
+			x = &Call{Fn: x, Args: args}
+		default:
+			return x, nil
+		}
+	}
+}
+
+func (p *parser) primary() (Node, error) {
+	t := p.peek()
+	switch t.kind {
+	case tokNumber:
+		p.advance()
+		return &NumberLit{Value: t.num}, nil
+	case tokString:
+		p.advance()
+		return &StringLit{Value: t.text}, nil
+	case tokTemplate:
+		p.advance()
+		lit := &TemplateLit{Quasis: t.parts}
+		for _, src := range t.exprs {
+			sub, err := newParser(p.lex.name, src, false)
+			if err != nil {
+				return nil, err
+			}
+			x, err := sub.expression()
+			if err != nil {
+				return nil, err
+			}
+			if !sub.at(tokEOF, "") {
+				return nil, sub.unexpected()
+			}
+			lit.Exprs = append(lit.Exprs, x)
+		}
+		return lit, nil
+	case tokIdent:
+		switch t.text {
+		case "true", "false":
+			p.advance()
+			return &BoolLit{Value: t.text == "true"}, nil
+		case "null":
+			p.advance()
+			return &NullLit{}, nil
+		case "this":
+			p.advance()
+			return &This{}, nil
+		case "function":
+			p.advance()
+			return p.function(false)
+		}
+		name, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		return &Ident{Name: name}, nil
+	case tokPunct:
+		switch t.text {
+		case "(":
+			return p.paren()
+		case "[":
+			return p.arrayLiteral()
+		case "{":
+			return p.objectLiteral()
+		}
+	}
+	return nil, p.unexpected()
+}
+
+func (p *parser) arrayLiteral() (Node, error) {
+	p.advance()
+	lit := &ArrayLit{}
+	for !p.accept("]") {
+		if p.accept(",") {
+			lit.Elems = append(lit.Elems, &Ident{Name: "undefined"})
+			continue
+		}
+		x, err := p.assignment()
+		if err != nil {
+			return nil, err
+		}
+		lit.Elems = append(lit.Elems, x)
+		if !p.accept(",") {
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	return lit, nil
+}
+
+func (p *parser) objectLiteral() (Node, error) {
+	p.advance()
+	lit := &ObjectLit{}
+	for !p.accept("}") {
+		var prop Prop
+		var err error
+		if p.atPunct("[") {
+			return nil, p.errorf("computed property names are not supported")
+		}
+		shorthand := p.peek().kind == tokIdent
+		if prop.Key, err = p.propertyName(); err != nil {
+			return nil, err
+		}
+		if shorthand && (p.atPunct(",") || p.atPunct("}")) {
+			if reserved[prop.Key] {
+				return nil, p.unexpected()
+			}
+			prop.Value = &Ident{Name: prop.Key}
+		} else {
+			if p.atPunct("(") {
+				params, err := p.params()
+				if err != nil {
+					return nil, err
+				}
+				body, err := p.block()
+				if err != nil {
+					return nil, err
+				}
+				prop.Value = &FuncLit{Name: prop.Key, Params: params, Body: body.Body}
+			} else {
+				if err := p.expect(":"); err != nil {
+					return nil, err
+				}
+				if prop.Value, err = p.assignment(); err != nil {
+					return nil, err
+				}
+			}
+		}
+		lit.Props = append(lit.Props, prop)
+		if !p.accept(",") {
+			if err := p.expect("}"); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	return lit, nil
+}
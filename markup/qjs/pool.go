@@ -0,0 +1,89 @@
+package qjs
+
+import (
+	"errors"
+	"sync"
+)
+
+// Pool is a set of identically prepared runtimes for running scripts
+// concurrently.
+type Pool struct {
+	opt      Option
+	setup    func(*Runtime) error
+	runtimes chan *Runtime
+	mu       sync.Mutex
+	closed   bool
+}
+
+// NewPool returns a pool keeping up to size idle runtimes. Every runtime is
+// created with opt and passed to setup, if not nil, before first use.
+func NewPool(size int, opt Option, setup func(*Runtime) error) *Pool {
+	return &Pool{
+		opt:      opt,
+		setup:    setup,
+		runtimes: make(chan *Runtime, max(size, 1)),
+	}
+}
+
+// Get returns an idle runtime or creates a new one.
+func (p *Pool) Get() (*Runtime, error) {
+	p.mu.Lock()
+	closed := p.closed
+	p.mu.Unlock()
+	if closed {
+		return nil, errors.New("qjs: pool is closed")
+	}
+	select {
+	case r := <-p.runtimes:
+		return r, nil
+	default:
+	}
+	r, err := New(p.opt)
+	if err != nil {
+		return nil, err
+	}
+	if p.setup != nil {
+		if err := p.setup(r); err != nil {
+			r.Close()
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Put returns a runtime obtained by Get to the pool.
+func (p *Pool) Put(r *Runtime) {
+	if r == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		r.Close()
+		return
+	}
+	select {
+	case p.runtimes <- r:
+	default:
+		r.Close()
+	}
+}
+
+// Close closes all idle runtimes. Runtimes returned afterwards are closed
+// immediately.
+func (p *Pool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return
+	}
+	p.closed = true
+	for {
+		select {
+		case r := <-p.runtimes:
+			r.Close()
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,326 @@
+// Package qjs provides a small, sandboxed JavaScript interpreter for the
+// scripts and event handlers of markup documents. Scripts have no access to
+// the host beyond the functions and objects registered by Go code.
+//
+// The interpreter implements a deliberately small subset of JavaScript,
+// everything else is rejected with a SyntaxError when the script is parsed or
+// is not defined when it runs:
+//
+//   - statements: var, let and const declarations, function declarations,
+//     if/else, for, for...of over arrays and strings, while, break, continue,
+//     return, throw, try/catch/finally and blocks;
+//   - modules: export default, and export of var, let, const and function
+//     declarations;
+//   - expressions: number, string, template, boolean, null, array and object
+//     literals (with shorthand properties and methods), function expressions
+//     and arrow functions with plain parameters, property access, calls,
+//     new for the error types, this, typeof, the unary operators ! - +,
+//     the binary operators + - * / % < > <= >= == != === !== && || ??,
+//     the conditional operator, the assignments = += -= *= /= %= and the
+//     increment and decrement operators;
+//   - globals: globalThis, undefined, NaN, Infinity, parseInt, parseFloat,
+//     isNaN, Boolean, Number, String, Object.keys, Object.values,
+//     Object.entries, Object.assign, Array.isArray, Math (PI, abs, ceil,
+//     floor, max, min, pow, random, round, sign, sqrt, trunc), JSON.parse,
+//     JSON.stringify, console (log, info, warn, error, debug) and the error
+//     types Error, TypeError, RangeError, ReferenceError and SyntaxError;
+//   - array methods: concat, every, filter, find, findIndex, forEach,
+//     includes, indexOf, join, map, pop, push, reduce, reverse, shift, slice,
+//     some, sort, toString and unshift;
+//   - string methods: charAt, endsWith, includes, indexOf, padEnd, padStart,
+//     repeat, replace, replaceAll (with string patterns), slice, split,
+//     startsWith, substring, toLowerCase, toString, toUpperCase and trim;
+//   - number methods: toFixed and toString.
+//
+// Not supported are among others classes, script constructors and
+// prototypes, getters and setters, destructuring, spread and rest syntax,
+// default parameters, optional chaining, regular expressions, switch,
+// do...while, for...in, labels, the comma operator, the bitwise operators,
+// **, in, instanceof, delete and void, imports, generators, async functions
+// and Promises.
+//
+// Scripts can be compiled ahead of time by Compile and evaluated by any
+// runtime with the Bytecode option, e.g. by the runtimes of a Pool, and are
+// cached on disk if Option.CacheDir is set. The "bytecode" is the parsed
+// syntax tree of the script serialized with encoding/gob, not instructions of
+// a virtual machine: it saves parsing, and evaluating it still walks the
+// tree. Eval checks the decoded tree before running it.
+package qjs
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// DefaultMaxCallDepth is the call depth limit used when Option does not
+	// set one.
+	DefaultMaxCallDepth = 512
+
+	bytecodeMagic = "QJSB\x02"
+)
+
+// Option configures a Runtime.
+type Option struct {
+	// CacheDir enables caching compiled scripts in the given directory.
+	CacheDir string
+	// Console receives the output of console.log and friends. Output is
+	// discarded if nil.
+	Console io.Writer
+	// MaxCallDepth limits the nesting of function calls.
+	MaxCallDepth int
+	// MaxSteps limits the number of statements and calls a single
+	// evaluation or call from Go may execute, zero means no limit. Scripts
+	// exceeding the limit fail with ErrInterrupted.
+	MaxSteps uint64
+}
+
+// Runtime is an isolated JavaScript environment. A runtime must not be used
+// by multiple goroutines at the same time, use a Pool to run scripts
+// concurrently.
+type Runtime struct {
+	opt    Option
+	in     *interp
+	ctx    *Context
+	closed bool
+}
+
+// New returns a new runtime. At most one option may be given.
+func New(opts ...Option) (*Runtime, error) {
+	var opt Option
+	switch len(opts) {
+	case 0:
+	case 1:
+		opt = opts[0]
+	default:
+		return nil, errors.New("qjs: too many options")
+	}
+	if opt.MaxCallDepth <= 0 {
+		opt.MaxCallDepth = DefaultMaxCallDepth
+	}
+	if opt.CacheDir != "" {
+		if err := os.MkdirAll(opt.CacheDir, 0o755); err != nil {
+			return nil, fmt.Errorf("qjs: failed to create cache directory: %w", err)
+		}
+	}
+	r := &Runtime{opt: opt, in: newInterp(opt.Console, opt.MaxCallDepth, opt.MaxSteps)}
+	r.ctx = &Context{rt: r}
+	return r, nil
+}
+
+// Context returns the execution context of the runtime.
+func (r *Runtime) Context() *Context {
+	return r.ctx
+}
+
+// Close releases the runtime. Values of a closed runtime must not be used.
+func (r *Runtime) Close() {
+	r.closed = true
+}
+
+// Context evaluates scripts and creates values.
+type Context struct {
+	rt *Runtime
+}
+
+// Runtime returns the runtime of the context.
+func (c *Context) Runtime() *Runtime {
+	return c.rt
+}
+
+type evalOptions struct {
+	code     *string
+	bytecode []byte
+	module   bool
+}
+
+// EvalOption configures Compile and Eval.
+type EvalOption func(o *evalOptions)
+
+// Code sets the source code to evaluate.
+func Code(src string) EvalOption {
+	return func(o *evalOptions) {
+		o.code = &src
+	}
+}
+
+// Bytecode sets the compiled script to evaluate, as returned by Compile.
+func Bytecode(b []byte) EvalOption {
+	return func(o *evalOptions) {
+		o.bytecode = b
+	}
+}
+
+// TypeModule evaluates the script as module.
+func TypeModule() EvalOption {
+	return func(o *evalOptions) {
+		o.module = true
+	}
+}
+
+// TypeGlobal evaluates the script as global script. This is the default.
+func TypeGlobal() EvalOption {
+	return func(o *evalOptions) {
+		o.module = false
+	}
+}
+
+// Compile parses a script into bytecode, the gob-encoded syntax tree, which
+// can be evaluated by any runtime using the Bytecode option.
+func (c *Context) Compile(name string, opts ...EvalOption) ([]byte, error) {
+	var o evalOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.bytecode != nil {
+		return o.bytecode, nil
+	}
+	if o.code == nil {
+		return nil, errors.New("qjs: no code to compile")
+	}
+	cache := c.cachePath(name, *o.code, o.module)
+	if cache != "" {
+		if b, err := os.ReadFile(cache); err == nil {
+			if _, err := decodeProgram(b); err == nil {
+				return b, nil
+			}
+		}
+	}
+	prog, err := parse(name, *o.code, o.module)
+	if err != nil {
+		return nil, err
+	}
+	b, err := encodeProgram(prog)
+	if err != nil {
+		return nil, err
+	}
+	if cache != "" {
+		_ = os.WriteFile(cache, b, 0o644)
+	}
+	return b, nil
+}
+
+func (c *Context) cachePath(name, src string, module bool) string {
+	if c.rt.opt.CacheDir == "" {
+		return ""
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%t\x00", bytecodeMagic, name, module)
+	io.WriteString(h, src)
+	return filepath.Join(c.rt.opt.CacheDir, "qjs-"+hex.EncodeToString(h.Sum(nil))+".bin")
+}
+
+func encodeProgram(prog *Program) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString(bytecodeMagic)
+	if err := gob.NewEncoder(&b).Encode(prog); err != nil {
+		return nil, fmt.Errorf("qjs: failed to encode bytecode: %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+func decodeProgram(b []byte) (*Program, error) {
+	if !bytes.HasPrefix(b, []byte(bytecodeMagic)) {
+		return nil, errors.New("qjs: invalid bytecode")
+	}
+	var prog Program
+	if err := gob.NewDecoder(bytes.NewReader(b[len(bytecodeMagic):])).Decode(&prog); err != nil {
+		return nil, fmt.Errorf("qjs: invalid bytecode: %w", err)
+	}
+	if err := prog.check(); err != nil {
+		return nil, fmt.Errorf("qjs: invalid bytecode: %w", err)
+	}
+	return &prog, nil
+}
+
+// Eval evaluates a script given as source code or bytecode. Global scripts
+// return the value of their last expression statement; modules return their
+// default export or, if there is none, an object holding their exports.
+func (c *Context) Eval(name string, opts ...EvalOption) (*Value, error) {
+	if c.rt.closed {
+		return nil, errors.New("qjs: runtime is closed")
+	}
+	var o evalOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	var prog *Program
+	var err error
+	switch {
+	case o.bytecode != nil:
+		prog, err = decodeProgram(o.bytecode)
+	case o.code != nil && c.rt.opt.CacheDir != "":
+		var b []byte
+		if b, err = c.Compile(name, opts...); err == nil {
+			prog, err = decodeProgram(b)
+		}
+	case o.code != nil:
+		prog, err = parse(name, *o.code, o.module)
+	default:
+		err = errors.New("qjs: no code to evaluate")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if prog.Module != o.module {
+		return nil, fmt.Errorf("qjs: bytecode of %s was not compiled as %s", name, scriptType(o.module))
+	}
+	v, err := c.rt.in.run(prog)
+	if err != nil {
+		return nil, c.exception(err)
+	}
+	return c.value(v), nil
+}
+
+func scriptType(module bool) string {
+	if module {
+		return "module"
+	}
+	return "global script"
+}
+
+// Error is a JavaScript exception that was not caught by the script.
+type Error struct {
+	Name    string
+	Message string
+	// Value is the thrown value.
+	Value *Value
+}
+
+func (e *Error) Error() string {
+	if e.Name == "" {
+		return "uncaught " + e.Message
+	}
+	if e.Message == "" {
+		return e.Name
+	}
+	return e.Name + ": " + e.Message
+}
+
+// exception converts an error returned by the interpreter.
+func (c *Context) exception(err error) error {
+	var t *throw
+	if !errors.As(err, &t) {
+		return err
+	}
+	e := &Error{Value: c.value(t.value)}
+	if o, ok := t.value.(*object); ok && o.class == classError {
+		name, _ := c.rt.in.getFrom(o, "name")
+		msg, _ := c.rt.in.getFrom(o, "message")
+		e.Name, e.Message = stringOf(name), stringOf(msg)
+		return e
+	}
+	s, serr := c.rt.in.toString(t.value)
+	if serr != nil {
+		s = typeOf(t.value)
+	}
+	e.Message = s
+	return e
+}
@@ -0,0 +1,176 @@
+package qjs
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func eval(t *testing.T, rt *Runtime, src string) *Value {
+	t.Helper()
+	v, err := rt.Context().Eval("test.js", Code(src))
+	if err != nil {
+		t.Fatalf("failed to evaluate %q: %s", src, err)
+	}
+	return v
+}
+
+func TestErrors(t *testing.T) {
+	t.Parallel()
+
+	rt, _ := New(Option{MaxSteps: 1000, MaxCallDepth: 32})
+	ctx := rt.Context()
+
+	_, err := ctx.Eval("syntax.js", Code("let x = ;"))
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) || syntaxErr.Line != 1 || syntaxErr.Column != 9 {
+		t.Errorf("failed to report syntax error: got %v", err)
+	}
+
+	_, err = ctx.Eval("throw.js", Code(`throw new TypeError("bad")`))
+	var jsErr *Error
+	if !errors.As(err, &jsErr) || jsErr.Name != "TypeError" || jsErr.Message != "bad" {
+		t.Errorf("failed to report exception: got %v", err)
+	}
+
+	_, err = ctx.Eval("loop.js", Code(`try { while (true) {} } catch (e) {}`))
+	if !errors.Is(err, ErrInterrupted) {
+		t.Errorf("failed to interrupt endless loop: got %v", err)
+	}
+
+	_, err = ctx.Eval("recursion.js", Code(`function f() { return f() } f()`))
+	if !errors.As(err, &jsErr) || jsErr.Name != "RangeError" {
+		t.Errorf("failed to limit recursion: got %v", err)
+	}
+
+	_, err = ctx.Eval("const.js", Code(`const c = 1; c = 2`))
+	if !errors.As(err, &jsErr) || jsErr.Name != "TypeError" {
+		t.Errorf("failed to protect constant: got %v", err)
+	}
+
+	for _, src := range []string{`import x from "y"`, `Function("return 1")()`, `export default 1`} {
+		if _, err := ctx.Eval("denied.js", Code(src)); err == nil {
+			t.Errorf("expected %q to fail", src)
+		}
+	}
+}
+
+func TestModule(t *testing.T) {
+	t.Parallel()
+
+	rt, _ := New(Option{CacheDir: t.TempDir()})
+	defer rt.Close()
+	ctx := rt.Context()
+
+	const src = `
+		const about = () => "about";
+		export function add(a, b) { return a + b }
+		export default { about, add };
+	`
+	bytecode, err := ctx.Compile("module.js", Code(src), TypeModule())
+	if err != nil {
+		t.Fatalf("failed to compile module: %s", err)
+	}
+	cached, err := ctx.Compile("module.js", Code(src), TypeModule())
+	if err != nil || !bytes.Equal(bytecode, cached) {
+		t.Errorf("failed to compile module from cache: %v", err)
+	}
+	if _, err := ctx.Eval("module.js", Bytecode(bytecode)); err == nil {
+		t.Errorf("expected module bytecode to be rejected as global script")
+	}
+
+	exports, err := ctx.Eval("module.js", Bytecode(bytecode), TypeModule())
+	if err != nil {
+		t.Fatalf("failed to evaluate module: %s", err)
+	}
+	names, err := exports.GetOwnPropertyNames()
+	if err != nil || strings.Join(names, ",") != "about,add" {
+		t.Errorf("incorrect exports: got %v (%v)", names, err)
+	}
+	result, err := exports.InvokeJS("add", ctx.NewFloat64(2), ctx.NewFloat64(3))
+	if err != nil || result.Int() != 5 {
+		t.Errorf("failed to invoke export: got %v (%v)", result, err)
+	}
+
+	named, err := ctx.Eval("named.js", Code(`export const x = 1; export let y = x + 1;`), TypeModule())
+	if err != nil {
+		t.Fatalf("failed to evaluate module: %s", err)
+	}
+	if s, _ := named.JSONStringify(); s != `{"x":1,"y":2}` {
+		t.Errorf("incorrect named exports: got %s", s)
+	}
+}
+
+func TestHostFunctions(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	rt, _ := New(Option{Console: &out})
+	ctx := rt.Context()
+	global := ctx.Global()
+
+	global.SetPropertyStr("greet", ctx.NewFunction("greet", func(this *Value, args []*Value) (*Value, error) {
+		if len(args) == 0 {
+			return nil, errors.New("missing name")
+		}
+		return ctx.NewString("hello " + args[0].String()), nil
+	}))
+	counter := 0
+	host := ctx.NewObject()
+	host.DefineProperty("count", func() (*Value, error) {
+		return ctx.NewInt64(int64(counter)), nil
+	}, func(v *Value) error {
+		counter = v.Int()
+		return nil
+	})
+	global.SetPropertyStr("host", host)
+
+	if got := eval(t, rt, `host.count = 41; host.count++; greet("world")`).String(); got != "hello world" {
+		t.Errorf("failed to call host function: got %s", got)
+	}
+	if counter != 42 {
+		t.Errorf("failed to set host property: expected 42, got %d", counter)
+	}
+	if got := eval(t, rt, `try { greet() } catch (e) { e.message }`).String(); got != "missing name" {
+		t.Errorf("failed to throw host error: got %s", got)
+	}
+
+	eval(t, rt, `console.log("x", 1, [1, "a"], {a: {b: 2}}, greet)`)
+	if got := out.String(); got != "x 1 [ 1, \"a\" ] { a: { b: 2 } } [Function: greet]\n" {
+		t.Errorf("incorrect console output: got %q", got)
+	}
+
+	eval(t, rt, `var shared = 1; function double(x) { return x * 2 }`)
+	fn := global.GetPropertyStr("double")
+	if r, err := fn.Call(global.GetPropertyStr("shared")); err != nil || r.Int() != 2 {
+		t.Errorf("failed to call script function: got %v (%v)", r, err)
+	}
+}
+
+func TestPool(t *testing.T) {
+	t.Parallel()
+
+	setups := 0
+	pool := NewPool(2, Option{}, func(r *Runtime) error {
+		setups++
+		_, err := r.Context().Eval("setup.js", Code(`var handlers = {about() { return "about" }}`))
+		return err
+	})
+	defer pool.Close()
+
+	r1, err := pool.Get()
+	if err != nil {
+		t.Fatalf("failed to get runtime: %s", err)
+	}
+	result, err := r1.Context().Global().GetPropertyStr("handlers").InvokeJS("about")
+	if err != nil || result.String() != "about" {
+		t.Errorf("failed to invoke handler: got %v (%v)", result, err)
+	}
+	pool.Put(r1)
+	r2, _ := pool.Get()
+	if r1 != r2 || setups != 1 {
+		t.Errorf("expected runtime to be reused")
+	}
+	pool.Put(r2)
+}
@@ -0,0 +1,375 @@
+package qjs
+
+import (
+	"errors"
+	"math"
+	"strconv"
+)
+
+// Value is a JavaScript value owned by a context.
+type Value struct {
+	ctx *Context
+	v   interface{}
+}
+
+// Function is a Go function callable from scripts. Returning an error throws
+// it as JavaScript exception; a nil value returns undefined.
+type Function func(this *Value, args []*Value) (*Value, error)
+
+func (c *Context) value(v interface{}) *Value {
+	return &Value{ctx: c, v: v}
+}
+
+// raw returns the interpreter value, treating nil as undefined.
+func (v *Value) raw() interface{} {
+	if v == nil {
+		return undefined
+	}
+	return v.v
+}
+
+func (c *Context) values(vs []interface{}) []*Value {
+	out := make([]*Value, len(vs))
+	for i, v := range vs {
+		out[i] = c.value(v)
+	}
+	return out
+}
+
+// Global returns the global object.
+func (c *Context) Global() *Value {
+	return c.value(c.rt.in.global)
+}
+
+// NewUndefined returns undefined.
+func (c *Context) NewUndefined() *Value {
+	return c.value(undefined)
+}
+
+// NewNull returns null.
+func (c *Context) NewNull() *Value {
+	return c.value(nil)
+}
+
+// NewBool returns a boolean.
+func (c *Context) NewBool(b bool) *Value {
+	return c.value(b)
+}
+
+// NewFloat64 returns a number.
+func (c *Context) NewFloat64(f float64) *Value {
+	return c.value(f)
+}
+
+// NewInt64 returns a number.
+func (c *Context) NewInt64(i int64) *Value {
+	return c.value(float64(i))
+}
+
+// NewString returns a string.
+func (c *Context) NewString(s string) *Value {
+	return c.value(s)
+}
+
+// NewObject returns an empty object.
+func (c *Context) NewObject() *Value {
+	return c.value(c.rt.in.newObject())
+}
+
+// NewArray returns an array holding the given values.
+func (c *Context) NewArray(items ...*Value) *Value {
+	elems := make([]interface{}, len(items))
+	for i, item := range items {
+		elems[i] = item.raw()
+	}
+	return c.value(c.rt.in.newArray(elems))
+}
+
+// NewError returns an Error object with the message of err.
+func (c *Context) NewError(err error) *Value {
+	return c.value(c.rt.in.newError("Error", err.Error()))
+}
+
+// NewFunction returns a function calling fn.
+func (c *Context) NewFunction(name string, fn Function) *Value {
+	in := c.rt.in
+	return c.value(in.newNative(name, 0, func(this interface{}, args []interface{}) (interface{}, error) {
+		r, err := fn(c.value(this), c.values(args))
+		if err != nil {
+			var e *Error
+			if errors.As(err, &e) && e.Value != nil {
+				return nil, &throw{value: e.Value.v}
+			}
+			return nil, err
+		}
+		return r.raw(), nil
+	}))
+}
+
+// ParseJSON decodes JSON text into a value.
+func (c *Context) ParseJSON(s string) (*Value, error) {
+	v, err := c.rt.in.parseJSON(s)
+	if err != nil {
+		return nil, c.exception(err)
+	}
+	return c.value(v), nil
+}
+
+// Context returns the context owning the value.
+func (v *Value) Context() *Context {
+	return v.ctx
+}
+
+// Free releases the value. Values are garbage collected, Free exists for
+// symmetry with the QuickJS API and does nothing.
+func (v *Value) Free() {}
+
+// IsUndefined reports whether the value is undefined.
+func (v *Value) IsUndefined() bool {
+	_, ok := v.raw().(undefinedType)
+	return ok
+}
+
+// IsNull reports whether the value is null.
+func (v *Value) IsNull() bool {
+	return v != nil && v.v == nil
+}
+
+// IsBool reports whether the value is a boolean.
+func (v *Value) IsBool() bool {
+	_, ok := v.raw().(bool)
+	return ok
+}
+
+// IsNumber reports whether the value is a number.
+func (v *Value) IsNumber() bool {
+	_, ok := v.raw().(float64)
+	return ok
+}
+
+// IsString reports whether the value is a string.
+func (v *Value) IsString() bool {
+	_, ok := v.raw().(string)
+	return ok
+}
+
+// IsObject reports whether the value is an object, including arrays and
+// functions.
+func (v *Value) IsObject() bool {
+	_, ok := v.raw().(*object)
+	return ok
+}
+
+// IsArray reports whether the value is an array.
+func (v *Value) IsArray() bool {
+	o, ok := v.raw().(*object)
+	return ok && o.class == classArray
+}
+
+// IsFunction reports whether the value is callable.
+func (v *Value) IsFunction() bool {
+	o, ok := v.raw().(*object)
+	return ok && o.callable()
+}
+
+// IsError reports whether the value is an Error object.
+func (v *Value) IsError() bool {
+	o, ok := v.raw().(*object)
+	return ok && o.class == classError
+}
+
+// String converts the value to a string.
+func (v *Value) String() string {
+	if v == nil {
+		return "undefined"
+	}
+	s, err := v.ctx.rt.in.toString(v.v)
+	if err != nil {
+		return "[object]"
+	}
+	return s
+}
+
+// Bool converts the value to a boolean.
+func (v *Value) Bool() bool {
+	return toBoolean(v.raw())
+}
+
+// Float64 converts the value to a number.
+func (v *Value) Float64() float64 {
+	if v == nil {
+		return math.NaN()
+	}
+	f, err := v.ctx.rt.in.toNumber(v.v)
+	if err != nil {
+		return math.NaN()
+	}
+	return f
+}
+
+// Int64 converts the value to an integer.
+func (v *Value) Int64() int64 {
+	f := v.Float64()
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0
+	}
+	return int64(f)
+}
+
+// Int converts the value to an integer.
+func (v *Value) Int() int {
+	return int(v.Int64())
+}
+
+// Len returns the length of an array or string.
+func (v *Value) Len() int {
+	switch x := v.raw().(type) {
+	case string:
+		return len([]rune(x))
+	case *object:
+		if x.class == classArray {
+			return len(x.array)
+		}
+	}
+	return 0
+}
+
+// GetPropertyStr returns a property of the value. Properties of null and
+// undefined and failing getters yield undefined.
+func (v *Value) GetPropertyStr(name string) *Value {
+	r, err := v.ctx.rt.in.get(v.raw(), name)
+	if err != nil {
+		return v.ctx.NewUndefined()
+	}
+	return v.ctx.value(r)
+}
+
+// GetPropertyIndex returns an element of the value.
+func (v *Value) GetPropertyIndex(i int) *Value {
+	return v.GetPropertyStr(strconv.Itoa(i))
+}
+
+// SetPropertyStr sets a property of the value.
+func (v *Value) SetPropertyStr(name string, value *Value) error {
+	return v.ctx.exception(v.ctx.rt.in.set(v.raw(), name, value.raw()))
+}
+
+// SetPropertyIndex sets an element of the value.
+func (v *Value) SetPropertyIndex(i int, value *Value) error {
+	return v.SetPropertyStr(strconv.Itoa(i), value)
+}
+
+// DeletePropertyStr deletes an own property of the value.
+func (v *Value) DeletePropertyStr(name string) {
+	if o, ok := v.raw().(*object); ok {
+		o.remove(name)
+	}
+}
+
+// HasProperty reports whether the value or its prototypes have a property.
+func (v *Value) HasProperty(name string) bool {
+	o, ok := v.raw().(*object)
+	return ok && o.has(name)
+}
+
+// DefineProperty defines an accessor property backed by Go functions. Either
+// function may be nil, a property without setter ignores assignments and one
+// without getter reads as undefined. Accessor properties are enumerable.
+func (v *Value) DefineProperty(name string, get func() (*Value, error), set func(*Value) error) error {
+	o, ok := v.raw().(*object)
+	if !ok {
+		return errors.New("qjs: cannot define property on primitive value")
+	}
+	p := &property{
+		get: func() (interface{}, error) {
+			if get == nil {
+				return undefined, nil
+			}
+			r, err := get()
+			if err != nil {
+				return nil, err
+			}
+			return r.raw(), nil
+		},
+		set: func(x interface{}) error {
+			if set == nil {
+				return nil
+			}
+			return set(v.ctx.value(x))
+		},
+	}
+	o.remove(name)
+	o.put(name, undefined)
+	o.props[name] = p
+	return nil
+}
+
+// GetOwnPropertyNames returns the enumerable own property keys of an object.
+func (v *Value) GetOwnPropertyNames() ([]string, error) {
+	o, ok := v.raw().(*object)
+	if !ok {
+		return nil, errors.New("qjs: value is not an object")
+	}
+	return o.ownKeys(), nil
+}
+
+// ForEach calls fn for every enumerable own property of an object.
+func (v *Value) ForEach(fn func(key *Value, value *Value)) {
+	o, ok := v.raw().(*object)
+	if !ok {
+		return
+	}
+	for _, k := range o.ownKeys() {
+		pv, err := v.ctx.rt.in.getFrom(o, k)
+		if err != nil {
+			continue
+		}
+		fn(v.ctx.value(k), v.ctx.value(pv))
+	}
+}
+
+// Call calls the value as function with undefined as this.
+func (v *Value) Call(args ...*Value) (*Value, error) {
+	return v.CallThis(v.ctx.NewUndefined(), args...)
+}
+
+// CallThis calls the value as function with the given this.
+func (v *Value) CallThis(this *Value, args ...*Value) (*Value, error) {
+	in := v.ctx.rt.in
+	raw := make([]interface{}, len(args))
+	for i, a := range args {
+		raw[i] = a.raw()
+	}
+	in.enter()
+	r, err := in.call(v.raw(), this.raw(), raw)
+	if err != nil {
+		return nil, v.ctx.exception(err)
+	}
+	return v.ctx.value(r), nil
+}
+
+// InvokeJS calls the method of the value with the given name.
+func (v *Value) InvokeJS(name string, args ...*Value) (*Value, error) {
+	fn := v.GetPropertyStr(name)
+	if !fn.IsFunction() {
+		return nil, &Error{Name: "TypeError", Message: name + " is not a function"}
+	}
+	return fn.CallThis(v, args...)
+}
+
+// JSONStringify serializes the value to JSON.
+func (v *Value) JSONStringify() (string, error) {
+	s, ok, err := v.ctx.rt.in.stringify(v.raw(), "", "", nil)
+	if err != nil {
+		return "", v.ctx.exception(err)
+	}
+	if !ok {
+		return "undefined", nil
+	}
+	return s, nil
+}
+
+// StrictEquals reports whether two values are strictly equal.
+func (v *Value) StrictEquals(other *Value) bool {
+	return strictEquals(v.raw(), other.raw())
+}
@@ -0,0 +1,522 @@
+package markup
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/malivvan/cui"
+	"github.com/malivvan/cui/markup/atom"
+	"github.com/malivvan/cui/markup/qjs"
+)
+
+// DefaultMaxSteps is the number of steps a script or an event handler may
+// execute when DocumentOptionRuntime does not set a limit. Scripts run in the
+// event loop, so a runaway script must not freeze the application.
+const DefaultMaxSteps = 1_000_000
+
+// DocumentOption configures how a document is bound.
+type DocumentOption func(d *Document)
+
+// DocumentOptionScripting enables or disables running <script> elements and
+// on* event attributes. Scripting is enabled by default.
+func DocumentOptionScripting(enable bool) DocumentOption {
+	return func(d *Document) {
+		d.scripting = enable
+	}
+}
+
+// DocumentOptionRuntime sets the options of the script runtime, e.g. where
+// console output goes and how many steps a handler may take. Limits which are
+// not set default to DefaultMaxSteps and qjs.DefaultMaxCallDepth.
+func DocumentOptionRuntime(opt qjs.Option) DocumentOption {
+	return func(d *Document) {
+		d.runtimeOption = opt
+	}
+}
+
+// DocumentOptionErrorFunc sets a handler which is called with errors thrown
+// by event handlers. Such errors are discarded by default.
+func DocumentOptionErrorFunc(handler func(err error)) DocumentOption {
+	return func(d *Document) {
+		d.errorFunc = handler
+	}
+}
+
+// events lists the event types each widget type dispatches.
+var events = map[string][]string{
+	"button":   {"click"},
+	"checkbox": {"change"},
+	"dropdown": {"change", "select"},
+	"input":    {"change", "done"},
+	"list":     {"change", "select"},
+	"table":    {"change", "select"},
+	"tree":     {"change", "select"},
+}
+
+// Runtime returns the script runtime of the document, e.g. to register host
+// functions, or nil if the document has neither scripts nor event handlers
+// or scripting is disabled.
+func (d *Document) Runtime() *qjs.Runtime {
+	return d.runtime
+}
+
+// runScripts compiles the event handlers of all elements and evaluates the
+// <script> elements in document order. Every script is a module; its default
+// export, if it is an object, and its named exports become global so that
+// event handlers and later scripts can refer to them.
+func (d *Document) runScripts() error {
+	var scripts []*Node
+	for n := range d.node.Descendants() {
+		if n.Type == ElementNode && n.Atom == atom.Script {
+			scripts = append(scripts, n)
+		}
+	}
+	handlers := false
+	for n := range d.byNode {
+		if slices.ContainsFunc(n.Attrs, isEventAttr) {
+			handlers = true
+			break
+		}
+	}
+	if !d.scripting || len(scripts) == 0 && !handlers {
+		return nil
+	}
+
+	opt := d.runtimeOption
+	if opt.MaxSteps == 0 {
+		opt.MaxSteps = DefaultMaxSteps
+	}
+	if opt.MaxCallDepth <= 0 {
+		opt.MaxCallDepth = qjs.DefaultMaxCallDepth
+	}
+	rt, err := qjs.New(opt)
+	if err != nil {
+		return err
+	}
+	d.runtime = rt
+	d.objects = make(map[*Element]*qjs.Value)
+	ctx := rt.Context()
+	global := ctx.Global()
+	if err := global.SetPropertyStr("document", d.documentObject()); err != nil {
+		return err
+	}
+
+	var wire func(e *Element) error
+	wire = func(e *Element) error {
+		if err := d.wire(e); err != nil {
+			return err
+		}
+		for _, c := range e.children {
+			if err := wire(c); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, e := range d.roots {
+		if err := wire(e); err != nil {
+			return fmt.Errorf("%s: %w", d.name, err)
+		}
+	}
+
+	for i, n := range scripts {
+		if n.GetAttr("src", "") != "" {
+			return fmt.Errorf("%s: external scripts are not supported", d.name)
+		}
+		exports, err := ctx.Eval(fmt.Sprintf("%s:script[%d]", d.name, i), qjs.Code(textContent(n)), qjs.TypeModule())
+		if err != nil {
+			return fmt.Errorf("%s: %w", d.name, err)
+		}
+		exports.ForEach(func(key, value *qjs.Value) {
+			global.SetPropertyStr(key.String(), value)
+		})
+	}
+	return d.ApplyStyles()
+}
+
+func isEventAttr(a Attribute) bool {
+	return strings.HasPrefix(a.Key, "on")
+}
+
+// wire compiles the event attributes of an element and installs the widget
+// callbacks that dispatch its events.
+func (d *Document) wire(e *Element) error {
+	supported := events[e.Tag()]
+	for _, a := range e.node.Attrs {
+		if !isEventAttr(a) {
+			continue
+		}
+		typ := strings.TrimPrefix(a.Key, "on")
+		if !slices.Contains(supported, typ) {
+			return fmt.Errorf("<%s>: unsupported event attribute %s", e.Tag(), a.Key)
+		}
+		code, err := d.runtime.Context().Compile(e.handlerName(typ), qjs.Code(a.Val))
+		if err != nil {
+			return err
+		}
+		if e.handlers == nil {
+			e.handlers = make(map[string][]byte)
+		}
+		e.handlers[typ] = code
+	}
+
+	emit := func(typ string, fields ...interface{}) {
+		d.dispatch(e, typ, fields...)
+	}
+	switch w := e.widget.(type) {
+	case *cui.Button:
+		w.OnClick(func() { emit("click") })
+	case *cui.CheckBox:
+		w.SetChangedFunc(func(checked bool) { emit("change", "value", checked) })
+	case *cui.DropDown:
+		option := func(typ string) func(int, *cui.DropDownOption) {
+			return func(index int, option *cui.DropDownOption) {
+				text := ""
+				if option != nil {
+					text = option.GetText()
+				}
+				emit(typ, "index", index, "value", text)
+			}
+		}
+		w.SetChangedFunc(option("change"))
+		w.SetSelectedFunc(option("select"))
+	case *cui.Input:
+		w.SetChangedFunc(func(text string) { emit("change", "value", text) })
+		w.SetDoneFunc(func(key tcell.Key) { emit("done", "key", tcell.KeyNames[key]) })
+	case *cui.List:
+		item := func(typ string) func(int, *cui.ListItem) {
+			return func(index int, item *cui.ListItem) {
+				text := ""
+				if item != nil {
+					text = item.GetMainText()
+				}
+				emit(typ, "index", index, "value", text)
+			}
+		}
+		w.SetChangedFunc(item("change"))
+		w.SetSelectedFunc(item("select"))
+	case *cui.Table:
+		cell := func(typ string) func(row, column int) {
+			return func(row, column int) {
+				text := ""
+				if c := w.GetCell(row, column); c != nil {
+					text = c.GetText()
+				}
+				emit(typ, "row", row, "column", column, "value", text)
+			}
+		}
+		w.SetSelectionChangedFunc(cell("change"))
+		w.SetSelectedFunc(cell("select"))
+	case *cui.Tree:
+		node := func(typ string) func(*cui.TreeNode) {
+			return func(node *cui.TreeNode) {
+				text := ""
+				if node != nil {
+					text = node.GetText()
+				}
+				emit(typ, "value", text)
+			}
+		}
+		w.SetChangedFunc(node("change"))
+		w.SetSelectedFunc(node("select"))
+	}
+	return nil
+}
+
+func (e *Element) handlerName(typ string) string {
+	target := e.Tag()
+	if id := e.ID(); id != "" {
+		target += "#" + id
+	}
+	return fmt.Sprintf("%s:%s.on%s", e.doc.name, target, typ)
+}
+
+// dispatch runs the handlers of an event. The fields are key value pairs
+// copied to the event object. The event attribute is evaluated first; if it
+// yields a function, e.g. onclick="handleClick", that function is called.
+func (d *Document) dispatch(e *Element, typ string, fields ...interface{}) {
+	code, ok := e.handlers[typ]
+	listeners := e.listeners[typ]
+	if !ok && len(listeners) == 0 {
+		return
+	}
+	ctx := d.runtime.Context()
+	target := d.object(e)
+	event := ctx.NewObject()
+	event.SetPropertyStr("type", ctx.NewString(typ))
+	event.SetPropertyStr("target", target)
+	for i := 0; i+1 < len(fields); i += 2 {
+		event.SetPropertyStr(fields[i].(string), toValue(ctx, fields[i+1]))
+	}
+
+	global := ctx.Global()
+	global.SetPropertyStr("event", event)
+	defer global.DeletePropertyStr("event")
+	if ok {
+		v, err := ctx.Eval(e.handlerName(typ), qjs.Bytecode(code))
+		if err == nil && v.IsFunction() {
+			_, err = v.CallThis(target, event)
+		}
+		d.scriptError(err)
+	}
+	for _, l := range listeners {
+		_, err := l.CallThis(target, event)
+		d.scriptError(err)
+	}
+	d.scriptError(d.ApplyStyles())
+}
+
+func (d *Document) scriptError(err error) {
+	if err != nil && d.errorFunc != nil {
+		d.errorFunc(err)
+	}
+}
+
+func toValue(ctx *qjs.Context, v interface{}) *qjs.Value {
+	switch x := v.(type) {
+	case *qjs.Value:
+		return x
+	case string:
+		return ctx.NewString(x)
+	case int:
+		return ctx.NewInt64(int64(x))
+	case bool:
+		return ctx.NewBool(x)
+	case nil:
+		return ctx.NewNull()
+	}
+	return ctx.NewUndefined()
+}
+
+// documentObject creates the document global of the scripts.
+func (d *Document) documentObject() *qjs.Value {
+	ctx := d.runtime.Context()
+	doc := ctx.NewObject()
+	doc.SetPropertyStr("getElementById", ctx.NewFunction("getElementById", func(_ *qjs.Value, args []*qjs.Value) (*qjs.Value, error) {
+		if len(args) == 0 {
+			return ctx.NewNull(), nil
+		}
+		if e := d.Element(args[0].String()); e != nil {
+			return d.object(e), nil
+		}
+		return ctx.NewNull(), nil
+	}))
+	doc.SetPropertyStr("querySelector", ctx.NewFunction("querySelector", func(_ *qjs.Value, args []*qjs.Value) (*qjs.Value, error) {
+		elements, err := d.query(args)
+		if err != nil || len(elements) == 0 {
+			return ctx.NewNull(), err
+		}
+		return d.object(elements[0]), nil
+	}))
+	doc.SetPropertyStr("querySelectorAll", ctx.NewFunction("querySelectorAll", func(_ *qjs.Value, args []*qjs.Value) (*qjs.Value, error) {
+		elements, err := d.query(args)
+		if err != nil {
+			return nil, err
+		}
+		items := make([]*qjs.Value, len(elements))
+		for i, e := range elements {
+			items[i] = d.object(e)
+		}
+		return ctx.NewArray(items...), nil
+	}))
+	return doc
+}
+
+func (d *Document) query(args []*qjs.Value) ([]*Element, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("missing selector")
+	}
+	selector := args[0].String()
+	if _, err := ParseSelector(selector); err != nil {
+		return nil, fmt.Errorf("invalid selector %q", selector)
+	}
+	return d.Query(selector), nil
+}
+
+// object returns the script object representing an element.
+func (d *Document) object(e *Element) *qjs.Value {
+	if o, ok := d.objects[e]; ok {
+		return o
+	}
+	ctx := d.runtime.Context()
+	o := ctx.NewObject()
+	d.objects[e] = o
+
+	str := func(name string, get func() string, set func(s string)) {
+		var setter func(v *qjs.Value) error
+		if set != nil {
+			setter = func(v *qjs.Value) error {
+				set(v.String())
+				return nil
+			}
+		}
+		o.DefineProperty(name, func() (*qjs.Value, error) { return ctx.NewString(get()), nil }, setter)
+	}
+	str("id", e.ID, nil)
+	str("tagName", e.Tag, nil)
+	str("className", func() string {
+		return e.node.GetAttr("class", "")
+	}, func(s string) {
+		e.setAttr("class", s)
+	})
+	str("text", e.text, e.setText)
+	o.DefineProperty("value", func() (*qjs.Value, error) {
+		return toValue(ctx, e.value()), nil
+	}, func(v *qjs.Value) error {
+		e.setValue(v)
+		return nil
+	})
+	o.DefineProperty("disabled", func() (*qjs.Value, error) {
+		return ctx.NewBool(e.IsDisabled()), nil
+	}, func(v *qjs.Value) error {
+		e.SetDisabled(v.Bool())
+		return nil
+	})
+	o.DefineProperty("hidden", func() (*qjs.Value, error) {
+		return ctx.NewBool(!e.widget.GetVisible()), nil
+	}, func(v *qjs.Value) error {
+		e.widget.SetVisible(!v.Bool())
+		return nil
+	})
+	o.DefineProperty("parentElement", func() (*qjs.Value, error) {
+		if e.parent == nil {
+			return ctx.NewNull(), nil
+		}
+		return d.object(e.parent), nil
+	}, nil)
+
+	method := func(name string, fn qjs.Function) {
+		o.SetPropertyStr(name, ctx.NewFunction(name, fn))
+	}
+	method("getAttribute", func(_ *qjs.Value, args []*qjs.Value) (*qjs.Value, error) {
+		if len(args) > 0 {
+			for _, a := range e.node.Attrs {
+				if a.Key == args[0].String() {
+					return ctx.NewString(a.Val), nil
+				}
+			}
+		}
+		return ctx.NewNull(), nil
+	})
+	method("setAttribute", func(_ *qjs.Value, args []*qjs.Value) (*qjs.Value, error) {
+		if len(args) < 2 {
+			return nil, fmt.Errorf("setAttribute requires 2 arguments")
+		}
+		e.setAttr(args[0].String(), args[1].String())
+		return nil, nil
+	})
+	method("removeAttribute", func(_ *qjs.Value, args []*qjs.Value) (*qjs.Value, error) {
+		if len(args) > 0 {
+			e.removeAttr(args[0].String())
+		}
+		return nil, nil
+	})
+	method("addEventListener", func(_ *qjs.Value, args []*qjs.Value) (*qjs.Value, error) {
+		if len(args) < 2 || !args[1].IsFunction() {
+			return nil, fmt.Errorf("addEventListener requires an event type and a function")
+		}
+		typ := args[0].String()
+		if !slices.Contains(events[e.Tag()], typ) {
+			return nil, fmt.Errorf("<%s> does not dispatch %s events", e.Tag(), typ)
+		}
+		if e.listeners == nil {
+			e.listeners = make(map[string][]*qjs.Value)
+		}
+		e.listeners[typ] = append(e.listeners[typ], args[1])
+		return nil, nil
+	})
+	method("removeEventListener", func(_ *qjs.Value, args []*qjs.Value) (*qjs.Value, error) {
+		if len(args) < 2 {
+			return nil, nil
+		}
+		typ := args[0].String()
+		e.listeners[typ] = slices.DeleteFunc(e.listeners[typ], args[1].StrictEquals)
+		return nil, nil
+	})
+	return o
+}
+
+func (e *Element) setAttr(key, val string) {
+	if key == "disabled" {
+		e.SetDisabled(true)
+	}
+	for i, a := range e.node.Attrs {
+		if a.Key == key {
+			e.node.Attrs[i].Val = val
+			return
+		}
+	}
+	e.node.Attrs = append(e.node.Attrs, Attribute{Key: key, Val: val})
+}
+
+func (e *Element) removeAttr(key string) {
+	if key == "disabled" {
+		e.SetDisabled(false)
+	}
+	e.node.Attrs = slices.DeleteFunc(e.node.Attrs, func(a Attribute) bool { return a.Key == key })
+}
+
+// text returns the text shown by the widget of the element.
+func (e *Element) text() string {
+	switch w := e.widget.(type) {
+	case *cui.Button:
+		return w.GetLabel()
+	case *cui.CheckBox:
+		return w.GetMessage()
+	case *cui.Input:
+		return w.GetText()
+	case *cui.Text:
+		return w.GetText(false)
+	}
+	return textContent(e.node)
+}
+
+func (e *Element) setText(text string) {
+	switch w := e.widget.(type) {
+	case *cui.Button:
+		w.SetLabel(text)
+	case *cui.CheckBox:
+		w.SetMessage(text)
+	case *cui.Input:
+		w.SetText(text)
+	case *cui.Text:
+		w.SetText(text)
+	}
+}
+
+// value returns the value of an input element: the text of inputs, the
+// checked state of checkboxes, the index of the current dropdown option or
+// list item and the progress of progress bars.
+func (e *Element) value() interface{} {
+	switch w := e.widget.(type) {
+	case *cui.Input:
+		return w.GetText()
+	case *cui.CheckBox:
+		return w.IsChecked()
+	case *cui.DropDown:
+		index, _ := w.GetCurrentOption()
+		return index
+	case *cui.List:
+		return w.GetCurrentItemIndex()
+	case *cui.Progress:
+		return w.GetProgress()
+	}
+	return nil
+}
+
+func (e *Element) setValue(v *qjs.Value) {
+	switch w := e.widget.(type) {
+	case *cui.Input:
+		w.SetText(v.String())
+	case *cui.CheckBox:
+		w.SetChecked(v.Bool())
+	case *cui.DropDown:
+		w.SetCurrentOption(v.Int())
+	case *cui.List:
+		w.SetCurrentItem(v.Int())
+	case *cui.Progress:
+		w.SetProgress(v.Int())
+	}
+}
@@ -0,0 +1,126 @@
+package markup
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/malivvan/cui"
+	"github.com/malivvan/cui/markup/qjs"
+)
+
+const testScriptDocument = `<style>
+	.done {color: green;}
+</style>
+<script>
+	let clicks = 0;
+	const count = () => {
+		clicks++;
+		const label = document.getElementById("label");
+		label.text = "clicked " + clicks;
+		if (clicks > 1) {
+			label.className = "done";
+			document.getElementById("b").disabled = true;
+		}
+	};
+	export default { count };
+</script>
+<flex direction="column">
+	<button id="b" onclick="count">click</button>
+	<text id="label">never</text>
+	<input id="name" onchange="document.querySelector('#greeting').text = 'hi ' + event.value"/>
+	<text id="greeting"></text>
+	<checkbox id="c" label="check"></checkbox>
+</flex>
+<script>
+	console.log("buttons:", document.querySelectorAll("button").length);
+	document.getElementById("c").addEventListener("change", (e) => console.log("checked", e.value, e.target.id));
+	export const ready = true;
+</script>`
+
+func TestScripts(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	var errs []error
+	doc, err := NewFile("script.cml", testScriptDocument,
+		DocumentOptionRuntime(qjs.Option{Console: &out}),
+		DocumentOptionErrorFunc(func(err error) { errs = append(errs, err) }))
+	if err != nil {
+		t.Fatalf("failed to bind document: %s", err)
+	}
+	if doc.Runtime() == nil || !doc.Runtime().Context().Global().GetPropertyStr("ready").Bool() {
+		t.Errorf("expected named export to become global")
+	}
+
+	enter := tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone)
+	click := func() { doc.Button("b").InputHandler()(enter, func(cui.Widget) {}) }
+	click()
+	if got := doc.Text("label").GetText(false); got != "clicked 1" {
+		t.Errorf("failed to handle click: expected clicked 1, got %s", got)
+	}
+	click()
+	if !doc.Element("b").IsDisabled() || doc.Element("label").Style("color") != "green" {
+		t.Errorf("expected handler changes to be styled")
+	}
+
+	doc.Input("name").SetText("joe")
+	if got := doc.Text("greeting").GetText(false); got != "hi joe" {
+		t.Errorf("failed to handle change: expected hi joe, got %s", got)
+	}
+
+	doc.CheckBox("c").InputHandler()(enter, func(cui.Widget) {})
+	if got := out.String(); got != "buttons: 1\nchecked true c\n" {
+		t.Errorf("incorrect console output: got %q", got)
+	}
+	if len(errs) != 0 {
+		t.Errorf("unexpected handler errors: %v", errs)
+	}
+}
+
+func TestScriptErrors(t *testing.T) {
+	t.Parallel()
+
+	for src, expected := range map[string]string{
+		`<button onclick="(">x</button>`:              "SyntaxError",
+		`<text onclick="x()">x</text>`:                "unsupported event attribute onclick",
+		`<script>throw new Error("boom")</script>`:    "Error: boom",
+		`<script src="x.js"></script><box></box>`:     "external scripts are not supported",
+		`<script>while (true) {}</script><box></box>`: "interrupted",
+	} {
+		_, err := NewFile("error.cml", src, DocumentOptionRuntime(qjs.Option{MaxSteps: 10000}))
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("failed to report error of %q: expected %s, got %v", src, expected, err)
+		}
+	}
+
+	var errs []error
+	doc, err := NewFile("error.cml", `<button id="b" onclick="missing()">x</button>`,
+		DocumentOptionErrorFunc(func(err error) { errs = append(errs, err) }))
+	if err != nil {
+		t.Fatalf("failed to bind document: %s", err)
+	}
+	doc.Button("b").InputHandler()(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone), func(cui.Widget) {})
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "missing is not defined") {
+		t.Errorf("failed to report handler error: got %v", errs)
+	}
+
+	// Runaway handlers are interrupted by the default step limit.
+	errs = nil
+	doc, err = NewFile("loop.cml", `<button id="b" onclick="while (true) {}">x</button>`,
+		DocumentOptionErrorFunc(func(err error) { errs = append(errs, err) }))
+	if err != nil {
+		t.Fatalf("failed to bind document: %s", err)
+	}
+	doc.Button("b").InputHandler()(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone), func(cui.Widget) {})
+	if len(errs) != 1 || !errors.Is(errs[0], qjs.ErrInterrupted) {
+		t.Errorf("failed to interrupt runaway handler: got %v", errs)
+	}
+
+	doc, err = NewFile("disabled.cml", `<script>throw 1</script><button onclick="x">x</button>`, DocumentOptionScripting(false))
+	if err != nil || doc.Runtime() != nil {
+		t.Errorf("expected scripts not to run: got %v", err)
+	}
+}
@@ -1173,7 +1173,9 @@ func (p *selectorParser) parsePseudoclassSelector() (out Sel, pseudoElement stri
 		out = disabledPseudoClassSelector{}
 	case "checked":
 		out = checkedPseudoClassSelector{}
-	case "visited", "hover", "active", "focus", "target":
+	case "focus":
+		out = focusPseudoClassSelector{}
+	case "visited", "hover", "active", "target":
 		// Not applicable in a static context: never match.
 		out = neverMatchSelector{value: ":" + name}
 	case "after", "backdrop", "before", "cue", "first-letter", "first-line", "grammar-error", "marker", "placeholder", "selection", "spelling-error":
@@ -140,6 +140,10 @@ func (c disabledPseudoClassSelector) String() string {
 	return ":disabled"
 }
 
+func (c focusPseudoClassSelector) String() string {
+	return ":focus"
+}
+
 func (c checkedPseudoClassSelector) String() string {
 	return ":checked"
 }
@@ -0,0 +1,516 @@
+package markup
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/malivvan/cui"
+	"github.com/malivvan/cui/internal/css"
+	"github.com/malivvan/cui/markup/atom"
+)
+
+// inherited lists the properties whose computed value is passed on from an
+// element to its children when they do not specify the property themselves.
+var inherited = map[string]bool{
+	"color":       true,
+	"title-color": true,
+}
+
+// styleRule is a single selector of a stylesheet rule together with its
+// declarations.
+type styleRule struct {
+	sel   Sel
+	order int
+	decls []*css.Declaration
+}
+
+// styleDecl is a declaration matching an element, ranked for the cascade.
+type styleDecl struct {
+	decl        *css.Declaration
+	inline      bool
+	specificity Specificity
+	order       int
+}
+
+// less reports whether s loses against o in the cascade.
+func (s styleDecl) less(o styleDecl) bool {
+	if s.decl.Important != o.decl.Important {
+		return o.decl.Important
+	}
+	if s.inline != o.inline {
+		return o.inline
+	}
+	if s.specificity != o.specificity {
+		return s.specificity.Less(o.specificity)
+	}
+	return s.order < o.order
+}
+
+// stylesheet collects the rules of all <style> elements in document order.
+// Selectors which cannot be parsed are skipped like a browser would.
+func (d *Document) stylesheet() ([]styleRule, error) {
+	var rules []styleRule
+	for n := range d.node.Descendants() {
+		if n.Type != ElementNode || n.Atom != atom.Style {
+			continue
+		}
+		sheet, err := css.Parse(textContent(n))
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range sheet {
+			if r.Kind != css.QualifiedRule {
+				continue
+			}
+			for _, s := range r.Selectors {
+				sel, err := ParseSelector(s)
+				if err != nil {
+					continue
+				}
+				rules = append(rules, styleRule{sel: sel, order: len(rules), decls: r.Declarations})
+			}
+		}
+	}
+	return rules, nil
+}
+
+// ApplyStyles resolves the cascade of the document stylesheets and inline
+// style attributes for every element and applies the result to the bound
+// widgets. The computed style of an element is stored in Node.Style.
+//
+// Styles have to be re-applied whenever the document tree, the disabled state
+// of an element or the focus changes, e.g. from App.SetAfterFocusFunc.
+func (d *Document) ApplyStyles() error {
+	rules, err := d.stylesheet()
+	if err != nil {
+		return fmt.Errorf("%s: %w", d.name, err)
+	}
+	for _, e := range d.roots {
+		e.updateState()
+	}
+	var walk func(e *Element, parent map[string]string) error
+	walk = func(e *Element, parent map[string]string) error {
+		style, err := e.cascade(rules, parent)
+		if err != nil {
+			return err
+		}
+		e.applyStyle(style)
+		for _, c := range e.children {
+			if err := walk(c, style); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, e := range d.roots {
+		if err := walk(e, nil); err != nil {
+			return fmt.Errorf("%s: %w", d.name, err)
+		}
+	}
+	return nil
+}
+
+// SetDisabled sets whether the element matches the :disabled pseudo-class.
+// Enabling an element also removes its disabled attribute. Call
+// Document.ApplyStyles afterwards to update the widget.
+func (e *Element) SetDisabled(disabled bool) *Element {
+	if disabled {
+		e.node.State |= StateDisabled
+		return e
+	}
+	e.node.State &^= StateDisabled
+	e.node.Attrs = slices.DeleteFunc(e.node.Attrs, func(a Attribute) bool { return a.Key == "disabled" })
+	return e
+}
+
+// IsDisabled returns whether the element matches the :disabled pseudo-class.
+func (e *Element) IsDisabled() bool {
+	return e.node.State&StateDisabled != 0
+}
+
+// Style returns the computed value of the given property or an empty string
+// if the property is not set.
+func (e *Element) Style(property string) string {
+	for _, p := range e.node.Style {
+		if p.Key == property {
+			return p.Val
+		}
+	}
+	return ""
+}
+
+// updateState updates the focus state of e and its descendants and reports
+// whether any of them has focus. Only the innermost focused element matches
+// :focus.
+func (e *Element) updateState() bool {
+	focusWithin := false
+	for _, c := range e.children {
+		if c.updateState() {
+			focusWithin = true
+		}
+	}
+	e.node.State &^= StateFocus
+	if !focusWithin && e.widget.HasFocus() {
+		e.node.State |= StateFocus
+		return true
+	}
+	return focusWithin
+}
+
+// cascade computes the style of e from the matching rules, its inline style
+// and the computed style of its parent.
+func (e *Element) cascade(rules []styleRule, parent map[string]string) (map[string]string, error) {
+	var matched []styleDecl
+	for _, r := range rules {
+		if !r.sel.Match(e.node) {
+			continue
+		}
+		for _, decl := range r.decls {
+			matched = append(matched, styleDecl{decl: decl, specificity: r.sel.Specificity(), order: r.order})
+		}
+	}
+	if inline := e.node.GetAttr("style", ""); inline != "" {
+		decls, err := css.ParseDeclarations(inline)
+		if err != nil {
+			return nil, fmt.Errorf("<%s>: invalid style attribute: %w", e.node.Tag, err)
+		}
+		for i, decl := range decls {
+			matched = append(matched, styleDecl{decl: decl, inline: true, order: i})
+		}
+	}
+	slices.SortStableFunc(matched, func(a, b styleDecl) int {
+		if a.less(b) {
+			return -1
+		}
+		if b.less(a) {
+			return 1
+		}
+		return 0
+	})
+
+	style := make(map[string]string)
+	for property, value := range parent {
+		if inherited[property] {
+			style[property] = value
+		}
+	}
+	for _, m := range matched {
+		property := strings.ToLower(m.decl.Property)
+		switch value := strings.TrimSpace(m.decl.Value); value {
+		case "inherit":
+			if v, ok := parent[property]; ok {
+				style[property] = v
+			} else {
+				delete(style, property)
+			}
+		case "initial":
+			delete(style, property)
+		default:
+			style[property] = value
+		}
+	}
+
+	e.node.Style = e.node.Style[:0]
+	for property, value := range style {
+		e.node.Style = append(e.node.Style, Property{Key: property, Val: value})
+	}
+	slices.SortFunc(e.node.Style, func(a, b Property) int { return strings.Compare(a.Key, b.Key) })
+	return style, nil
+}
+
+// styleTarget holds the setters of the styleable properties of a widget.
+// Setters which are nil are not supported by the widget.
+type styleTarget struct {
+	color, colorFocused           func(tcell.Color)
+	background, backgroundFocused func(tcell.Color)
+	borderColor                   func(tcell.Color)
+	borderColorFocused            func(tcell.Color)
+	titleColor                    func(tcell.Color)
+	border                        func(bool)
+	borderAttributes              func(tcell.AttrMask)
+	padding                       func(top, bottom, left, right int)
+}
+
+// styleValues holds the values of the styleable properties of a widget.
+type styleValues struct {
+	color, colorFocused           tcell.Color
+	background, backgroundFocused tcell.Color
+	borderColor                   tcell.Color
+	borderColorFocused            tcell.Color
+	titleColor                    tcell.Color
+	border                        bool
+	borderAttributes              tcell.AttrMask
+	padding                       [4]int
+}
+
+// styledBox is the part of the box API used to style every widget.
+type styledBox[T any] interface {
+	GetBackgroundColor() tcell.Color
+	SetBackgroundColor(color tcell.Color) T
+	GetBorder() bool
+	SetBorder(show bool) T
+	GetBorderColor() tcell.Color
+	SetBorderColor(color tcell.Color) T
+	GetBorderColorFocused() tcell.Color
+	SetBorderColorFocused(color tcell.Color) T
+	GetBorderAttributes() tcell.AttrMask
+	SetBorderAttributes(attr tcell.AttrMask) T
+	GetTitleColor() tcell.Color
+	SetTitleColor(color tcell.Color) T
+	GetPadding() (top, bottom, left, right int)
+	SetPadding(top, bottom, left, right int) T
+}
+
+// styleBox returns the style target and current values of the box properties
+// of w.
+func styleBox[T any](w styledBox[T]) (*styleTarget, styleValues) {
+	t := &styleTarget{
+		background:         func(c tcell.Color) { w.SetBackgroundColor(c) },
+		borderColor:        func(c tcell.Color) { w.SetBorderColor(c) },
+		borderColorFocused: func(c tcell.Color) { w.SetBorderColorFocused(c) },
+		titleColor:         func(c tcell.Color) { w.SetTitleColor(c) },
+		border:             func(b bool) { w.SetBorder(b) },
+		borderAttributes:   func(a tcell.AttrMask) { w.SetBorderAttributes(a) },
+		padding:            func(top, bottom, left, right int) { w.SetPadding(top, bottom, left, right) },
+	}
+	v := styleValues{
+		background:         w.GetBackgroundColor(),
+		borderColor:        w.GetBorderColor(),
+		borderColorFocused: w.GetBorderColorFocused(),
+		titleColor:         w.GetTitleColor(),
+		border:             w.GetBorder(),
+		borderAttributes:   w.GetBorderAttributes(),
+	}
+	v.padding[0], v.padding[1], v.padding[2], v.padding[3] = w.GetPadding()
+	return t, v
+}
+
+// newStyleTarget returns the style target of w and the values its properties
+// had before any style was applied. Colors without a getter are assumed to
+// still have the defaults the constructors use.
+func newStyleTarget(w cui.Widget) (t *styleTarget, v styleValues) {
+	switch w := w.(type) {
+	case *cui.Box:
+		t, v = styleBox[*cui.Box](w)
+	case *cui.Button:
+		t, v = styleBox[*cui.Button](w)
+		t.color = func(c tcell.Color) { w.SetLabelColor(c) }
+		t.colorFocused = func(c tcell.Color) { w.SetLabelColorFocused(c) }
+		t.backgroundFocused = func(c tcell.Color) { w.SetBackgroundColorFocused(c) }
+		v.color, v.colorFocused = cui.Styles.PrimaryTextColor, cui.Styles.PrimaryTextColor
+		v.backgroundFocused = cui.Styles.ContrastBackgroundColor
+	case *cui.CheckBox:
+		t, v = styleBox[*cui.CheckBox](w)
+		t.color = func(c tcell.Color) { w.SetLabelColor(c) }
+		t.colorFocused = func(c tcell.Color) { w.SetLabelColorFocused(c) }
+		v.color, v.colorFocused = cui.Styles.SecondaryTextColor, cui.ColorUnset
+	case *cui.DropDown:
+		t, v = styleBox[*cui.DropDown](w)
+		t.color = func(c tcell.Color) { w.SetLabelColor(c) }
+		t.colorFocused = func(c tcell.Color) { w.SetLabelColorFocused(c) }
+		v.color, v.colorFocused = cui.Styles.SecondaryTextColor, cui.ColorUnset
+	case *cui.Flex:
+		t, v = styleBox[*cui.Flex](w)
+	case *cui.Form:
+		t, v = styleBox[*cui.Form](w)
+		t.color = func(c tcell.Color) { w.SetLabelColor(c) }
+		t.colorFocused = func(c tcell.Color) { w.SetLabelColorFocused(c) }
+		v.color, v.colorFocused = cui.Styles.SecondaryTextColor, cui.ColorUnset
+	case *cui.Grid:
+		t, v = styleBox[*cui.Grid](w)
+	case *cui.Input:
+		t, v = styleBox[*cui.Input](w)
+		t.color = func(c tcell.Color) { w.SetLabelColor(c) }
+		t.colorFocused = func(c tcell.Color) { w.SetLabelColorFocused(c) }
+		v.color, v.colorFocused = cui.Styles.SecondaryTextColor, cui.ColorUnset
+	case *cui.List:
+		t, v = styleBox[*cui.List](w)
+		t.color = func(c tcell.Color) { w.SetMainTextColor(c) }
+		v.color = cui.Styles.PrimaryTextColor
+	case *cui.Panels:
+		t, v = styleBox[*cui.Panels](w)
+	case *cui.Progress:
+		t, v = styleBox[*cui.Progress](w)
+		t.color = func(c tcell.Color) { w.SetFilledColor(c) }
+		v.color = cui.Styles.PrimaryTextColor
+	case *cui.Table:
+		t, v = styleBox[*cui.Table](w)
+	case *cui.Text:
+		t, v = styleBox[*cui.Text](w)
+		t.color = func(c tcell.Color) { w.SetTextColor(c) }
+		v.color = cui.Styles.PrimaryTextColor
+	case *cui.Tree:
+		t, v = styleBox[*cui.Tree](w)
+	default:
+		t = &styleTarget{}
+	}
+	return t, v
+}
+
+// applyStyle applies the computed style to the widget of e. Properties which
+// are not part of the style are reset to the values the widget had before
+// styles were applied for the first time. While the element has focus, colors
+// go to the focused variants of the setters where the widget has them.
+func (e *Element) applyStyle(style map[string]string) {
+	if e.target == nil {
+		e.target, e.initial = newStyleTarget(e.widget)
+	}
+	t, v := e.target, e.initial
+
+	color := func(property string, def tcell.Color) tcell.Color {
+		if c, ok := parseColor(style[property]); ok {
+			return c
+		}
+		return def
+	}
+	border := v.border
+	borderColor, borderColorFocused := v.borderColor, v.borderColorFocused
+	if value, ok := style["border"]; ok {
+		for _, f := range strings.Fields(value) {
+			if c, ok := parseColor(f); ok {
+				borderColor, borderColorFocused = c, c
+			} else if _, ok := parseLength(f); !ok {
+				border = f != "none" && f != "hidden"
+			}
+		}
+	}
+	if value, ok := style["border-style"]; ok {
+		border = value != "none" && value != "hidden"
+	}
+	borderColor = color("border-color", borderColor)
+	borderColorFocused = color("border-color", borderColorFocused)
+
+	background := color("background", v.background)
+	background = color("background-color", background)
+
+	padding := v.padding
+	if value, ok := style["padding"]; ok {
+		var p []int
+		for _, f := range strings.Fields(value) {
+			if l, ok := parseLength(f); ok {
+				p = append(p, l)
+			}
+		}
+		switch len(p) {
+		case 1:
+			padding = [4]int{p[0], p[0], p[0], p[0]}
+		case 2:
+			padding = [4]int{p[0], p[0], p[1], p[1]}
+		case 3:
+			padding = [4]int{p[0], p[2], p[1], p[1]}
+		case 4:
+			padding = [4]int{p[0], p[2], p[3], p[1]}
+		}
+	}
+	for i, side := range []string{"padding-top", "padding-bottom", "padding-left", "padding-right"} {
+		if l, ok := parseLength(style[side]); ok {
+			padding[i] = l
+		}
+	}
+
+	focused := e.node.State&StateFocus != 0
+	if t.color != nil {
+		if focused && t.colorFocused != nil {
+			t.colorFocused(color("color", v.colorFocused))
+		} else {
+			t.color(color("color", v.color))
+		}
+	}
+	if t.background != nil {
+		if focused && t.backgroundFocused != nil {
+			t.backgroundFocused(color("background-color", color("background", v.backgroundFocused)))
+		} else {
+			t.background(background)
+		}
+	}
+	if t.borderColor != nil {
+		if focused {
+			t.borderColorFocused(borderColorFocused)
+		} else {
+			t.borderColor(borderColor)
+		}
+	}
+	if t.titleColor != nil {
+		t.titleColor(color("title-color", v.titleColor))
+	}
+	if t.border != nil {
+		t.border(border)
+	}
+	if t.borderAttributes != nil {
+		attributes := v.borderAttributes
+		if value, ok := style["border-attributes"]; ok {
+			attributes = parseAttributes(value)
+		}
+		t.borderAttributes(attributes)
+	}
+	if t.padding != nil {
+		t.padding(padding[0], padding[1], padding[2], padding[3])
+	}
+}
+
+// parseColor parses a CSS color value: a color name, #rgb, #rrggbb or
+// rgb(r, g, b).
+func parseColor(value string) (tcell.Color, bool) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	switch {
+	case value == "":
+		return tcell.ColorDefault, false
+	case value == "transparent":
+		return tcell.ColorDefault, true
+	case strings.HasPrefix(value, "#") && len(value) == 4:
+		value = "#" + strings.Repeat(value[1:2], 2) + strings.Repeat(value[2:3], 2) + strings.Repeat(value[3:4], 2)
+	case strings.HasPrefix(value, "rgb(") && strings.HasSuffix(value, ")"):
+		parts := strings.Split(value[4:len(value)-1], ",")
+		if len(parts) != 3 {
+			return tcell.ColorDefault, false
+		}
+		var rgb [3]int32
+		for i, p := range parts {
+			v, err := strconv.Atoi(strings.TrimSpace(p))
+			if err != nil || v < 0 || v > 255 {
+				return tcell.ColorDefault, false
+			}
+			rgb[i] = int32(v)
+		}
+		return tcell.NewRGBColor(rgb[0], rgb[1], rgb[2]), true
+	}
+	c := tcell.GetColor(value)
+	return c, c != tcell.ColorDefault
+}
+
+// parseLength parses a length in cells. The units px, ch and em are accepted
+// and treated as cells.
+func parseLength(value string) (int, bool) {
+	value = strings.TrimSpace(value)
+	for _, unit := range []string{"px", "ch", "em"} {
+		value = strings.TrimSuffix(value, unit)
+	}
+	l, err := strconv.Atoi(value)
+	return l, err == nil && l >= 0
+}
+
+// parseAttributes parses a whitespace separated list of text attributes.
+func parseAttributes(value string) (attributes tcell.AttrMask) {
+	for _, f := range strings.Fields(value) {
+		switch f {
+		case "bold":
+			attributes |= tcell.AttrBold
+		case "dim":
+			attributes |= tcell.AttrDim
+		case "italic":
+			attributes |= tcell.AttrItalic
+		case "blink":
+			attributes |= tcell.AttrBlink
+		case "reverse":
+			attributes |= tcell.AttrReverse
+		case "strikethrough":
+			attributes |= tcell.AttrStrikeThrough
+		case "underline":
+			attributes |= tcell.AttrUnderline
+		}
+	}
+	return
+}
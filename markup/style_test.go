@@ -0,0 +1,101 @@
+package markup
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+const testStyleDocument = `<style>
+	flex {color: green; background-color: #000080;}
+	button {color: black; border: 1px solid red;}
+	#b2 {color: orange;}
+	.blue {color: blue !important;}
+	button:focus {color: yellow;}
+	button:disabled {title-color: gray;}
+	text {padding: 1 2;}
+</style>
+<flex id="f">
+	<button id="b1">one</button>
+	<button id="b2" class="blue" style="color: red;">two</button>
+	<button id="b3" style="color: red; background-color: white;">three</button>
+	<button id="b4" disabled>four</button>
+	<text id="t">text</text>
+	<box id="box" style="color: inherit; border-style: none;"></box>
+</flex>`
+
+func TestApplyStyles(t *testing.T) {
+	t.Parallel()
+
+	doc, err := NewFile("style.cml", testStyleDocument)
+	if err != nil {
+		t.Fatalf("failed to bind document: %s", err)
+	}
+
+	for id, expected := range map[string]string{
+		"f":   "green",
+		"b1":  "black",
+		"b2":  "blue",
+		"b3":  "red",
+		"t":   "green",
+		"box": "green",
+	} {
+		if got := doc.Element(id).Style("color"); got != expected {
+			t.Errorf("incorrect color of #%s: expected %s, got %s", id, expected, got)
+		}
+	}
+
+	if got := doc.Flex("f").GetBackgroundColor(); got != tcell.NewHexColor(0x000080) {
+		t.Errorf("incorrect flex background color: got %v", got)
+	}
+	if got := doc.Button("b3").GetBackgroundColor(); got != tcell.ColorWhite {
+		t.Errorf("incorrect button background color: got %v", got)
+	}
+	if b := doc.Button("b1"); !b.GetBorder() || b.GetBorderColor() != tcell.ColorRed {
+		t.Errorf("expected red button border")
+	}
+	if doc.Box("box").GetBorder() {
+		t.Errorf("expected no box border")
+	}
+	if top, bottom, left, right := doc.Text("t").GetPadding(); top != 1 || bottom != 1 || left != 2 || right != 2 {
+		t.Errorf("incorrect text padding: got %d %d %d %d", top, bottom, left, right)
+	}
+	if got := doc.Button("b4").GetTitleColor(); got != tcell.ColorGray {
+		t.Errorf("incorrect disabled title color: got %v", got)
+	}
+
+	// Focus and disabled state changes are picked up when re-applying.
+	doc.Button("b1").Focus(nil)
+	doc.Element("b4").SetDisabled(false)
+	if err := doc.ApplyStyles(); err != nil {
+		t.Fatalf("failed to re-apply styles: %s", err)
+	}
+	if got := doc.Element("b1").Style("color"); got != "yellow" {
+		t.Errorf("incorrect focused color: expected yellow, got %s", got)
+	}
+	if got := doc.Element("f").Style("color"); got != "green" {
+		t.Errorf("expected container not to match :focus, got %s", got)
+	}
+	if got := doc.Button("b4").GetTitleColor(); got == tcell.ColorGray {
+		t.Errorf("expected title color to be reset after enabling")
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	t.Parallel()
+
+	for value, expected := range map[string]tcell.Color{
+		"red":              tcell.ColorRed,
+		"#fff":             tcell.NewHexColor(0xffffff),
+		"#102030":          tcell.NewHexColor(0x102030),
+		"rgb(1, 2, 3)":     tcell.NewRGBColor(1, 2, 3),
+		"  Blue ":          tcell.ColorBlue,
+		"transparent":      tcell.ColorDefault,
+		"rgb(1, 2)":        tcell.ColorDefault,
+		"not-a-color-name": tcell.ColorDefault,
+	} {
+		if got, _ := parseColor(value); got != expected {
+			t.Errorf("failed to parse color %q: expected %v, got %v", value, expected, got)
+		}
+	}
+}
@@ -24,6 +24,12 @@ const (
 	MouseScrollDown
 	MouseScrollLeft
 	MouseScrollRight
+
+	// Probes sent to find the drag source and drop target under the mouse
+	// while dragging with the left mouse button (see DragSource and
+	// DropTarget).
+	MouseDragStart
+	MouseDragOver
 )
 
 // StandardDoubleClick is a commonly used double click interval.
@@ -539,6 +539,35 @@ func (p *Panels) SendToBack(name string) {
 	}
 }
 
+// MovePanel moves the panel with the given name to the given index in the
+// order of the panels. The index is clamped to the valid range.
+func (p *Panels) MovePanel(name string, index int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for from, pg := range p.panels {
+		if pg.Name != name {
+			continue
+		}
+		if index < 0 {
+			index = 0
+		} else if index >= len(p.panels) {
+			index = len(p.panels) - 1
+		}
+		if from == index {
+			return
+		}
+		p.panels = append(p.panels[:from], p.panels[from+1:]...)
+		p.panels = append(p.panels[:index], append([]*panel{pg}, p.panels[index:]...)...)
+		if p.changed != nil {
+			p.mu.Unlock()
+			p.changed()
+			p.mu.Lock()
+		}
+		return
+	}
+}
+
 // GetFrontPanel returns the front-most visible panel. If there are no visible
 // panels, ("", nil) is returned.
 func (p *Panels) GetFrontPanel() (name string, item Widget) {
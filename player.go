@@ -0,0 +1,617 @@
+package cui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/gdamore/tcell/v2/views"
+	"github.com/malivvan/cui/terminal/vte"
+)
+
+// playerFrame is the interval in which the screen is updated during playback.
+const playerFrame = time.Second / 30
+
+// Player implements a widget which replays an asciicast v2 recording of a
+// terminal session (see [vte.VT.StartRecording]) into a [vte.VT]. Playback
+// may be paused, sought and sped up. A status line at the bottom shows the
+// playback position.
+type Player struct {
+	box *Box
+
+	app  *App
+	term *vte.VT
+	cast *vte.Cast
+
+	// The index of the next event of the recording to be replayed.
+	next int
+
+	// The playback position when playback was last started or sought, and
+	// the time it happened.
+	position time.Duration
+	started  time.Time
+
+	playing bool
+	speed   float64
+
+	// Closed to stop the playback goroutine.
+	stop chan struct{}
+
+	// The time skipped when seeking with the keyboard.
+	seekStep time.Duration
+
+	showStatus bool
+
+	mu sync.RWMutex
+}
+
+// NewPlayer returns a new Player without a recording.
+func NewPlayer(app *App) *Player {
+	return &Player{
+		box:        NewBox(),
+		app:        app,
+		term:       vte.New(),
+		speed:      1,
+		seekStep:   5 * time.Second,
+		showStatus: true,
+	}
+}
+
+///////////////////////////////////// <MUTEX> ///////////////////////////////////
+
+func (p *Player) set(setter func(p *Player)) *Player {
+	p.mu.Lock()
+	setter(p)
+	p.mu.Unlock()
+	return p
+}
+
+func (p *Player) get(getter func(p *Player)) {
+	p.mu.RLock()
+	getter(p)
+	p.mu.RUnlock()
+}
+
+///////////////////////////////////// <BOX> ////////////////////////////////////
+
+// GetTitle returns the title of this Player.
+func (p *Player) GetTitle() string {
+	return p.box.GetTitle()
+}
+
+// SetTitle sets the title of this Player.
+func (p *Player) SetTitle(title string) *Player {
+	p.box.SetTitle(title)
+	return p
+}
+
+// GetTitleAlign returns the title alignment of this Player.
+func (p *Player) GetTitleAlign() int {
+	return p.box.GetTitleAlign()
+}
+
+// SetTitleAlign sets the title alignment of this Player.
+func (p *Player) SetTitleAlign(align int) *Player {
+	p.box.SetTitleAlign(align)
+	return p
+}
+
+// GetBorder returns whether this Player has a border.
+func (p *Player) GetBorder() bool {
+	return p.box.GetBorder()
+}
+
+// SetBorder sets whether this Player has a border.
+func (p *Player) SetBorder(show bool) *Player {
+	p.box.SetBorder(show)
+	return p
+}
+
+// GetBorderColor returns the border color of this Player.
+func (p *Player) GetBorderColor() tcell.Color {
+	return p.box.GetBorderColor()
+}
+
+// SetBorderColor sets the border color of this Player.
+func (p *Player) SetBorderColor(color tcell.Color) *Player {
+	p.box.SetBorderColor(color)
+	return p
+}
+
+// GetBorderAttributes returns the border attributes of this Player.
+func (p *Player) GetBorderAttributes() tcell.AttrMask {
+	return p.box.GetBorderAttributes()
+}
+
+// SetBorderAttributes sets the border attributes of this Player.
+func (p *Player) SetBorderAttributes(attr tcell.AttrMask) *Player {
+	p.box.SetBorderAttributes(attr)
+	return p
+}
+
+// GetBorderColorFocused returns the border color of this Player when focused.
+func (p *Player) GetBorderColorFocused() tcell.Color {
+	return p.box.GetBorderColorFocused()
+}
+
+// SetBorderColorFocused sets the border color of this Player when focused.
+func (p *Player) SetBorderColorFocused(color tcell.Color) *Player {
+	p.box.SetBorderColorFocused(color)
+	return p
+}
+
+// GetTitleColor returns the title color of this Player.
+func (p *Player) GetTitleColor() tcell.Color {
+	return p.box.GetTitleColor()
+}
+
+// SetTitleColor sets the title color of this Player.
+func (p *Player) SetTitleColor(color tcell.Color) *Player {
+	p.box.SetTitleColor(color)
+	return p
+}
+
+// GetDrawFunc returns the custom draw function of this Player.
+func (p *Player) GetDrawFunc() func(screen tcell.Screen, x, y, width, height int) (int, int, int, int) {
+	return p.box.GetDrawFunc()
+}
+
+// SetDrawFunc sets a custom draw function for this Player.
+func (p *Player) SetDrawFunc(handler func(screen tcell.Screen, x, y, width, height int) (int, int, int, int)) *Player {
+	p.box.SetDrawFunc(handler)
+	return p
+}
+
+// ShowFocus sets whether this Player should show a focus indicator when focused.
+func (p *Player) ShowFocus(showFocus bool) *Player {
+	p.box.ShowFocus(showFocus)
+	return p
+}
+
+// GetMouseCapture returns the mouse capture function of this Player.
+func (p *Player) GetMouseCapture() func(action MouseAction, event *tcell.EventMouse) (MouseAction, *tcell.EventMouse) {
+	return p.box.GetMouseCapture()
+}
+
+// SetMouseCapture sets a mouse capture function for this Player.
+func (p *Player) SetMouseCapture(capture func(action MouseAction, event *tcell.EventMouse) (MouseAction, *tcell.EventMouse)) *Player {
+	p.box.SetMouseCapture(capture)
+	return p
+}
+
+// GetBackgroundColor returns the background color of this Player.
+func (p *Player) GetBackgroundColor() tcell.Color {
+	return p.box.GetBackgroundColor()
+}
+
+// SetBackgroundColor sets the background color of this Player.
+func (p *Player) SetBackgroundColor(color tcell.Color) *Player {
+	p.box.SetBackgroundColor(color)
+	return p
+}
+
+// GetBackgroundTransparent returns whether the background of this Player is transparent.
+func (p *Player) GetBackgroundTransparent() bool {
+	return p.box.GetBackgroundTransparent()
+}
+
+// SetBackgroundTransparent sets whether the background of this Player is transparent.
+func (p *Player) SetBackgroundTransparent(transparent bool) *Player {
+	p.box.SetBackgroundTransparent(transparent)
+	return p
+}
+
+// GetInputCapture returns the input capture function of this Player.
+func (p *Player) GetInputCapture() func(event *tcell.EventKey) *tcell.EventKey {
+	return p.box.GetInputCapture()
+}
+
+// SetInputCapture sets a custom input capture function for this Player.
+func (p *Player) SetInputCapture(capture func(event *tcell.EventKey) *tcell.EventKey) *Player {
+	p.box.SetInputCapture(capture)
+	return p
+}
+
+// GetPadding returns the padding of this Player.
+func (p *Player) GetPadding() (top, bottom, left, right int) {
+	return p.box.GetPadding()
+}
+
+// SetPadding sets the padding of this Player.
+func (p *Player) SetPadding(top, bottom, left, right int) *Player {
+	p.box.SetPadding(top, bottom, left, right)
+	return p
+}
+
+// InRect returns whether the given screen coordinates are within this Player.
+func (p *Player) InRect(x, y int) bool {
+	return p.box.InRect(x, y)
+}
+
+// GetInnerRect returns the inner rectangle of this Player.
+func (p *Player) GetInnerRect() (x, y, width, height int) {
+	return p.box.GetInnerRect()
+}
+
+// WrapInputHandler wraps the provided input handler function such that
+// input capture and other processing of the Player is preserved.
+func (p *Player) WrapInputHandler(inputHandler func(event *tcell.EventKey, setFocus func(p Widget))) func(event *tcell.EventKey, setFocus func(p Widget)) {
+	return p.box.WrapInputHandler(inputHandler)
+}
+
+// WrapMouseHandler wraps the provided mouse handler function such that
+// mouse capture and other processing of the Player is preserved.
+func (p *Player) WrapMouseHandler(mouseHandler func(action MouseAction, event *tcell.EventMouse, setFocus func(p Widget)) (consumed bool, capture Widget)) func(action MouseAction, event *tcell.EventMouse, setFocus func(p Widget)) (consumed bool, capture Widget) {
+	return p.box.WrapMouseHandler(mouseHandler)
+}
+
+// GetRect returns the rectangle occupied by this Player.
+func (p *Player) GetRect() (x, y, width, height int) {
+	return p.box.GetRect()
+}
+
+// SetRect sets the rectangle occupied by this Player.
+func (p *Player) SetRect(x, y, width, height int) {
+	p.box.SetRect(x, y, width, height)
+}
+
+// GetVisible returns whether this Player is visible.
+func (p *Player) GetVisible() bool {
+	return p.box.GetVisible()
+}
+
+// SetVisible sets whether this Player is visible.
+func (p *Player) SetVisible(visible bool) {
+	p.box.SetVisible(visible)
+}
+
+// Focus is called when this Player receives focus.
+func (p *Player) Focus(delegate func(p Widget)) {
+	p.box.Focus(delegate)
+}
+
+// HasFocus returns whether this Player has focus.
+func (p *Player) HasFocus() bool {
+	return p.box.HasFocus()
+}
+
+// GetFocusable returns the focusable primitive of this Player.
+func (p *Player) GetFocusable() Focusable {
+	return p.box.GetFocusable()
+}
+
+// Blur is called when this Player loses focus.
+func (p *Player) Blur() {
+	p.box.Blur()
+}
+
+////////////////////////////////// <API> ////////////////////////////////////
+
+// Load reads an asciicast v2 recording and sets it to be replayed.
+func (p *Player) Load(r io.Reader) error {
+	cast, err := vte.ReadCast(r)
+	if err != nil {
+		return err
+	}
+	p.SetCast(cast)
+	return nil
+}
+
+// SetCast sets the recording to be replayed. Playback is paused and starts
+// from the beginning.
+func (p *Player) SetCast(cast *vte.Cast) *Player {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pause()
+	p.cast = cast
+	p.reset()
+	return p
+}
+
+// GetCast returns the recording being replayed.
+func (p *Player) GetCast() (cast *vte.Cast) {
+	p.get(func(p *Player) { cast = p.cast })
+	return
+}
+
+// Play starts or resumes playback. Playback restarts from the beginning if
+// it has reached the end.
+func (p *Player) Play() *Player {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.playing || p.cast == nil {
+		return p
+	}
+	if p.position >= p.cast.Duration() {
+		p.reset()
+	}
+	p.playing = true
+	p.started = time.Now()
+	p.stop = make(chan struct{})
+	go p.run(p.stop)
+	return p
+}
+
+// Pause pauses playback.
+func (p *Player) Pause() *Player {
+	return p.set(func(p *Player) { p.pause() })
+}
+
+// IsPlaying returns whether the recording is being played.
+func (p *Player) IsPlaying() (playing bool) {
+	p.get(func(p *Player) { playing = p.playing })
+	return
+}
+
+// Seek sets the playback position. The screen shows the state of the
+// recording at that time.
+func (p *Player) Seek(position time.Duration) *Player {
+	return p.set(func(p *Player) { p.seek(position) })
+}
+
+// GetPosition returns the playback position.
+func (p *Player) GetPosition() (position time.Duration) {
+	p.get(func(p *Player) { position = p.currentPosition() })
+	return
+}
+
+// GetDuration returns the duration of the recording.
+func (p *Player) GetDuration() (duration time.Duration) {
+	p.get(func(p *Player) {
+		if p.cast != nil {
+			duration = p.cast.Duration()
+		}
+	})
+	return
+}
+
+// SetSpeed sets the playback speed, where 1 is the recorded speed.
+func (p *Player) SetSpeed(speed float64) *Player {
+	if speed <= 0 {
+		return p
+	}
+	return p.set(func(p *Player) {
+		p.position = p.currentPosition()
+		p.started = time.Now()
+		p.speed = speed
+	})
+}
+
+// GetSpeed returns the playback speed.
+func (p *Player) GetSpeed() (speed float64) {
+	p.get(func(p *Player) { speed = p.speed })
+	return
+}
+
+// SetSeekStep sets the time skipped when seeking with the keyboard.
+func (p *Player) SetSeekStep(step time.Duration) *Player {
+	return p.set(func(p *Player) { p.seekStep = step })
+}
+
+// SetShowStatus sets whether the status line with the playback position is
+// shown.
+func (p *Player) SetShowStatus(show bool) *Player {
+	return p.set(func(p *Player) { p.showStatus = show })
+}
+
+// GetShowStatus returns whether the status line is shown.
+func (p *Player) GetShowStatus() (show bool) {
+	p.get(func(p *Player) { show = p.showStatus })
+	return
+}
+
+// reset clears the screen and rewinds to the beginning of the recording.
+func (p *Player) reset() {
+	p.term = vte.New()
+	p.next = 0
+	p.position = 0
+	p.started = time.Now()
+	if p.cast != nil {
+		p.resize(p.cast.Header.Width, p.cast.Header.Height)
+	}
+}
+
+// resize resizes the terminal. Sizes larger than the largest size of a
+// recording are clamped and invalid sizes are ignored.
+func (p *Player) resize(w, h int) {
+	if w > 0 && h > 0 {
+		p.term.Resize(min(w, vte.MaxCastWidth), min(h, vte.MaxCastHeight))
+	}
+}
+
+func (p *Player) pause() {
+	if !p.playing {
+		return
+	}
+	p.position = p.currentPosition()
+	p.playing = false
+	close(p.stop)
+}
+
+func (p *Player) seek(position time.Duration) {
+	if p.cast == nil {
+		return
+	}
+	position = max(0, min(position, p.cast.Duration()))
+	if p.next > 0 && position < p.cast.Events[p.next-1].Time {
+		p.reset()
+	}
+	p.replay(position)
+	p.position = position
+	p.started = time.Now()
+}
+
+// currentPosition returns the playback position, which advances with the
+// time since playback was started.
+func (p *Player) currentPosition() time.Duration {
+	if !p.playing {
+		return p.position
+	}
+	position := p.position + time.Duration(float64(time.Since(p.started))*p.speed)
+	if p.cast != nil {
+		position = min(position, p.cast.Duration())
+	}
+	return position
+}
+
+// replay replays the events of the recording up to the position.
+func (p *Player) replay(position time.Duration) {
+	for ; p.next < len(p.cast.Events) && p.cast.Events[p.next].Time <= position; p.next++ {
+		ev := p.cast.Events[p.next]
+		switch ev.Type {
+		case vte.CastOutput:
+			p.term.Write([]byte(ev.Data))
+		case vte.CastResize:
+			var w, h int
+			if _, err := fmt.Sscanf(ev.Data, "%dx%d", &w, &h); err == nil {
+				p.resize(w, h)
+			}
+		}
+	}
+}
+
+// run replays the recording until it is paused or ends.
+func (p *Player) run(stop chan struct{}) {
+	ticker := time.NewTicker(playerFrame)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		p.mu.Lock()
+		position := p.currentPosition()
+		p.replay(position)
+		done := position >= p.cast.Duration()
+		if done {
+			p.pause()
+		}
+		p.mu.Unlock()
+		if p.app != nil {
+			p.app.QueueUpdateDraw(func() {})
+		}
+		if done {
+			return
+		}
+	}
+}
+
+// formatPlayerTime formats a playback position as minutes and seconds.
+func formatPlayerTime(d time.Duration) string {
+	s := int(d / time.Second)
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
+}
+
+/////////////////////////////////// <WIDGET> ////////////////////////////////////
+
+// Draw draws this primitive onto the screen.
+func (p *Player) Draw(s tcell.Screen) {
+	if !p.GetVisible() {
+		return
+	}
+
+	p.box.Draw(s)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	x, y, w, h := p.GetInnerRect()
+	if w <= 0 || h <= 0 {
+		return
+	}
+	if p.showStatus {
+		h--
+		p.drawStatus(s, x, y+h, w)
+	}
+	p.term.SetSurface(views.NewViewPort(s, x, y, w, h))
+	p.term.Draw()
+	if p.HasFocus() {
+		cy, cx, style, vis := p.term.Cursor()
+		if vis && cx < w && cy < h {
+			s.ShowCursor(cx+x, cy+y)
+			s.SetCursorStyle(style)
+		} else {
+			s.HideCursor()
+		}
+	}
+}
+
+// drawStatus draws the status line with the playback state, the position
+// and a progress bar.
+func (p *Player) drawStatus(s tcell.Screen, x, y, w int) {
+	var duration time.Duration
+	if p.cast != nil {
+		duration = p.cast.Duration()
+	}
+	position := p.currentPosition()
+	state := "▶"
+	if p.playing {
+		state = "⏸"
+	}
+	label := fmt.Sprintf(" %s %s / %s %gx ", state, formatPlayerTime(position), formatPlayerTime(duration), p.speed)
+	style := tcell.StyleDefault.Foreground(Styles.PrimaryTextColor).Background(Styles.ContrastBackgroundColor)
+	for i := 0; i < w; i++ {
+		s.SetContent(x+i, y, ' ', nil, style)
+	}
+	PrintStyle(s, []byte(label), x, y, w, AlignLeft, style)
+
+	// The progress bar fills the rest of the line.
+	bar := w - len([]rune(label)) - 1
+	if bar <= 0 {
+		return
+	}
+	filled := bar
+	if duration > 0 {
+		filled = int(int64(bar) * int64(position) / int64(duration))
+	}
+	PrintStyle(s, []byte(strings.Repeat("━", filled)+strings.Repeat("─", bar-filled)), x+w-bar-1, y, bar, AlignLeft, style)
+}
+
+// InputHandler returns the handler for this primitive.
+func (p *Player) InputHandler() func(event *tcell.EventKey, setFocus func(p Widget)) {
+	return p.WrapInputHandler(func(event *tcell.EventKey, setFocus func(p Widget)) {
+		switch {
+		case HitShortcut(event, Keys.PlayerPlayPause):
+			if p.IsPlaying() {
+				p.Pause()
+			} else {
+				p.Play()
+			}
+		case HitShortcut(event, Keys.PlayerSeekBackward):
+			p.set(func(p *Player) { p.seek(p.currentPosition() - p.seekStep) })
+		case HitShortcut(event, Keys.PlayerSeekForward):
+			p.set(func(p *Player) { p.seek(p.currentPosition() + p.seekStep) })
+		case HitShortcut(event, Keys.PlayerRestart):
+			p.Seek(0)
+		case HitShortcut(event, Keys.PlayerSpeedUp):
+			p.SetSpeed(min(p.GetSpeed()*2, 16))
+		case HitShortcut(event, Keys.PlayerSlowDown):
+			p.SetSpeed(max(p.GetSpeed()/2, 0.125))
+		}
+	})
+}
+
+// MouseHandler returns the mouse handler for this primitive. Clicking the
+// status line seeks to the clicked position.
+func (p *Player) MouseHandler() func(action MouseAction, event *tcell.EventMouse, setFocus func(p Widget)) (consumed bool, capture Widget) {
+	return p.WrapMouseHandler(func(action MouseAction, event *tcell.EventMouse, setFocus func(p Widget)) (consumed bool, capture Widget) {
+		x, y := event.Position()
+		if !p.InRect(x, y) {
+			return false, nil
+		}
+		if action != MouseLeftClick {
+			return false, nil
+		}
+		setFocus(p)
+		rectX, rectY, width, height := p.GetInnerRect()
+		if p.GetShowStatus() && y == rectY+height-1 && width > 1 {
+			duration := p.GetDuration()
+			p.Seek(time.Duration(int64(duration) * int64(x-rectX) / int64(width-1)))
+		}
+		return true, nil
+	})
+}
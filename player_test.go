@@ -0,0 +1,114 @@
+package cui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/malivvan/cui/terminal/vte"
+)
+
+const testCast = `{"version": 2, "width": 6, "height": 2}
+[0.5, "o", "one"]
+[1.0, "i", "x"]
+[1.2, "r", "8x2"]
+[1.5, "o", "\r\ntwo"]
+[3.0, "o", "\u001b[2J\u001b[Hthree"]
+`
+
+func playerScreen(p *Player) string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return strings.TrimRight(p.term.String(), " \n")
+}
+
+func TestPlayer(t *testing.T) {
+	t.Parallel()
+
+	p := NewPlayer(nil)
+	if err := p.Load(strings.NewReader(`{"version": 1}`)); err == nil {
+		t.Errorf("failed to reject recording: expected error for version 1")
+	}
+	if err := p.Load(strings.NewReader(testCast)); err != nil {
+		t.Fatalf("failed to load recording: %s", err)
+	}
+	if p.GetDuration() != 3*time.Second {
+		t.Errorf("failed to load recording: expected duration 3s, got %s", p.GetDuration())
+	}
+
+	for _, test := range []struct {
+		position time.Duration
+		expected string
+	}{
+		{0, ""},
+		{time.Second, "one"},
+		{2 * time.Second, "        \ntwo"},
+		{3 * time.Second, "three"},
+		{1600 * time.Millisecond, "        \ntwo"},
+		{5 * time.Second, "three"},
+	} {
+		p.Seek(test.position)
+		if got := playerScreen(p); got != test.expected {
+			t.Errorf("failed to seek to %s: expected %q, got %q", test.position, test.expected, got)
+		}
+	}
+	if p.GetPosition() != 3*time.Second {
+		t.Errorf("failed to clamp position: expected 3s, got %s", p.GetPosition())
+	}
+
+	// Invalid and oversized sizes
+	p.SetCast(&vte.Cast{
+		Header: vte.CastHeader{Version: 2, Width: -1, Height: 2},
+		Events: []vte.CastEvent{
+			{Time: time.Second, Type: vte.CastResize, Data: "100000x2"},
+			{Time: 2 * time.Second, Type: vte.CastOutput, Data: "\r\nx"},
+		},
+	})
+	p.Seek(2 * time.Second)
+	if got := playerScreen(p); got != strings.Repeat(" ", vte.MaxCastWidth)+"\nx" {
+		t.Errorf("failed to clamp size: got %d columns", strings.Index(got, "\n"))
+	}
+	p.Load(strings.NewReader(testCast))
+
+	// Keyboard controls
+	p.Seek(2 * time.Second)
+	p.SetSeekStep(time.Second)
+	handler := p.InputHandler()
+	handler(tcell.NewEventKey(tcell.KeyLeft, 0, tcell.ModNone), nil)
+	if p.GetPosition() != time.Second {
+		t.Errorf("failed to seek backward: expected 1s, got %s", p.GetPosition())
+	}
+	handler(tcell.NewEventKey(tcell.KeyRune, '+', tcell.ModNone), nil)
+	if p.GetSpeed() != 2 {
+		t.Errorf("failed to speed up: expected 2, got %g", p.GetSpeed())
+	}
+
+	// Playback runs to the end
+	p.SetSpeed(16)
+	handler(tcell.NewEventKey(tcell.KeyRune, ' ', tcell.ModNone), nil)
+	if !p.IsPlaying() {
+		t.Errorf("failed to play: expected playing")
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for p.IsPlaying() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if p.IsPlaying() || p.GetPosition() != 3*time.Second || playerScreen(p) != "three" {
+		t.Errorf("failed to play to the end: got position %s and screen %q", p.GetPosition(), playerScreen(p))
+	}
+
+	// Pausing keeps the position
+	p.Seek(0).Play().Pause()
+	if p.IsPlaying() || p.GetPosition() > time.Second {
+		t.Errorf("failed to pause: got position %s", p.GetPosition())
+	}
+
+	// Draw
+	app, err := newTestApp(p)
+	if err != nil {
+		t.Fatalf("failed to initialize Application: %s", err)
+	}
+	p.Seek(3 * time.Second)
+	p.Draw(app.screen)
+}
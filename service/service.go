@@ -23,6 +23,7 @@ type Config struct {
 	SSH  *ssh.Server
 	GRPC *grpc.Server
 	HTTP *http.Server
+	WS   *http.Server
 	TLS  *tls.Config
 	ERR  func(error)
 }
@@ -106,6 +107,15 @@ func NewServer(cfg Config) (s *Server, err error) {
 			s.err <- s.cfg.GRPC.Serve(s.tcp.grpc)
 		}()
 	}
+	if s.cfg.WS != nil {
+		s.tcp.http.ws = s.tcp.mux.Match(cmux.HTTP1HeaderField("Upgrade", "websocket"))
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			log.Printf("WebSocket server listening on %s", s.cfg.tcpAddr())
+			s.err <- s.cfg.WS.Serve(s.tcp.http.ws)
+		}()
+	}
 	if s.cfg.HTTP != nil {
 		s.tcp.http.Listener = s.tcp.mux.Match(cmux.HTTP1Fast(), cmux.HTTP2())
 		s.wg.Add(1)
@@ -141,6 +151,15 @@ func NewServer(cfg Config) (s *Server, err error) {
 				s.err <- s.cfg.GRPC.Serve(s.tcp.tls.grpc)
 			}()
 		}
+		if s.cfg.WS != nil {
+			s.tcp.tls.https.wss = s.tcp.tls.mux.Match(cmux.HTTP1HeaderField("Upgrade", "websocket"))
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				log.Printf("WebSocket over TLS server listening on %s", s.cfg.tcpAddr())
+				s.err <- s.cfg.WS.Serve(s.tcp.tls.https.wss)
+			}()
+		}
 		if s.cfg.HTTP != nil {
 			s.tcp.tls.https.Listener = s.tcp.tls.mux.Match(cmux.Any())
 			s.wg.Add(1)
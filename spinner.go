@@ -340,11 +340,14 @@ func (s *Spinner) Draw(screen tcell.Screen) {
 
 	x, y, width, _ := s.GetInnerRect()
 
-	Print(screen, []byte(func(s *Spinner) string {
-		frames := s.styles[s.currentStyle]
-		if len(frames) == 0 {
-			return ""
-		}
-		return string(frames[s.counter%len(frames)])
-	}(s)), x, y, width, AlignLeft, tcell.ColorDefault)
+	Print(screen, []byte(s.frame()), x, y, width, AlignLeft, tcell.ColorDefault)
+}
+
+// frame returns the current frame of the spinner.
+func (s *Spinner) frame() string {
+	frames := s.styles[s.currentStyle]
+	if len(frames) == 0 {
+		return ""
+	}
+	return string(frames[s.counter%len(frames)])
 }
@@ -0,0 +1,261 @@
+package cui
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/gliderlabs/ssh"
+)
+
+// defaultSSHTerm is the terminal type assumed when a client requests a PTY
+// for a terminal which is unknown to terminfo.
+const defaultSSHTerm = "xterm-256color"
+
+// ErrNoPty is returned when an SSH session did not request a PTY.
+var ErrNoPty = errors.New("no pty requested")
+
+// ServeSSH returns an SSH handler which runs an independent application for
+// each session. The handler function is called once per session and returns
+// the application to be run on a screen backed by the session's PTY. Sessions
+// without a PTY are rejected before the handler function is called. Window
+// changes of the client are forwarded as resize events and the application is
+// stopped when the client disconnects. Like with ServeWeb, window sizes are
+// limited to 1000x1000 cells. The session ends when the application stops.
+//
+// The returned handler may be used with ssh.Handle, an ssh.Server or the SSH
+// multiplexing of service.Server:
+//
+//	service.NewServer(service.Config{
+//	    TCP: 2222,
+//	    SSH: &ssh.Server{Handler: cui.ServeSSH(func(sess ssh.Session) *cui.App {
+//	        return cui.New().SetRoot(cui.NewTextView().SetText("hello "+sess.User()), true)
+//	    })},
+//	})
+func ServeSSH(handler func(sess ssh.Session) *App) ssh.Handler {
+	return func(sess ssh.Session) {
+		if _, _, ok := sess.Pty(); !ok {
+			fmt.Fprintln(sess.Stderr(), ErrNoPty)
+			_ = sess.Exit(1)
+			return
+		}
+		if err := RunSSH(sess, handler(sess)); err != nil {
+			fmt.Fprintln(sess.Stderr(), err)
+			_ = sess.Exit(1)
+			return
+		}
+		_ = sess.Exit(0)
+	}
+}
+
+// RunSSH runs the application on a screen backed by the PTY of the provided
+// SSH session. It returns when the application is stopped or the client
+// disconnects. A nil application is ignored.
+func RunSSH(sess ssh.Session, app *App) error {
+	if app == nil {
+		return nil
+	}
+	screen, tty, err := newSSHScreen(sess)
+	if err != nil {
+		return err
+	}
+	if err := screen.Init(); err != nil {
+		return err
+	}
+	return runScreen(app, screen, tty.closed, sess.Context().Done())
+}
+
+// runScreen runs the application on the initialized screen. The application
+// is stopped as soon as any of the provided channels is closed.
+func runScreen(app *App, screen tcell.Screen, stop ...<-chan struct{}) error {
+	app.mu.Lock()
+	if app.enableBracketedPaste {
+		screen.EnablePaste()
+	}
+	if app.enableMouse {
+		screen.EnableMouse()
+	}
+	app.width, app.height = screen.Size()
+	app.mu.Unlock()
+	app.SetScreen(screen)
+
+	var once sync.Once
+	done := make(chan struct{})
+	defer close(done)
+	for _, ch := range stop {
+		go func(ch <-chan struct{}) {
+			select {
+			case <-ch:
+				once.Do(app.Stop)
+			case <-done:
+			}
+		}(ch)
+	}
+	return app.Run()
+}
+
+// NewSSHScreen returns a screen which draws to and reads from the PTY of the
+// provided SSH session. The terminal type requested by the client is used to
+// look up its terminfo description. The screen is not initialized.
+func NewSSHScreen(sess ssh.Session) (tcell.Screen, error) {
+	screen, _, err := newSSHScreen(sess)
+	return screen, err
+}
+
+func newSSHScreen(sess ssh.Session) (tcell.Screen, *sshTty, error) {
+	pty, winch, ok := sess.Pty()
+	if !ok {
+		return nil, nil, ErrNoPty
+	}
+	ti, err := tcell.LookupTerminfo(pty.Term)
+	if err != nil {
+		ti, err = tcell.LookupTerminfo(defaultSSHTerm)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	tty := newSSHTty(sess, pty.Window, winch)
+	screen, err := tcell.NewTerminfoScreenFromTtyTerminfo(tty, ti)
+	if err != nil {
+		return nil, nil, err
+	}
+	return screen, tty, nil
+}
+
+// sshTty adapts an SSH session to the tcell.Tty interface.
+type sshTty struct {
+	sess  ssh.Session
+	winch <-chan ssh.Window
+
+	mu     sync.Mutex
+	window ssh.Window
+	resize func()
+	drain  chan struct{}
+
+	once    sync.Once
+	input   chan []byte
+	pending []byte
+
+	// closed is closed when the client closed its input.
+	closed chan struct{}
+}
+
+func newSSHTty(sess ssh.Session, window ssh.Window, winch <-chan ssh.Window) *sshTty {
+	return &sshTty{
+		sess:   sess,
+		winch:  winch,
+		window: window,
+		input:  make(chan []byte),
+		closed: make(chan struct{}),
+	}
+}
+
+// Start activates the tty. Input is read from the session in the background
+// so that a blocked read can be interrupted by Drain.
+func (t *sshTty) Start() error {
+	t.mu.Lock()
+	t.drain = make(chan struct{})
+	t.mu.Unlock()
+	t.once.Do(func() {
+		go t.readLoop()
+		go t.resizeLoop()
+	})
+	return nil
+}
+
+func (t *sshTty) readLoop() {
+	defer close(t.closed)
+	for {
+		buf := make([]byte, 128)
+		n, err := t.sess.Read(buf)
+		if n > 0 {
+			select {
+			case t.input <- buf[:n]:
+			case <-t.sess.Context().Done():
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (t *sshTty) resizeLoop() {
+	for window := range t.winch {
+		t.mu.Lock()
+		t.window = window
+		resize := t.resize
+		t.mu.Unlock()
+		if resize != nil {
+			resize()
+		}
+	}
+}
+
+// Read reads input of the session.
+func (t *sshTty) Read(p []byte) (int, error) {
+	if len(t.pending) == 0 {
+		t.mu.Lock()
+		drain := t.drain
+		t.mu.Unlock()
+		select {
+		case t.pending = <-t.input:
+		case <-t.closed:
+			return 0, io.EOF
+		case <-drain:
+			return 0, os.ErrDeadlineExceeded
+		}
+	}
+	n := copy(p, t.pending)
+	t.pending = t.pending[n:]
+	return n, nil
+}
+
+// Write writes output to the session.
+func (t *sshTty) Write(p []byte) (int, error) {
+	return t.sess.Write(p)
+}
+
+// Close does nothing. The session is closed when its handler returns.
+func (t *sshTty) Close() error {
+	return nil
+}
+
+// Stop deactivates the tty.
+func (t *sshTty) Stop() error {
+	return nil
+}
+
+// Drain wakes up a blocked Read.
+func (t *sshTty) Drain() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.drain != nil {
+		select {
+		case <-t.drain:
+		default:
+			close(t.drain)
+		}
+	}
+	return nil
+}
+
+// NotifyResize registers a callback which is invoked when the client's window
+// size changes.
+func (t *sshTty) NotifyResize(cb func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.resize = cb
+}
+
+// WindowSize returns the client's window size, limited to 1000x1000 cells.
+func (t *sshTty) WindowSize() (tcell.WindowSize, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	width, height := clampRemoteSize(t.window.Width, t.window.Height)
+	return tcell.WindowSize{Width: width, Height: height}, nil
+}
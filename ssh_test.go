@@ -0,0 +1,160 @@
+package cui
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gliderlabs/ssh"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// sshOutput collects the output of an SSH client session.
+type sshOutput struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (o *sshOutput) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.buf.Write(p)
+}
+
+func (o *sshOutput) wait(t *testing.T, s string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		o.mu.Lock()
+		found := strings.Contains(o.buf.String(), s)
+		o.mu.Unlock()
+		if found {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("failed to receive %q", s)
+}
+
+func TestServeSSH(t *testing.T) {
+	t.Parallel()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	sizes := make(chan [2]int, 10)
+	stopped := make(chan struct{}, 2)
+	var built atomic.Int32
+	handler := ServeSSH(func(sess ssh.Session) *App {
+		built.Add(1)
+		app := New()
+		app.SetRoot(NewTextView().SetText("hello "+sess.User()), true)
+		app.SetAfterResizeFunc(func(width, height int) { sizes <- [2]int{width, height} })
+		return app
+	})
+	server := &ssh.Server{Handler: func(sess ssh.Session) {
+		handler(sess)
+		stopped <- struct{}{}
+	}}
+	go server.Serve(l)
+	defer server.Close()
+
+	client, err := gossh.Dial("tcp", l.Addr().String(), &gossh.ClientConfig{
+		User:            "joe",
+		HostKeyCallback: gossh.InsecureIgnoreHostKey(),
+	})
+	if err != nil {
+		t.Fatalf("failed to connect: %s", err)
+	}
+	defer client.Close()
+
+	// Sessions without a PTY are rejected.
+	plain, err := client.NewSession()
+	if err != nil {
+		t.Fatalf("failed to open session: %s", err)
+	}
+	var stderr bytes.Buffer
+	plain.Stderr = &stderr
+	if err := plain.Run(""); err == nil || !strings.Contains(stderr.String(), ErrNoPty.Error()) {
+		t.Errorf("expected session without pty to fail: got %v (%q)", err, stderr.String())
+	}
+	if n := built.Load(); n != 0 {
+		t.Errorf("failed to reject session before building the application: built %d", n)
+	}
+
+	sess, err := client.NewSession()
+	if err != nil {
+		t.Fatalf("failed to open session: %s", err)
+	}
+	out := &sshOutput{}
+	sess.Stdout = out
+	stdin, err := sess.StdinPipe()
+	if err != nil {
+		t.Fatalf("failed to open stdin: %s", err)
+	}
+	if err := sess.RequestPty("xterm", 24, 80, gossh.TerminalModes{}); err != nil {
+		t.Fatalf("failed to request pty: %s", err)
+	}
+	if err := sess.Shell(); err != nil {
+		t.Fatalf("failed to start shell: %s", err)
+	}
+	out.wait(t, "hello joe")
+
+	if err := sess.WindowChange(30, 100); err != nil {
+		t.Fatalf("failed to change window: %s", err)
+	}
+	deadline := time.After(5 * time.Second)
+	for size := [2]int{}; size != [2]int{100, 30}; {
+		select {
+		case size = <-sizes:
+		case <-deadline:
+			t.Fatalf("failed to resize: expected 100x30, got %dx%d", size[0], size[1])
+		}
+	}
+	if err := sess.WindowChange(10, 100000); err != nil {
+		t.Fatalf("failed to change window: %s", err)
+	}
+	for size := [2]int{}; size != [2]int{1000, 10}; {
+		select {
+		case size = <-sizes:
+		case <-deadline:
+			t.Fatalf("failed to clamp size: expected 1000x10, got %dx%d", size[0], size[1])
+		}
+	}
+
+	// Ctrl-C stops the application and ends the session.
+	if _, err := stdin.Write([]byte{0x03}); err != nil {
+		t.Fatalf("failed to send input: %s", err)
+	}
+	if err := sess.Wait(); err != nil {
+		t.Errorf("failed to end session: %s", err)
+	}
+
+	// Closing the session stops the application.
+	sess, err = client.NewSession()
+	if err != nil {
+		t.Fatalf("failed to open session: %s", err)
+	}
+	out = &sshOutput{}
+	sess.Stdout = out
+	if err := sess.RequestPty("xterm", 24, 80, gossh.TerminalModes{}); err != nil {
+		t.Fatalf("failed to request pty: %s", err)
+	}
+	if err := sess.Shell(); err != nil {
+		t.Fatalf("failed to start shell: %s", err)
+	}
+	out.wait(t, "hello joe")
+	<-stopped // Session without a PTY.
+	<-stopped // Session stopped with Ctrl-C.
+	sess.Close()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Errorf("expected application to be stopped on disconnect")
+	}
+}
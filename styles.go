@@ -37,15 +37,37 @@ var Styles = struct {
 	ContextMenuPaddingLeft   int
 	ContextMenuPaddingRight  int
 
+	// Drag and drop
+	DragLabelTextColor tcell.Color // The label shown next to the mouse while dragging.
+	DragAcceptColor    tcell.Color // The background of the label and the drop position if the payload is accepted.
+	DragRejectColor    tcell.Color // The background of the label if the payload is not accepted.
+
 	// Drop down
 	DropDownAbbreviationChars string // The chars to show when the option's text gets shortened.
 	DropDownSymbol            rune   // The symbol to draw at the end of the field when closed.
 	DropDownOpenSymbol        rune   // The symbol to draw at the end of the field when opened.
 	DropDownSelectedSymbol    rune   // The symbol to draw to indicate the selected list item.
 
+	// Filter
+	FilterMatchColor tcell.Color // The matched characters of filtered list items and tree nodes.
+
+	// Form
+	FormInvalidColor tcell.Color // The labels and notes of invalid form items.
+
+	// Multi-selection
+	MultiSelectBackgroundColor tcell.Color // The selected items, rows and nodes of lists, tables and trees which allow selecting several.
+
 	// Scroll bar
 	ScrollBarColor tcell.Color
 
+	// Table
+	TableColumnShownSymbol    rune // The symbol to draw before shown columns in the column menu.
+	TableSortAscendingSymbol  rune // The symbol to draw after the header of a column sorted in ascending order.
+	TableSortDescendingSymbol rune // The symbol to draw after the header of a column sorted in descending order.
+
+	// Tree
+	TreeLoadErrorColor tcell.Color // The placeholder of nodes whose children failed to load.
+
 	// Window
 	WindowMinWidth  int
 	WindowMinHeight int
@@ -75,13 +97,29 @@ var Styles = struct {
 	ContextMenuPaddingLeft:   1,
 	ContextMenuPaddingRight:  1,
 
+	DragLabelTextColor: tcell.ColorBlack.TrueColor(),
+	DragAcceptColor:    tcell.ColorGreen.TrueColor(),
+	DragRejectColor:    tcell.ColorGray.TrueColor(),
+
 	DropDownAbbreviationChars: "...",
 	DropDownSymbol:            '◀',
 	DropDownOpenSymbol:        '▼',
 	DropDownSelectedSymbol:    '▶',
 
+	FilterMatchColor: tcell.ColorOrange.TrueColor(),
+
+	FormInvalidColor: tcell.ColorRed.TrueColor(),
+
+	MultiSelectBackgroundColor: tcell.ColorDarkBlue.TrueColor(),
+
 	ScrollBarColor: tcell.ColorWhite.TrueColor(),
 
+	TableColumnShownSymbol:    '✓',
+	TableSortAscendingSymbol:  '▲',
+	TableSortDescendingSymbol: '▼',
+
+	TreeLoadErrorColor: tcell.ColorRed.TrueColor(),
+
 	WindowMinWidth:  4,
 	WindowMinHeight: 3,
 }
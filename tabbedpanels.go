@@ -30,6 +30,19 @@ type TabbedPanels struct {
 
 	setFocus func(Widget)
 
+	// Whether the user may drag tabs with the mouse.
+	draggable bool
+
+	// Optional functions which decide whether a payload may be dropped onto
+	// the tab with the given index and drop it. Without them, tabs dragged
+	// from this widget are moved.
+	acceptDrop func(payload *DragPayload, index int) bool
+	drop       func(payload *DragPayload, index int)
+
+	// The index of the tab onto which a dragged payload would be dropped, or
+	// -1.
+	dropTab int
+
 	mu sync.RWMutex
 }
 
@@ -42,6 +55,7 @@ func NewTabbedPanels() *TabbedPanels {
 		dividerMid: string(BoxDrawingsDoubleVertical),
 		dividerEnd: string(BoxDrawingsLightVertical),
 		tabLabels:  make(map[string]string),
+		dropTab:    -1,
 	}
 
 	s := t.switcher
@@ -325,6 +339,122 @@ func (t *TabbedPanels) SetTabLabel(name, label string) *TabbedPanels {
 	return t
 }
 
+// MoveTab moves the tab with the given name to the given index.
+func (t *TabbedPanels) MoveTab(name string, index int) *TabbedPanels {
+	t.panels.MovePanel(name, index)
+
+	t.updateAll()
+	return t
+}
+
+// SetDraggable sets whether the user may drag tabs with the mouse. The payload
+// holds the name of the dragged tab. Unless a drop function is set with
+// SetDropFunc(), dropping a tab onto another tab moves it there.
+func (t *TabbedPanels) SetDraggable(draggable bool) *TabbedPanels {
+	return t.set(func(t *TabbedPanels) { t.draggable = draggable })
+}
+
+// SetDropFunc sets the functions which decide whether a payload dragged with
+// the mouse may be dropped onto the tab with the given index and drop it.
+// Without them, the widget accepts the tabs dragged from itself and moves
+// them.
+func (t *TabbedPanels) SetDropFunc(accept func(payload *DragPayload, index int) bool, drop func(payload *DragPayload, index int)) *TabbedPanels {
+	return t.set(func(t *TabbedPanels) { t.acceptDrop, t.drop = accept, drop })
+}
+
+// tabAt returns the index of the tab at the given screen position and its
+// region in the tab switcher, or -1 if there is none.
+func (t *TabbedPanels) tabAt(x, y int) (int, *textViewRegion) {
+	if !t.switcher.InRect(x, y) {
+		return -1, nil
+	}
+	t.switcher.mu.RLock()
+	defer t.switcher.mu.RUnlock()
+	for _, region := range t.switcher.regionInfos {
+		if y == region.FromY && x < region.FromX ||
+			y == region.ToY && x >= region.ToX ||
+			region.FromY >= 0 && y < region.FromY ||
+			region.ToY >= 0 && y > region.ToY {
+			continue
+		}
+		for index, panel := range t.panels.panels {
+			if panel.Name == string(region.ID) {
+				return index, region
+			}
+		}
+	}
+	return -1, nil
+}
+
+// DragStart returns the payload of the tab at the given screen position. It
+// implements DragSource.
+func (t *TabbedPanels) DragStart(x, y int) *DragPayload {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if !t.draggable {
+		return nil
+	}
+	index, _ := t.tabAt(x, y)
+	if index < 0 {
+		return nil
+	}
+	name := t.panels.panels[index].Name
+	return &DragPayload{Kind: DragKindTab, Data: name, Label: t.tabLabels[name]}
+}
+
+// DragEnd is called when dragging a tab has ended. It implements DragSource.
+func (t *TabbedPanels) DragEnd(payload *DragPayload, dropped bool) {}
+
+// acceptsDrop returns whether the payload may be dropped onto the tab with the
+// given index. Must be called with the widget locked.
+func (t *TabbedPanels) acceptsDrop(payload *DragPayload, index int) bool {
+	if accept := t.acceptDrop; accept != nil {
+		t.mu.Unlock()
+		defer t.mu.Lock()
+		return accept(payload, index)
+	}
+	return payload.Source == t && payload.Kind == DragKindTab
+}
+
+// DragOver shows where the payload would be dropped. It implements
+// DropTarget.
+func (t *TabbedPanels) DragOver(payload *DragPayload, x, y int) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.dropTab, _ = t.tabAt(x, y)
+	if t.dropTab >= 0 && !t.acceptsDrop(payload, t.dropTab) {
+		t.dropTab = -1
+	}
+	return t.dropTab >= 0
+}
+
+// DragLeave removes what was shown by DragOver(). It implements DropTarget.
+func (t *TabbedPanels) DragLeave() {
+	t.set(func(t *TabbedPanels) { t.dropTab = -1 })
+}
+
+// Drop drops the payload onto the tab at the given screen position. It
+// implements DropTarget.
+func (t *TabbedPanels) Drop(payload *DragPayload, x, y int) bool {
+	t.mu.Lock()
+	index, _ := t.tabAt(x, y)
+	if index < 0 || !t.acceptsDrop(payload, index) {
+		t.mu.Unlock()
+		return false
+	}
+	drop, acceptDrop := t.drop, t.acceptDrop
+	t.mu.Unlock()
+
+	if drop != nil {
+		drop(payload, index)
+	} else if name, ok := payload.Data.(string); ok && acceptDrop == nil {
+		t.MoveTab(name, index)
+	}
+	return true
+}
+
 // SetBackgroundColor sets the background color of the tabbed panels.
 func (t *TabbedPanels) SetBackgroundColor(color tcell.Color) *TabbedPanels {
 	t.panels.box.SetBackgroundColor(color)
@@ -553,6 +683,39 @@ func (t *TabbedPanels) Draw(screen tcell.Screen) {
 	t.lastWidth = t.width
 
 	t.flex.Draw(screen)
+
+	// Show where a dragged payload would be dropped.
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.dropTab < 0 {
+		return
+	}
+	sx, _, sw, _ := t.switcher.GetInnerRect()
+	t.switcher.mu.RLock()
+	defer t.switcher.mu.RUnlock()
+	if t.dropTab >= len(t.panels.panels) {
+		return
+	}
+	name := t.panels.panels[t.dropTab].Name
+	for _, region := range t.switcher.regionInfos {
+		if string(region.ID) != name || region.FromY < 0 {
+			continue
+		}
+		toY := region.ToY
+		if toY < 0 {
+			toY = region.FromY
+		}
+		for y := region.FromY; y <= toY; y++ {
+			fromX, toX := sx, sx+sw
+			if y == region.FromY {
+				fromX = region.FromX
+			}
+			if y == region.ToY && region.ToX >= 0 {
+				toX = region.ToX
+			}
+			dropFeedback(screen, fromX, y, toX-fromX)
+		}
+	}
 }
 
 // InputHandler returns the handler for this primitive.
@@ -577,6 +740,17 @@ func (t *TabbedPanels) MouseHandler() func(action MouseAction, event *tcell.Even
 			return false, nil
 		}
 
+		// Report the widget as drag source or drop target.
+		if action == MouseDragStart || action == MouseDragOver {
+			t.mu.RLock()
+			draggable, acceptDrop := t.draggable, t.acceptDrop
+			t.mu.RUnlock()
+			if t.switcher.InRect(x, y) && (draggable || action == MouseDragOver && acceptDrop != nil) {
+				return true, t
+			}
+			return t.flex.MouseHandler()(action, event, setFocus)
+		}
+
 		if t.switcher.InRect(x, y) {
 			if t.setFocus != nil {
 				defer t.setFocus(t.panels)
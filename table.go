@@ -2,7 +2,9 @@ package cui
 
 import (
 	"bytes"
+	"fmt"
 	"sort"
+	"strconv"
 	"sync"
 
 	"github.com/gdamore/tcell/v2"
@@ -62,6 +64,27 @@ func NewTableCell(text string) *TableCell {
 	}
 }
 
+// clone returns a copy of the cell.
+func (c *TableCell) clone() *TableCell {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return &TableCell{
+		Reference:       c.Reference,
+		Text:            c.Text,
+		Align:           c.Align,
+		MaxWidth:        c.MaxWidth,
+		Expansion:       c.Expansion,
+		Color:           c.Color,
+		BackgroundColor: c.BackgroundColor,
+		Attributes:      c.Attributes,
+		NotSelectable:   c.NotSelectable,
+		x:               c.x,
+		y:               c.y,
+		width:           c.width,
+	}
+}
+
 // SetBytes sets the cell's text.
 func (c *TableCell) SetBytes(text []byte) *TableCell {
 	c.mu.Lock()
@@ -219,10 +242,382 @@ func (c *TableCell) GetLastPosition() (x, y, width int) {
 	return c.x, c.y, c.width
 }
 
+// TableContent provides the cells of a Table. The table only requests the
+// cells it draws, so implementations may produce cells on demand from large
+// or remote data sets instead of keeping them in memory. Set it with
+// Table.SetContent. By default, a table keeps its cells in memory.
+//
+// The Table methods SetCell, RemoveRow, RemoveColumn, InsertRow,
+// InsertColumn and Clear are forwarded to the content. Read-only contents can
+// embed TableContentReadOnly to ignore them.
+//
+// The table locks itself while calling these methods, so they must not call
+// methods of the table.
+type TableContent interface {
+	// GetCell returns the cell at the given position or nil if there is no
+	// such cell. The table keeps no reference to the cell beyond drawing it,
+	// except for the position and width set by Draw.
+	GetCell(row, column int) *TableCell
+
+	// GetRowCount returns the number of rows.
+	GetRowCount() int
+
+	// GetColumnCount returns the number of columns.
+	GetColumnCount() int
+
+	// SetCell sets the cell at the given position.
+	SetCell(row, column int, cell *TableCell)
+
+	// RemoveRow removes the row at the given position. Rows below move up
+	// by one.
+	RemoveRow(row int)
+
+	// RemoveColumn removes the column at the given position. Columns to the
+	// right move left by one.
+	RemoveColumn(column int)
+
+	// InsertRow inserts an empty row before the row at the given position.
+	InsertRow(row int)
+
+	// InsertColumn inserts an empty column before the column at the given
+	// position.
+	InsertColumn(column int)
+
+	// Clear removes all cells.
+	Clear()
+}
+
+// TableContentSorter is implemented by table contents which can be sorted.
+// Table.Sort and clicks on fixed rows only sort contents implementing it.
+type TableContentSorter interface {
+	// Sort sorts the rows by the column, leaving the fixed rows in place.
+	// The less function is the one set with Table.SetSortFunc or nil, in
+	// which case the content picks its own order.
+	Sort(column int, descending bool, fixedRows int, less func(column, i, j int) bool)
+}
+
+// TableContentRowKeys is implemented by sortable table contents whose rows
+// can be identified independently of their position, e.g. by the IDs of the
+// records they show. The selected rows are found again by their keys after
+// sorting; contents without keys lose the selection.
+type TableContentRowKeys interface {
+	// GetRowKey returns a comparable key of the row at the given position,
+	// or nil if the row does not exist. It is called for every row after
+	// sorting, so it should not build the cells of the row.
+	GetRowKey(row int) interface{}
+}
+
+// TableContentFixed is implemented by table contents which determine the
+// number of fixed rows and columns themselves, such as header rows. It takes
+// precedence over Table.SetFixed.
+type TableContentFixed interface {
+	// GetFixed returns the number of fixed rows and columns.
+	GetFixed() (rows, columns int)
+}
+
+// TableContentReadOnly implements the methods of TableContent which modify
+// the content as no-ops. Embed it in read-only contents. The cells of tables
+// with such contents cannot be edited.
+type TableContentReadOnly struct{}
+
+// readOnly marks contents which embed TableContentReadOnly.
+func (TableContentReadOnly) readOnly() {}
+
+// SetCell does nothing.
+func (TableContentReadOnly) SetCell(row, column int, cell *TableCell) {}
+
+// RemoveRow does nothing.
+func (TableContentReadOnly) RemoveRow(row int) {}
+
+// RemoveColumn does nothing.
+func (TableContentReadOnly) RemoveColumn(column int) {}
+
+// InsertRow does nothing.
+func (TableContentReadOnly) InsertRow(row int) {}
+
+// InsertColumn does nothing.
+func (TableContentReadOnly) InsertColumn(column int) {}
+
+// Clear does nothing.
+func (TableContentReadOnly) Clear() {}
+
+// tableContent is the default TableContent, which keeps the cells in memory.
+type tableContent struct {
+	// The cells of the table. Rows first, then columns.
+	cells [][]*TableCell
+
+	// The rightmost column in the data set.
+	lastColumn int
+}
+
+func newTableContent() *tableContent {
+	return &tableContent{lastColumn: -1}
+}
+
+// SetCell sets a cell. Rows and columns up to the cell are created as
+// needed.
+func (c *tableContent) SetCell(row, column int, cell *TableCell) {
+	if row >= len(c.cells) {
+		c.cells = append(c.cells, make([][]*TableCell, row-len(c.cells)+1)...)
+	}
+	rowLen := len(c.cells[row])
+	if column >= rowLen {
+		c.cells[row] = append(c.cells[row], make([]*TableCell, column-rowLen+1)...)
+		for col := rowLen; col < column; col++ {
+			c.cells[row][col] = &TableCell{}
+		}
+	}
+	c.cells[row][column] = cell
+	if column > c.lastColumn {
+		c.lastColumn = column
+	}
+}
+
+// GetCell returns the cell at the given position or nil.
+func (c *tableContent) GetCell(row, column int) *TableCell {
+	if row < 0 || column < 0 || row >= len(c.cells) || column >= len(c.cells[row]) {
+		return nil
+	}
+	return c.cells[row][column]
+}
+
+// GetRowCount returns the number of rows.
+func (c *tableContent) GetRowCount() int {
+	return len(c.cells)
+}
+
+// GetColumnCount returns the (maximum) number of columns.
+func (c *tableContent) GetColumnCount() int {
+	if len(c.cells) == 0 {
+		return 0
+	}
+	return c.lastColumn + 1
+}
+
+// RemoveRow removes a row.
+func (c *tableContent) RemoveRow(row int) {
+	if row < 0 || row >= len(c.cells) {
+		return
+	}
+	c.cells = append(c.cells[:row], c.cells[row+1:]...)
+}
+
+// RemoveColumn removes a column.
+func (c *tableContent) RemoveColumn(column int) {
+	for row := range c.cells {
+		if column < 0 || column >= len(c.cells[row]) {
+			continue
+		}
+		c.cells[row] = append(c.cells[row][:column], c.cells[row][column+1:]...)
+	}
+}
+
+// InsertRow inserts a row. Rows at or beyond the end are not inserted.
+func (c *tableContent) InsertRow(row int) {
+	if row >= len(c.cells) {
+		return
+	}
+	c.cells = append(c.cells, nil)       // Extend by one.
+	copy(c.cells[row+1:], c.cells[row:]) // Shift down.
+	c.cells[row] = nil                   // New row is uninitialized.
+}
+
+// InsertColumn inserts a column into the rows which are long enough.
+func (c *tableContent) InsertColumn(column int) {
+	for row := range c.cells {
+		if column >= len(c.cells[row]) {
+			continue
+		}
+		c.cells[row] = append(c.cells[row], nil)             // Extend by one.
+		copy(c.cells[row][column+1:], c.cells[row][column:]) // Shift to the right.
+		c.cells[row][column] = &TableCell{}                  // New element is an uninitialized table cell.
+	}
+}
+
+// Clear removes all cells.
+func (c *tableContent) Clear() {
+	c.cells = nil
+	c.lastColumn = -1
+}
+
+// GetRowKey returns the first cell of the row, which identifies the row while
+// it is sorted.
+func (c *tableContent) GetRowKey(row int) interface{} {
+	if row < 0 || row >= len(c.cells) {
+		return nil
+	}
+	for _, cell := range c.cells[row] {
+		if cell != nil {
+			return cell
+		}
+	}
+	return nil
+}
+
+// Sort sorts the rows below the fixed rows. Without a less function, the
+// texts are compared case-sensitively.
+func (c *tableContent) Sort(column int, descending bool, fixedRows int, less func(column, i, j int) bool) {
+	if len(c.cells) == 0 || column < 0 || column >= len(c.cells[0]) {
+		return
+	}
+
+	if less == nil {
+		text := func(row int) []byte {
+			if cell := c.GetCell(row, column); cell != nil {
+				return cell.GetBytes()
+			}
+			return nil
+		}
+		less = func(column, i, j int) bool {
+			return bytes.Compare(text(i), text(j)) == -1
+		}
+	}
+
+	sort.SliceStable(c.cells, func(i, j int) bool {
+		if i < fixedRows {
+			return i < j
+		} else if j < fixedRows {
+			return j > i
+		}
+
+		if !descending {
+			return less(column, i, j)
+		}
+		return less(column, j, i)
+	})
+}
+
+// TableColumn describes a column of a Table whose columns are set with
+// Table.SetColumns. It shows a column of the content below a header cell and
+// constrains its width. Users may resize, move and hide columns, which
+// changes the fields of their TableColumn.
+type TableColumn struct {
+	// The column of the content shown in this column.
+	Index int
+
+	// The header cell drawn above the rows, or nil if there is no header.
+	Header *TableCell
+
+	// The minimum width of the column in screen space.
+	MinWidth int
+
+	// The maximum width of the column in screen space. Set to 0 if there is
+	// no maximum width.
+	MaxWidth int
+
+	// The width of the column in screen space. Set to 0 to fit the column to
+	// its cells. Resizing the column with the mouse sets it.
+	Width int
+
+	// If greater than 0, the proportion of free space given to the column,
+	// instead of the greatest Expansion of its cells.
+	Expansion int
+
+	// If set to true, the column is not drawn.
+	Hidden bool
+}
+
+// NewTableColumn returns a new table column showing the column of the
+// content at the given index, with a header cell of the given text. No
+// header cell is created if the text is empty.
+func NewTableColumn(index int, header string) *TableColumn {
+	c := &TableColumn{Index: index}
+	if header != "" {
+		c.Header = NewTableCell(header).SetAttributes(tcell.AttrBold)
+	}
+	return c
+}
+
+// SetHeader sets the header cell of the column.
+func (c *TableColumn) SetHeader(header *TableCell) *TableColumn {
+	c.Header = header
+	return c
+}
+
+// SetMinWidth sets the minimum width of the column.
+func (c *TableColumn) SetMinWidth(minWidth int) *TableColumn {
+	c.MinWidth = minWidth
+	return c
+}
+
+// SetMaxWidth sets the maximum width of the column. Set to 0 if there is no
+// maximum width.
+func (c *TableColumn) SetMaxWidth(maxWidth int) *TableColumn {
+	c.MaxWidth = maxWidth
+	return c
+}
+
+// SetWidth sets the fixed width of the column. Set to 0 to fit the column to
+// its cells.
+func (c *TableColumn) SetWidth(width int) *TableColumn {
+	c.Width = width
+	return c
+}
+
+// SetExpansion sets the proportion of free space given to the column. See
+// TableCell.SetExpansion for details.
+func (c *TableColumn) SetExpansion(expansion int) *TableColumn {
+	c.Expansion = expansion
+	return c
+}
+
+// SetHidden sets whether the column is hidden.
+func (c *TableColumn) SetHidden(hidden bool) *TableColumn {
+	c.Hidden = hidden
+	return c
+}
+
+// clamp limits a width of the column to its minimum and maximum width.
+func (c *TableColumn) clamp(width int) int {
+	if c.MaxWidth > 0 && width > c.MaxWidth {
+		width = c.MaxWidth
+	}
+	if width < c.MinWidth {
+		width = c.MinWidth
+	}
+	if width < 1 {
+		width = 1
+	}
+	return width
+}
+
+// TableLayout is the arrangement of the columns of a Table, as returned by
+// Table.GetLayout. It can be encoded as JSON and restored with
+// Table.SetLayout, so users keep their layout across runs.
+type TableLayout struct {
+	// The columns in the order they are shown.
+	Columns []TableColumnLayout `json:"columns"`
+
+	// Whether the table is sorted.
+	Sorted bool `json:"sorted,omitempty"`
+
+	// The column of the content the table is sorted by, if it is sorted.
+	SortColumn int `json:"sort_column,omitempty"`
+
+	// Whether the table is sorted in descending order.
+	SortDescending bool `json:"sort_descending,omitempty"`
+}
+
+// TableColumnLayout is the layout of a single column of a TableLayout.
+type TableColumnLayout struct {
+	// The column of the content.
+	Index int `json:"index"`
+
+	// The width set by the user, or 0 if the column fits its cells.
+	Width int `json:"width,omitempty"`
+
+	// Whether the column is hidden.
+	Hidden bool `json:"hidden,omitempty"`
+}
+
 // Table visualizes two-dimensional data consisting of rows and columns. Each
 // Table cell is defined via SetCell() by the TableCell type. They can be added
 // dynamically to the table and changed any time.
 //
+// Cells are kept in memory by default. For large data sets, provide the cells
+// on demand with a TableContent set via SetContent(). Only the visible cells
+// are then requested when drawing.
+//
 // Each row of the table must have the same number of columns when it is drawn
 // or navigated. This isn't strictly enforced, however you may encounter issues
 // when navigating a table with rows of varied column sizes.
@@ -269,6 +664,26 @@ func (c *TableCell) GetLastPosition() (x, y, width int) {
 // rows and columns). When there is a selection, the user moves the selection.
 // The class will attempt to keep the selection from moving out of the screen.
 //
+// # Editing
+//
+// If cells are selectable and editing is enabled via SetEditable(), pressing
+// Enter or typing a character opens an editor over the selected cell. The
+// editor of a column is set with SetColumnEditor() and may be an Input (the
+// default), a DropDown or a CheckBox. Enter commits the new text, Escape
+// cancels. Committed text is checked by the function set with
+// SetCellValidateFunc() and reported to the one set with SetCellChangedFunc().
+//
+// # Columns
+//
+// By default, the columns of the content are shown as they are. A column
+// model set via SetColumns() shows the columns of the content in any order,
+// below a header row, and constrains their widths. The user can then resize
+// a column by dragging the border to the right of its header, move it by
+// dragging its header or with Alt+Left and Alt+Right, and show or hide
+// columns in the menu opened by right-clicking the header or pressing
+// Alt+Enter. GetLayout() returns the widths, order, visibility and sort order
+// of the columns, which SetLayout() restores.
+//
 // Use SetInputCapture() to override or modify keyboard input.
 type Table struct {
 	box *Box
@@ -282,17 +697,14 @@ type Table struct {
 	// If there are no borders, the column separator.
 	separator rune
 
-	// The cells of the table. Rows first, then columns.
-	cells [][]*TableCell
-
-	// The rightmost column in the data set.
-	lastColumn int
+	// The content of the table.
+	content TableContent
 
 	// If true, when calculating the widths of the columns, all rows are evaluated
 	// instead of only the visible ones.
 	evaluateAllRows bool
 
-	// The number of fixed rows / columns.
+	// The number of fixed rows / columns, unless the content determines them.
 	fixedRows, fixedColumns int
 
 	// Whether or not rows or columns can be selected. If both are set to true,
@@ -355,6 +767,91 @@ type Table struct {
 	// or Backtab. Also when the user presses Enter if nothing is selectable.
 	done func(key tcell.Key)
 
+	// Whether the selected cell can be edited.
+	editable bool
+
+	// The editors of the columns. Other columns are edited with defaultEditor.
+	columnEditors map[int]Widget
+
+	// The editor of columns without their own editor.
+	defaultEditor *Input
+
+	// The editor of the cell being edited, or nil if no cell is edited.
+	editor Widget
+
+	// The position of the cell being edited.
+	editRow, editColumn int
+
+	// The screen position and width of the cell being edited as of the last
+	// time the table was drawn. The width is 0 if the cell was not visible.
+	editX, editY, editWidth int
+
+	// Returns the text in the editor.
+	editValue func() string
+
+	// Returns the focus from the editor to the table.
+	editFocus func(p Widget)
+
+	// An optional function which checks the text of an edited cell before it
+	// is committed.
+	validate func(row, column int, text string) bool
+
+	// An optional function which gets called when an edit of a cell was
+	// committed.
+	cellChanged func(row, column int, cell *TableCell)
+
+	// The columns in the order they are shown, or nil if the columns of the
+	// content are shown as they are.
+	columns []*TableColumn
+
+	// Whether the table was sorted by sortClickedColumn.
+	sorted bool
+
+	// The column being resized with the mouse and the screen position of its
+	// left edge.
+	resizeColumn *TableColumn
+	resizeX      int
+
+	// The column being moved with the mouse.
+	moveColumn *TableColumn
+
+	// Whether the column being resized or moved has changed.
+	dragged bool
+
+	// The menu which shows or hides columns.
+	columnMenu *ContextMenu
+
+	// An optional function which gets called when the user changed the layout
+	// of the columns.
+	layoutChanged func(layout TableLayout)
+
+	// Whether the user may select several rows.
+	multiSelect bool
+
+	// The rows selected by the user if multi-selection is enabled.
+	selectedRows map[int]bool
+
+	// The row at which ranges selected with the Shift key start (or -1), and
+	// the selection before the range was selected.
+	anchorRow      int
+	rangeSelection map[int]bool
+
+	// An optional function which gets called when the user changed the
+	// selected rows.
+	selectedRowsChanged func(rows []int)
+
+	// Whether the user may drag rows with the mouse.
+	draggable bool
+
+	// Optional functions which decide whether a payload may be dropped before
+	// the given row and drop it. Without them, rows dragged from this table
+	// are moved.
+	acceptDrop func(payload *DragPayload, row int) bool
+	drop       func(payload *DragPayload, row int)
+
+	// The row before which a dragged payload would be dropped, or -1.
+	dropRow int
+
 	mu sync.RWMutex
 }
 
@@ -367,7 +864,9 @@ func NewTable() *Table {
 		bordersColor:        Styles.GraphicsColor,
 		separator:           ' ',
 		sortClicked:         true,
-		lastColumn:          -1,
+		content:             newTableContent(),
+		anchorRow:           -1,
+		dropRow:             -1,
 	}
 }
 
@@ -581,11 +1080,34 @@ func (t *Table) SetVisible(visible bool) {
 
 // Focus is called when this Table receives focus.
 func (t *Table) Focus(delegate func(p Widget)) {
+	t.mu.RLock()
+	editor, menu := t.editor, t.columnMenu
+	t.mu.RUnlock()
+
+	if editor != nil {
+		delegate(editor)
+		return
+	}
+	if menu != nil && menu.open {
+		delegate(menu.list)
+		return
+	}
 	t.box.Focus(delegate)
 }
 
-// HasFocus returns whether this Table has focus.
+// HasFocus returns whether this Table, the editor of a cell or the column
+// menu has focus.
 func (t *Table) HasFocus() bool {
+	t.mu.RLock()
+	editor, menu := t.editor, t.columnMenu
+	t.mu.RUnlock()
+
+	if editor != nil && editor.GetFocusable().HasFocus() {
+		return true
+	}
+	if menu != nil && menu.open && menu.list.HasFocus() {
+		return true
+	}
 	return t.box.HasFocus()
 }
 
@@ -606,8 +1128,30 @@ func (t *Table) Clear() {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	t.cells = nil
-	t.lastColumn = -1
+	t.content.Clear()
+	t.selectedRows, t.anchorRow, t.rangeSelection = nil, -1, nil
+}
+
+// SetContent sets the content of the table, which provides its cells. Setting
+// nil restores the default content, which keeps the cells in memory. The
+// selection and offsets are kept.
+func (t *Table) SetContent(content TableContent) *Table {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if content == nil {
+		content = newTableContent()
+	}
+	t.content = content
+	return t
+}
+
+// GetContent returns the content of the table.
+func (t *Table) GetContent() TableContent {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.content
 }
 
 // SetBorders sets whether or not each cell in the table is surrounded by a
@@ -679,7 +1223,8 @@ func (t *Table) SetSeparator(separator rune) *Table {
 
 // SetFixed sets the number of fixed rows and columns which are always visible
 // even when the rest of the cells are scrolled out of view. Rows are always the
-// top-most ones. Columns are always the left-most ones.
+// top-most ones. Columns are always the left-most ones. This is ignored if the
+// content implements TableContentFixed.
 func (t *Table) SetFixed(rows, columns int) *Table {
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -688,6 +1233,23 @@ func (t *Table) SetFixed(rows, columns int) *Table {
 	return t
 }
 
+// GetFixed returns the number of fixed rows and columns.
+func (t *Table) GetFixed() (rows, columns int) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.fixed()
+}
+
+// fixed returns the number of fixed rows and columns, which the content may
+// determine.
+func (t *Table) fixed() (rows, columns int) {
+	if fixed, ok := t.content.(TableContentFixed); ok {
+		return fixed.GetFixed()
+	}
+	return t.fixedRows, t.fixedColumns
+}
+
 // SetSelectable sets the flags which determine what can be selected in a table.
 // There are three selection modi:
 //
@@ -769,7 +1331,8 @@ func (t *Table) GetOffset() (row, column int) {
 // are evaluated. When true, all rows in the table are evaluated.
 //
 // Set this flag to true to avoid shifting column widths when the table is
-// scrolled. (May be slower for large tables.)
+// scrolled. (May be slower for large tables, as every cell of the content is
+// requested on each draw.)
 func (t *Table) SetEvaluateAllRows(all bool) *Table {
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -802,67 +1365,650 @@ func (t *Table) SetSelectionChangedFunc(handler func(row, column int)) *Table {
 	return t
 }
 
-// SetDoneFunc sets a handler which is called whenever the user presses the
-// Escape, Tab, or Backtab key. If nothing is selected, it is also called when
-// user presses the Enter key (because pressing Enter on a selection triggers
-// the "selected" handler set via SetSelectedFunc()).
-func (t *Table) SetDoneFunc(handler func(key tcell.Key)) *Table {
+// SetMultiSelect sets whether the user may select several rows. Rows must be
+// selectable (see SetSelectable()). If enabled, the following keys change the
+// selected rows:
+//
+//   - Space: Toggle the selection of the current row
+//   - Shift+Up, Shift+Down: Select the rows between the current row and the
+//     row last toggled or moved to
+//   - Ctrl+A: Select all rows except the fixed rows
+//
+// Clicking a row with the Ctrl key pressed toggles its selection, clicking it
+// with the Shift key pressed selects a range of rows. The selected rows stay
+// selected when the table is sorted if the content implements
+// TableContentRowKeys, which the default content does. Disabling
+// multi-selection clears the selected rows.
+func (t *Table) SetMultiSelect(multiSelect bool) *Table {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	t.done = handler
+	t.multiSelect = multiSelect
+	if !multiSelect {
+		t.selectedRows, t.anchorRow, t.rangeSelection = nil, -1, nil
+	}
 	return t
 }
 
-// SetCell sets the content of a cell the specified position. It is ok to
-// directly instantiate a TableCell object. If the cell has content, at least
-// the Text and Color fields should be set.
-//
-// Note that setting cells in previously unknown rows and columns will
-// automatically extend the internal table representation, e.g. starting with
-// a row of 100,000 will immediately create 100,000 empty rows.
-//
-// To avoid unnecessary garbage collection, fill columns from left to right.
-func (t *Table) SetCell(row, column int, cell *TableCell) *Table {
+// SetRowSelected sets whether the row with the given index is selected.
+func (t *Table) SetRowSelected(row int, selected bool) *Table {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	if row >= len(t.cells) {
-		t.cells = append(t.cells, make([][]*TableCell, row-len(t.cells)+1)...)
-	}
-	rowLen := len(t.cells[row])
-	if column >= rowLen {
-		t.cells[row] = append(t.cells[row], make([]*TableCell, column-rowLen+1)...)
-		for c := rowLen; c < column; c++ {
-			t.cells[row][c] = &TableCell{}
-		}
-	}
-	t.cells[row][column] = cell
-	if column > t.lastColumn {
-		t.lastColumn = column
-	}
+	t.selectRow(row, selected)
+	t.anchorRow, t.rangeSelection = row, nil
 	return t
 }
 
-// SetCellSimple calls SetCell() with the given text, left-aligned, in white.
-func (t *Table) SetCellSimple(row, column int, text string) *Table {
-	t.SetCell(row, column, NewTableCell(text))
-	return t
+// IsRowSelected returns whether the row with the given index is selected.
+func (t *Table) IsRowSelected(row int) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.selectedRows[row]
 }
 
-// GetCell returns the contents of the cell at the specified position. A valid
-// TableCell object is always returned but it will be uninitialized if the cell
-// was not previously set. Such an uninitialized object will not automatically
-// be inserted. Therefore, repeated calls to this function may return different
-// pointers for uninitialized cells.
-func (t *Table) GetCell(row, column int) *TableCell {
+// GetSelectedRows returns the indices of the selected rows in ascending order.
+func (t *Table) GetSelectedRows() []int {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
 
-	if row >= len(t.cells) || column >= len(t.cells[row]) {
-		return &TableCell{}
-	}
-	return t.cells[row][column]
+	return t.getSelectedRows()
+}
+
+// ClearSelection deselects all rows.
+func (t *Table) ClearSelection() *Table {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.selectedRows, t.anchorRow, t.rangeSelection = nil, -1, nil
+	return t
+}
+
+// SetSelectedRowsChangedFunc sets a handler which is called whenever the user
+// changes the selected rows (see SetMultiSelect()). The handler receives the
+// indices of the selected rows in ascending order.
+func (t *Table) SetSelectedRowsChangedFunc(handler func(rows []int)) *Table {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.selectedRowsChanged = handler
+	return t
+}
+
+// getSelectedRows returns the indices of the selected rows in ascending order.
+func (t *Table) getSelectedRows() []int {
+	rows := make([]int, 0, len(t.selectedRows))
+	for row := range t.selectedRows {
+		rows = append(rows, row)
+	}
+	sort.Ints(rows)
+	return rows
+}
+
+// selectRow selects or deselects a row.
+func (t *Table) selectRow(row int, selected bool) {
+	if !selected {
+		delete(t.selectedRows, row)
+		return
+	}
+	if t.selectedRows == nil {
+		t.selectedRows = make(map[int]bool)
+	}
+	t.selectedRows[row] = true
+}
+
+// selectRowRange selects the rows between the anchor and the current row in
+// addition to the rows selected before the range.
+func (t *Table) selectRowRange() {
+	if t.rangeSelection == nil {
+		t.rangeSelection = make(map[int]bool)
+		for row := range t.selectedRows {
+			t.rangeSelection[row] = true
+		}
+	}
+	from, to := t.anchorRow, t.selectedRow
+	if from > to {
+		from, to = to, from
+	}
+
+	t.selectedRows = make(map[int]bool)
+	for row := range t.rangeSelection {
+		t.selectedRows[row] = true
+	}
+	for row := from; row <= to; row++ {
+		t.selectedRows[row] = true
+	}
+}
+
+// shiftSelectedRows moves the selected rows at or below the given row by the
+// given number of rows, after a row was inserted or removed.
+func (t *Table) shiftSelectedRows(from, delta int) {
+	if len(t.selectedRows) == 0 {
+		return
+	}
+	rows := make(map[int]bool)
+	for row := range t.selectedRows {
+		if row < from {
+			rows[row] = true
+		} else if row+delta >= from {
+			rows[row+delta] = true
+		}
+	}
+	t.selectedRows, t.anchorRow, t.rangeSelection = rows, -1, nil
+}
+
+// firstCell returns the first cell of a row.
+func (t *Table) firstCell(row int) *TableCell {
+	for column := 0; column < t.content.GetColumnCount(); column++ {
+		if cell := t.content.GetCell(row, column); cell != nil {
+			return cell
+		}
+	}
+	return nil
+}
+
+// MoveRow moves the row at index "from" to index "to". The cells are moved with
+// SetCell() of the content. Fixed rows should not be moved.
+func (t *Table) MoveRow(from, to int) *Table {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	before := to
+	if to > from {
+		before++
+	}
+	t.moveRows([]int{from}, before)
+	return t
+}
+
+// moveRows moves the given rows before the row with the given index, keeping
+// their order. The selection follows the moved rows.
+func (t *Table) moveRows(rows []int, before int) {
+	rowCount := t.content.GetRowCount()
+	if before > rowCount {
+		before = rowCount
+	}
+	moved := make(map[int]bool)
+	low, high := before, before-1
+	for _, row := range rows {
+		if row < 0 || row >= rowCount {
+			continue
+		}
+		moved[row] = true
+		if row < low {
+			low = row
+		}
+		if row > high {
+			high = row
+		}
+	}
+	if len(moved) == 0 {
+		return
+	}
+
+	// The previous indices of the rows from "low" to "high" in their new
+	// order.
+	var kept, order []int
+	for row := low; row <= high; row++ {
+		if moved[row] {
+			kept = append(kept, row)
+		}
+	}
+	for row := low; row <= high; row++ {
+		if row == before {
+			order = append(order, kept...)
+		}
+		if !moved[row] {
+			order = append(order, row)
+		}
+	}
+	if before > high {
+		order = append(order, kept...)
+	}
+
+	// Move the cells.
+	columnCount := t.content.GetColumnCount()
+	cells := make([][]*TableCell, len(order))
+	for index, row := range order {
+		cells[index] = make([]*TableCell, columnCount)
+		for column := range cells[index] {
+			cells[index][column] = t.content.GetCell(row, column)
+		}
+	}
+	for index := range order {
+		for column, cell := range cells[index] {
+			t.content.SetCell(low+index, column, cell)
+		}
+	}
+
+	// The selection follows the rows.
+	moves := make(map[int]int, len(order))
+	for index, row := range order {
+		moves[row] = low + index
+	}
+	if row, ok := moves[t.selectedRow]; ok {
+		t.selectedRow = row
+	}
+	if len(t.selectedRows) > 0 {
+		selected := make(map[int]bool, len(t.selectedRows))
+		for row := range t.selectedRows {
+			if moved, ok := moves[row]; ok {
+				row = moved
+			}
+			selected[row] = true
+		}
+		t.selectedRows, t.anchorRow, t.rangeSelection = selected, -1, nil
+	}
+}
+
+// SetDraggable sets whether the user may drag rows below the fixed rows with
+// the mouse. The payload holds the index of the dragged row, or the indices
+// of the selected rows if the dragged row is one of them (see
+// SetMultiSelect()). Unless a drop function is set with SetDropFunc(),
+// dropping the rows onto the table moves them.
+func (t *Table) SetDraggable(draggable bool) *Table {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.draggable = draggable
+	return t
+}
+
+// SetDropFunc sets the functions which decide whether a payload dragged with
+// the mouse may be dropped before the given row and drop it. A row of
+// GetRowCount() drops the payload after the last row. Without them, the table
+// accepts the rows dragged from itself and moves them.
+func (t *Table) SetDropFunc(accept func(payload *DragPayload, row int) bool, drop func(payload *DragPayload, row int)) *Table {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.acceptDrop, t.drop = accept, drop
+	return t
+}
+
+// DragStart returns the payload of the row at the given screen position. It
+// implements DragSource.
+func (t *Table) DragStart(x, y int) *DragPayload {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	fixedRows, _ := t.fixed()
+	if !t.draggable || t.editor != nil || t.headerAt(y) {
+		return nil
+	}
+	row, _ := t.cellAt(x, y)
+	if row < fixedRows {
+		return nil
+	}
+	rows := []int{row}
+	if t.multiSelect && t.selectedRows[row] {
+		rows = t.getSelectedRows()
+	}
+	var label string
+	if cell := t.firstCell(row); cell != nil {
+		label = string(StripTags(cell.GetBytes(), true, false))
+	}
+	if len(rows) > 1 {
+		label = fmt.Sprintf("%d rows", len(rows))
+	}
+	return &DragPayload{Kind: DragKindTableRows, Data: rows, Label: label}
+}
+
+// DragEnd is called when dragging rows of the table has ended. It implements
+// DragSource.
+func (t *Table) DragEnd(payload *DragPayload, dropped bool) {}
+
+// dropRowAt returns the row before which a payload would be dropped at the
+// given screen position, or -1 if there is none.
+func (t *Table) dropRowAt(x, y int) int {
+	if t.editor != nil || !t.InRect(x, y) || t.headerAt(y) {
+		return -1
+	}
+	row, _ := t.cellAt(x, y)
+	if row < 0 {
+		return t.content.GetRowCount() // Below the last row.
+	}
+	if fixedRows, _ := t.fixed(); row < fixedRows {
+		return -1
+	}
+	return row
+}
+
+// acceptsDrop returns whether the payload may be dropped before the given
+// row. Must be called with the table locked.
+func (t *Table) acceptsDrop(payload *DragPayload, row int) bool {
+	if accept := t.acceptDrop; accept != nil {
+		t.mu.Unlock()
+		defer t.mu.Lock()
+		return accept(payload, row)
+	}
+	return payload.Source == t && payload.Kind == DragKindTableRows
+}
+
+// DragOver shows where the payload would be dropped. It implements
+// DropTarget.
+func (t *Table) DragOver(payload *DragPayload, x, y int) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.dropRow = t.dropRowAt(x, y)
+	if t.dropRow >= 0 && !t.acceptsDrop(payload, t.dropRow) {
+		t.dropRow = -1
+	}
+	return t.dropRow >= 0
+}
+
+// DragLeave removes what was shown by DragOver(). It implements DropTarget.
+func (t *Table) DragLeave() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.dropRow = -1
+}
+
+// Drop drops the payload before the row at the given screen position. It
+// implements DropTarget.
+func (t *Table) Drop(payload *DragPayload, x, y int) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	row := t.dropRowAt(x, y)
+	if row < 0 || !t.acceptsDrop(payload, row) {
+		return false
+	}
+	if drop := t.drop; drop != nil {
+		t.mu.Unlock()
+		drop(payload, row)
+		t.mu.Lock()
+	} else if rows, ok := payload.Data.([]int); ok && t.acceptDrop == nil {
+		t.moveRows(rows, row)
+	}
+	return true
+}
+
+// SetDoneFunc sets a handler which is called whenever the user presses the
+// Escape, Tab, or Backtab key. If nothing is selected, it is also called when
+// user presses the Enter key (because pressing Enter on a selection triggers
+// the "selected" handler set via SetSelectedFunc()).
+func (t *Table) SetDoneFunc(handler func(key tcell.Key)) *Table {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.done = handler
+	return t
+}
+
+// SetEditable sets whether the selected cell can be edited. Only individual
+// cells can be edited, so both rows and columns must be selectable (see
+// SetSelectable()). Fixed cells and cells which are not selectable are never
+// edited. While editing, Enter does not trigger the "selected" handler.
+func (t *Table) SetEditable(editable bool) *Table {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.editable = editable
+	return t
+}
+
+// GetEditable returns whether the selected cell can be edited.
+func (t *Table) GetEditable() bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.editable
+}
+
+// SetColumnEditor sets the widget used to edit the cells of a column, which
+// must be one of the following:
+//
+//   - *Input: The cell text is edited. This is the default.
+//   - *DropDown: The option with the cell text is selected and the options
+//     are opened. Selecting an option commits its text.
+//   - *CheckBox: The box is checked if the cell text is "true" (see
+//     strconv.ParseBool()). Toggling it commits "true" or "false".
+//
+// The table installs its own finished function on the editor, as well as the
+// selected function of a DropDown and the changed function of a CheckBox.
+// Setting nil restores the default editor.
+func (t *Table) SetColumnEditor(column int, editor Widget) *Table {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.columnEditors == nil {
+		t.columnEditors = make(map[int]Widget)
+	}
+	if editor == nil {
+		delete(t.columnEditors, column)
+	} else {
+		t.columnEditors[column] = editor
+	}
+	return t
+}
+
+// SetCellValidateFunc sets a handler which is called with the text of an
+// edited cell before it is committed. If it returns false, the text is not
+// committed and the editor stays open.
+func (t *Table) SetCellValidateFunc(handler func(row, column int, text string) bool) *Table {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.validate = handler
+	return t
+}
+
+// SetCellChangedFunc sets a handler which is called when an edit changed the
+// text of a cell. The handler receives the position of the cell and the cell
+// with its new text.
+func (t *Table) SetCellChangedFunc(handler func(row, column int, cell *TableCell)) *Table {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.cellChanged = handler
+	return t
+}
+
+// IsEditing returns whether a cell is being edited, and its position.
+func (t *Table) IsEditing() (editing bool, row, column int) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.editor != nil, t.editRow, t.editColumn
+}
+
+// CancelEdit closes the editor without changing the cell.
+func (t *Table) CancelEdit() {
+	t.mu.Lock()
+	editing := t.editor != nil
+	setFocus := t.editFocus
+	t.editor, t.editValue, t.editFocus = nil, nil, nil
+	t.mu.Unlock()
+
+	if editing && setFocus != nil {
+		setFocus(t)
+	}
+}
+
+// CommitEdit sets the text in the editor as the text of the edited cell and
+// closes the editor. It returns false if no cell is edited or if validation
+// failed, in which case the editor stays open.
+func (t *Table) CommitEdit() bool {
+	t.mu.RLock()
+	editing, row, column, value, validate := t.editor != nil, t.editRow, t.editColumn, t.editValue, t.validate
+	t.mu.RUnlock()
+
+	if !editing {
+		return false
+	}
+	text := value()
+	if validate != nil && !validate(row, column, text) {
+		return false
+	}
+
+	t.mu.Lock()
+	cell := t.content.GetCell(row, column)
+	changed := cell == nil || cell.GetText() != text
+	if changed {
+		if cell == nil {
+			cell = NewTableCell(text)
+		} else {
+			cell = cell.clone().SetText(text)
+		}
+		// Contents may ignore new values.
+		t.content.SetCell(row, column, cell)
+		if stored := t.content.GetCell(row, column); stored == nil || stored.GetText() != text {
+			changed = false
+		}
+	}
+	cellChanged := t.cellChanged
+	t.mu.Unlock()
+
+	t.CancelEdit()
+	if changed && cellChanged != nil {
+		cellChanged(row, column, cell)
+	}
+	return true
+}
+
+// startEdit opens the editor of the selected cell and focuses it, unless the
+// cell cannot be edited. The table must be locked.
+func (t *Table) startEdit(event *tcell.EventKey, setFocus func(p Widget)) bool {
+	editor, forward := t.edit(event, setFocus)
+	if editor == nil {
+		return false
+	}
+	t.mu.Unlock()
+	setFocus(editor)
+	if forward {
+		editor.InputHandler()(event, setFocus)
+	}
+	t.mu.Lock()
+	return true
+}
+
+// edit opens the editor of the selected cell, unless it cannot be edited. It
+// returns whether the key which started editing should be passed on to the
+// editor.
+func (t *Table) edit(event *tcell.EventKey, setFocus func(p Widget)) (editor Widget, forward bool) {
+	row, column := t.selectedRow, t.selectedColumn
+	fixedRows, fixedColumns := t.fixed()
+	if _, readOnly := t.content.(interface{ readOnly() }); readOnly ||
+		!t.editable || !t.rowsSelectable || !t.columnsSelectable ||
+		row < fixedRows || row >= t.content.GetRowCount() || column < fixedColumns || column >= t.content.GetColumnCount() {
+		return nil, false
+	}
+	var text string
+	if cell := t.content.GetCell(row, column); cell != nil {
+		if cell.NotSelectable {
+			return nil, false
+		}
+		text = cell.GetText()
+	}
+
+	editor = t.columnEditors[column]
+	if editor == nil {
+		if t.defaultEditor == nil {
+			t.defaultEditor = NewInputField()
+		}
+		editor = t.defaultEditor
+	}
+	finished := func(key tcell.Key) {
+		if key == tcell.KeyEscape {
+			t.CancelEdit()
+		} else {
+			t.CommitEdit()
+		}
+	}
+	forward = true
+	switch e := editor.(type) {
+	case *Input:
+		if event.Key() == tcell.KeyRune {
+			text = "" // Typing replaces the text.
+		} else {
+			forward = false // Enter would commit right away.
+		}
+		e.SetText(text)
+		e.SetFinishedFunc(finished)
+		t.editValue = e.GetText
+	case *DropDown:
+		e.SetSelectedFunc(nil)
+		index := -1
+		for i := 0; i < e.GetOptionCount(); i++ {
+			if e.GetOption(i).GetText() == text {
+				index = i
+				break
+			}
+		}
+		e.SetCurrentOption(index)
+		e.SetSelectedFunc(func(int, *DropDownOption) { t.CommitEdit() })
+		e.SetFinishedFunc(finished)
+		t.editValue = func() string {
+			if _, option := e.GetCurrentOption(); option != nil {
+				return option.GetText()
+			}
+			return ""
+		}
+	case *CheckBox:
+		checked, _ := strconv.ParseBool(text)
+		e.SetChecked(checked)
+		e.SetChangedFunc(func(bool) { t.CommitEdit() })
+		e.SetFinishedFunc(finished)
+		t.editValue = func() string { return strconv.FormatBool(e.IsChecked()) }
+	default:
+		return nil, false
+	}
+
+	t.editor, t.editRow, t.editColumn, t.editFocus = editor, row, column, setFocus
+	t.editWidth = 0
+	return editor, forward
+}
+
+// drawEditor draws the editor over the cell being edited if it is visible.
+func (t *Table) drawEditor(screen tcell.Screen) {
+	if t.editor == nil || t.editWidth <= 0 {
+		return
+	}
+	t.editor.SetRect(t.editX, t.editY, t.editWidth, 1)
+	t.editor.Draw(screen)
+}
+
+// SetCell sets the content of a cell the specified position. It is ok to
+// directly instantiate a TableCell object. If the cell has content, at least
+// the Text and Color fields should be set.
+//
+// Note that setting cells in previously unknown rows and columns will
+// automatically extend the internal table representation, e.g. starting with
+// a row of 100,000 will immediately create 100,000 empty rows.
+//
+// To avoid unnecessary garbage collection, fill columns from left to right.
+func (t *Table) SetCell(row, column int, cell *TableCell) *Table {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.content.SetCell(row, column, cell)
+	return t
+}
+
+// SetCellSimple calls SetCell() with the given text, left-aligned, in white.
+func (t *Table) SetCellSimple(row, column int, text string) *Table {
+	t.SetCell(row, column, NewTableCell(text))
+	return t
+}
+
+// GetCell returns the contents of the cell at the specified position. A valid
+// TableCell object is always returned but it will be uninitialized if the cell
+// was not previously set. Such an uninitialized object will not automatically
+// be inserted. Therefore, repeated calls to this function may return different
+// pointers for uninitialized cells.
+func (t *Table) GetCell(row, column int) *TableCell {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	cell := t.content.GetCell(row, column)
+	if cell == nil {
+		return &TableCell{}
+	}
+	return cell
 }
 
 // RemoveRow removes the row at the given position from the table. If there is
@@ -871,77 +2017,428 @@ func (t *Table) RemoveRow(row int) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	if row < 0 || row >= len(t.cells) {
-		return
+	t.content.RemoveRow(row)
+	t.shiftSelectedRows(row, -1)
+}
+
+// RemoveColumn removes the column at the given position from the table. If
+// there is no such column, this has no effect.
+func (t *Table) RemoveColumn(column int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.content.RemoveColumn(column)
+}
+
+// InsertRow inserts a row before the row with the given index. Cells on the
+// given row and below will be shifted to the bottom by one row. If "row" is
+// equal or larger than the current number of rows, this function has no effect.
+func (t *Table) InsertRow(row int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.content.InsertRow(row)
+	t.shiftSelectedRows(row, 1)
+}
+
+// InsertColumn inserts a column before the column with the given index. Cells
+// in the given column and to its right will be shifted to the right by one
+// column. Rows that have fewer initialized cells than "column" will remain
+// unchanged.
+func (t *Table) InsertColumn(column int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.content.InsertColumn(column)
+}
+
+// GetRowCount returns the number of rows in the table.
+func (t *Table) GetRowCount() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.content.GetRowCount()
+}
+
+// GetColumnCount returns the (maximum) number of columns in the table.
+func (t *Table) GetColumnCount() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.content.GetColumnCount()
+}
+
+// SetColumns sets the column model of the table: the columns are shown in the
+// given order, below a header row if any column has a header cell. Call it
+// without columns to show the columns of the content as they are. Rows and
+// columns of the selection and of all other methods still refer to the
+// content.
+func (t *Table) SetColumns(columns ...*TableColumn) *Table {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.columns = nil
+	if len(columns) > 0 {
+		t.columns = append([]*TableColumn(nil), columns...)
+	}
+	return t
+}
+
+// GetColumns returns the columns set with SetColumns in the order they are
+// shown, including hidden columns.
+func (t *Table) GetColumns() []*TableColumn {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return append([]*TableColumn(nil), t.columns...)
+}
+
+// MoveColumn moves the column at the given position of GetColumns to another
+// position.
+func (t *Table) MoveColumn(from, to int) *Table {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.move(from, to)
+	return t
+}
+
+// move moves a column of the column model and returns whether it was moved.
+func (t *Table) move(from, to int) bool {
+	if from < 0 || from >= len(t.columns) || to < 0 || to >= len(t.columns) || from == to {
+		return false
 	}
+	column := t.columns[from]
+	t.columns = append(t.columns[:from], t.columns[from+1:]...)
+	t.columns = append(t.columns[:to], append([]*TableColumn{column}, t.columns[to:]...)...)
+	return true
+}
+
+// GetLayout returns the widths, order and visibility of the columns set with
+// SetColumns and the sort order of the table.
+func (t *Table) GetLayout() TableLayout {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
 
-	t.cells = append(t.cells[:row], t.cells[row+1:]...)
+	return t.layout()
 }
 
-// RemoveColumn removes the column at the given position from the table. If
-// there is no such column, this has no effect.
-func (t *Table) RemoveColumn(column int) {
+func (t *Table) layout() TableLayout {
+	layout := TableLayout{
+		Columns: make([]TableColumnLayout, 0, len(t.columns)),
+	}
+	if t.sorted {
+		layout.Sorted = true
+		layout.SortColumn, layout.SortDescending = t.sortClickedColumn, t.sortClickedDescending
+	}
+	for _, column := range t.columns {
+		layout.Columns = append(layout.Columns, TableColumnLayout{
+			Index:  column.Index,
+			Width:  column.Width,
+			Hidden: column.Hidden,
+		})
+	}
+	return layout
+}
+
+// SetLayout restores a layout returned by GetLayout. Columns of the layout
+// which are not in the column model are ignored, columns of the model which
+// are not in the layout are shown after the others. Widths are limited to the
+// minimum and maximum widths of the columns. The table is sorted again if it
+// was sorted.
+func (t *Table) SetLayout(layout TableLayout) *Table {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	for row := range t.cells {
-		if column < 0 || column >= len(t.cells[row]) {
-			continue
+	if t.columns != nil {
+		columns := make([]*TableColumn, 0, len(t.columns))
+		restored := make(map[*TableColumn]bool)
+		for _, entry := range layout.Columns {
+			for _, column := range t.columns {
+				if column.Index == entry.Index && !restored[column] {
+					column.Width, column.Hidden = 0, entry.Hidden
+					if entry.Width > 0 {
+						column.Width = column.clamp(entry.Width)
+					}
+					columns = append(columns, column)
+					restored[column] = true
+					break
+				}
+			}
 		}
-		t.cells[row] = append(t.cells[row][:column], t.cells[row][column+1:]...)
+		for _, column := range t.columns {
+			if !restored[column] {
+				columns = append(columns, column)
+			}
+		}
+		t.columns = columns
 	}
+	if layout.Sorted {
+		t.sort(layout.SortColumn, layout.SortDescending)
+	}
+	return t
 }
 
-// InsertRow inserts a row before the row with the given index. Cells on the
-// given row and below will be shifted to the bottom by one row. If "row" is
-// equal or larger than the current number of rows, this function has no effect.
-func (t *Table) InsertRow(row int) {
+// SetLayoutChangedFunc sets a handler which is called when the user resizes,
+// moves, shows or hides a column or sorts the table by clicking it. The
+// handler receives the new layout, to be stored and restored with SetLayout.
+func (t *Table) SetLayoutChangedFunc(handler func(layout TableLayout)) *Table {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	if row >= len(t.cells) {
+	t.layoutChanged = handler
+	return t
+}
+
+// notifyLayout calls the layout changed handler. The table must not be
+// locked.
+func (t *Table) notifyLayout() {
+	t.mu.RLock()
+	handler, layout := t.layoutChanged, t.layout()
+	t.mu.RUnlock()
+
+	if handler != nil {
+		handler(layout)
+	}
+}
+
+// shownColumns returns the columns of the column model which are not hidden.
+func (t *Table) shownColumns() []*TableColumn {
+	shown := make([]*TableColumn, 0, len(t.columns))
+	for _, column := range t.columns {
+		if !column.Hidden {
+			shown = append(shown, column)
+		}
+	}
+	return shown
+}
+
+// showHeader returns whether the header row of the column model is drawn.
+func (t *Table) showHeader() bool {
+	for _, column := range t.columns {
+		if column.Header != nil && !column.Hidden {
+			return true
+		}
+	}
+	return false
+}
+
+// headerCell returns the header cell of a column, followed by the sort
+// direction if the table is sorted by the column.
+func (t *Table) headerCell(column *TableColumn) *TableCell {
+	header := column.Header
+	if header == nil || !t.sorted || t.sortClickedColumn != column.Index {
+		return header
+	}
+	symbol := Styles.TableSortAscendingSymbol
+	if t.sortClickedDescending {
+		symbol = Styles.TableSortDescendingSymbol
+	}
+	return &TableCell{
+		Text:            append(append(append([]byte(nil), header.Text...), ' '), string(symbol)...),
+		Align:           header.Align,
+		Color:           header.Color,
+		BackgroundColor: header.BackgroundColor,
+		Attributes:      header.Attributes,
+		NotSelectable:   true,
+	}
+}
+
+// headerAt returns whether the header row is drawn at the given screen row.
+func (t *Table) headerAt(y int) bool {
+	if !t.showHeader() {
+		return false
+	}
+	_, rectY, _, _ := t.GetInnerRect()
+	if t.borders {
+		return y == rectY || y == rectY+1
+	}
+	return y == rectY
+}
+
+// columnAt returns the column of the column model drawn at the given screen
+// column, the screen column of its left edge and whether x is on its right
+// border. The column is nil if there is none.
+func (t *Table) columnAt(x int) (column *TableColumn, left int, border bool) {
+	rectX, _, _, _ := t.GetInnerRect()
+	columnX := rectX
+	if t.borders {
+		columnX++
+	}
+	for index, width := range t.visibleColumnWidths {
+		left = columnX
+		columnX += width + 1
+		if x < columnX {
+			for _, column := range t.columns {
+				if column.Index == t.visibleColumnIndices[index] && !column.Hidden {
+					return column, left, x == columnX-1
+				}
+			}
+			break
+		}
+	}
+	return nil, 0, false
+}
+
+// moveSelected moves the column of the selected cell by one position to the
+// left or right, past hidden columns, and returns whether it was moved.
+func (t *Table) moveSelected(right bool) bool {
+	if !t.columnsSelectable {
+		return false
+	}
+	from := -1
+	for index, column := range t.columns {
+		if column.Index == t.selectedColumn && !column.Hidden {
+			from = index
+			break
+		}
+	}
+	if from < 0 {
+		return false
+	}
+	step := -1
+	if right {
+		step = 1
+	}
+	for to := from + step; to >= 0 && to < len(t.columns); to += step {
+		if !t.columns[to].Hidden {
+			return t.move(from, to)
+		}
+	}
+	return false
+}
+
+// dragColumn resizes or moves the column dragged with the mouse to the given
+// screen column.
+func (t *Table) dragColumn(x int) {
+	if column := t.resizeColumn; column != nil {
+		width := column.clamp(x - t.resizeX)
+		if width != column.Width {
+			column.Width = width
+			t.dragged = true
+		}
 		return
 	}
-	t.cells = append(t.cells, nil)       // Extend by one.
-	copy(t.cells[row+1:], t.cells[row:]) // Shift down.
-	t.cells[row] = nil                   // New row is uninitialized.
+	target, _, _ := t.columnAt(x)
+	if target == nil || target == t.moveColumn {
+		return
+	}
+	from, to := -1, -1
+	for index, column := range t.columns {
+		if column == t.moveColumn {
+			from = index
+		} else if column == target {
+			to = index
+		}
+	}
+	if t.move(from, to) {
+		t.dragged = true
+	}
 }
 
-// InsertColumn inserts a column before the column with the given index. Cells
-// in the given column and to its right will be shifted to the right by one
-// column. Rows that have fewer initialized cells than "column" will remain
-// unchanged.
-func (t *Table) InsertColumn(column int) {
+// showColumnMenu opens the menu which shows or hides columns at the given
+// screen position. The table must not be locked.
+func (t *Table) showColumnMenu(x, y int, setFocus func(p Widget)) {
 	t.mu.Lock()
-	defer t.mu.Unlock()
-
-	for row := range t.cells {
-		if column >= len(t.cells[row]) {
-			continue
+	if t.columnMenu == nil {
+		t.columnMenu = NewContextMenu(t)
+	}
+	menu := t.columnMenu
+	menu.ClearContextMenu()
+	for _, column := range t.columns {
+		column := column
+		text := "Column " + strconv.Itoa(column.Index+1)
+		if column.Header != nil && len(column.Header.Text) > 0 {
+			text = string(column.Header.Text)
+		}
+		if column.Hidden {
+			text = "  " + text
+		} else {
+			text = string(Styles.TableColumnShownSymbol) + " " + text
 		}
-		t.cells[row] = append(t.cells[row], nil)             // Extend by one.
-		copy(t.cells[row][column+1:], t.cells[row][column:]) // Shift to the right.
-		t.cells[row][column] = &TableCell{}                  // New element is an uninitialized table cell.
+		menu.AddContextItem(text, 0, func(int) {
+			t.toggleColumn(column)
+		})
 	}
+	t.mu.Unlock()
+
+	menu.ShowContextMenu(0, x, y, setFocus)
 }
 
-// GetRowCount returns the number of rows in the table.
-func (t *Table) GetRowCount() int {
-	t.mu.RLock()
-	defer t.mu.RUnlock()
+// toggleColumn shows or hides a column. The last shown column is not hidden.
+func (t *Table) toggleColumn(column *TableColumn) {
+	t.mu.Lock()
+	if !column.Hidden && len(t.shownColumns()) <= 1 {
+		t.mu.Unlock()
+		return
+	}
+	column.Hidden = !column.Hidden
+	t.mu.Unlock()
 
-	return len(t.cells)
+	t.notifyLayout()
 }
 
-// GetColumnCount returns the (maximum) number of columns in the table.
-func (t *Table) GetColumnCount() int {
-	t.mu.RLock()
-	defer t.mu.RUnlock()
+// drawColumnMenu draws the menu which shows or hides columns, if it is open.
+func (t *Table) drawColumnMenu(screen tcell.Screen) {
+	if t.columnMenu == nil || !t.columnMenu.ContextMenuVisible() {
+		return
+	}
+	list := t.columnMenu.ContextMenuList()
 
-	if len(t.cells) == 0 {
-		return 0
+	width, height := 0, list.GetItemCount()
+	for index := 0; index < height; index++ {
+		text, _ := list.GetItemText(index)
+		if textWidth := TaggedTextWidth([]byte(text)); textWidth > width {
+			width = textWidth
+		}
+	}
+	top, bottom, left, right := list.GetPadding()
+	width += 2 + left + right
+	height += 2 + top + bottom
+
+	t.columnMenu.mu.RLock()
+	x, y := t.columnMenu.x, t.columnMenu.y
+	t.columnMenu.mu.RUnlock()
+	screenWidth, screenHeight := screen.Size()
+	if x+width > screenWidth {
+		x = screenWidth - width
+	}
+	if y+height > screenHeight {
+		y = screenHeight - height
+	}
+	list.SetRect(max(x, 0), max(y, 0), width, height)
+	list.Draw(screen)
+}
+
+// selectClicked changes the selected rows after the given row was clicked
+// with the given modifier keys pressed. Ctrl toggles the row, Shift selects
+// the range of rows from the previous row.
+func (t *Table) selectClicked(row, previousRow int, modifiers tcell.ModMask) {
+	t.mu.Lock()
+	fixedRows, _ := t.fixed()
+	if !t.multiSelect || !t.rowsSelectable || row < fixedRows {
+		t.mu.Unlock()
+		return
+	}
+	switch {
+	case modifiers&tcell.ModShift != 0:
+		if t.anchorRow < 0 {
+			t.anchorRow = previousRow
+		}
+		t.selectRowRange()
+	case modifiers&tcell.ModCtrl != 0:
+		t.selectRow(row, !t.selectedRows[row])
+		t.anchorRow, t.rangeSelection = row, nil
+	default:
+		t.anchorRow, t.rangeSelection = row, nil
+		t.mu.Unlock()
+		return
+	}
+	handler, rows := t.selectedRowsChanged, t.getSelectedRows()
+	t.mu.Unlock()
+	if handler != nil {
+		handler(rows)
 	}
-	return t.lastColumn + 1
 }
 
 // cellAt returns the row and column located at the given screen coordinates.
@@ -958,12 +2455,15 @@ func (t *Table) cellAt(x, y int) (row, column int) {
 		row = y - rectY
 	}
 
-	// Respect fixed rows and row offset.
+	// Respect the header row, fixed rows and row offset.
+	if t.showHeader() {
+		row--
+	}
 	if row >= 0 {
-		if row >= t.fixedRows {
+		if fixedRows, _ := t.fixed(); row >= fixedRows {
 			row += t.rowOffset
 		}
-		if row >= len(t.cells) {
+		if row >= t.content.GetRowCount() {
 			row = -1
 		}
 	}
@@ -1009,7 +2509,7 @@ func (t *Table) ScrollToEnd() {
 
 	t.trackEnd = true
 	t.columnOffset = 0
-	t.rowOffset = len(t.cells)
+	t.rowOffset = t.content.GetRowCount()
 }
 
 // SetSortClicked sets a flag which determines whether the table is sorted when
@@ -1023,7 +2523,8 @@ func (t *Table) SetSortClicked(sortClicked bool) *Table {
 }
 
 // SetSortFunc sets the sorting function used for the table. When unset, a
-// case-sensitive string comparison is used.
+// case-sensitive string comparison is used. The function is passed to the
+// content, which may ignore it.
 func (t *Table) SetSortFunc(sortFunc func(column, i, j int) bool) *Table {
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -1033,33 +2534,46 @@ func (t *Table) SetSortFunc(sortFunc func(column, i, j int) bool) *Table {
 }
 
 // Sort sorts the table by the column at the given index. You may set a custom
-// sorting function with SetSortFunc.
+// sorting function with SetSortFunc. Only contents implementing
+// TableContentSorter are sorted, which the default content does.
 func (t *Table) Sort(column int, descending bool) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	if len(t.cells) == 0 || column < 0 || column >= len(t.cells[0]) {
+	t.sort(column, descending)
+}
+
+func (t *Table) sort(column int, descending bool) {
+	sorter, ok := t.content.(TableContentSorter)
+	if !ok {
 		return
 	}
-
-	if t.sortFunc == nil {
-		t.sortFunc = func(column, i, j int) bool {
-			return bytes.Compare(t.cells[i][column].Text, t.cells[j][column].Text) == -1
+	t.sorted, t.sortClickedColumn, t.sortClickedDescending = true, column, descending
+	fixedRows, _ := t.fixed()
+
+	// The selected rows are found by their keys after sorting.
+	keys, _ := t.content.(TableContentRowKeys)
+	var selected map[interface{}]bool
+	if len(t.selectedRows) > 0 && keys != nil {
+		selected = make(map[interface{}]bool)
+		for row := range t.selectedRows {
+			if key := keys.GetRowKey(row); key != nil {
+				selected[key] = true
+			}
 		}
 	}
 
-	sort.SliceStable(t.cells, func(i, j int) bool {
-		if i < t.fixedRows {
-			return i < j
-		} else if j < t.fixedRows {
-			return j > i
-		}
+	sorter.Sort(column, descending, fixedRows, t.sortFunc)
 
-		if !descending {
-			return t.sortFunc(column, i, j)
+	if len(t.selectedRows) > 0 {
+		t.selectedRows, t.anchorRow, t.rangeSelection = nil, -1, nil
+	}
+	for row := 0; row < t.content.GetRowCount() && len(selected) > 0; row++ {
+		if key := keys.GetRowKey(row); selected[key] {
+			t.selectRow(row, true)
+			delete(selected, key)
 		}
-		return t.sortFunc(column, j, i)
-	})
+	}
 }
 
 // Draw draws this primitive onto the screen.
@@ -1073,43 +2587,85 @@ func (t *Table) Draw(screen tcell.Screen) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
+	// The column menu and the editor are drawn last, over the cells.
+	defer t.drawColumnMenu(screen)
+	defer t.drawEditor(screen)
+	t.editWidth = 0
+
+	// Only the visible cells are requested from the content.
+	rowCount, lastColumn := t.content.GetRowCount(), t.content.GetColumnCount()-1
+	fixedRows, fixedColumns := t.fixed()
+
+	// Return the cell at the specified position (nil if it doesn't exist).
+	getCell := t.content.GetCell
+
+	// The columns are laid out by their position on screen, which is the
+	// column of the content unless there is a column model. The header row
+	// has the index -1.
+	selectedColumn, editColumn := t.selectedColumn, t.editColumn
+	shown := t.shownColumns()
+	if t.columns != nil {
+		lastColumn = len(shown) - 1
+		selectedColumn, editColumn = 0, -1
+		for position, column := range shown {
+			if column.Index == t.selectedColumn {
+				selectedColumn = position
+			}
+			if column.Index == t.editColumn {
+				editColumn = position
+			}
+		}
+		getCell = func(row, column int) *TableCell {
+			if column < 0 || column >= len(shown) {
+				return nil
+			}
+			if row < 0 {
+				return t.headerCell(shown[column])
+			}
+			return t.content.GetCell(row, shown[column].Index)
+		}
+	}
+	contentColumn := func(column int) int {
+		if t.columns == nil {
+			return column
+		}
+		return shown[column].Index
+	}
+
 	// What's our available screen space?
 	x, y, width, height := t.GetInnerRect()
+	rowStep := 1
 	if t.borders {
-		t.visibleRows = height / 2
-	} else {
-		t.visibleRows = height
+		rowStep = 2 // With borders, every table row takes two screen rows.
 	}
+	headerHeight := 0
+	if t.showHeader() {
+		headerHeight = rowStep
+	}
+	rowsHeight := height - headerHeight
+	t.visibleRows = rowsHeight / rowStep
 
-	showVerticalScrollBar := t.scrollBarVisibility == ScrollBarAlways || (t.scrollBarVisibility == ScrollBarAuto && len(t.cells) > t.visibleRows-t.fixedRows)
+	showVerticalScrollBar := t.scrollBarVisibility == ScrollBarAlways || (t.scrollBarVisibility == ScrollBarAuto && rowCount > t.visibleRows-fixedRows)
 	if showVerticalScrollBar {
 		width-- // Subtract space for scroll bar.
 	}
 
-	// Return the cell at the specified position (nil if it doesn't exist).
-	getCell := func(row, column int) *TableCell {
-		if row < 0 || column < 0 || row >= len(t.cells) || column >= len(t.cells[row]) {
-			return nil
-		}
-		return t.cells[row][column]
-	}
-
 	// If this cell is not selectable, find the next one.
 	if t.rowsSelectable || t.columnsSelectable {
-		if t.selectedColumn < 0 {
-			t.selectedColumn = 0
+		if selectedColumn < 0 {
+			selectedColumn = 0
 		}
 		if t.selectedRow < 0 {
 			t.selectedRow = 0
 		}
-		for t.selectedRow < len(t.cells) {
-			cell := getCell(t.selectedRow, t.selectedColumn)
+		for t.selectedRow < rowCount {
+			cell := getCell(t.selectedRow, selectedColumn)
 			if cell == nil || !cell.NotSelectable {
 				break
 			}
-			t.selectedColumn++
-			if t.selectedColumn > t.lastColumn {
-				t.selectedColumn = 0
+			selectedColumn++
+			if selectedColumn > lastColumn {
+				selectedColumn = 0
 				t.selectedRow++
 			}
 		}
@@ -1117,36 +2673,36 @@ func (t *Table) Draw(screen tcell.Screen) {
 
 	// Clamp row offsets.
 	if t.rowsSelectable {
-		if t.selectedRow >= t.fixedRows && t.selectedRow < t.fixedRows+t.rowOffset {
-			t.rowOffset = t.selectedRow - t.fixedRows
+		if t.selectedRow >= fixedRows && t.selectedRow < fixedRows+t.rowOffset {
+			t.rowOffset = t.selectedRow - fixedRows
 			t.trackEnd = false
 		}
 		if t.borders {
-			if 2*(t.selectedRow+1-t.rowOffset) >= height {
-				t.rowOffset = t.selectedRow + 1 - height/2
+			if 2*(t.selectedRow+1-t.rowOffset) >= rowsHeight {
+				t.rowOffset = t.selectedRow + 1 - rowsHeight/2
 				t.trackEnd = false
 			}
 		} else {
-			if t.selectedRow+1-t.rowOffset >= height {
-				t.rowOffset = t.selectedRow + 1 - height
+			if t.selectedRow+1-t.rowOffset >= rowsHeight {
+				t.rowOffset = t.selectedRow + 1 - rowsHeight
 				t.trackEnd = false
 			}
 		}
 	}
 	if t.borders {
-		if 2*(len(t.cells)-t.rowOffset) < height {
+		if 2*(rowCount-t.rowOffset) < rowsHeight {
 			t.trackEnd = true
 		}
 	} else {
-		if len(t.cells)-t.rowOffset < height {
+		if rowCount-t.rowOffset < rowsHeight {
 			t.trackEnd = true
 		}
 	}
 	if t.trackEnd {
 		if t.borders {
-			t.rowOffset = len(t.cells) - height/2
+			t.rowOffset = rowCount - rowsHeight/2
 		} else {
-			t.rowOffset = len(t.cells) - height
+			t.rowOffset = rowCount - rowsHeight
 		}
 	}
 	if t.rowOffset < 0 {
@@ -1155,33 +2711,28 @@ func (t *Table) Draw(screen tcell.Screen) {
 
 	// Clamp column offset. (Only left side here. The right side is more
 	// difficult and we'll do it below.)
-	if t.columnsSelectable && t.selectedColumn >= t.fixedColumns && t.selectedColumn < t.fixedColumns+t.columnOffset {
-		t.columnOffset = t.selectedColumn - t.fixedColumns
+	if t.columnsSelectable && selectedColumn >= fixedColumns && selectedColumn < fixedColumns+t.columnOffset {
+		t.columnOffset = selectedColumn - fixedColumns
 	}
 	if t.columnOffset < 0 {
 		t.columnOffset = 0
 	}
-	if t.selectedColumn < 0 {
-		t.selectedColumn = 0
+	if selectedColumn < 0 {
+		selectedColumn = 0
+	}
+	if t.columns == nil || selectedColumn <= lastColumn {
+		t.selectedColumn = contentColumn(selectedColumn)
 	}
 
 	// Determine the indices and widths of the columns and rows which fit on the
 	// screen.
 	var (
-		columns, rows, allRows, widths []int
-		tableHeight, tableWidth        int
+		columns, rows, widths   []int
+		tableHeight, tableWidth int
 	)
-	rowStep := 1
 	if t.borders {
-		rowStep = 2    // With borders, every table row takes two screen rows.
 		tableWidth = 1 // We start at the second character because of the left table border.
 	}
-	if t.evaluateAllRows {
-		allRows = make([]int, len(t.cells))
-		for row := range t.cells {
-			allRows[row] = row
-		}
-	}
 	indexRow := func(row int) bool { // Determine if this row is visible, store its index.
 		if tableHeight >= height {
 			return false
@@ -1190,12 +2741,15 @@ func (t *Table) Draw(screen tcell.Screen) {
 		tableHeight += rowStep
 		return true
 	}
-	for row := 0; row < t.fixedRows && row < len(t.cells); row++ { // Do the fixed rows first.
+	if headerHeight > 0 { // The header row comes first.
+		indexRow(-1)
+	}
+	for row := 0; row < fixedRows && row < rowCount; row++ { // Do the fixed rows first.
 		if !indexRow(row) {
 			break
 		}
 	}
-	for row := t.fixedRows + t.rowOffset; row < len(t.cells); row++ { // Then the remaining rows.
+	for row := fixedRows + t.rowOffset; row < rowCount; row++ { // Then the remaining rows.
 		if !indexRow(row) {
 			break
 		}
@@ -1209,40 +2763,36 @@ ColumnLoop:
 		// If we've moved beyond the right border, we stop or skip a column.
 		for tableWidth-1 >= width { // -1 because we include one extra column if the separator falls on the right end of the box.
 			// We've moved beyond the available space.
-			if column < t.fixedColumns {
+			if column < fixedColumns {
 				break ColumnLoop // We're in the fixed area. We're done.
 			}
 			if !t.columnsSelectable && skipped >= t.columnOffset {
 				break ColumnLoop // There is no selection and we've already reached the offset.
 			}
-			if t.columnsSelectable && t.selectedColumn-skipped == t.fixedColumns {
+			if t.columnsSelectable && selectedColumn-skipped == fixedColumns {
 				break ColumnLoop // The selected column reached the leftmost point before disappearing.
 			}
 			if t.columnsSelectable && skipped >= t.columnOffset &&
-				(t.selectedColumn < column && lastTableWidth < width-1 && tableWidth < width-1 || t.selectedColumn < column-1) {
+				(selectedColumn < column && lastTableWidth < width-1 && tableWidth < width-1 || selectedColumn < column-1) {
 				break ColumnLoop // We've skipped as many as requested and the selection is visible.
 			}
-			if len(columns) <= t.fixedColumns {
+			if len(columns) <= fixedColumns {
 				break // Nothing to skip.
 			}
 
 			// We need to skip a column.
 			skipped++
-			lastTableWidth -= widths[t.fixedColumns] + 1
-			tableWidth -= widths[t.fixedColumns] + 1
-			columns = append(columns[:t.fixedColumns], columns[t.fixedColumns+1:]...)
-			widths = append(widths[:t.fixedColumns], widths[t.fixedColumns+1:]...)
-			expansions = append(expansions[:t.fixedColumns], expansions[t.fixedColumns+1:]...)
+			lastTableWidth -= widths[fixedColumns] + 1
+			tableWidth -= widths[fixedColumns] + 1
+			columns = append(columns[:fixedColumns], columns[fixedColumns+1:]...)
+			widths = append(widths[:fixedColumns], widths[fixedColumns+1:]...)
+			expansions = append(expansions[:fixedColumns], expansions[fixedColumns+1:]...)
 		}
 
 		// What's this column's width (without expansion)?
 		maxWidth := -1
 		expansion := 0
-		evaluationRows := rows
-		if t.evaluateAllRows {
-			evaluationRows = allRows
-		}
-		for _, row := range evaluationRows {
+		evaluateRow := func(row int) {
 			if cell := getCell(row, column); cell != nil {
 				_, _, _, _, _, _, cellWidth := decomposeText(cell.Text, true, false)
 				if cell.MaxWidth > 0 && cell.MaxWidth < cellWidth {
@@ -1256,6 +2806,28 @@ ColumnLoop:
 				}
 			}
 		}
+		if t.evaluateAllRows {
+			for row := -headerHeight / rowStep; row < rowCount; row++ {
+				evaluateRow(row)
+			}
+		} else {
+			for _, row := range rows {
+				evaluateRow(row)
+			}
+		}
+		if t.columns != nil {
+			// The column model determines the columns and their widths.
+			if column > lastColumn {
+				break
+			}
+			if shown[column].Width > 0 {
+				maxWidth = shown[column].Width
+			}
+			maxWidth = shown[column].clamp(maxWidth)
+			if shown[column].Expansion > 0 {
+				expansion = shown[column].Expansion
+			}
+		}
 		if maxWidth < 0 {
 			break // No more cells found in this column.
 		}
@@ -1326,17 +2898,20 @@ ColumnLoop:
 				drawBorder(columnX, rowY, t.separator)
 			}
 
-			// Get the cell.
-			cell := getCell(row, column)
-			if cell == nil {
-				continue
-			}
-
 			// Draw text.
 			finalWidth := columnWidth
 			if columnX+1+columnWidth >= width {
 				finalWidth = width - columnX - 1
 			}
+			if t.editor != nil && row == t.editRow && column == editColumn {
+				t.editX, t.editY, t.editWidth = x+columnX+1, y+rowY, finalWidth
+			}
+
+			// Get the cell.
+			cell := getCell(row, column)
+			if cell == nil {
+				continue
+			}
 			cell.x, cell.y, cell.width = x+columnX+1, y+rowY, finalWidth
 			_, printed := PrintStyle(screen, cell.Text, x+columnX+1, y+rowY, finalWidth, cell.Align, SetAttributes(tcell.StyleDefault.Foreground(cell.Color), cell.Attributes))
 			if TaggedTextWidth(cell.Text)-printed > 0 && printed > 0 {
@@ -1361,7 +2936,7 @@ ColumnLoop:
 	}
 
 	// Draw right border.
-	if t.borders && len(t.cells) > 0 && columnX < width {
+	if t.borders && rowCount > 0 && columnX < width {
 		for rowY := range rows {
 			rowY *= 2
 			if rowY+1 < height {
@@ -1380,13 +2955,13 @@ ColumnLoop:
 
 	if showVerticalScrollBar {
 		// Calculate scroll bar position and dimensions.
-		rows := len(t.cells)
+		rows := rowCount
 
-		scrollBarItems := rows - t.fixedRows
-		scrollBarHeight := t.visibleRows - t.fixedRows
+		scrollBarItems := rows - fixedRows
+		scrollBarHeight := t.visibleRows - fixedRows
 
 		scrollBarX := x + width
-		scrollBarY := y + t.fixedRows
+		scrollBarY := y + headerHeight + fixedRows
 		if scrollBarX > x+tableWidth {
 			scrollBarX = x + tableWidth
 		}
@@ -1398,11 +2973,11 @@ ColumnLoop:
 			scrollBarItems *= 2
 			scrollBarHeight = (scrollBarHeight * 2) - 1
 
-			scrollBarY += t.fixedRows + 1
+			scrollBarY += fixedRows + 1
 		}
 
 		// Draw scroll bar.
-		cursor := int(float64(scrollBarItems) * (float64(t.rowOffset) / float64(((rows-t.fixedRows)-t.visibleRows)+padTotalOffset)))
+		cursor := int(float64(scrollBarItems) * (float64(t.rowOffset) / float64(((rows-fixedRows)-t.visibleRows)+padTotalOffset)))
 		for printed := 0; printed < scrollBarHeight; printed++ {
 			RenderScrollBar(screen, t.scrollBarVisibility, scrollBarX, scrollBarY+printed, scrollBarHeight, scrollBarItems, cursor, printed, t.box.hasFocus, t.scrollBarColor)
 		}
@@ -1452,12 +3027,14 @@ ColumnLoop:
 		x, y, w, h int
 		color      tcell.Color
 		selected   bool
+		marked     bool
 	}
 	cellsByBackgroundColor := make(map[tcell.Color][]*cellInfo)
 	var backgroundColors []tcell.Color
 	for rowY, row := range rows {
 		columnX := 0
 		rowSelected := t.rowsSelectable && !t.columnsSelectable && row == t.selectedRow
+		rowMarked := t.multiSelect && t.selectedRows[row]
 		for columnIndex, column := range columns {
 			columnWidth := widths[columnIndex]
 			cell := getCell(row, column)
@@ -1470,8 +3047,8 @@ ColumnLoop:
 				bw++
 				bh = 3
 			}
-			columnSelected := t.columnsSelectable && !t.rowsSelectable && column == t.selectedColumn
-			cellSelected := !cell.NotSelectable && (columnSelected || rowSelected || t.rowsSelectable && t.columnsSelectable && column == t.selectedColumn && row == t.selectedRow)
+			columnSelected := t.columnsSelectable && !t.rowsSelectable && column == selectedColumn && row >= 0
+			cellSelected := !cell.NotSelectable && (columnSelected || rowSelected || t.rowsSelectable && t.columnsSelectable && column == selectedColumn && row == t.selectedRow)
 			entries, ok := cellsByBackgroundColor[cell.BackgroundColor]
 			cellsByBackgroundColor[cell.BackgroundColor] = append(entries, &cellInfo{
 				x:        bx,
@@ -1480,6 +3057,7 @@ ColumnLoop:
 				h:        bh,
 				color:    cell.Color,
 				selected: cellSelected,
+				marked:   rowMarked,
 			})
 			if !ok {
 				backgroundColors = append(backgroundColors, cell.BackgroundColor)
@@ -1497,6 +3075,23 @@ ColumnLoop:
 		_, _, lj := c.Hcl()
 		return li < lj
 	})
+	// Show where a dragged payload would be dropped, over the selected cells
+	// colored below.
+	if t.dropRow >= 0 {
+		dropY := -1
+		for rowY, row := range rows {
+			if row == t.dropRow {
+				dropY = y + rowY*rowStep + rowStep - 1
+			}
+		}
+		if t.dropRow == rowCount && tableHeight+rowStep <= height {
+			dropY = y + len(rows)*rowStep + rowStep - 1
+		}
+		if dropY >= 0 {
+			defer dropFeedback(screen, x, dropY, tableWidth)
+		}
+	}
+
 	selFg, selBg, selAttr := t.selectedStyle.Decompose()
 	for _, bgColor := range backgroundColors {
 		entries := cellsByBackgroundColor[bgColor]
@@ -1507,6 +3102,8 @@ ColumnLoop:
 				} else {
 					defer colorBackground(cell.x, cell.y, cell.w, cell.h, bgColor, cell.color, 0, true)
 				}
+			} else if cell.marked {
+				colorBackground(cell.x, cell.y, cell.w, cell.h, Styles.MultiSelectBackgroundColor, tcell.ColorDefault, 0, false)
 			} else {
 				colorBackground(cell.x, cell.y, cell.w, cell.h, bgColor, tcell.ColorDefault, 0, false)
 			}
@@ -1514,6 +3111,11 @@ ColumnLoop:
 	}
 
 	// Remember column infos.
+	if t.columns != nil {
+		for index, column := range columns {
+			columns[index] = contentColumn(column)
+		}
+	}
 	t.visibleColumnIndices, t.visibleColumnWidths = columns, widths
 }
 
@@ -1524,6 +3126,8 @@ func (t *Table) InputHandler() func(event *tcell.EventKey, setFocus func(p Widge
 		defer t.mu.Unlock()
 
 		key := event.Key()
+		rowCount, lastColumn := t.content.GetRowCount(), t.content.GetColumnCount()-1
+		fixedRows, fixedColumns := t.fixed()
 
 		if (!t.rowsSelectable && !t.columnsSelectable && key == tcell.KeyEnter) ||
 			key == tcell.KeyEscape ||
@@ -1537,21 +3141,52 @@ func (t *Table) InputHandler() func(event *tcell.EventKey, setFocus func(p Widge
 			return
 		}
 
-		// Movement functions.
+		// Movement functions. With a column model, columns are moved through by
+		// their position on screen.
 		previouslySelectedRow, previouslySelectedColumn := t.selectedRow, t.selectedColumn
+		multiSelect, selectionChanged := t.multiSelect && t.rowsSelectable, false
+		shown := t.shownColumns()
+		if t.columns != nil {
+			lastColumn = len(shown) - 1
+		}
 		var (
+			position = func(column int) int {
+				if t.columns == nil {
+					if column > lastColumn {
+						return -1
+					}
+					return column
+				}
+				for position, shownColumn := range shown {
+					if shownColumn.Index == column {
+						return position
+					}
+				}
+				return -1
+			}
+
+			contentColumn = func(position int) int {
+				if t.columns == nil {
+					return position
+				}
+				if position < 0 || position >= len(shown) {
+					return -1
+				}
+				return shown[position].Index
+			}
+
 			validSelection = func(row, column int) bool {
-				if row < t.fixedRows || row >= len(t.cells) || column < t.fixedColumns || column > t.lastColumn {
+				if row < fixedRows || row >= rowCount || column < 0 || position(column) < fixedColumns {
 					return false
 				}
-				cell := t.cells[row][column]
+				cell := t.content.GetCell(row, column)
 				return cell == nil || !cell.NotSelectable
 			}
 
 			home = func() {
 				if t.rowsSelectable {
 					t.selectedRow = 0
-					t.selectedColumn = 0
+					t.selectedColumn = contentColumn(0)
 				} else {
 					t.trackEnd = false
 					t.rowOffset = 0
@@ -1561,8 +3196,8 @@ func (t *Table) InputHandler() func(event *tcell.EventKey, setFocus func(p Widge
 
 			end = func() {
 				if t.rowsSelectable {
-					t.selectedRow = len(t.cells) - 1
-					t.selectedColumn = t.lastColumn
+					t.selectedRow = rowCount - 1
+					t.selectedColumn = contentColumn(lastColumn)
 				} else {
 					t.trackEnd = true
 					t.columnOffset = 0
@@ -1592,8 +3227,8 @@ func (t *Table) InputHandler() func(event *tcell.EventKey, setFocus func(p Widge
 
 			left = func() {
 				if t.columnsSelectable {
-					if validSelection(t.selectedRow, t.selectedColumn-1) {
-						t.selectedColumn--
+					if column := contentColumn(position(t.selectedColumn) - 1); validSelection(t.selectedRow, column) {
+						t.selectedColumn = column
 					}
 				} else {
 					t.columnOffset--
@@ -1602,8 +3237,8 @@ func (t *Table) InputHandler() func(event *tcell.EventKey, setFocus func(p Widge
 
 			right = func() {
 				if t.columnsSelectable {
-					if validSelection(t.selectedRow, t.selectedColumn+1) {
-						t.selectedColumn++
+					if column := contentColumn(position(t.selectedColumn) + 1); validSelection(t.selectedRow, column) {
+						t.selectedColumn = column
 					}
 				} else {
 					t.columnOffset++
@@ -1611,15 +3246,15 @@ func (t *Table) InputHandler() func(event *tcell.EventKey, setFocus func(p Widge
 			}
 
 			pageDown = func() {
-				offsetAmount := t.visibleRows - t.fixedRows
+				offsetAmount := t.visibleRows - fixedRows
 				if offsetAmount < 0 {
 					offsetAmount = 0
 				}
 
 				if t.rowsSelectable {
 					t.selectedRow += offsetAmount
-					if t.selectedRow >= len(t.cells) {
-						t.selectedRow = len(t.cells) - 1
+					if t.selectedRow >= rowCount {
+						t.selectedRow = rowCount - 1
 					}
 				} else {
 					t.rowOffset += offsetAmount
@@ -1627,7 +3262,7 @@ func (t *Table) InputHandler() func(event *tcell.EventKey, setFocus func(p Widge
 			}
 
 			pageUp = func() {
-				offsetAmount := t.visibleRows - t.fixedRows
+				offsetAmount := t.visibleRows - fixedRows
 				if offsetAmount < 0 {
 					offsetAmount = 0
 				}
@@ -1644,7 +3279,44 @@ func (t *Table) InputHandler() func(event *tcell.EventKey, setFocus func(p Widge
 			}
 		)
 
-		if HitShortcut(event, Keys.MoveFirst, Keys.MoveFirst2) {
+		if t.columns != nil && HitShortcut(event, Keys.TableMoveColumnLeft, Keys.TableMoveColumnRight) {
+			if t.moveSelected(HitShortcut(event, Keys.TableMoveColumnRight)) {
+				t.mu.Unlock()
+				t.notifyLayout()
+				t.mu.Lock()
+			}
+		} else if t.columns != nil && HitShortcut(event, Keys.ShowContextMenu) {
+			x, y, _, _ := t.GetInnerRect()
+			t.mu.Unlock()
+			t.showColumnMenu(x, y+1, setFocus)
+			t.mu.Lock()
+		} else if multiSelect && HitShortcut(event, Keys.SelectToggle) {
+			if t.selectedRow >= fixedRows && t.selectedRow < rowCount {
+				t.selectRow(t.selectedRow, !t.selectedRows[t.selectedRow])
+				t.anchorRow, t.rangeSelection = t.selectedRow, nil
+				selectionChanged = true
+			}
+		} else if multiSelect && HitShortcut(event, Keys.SelectAll) {
+			for row := fixedRows; row < rowCount; row++ {
+				t.selectRow(row, true)
+			}
+			t.rangeSelection = nil
+			selectionChanged = true
+		} else if multiSelect && HitShortcut(event, Keys.SelectUp, Keys.SelectDown) {
+			if t.anchorRow < 0 {
+				t.anchorRow = t.selectedRow
+			}
+			if HitShortcut(event, Keys.SelectUp) {
+				up()
+			} else {
+				down()
+			}
+			t.selectRowRange()
+			selectionChanged = true
+		} else if key == tcell.KeyRune && t.editable && t.startEdit(event, setFocus) {
+			// Typing edits the cell, even letters which move the selection
+			// otherwise.
+		} else if HitShortcut(event, Keys.MoveFirst, Keys.MoveFirst2) {
 			home()
 		} else if HitShortcut(event, Keys.MoveLast, Keys.MoveLast2) {
 			end()
@@ -1660,7 +3332,7 @@ func (t *Table) InputHandler() func(event *tcell.EventKey, setFocus func(p Widge
 			pageUp()
 		} else if HitShortcut(event, Keys.MoveNextPage) {
 			pageDown()
-		} else if HitShortcut(event, Keys.Select, Keys.Select2) {
+		} else if HitShortcut(event, Keys.Select, Keys.Select2) && !t.startEdit(event, setFocus) {
 			if (t.rowsSelectable || t.columnsSelectable) && t.selected != nil {
 				t.mu.Unlock()
 				t.selected(t.selectedRow, t.selectedColumn)
@@ -1668,7 +3340,18 @@ func (t *Table) InputHandler() func(event *tcell.EventKey, setFocus func(p Widge
 			}
 		}
 
-		// If the selection has changed, notify the handler.
+		// Ranges are selected from the row moved to last.
+		if !selectionChanged && previouslySelectedRow != t.selectedRow {
+			t.anchorRow, t.rangeSelection = t.selectedRow, nil
+		}
+
+		// If the selection has changed, notify the handlers.
+		if selectionChanged && t.selectedRowsChanged != nil {
+			rows := t.getSelectedRows()
+			t.mu.Unlock()
+			t.selectedRowsChanged(rows)
+			t.mu.Lock()
+		}
 		if t.selectionChanged != nil && ((t.rowsSelectable && previouslySelectedRow != t.selectedRow) || (t.columnsSelectable && previouslySelectedColumn != t.selectedColumn)) {
 			t.mu.Unlock()
 			t.selectionChanged(t.selectedRow, t.selectedColumn)
@@ -1680,12 +3363,87 @@ func (t *Table) InputHandler() func(event *tcell.EventKey, setFocus func(p Widge
 // MouseHandler returns the mouse handler for this primitive.
 func (t *Table) MouseHandler() func(action MouseAction, event *tcell.EventMouse, setFocus func(p Widget)) (consumed bool, capture Widget) {
 	return t.WrapMouseHandler(func(action MouseAction, event *tcell.EventMouse, setFocus func(p Widget)) (consumed bool, capture Widget) {
+		// Report the table as drag source or drop target.
+		if action == MouseDragStart || action == MouseDragOver {
+			t.mu.RLock()
+			defer t.mu.RUnlock()
+			if t.InRect(event.Position()) && (t.draggable || action == MouseDragOver && t.acceptDrop != nil) {
+				return true, t
+			}
+			return false, nil
+		}
+
+		t.mu.RLock()
+		editor, menu := t.editor, t.columnMenu
+		t.mu.RUnlock()
+		if editor != nil {
+			// The editor gets the first chance, as a DropDown may extend
+			// beyond the table. Clicking elsewhere commits the edit.
+			if consumed, capture = editor.MouseHandler()(action, event, setFocus); consumed {
+				return
+			}
+			if action == MouseLeftClick && !t.CommitEdit() {
+				return true, nil
+			}
+		}
+
+		// The column menu gets the next chance. Clicking elsewhere closes it.
+		if menu != nil && menu.ContextMenuVisible() {
+			if list := menu.ContextMenuList(); list.InRect(event.Position()) {
+				return list.MouseHandler()(action, event, setFocus)
+			}
+			if action == MouseLeftClick || action == MouseMiddleClick {
+				menu.HideContextMenu(setFocus)
+			}
+			return true, nil
+		}
+
+		// Columns dragged with the mouse follow it until the button is
+		// released, even outside the table.
 		x, y := event.Position()
+		if action == MouseMove || action == MouseLeftUp {
+			t.mu.Lock()
+			if t.resizeColumn != nil || t.moveColumn != nil {
+				t.dragColumn(x)
+				dragged := t.dragged
+				if action == MouseLeftUp {
+					t.resizeColumn, t.moveColumn, t.dragged = nil, nil, false
+				}
+				t.mu.Unlock()
+				if action == MouseMove {
+					return true, t
+				}
+				if dragged {
+					t.notifyLayout()
+				}
+				return true, nil
+			}
+			t.mu.Unlock()
+		}
+
 		if !t.InRect(x, y) {
 			return false, nil
 		}
 
 		switch action {
+		case MouseLeftDown:
+			t.mu.Lock()
+			var column *TableColumn
+			if t.headerAt(y) {
+				var left int
+				var border bool
+				column, left, border = t.columnAt(x)
+				if column != nil && border {
+					t.resizeColumn, t.resizeX = column, left
+				} else if column != nil {
+					t.moveColumn = column
+				}
+			}
+			t.mu.Unlock()
+			if column != nil {
+				setFocus(t)
+				return true, t
+			}
 		case MouseLeftClick:
 			_, tableY, _, _ := t.GetInnerRect()
 			mul := 1
@@ -1695,9 +3453,19 @@ func (t *Table) MouseHandler() func(action MouseAction, event *tcell.EventMouse,
 				maxY = tableY + 1
 			}
 
-			if t.sortClicked && t.fixedRows > 0 && (y >= tableY && y < maxY+(t.fixedRows*mul)) {
+			fixedRows, _ := t.GetFixed()
+			t.mu.RLock()
+			model, header := t.columns != nil, t.headerAt(y)
+			_, _, border := t.columnAt(x)
+			if t.showHeader() {
+				fixedRows++ // The header row sorts like a fixed row.
+			}
+			t.mu.RUnlock()
+			if header && border {
+				// Clicking the border of a header resizes the column.
+			} else if t.sortClicked && fixedRows > 0 && (y >= tableY && y < maxY+(fixedRows*mul)) {
 				_, column := t.cellAt(x, y)
-				if t.sortClickedColumn != column {
+				if !t.sorted || t.sortClickedColumn != column {
 					t.sortClickedColumn = column
 					t.sortClickedDescending = false
 				} else {
@@ -1708,12 +3476,28 @@ func (t *Table) MouseHandler() func(action MouseAction, event *tcell.EventMouse,
 				if t.columnsSelectable {
 					t.selectedColumn = column
 				}
+				if model {
+					t.notifyLayout()
+				}
 			} else if t.rowsSelectable || t.columnsSelectable {
-				t.Select(t.cellAt(x, y))
+				row, column := t.cellAt(x, y)
+				t.mu.RLock()
+				previousRow := t.selectedRow
+				t.mu.RUnlock()
+				t.Select(row, column)
+				t.selectClicked(row, previousRow, event.Modifiers())
 			}
 
 			consumed = true
 			setFocus(t)
+		case MouseRightDown:
+			t.mu.RLock()
+			header := t.headerAt(y)
+			t.mu.RUnlock()
+			if header {
+				t.showColumnMenu(x, y+1, setFocus)
+				consumed = true
+			}
 		case MouseScrollUp:
 			t.trackEnd = false
 			t.rowOffset--
@@ -1,8 +1,12 @@
 package cui
 
 import (
+	"encoding/json"
 	"fmt"
+	"sort"
 	"testing"
+
+	"github.com/gdamore/tcell/v2"
 )
 
 var tableTestCases = generateTableTestCases()
@@ -104,3 +108,472 @@ func tc(c *tableTestCase) *Table {
 
 	return table
 }
+
+// virtualTableContent provides the cells of a large table on demand.
+type virtualTableContent struct {
+	TableContentReadOnly
+
+	rows     int
+	requests map[int]bool
+}
+
+func (c *virtualTableContent) GetCell(row, column int) *TableCell {
+	if row < 0 || row >= c.rows || column < 0 || column >= 2 {
+		return nil
+	}
+	c.requests[row] = true
+	return NewTableCell(fmt.Sprintf("%d,%d", column, row))
+}
+
+func (c *virtualTableContent) GetRowCount() int {
+	return c.rows
+}
+
+func (c *virtualTableContent) GetColumnCount() int {
+	return 2
+}
+
+func (c *virtualTableContent) GetFixed() (rows, columns int) {
+	return 1, 0
+}
+
+func TestTableContent(t *testing.T) {
+	t.Parallel()
+
+	content := &virtualTableContent{rows: 1000000, requests: map[int]bool{}}
+	table := NewTable().SetContent(content).SetFixed(0, 0)
+	table.SetRect(0, 0, 20, 5)
+	table.SetOffset(500, 0)
+
+	screen := tcell.NewSimulationScreen("UTF-8")
+	if err := screen.Init(); err != nil {
+		t.Fatalf("failed to initialize screen: %s", err)
+	}
+	screen.SetSize(20, 5)
+	table.Draw(screen)
+
+	if len(content.requests) != 5 {
+		t.Errorf("failed to request visible rows only: expected 5 rows, got %d", len(content.requests))
+	}
+	for _, row := range []int{0, 501, 504} {
+		if !content.requests[row] {
+			t.Errorf("failed to request visible row %d", row)
+		}
+	}
+	if row, _ := table.GetFixed(); row != 1 {
+		t.Errorf("failed to get fixed rows from content: expected 1, got %d", row)
+	}
+	if count := table.GetRowCount(); count != 1000000 {
+		t.Errorf("failed to get row count from content: expected 1000000, got %d", count)
+	}
+
+	// Read-only contents ignore modifications and are not sorted
+	table.SetCellSimple(0, 0, "changed")
+	table.Sort(0, true)
+	if text := table.GetCell(1, 0).GetText(); text != "0,1" {
+		t.Errorf("failed to keep read-only content: expected 0,1, got %s", text)
+	}
+	if text := table.GetCell(2000000, 0).GetText(); text != "" {
+		t.Errorf("failed to get missing cell: expected empty cell, got %s", text)
+	}
+
+	// The default content is restored
+	table.SetContent(nil)
+	table.SetCellSimple(0, 0, "b").SetCellSimple(1, 0, "a")
+	table.Sort(0, false)
+	if text := table.GetCell(0, 0).GetText(); text != "a" {
+		t.Errorf("failed to sort default content: expected a, got %s", text)
+	}
+}
+
+// recordTableContent sorts records without building their cells and
+// identifies rows by their records.
+type recordTableContent struct {
+	TableContentReadOnly
+
+	records  []int
+	requests int
+}
+
+func (c *recordTableContent) GetCell(row, column int) *TableCell {
+	if row < 1 || row > len(c.records) || column != 0 {
+		return nil
+	}
+	c.requests++
+	return NewTableCell(fmt.Sprintf("record %d", c.records[row-1]))
+}
+
+func (c *recordTableContent) GetRowCount() int {
+	return len(c.records) + 1
+}
+
+func (c *recordTableContent) GetColumnCount() int {
+	return 1
+}
+
+func (c *recordTableContent) GetFixed() (rows, columns int) {
+	return 1, 0
+}
+
+func (c *recordTableContent) Sort(column int, descending bool, fixedRows int, less func(column, i, j int) bool) {
+	sort.Slice(c.records, func(i, j int) bool {
+		return c.records[i] < c.records[j] != descending
+	})
+}
+
+func (c *recordTableContent) GetRowKey(row int) interface{} {
+	if row < 1 || row > len(c.records) {
+		return nil
+	}
+	return c.records[row-1]
+}
+
+func TestTableSortContent(t *testing.T) {
+	t.Parallel()
+
+	content := &recordTableContent{records: []int{0, 1, 2, 3, 4}}
+	table := NewTable().SetContent(content).SetMultiSelect(true)
+	table.SetRowSelected(1, true).SetRowSelected(3, true)
+
+	// The selected rows are found by their keys without building cells
+	table.Sort(0, true)
+	if rows := fmt.Sprint(table.GetSelectedRows()); rows != "[3 5]" {
+		t.Errorf("failed to keep selection when sorting: expected [3 5], got %s", rows)
+	}
+	if content.requests != 0 {
+		t.Errorf("failed to sort without cells: expected no cells, got %d", content.requests)
+	}
+}
+
+func TestTableEdit(t *testing.T) {
+	t.Parallel()
+
+	table := NewTable().SetFixed(1, 0).SetSelectable(true, true).SetEditable(true)
+	for column, text := range []string{"name", "done", "color"} {
+		table.SetCellSimple(0, column, text)
+	}
+	table.SetCellSimple(1, 0, "one").SetCellSimple(1, 1, "false").SetCellSimple(1, 2, "red")
+	colors := NewDropDown().SetOptionsSimple(nil, "red", "green", "blue")
+	table.SetColumnEditor(1, NewCheckBox()).SetColumnEditor(2, colors)
+
+	var changed []string
+	table.SetCellChangedFunc(func(row, column int, cell *TableCell) {
+		changed = append(changed, fmt.Sprintf("%d,%d=%s", row, column, cell.GetText()))
+	})
+	table.SetCellValidateFunc(func(row, column int, text string) bool {
+		return text != "invalid"
+	})
+
+	var focus Widget = table
+	setFocus := func(p Widget) { focus = p }
+	press := func(key tcell.Key, r rune) {
+		focus.InputHandler()(tcell.NewEventKey(key, r, tcell.ModNone), setFocus)
+	}
+	typeText := func(text string) {
+		for _, r := range text {
+			press(tcell.KeyRune, r)
+		}
+	}
+
+	// Enter edits the text of the selected cell
+	table.Select(1, 0)
+	press(tcell.KeyEnter, 0)
+	if editing, row, column := table.IsEditing(); !editing || row != 1 || column != 0 {
+		t.Fatalf("failed to start editing: expected 1,0, got %t %d,%d", editing, row, column)
+	}
+	if _, ok := focus.(*Input); !ok {
+		t.Fatalf("failed to focus editor: expected *Input, got %T", focus)
+	}
+	typeText("s")
+	press(tcell.KeyEnter, 0)
+	if text := table.GetCell(1, 0).GetText(); text != "ones" {
+		t.Errorf("failed to commit edit: expected ones, got %s", text)
+	}
+	if focus != table {
+		t.Errorf("failed to return focus: expected table, got %T", focus)
+	}
+
+	// Typing replaces the text, validation keeps the editor open and Escape
+	// cancels
+	typeText("invalid")
+	press(tcell.KeyEnter, 0)
+	if editing, _, _ := table.IsEditing(); !editing {
+		t.Errorf("failed to reject invalid text: expected editor to stay open")
+	}
+	press(tcell.KeyEscape, 0)
+	if editing, _, _ := table.IsEditing(); editing || table.GetCell(1, 0).GetText() != "ones" {
+		t.Errorf("failed to cancel edit: expected ones, got %s", table.GetCell(1, 0).GetText())
+	}
+
+	// Enter toggles a check box
+	table.Select(1, 1)
+	press(tcell.KeyEnter, 0)
+	if text := table.GetCell(1, 1).GetText(); text != "true" {
+		t.Errorf("failed to toggle check box: expected true, got %s", text)
+	}
+
+	// A drop-down opens its options
+	table.Select(1, 2)
+	press(tcell.KeyEnter, 0)
+	if _, ok := focus.(*List); !ok {
+		t.Fatalf("failed to open drop-down: expected *List, got %T", focus)
+	}
+	press(tcell.KeyDown, 0)
+	press(tcell.KeyEnter, 0)
+	if text := table.GetCell(1, 2).GetText(); text != "green" {
+		t.Errorf("failed to select option: expected green, got %s", text)
+	}
+
+	expected := []string{"1,0=ones", "1,1=true", "1,2=green"}
+	if fmt.Sprint(changed) != fmt.Sprint(expected) {
+		t.Errorf("failed to report changes: expected %v, got %v", expected, changed)
+	}
+
+	// Typing letters which also move the selection edits the cell
+	table.Select(1, 0)
+	typeText("hjkl")
+	press(tcell.KeyEnter, 0)
+	if row, column := table.GetSelection(); row != 1 || column != 0 || table.GetCell(1, 0).GetText() != "hjkl" {
+		t.Errorf("failed to type letters: expected hjkl at 1,0, got %s at %d,%d", table.GetCell(1, 0).GetText(), row, column)
+	}
+
+	// Fixed cells are not edited
+	table.Select(0, 0)
+	press(tcell.KeyEnter, 0)
+	if editing, _, _ := table.IsEditing(); editing {
+		t.Errorf("failed to skip fixed cell: expected no editor")
+	}
+
+	// The editor is drawn over the cell
+	table.Select(1, 0)
+	typeText("z")
+	screen := tcell.NewSimulationScreen("UTF-8")
+	if err := screen.Init(); err != nil {
+		t.Fatalf("failed to initialize screen: %s", err)
+	}
+	screen.SetSize(20, 3)
+	table.SetRect(0, 0, 20, 3)
+	table.Draw(screen)
+	if r, _, _, _ := screen.GetContent(0, 1); r != 'z' {
+		t.Errorf("failed to draw editor: expected z, got %c", r)
+	}
+	if r, _, _, _ := screen.GetContent(1, 1); r == 'n' {
+		t.Errorf("failed to draw editor: expected cell text to be covered")
+	}
+	table.CancelEdit()
+
+	// Read-only contents are not edited, and changes which contents ignore
+	// are not reported
+	focus = table
+	table.SetContent(&virtualTableContent{rows: 3, requests: map[int]bool{}})
+	table.Select(1, 0)
+	press(tcell.KeyEnter, 0)
+	if editing, _, _ := table.IsEditing(); editing {
+		t.Errorf("failed to skip read-only content: expected no editor")
+	}
+	content := newTableContent()
+	content.SetCell(1, 0, NewTableCell("kept"))
+	table.SetContent(ignoringTableContent{content})
+	changed = nil
+	typeText("x")
+	press(tcell.KeyEnter, 0)
+	if text := table.GetCell(1, 0).GetText(); text != "kept" || len(changed) != 0 {
+		t.Errorf("failed to ignore rejected change: expected kept without changes, got %s and %v", text, changed)
+	}
+}
+
+// ignoringTableContent ignores new values of cells.
+type ignoringTableContent struct {
+	TableContent
+}
+
+func (ignoringTableContent) SetCell(row, column int, cell *TableCell) {}
+
+func TestTableColumns(t *testing.T) {
+	t.Parallel()
+
+	table := NewTable().SetFixed(0, 0).SetSelectable(true, true)
+	for row, texts := range [][]string{{"b", "x", "c1"}, {"a", "y", "c2"}} {
+		for column, text := range texts {
+			table.SetCellSimple(row, column, text)
+		}
+	}
+	a, b, c := NewTableColumn(0, "A").SetWidth(3), NewTableColumn(1, "B").SetHidden(true), NewTableColumn(2, "C")
+	table.SetColumns(c, a, b)
+
+	var layouts []TableLayout
+	table.SetLayoutChangedFunc(func(layout TableLayout) {
+		layouts = append(layouts, layout)
+	})
+
+	screen := tcell.NewSimulationScreen("UTF-8")
+	if err := screen.Init(); err != nil {
+		t.Fatalf("failed to initialize screen: %s", err)
+	}
+	screen.SetSize(20, 4)
+	table.SetRect(0, 0, 20, 4)
+	line := func(y int) string {
+		screen.Clear()
+		table.Draw(screen)
+		var text []rune
+		for x := 0; x < 8; x++ {
+			r, _, _, _ := screen.GetContent(x, y)
+			text = append(text, r)
+		}
+		return string(text)
+	}
+	if text := line(0); text != "C  A    " {
+		t.Errorf("failed to draw header: expected %q, got %q", "C  A    ", text)
+	}
+	if text := line(1); text != "c1 b    " {
+		t.Errorf("failed to draw columns: expected %q, got %q", "c1 b    ", text)
+	}
+
+	var focus Widget = table
+	setFocus := func(p Widget) { focus = p }
+	mouse := func(action MouseAction, x, y int) {
+		table.MouseHandler()(action, tcell.NewEventMouse(x, y, tcell.ButtonNone, tcell.ModNone), setFocus)
+	}
+
+	// Clicking a header sorts by its column
+	mouse(MouseLeftClick, 3, 0)
+	if text := line(0); text != "C  A ▲  " {
+		t.Errorf("failed to sort by header: expected %q, got %q", "C  A ▲  ", text)
+	}
+	if text := line(1); text != "c2 a    " {
+		t.Errorf("failed to sort by header: expected %q, got %q", "c2 a    ", text)
+	}
+
+	// Dragging the border of a header resizes its column
+	mouse(MouseLeftDown, 2, 0)
+	mouse(MouseMove, 5, 0)
+	mouse(MouseLeftUp, 5, 0)
+	if c.Width != 5 {
+		t.Errorf("failed to resize column: expected 5, got %d", c.Width)
+	}
+	if text := line(1); text != "c2    a " {
+		t.Errorf("failed to draw resized column: expected %q, got %q", "c2    a ", text)
+	}
+
+	// The selected column moves with the keyboard, past hidden columns
+	table.Select(0, 2)
+	table.InputHandler()(tcell.NewEventKey(tcell.KeyRight, 0, tcell.ModAlt), setFocus)
+	if columns := table.GetColumns(); columns[0] != a || columns[1] != c || columns[2] != b {
+		t.Errorf("failed to move column: expected A, C, B")
+	}
+
+	// The header menu shows and hides columns
+	mouse(MouseRightDown, 0, 0)
+	if _, ok := focus.(*List); !ok {
+		t.Fatalf("failed to open column menu: expected *List, got %T", focus)
+	}
+	focus.InputHandler()(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone), setFocus)
+	if !a.Hidden {
+		t.Errorf("failed to hide column from menu: expected A to be hidden")
+	}
+	if table.columnMenu.ContextMenuVisible() {
+		t.Errorf("failed to close column menu: expected menu to be hidden")
+	}
+
+	if len(layouts) != 4 {
+		t.Fatalf("failed to report layout changes: expected 4, got %d", len(layouts))
+	}
+	data, err := json.Marshal(layouts[3])
+	if err != nil {
+		t.Fatalf("failed to encode layout: %s", err)
+	}
+	expected := `{"columns":[{"index":0,"width":3,"hidden":true},{"index":2,"width":5},{"index":1,"hidden":true}],"sorted":true}`
+	if string(data) != expected {
+		t.Errorf("failed to encode layout: expected %s, got %s", expected, data)
+	}
+
+	// A layout is restored on another table
+	var layout TableLayout
+	if err := json.Unmarshal(data, &layout); err != nil {
+		t.Fatalf("failed to decode layout: %s", err)
+	}
+	restored := NewTable()
+	restored.SetCellSimple(0, 0, "b").SetCellSimple(1, 0, "a")
+	restored.SetColumns(NewTableColumn(0, "A"), NewTableColumn(1, "B"), NewTableColumn(2, "C"), NewTableColumn(3, "D"))
+	restored.SetLayout(layout)
+	var order []int
+	for _, column := range restored.GetColumns() {
+		order = append(order, column.Index)
+	}
+	if fmt.Sprint(order) != "[0 2 1 3]" {
+		t.Errorf("failed to restore order: expected [0 2 1 3], got %v", order)
+	}
+	if text := restored.GetCell(0, 0).GetText(); text != "a" {
+		t.Errorf("failed to restore sort order: expected a, got %s", text)
+	}
+
+	// The zero layout does not sort and widths are limited
+	unsorted := NewTable()
+	unsorted.SetCellSimple(0, 0, "b").SetCellSimple(1, 0, "a")
+	unsorted.SetColumns(NewTableColumn(0, "A").SetMaxWidth(4))
+	unsorted.SetLayout(TableLayout{Columns: []TableColumnLayout{{Index: 0, Width: 10}}})
+	if text := unsorted.GetCell(0, 0).GetText(); text != "b" {
+		t.Errorf("failed to keep order of unsorted layout: expected b, got %s", text)
+	}
+	if width := unsorted.GetColumns()[0].Width; width != 4 {
+		t.Errorf("failed to limit restored width: expected 4, got %d", width)
+	}
+}
+
+func TestTableMultiSelect(t *testing.T) {
+	t.Parallel()
+
+	table := NewTable().SetFixed(1, 0).SetSelectable(true, false).SetMultiSelect(true)
+	for row, text := range []string{"name", "delta", "alpha", "charlie", "bravo"} {
+		table.SetCellSimple(row, 0, text)
+	}
+	table.Select(1, 0)
+
+	var changed []int
+	table.SetSelectedRowsChangedFunc(func(rows []int) { changed = rows })
+	press := func(key tcell.Key, r rune, mod tcell.ModMask) {
+		table.InputHandler()(tcell.NewEventKey(key, r, mod), func(p Widget) {})
+	}
+	texts := func() (texts []string) {
+		for _, row := range table.GetSelectedRows() {
+			texts = append(texts, table.GetCell(row, 0).GetText())
+		}
+		return
+	}
+
+	// Space toggles rows, Shift+Down selects a range
+	press(tcell.KeyRune, ' ', tcell.ModNone)
+	press(tcell.KeyDown, 0, tcell.ModNone)
+	press(tcell.KeyDown, 0, tcell.ModNone)
+	press(tcell.KeyRune, ' ', tcell.ModNone)
+	press(tcell.KeyDown, 0, tcell.ModShift)
+	if fmt.Sprint(changed) != "[1 3 4]" {
+		t.Errorf("failed to select rows: expected [1 3 4], got %v", changed)
+	}
+	if row, _ := table.GetSelection(); row != 4 {
+		t.Errorf("failed to move with range: expected 4, got %d", row)
+	}
+
+	// The selected rows follow their cells when sorted
+	table.Sort(0, false)
+	if selected := fmt.Sprint(texts()); selected != "[bravo charlie delta]" {
+		t.Errorf("failed to keep selection when sorting: expected [bravo charlie delta], got %s", selected)
+	}
+
+	// Removing and inserting rows shifts the selection
+	table.RemoveRow(1)
+	table.InsertRow(1)
+	if rows := fmt.Sprint(table.GetSelectedRows()); rows != "[2 3 4]" {
+		t.Errorf("failed to shift selection: expected [2 3 4], got %s", rows)
+	}
+
+	// Ctrl+A selects all rows but the fixed ones
+	press(tcell.KeyCtrlA, 0, tcell.ModCtrl)
+	if fmt.Sprint(changed) != "[1 2 3 4]" {
+		t.Errorf("failed to select all rows: expected [1 2 3 4], got %v", changed)
+	}
+	table.ClearSelection()
+	if rows := table.GetSelectedRows(); len(rows) != 0 {
+		t.Errorf("failed to clear selection: expected no rows, got %v", rows)
+	}
+}
@@ -1,6 +1,7 @@
 package cui
 
 import (
+	"io"
 	"sync"
 
 	"github.com/gdamore/tcell/v2"
@@ -19,16 +20,35 @@ type Terminal struct {
 	w       int
 	h       int
 
+	// The clipboard selected text is copied to, and whether programs may
+	// read it.
+	clipboard     Clipboard
+	clipboardRead bool
+
+	// Whether the selection is being extended by dragging the mouse.
+	dragging       bool
+	dragX, dragY   int
+	dragged        bool
+	copyMode       bool
+	copyX, copyY   int
+	selecting      bool
+	searching      bool
+	searchBackward bool
+	searchQuery    []rune
+
 	mu sync.RWMutex
 }
 
 func NewTerminal(app *App, opt pty.Options) *Terminal {
 	t := &Terminal{
-		box:  NewBox(),
-		term: vte.New(),
-		app:  app,
-		opt:  opt,
+		box:       NewBox(),
+		term:      vte.New(),
+		app:       app,
+		opt:       opt,
+		clipboard: SystemClipboard,
 	}
+	// Queries are answered depending on SetClipboardReadable.
+	t.term.OSC52Read = true
 	return t
 }
 
@@ -262,6 +282,138 @@ func (t *Terminal) Blur() {
 
 ////////////////////////////////// <API> ////////////////////////////////////
 
+// SetScrollback sets the maximum number of lines kept in the scrollback
+// history of this Terminal. Zero disables the history.
+func (t *Terminal) SetScrollback(lines int) *Terminal {
+	t.term.SetScrollback(lines)
+	return t
+}
+
+// GetScrollback returns the maximum number of lines kept in the scrollback
+// history of this Terminal.
+func (t *Terminal) GetScrollback() int {
+	return t.term.Scrollback()
+}
+
+// GetHistoryLen returns the number of lines in the scrollback history.
+func (t *Terminal) GetHistoryLen() int {
+	return t.term.HistoryLen()
+}
+
+// ScrollBack scrolls the view n lines back into the scrollback history.
+// Negative values scroll forward.
+func (t *Terminal) ScrollBack(n int) *Terminal {
+	t.term.ScrollBack(n)
+	return t
+}
+
+// ScrollToBottom scrolls the view back to the bottom of the scrollback history.
+func (t *Terminal) ScrollToBottom() *Terminal {
+	t.term.ScrollToBottom()
+	return t
+}
+
+// SetClipboard sets the clipboard selected text is copied to. The system
+// clipboard is used by default.
+func (t *Terminal) SetClipboard(clipboard Clipboard) *Terminal {
+	return t.set(func(t *Terminal) { t.clipboard = clipboard })
+}
+
+// SetClipboardReadable sets whether programs running in the terminal may read
+// the clipboard with OSC 52 queries. This lets any program, including one on
+// a remote host or a file printed with cat, read what was copied on the host,
+// so it is disabled by default. Programs may always set the clipboard.
+func (t *Terminal) SetClipboardReadable(readable bool) *Terminal {
+	return t.set(func(t *Terminal) { t.clipboardRead = readable })
+}
+
+// GetSelectedText returns the selected text. Wrapped lines are joined.
+func (t *Terminal) GetSelectedText() string {
+	return t.term.SelectedText()
+}
+
+// Copy copies the selected text to the clipboard.
+func (t *Terminal) Copy() error {
+	var clipboard Clipboard
+	t.get(func(t *Terminal) { clipboard = t.clipboard })
+	text := t.term.SelectedText()
+	if clipboard == nil || text == "" {
+		return nil
+	}
+	return clipboard.WriteAll(text)
+}
+
+// ClearSelection removes the selection.
+func (t *Terminal) ClearSelection() *Terminal {
+	t.term.ClearSelection()
+	return t
+}
+
+// Search selects the nearest match of the query in the screen and the
+// scrollback history, starting at the selection, and scrolls the view to it.
+// It returns whether there was a match.
+func (t *Terminal) Search(query string, backward bool) bool {
+	return t.term.Search(query, backward)
+}
+
+// SearchNext selects the next match of the query after the selection, or
+// before it when searching backward.
+func (t *Terminal) SearchNext(query string, backward bool) bool {
+	return t.term.SearchNext(query, backward)
+}
+
+// ScrollToPrompt scrolls the view to the previous or the next shell prompt.
+// It returns false if there is no such prompt.
+func (t *Terminal) ScrollToPrompt(backward bool) bool {
+	return t.term.ScrollToPrompt(backward)
+}
+
+// GetWorkingDirectory returns the working directory last reported by the
+// program.
+func (t *Terminal) GetWorkingDirectory() string {
+	return t.term.WorkingDirectory()
+}
+
+// StartRecording starts recording the output of the program to w in the
+// asciicast v2 format, along with the input sent to it if input is set. The
+// recording may be replayed with a [Player].
+func (t *Terminal) StartRecording(w io.Writer, input bool) error {
+	return t.term.StartRecording(w, input)
+}
+
+// StopRecording stops recording. It returns the first error writing the
+// recording.
+func (t *Terminal) StopRecording() error {
+	return t.term.StopRecording()
+}
+
+// SetCopyMode enters or leaves copy mode. In copy mode, keys move a cursor
+// which may be used to select, search and copy text instead of being sent to
+// the program.
+func (t *Terminal) SetCopyMode(copyMode bool) *Terminal {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.setCopyMode(copyMode)
+	return t
+}
+
+// GetCopyMode returns whether this Terminal is in copy mode.
+func (t *Terminal) GetCopyMode() (copyMode bool) {
+	t.get(func(t *Terminal) { copyMode = t.copyMode })
+	return
+}
+
+func (t *Terminal) setCopyMode(copyMode bool) {
+	t.copyMode = copyMode
+	t.selecting = false
+	t.searching = false
+	t.term.ClearSelection()
+	if copyMode {
+		cy, cx, _, _ := t.term.Cursor()
+		t.copyX, t.copyY = cx, cy
+	}
+}
+
 func (t *Terminal) Draw(s tcell.Screen) {
 	if !t.GetVisible() {
 		return
@@ -291,6 +443,10 @@ func (t *Terminal) Draw(s tcell.Screen) {
 	}
 	if t.HasFocus() {
 		cy, cx, style, vis := t.term.Cursor()
+		if t.copyMode {
+			cx, cy, vis = t.copyX, t.copyY, true
+			style = tcell.CursorStyleSteadyBlock
+		}
 		if vis {
 			s.ShowCursor(cx+x, cy+y)
 			s.SetCursorStyle(style)
@@ -299,28 +455,244 @@ func (t *Terminal) Draw(s tcell.Screen) {
 		}
 	}
 	t.term.Draw()
+
+	if t.searching && h > 0 {
+		prompt := "/"
+		if t.searchBackward {
+			prompt = "?"
+		}
+		prompt += string(t.searchQuery)
+		style := tcell.StyleDefault.Reverse(true)
+		for i := 0; i < w; i++ {
+			s.SetContent(x+i, y+h-1, ' ', nil, style)
+		}
+		PrintStyle(s, []byte(prompt), x, y+h-1, w, AlignLeft, style)
+		if t.HasFocus() {
+			s.ShowCursor(x+min(len(prompt), w-1), y+h-1)
+		}
+	}
 }
 
 func (t *Terminal) HandleEvent(ev tcell.Event) {
-	switch ev.(type) {
+	switch ev := ev.(type) {
 	case *vte.EventRedraw:
 		go func() {
 			t.app.QueueUpdateDraw(func() {})
 		}()
+	case *vte.EventClipboard:
+		var clipboard Clipboard
+		var readable bool
+		t.get(func(t *Terminal) { clipboard, readable = t.clipboard, t.clipboardRead })
+		if clipboard == nil {
+			return
+		}
+		switch {
+		case !ev.Query():
+			_ = clipboard.WriteAll(ev.Text())
+		case readable:
+			if text, err := clipboard.ReadAll(); err == nil {
+				t.term.ReplyClipboard(ev.Selection(), text)
+			}
+		}
 	}
 }
 
 func (t *Terminal) InputHandler() func(event *tcell.EventKey, setFocus func(p Widget)) {
 	return t.WrapInputHandler(func(event *tcell.EventKey, setFocus func(p Widget)) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		switch {
+		case t.searching:
+			t.handleSearch(event)
+			return
+		case t.copyMode:
+			t.handleCopyMode(event)
+			return
+		case HitShortcut(event, Keys.TerminalScrollBack):
+			t.term.ScrollBack(t.h)
+			return
+		case HitShortcut(event, Keys.TerminalScrollForward):
+			t.term.ScrollBack(-t.h)
+			return
+		case HitShortcut(event, Keys.TerminalCopyMode):
+			t.setCopyMode(true)
+			return
+		case HitShortcut(event, Keys.TerminalPreviousPrompt):
+			t.term.ScrollToPrompt(true)
+			return
+		case HitShortcut(event, Keys.TerminalNextPrompt):
+			t.term.ScrollToPrompt(false)
+			return
+		}
+		t.term.ClearSelection()
 		t.term.HandleEvent(event)
 	})
 }
 
+// handleCopyMode handles a key event in copy mode.
+func (t *Terminal) handleCopyMode(event *tcell.EventKey) {
+	switch {
+	case HitShortcut(event, Keys.Cancel, Keys.TerminalCopyMode):
+		t.setCopyMode(false)
+		return
+	case HitShortcut(event, Keys.Select):
+		t.copy()
+		t.setCopyMode(false)
+		return
+	case HitShortcut(event, Keys.Select2):
+		t.selecting = !t.selecting
+		if t.selecting {
+			t.term.StartSelection(t.copyX, t.copyY)
+		} else {
+			t.term.ClearSelection()
+		}
+		return
+	case HitShortcut(event, Keys.TerminalSearch, Keys.TerminalSearchBackward):
+		t.searching = true
+		t.searchBackward = HitShortcut(event, Keys.TerminalSearchBackward)
+		t.searchQuery = nil
+		return
+	case HitShortcut(event, Keys.TerminalSearchNext, Keys.TerminalSearchPrevious):
+		backward := t.searchBackward != HitShortcut(event, Keys.TerminalSearchPrevious)
+		if t.term.SearchNext(string(t.searchQuery), backward) {
+			t.moveToSelection()
+		}
+		return
+	case HitShortcut(event, Keys.MoveUp, Keys.MoveUp2):
+		t.moveCopyCursor(0, -1)
+	case HitShortcut(event, Keys.MoveDown, Keys.MoveDown2):
+		t.moveCopyCursor(0, 1)
+	case HitShortcut(event, Keys.MoveLeft, Keys.MoveLeft2):
+		t.moveCopyCursor(-1, 0)
+	case HitShortcut(event, Keys.MoveRight, Keys.MoveRight2):
+		t.moveCopyCursor(1, 0)
+	case HitShortcut(event, Keys.MoveFirst, Keys.MoveFirst2):
+		t.moveCopyCursor(-t.copyX, 0)
+	case HitShortcut(event, Keys.MoveLast, Keys.MoveLast2):
+		t.moveCopyCursor(t.w-1-t.copyX, 0)
+	case HitShortcut(event, Keys.MovePreviousPage, Keys.TerminalScrollBack):
+		t.moveCopyCursor(0, -t.h)
+	case HitShortcut(event, Keys.MoveNextPage, Keys.TerminalScrollForward):
+		t.moveCopyCursor(0, t.h)
+	default:
+		return
+	}
+	if t.selecting {
+		t.term.ExtendSelection(t.copyX, t.copyY)
+	}
+}
+
+// moveCopyCursor moves the copy mode cursor, scrolling the view when the
+// cursor leaves it.
+func (t *Terminal) moveCopyCursor(dx, dy int) {
+	t.copyX = max(0, min(t.copyX+dx, t.w-1))
+	t.copyY += dy
+	if t.copyY < 0 {
+		t.term.ScrollBack(-t.copyY)
+		t.copyY = 0
+	} else if t.copyY >= t.h {
+		t.term.ScrollBack(t.h - 1 - t.copyY)
+		t.copyY = max(0, t.h-1)
+	}
+}
+
+// moveToSelection moves the copy mode cursor to the start of the selection.
+func (t *Terminal) moveToSelection() {
+	if x, y, ok := t.term.SelectionStart(); ok {
+		t.copyX, t.copyY = x, y
+		t.selecting = false
+	}
+}
+
+// handleSearch handles a key event while entering a search query.
+func (t *Terminal) handleSearch(event *tcell.EventKey) {
+	switch event.Key() {
+	case tcell.KeyEscape:
+		t.searching = false
+		t.term.ClearSelection()
+		return
+	case tcell.KeyEnter:
+		t.searching = false
+		return
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if len(t.searchQuery) == 0 {
+			t.searching = false
+			return
+		}
+		t.searchQuery = t.searchQuery[:len(t.searchQuery)-1]
+	case tcell.KeyRune:
+		t.searchQuery = append(t.searchQuery, event.Rune())
+	default:
+		return
+	}
+	if t.term.Search(string(t.searchQuery), t.searchBackward) {
+		t.moveToSelection()
+	}
+}
+
+// copy copies the selected text to the clipboard.
+func (t *Terminal) copy() {
+	if text := t.term.SelectedText(); text != "" && t.clipboard != nil {
+		_ = t.clipboard.WriteAll(text)
+	}
+}
+
 func (t *Terminal) MouseHandler() func(action MouseAction, event *tcell.EventMouse, setFocus func(p Widget)) (consumed bool, capture Widget) {
 	return t.WrapMouseHandler(func(action MouseAction, event *tcell.EventMouse, setFocus func(p Widget)) (consumed bool, capture Widget) {
-		if action == MouseLeftClick && t.InRect(event.Position()) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		x, y := event.Position()
+		rectX, rectY, _, _ := t.GetInnerRect()
+		if t.dragging {
+			// Select text by dragging the mouse.
+			switch action {
+			case MouseMove:
+				if x-rectX != t.dragX || y-rectY != t.dragY {
+					t.dragged = true
+				}
+				if t.dragged {
+					t.term.ExtendSelection(x-rectX, y-rectY)
+				}
+				return true, t
+			case MouseLeftUp:
+				t.dragging = false
+				if t.dragged {
+					t.copy()
+				} else {
+					t.term.ClearSelection()
+				}
+				return true, nil
+			}
+			return true, t
+		}
+		if !t.InRect(x, y) {
+			return false, nil
+		}
+
+		// Text is selected with the mouse unless the program reports mouse
+		// events, which may be bypassed by holding shift.
+		selectable := !t.term.MouseReporting() || event.Modifiers()&tcell.ModShift != 0
+		switch action {
+		case MouseLeftDown:
+			setFocus(t)
+			if !selectable {
+				return false, nil
+			}
+			t.dragging, t.dragged = true, false
+			t.dragX, t.dragY = x-rectX, y-rectY
+			t.term.StartSelection(t.dragX, t.dragY)
+			return true, t
+		case MouseLeftClick:
 			setFocus(t)
+			if selectable {
+				return true, nil
+			}
 			return t.term.HandleEvent(event), nil
+		case MouseScrollUp, MouseScrollDown:
+			t.term.HandleEvent(event)
+			return true, nil
 		}
 		return false, nil
 	})
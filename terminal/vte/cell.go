@@ -8,6 +8,10 @@ type cell struct {
 	width     int
 	attrs     tcell.Style
 	wrapped   bool
+	// prompt marks the first cell of a line holding a shell prompt
+	prompt bool
+	// image identifies the image the cell shows a part of, if not zero
+	image uint32
 }
 
 func (c *cell) rune() rune {
@@ -25,6 +29,8 @@ func (c *cell) erase(s tcell.Style) {
 	_, bg, _ := s.Decompose()
 	c.content = 0
 	c.attrs = tcell.StyleDefault.Background(bg)
+	c.prompt = false
+	c.image = 0
 }
 
 // selectiveErase removes the cell content, but keeps the attributes
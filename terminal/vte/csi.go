@@ -78,7 +78,7 @@ func (vt *VT) csi(csi string, params []int) {
 		resp.WriteString("22")
 		// Response terminator
 		resp.WriteString("c")
-		vt.pty.Write([]byte(resp.String()))
+		vt.respond(resp.String())
 	case "d":
 		vt.vpa(ps(params))
 	case "e":
@@ -103,16 +103,26 @@ func (vt *VT) csi(csi string, params []int) {
 		switch ps(params) {
 		case 5:
 			// "Ok"
-			vt.pty.Write([]byte("\x1B[0n"))
+			vt.respond("\x1B[0n")
 		case 6:
 			// report cursor position
 			// This sequence can be identical to a function key?
 			// CSI r ; c R
 			resp := fmt.Sprintf("\x1B[%d;%dR", vt.cursor.row+1, vt.cursor.col+1)
-			vt.pty.Write([]byte(resp))
+			vt.respond(resp)
 		}
 	case "r":
 		vt.decstbm(params)
+	case "t":
+		// Window manipulation. Only the size reports are supported
+		switch ps(params) {
+		case 14:
+			vt.respond(fmt.Sprintf("\x1B[4;%d;%dt", vt.height()*vt.cellHeight, vt.width()*vt.cellWidth))
+		case 16:
+			vt.respond(fmt.Sprintf("\x1B[6;%d;%dt", vt.cellHeight, vt.cellWidth))
+		case 18:
+			vt.respond(fmt.Sprintf("\x1B[8;%d;%dt", vt.height(), vt.width()))
+		}
 	case "s":
 		vt.decsc()
 	case "u":
@@ -1,5 +1,7 @@
 package vte
 
+import "github.com/gdamore/tcell/v2"
+
 func (vt *VT) esc(esc string) {
 	switch esc {
 	case "7":
@@ -169,6 +171,9 @@ func (vt *VT) ris() {
 	vt.cursor.col = 0
 	vt.lastCol = false
 	vt.activeScreen = vt.primaryScreen
+	vt.palette = nil
+	vt.foreground = tcell.ColorDefault
+	vt.background = defaultBackground
 	vt.charsets = charsets{
 		selected: 0,
 		saved:    0,
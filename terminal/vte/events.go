@@ -67,3 +67,75 @@ type EventPanic struct {
 	*EventTerminal
 	Error error
 }
+
+// EventClipboard is emitted when the program sets or queries the clipboard
+// with OSC 52. Queries are only reported if VT.OSC52Read is set and are
+// answered with VT.ReplyClipboard
+type EventClipboard struct {
+	*EventTerminal
+	selection string
+	text      string
+	query     bool
+}
+
+// Selection returns the clipboard selection, such as "c" for the clipboard
+// or "p" for the primary selection
+func (ev *EventClipboard) Selection() string {
+	return ev.selection
+}
+
+// Text returns the text the clipboard is set to
+func (ev *EventClipboard) Text() string {
+	return ev.text
+}
+
+// Query reports whether the program queries the clipboard
+func (ev *EventClipboard) Query() bool {
+	return ev.query
+}
+
+// EventWorkingDirectory is emitted when the program reports its working
+// directory with OSC 7
+type EventWorkingDirectory struct {
+	*EventTerminal
+	host string
+	dir  string
+}
+
+// Host returns the host name of the working directory
+func (ev *EventWorkingDirectory) Host() string {
+	return ev.host
+}
+
+// Dir returns the path of the working directory
+func (ev *EventWorkingDirectory) Dir() string {
+	return ev.dir
+}
+
+// Shell integration marks of OSC 133
+const (
+	PromptStart     = 'A'
+	CommandStart    = 'B'
+	CommandExecuted = 'C'
+	CommandFinished = 'D'
+)
+
+// EventPrompt is emitted when the shell marks the start of a prompt, the
+// start or execution of a command, or the end of its output with OSC 133
+type EventPrompt struct {
+	*EventTerminal
+	mark     rune
+	exitCode int
+}
+
+// Mark returns the kind of the mark, one of PromptStart, CommandStart,
+// CommandExecuted and CommandFinished
+func (ev *EventPrompt) Mark() rune {
+	return ev.mark
+}
+
+// ExitCode returns the exit code of a finished command, or -1 if it is
+// unknown
+func (ev *EventPrompt) ExitCode() int {
+	return ev.exitCode
+}
@@ -0,0 +1,178 @@
+package vte
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+const (
+	// maxImageSize is the maximum width and height of images in pixels
+	maxImageSize = 4096
+	// maxImages is the maximum number of stored images, and maxImagePixels
+	// the maximum number of their pixels in total
+	maxImages      = 256
+	maxImagePixels = 4 * maxImageSize * maxImageSize
+	// anonymousImage identifies the cells of images placed without an id
+	anonymousImage = ^uint32(0)
+
+	// the default size of a cell in pixels, used to place images
+	defaultCellWidth  = 10
+	defaultCellHeight = 20
+)
+
+// SetCellSize sets the size of a cell in pixels. Images are scaled to cells
+// accordingly, and programs may query the size to fit their images
+func (vt *VT) SetCellSize(width int, height int) {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	vt.cellWidth = max(width, 1)
+	vt.cellHeight = max(height, 1)
+}
+
+// CellSize returns the size of a cell in pixels
+func (vt *VT) CellSize() (int, int) {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	return vt.cellWidth, vt.cellHeight
+}
+
+// imageCells returns the number of columns and rows an image covers. If only
+// one of cols and rows is given, the other is derived from the aspect ratio
+// of the image. If none is given, the size is derived from the cell size.
+// The image is at most as wide as the screen and as high as the maximum
+// image size
+func (vt *VT) imageCells(img image.Image, cols int, rows int) (int, int) {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	maxCols, maxRows := max(vt.width(), 1), max(maxImageSize/vt.cellHeight, 1)
+	cols, rows = min(cols, maxCols), min(rows, maxRows)
+	switch {
+	case cols > 0 && rows > 0:
+	case cols > 0:
+		rows = (h*cols*vt.cellWidth + w*vt.cellHeight - 1) / (w * vt.cellHeight)
+	case rows > 0:
+		cols = (w*rows*vt.cellHeight + h*vt.cellWidth - 1) / (h * vt.cellWidth)
+	default:
+		cols = (w + vt.cellWidth - 1) / vt.cellWidth
+		rows = (h + vt.cellHeight - 1) / vt.cellHeight
+	}
+	return max(min(cols, maxCols), 1), max(min(rows, maxRows), 1)
+}
+
+// placeImage draws the image into the cells starting at the cursor, which
+// anchors it to the text: it scrolls along and moves into the history. The
+// screen is scrolled if the image extends below the bottom margin. Each cell
+// shows two pixels with an upper half block, the upper one in the foreground
+// color and the lower one in the background color. The cursor is left on the
+// last row of the image
+func (vt *VT) placeImage(img image.Image, id uint32, cols int, rows int) {
+	if vt.height() == 0 {
+		return
+	}
+	bg := vt.background
+	if bg == tcell.ColorDefault {
+		bg = defaultBackground
+	}
+	br, bgg, bb := bg.RGB()
+	background := color.NRGBA{R: uint8(br), G: uint8(bgg), B: uint8(bb), A: 255}
+
+	left := vt.cursor.col
+	for r := 0; r < rows; r += 1 {
+		if r > 0 {
+			vt.ind()
+		}
+		line := vt.activeScreen[vt.cursor.row]
+		for c := 0; c < cols; c += 1 {
+			col := left + column(c)
+			if col > vt.margin.right {
+				break
+			}
+			top := sampleImage(img, background, c, 2*r, cols, 2*rows)
+			bottom := sampleImage(img, background, c, 2*r+1, cols, 2*rows)
+			line[col] = cell{
+				content: '▀',
+				width:   1,
+				attrs:   tcell.StyleDefault.Foreground(top).Background(bottom),
+				image:   id,
+			}
+		}
+	}
+	vt.lastCol = false
+	vt.dirty = true
+}
+
+// sampleImage returns the average color of the part of the image in the
+// given cell of a grid of the given size. Transparent pixels are blended with
+// the background
+func sampleImage(img image.Image, background color.NRGBA, x int, y int, cols int, rows int) tcell.Color {
+	b := img.Bounds()
+	x0 := b.Min.X + x*b.Dx()/cols
+	x1 := max(b.Min.X+(x+1)*b.Dx()/cols, x0+1)
+	y0 := b.Min.Y + y*b.Dy()/rows
+	y1 := max(b.Min.Y+(y+1)*b.Dy()/rows, y0+1)
+	var sum [3]uint64
+	var n uint64
+	for py := y0; py < y1 && py < b.Max.Y; py += 1 {
+		for px := x0; px < x1 && px < b.Max.X; px += 1 {
+			c := color.NRGBAModel.Convert(img.At(px, py)).(color.NRGBA)
+			a := uint64(c.A)
+			sum[0] += (uint64(c.R)*a + uint64(background.R)*(255-a)) / 255
+			sum[1] += (uint64(c.G)*a + uint64(background.G)*(255-a)) / 255
+			sum[2] += (uint64(c.B)*a + uint64(background.B)*(255-a)) / 255
+			n += 1
+		}
+	}
+	if n == 0 {
+		return tcell.NewRGBColor(int32(background.R), int32(background.G), int32(background.B))
+	}
+	return tcell.NewRGBColor(int32(sum[0]/n), int32(sum[1]/n), int32(sum[2]/n))
+}
+
+// eraseImages erases the cells of the images with the given id in the
+// screens and the history, or of all images if the id is zero
+func (vt *VT) eraseImages(id uint32) {
+	for _, lines := range [][][]cell{vt.history, vt.primaryScreen, vt.altScreen} {
+		for _, line := range lines {
+			for col := range line {
+				if line[col].image != 0 && (id == 0 || line[col].image == id) {
+					line[col] = cell{}
+				}
+			}
+		}
+	}
+	vt.dirty = true
+}
+
+// dcs handles the start of a DCS sequence. Sixel data is collected until
+// the end of the sequence
+func (vt *VT) dcs(seq DCS) {
+	vt.sixel = nil
+	if seq.Final == 'q' && len(seq.Intermediate) == 0 {
+		vt.sixel = newSixelDecoder()
+	}
+}
+
+func (vt *VT) dcsPut(r rune) {
+	if vt.sixel != nil {
+		vt.sixel.put(r)
+	}
+}
+
+// dcsEnd places the decoded sixel image at the cursor. The cursor is moved
+// to the line below the image
+func (vt *VT) dcsEnd() {
+	if vt.sixel == nil {
+		return
+	}
+	img := vt.sixel.image()
+	vt.sixel = nil
+	if img == nil {
+		return
+	}
+	col := vt.cursor.col
+	cols, rows := vt.imageCells(img, 0, 0)
+	vt.placeImage(img, anonymousImage, cols, rows)
+	vt.ind()
+	vt.cursor.col = col
+}
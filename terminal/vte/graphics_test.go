@@ -0,0 +1,156 @@
+package vte
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// imageCell returns the colors of the upper and the lower half of a cell
+func imageCell(vt *VT, col int, row int) (tcell.Color, tcell.Color) {
+	c := vt.activeScreen[row][col]
+	fg, bg, _ := c.attrs.Decompose()
+	return fg, bg
+}
+
+func TestSixelDecoder(t *testing.T) {
+	d := newSixelDecoder()
+	// Define red and green, draw 3 columns of red and a column of green in
+	// the first band and the top pixel of the second band
+	for _, r := range "\"1;1;4;12#1;2;100;0;0#2;2;0;100;0#1!3~#2~-#1@" {
+		d.put(r)
+	}
+	img := d.image().(*image.NRGBA)
+	assert.Equal(t, image.Rect(0, 0, 4, 12), img.Bounds())
+	assert.Equal(t, color.NRGBA{R: 255, A: 255}, img.NRGBAAt(2, 5))
+	assert.Equal(t, color.NRGBA{G: 255, A: 255}, img.NRGBAAt(3, 0))
+	assert.Equal(t, color.NRGBA{R: 255, A: 255}, img.NRGBAAt(0, 6))
+	assert.Equal(t, color.NRGBA{}, img.NRGBAAt(1, 6))
+
+	assert.Nil(t, newSixelDecoder().image())
+	assert.Equal(t, color.NRGBA{R: 255, A: 255}, hlsColor(120, 50, 100))
+	assert.Equal(t, color.NRGBA{B: 255, A: 255}, hlsColor(0, 50, 100))
+}
+
+func TestSixel(t *testing.T) {
+	vt := New()
+	vt.Resize(4, 2)
+	vt.SetCellSize(1, 2)
+	vt.print('x')
+	vt.update(DCS{Final: 'q', Parameters: []int{0, 1}})
+	for _, r := range "#1;2;100;0;0!2~-!2~" {
+		vt.update(DCSData(r))
+	}
+	vt.update(DCSEndOfData{})
+
+	// The image covers 2x12 pixels, which are 2x6 cells. It scrolls the
+	// screen and the cursor is placed below it
+	assert.Equal(t, 5, vt.HistoryLen())
+	fg, bg := imageCell(vt, 1, 0)
+	assert.Equal(t, tcell.NewRGBColor(255, 0, 0), fg)
+	assert.Equal(t, tcell.NewRGBColor(255, 0, 0), bg)
+	assert.Equal(t, row(1), vt.cursor.row)
+	assert.Equal(t, column(1), vt.cursor.col)
+
+	// Images scroll with the text into the history
+	vt.ScrollBack(5)
+	assert.Equal(t, "x▀▀ \n ▀▀ ", draw(vt))
+}
+
+func TestKittyGraphics(t *testing.T) {
+	vt := New()
+	vt.Resize(6, 4)
+	vt.SetCellSize(1, 2)
+
+	// Transmit a 2x4 PNG in chunks and display it
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 4))
+	for y := 0; y < 4; y += 1 {
+		for x := 0; x < 2; x += 1 {
+			img.SetNRGBA(x, y, color.NRGBA{B: uint8(y * 80), A: 255})
+		}
+	}
+	buf := bytes.Buffer{}
+	assert.NoError(t, png.Encode(&buf, img))
+	data := base64.StdEncoding.EncodeToString(buf.Bytes())
+	vt.apc("Ga=t,f=100,i=7,m=1;" + data[:8])
+	assert.NotNil(t, vt.kittyChunk)
+	vt.apc("Gm=0;" + data[8:])
+	assert.Nil(t, vt.kittyChunk)
+	assert.Contains(t, vt.images, uint32(7))
+
+	vt.apc("Ga=p,i=7")
+	assert.Equal(t, "▀▀    \n▀▀    \n      \n      ", vt.String())
+	assert.Equal(t, row(1), vt.cursor.row)
+	assert.Equal(t, column(2), vt.cursor.col)
+	fg, bg := imageCell(vt, 0, 1)
+	assert.Equal(t, tcell.NewRGBColor(0, 0, 160), fg)
+	assert.Equal(t, tcell.NewRGBColor(0, 0, 240), bg)
+
+	// Raw RGB data scaled to the requested cells without moving the cursor
+	vt.apc("Ga=T,f=24,s=1,v=1,c=3,r=1,C=1;" + base64.StdEncoding.EncodeToString([]byte{255, 0, 0}))
+	assert.Equal(t, "▀▀    \n▀▀▀▀▀ \n      \n      ", vt.String())
+	assert.Equal(t, column(2), vt.cursor.col)
+
+	// Delete the image by id, keeping the other image
+	vt.apc("Ga=d,d=I,i=7")
+	assert.Equal(t, "      \n  ▀▀▀ \n      \n      ", vt.String())
+	assert.NotContains(t, vt.images, uint32(7))
+	vt.apc("Ga=d")
+	assert.Equal(t, strings.Repeat(" ", 6), strings.Split(vt.String(), "\n")[1])
+
+	// Errors
+	assert.Equal(t, errKittyUnsupported, vt.kittyExec(parseKittyCommand("a=T,t=f")))
+	assert.Error(t, vt.kittyExec(parseKittyCommand("a=p,i=7")))
+	assert.Error(t, vt.kittyExec(parseKittyCommand("a=T,f=24,s=2,v=2")))
+}
+
+func TestKittyGraphicsLimits(t *testing.T) {
+	vt := New()
+	vt.Resize(6, 4)
+	pixel := base64.StdEncoding.EncodeToString([]byte{255, 0, 0})
+
+	// Huge sizes are clamped to the screen width and the maximum image size
+	done := make(chan struct{})
+	go func() {
+		vt.Write([]byte("\x1b_Ga=T,f=24,s=1,v=1,r=200000000,c=200000000;" + pixel + "\x1b\\"))
+		vt.Write([]byte("\x1b_Ga=T,f=24,s=1,v=1,c=2000000000;" + pixel + "\x1b\\"))
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("placing a huge image did not finish")
+	}
+	assert.Equal(t, strings.Repeat("▀", 6), strings.Split(vt.String(), "\n")[0])
+	cols, rows := vt.imageCells(image.NewNRGBA(image.Rect(0, 0, 1, 1)), 0, 200000000)
+	assert.Equal(t, 6, cols)
+	assert.Equal(t, maxImageSize/defaultCellHeight, rows)
+
+	// The number of stored images is limited
+	transmit := func(id int) error {
+		cmd := parseKittyCommand(fmt.Sprintf("a=t,f=24,s=1,v=1,i=%d", id))
+		cmd.payload.WriteString(pixel)
+		return vt.kittyExec(cmd)
+	}
+	for id := 1; id <= maxImages; id += 1 {
+		assert.NoError(t, transmit(id))
+	}
+	assert.Error(t, transmit(maxImages+1))
+	assert.NoError(t, transmit(1))
+	assert.Len(t, vt.images, maxImages)
+
+	// APC strings are truncated
+	p := NewParser(strings.NewReader("\x1b_G" + strings.Repeat("A", maxAPCLength+10) + "\x1b\\"))
+	apc, ok := p.Next().(APC)
+	assert.True(t, ok)
+	assert.Len(t, apc.Payload, maxAPCLength)
+}
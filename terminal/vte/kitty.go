@@ -0,0 +1,271 @@
+package vte
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// kittyCommand is a command of the kitty graphics protocol
+type kittyCommand struct {
+	// action is one of t (transmit), T (transmit and display), p (display),
+	// q (query) and d (delete)
+	action rune
+	// format is 24 (RGB), 32 (RGBA) or 100 (PNG)
+	format int
+	// medium is the transmission medium. Only direct transmission is
+	// supported
+	medium rune
+	// width and height are the size of raw images in pixels
+	width, height int
+	id            uint32
+	// more is set if more chunks of the payload follow
+	more       bool
+	compressed bool
+	// cols and rows are the size the image is displayed with in cells
+	cols, rows int
+	// quiet suppresses OK responses if 1 and errors too if 2
+	quiet int
+	// noMove keeps the cursor in place after displaying the image
+	noMove bool
+	// delete is what to delete: a for all images or i for an image by id.
+	// Uppercase letters also free the image data
+	delete  rune
+	payload strings.Builder
+}
+
+// maxKittyPayload is the maximum length of the base64 payload of a command,
+// which fits an uncompressed RGBA image of the maximum size
+const maxKittyPayload = (maxImageSize*maxImageSize*4 + 2) / 3 * 4
+
+var errKittyUnsupported = errors.New("ENOTSUPPORTED:unsupported")
+
+// parseKittyCommand parses the control data of a command
+func parseKittyCommand(control string) *kittyCommand {
+	cmd := &kittyCommand{action: 't', format: 32, medium: 'd', delete: 'a'}
+	for _, kv := range strings.Split(control, ",") {
+		key, val, found := cutString(kv, "=")
+		if !found || len(key) != 1 || val == "" {
+			continue
+		}
+		n, _ := strconv.Atoi(val)
+		switch key[0] {
+		case 'a':
+			cmd.action = rune(val[0])
+		case 'f':
+			cmd.format = n
+		case 't':
+			cmd.medium = rune(val[0])
+		case 's':
+			cmd.width = n
+		case 'v':
+			cmd.height = n
+		case 'i':
+			id, _ := strconv.ParseUint(val, 10, 32)
+			cmd.id = uint32(id)
+		case 'm':
+			cmd.more = n == 1
+		case 'o':
+			cmd.compressed = val == "z"
+		case 'c':
+			cmd.cols = n
+		case 'r':
+			cmd.rows = n
+		case 'q':
+			cmd.quiet = n
+		case 'C':
+			cmd.noMove = n == 1
+		case 'd':
+			cmd.delete = rune(val[0])
+		}
+	}
+	return cmd
+}
+
+// apc handles APC sequences. Only kitty graphics commands are supported
+func (vt *VT) apc(data string) {
+	if payload, ok := strings.CutPrefix(data, "G"); ok {
+		vt.kittyGraphics(payload)
+	}
+}
+
+// kittyGraphics handles a command of the kitty graphics protocol. Payloads
+// may be transmitted in chunks, where the control data of the following
+// chunks is ignored apart from the m key
+func (vt *VT) kittyGraphics(data string) {
+	control, payload, _ := cutString(data, ";")
+	cmd := vt.kittyChunk
+	if cmd == nil {
+		cmd = parseKittyCommand(control)
+	} else {
+		cmd.more = parseKittyCommand(control).more
+	}
+	var err error
+	if cmd.payload.Len()+len(payload) > maxKittyPayload {
+		// the chunks are dropped until the last one
+		err = errors.New("EINVAL:payload too large")
+		cmd.payload.Reset()
+	} else {
+		cmd.payload.WriteString(payload)
+	}
+	if cmd.more {
+		vt.kittyChunk = cmd
+		return
+	}
+	vt.kittyChunk = nil
+
+	if err == nil {
+		err = vt.kittyExec(cmd)
+	}
+	if cmd.id == 0 {
+		return
+	}
+	switch {
+	case err == nil && cmd.quiet < 1:
+		vt.respond(fmt.Sprintf("\x1b_Gi=%d;OK\x1b\\", cmd.id))
+	case err != nil && cmd.quiet < 2:
+		vt.respond(fmt.Sprintf("\x1b_Gi=%d;%s\x1b\\", cmd.id, err))
+	}
+}
+
+func (vt *VT) kittyExec(cmd *kittyCommand) error {
+	switch cmd.action {
+	case 't', 'T', 'q':
+		img, err := cmd.decode()
+		if err != nil || cmd.action == 'q' {
+			return err
+		}
+		if cmd.id != 0 {
+			if err := vt.storeImage(cmd.id, img); err != nil {
+				return err
+			}
+		}
+		if cmd.action == 'T' {
+			vt.kittyPlace(cmd, img)
+		}
+	case 'p':
+		img, ok := vt.images[cmd.id]
+		if !ok {
+			return errors.New("ENOENT:image not found")
+		}
+		vt.kittyPlace(cmd, img)
+	case 'd':
+		switch cmd.delete {
+		case 'a':
+			vt.eraseImages(0)
+		case 'A':
+			vt.eraseImages(0)
+			vt.images = nil
+		case 'i':
+			vt.eraseImages(cmd.id)
+		case 'I':
+			vt.eraseImages(cmd.id)
+			delete(vt.images, cmd.id)
+		default:
+			return errKittyUnsupported
+		}
+	default:
+		return errKittyUnsupported
+	}
+	return nil
+}
+
+// storeImage stores the image with the given id, replacing the image with
+// the same id. It fails if the stored images would exceed the maximum count
+// or size
+func (vt *VT) storeImage(id uint32, img image.Image) error {
+	pixels := img.Bounds().Dx() * img.Bounds().Dy()
+	for other, stored := range vt.images {
+		if other != id {
+			pixels += stored.Bounds().Dx() * stored.Bounds().Dy()
+		}
+	}
+	_, replaced := vt.images[id]
+	if !replaced && len(vt.images) >= maxImages || pixels > maxImagePixels {
+		return errors.New("ENOSPC:too many images")
+	}
+	if vt.images == nil {
+		vt.images = map[uint32]image.Image{}
+	}
+	vt.images[id] = img
+	return nil
+}
+
+// kittyPlace displays the image at the cursor. Unless requested otherwise,
+// the cursor is moved to the cell after the last column of the image
+func (vt *VT) kittyPlace(cmd *kittyCommand, img image.Image) {
+	id := cmd.id
+	if id == 0 {
+		id = anonymousImage
+	}
+	col := vt.cursor.col
+	cols, rows := vt.imageCells(img, cmd.cols, cmd.rows)
+	vt.placeImage(img, id, cols, rows)
+	if cmd.noMove {
+		// the screen may have been scrolled to fit the image
+		vt.cursor.row = max(vt.cursor.row-row(rows-1), 0)
+		vt.cursor.col = col
+		return
+	}
+	vt.cursor.col = min(col+column(cols), vt.margin.right)
+}
+
+// decode decodes the transmitted image
+func (cmd *kittyCommand) decode() (image.Image, error) {
+	if cmd.medium != 'd' {
+		return nil, errKittyUnsupported
+	}
+	data, err := base64.StdEncoding.DecodeString(cmd.payload.String())
+	if err != nil {
+		return nil, errors.New("EINVAL:invalid base64 payload")
+	}
+	if cmd.compressed {
+		r, err := zlib.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, errors.New("EINVAL:invalid compressed payload")
+		}
+		data, err = io.ReadAll(io.LimitReader(r, maxImageSize*maxImageSize*4))
+		if err != nil {
+			return nil, errors.New("EINVAL:invalid compressed payload")
+		}
+	}
+
+	switch cmd.format {
+	case 100:
+		config, err := png.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			return nil, errors.New("EBADPNG:invalid PNG data")
+		}
+		if config.Width > maxImageSize || config.Height > maxImageSize {
+			return nil, errors.New("EINVAL:image too large")
+		}
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, errors.New("EBADPNG:invalid PNG data")
+		}
+		return img, nil
+	case 24, 32:
+		bpp := cmd.format / 8
+		w, h := cmd.width, cmd.height
+		if w <= 0 || h <= 0 || w > maxImageSize || h > maxImageSize || len(data) != w*h*bpp {
+			return nil, errors.New("EINVAL:invalid image size")
+		}
+		img := image.NewNRGBA(image.Rect(0, 0, w, h))
+		for i := 0; i < w*h; i += 1 {
+			px := data[i*bpp : i*bpp+bpp]
+			copy(img.Pix[i*4:], px)
+			if bpp == 3 {
+				img.Pix[i*4+3] = 255
+			}
+		}
+		return img, nil
+	}
+	return nil, errKittyUnsupported
+}
@@ -120,6 +120,7 @@ func (vt *VT) decset(params []int) {
 			vt.mode |= altScroll
 		case 1049:
 			vt.decsc()
+			vt.selection = selection{}
 			vt.activeScreen = vt.altScreen
 			vt.mode |= smcup
 			// Enable altScroll in the alt screen. This is only used
@@ -167,6 +168,7 @@ func (vt *VT) decrst(params []int) {
 				// Only clear if we were in the alternate
 				vt.ed(2)
 			}
+			vt.selection = selection{}
 			vt.activeScreen = vt.primaryScreen
 			vt.mode &^= smcup
 			vt.mode &^= altScroll
@@ -8,18 +8,28 @@ import (
 
 func (vt *VT) handleMouse(ev *tcell.EventMouse) string {
 	if vt.mode&mouseButtons == 0 && vt.mode&mouseDrag == 0 && vt.mode&mouseMotion == 0 && vt.mode&mouseSGR == 0 {
+		if vt.primary() {
+			// Scroll the view through the history, 3x rows
+			if ev.Buttons()&tcell.WheelUp != 0 {
+				vt.scrollBack(3)
+			}
+			if ev.Buttons()&tcell.WheelDown != 0 {
+				vt.scrollBack(-3)
+			}
+			return ""
+		}
 		if vt.mode&altScroll != 0 && vt.mode&smcup != 0 {
 			// Translate wheel motion into arrows up and down
 			// 3x rows
 			if ev.Buttons()&tcell.WheelUp != 0 {
-				vt.pty.Write([]byte(info.KeyUp))
-				vt.pty.Write([]byte(info.KeyUp))
-				vt.pty.Write([]byte(info.KeyUp))
+				vt.input([]byte(info.KeyUp))
+				vt.input([]byte(info.KeyUp))
+				vt.input([]byte(info.KeyUp))
 			}
 			if ev.Buttons()&tcell.WheelDown != 0 {
-				vt.pty.Write([]byte(info.KeyDown))
-				vt.pty.Write([]byte(info.KeyDown))
-				vt.pty.Write([]byte(info.KeyDown))
+				vt.input([]byte(info.KeyDown))
+				vt.input([]byte(info.KeyDown))
+				vt.input([]byte(info.KeyDown))
 			}
 		}
 		return ""
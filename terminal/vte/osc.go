@@ -1,28 +1,320 @@
 package vte
 
 import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strconv"
 	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+var (
+	// defaultForeground is reported for queries of the default foreground
+	// color if it was not set
+	defaultForeground = tcell.NewRGBColor(229, 229, 229)
+	// defaultBackground is the default background color
+	defaultBackground = tcell.NewRGBColor(0, 0, 0)
 )
 
 func (vt *VT) osc(data string) {
 	selector, val, found := cutString(data, ";")
-	if !found {
-		return
-	}
 	switch selector {
 	case "0", "2":
+		if !found {
+			return
+		}
 		ev := &EventTitle{
 			EventTerminal: newEventTerminal(vt),
 			title:         val,
 		}
 		vt.postEvent(ev)
+	case "4":
+		vt.respond(vt.oscPalette(val))
+	case "7":
+		vt.oscWorkingDirectory(val)
 	case "8":
-		if vt.OSC8 {
+		if vt.OSC8 && found {
 			url, id := osc8(val)
 			vt.cursor.attrs = vt.cursor.attrs.Url(url)
 			vt.cursor.attrs = vt.cursor.attrs.UrlId(id)
 		}
+	case "10", "11":
+		vt.respond(vt.oscDefaultColors(selector, val))
+	case "52":
+		if vt.OSC52 && found {
+			vt.osc52(val)
+		}
+	case "104":
+		vt.oscResetPalette(val)
+	case "110":
+		vt.foreground = tcell.ColorDefault
+	case "111":
+		vt.background = defaultBackground
+	case "133":
+		if found {
+			vt.osc133(val)
+		}
+	}
+}
+
+// respond writes the response to a query to the pty
+func (vt *VT) respond(resp string) {
+	if resp == "" || vt.pty == nil {
+		return
+	}
+	vt.pty.Write([]byte(resp))
+}
+
+// oscPalette sets or queries colors of the 256 color palette. The payload
+// is a list of index and color pairs, where a color of "?" queries the
+// current color. It returns the responses to the queries
+func (vt *VT) oscPalette(val string) string {
+	resp := strings.Builder{}
+	params := strings.Split(val, ";")
+	for i := 0; i+1 < len(params); i += 2 {
+		index, err := strconv.Atoi(params[i])
+		if err != nil || index < 0 || index > 255 {
+			continue
+		}
+		color := tcell.PaletteColor(index)
+		if params[i+1] == "?" {
+			resp.WriteString(oscColorResponse("4;"+params[i], vt.paletteColor(color)))
+			continue
+		}
+		if rgb, ok := parseColor(params[i+1]); ok {
+			if vt.palette == nil {
+				vt.palette = map[tcell.Color]tcell.Color{}
+			}
+			vt.palette[color] = rgb
+		}
+	}
+	return resp.String()
+}
+
+// oscResetPalette resets the given colors of the palette, or all colors if
+// none are given
+func (vt *VT) oscResetPalette(val string) {
+	if val == "" {
+		vt.palette = nil
+		return
+	}
+	for _, param := range strings.Split(val, ";") {
+		index, err := strconv.Atoi(param)
+		if err != nil {
+			continue
+		}
+		delete(vt.palette, tcell.PaletteColor(index))
+	}
+}
+
+// oscDefaultColors sets or queries the default foreground (10) and
+// background (11) colors. Additional colors of the payload apply to the
+// following selectors. It returns the responses to the queries
+func (vt *VT) oscDefaultColors(selector string, val string) string {
+	resp := strings.Builder{}
+	first, _ := strconv.Atoi(selector)
+	for i, spec := range strings.Split(val, ";") {
+		var color *tcell.Color
+		switch first + i {
+		case 10:
+			color = &vt.foreground
+		case 11:
+			color = &vt.background
+		default:
+			return resp.String()
+		}
+		if spec == "?" {
+			current := *color
+			switch {
+			case current == tcell.ColorDefault && first+i == 10:
+				current = defaultForeground
+			case current == tcell.ColorDefault:
+				current = defaultBackground
+			}
+			resp.WriteString(oscColorResponse(strconv.Itoa(first+i), current))
+			continue
+		}
+		if rgb, ok := parseColor(spec); ok {
+			*color = rgb
+		}
+	}
+	return resp.String()
+}
+
+// paletteColor returns the color the palette color is displayed with
+func (vt *VT) paletteColor(color tcell.Color) tcell.Color {
+	if rgb, ok := vt.palette[color]; ok {
+		return rgb
+	}
+	return color
+}
+
+// style returns the style a cell is displayed with, applying the palette
+// and the default colors
+func (vt *VT) style(s tcell.Style) tcell.Style {
+	fg, bg, _ := s.Decompose()
+	if fg == tcell.ColorDefault {
+		fg = vt.foreground
+	}
+	if bg == tcell.ColorDefault {
+		bg = vt.background
 	}
+	return s.Foreground(vt.paletteColor(fg)).Background(vt.paletteColor(bg))
+}
+
+// oscColorResponse formats the response to a color query in the X11 color
+// format
+func oscColorResponse(selector string, color tcell.Color) string {
+	r, g, b := color.RGB()
+	return fmt.Sprintf("\x1b]%s;rgb:%04x/%04x/%04x\x1b\\", selector, r*0x101, g*0x101, b*0x101)
+}
+
+// parseColor parses a color in the X11 "rgb:r/g/b" format with one to four
+// hex digits per component, or a color name or "#rrggbb" value understood by
+// tcell
+func parseColor(spec string) (tcell.Color, bool) {
+	if rgb, ok := strings.CutPrefix(spec, "rgb:"); ok {
+		parts := strings.Split(rgb, "/")
+		if len(parts) != 3 {
+			return tcell.ColorDefault, false
+		}
+		var components [3]int32
+		for i, part := range parts {
+			if len(part) < 1 || len(part) > 4 {
+				return tcell.ColorDefault, false
+			}
+			v, err := strconv.ParseUint(part, 16, 16)
+			if err != nil {
+				return tcell.ColorDefault, false
+			}
+			// scale the component to 8 bits
+			max := uint64(1)<<(4*len(part)) - 1
+			components[i] = int32(v * 255 / max)
+		}
+		return tcell.NewRGBColor(components[0], components[1], components[2]), true
+	}
+	color := tcell.GetColor(spec)
+	if color == tcell.ColorDefault {
+		return color, false
+	}
+	return color.TrueColor(), true
+}
+
+// oscWorkingDirectory handles the working directory reported by the shell
+// as a file URL
+func (vt *VT) oscWorkingDirectory(val string) {
+	u, err := url.Parse(val)
+	if err != nil || u.Scheme != "file" {
+		return
+	}
+	vt.workingDirectory = u.Path
+	vt.postEvent(&EventWorkingDirectory{
+		EventTerminal: newEventTerminal(vt),
+		host:          u.Host,
+		dir:           u.Path,
+	})
+}
+
+// osc52 sets or queries the clipboard. The payload is the selection
+// followed by the base64 encoded text, or "?" to query the clipboard
+func (vt *VT) osc52(val string) {
+	sel, data, found := cutString(val, ";")
+	if !found {
+		return
+	}
+	if sel == "" {
+		sel = "s0"
+	}
+	ev := &EventClipboard{
+		EventTerminal: newEventTerminal(vt),
+		selection:     sel,
+	}
+	if data == "?" {
+		if !vt.OSC52Read {
+			return
+		}
+		ev.query = true
+	} else {
+		text, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return
+		}
+		ev.text = string(text)
+	}
+	vt.postEvent(ev)
+}
+
+// ReplyClipboard answers a clipboard query of the program with the text
+func (vt *VT) ReplyClipboard(selection string, text string) {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	vt.respond(osc52Response(selection, text))
+}
+
+func osc52Response(selection string, text string) string {
+	return "\x1b]52;" + selection + ";" + base64.StdEncoding.EncodeToString([]byte(text)) + "\x1b\\"
+}
+
+// osc133 handles the shell integration marks. The start of a prompt is
+// marked in the first cell of the cursor line, the other marks are reported
+// as events
+func (vt *VT) osc133(val string) {
+	kind, params, _ := cutString(val, ";")
+	if len(kind) != 1 {
+		return
+	}
+	ev := &EventPrompt{
+		EventTerminal: newEventTerminal(vt),
+		mark:          rune(kind[0]),
+		exitCode:      -1,
+	}
+	switch ev.mark {
+	case PromptStart:
+		vt.activeScreen[vt.cursor.row][0].prompt = true
+	case CommandStart, CommandExecuted:
+	case CommandFinished:
+		code, _, _ := cutString(params, ";")
+		if n, err := strconv.Atoi(code); err == nil {
+			ev.exitCode = n
+		}
+	default:
+		return
+	}
+	vt.postEvent(ev)
+}
+
+// ScrollToPrompt scrolls the view to show the previous prompt above the top
+// of the view at its top, or the next prompt below it when scrolling forward.
+// Prompts are marked by shells with OSC 133. It returns false if there is no
+// such prompt
+func (vt *VT) ScrollToPrompt(backward bool) bool {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	if !vt.primary() || vt.height() == 0 {
+		return false
+	}
+	top := vt.viewTop()
+	step := 1
+	if backward {
+		step = -1
+	}
+	for i := top + step; i >= 0 && i < vt.lineCount(); i += step {
+		if line := vt.line(i); len(line) > 0 && line[0].prompt {
+			vt.scrollBack(top - i)
+			return true
+		}
+	}
+	return false
+}
+
+// WorkingDirectory returns the working directory last reported by the
+// program with OSC 7
+func (vt *VT) WorkingDirectory() string {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	return vt.workingDirectory
 }
 
 // parses an osc8 payload into the URL and optional ID
@@ -3,6 +3,7 @@ package vte
 import (
 	"testing"
 
+	"github.com/gdamore/tcell/v2"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -72,3 +73,112 @@ func TestParseOSC8(t *testing.T) {
 		})
 	}
 }
+
+func TestOSC52(t *testing.T) {
+	vt := New()
+	vt.Resize(2, 2)
+	vt.osc("52;c;aGVsbG8=")
+	ev := (<-vt.events).(*EventClipboard)
+	assert.Equal(t, "c", ev.Selection())
+	assert.Equal(t, "hello", ev.Text())
+	assert.False(t, ev.Query())
+
+	// Queries are ignored unless reading the clipboard is enabled
+	vt.osc("52;;?")
+	assert.Len(t, vt.events, 0)
+	vt.OSC52Read = true
+	vt.osc("52;;?")
+	ev = (<-vt.events).(*EventClipboard)
+	assert.Equal(t, "s0", ev.Selection())
+	assert.True(t, ev.Query())
+	assert.Equal(t, "\x1b]52;c;aGVsbG8=\x1b\\", osc52Response("c", "hello"))
+
+	// Invalid payloads and disabled clipboard access are ignored
+	vt.osc("52;c;!!")
+	vt.OSC52 = false
+	vt.osc("52;c;aGVsbG8=")
+	assert.Len(t, vt.events, 0)
+}
+
+func TestOSC7(t *testing.T) {
+	vt := New()
+	vt.Resize(2, 2)
+	vt.osc("7;file://host/home/user%20name")
+	ev := (<-vt.events).(*EventWorkingDirectory)
+	assert.Equal(t, "host", ev.Host())
+	assert.Equal(t, "/home/user name", ev.Dir())
+	assert.Equal(t, "/home/user name", vt.WorkingDirectory())
+
+	vt.osc("7;http://host/path")
+	assert.Len(t, vt.events, 0)
+}
+
+func TestOSC133(t *testing.T) {
+	vt := New()
+	vt.Resize(4, 2)
+	prompt := func(cmd string) {
+		vt.osc("133;A")
+		assert.Equal(t, rune(PromptStart), (<-vt.events).(*EventPrompt).Mark())
+		printLines(vt, "$ "+cmd, "out")
+		vt.osc("133;D;1")
+		ev := (<-vt.events).(*EventPrompt)
+		assert.Equal(t, rune(CommandFinished), ev.Mark())
+		assert.Equal(t, 1, ev.ExitCode())
+	}
+	prompt("a")
+	prompt("b")
+	prompt("c")
+	assert.Equal(t, "out \n    ", draw(vt))
+
+	assert.True(t, vt.ScrollToPrompt(true))
+	assert.Equal(t, "$ c \nout ", draw(vt))
+	assert.True(t, vt.ScrollToPrompt(true))
+	assert.Equal(t, "$ b \nout ", draw(vt))
+	assert.True(t, vt.ScrollToPrompt(true))
+	assert.False(t, vt.ScrollToPrompt(true))
+	assert.Equal(t, "$ a \nout ", draw(vt))
+	assert.True(t, vt.ScrollToPrompt(false))
+	assert.Equal(t, "$ b \nout ", draw(vt))
+
+	// Marks are kept when rewrapping lines
+	vt.Resize(5, 2)
+	assert.True(t, vt.ScrollToPrompt(true))
+	assert.Equal(t, "$ c  \nout  ", draw(vt))
+
+	// Erasing the line removes the mark
+	vt.osc("133;A")
+	<-vt.events
+	vt.activeScreen[vt.cursor.row][0].erase(vt.cursor.attrs)
+	assert.False(t, vt.activeScreen[vt.cursor.row][0].prompt)
+	vt.osc("133;Z")
+	assert.Len(t, vt.events, 0)
+}
+
+func TestOSCColors(t *testing.T) {
+	vt := New()
+	vt.Resize(2, 2)
+
+	assert.Equal(t, "\x1b]4;1;rgb:8080/0000/0000\x1b\\", vt.oscPalette("1;?"))
+	assert.Equal(t, "", vt.oscPalette("1;rgb:ff/8/0;2;#0000ff"))
+	assert.Equal(t, "\x1b]4;1;rgb:ffff/8888/0000\x1b\\\x1b]4;2;rgb:0000/0000/ffff\x1b\\", vt.oscPalette("1;?;2;?"))
+	fg, _, _ := vt.style(tcell.StyleDefault.Foreground(tcell.ColorMaroon)).Decompose()
+	assert.Equal(t, tcell.NewRGBColor(255, 136, 0), fg)
+	vt.osc("104;1")
+	assert.Equal(t, "\x1b]4;1;rgb:8080/0000/0000\x1b\\", vt.oscPalette("1;?"))
+	vt.osc("104")
+	assert.Equal(t, "\x1b]4;2;rgb:0000/8080/0000\x1b\\", vt.oscPalette("2;?"))
+
+	assert.Equal(t, "\x1b]10;rgb:e5e5/e5e5/e5e5\x1b\\\x1b]11;rgb:0000/0000/0000\x1b\\", vt.oscDefaultColors("10", "?;?"))
+	assert.Equal(t, "", vt.oscDefaultColors("11", "rgb:1/2/3"))
+	assert.Equal(t, "\x1b]11;rgb:1111/2222/3333\x1b\\", vt.oscDefaultColors("11", "?"))
+	_, bg, _ := vt.style(tcell.StyleDefault).Decompose()
+	assert.Equal(t, tcell.NewRGBColor(0x11, 0x22, 0x33), bg)
+	vt.osc("111")
+	_, bg, _ = vt.style(tcell.StyleDefault).Decompose()
+	assert.Equal(t, tcell.NewRGBColor(0, 0, 0), bg)
+
+	_, ok := parseColor("rgb:1/2")
+	assert.False(t, ok)
+	_, ok = parseColor("nonsense")
+	assert.False(t, ok)
+}
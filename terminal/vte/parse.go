@@ -8,10 +8,15 @@ import (
 	"strings"
 	"sync"
 	"unicode"
+	"unicode/utf8"
 )
 
 const eof rune = -1
 
+// maxAPCLength is the maximum number of characters of an APC string. Larger
+// payloads, like images, are sent in chunks
+const maxAPCLength = 1 << 20
+
 // Parser is an implementation of Paul Flo Williams' VT500-series
 // parser, as seen [here](https://vt100.net/emu/dec_ansi_parser). The
 // architecture is designed after Rob Pike's text/template parser, with a
@@ -29,6 +34,13 @@ type Parser struct {
 	final        rune
 
 	oscData []rune
+	apcData []rune
+
+	// handler receives the sequences of a parser fed with feed instead of
+	// reading from a reader
+	handler func(Sequence)
+	// partial holds the bytes of an incomplete UTF-8 sequence passed to feed
+	partial []byte
 
 	// Seems to be required to avoid race-conditions when using the parser.
 	mu sync.Mutex
@@ -59,6 +71,7 @@ func NewParser(r io.Reader) *Parser {
 //	DCS            Signals start of a DCS sequence, and DCS params/intermediates
 //	DCSData        Raw DCS passthrough data
 //	DCSEndOfData   Signals end of DCS sequence
+//	APC            An APC sequence
 //	EOF            Sent at end of input
 func (p *Parser) Next() Sequence {
 	return <-p.sequences
@@ -98,9 +111,44 @@ func (p *Parser) readRune() rune {
 }
 
 func (p *Parser) emit(seq Sequence) {
+	if p.handler != nil {
+		p.handler(seq)
+		return
+	}
 	p.sequences <- seq
 }
 
+// newFeedParser returns a parser which passes the sequences of the data
+// passed to feed to the handler
+func newFeedParser(handler func(Sequence)) *Parser {
+	return &Parser{
+		state:   ground,
+		handler: handler,
+	}
+}
+
+// feed parses the data synchronously. Incomplete UTF-8 sequences at the end
+// of the data are kept until the next call
+func (p *Parser) feed(data []byte) {
+	if len(p.partial) > 0 {
+		data = append(p.partial, data...)
+		p.partial = nil
+	}
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		if r == utf8.RuneError && size == 1 {
+			if !utf8.FullRune(data) {
+				p.partial = append([]byte(nil), data...)
+				return
+			}
+			// If invalid UTF-8, deliver the byte as is
+			r = rune(data[0])
+		}
+		data = data[size:]
+		p.state = anywhere(r, p)
+	}
+}
+
 // This action only occurs in ground state. The current code should be mapped to
 // a glyph according to the character set mappings and shift states in effect,
 // and that glyph should be displayed. 20 (SP) and 7F (DEL) have special
@@ -327,6 +375,29 @@ func (p *Parser) put(r rune) {
 	p.emit(DCSData(r))
 }
 
+// apcStart registers apcEnd as the exit function. This will be called on when
+// the state moves from apcString to any other state
+func (p *Parser) apcStart() {
+	p.exit = p.apcEnd
+}
+
+// This action collects characters of the APC string. Characters beyond the
+// maximum length are dropped
+func (p *Parser) apcPut(r rune) {
+	if len(p.apcData) < maxAPCLength {
+		p.apcData = append(p.apcData, r)
+	}
+}
+
+// This action is called when the APC string is terminated by ST, CAN, SUB
+// or ESC
+func (p *Parser) apcEnd() {
+	p.emit(APC{
+		Payload: p.apcData,
+	})
+	p.apcData = []rune{}
+}
+
 // When a device control string is terminated by ST, CAN, SUB or ESC, this
 // action calls the previously selected handler function with an “end of
 // data” parameter. This allows the handler to finish neatly.
@@ -733,8 +804,11 @@ func escape(r rune, p *Parser) stateFn {
 	case is(r, 0x50):
 		p.clear()
 		return dcsEntry
-	case is(r, 0x58, 0x5E, 0x5F):
+	case is(r, 0x58, 0x5E):
 		return sosPmApc
+	case is(r, 0x5F):
+		p.apcStart()
+		return apcString
 	case is(r, 0x5B):
 		p.clear()
 		return csiEntry
@@ -785,6 +859,21 @@ func escapeIntermediate(r rune, p *Parser) stateFn {
 	}
 }
 
+// This state is entered when the control function APC (Application Program
+// Command) is recognised. The VT500 doesn’t define any function for APC, but
+// it is used by the kitty graphics protocol. All printable characters are
+// collected until the control string is terminated.
+func apcString(r rune, p *Parser) stateFn {
+	switch {
+	case in(r, 0x00, 0x17), is(r, 0x19), in(r, 0x1C, 0x1F):
+		// ignore
+		return apcString
+	default:
+		p.apcPut(r)
+		return apcString
+	}
+}
+
 // The VT500 doesn’t define any function for these control strings, so this
 // state ignores all received characters until the control function ST is
 // recognised.
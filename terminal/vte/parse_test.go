@@ -883,3 +883,58 @@ func TestOSC(t *testing.T) {
 		})
 	}
 }
+
+func TestAPC(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []Sequence
+	}{
+		{
+			name:  "APC end ST",
+			input: "a\x1b\x5FGa=T;AA==\x1b\x5Cb",
+			expected: []Sequence{
+				Print('a'),
+				APC{
+					Payload: []rune("Ga=T;AA=="),
+				},
+				ESC{
+					Final:        0x5C,
+					Intermediate: []rune{},
+				},
+				Print('b'),
+			},
+		},
+		{
+			name:  "PM ignored",
+			input: "a\x1b\x5Ex\x1b\x5Cb",
+			expected: []Sequence{
+				Print('a'),
+				ESC{
+					Final:        0x5C,
+					Intermediate: []rune{},
+				},
+				Print('b'),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := strings.NewReader(test.input)
+			parse := NewParser(r)
+			i := 0
+			for {
+				seq := parse.Next()
+				if seq == nil {
+					assert.Equal(t, len(test.expected), i, "wrong amount of sequences")
+					break
+				}
+				if i < len(test.expected) {
+					assert.Equal(t, test.expected[i], seq)
+				}
+				i += 1
+			}
+		})
+	}
+}
@@ -0,0 +1,270 @@
+package vte
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// CastHeader is the header of an asciicast v2 recording
+type CastHeader struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp,omitempty"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// Types of asciicast events
+const (
+	CastOutput = "o"
+	CastInput  = "i"
+	CastResize = "r"
+	CastMarker = "m"
+)
+
+// CastEvent is an event of an asciicast v2 recording. It is encoded as an
+// array of the time, the type and the data
+type CastEvent struct {
+	// Time is the time since the start of the recording
+	Time time.Duration
+	Type string
+	Data string
+}
+
+func (ev CastEvent) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]any{ev.Time.Seconds(), ev.Type, ev.Data})
+}
+
+func (ev *CastEvent) UnmarshalJSON(data []byte) error {
+	var (
+		secs float64
+		raw  = []any{&secs, &ev.Type, &ev.Data}
+	)
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if len(raw) != 3 {
+		return errors.New("invalid asciicast event")
+	}
+	ev.Time = time.Duration(secs * float64(time.Second))
+	return nil
+}
+
+// The largest terminal size of an asciicast recording. ReadCast rejects
+// recordings with larger sizes
+const (
+	MaxCastWidth  = 1000
+	MaxCastHeight = 1000
+)
+
+// Cast is an asciicast v2 recording
+type Cast struct {
+	Header CastHeader
+	Events []CastEvent
+}
+
+// Duration returns the time of the last event
+func (c *Cast) Duration() time.Duration {
+	if len(c.Events) == 0 {
+		return 0
+	}
+	return c.Events[len(c.Events)-1].Time
+}
+
+// ReadCast reads an asciicast v2 recording. The terminal size of the header
+// must be positive and at most MaxCastWidth x MaxCastHeight
+func ReadCast(r io.Reader) (*Cast, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16*1024*1024)
+	cast := &Cast{}
+	for line := 0; scanner.Scan(); line += 1 {
+		data := scanner.Bytes()
+		if len(data) == 0 {
+			continue
+		}
+		if line == 0 {
+			if err := json.Unmarshal(data, &cast.Header); err != nil {
+				return nil, fmt.Errorf("asciicast header: %w", err)
+			}
+			if cast.Header.Version != 2 {
+				return nil, fmt.Errorf("asciicast header: unsupported version %d", cast.Header.Version)
+			}
+			if w, h := cast.Header.Width, cast.Header.Height; w <= 0 || h <= 0 || w > MaxCastWidth || h > MaxCastHeight {
+				return nil, fmt.Errorf("asciicast header: invalid size %dx%d", w, h)
+			}
+			continue
+		}
+		ev := CastEvent{}
+		if err := json.Unmarshal(data, &ev); err != nil {
+			return nil, fmt.Errorf("asciicast line %d: %w", line+1, err)
+		}
+		cast.Events = append(cast.Events, ev)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return cast, nil
+}
+
+// recorder writes events to an asciicast v2 recording
+type recorder struct {
+	mu    sync.Mutex
+	w     io.Writer
+	start time.Time
+	input bool
+	err   error
+	// partial holds the bytes of an incomplete UTF-8 sequence of the
+	// output, which is written with the next output
+	partial []byte
+}
+
+func (r *recorder) write(typ string, data []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return
+	}
+	if typ == CastOutput {
+		data = append(r.partial, data...)
+		r.partial = nil
+		// keep an incomplete UTF-8 sequence at the end for the next write
+		for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i -= 1 {
+			if utf8.RuneStart(data[i]) {
+				if !utf8.FullRune(data[i:]) {
+					r.partial = append([]byte(nil), data[i:]...)
+					data = data[:i]
+				}
+				break
+			}
+		}
+		if len(data) == 0 {
+			return
+		}
+	}
+	line, err := json.Marshal(CastEvent{
+		Time: time.Since(r.start),
+		Type: typ,
+		Data: string(data),
+	})
+	if err == nil {
+		_, err = r.w.Write(append(line, '\n'))
+	}
+	r.err = err
+}
+
+// StartRecording starts recording the output of the program with
+// timestamps to w in the asciicast v2 format. Resizes are recorded as well,
+// and input sent to the program if input is set. Recording an active VT
+// starts with the output from then on
+func (vt *VT) StartRecording(w io.Writer, input bool) error {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	term := vt.TERM
+	if term == "" {
+		term = "xterm-256color"
+	}
+	header, err := json.Marshal(CastHeader{
+		Version:   2,
+		Width:     vt.width(),
+		Height:    vt.height(),
+		Timestamp: time.Now().Unix(),
+		Env:       map[string]string{"TERM": term, "SHELL": os.Getenv("SHELL")},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(append(header, '\n')); err != nil {
+		return err
+	}
+	vt.recorder = &recorder{w: w, start: time.Now(), input: input}
+	return nil
+}
+
+// StopRecording stops recording. It returns the first error writing the
+// recording
+func (vt *VT) StopRecording() error {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	rec := vt.recorder
+	vt.recorder = nil
+	if rec == nil {
+		return nil
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return rec.err
+}
+
+// Recording reports whether the VT is being recorded
+func (vt *VT) Recording() bool {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	return vt.recorder != nil
+}
+
+// record records an event if the VT is being recorded
+func (vt *VT) record(typ string, data []byte) {
+	if vt.recorder == nil || typ == CastInput && !vt.recorder.input {
+		return
+	}
+	vt.recorder.write(typ, data)
+}
+
+// input writes input to the program
+func (vt *VT) input(data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	vt.record(CastInput, data)
+	if vt.pty != nil {
+		vt.pty.Write(data)
+	}
+}
+
+// ptyReader reads the output of the program from the pty, recording it
+type ptyReader struct {
+	vt *VT
+}
+
+func (r ptyReader) Read(p []byte) (int, error) {
+	n, err := r.vt.pty.Read(p)
+	if n > 0 {
+		r.vt.mu.Lock()
+		r.vt.record(CastOutput, p[:n])
+		r.vt.mu.Unlock()
+	}
+	return n, err
+}
+
+// Write processes output as if the program wrote it to the pty. This drives
+// a VT without a pty, such as when replaying a recording. Events are passed
+// to the attached handler before Write returns
+func (vt *VT) Write(p []byte) (int, error) {
+	vt.mu.Lock()
+	vt.record(CastOutput, p)
+	if vt.feeder == nil {
+		vt.feeder = newFeedParser(vt.apply)
+	}
+	vt.feeding = true
+	vt.feeder.feed(p)
+	vt.feeding = false
+	events := vt.pending
+	vt.pending = nil
+	handler := vt.eventHandler
+	vt.mu.Unlock()
+
+	if handler != nil {
+		for _, ev := range events {
+			handler(ev)
+		}
+	}
+	return len(p), nil
+}
@@ -0,0 +1,103 @@
+package vte
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCastEvent(t *testing.T) {
+	ev := CastEvent{Time: 1500 * time.Millisecond, Type: CastOutput, Data: "a\x1b[1m"}
+	data, err := ev.MarshalJSON()
+	assert.NoError(t, err)
+	assert.Equal(t, `[1.5,"o","a\u001b[1m"]`, string(data))
+
+	decoded := CastEvent{}
+	assert.NoError(t, decoded.UnmarshalJSON(data))
+	assert.Equal(t, ev, decoded)
+	assert.Error(t, decoded.UnmarshalJSON([]byte(`[1.5,"o"]`)))
+
+	_, err = ReadCast(strings.NewReader(`{"version":1}`))
+	assert.Error(t, err)
+	_, err = ReadCast(strings.NewReader("{\"version\":2,\"width\":80,\"height\":24}\n[1, 2]"))
+	assert.Error(t, err)
+	for _, header := range []string{
+		`{"version":2}`,
+		`{"version":2,"width":-1,"height":24}`,
+		`{"version":2,"width":80,"height":100000}`,
+	} {
+		_, err = ReadCast(strings.NewReader(header))
+		assert.Error(t, err, header)
+	}
+}
+
+func TestRecording(t *testing.T) {
+	fixture, err := os.ReadFile("../tests/vim_simple_edit")
+	assert.NoError(t, err)
+
+	vt := New()
+	vt.Resize(80, 24)
+	buf := bytes.Buffer{}
+	assert.NoError(t, vt.StartRecording(&buf, true))
+	assert.True(t, vt.Recording())
+
+	// Chunks may split UTF-8 sequences
+	for i := 0; i < len(fixture); i += 7 {
+		vt.Write(fixture[i:min(i+7, len(fixture))])
+	}
+	vt.HandleEvent(tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone))
+	vt.Resize(40, 10)
+	assert.NoError(t, vt.StopRecording())
+	assert.False(t, vt.Recording())
+	vt.Write([]byte("not recorded"))
+
+	cast, err := ReadCast(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, cast.Header.Version)
+	assert.Equal(t, 80, cast.Header.Width)
+	assert.Equal(t, 24, cast.Header.Height)
+	assert.Equal(t, "xterm-256color", cast.Header.Env["TERM"])
+
+	// Replaying the recording reproduces the screen
+	replay := New()
+	replay.Resize(cast.Header.Width, cast.Header.Height)
+	output := strings.Builder{}
+	var inputs, resizes int
+	for _, ev := range cast.Events {
+		switch ev.Type {
+		case CastOutput:
+			output.WriteString(ev.Data)
+			replay.Write([]byte(ev.Data))
+		case CastInput:
+			inputs += 1
+			assert.Equal(t, "x", ev.Data)
+		case CastResize:
+			resizes += 1
+			assert.Equal(t, "40x10", ev.Data)
+			replay.Resize(40, 10)
+		}
+	}
+	assert.Equal(t, string(fixture), output.String())
+	assert.Equal(t, 1, inputs)
+	assert.Equal(t, 1, resizes)
+	vt.Resize(40, 10)
+	assert.Equal(t, draw(vt)[:40], draw(replay)[:40])
+}
+
+func TestWriteEvents(t *testing.T) {
+	vt := New()
+	vt.Resize(4, 2)
+	var events []tcell.Event
+	vt.Attach(func(ev tcell.Event) {
+		events = append(events, ev)
+	})
+	vt.Write([]byte("\x1b]2;one\x07\x1b]2;two\x07\x1b]2;three\x07ab"))
+	assert.Equal(t, "ab  \n    ", vt.String())
+	assert.Len(t, events, 4)
+	assert.Equal(t, "three", events[3].(*EventTitle).Title())
+}
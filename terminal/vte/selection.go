@@ -0,0 +1,308 @@
+package vte
+
+import (
+	"strings"
+	"unicode"
+)
+
+// position is a cell position in the buffer made of the history followed by
+// the active screen
+type position struct {
+	line int
+	col  int
+}
+
+func (p position) before(o position) bool {
+	return p.line < o.line || p.line == o.line && p.col < o.col
+}
+
+// selection is a stream of cells between the anchor and the head, inclusive
+type selection struct {
+	active bool
+	anchor position
+	head   position
+}
+
+// bounds returns the first and the last selected position
+func (s selection) bounds() (position, position) {
+	if s.head.before(s.anchor) {
+		return s.head, s.anchor
+	}
+	return s.anchor, s.head
+}
+
+func (s selection) contains(p position) bool {
+	if !s.active {
+		return false
+	}
+	start, end := s.bounds()
+	return !p.before(start) && !end.before(p)
+}
+
+// lineCount returns the number of lines in the buffer. The alternate screen
+// has no history
+func (vt *VT) lineCount() int {
+	if !vt.primary() {
+		return vt.height()
+	}
+	return len(vt.history) + vt.height()
+}
+
+// line returns the line of the buffer with the given index
+func (vt *VT) line(i int) []cell {
+	if !vt.primary() {
+		return vt.activeScreen[i]
+	}
+	if i < len(vt.history) {
+		return vt.history[i]
+	}
+	return vt.activeScreen[i-len(vt.history)]
+}
+
+// viewTop returns the buffer index of the first line of the view
+func (vt *VT) viewTop() int {
+	if !vt.primary() {
+		return 0
+	}
+	return len(vt.history) - vt.scrollOffset
+}
+
+// viewPosition converts view coordinates to a buffer position. Positions
+// outside of the buffer are clamped
+func (vt *VT) viewPosition(x int, y int) position {
+	p := position{line: vt.viewTop() + y, col: x}
+	switch {
+	case p.line < 0:
+		p = position{}
+	case p.line >= vt.lineCount():
+		p = position{line: vt.lineCount() - 1, col: vt.width() - 1}
+	}
+	line := vt.line(p.line)
+	p.col = max(0, min(p.col, len(line)-1))
+	// Select the leading cell of wide runes
+	if p.col > 0 && line[p.col-1].width == 2 {
+		p.col -= 1
+	}
+	return p
+}
+
+// StartSelection starts a new selection at the given view coordinates
+func (vt *VT) StartSelection(x int, y int) {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	if vt.height() == 0 {
+		return
+	}
+	p := vt.viewPosition(x, y)
+	vt.selection = selection{active: true, anchor: p, head: p}
+}
+
+// ExtendSelection moves the end of the selection to the given view
+// coordinates. A selection is started if there is none
+func (vt *VT) ExtendSelection(x int, y int) {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	if vt.height() == 0 {
+		return
+	}
+	p := vt.viewPosition(x, y)
+	if !vt.selection.active {
+		vt.selection = selection{active: true, anchor: p}
+	}
+	vt.selection.head = p
+}
+
+// ClearSelection removes the selection
+func (vt *VT) ClearSelection() {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	vt.selection = selection{}
+}
+
+// HasSelection reports whether there is a selection
+func (vt *VT) HasSelection() bool {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	return vt.selection.active
+}
+
+// SelectionStart returns the view coordinates of the first selected cell.
+// The row is outside of the view if the selection was scrolled out of it
+func (vt *VT) SelectionStart() (int, int, bool) {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	if !vt.selection.active {
+		return 0, 0, false
+	}
+	start, _ := vt.selection.bounds()
+	return start.col, start.line - vt.viewTop(), true
+}
+
+// SelectedText returns the text of the selection. Wrapped lines are joined
+// and trailing blanks of other lines are removed
+func (vt *VT) SelectedText() string {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	if !vt.selection.active {
+		return ""
+	}
+	start, end := vt.selection.bounds()
+	str := strings.Builder{}
+	for i := start.line; i <= end.line && i < vt.lineCount(); i += 1 {
+		line := vt.line(i)
+		from, to := 0, len(line)-1
+		if i == start.line {
+			from = start.col
+		}
+		if i == end.line {
+			to = min(end.col, to)
+		}
+		wrapped := len(line) > 0 && line[len(line)-1].wrapped && i < end.line
+		text := []rune{}
+		for col := from; col <= to; {
+			c := line[col]
+			text = append(text, c.rune())
+			text = append(text, c.combining...)
+			col += max(c.width, 1)
+		}
+		if !wrapped {
+			text = []rune(strings.TrimRightFunc(string(text), unicode.IsSpace))
+		}
+		str.WriteString(string(text))
+		if !wrapped && i < end.line {
+			str.WriteRune('\n')
+		}
+	}
+	return str.String()
+}
+
+// Search selects the nearest match of the query, starting at the selection.
+// If there is no selection, the search starts at the end of the buffer when
+// searching backward and at its start otherwise. Searching again with a longer
+// query keeps the match in place if possible, which makes the search
+// incremental. A query without uppercase letters matches case-insensitively.
+// The view is scrolled to show the match. It returns false if there is no
+// match, leaving the selection untouched
+func (vt *VT) Search(query string, backward bool) bool {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	return vt.search(query, backward, false)
+}
+
+// SearchNext selects the next match of the query after the selection, or
+// before it when searching backward
+func (vt *VT) SearchNext(query string, backward bool) bool {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	return vt.search(query, backward, true)
+}
+
+func (vt *VT) search(query string, backward bool, next bool) bool {
+	if query == "" || vt.height() == 0 {
+		return false
+	}
+	ref := position{}
+	switch {
+	case vt.selection.active:
+		ref, _ = vt.selection.bounds()
+	case backward:
+		ref = position{line: vt.lineCount(), col: 0}
+		next = true
+	default:
+		next = false
+	}
+
+	var (
+		found    bool
+		matchPos [2]position
+	)
+	vt.matches(query, func(start position, end position) bool {
+		var ok bool
+		switch {
+		case backward && next:
+			ok = start.before(ref)
+		case backward:
+			ok = !ref.before(start)
+		case next:
+			ok = ref.before(start)
+		default:
+			ok = !start.before(ref)
+		}
+		if !ok {
+			// Matches are reported in order
+			return !backward
+		}
+		found = true
+		matchPos = [2]position{start, end}
+		return backward
+	})
+	if !found {
+		return false
+	}
+	vt.selection = selection{active: true, anchor: matchPos[0], head: matchPos[1]}
+
+	// Scroll the match into the view
+	if vt.primary() {
+		top := vt.viewTop()
+		switch {
+		case matchPos[0].line < top:
+			vt.scrollOffset = len(vt.history) - matchPos[0].line
+		case matchPos[0].line >= top+vt.height():
+			vt.scrollOffset = max(0, len(vt.history)-(matchPos[0].line-vt.height()+1))
+		}
+	}
+	return true
+}
+
+// matches calls fn with the first and the last position of every match of
+// the query in the buffer, in order, until fn returns false. Matches may span
+// wrapped lines
+func (vt *VT) matches(query string, fn func(start position, end position) bool) {
+	fold := strings.ToLower(query) == query
+	if fold {
+		query = strings.ToLower(query)
+	}
+	needle := []rune(query)
+
+	var (
+		text      []rune
+		positions []position
+	)
+	for i := 0; i < vt.lineCount(); i += 1 {
+		line := vt.line(i)
+		for col := 0; col < len(line); {
+			c := line[col]
+			r := c.rune()
+			if fold {
+				r = unicode.ToLower(r)
+			}
+			text = append(text, r)
+			positions = append(positions, position{line: i, col: col})
+			col += max(c.width, 1)
+		}
+		if len(line) > 0 && line[len(line)-1].wrapped && i < vt.lineCount()-1 {
+			continue
+		}
+		for j := 0; j+len(needle) <= len(text); j += 1 {
+			if string(text[j:j+len(needle)]) != string(needle) {
+				continue
+			}
+			end := positions[j+len(needle)-1]
+			if c := vt.line(end.line)[end.col]; c.width == 2 {
+				end.col += 1
+			}
+			if !fn(positions[j], end) {
+				return
+			}
+		}
+		text = text[:0]
+		positions = positions[:0]
+	}
+}
+
+// MouseReporting reports whether the child program requested mouse events
+func (vt *VT) MouseReporting() bool {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	return vt.mode&(mouseButtons|mouseDrag|mouseMotion|mouseSGR) != 0
+}
@@ -0,0 +1,107 @@
+package vte
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectedText(t *testing.T) {
+	vt := New()
+	vt.Resize(4, 5)
+	printLines(vt, "abcdef", "g  ", "日本")
+	assert.Equal(t, "", vt.SelectedText())
+	assert.False(t, vt.HasSelection())
+
+	// Wrapped lines are joined and trailing blanks are trimmed
+	vt.StartSelection(1, 0)
+	vt.ExtendSelection(3, 3)
+	assert.True(t, vt.HasSelection())
+	assert.Equal(t, "bcdef\ng\n日本", vt.SelectedText())
+
+	// The selection may be made backward
+	vt.StartSelection(3, 3)
+	vt.ExtendSelection(0, 1)
+	assert.Equal(t, "ef\ng\n日本", vt.SelectedText())
+
+	// Wide runes are selected as a whole
+	vt.StartSelection(1, 3)
+	vt.ExtendSelection(1, 3)
+	assert.Equal(t, "日", vt.SelectedText())
+
+	x, y, ok := vt.SelectionStart()
+	assert.True(t, ok)
+	assert.Equal(t, 0, x)
+	assert.Equal(t, 3, y)
+
+	vt.ClearSelection()
+	assert.Equal(t, "", vt.SelectedText())
+	_, _, ok = vt.SelectionStart()
+	assert.False(t, ok)
+}
+
+func TestSelectionScrollback(t *testing.T) {
+	vt := New()
+	vt.Resize(3, 2)
+	printLines(vt, "a", "b", "c")
+	vt.ScrollBack(2)
+	vt.StartSelection(0, 0)
+	vt.ExtendSelection(0, 1)
+	assert.Equal(t, "a\nb", vt.SelectedText())
+
+	// The selection moves along with the lines
+	printLines(vt, "d")
+	assert.Equal(t, "a\nb", vt.SelectedText())
+
+	// Lines dropped from the history are dropped from the selection
+	vt.SetScrollback(2)
+	assert.Equal(t, "b", vt.SelectedText())
+}
+
+func TestSearch(t *testing.T) {
+	vt := New()
+	vt.Resize(4, 2)
+	printLines(vt, "foo", "bar", "Foo", "bazfoo")
+	assert.False(t, vt.Search("qux", false))
+	assert.False(t, vt.HasSelection())
+
+	// Search from the start of the buffer and scroll the match into view
+	assert.True(t, vt.Search("foo", false))
+	assert.Equal(t, "foo", vt.SelectedText())
+	assert.Equal(t, vt.HistoryLen(), vt.ScrollOffset())
+
+	// Incremental searches keep the match in place
+	assert.True(t, vt.Search("fo", false))
+	_, y, _ := vt.SelectionStart()
+	assert.Equal(t, 0, y)
+
+	// Matches may span wrapped lines
+	assert.True(t, vt.SearchNext("foo", false))
+	assert.Equal(t, "Foo", vt.SelectedText())
+	assert.True(t, vt.SearchNext("zfo", false))
+	assert.Equal(t, "zfo", vt.SelectedText())
+	assert.False(t, vt.SearchNext("zfo", false))
+
+	// Uppercase letters match case-sensitively
+	assert.True(t, vt.SearchNext("Foo", true))
+	assert.Equal(t, "Foo", vt.SelectedText())
+	assert.False(t, vt.SearchNext("Foo", true))
+
+	// Backward searches start at the end of the buffer
+	vt.ClearSelection()
+	assert.True(t, vt.Search("foo", true))
+	assert.Equal(t, "foo", vt.SelectedText())
+	_, y, _ = vt.SelectionStart()
+	assert.Equal(t, 1, y)
+}
+
+func TestMouseReporting(t *testing.T) {
+	vt := New()
+	vt.Resize(2, 2)
+	assert.False(t, vt.MouseReporting())
+	vt.decset([]int{1000})
+	assert.True(t, vt.MouseReporting())
+	vt.handleMouse(tcell.NewEventMouse(0, 0, tcell.WheelUp, tcell.ModNone))
+	assert.Equal(t, 0, vt.ScrollOffset())
+}
@@ -60,6 +60,16 @@ func (seq OSC) String() string {
 	return "OSC " + string(seq.Payload)
 }
 
+// An APC sequence. The Payload is the raw runes received, and must be parsed
+// externally
+type APC struct {
+	Payload []rune
+}
+
+func (seq APC) String() string {
+	return "APC " + string(seq.Payload)
+}
+
 // Sent at the beginning of a DCS passthrough sequence.
 type DCS struct {
 	Final        rune
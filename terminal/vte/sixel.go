@@ -1,68 +1,192 @@
 package vte
 
-// type sixel struct {
-// 	X    int
-// 	Y    int // raw line
-// 	Tag []byte
-// }
-//
-// type visibleSixel struct {
-// 	ViewLineOffset int
-// 	Sixel          sixel
-// }
-//
-// func (b *buffer) addSixel(data []byte) {
-// 	b.sixels = append(b.sixels, sixel{
-// 		X:    b.cursorColumn(),
-// 		Y:    b.cursorPosition.Line,
-// 		Tag: data,
-// 	})
-// }
-//
-// func (b *buffer) getVisibleSixels() []visibleSixel {
-// 	firstLine := b.convertViewLineToRawLine(0)
-// 	lastLine := b.convertViewLineToRawLine(b.viewHeight - 1)
-//
-// 	var visible []visibleSixel
-//
-// 	for _, sixelImage := range b.sixels {
-// 		if sixelImage.Y < firstLine {
-// 			continue
-// 		}
-// 		if sixelImage.Y > lastLine {
-// 			continue
-// 		}
-//
-// 		visible = append(visible, visibleSixel{
-// 			ViewLineOffset: int(sixelImage.Y) - int(firstLine),
-// 			Sixel:          sixelImage,
-// 		})
-// 	}
-//
-// 	return visible
-// }
-//
-// func (t *Terminal) handleSixel(readChan chan measuredRune) (renderRequired bool) {
-// 	var data []rune
-//
-// 	var inEscape bool
-//
-// 	for {
-// 		r := <-readChan
-//
-// 		switch r.rune {
-// 		case 0x1b:
-// 			inEscape = true
-// 			continue
-// 		case 0x5c:
-// 			if inEscape {
-// 				t.activeBuffer.addSixel([]byte(string(data)))
-// 				return true
-// 			}
-// 		}
-//
-// 		inEscape = false
-//
-// 		data = append(data, r.rune)
-// 	}
-// }
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// sixelDecoder decodes the data of a DCS sixel sequence into an image. Sixel
+// data is a stream of characters, each of which encodes a column of six
+// vertical pixels in the current color
+type sixelDecoder struct {
+	palette [256]color.NRGBA
+	color   int
+	// x and y are the position of the next sixel. y is the top of the
+	// current band of six pixel rows
+	x, y   int
+	repeat int
+	// width and height are set by the raster attributes
+	width, height int
+	rows          [][]color.NRGBA
+
+	// cmd is the command whose parameters are being received, or 0
+	cmd    rune
+	params []int
+}
+
+// sixelPalette is the default palette of the VT340 in RGB percentages
+var sixelPalette = [16][3]int{
+	{0, 0, 0}, {20, 20, 80}, {80, 13, 13}, {20, 80, 20},
+	{80, 20, 80}, {20, 80, 80}, {80, 80, 20}, {53, 53, 53},
+	{26, 26, 26}, {33, 33, 60}, {60, 26, 26}, {33, 60, 33},
+	{60, 33, 60}, {33, 60, 60}, {60, 60, 33}, {80, 80, 80},
+}
+
+func newSixelDecoder() *sixelDecoder {
+	d := &sixelDecoder{}
+	for i := range d.palette {
+		c := sixelPalette[i%len(sixelPalette)]
+		d.palette[i] = percentColor(c[0], c[1], c[2])
+	}
+	return d
+}
+
+// put decodes the next character of the sixel data
+func (d *sixelDecoder) put(r rune) {
+	if d.cmd != 0 {
+		switch {
+		case r >= '0' && r <= '9':
+			n := &d.params[len(d.params)-1]
+			*n = min(*n*10+int(r-'0'), maxImageSize)
+			return
+		case r == ';':
+			d.params = append(d.params, 0)
+			return
+		}
+		d.exec()
+	}
+
+	switch {
+	case r == '#' || r == '!' || r == '"':
+		d.cmd = r
+		d.params = []int{0}
+	case r == '$':
+		d.x = 0
+	case r == '-':
+		d.x = 0
+		d.y = min(d.y+6, maxImageSize)
+	case r >= '?' && r <= '~':
+		bits := r - '?'
+		n := max(d.repeat, 1)
+		d.repeat = 0
+		if d.x+n > maxImageSize {
+			n = maxImageSize - d.x
+		}
+		for bit := 0; bit < 6; bit += 1 {
+			if bits&(1<<bit) == 0 {
+				continue
+			}
+			for i := 0; i < n; i += 1 {
+				d.set(d.x+i, d.y+bit)
+			}
+		}
+		d.x += n
+	}
+}
+
+// exec executes the command whose parameters were received
+func (d *sixelDecoder) exec() {
+	params := d.params
+	switch d.cmd {
+	case '!':
+		// Graphics repeat introducer
+		d.repeat = params[0]
+	case '#':
+		// Color introducer. Colors are selected by their register and may be
+		// defined in HLS (1) or RGB (2)
+		d.color = params[0] % len(d.palette)
+		if len(params) < 5 {
+			break
+		}
+		switch params[1] {
+		case 1:
+			d.palette[d.color] = hlsColor(params[2], params[3], params[4])
+		case 2:
+			d.palette[d.color] = percentColor(params[2], params[3], params[4])
+		}
+	case '"':
+		// Raster attributes: Pan; Pad; Ph; Pv
+		if len(params) >= 4 {
+			d.width, d.height = params[2], params[3]
+		}
+	}
+	d.cmd = 0
+	d.params = nil
+}
+
+// set sets the pixel to the current color
+func (d *sixelDecoder) set(x int, y int) {
+	for len(d.rows) <= y {
+		d.rows = append(d.rows, nil)
+	}
+	if len(d.rows[y]) <= x {
+		d.rows[y] = append(d.rows[y], make([]color.NRGBA, x+1-len(d.rows[y]))...)
+	}
+	d.rows[y][x] = d.palette[d.color]
+}
+
+// image returns the decoded image, or nil if it is empty. Pixels which were
+// not set are transparent
+func (d *sixelDecoder) image() image.Image {
+	if d.cmd != 0 {
+		d.exec()
+	}
+	w, h := d.width, max(d.height, len(d.rows))
+	for _, row := range d.rows {
+		w = max(w, len(row))
+	}
+	if w == 0 || h == 0 {
+		return nil
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y, row := range d.rows {
+		for x, c := range row {
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// percentColor returns the color with the RGB components in percent
+func percentColor(r int, g int, b int) color.NRGBA {
+	c := func(v int) uint8 {
+		return uint8(min(v, 100) * 255 / 100)
+	}
+	return color.NRGBA{R: c(r), G: c(g), B: c(b), A: 255}
+}
+
+// hlsColor returns the color with the hue in degrees and the lightness and
+// saturation in percent. Sixel hues start with blue at 0 degrees and red at
+// 120 degrees
+func hlsColor(h int, l int, s int) color.NRGBA {
+	hue := float64((h+240)%360) / 360
+	light := float64(min(l, 100)) / 100
+	sat := float64(min(s, 100)) / 100
+	if sat == 0 {
+		return percentColor(l, l, l)
+	}
+	var q float64
+	if light < 0.5 {
+		q = light * (1 + sat)
+	} else {
+		q = light + sat - light*sat
+	}
+	p := 2*light - q
+	component := func(t float64) uint8 {
+		t = t - math.Floor(t)
+		var v float64
+		switch {
+		case t < 1.0/6:
+			v = p + (q-p)*6*t
+		case t < 1.0/2:
+			v = q
+		case t < 2.0/3:
+			v = p + (q-p)*(2.0/3-t)*6
+		default:
+			v = p
+		}
+		return uint8(math.Round(v * 255))
+	}
+	return color.NRGBA{R: component(hue + 1.0/3), G: component(hue), B: component(hue - 1.0/3), A: 255}
+}
@@ -2,6 +2,7 @@ package vte
 
 import (
 	"fmt"
+	"image"
 	"io"
 	"log"
 	"runtime/debug"
@@ -18,12 +19,21 @@ type (
 	row    int
 )
 
+// DefaultScrollback is the default number of history lines of a VT
+const DefaultScrollback = 1000
+
 // VT models a virtual terminal
 type VT struct {
 	Logger *log.Logger
 	// If true, OSC8 enables the output of OSC8 strings. Otherwise, any OSC8
 	// sequences will be stripped
 	OSC8 bool
+	// If true, programs may set the clipboard with OSC 52, which is reported
+	// as EventClipboard
+	OSC52 bool
+	// If true, programs may also query the clipboard with OSC 52. This lets
+	// any program read the clipboard of the host, so it is false by default
+	OSC52Read bool
 	// Set the TERM environment variable to be passed to the command's
 	// environment. If not set, xterm-256color will be used
 	TERM string
@@ -34,6 +44,32 @@ type VT struct {
 	altScreen     [][]cell
 	primaryScreen [][]cell
 
+	// history holds the lines scrolled off the primary screen, oldest first
+	history [][]cell
+	// scrollback is the maximum number of lines kept in the history
+	scrollback int
+	// scrollOffset is the number of history lines the view is scrolled back
+	scrollOffset int
+	// selection is the selected text of the history and the active screen
+	selection selection
+	// palette holds the colors of the palette changed with OSC 4
+	palette map[tcell.Color]tcell.Color
+	// foreground and background are the default colors set with OSC 10 and
+	// OSC 11
+	foreground tcell.Color
+	background tcell.Color
+	// workingDirectory is the directory last reported with OSC 7
+	workingDirectory string
+	// cellWidth and cellHeight are the size of a cell in pixels
+	cellWidth  int
+	cellHeight int
+	// sixel decodes the data of the current DCS sixel sequence
+	sixel *sixelDecoder
+	// kittyChunk is the kitty graphics command receiving more chunks
+	kittyChunk *kittyCommand
+	// images holds the images transmitted with the kitty graphics protocol
+	images map[uint32]image.Image
+
 	charsets charsets
 	cursor   cursor
 	margin   margin
@@ -55,6 +91,15 @@ type VT struct {
 	events       chan tcell.Event
 
 	mouseBtn tcell.ButtonMask
+
+	// recorder records the output to an asciicast recording
+	recorder *recorder
+	// feeder parses the output passed to Write
+	feeder *Parser
+	// feeding is set while Write applies output. Events are collected in
+	// pending and passed to the handler when done
+	feeding bool
+	pending []tcell.Event
 }
 
 type cursorState struct {
@@ -77,8 +122,14 @@ func New() *VT {
 		tabs = append(tabs, column(i))
 	}
 	return &VT{
-		Logger: log.New(io.Discard, "", log.Flags()),
-		OSC8:   true,
+		Logger:     log.New(io.Discard, "", log.Flags()),
+		OSC8:       true,
+		OSC52:      true,
+		scrollback: DefaultScrollback,
+		foreground: tcell.ColorDefault,
+		background: defaultBackground,
+		cellWidth:  defaultCellWidth,
+		cellHeight: defaultCellHeight,
 		charsets: charsets{
 			designations: map[charsetDesignator]charset{
 				g0: ascii,
@@ -143,7 +194,7 @@ func (vt *VT) Start(opt pty.Options) error {
 	}
 
 	vt.Resize(w, h)
-	vt.parser = NewParser(vt.pty)
+	vt.parser = NewParser(ptyReader{vt})
 	go func() {
 		defer vt.recover()
 		for {
@@ -170,6 +221,11 @@ func (vt *VT) Start(opt pty.Options) error {
 func (vt *VT) update(seq Sequence) {
 	vt.mu.Lock()
 	defer vt.mu.Unlock()
+	vt.apply(seq)
+}
+
+// apply applies the sequence to the screen
+func (vt *VT) apply(seq Sequence) {
 	switch seq := seq.(type) {
 	case Print:
 		vt.print(rune(seq))
@@ -184,8 +240,13 @@ func (vt *VT) update(seq Sequence) {
 	case OSC:
 		vt.osc(string(seq.Payload))
 	case DCS:
+		vt.dcs(seq)
 	case DCSData:
+		vt.dcsPut(rune(seq))
 	case DCSEndOfData:
+		vt.dcsEnd()
+	case APC:
+		vt.apc(string(seq.Payload))
 	}
 	// TODO optimize when we post EventRedraw
 	if !vt.dirty {
@@ -235,46 +296,79 @@ func (vt *VT) recover() {
 	vt.Close()
 }
 
-// row, col, style, vis
+// row, col, style, vis. The row is relative to the view, the cursor is not
+// visible if it was scrolled out of the view
 func (vt *VT) Cursor() (int, int, tcell.CursorStyle, bool) {
 	vt.mu.Lock()
 	defer vt.mu.Unlock()
-	vis := vt.mode&dectcem > 0
-	return int(vt.cursor.row), int(vt.cursor.col), vt.cursor.style, vis
+	rw := int(vt.cursor.row) + vt.scrollOffset
+	vis := vt.mode&dectcem > 0 && rw < vt.height()
+	return rw, int(vt.cursor.col), vt.cursor.style, vis
 }
 
+// Resize resizes the screens. The lines of the history and the primary screen
+// above the cursor are rewrapped to the new width
 func (vt *VT) Resize(w int, h int) {
 	primary := vt.primaryScreen
+	last := int(vt.cursor.row)
+	if last > len(primary) {
+		last = len(primary)
+	}
+	lines := append(vt.history, primary[:last]...)
+
 	vt.altScreen = make([][]cell, h)
 	vt.primaryScreen = make([][]cell, h)
 	for i := range vt.altScreen {
 		vt.altScreen[i] = make([]cell, w)
 		vt.primaryScreen[i] = make([]cell, w)
 	}
-	last := vt.cursor.row
+	vt.history = nil
+	vt.scrollOffset = 0
+	vt.selection = selection{}
+	vt.margin.top = 0
 	vt.margin.bottom = row(h) - 1
+	vt.margin.left = 0
 	vt.margin.right = column(w) - 1
 	vt.cursor.row = 0
 	vt.cursor.col = 0
 	vt.lastCol = false
 	vt.activeScreen = vt.primaryScreen
 
-	// transfer primary to new, skipping the last row
-	for row := 0; row < len(primary); row += 1 {
-		if row == int(last) {
-			break
-		}
+	// transfer the history and primary to new, skipping the rows from the
+	// cursor on. Lines scrolled off the top are added to the new history
+	attrs := vt.cursor.attrs
+	for _, line := range lines {
 		wrapped := false
-		for col := 0; col < len(primary[0]); col += 1 {
-			cell := primary[row][col]
+		end := len(line)
+		if end > 0 {
+			wrapped = line[end-1].wrapped
+		}
+		if !wrapped {
+			for end > 0 && line[end-1].content == 0 {
+				end -= 1
+			}
+		}
+		for col := 0; col < end; {
+			cell := line[col]
 			vt.cursor.attrs = cell.attrs
-			vt.print(cell.content)
-			wrapped = cell.wrapped
+			if cell.content == 0 {
+				vt.print(' ')
+			} else {
+				vt.print(cell.content)
+				for _, comb := range cell.combining {
+					vt.print(comb)
+				}
+			}
+			if col == 0 && cell.prompt {
+				vt.activeScreen[vt.cursor.row][0].prompt = true
+			}
+			col += max(cell.width, 1)
 		}
 		if !wrapped {
 			vt.nel()
 		}
 	}
+	vt.cursor.attrs = attrs
 	switch vt.mode & smcup {
 	case 0:
 		vt.activeScreen = vt.primaryScreen
@@ -282,6 +376,7 @@ func (vt *VT) Resize(w int, h int) {
 		vt.activeScreen = vt.altScreen
 	}
 
+	vt.record(CastResize, []byte(fmt.Sprintf("%dx%d", w, h)))
 	if vt.pty != nil {
 		_ = vt.pty.SetSize(&pty.WinSize{
 			Cols: uint16(w),
@@ -351,6 +446,8 @@ func (vt *VT) print(r rune) {
 		content: r,
 		width:   w,
 		attrs:   vt.cursor.attrs,
+		// overwriting the prompt keeps the line marked
+		prompt: vt.activeScreen[rw][col].prompt,
 	}
 
 	vt.activeScreen[rw][col] = cell
@@ -377,6 +474,7 @@ func (vt *VT) print(r rune) {
 // scrollUp shifts all text upward by n rows. Semantically, this is backwards -
 // usually scroll up would mean you shift rows down
 func (vt *VT) scrollUp(n int) {
+	vt.pushHistory(n)
 	for row := range vt.activeScreen {
 		if row > int(vt.margin.bottom) {
 			continue
@@ -394,6 +492,59 @@ func (vt *VT) scrollUp(n int) {
 	}
 }
 
+// primary reports whether the primary screen is active
+func (vt *VT) primary() bool {
+	return len(vt.activeScreen) > 0 && len(vt.primaryScreen) > 0 && &vt.activeScreen[0] == &vt.primaryScreen[0]
+}
+
+// pushHistory adds the top n rows of the primary screen to the history if
+// they are about to be scrolled off the whole screen
+func (vt *VT) pushHistory(n int) {
+	if vt.scrollback <= 0 || !vt.primary() || vt.margin.top != 0 {
+		return
+	}
+	if vt.margin.left != 0 || vt.margin.right != column(vt.width()-1) {
+		return
+	}
+	if n > int(vt.margin.bottom)+1 {
+		n = int(vt.margin.bottom) + 1
+	}
+	for _, line := range vt.activeScreen[:n] {
+		vt.history = append(vt.history, append([]cell(nil), line...))
+	}
+	if vt.scrollOffset > 0 {
+		// keep the view on the same lines
+		vt.scrollOffset += n
+	}
+	vt.trimHistory()
+	if vt.scrollOffset > len(vt.history) {
+		vt.scrollOffset = len(vt.history)
+	}
+}
+
+// trimHistory removes the oldest lines exceeding the scrollback. The
+// selection is moved along with the remaining lines
+func (vt *VT) trimHistory() {
+	over := len(vt.history) - vt.scrollback
+	if over <= 0 {
+		return
+	}
+	vt.history = vt.history[over:]
+	if vt.selection.active {
+		vt.selection.anchor.line -= over
+		vt.selection.head.line -= over
+		if _, end := vt.selection.bounds(); end.line < 0 {
+			vt.selection = selection{}
+		} else if start, _ := vt.selection.bounds(); start.line < 0 {
+			if vt.selection.anchor.line < 0 {
+				vt.selection.anchor = position{}
+			} else {
+				vt.selection.head = position{}
+			}
+		}
+	}
+}
+
 // scrollDown shifts all lines down by n rows.
 func (vt *VT) scrollDown(n int) {
 	for r := vt.margin.bottom; r >= vt.margin.top; r -= 1 {
@@ -434,6 +585,10 @@ func (vt *VT) Detach() {
 }
 
 func (vt *VT) postEvent(ev tcell.Event) {
+	if vt.feeding {
+		vt.pending = append(vt.pending, ev)
+		return
+	}
 	vt.events <- ev
 }
 
@@ -450,11 +605,18 @@ func (vt *VT) Draw() {
 	if vt.surface == nil {
 		return
 	}
+	top := vt.viewTop()
 	for row := 0; row < vt.height(); row += 1 {
-		for col := 0; col < vt.width(); {
-			cell := vt.activeScreen[row][col]
+		line := vt.viewLine(row)
+		for col := 0; col < vt.width() && col < len(line); {
+			cell := line[col]
 			w := cell.width
-			vt.surface.SetContent(col, row, cell.content, cell.combining, cell.attrs.Background(tcell.NewRGBColor(0, 0, 0)))
+			style := vt.style(cell.attrs)
+			if vt.selection.contains(position{line: top + row, col: col}) {
+				_, _, attrs := style.Decompose()
+				style = style.Reverse(attrs&tcell.AttrReverse == 0)
+			}
+			vt.surface.SetContent(col, row, cell.content, cell.combining, style)
 			if w == 0 {
 				w = 1
 			}
@@ -471,27 +633,104 @@ func (vt *VT) Draw() {
 	// }
 }
 
+// viewLine returns the line displayed in the given row of the view
+func (vt *VT) viewLine(rw int) []cell {
+	if vt.scrollOffset == 0 || !vt.primary() {
+		return vt.activeScreen[rw]
+	}
+	i := len(vt.history) - vt.scrollOffset + rw
+	if i < len(vt.history) {
+		return vt.history[i]
+	}
+	return vt.activeScreen[i-len(vt.history)]
+}
+
+// SetScrollback sets the maximum number of lines scrolled off the top of the
+// primary screen which are kept in the history. Zero disables the history
+func (vt *VT) SetScrollback(lines int) {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	vt.scrollback = max(lines, 0)
+	vt.trimHistory()
+	vt.scrollBack(0)
+}
+
+// Scrollback returns the maximum number of lines kept in the history
+func (vt *VT) Scrollback() int {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	return vt.scrollback
+}
+
+// ScrollBack scrolls the view n lines back into the history. Negative values
+// scroll forward. The view is never scrolled beyond the history
+func (vt *VT) ScrollBack(n int) {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	vt.scrollBack(n)
+}
+
+func (vt *VT) scrollBack(n int) {
+	vt.scrollOffset += n
+	if vt.scrollOffset > len(vt.history) {
+		vt.scrollOffset = len(vt.history)
+	}
+	if vt.scrollOffset < 0 || !vt.primary() {
+		vt.scrollOffset = 0
+	}
+}
+
+// ScrollToBottom scrolls the view back to the screen
+func (vt *VT) ScrollToBottom() {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	vt.scrollOffset = 0
+}
+
+// ScrollOffset returns the number of history lines the view is scrolled back
+func (vt *VT) ScrollOffset() int {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	return vt.scrollOffset
+}
+
+// HistoryLen returns the number of lines in the history
+func (vt *VT) HistoryLen() int {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	return len(vt.history)
+}
+
+// ClearHistory removes all lines from the history
+func (vt *VT) ClearHistory() {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	vt.history = nil
+	vt.scrollOffset = 0
+}
+
 func (vt *VT) HandleEvent(e tcell.Event) bool {
 	vt.mu.Lock()
 	defer vt.mu.Unlock()
 	switch e := e.(type) {
 	case *tcell.EventKey:
-		vt.pty.Write([]byte(keyCode(e)))
+		vt.scrollOffset = 0
+		vt.input([]byte(keyCode(e)))
 		return true
 	case *tcell.EventPaste:
 		switch {
 		case vt.mode&paste == 0:
 			return false
 		case e.Start():
-			vt.pty.Write([]byte(info.PasteStart))
+			vt.input([]byte(info.PasteStart))
 			return true
 		case e.End():
-			vt.pty.Write([]byte(info.PasteEnd))
+			vt.input([]byte(info.PasteEnd))
 			return true
 		}
 	case *tcell.EventMouse:
 		str := vt.handleMouse(e)
-		vt.pty.Write([]byte(str))
+		vt.input([]byte(str))
 	}
 	return false
 }
@@ -1,8 +1,10 @@
 package vte
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/gdamore/tcell/v2"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -146,3 +148,126 @@ func TestCombiningRunes(t *testing.T) {
 
 	assert.Equal(t, "h̷̗ \n  ", vt.String())
 }
+
+// testSurface records the contents drawn by a VT
+type testSurface struct {
+	w, h  int
+	cells map[[2]int]rune
+}
+
+func (s *testSurface) SetContent(x int, y int, ch rune, comb []rune, style tcell.Style) {
+	s.cells[[2]int{x, y}] = ch
+}
+
+func (s *testSurface) Size() (int, int) {
+	return s.w, s.h
+}
+
+func (s *testSurface) String() string {
+	str := strings.Builder{}
+	for y := 0; y < s.h; y += 1 {
+		if y > 0 {
+			str.WriteRune('\n')
+		}
+		for x := 0; x < s.w; x += 1 {
+			ch := s.cells[[2]int{x, y}]
+			if ch == 0 {
+				ch = ' '
+			}
+			str.WriteRune(ch)
+		}
+	}
+	return str.String()
+}
+
+func draw(vt *VT) string {
+	w, h := vt.width(), vt.height()
+	srf := &testSurface{w: w, h: h, cells: map[[2]int]rune{}}
+	vt.SetSurface(srf)
+	vt.Draw()
+	return srf.String()
+}
+
+func printLines(vt *VT, lines ...string) {
+	for _, line := range lines {
+		for _, r := range line {
+			vt.print(r)
+		}
+		vt.nel()
+	}
+}
+
+func TestScrollback(t *testing.T) {
+	vt := New()
+	vt.Resize(3, 2)
+	printLines(vt, "a", "b", "c", "d")
+	assert.Equal(t, 3, vt.HistoryLen())
+	assert.Equal(t, "d  \n   ", draw(vt))
+
+	vt.ScrollBack(1)
+	assert.Equal(t, 1, vt.ScrollOffset())
+	assert.Equal(t, "c  \nd  ", draw(vt))
+	row, _, _, vis := vt.Cursor()
+	assert.Equal(t, 2, row)
+	assert.False(t, vis)
+
+	// New output keeps the view on the same lines
+	printLines(vt, "e")
+	assert.Equal(t, 2, vt.ScrollOffset())
+	assert.Equal(t, "c  \nd  ", draw(vt))
+
+	vt.ScrollBack(10)
+	assert.Equal(t, 4, vt.ScrollOffset())
+	assert.Equal(t, "a  \nb  ", draw(vt))
+	vt.ScrollBack(-1)
+	assert.Equal(t, "b  \nc  ", draw(vt))
+	vt.ScrollToBottom()
+	assert.Equal(t, "e  \n   ", draw(vt))
+
+	// Without mouse reporting the wheel scrolls the view
+	assert.Equal(t, "", vt.handleMouse(tcell.NewEventMouse(0, 0, tcell.WheelUp, tcell.ModNone)))
+	assert.Equal(t, 3, vt.ScrollOffset())
+	vt.handleMouse(tcell.NewEventMouse(0, 0, tcell.WheelDown, tcell.ModNone))
+	assert.Equal(t, 0, vt.ScrollOffset())
+
+	vt.SetScrollback(2)
+	assert.Equal(t, 2, vt.HistoryLen())
+	vt.ScrollBack(10)
+	assert.Equal(t, "c  \nd  ", draw(vt))
+
+	// The alternate screen has no history
+	vt.ScrollToBottom()
+	vt.decset([]int{1049})
+	printLines(vt, "x", "y", "z")
+	assert.Equal(t, 2, vt.HistoryLen())
+	vt.ScrollBack(1)
+	assert.Equal(t, 0, vt.ScrollOffset())
+	vt.decrst([]int{1049})
+
+	vt.ClearHistory()
+	assert.Equal(t, 0, vt.HistoryLen())
+
+	vt.SetScrollback(0)
+	printLines(vt, "f", "g", "h")
+	assert.Equal(t, 0, vt.HistoryLen())
+}
+
+func TestResizeRewrap(t *testing.T) {
+	vt := New()
+	vt.Resize(4, 2)
+	printLines(vt, "abcdef", "gh", "ij")
+	assert.Equal(t, 3, vt.HistoryLen())
+	assert.Equal(t, "ij  \n    ", vt.String())
+
+	vt.Resize(6, 3)
+	assert.Equal(t, 1, vt.HistoryLen())
+	assert.Equal(t, "gh    \nij    \n      ", vt.String())
+	vt.ScrollBack(1)
+	assert.Equal(t, "abcdef\ngh    \nij    ", draw(vt))
+
+	vt.Resize(2, 3)
+	assert.Equal(t, 3, vt.HistoryLen())
+	assert.Equal(t, "gh\nij\n  ", vt.String())
+	vt.ScrollBack(4)
+	assert.Equal(t, "ab\ncd\nef", draw(vt))
+}
@@ -0,0 +1,1192 @@
+package cui
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+)
+
+// TextArea is a multi-line box where the user can enter text. Long lines are
+// wrapped at word boundaries by default, see SetWrap() and SetWordWrap(). Use
+// SetChangedFunc() to listen for changes and SetMaxLength() to limit the
+// number of characters which may be entered. A TextArea may be added to a Form
+// with Form.AddFormItem() or Form.AddTextArea().
+//
+// The following keys can be used for navigation and editing:
+//
+//   - Left arrow: Move left by one character.
+//   - Right arrow: Move right by one character.
+//   - Up arrow: Move up by one line.
+//   - Down arrow: Move down by one line.
+//   - Home, Ctrl-A: Move to the beginning of the line.
+//   - End, Ctrl-E: Move to the end of the line.
+//   - Ctrl-Home: Move to the beginning of the text.
+//   - Ctrl-End: Move to the end of the text.
+//   - Page up, page down: Move up or down by one page.
+//   - Alt-left, Ctrl-left: Move left by one word.
+//   - Alt-right, Ctrl-right: Move right by one word.
+//   - Shift plus any of the movement keys above: Extend the selection.
+//   - Enter: Insert a new line.
+//   - Backspace: Delete the selection or the character before the cursor.
+//   - Delete: Delete the selection or the character after the cursor.
+//   - Ctrl-K: Delete from the cursor to the end of the line.
+//   - Ctrl-U: Delete from the beginning of the line to the cursor.
+//   - Ctrl-W: Delete the last word before the cursor.
+//   - Ctrl-Z: Undo the last change.
+//   - Ctrl-Y: Redo the last undone change.
+//   - Alt-c, Ctrl-Insert: Copy the selection to the clipboard.
+//   - Ctrl-X: Cut the selection to the clipboard.
+//   - Ctrl-V, Shift-Insert: Paste from the clipboard.
+//   - Tab, Backtab, Escape: Leave the text area.
+//
+// The clipboard, undo, redo, copy, cut and paste shortcuts may be changed in
+// Keys.
+type TextArea struct {
+	box *Box
+
+	// The text that was entered.
+	text []rune
+
+	// The text to be displayed before the input area.
+	label []byte
+
+	// The text to be displayed in the input area when "text" is empty.
+	placeholder []byte
+
+	// The label color.
+	labelColor tcell.Color
+
+	// The label color when focused.
+	labelColorFocused tcell.Color
+
+	// The background color of the input area.
+	fieldBackgroundColor tcell.Color
+
+	// The background color of the input area when focused.
+	fieldBackgroundColorFocused tcell.Color
+
+	// The text color of the input area.
+	fieldTextColor tcell.Color
+
+	// The text color of the input area when focused.
+	fieldTextColorFocused tcell.Color
+
+	// The text color of the placeholder.
+	placeholderTextColor tcell.Color
+
+	// The text color of the placeholder when focused.
+	placeholderTextColorFocused tcell.Color
+
+	// The screen width of the label area. A value of 0 means use the width of
+	// the label text.
+	labelWidth int
+
+	// The screen width of the input area. A value of 0 means extend as much as
+	// possible.
+	fieldWidth int
+
+	// The number of lines the input area occupies when hosted in a Form.
+	fieldHeight int
+
+	// The maximum number of characters. A value of 0 means no limit.
+	maxLength int
+
+	// Whether long lines are wrapped.
+	wrap bool
+
+	// Whether long lines are wrapped at word boundaries.
+	wordWrap bool
+
+	// The cursor position as a rune index into the text.
+	cursor int
+
+	// The other end of the selection as a rune index into the text. There is
+	// no selection if it equals the cursor position.
+	anchor int
+
+	// The screen column the cursor moves to when moving up or down, or -1 to
+	// use the current column.
+	column int
+
+	// The states of the text before recent changes, and the states undone.
+	undoStack, redoStack []textAreaState
+
+	// Whether the last change was typing a character. Consecutive characters
+	// are undone together.
+	typing bool
+
+	// The clipboard used to copy, cut and paste.
+	clipboard Clipboard
+
+	// An optional function which is called when the text has changed.
+	changed func(text string)
+
+	// An optional function which is called when the user leaves the text area.
+	// The key which was pressed is provided (tab, shift-tab, or escape).
+	done func(tcell.Key)
+
+	// A callback function set by the Form class and called when the user leaves
+	// this form item.
+	finished func(tcell.Key)
+
+	// The position and size of the input area as determined during the last
+	// call to Draw(). The wrap width is 0 if lines are not wrapped.
+	fieldX, fieldY, wrapWidth, pageHeight int
+
+	// The first line and the first screen column shown.
+	rowOffset, columnOffset int
+
+	// Whether the next call to Draw() scrolls the cursor into view.
+	follow bool
+
+	// Whether a selection is being made with the mouse.
+	dragging bool
+
+	mu sync.RWMutex
+}
+
+// textAreaState is a snapshot of the text and cursor position of a TextArea.
+type textAreaState struct {
+	text   []rune
+	cursor int
+}
+
+// textAreaLine is a line on screen given by rune indices into the text. The
+// end is exclusive and excludes the line break.
+type textAreaLine struct {
+	start, end int
+}
+
+// NewTextArea returns a new text area.
+func NewTextArea() *TextArea {
+	return &TextArea{
+		box:                         NewBox(),
+		labelColor:                  Styles.SecondaryTextColor,
+		fieldBackgroundColor:        Styles.MoreContrastBackgroundColor,
+		fieldBackgroundColorFocused: Styles.ContrastBackgroundColor,
+		fieldTextColor:              Styles.PrimaryTextColor,
+		fieldTextColorFocused:       Styles.PrimaryTextColor,
+		placeholderTextColor:        Styles.ContrastSecondaryTextColor,
+		labelColorFocused:           ColorUnset,
+		placeholderTextColorFocused: ColorUnset,
+		fieldHeight:                 3,
+		wrap:                        true,
+		wordWrap:                    true,
+		column:                      -1,
+		clipboard:                   SystemClipboard,
+	}
+}
+
+///////////////////////////////////// <MUTEX> ///////////////////////////////////
+
+func (t *TextArea) set(setter func(t *TextArea)) *TextArea {
+	t.mu.Lock()
+	setter(t)
+	t.mu.Unlock()
+	return t
+}
+
+func (t *TextArea) get(getter func(t *TextArea)) {
+	t.mu.RLock()
+	getter(t)
+	t.mu.RUnlock()
+}
+
+///////////////////////////////////// <BOX> ////////////////////////////////////
+
+// GetTitle returns the title of this TextArea.
+func (t *TextArea) GetTitle() string {
+	return t.box.GetTitle()
+}
+
+// SetTitle sets the title of this TextArea.
+func (t *TextArea) SetTitle(title string) *TextArea {
+	t.box.SetTitle(title)
+	return t
+}
+
+// GetTitleAlign returns the title alignment of this TextArea.
+func (t *TextArea) GetTitleAlign() int {
+	return t.box.GetTitleAlign()
+}
+
+// SetTitleAlign sets the title alignment of this TextArea.
+func (t *TextArea) SetTitleAlign(align int) *TextArea {
+	t.box.SetTitleAlign(align)
+	return t
+}
+
+// GetBorder returns whether this TextArea has a border.
+func (t *TextArea) GetBorder() bool {
+	return t.box.GetBorder()
+}
+
+// SetBorder sets whether this TextArea has a border.
+func (t *TextArea) SetBorder(show bool) *TextArea {
+	t.box.SetBorder(show)
+	return t
+}
+
+// GetBorderColor returns the border color of this TextArea.
+func (t *TextArea) GetBorderColor() tcell.Color {
+	return t.box.GetBorderColor()
+}
+
+// SetBorderColor sets the border color of this TextArea.
+func (t *TextArea) SetBorderColor(color tcell.Color) *TextArea {
+	t.box.SetBorderColor(color)
+	return t
+}
+
+// GetBorderAttributes returns the border attributes of this TextArea.
+func (t *TextArea) GetBorderAttributes() tcell.AttrMask {
+	return t.box.GetBorderAttributes()
+}
+
+// SetBorderAttributes sets the border attributes of this TextArea.
+func (t *TextArea) SetBorderAttributes(attr tcell.AttrMask) *TextArea {
+	t.box.SetBorderAttributes(attr)
+	return t
+}
+
+// GetBorderColorFocused returns the border color of this TextArea when focused.
+func (t *TextArea) GetBorderColorFocused() tcell.Color {
+	return t.box.GetBorderColorFocused()
+}
+
+// SetBorderColorFocused sets the border color of this TextArea when focused.
+func (t *TextArea) SetBorderColorFocused(color tcell.Color) *TextArea {
+	t.box.SetBorderColorFocused(color)
+	return t
+}
+
+// GetTitleColor returns the title color of this TextArea.
+func (t *TextArea) GetTitleColor() tcell.Color {
+	return t.box.GetTitleColor()
+}
+
+// SetTitleColor sets the title color of this TextArea.
+func (t *TextArea) SetTitleColor(color tcell.Color) *TextArea {
+	t.box.SetTitleColor(color)
+	return t
+}
+
+// GetDrawFunc returns the custom draw function of this TextArea.
+func (t *TextArea) GetDrawFunc() func(screen tcell.Screen, x, y, width, height int) (int, int, int, int) {
+	return t.box.GetDrawFunc()
+}
+
+// SetDrawFunc sets a custom draw function for this TextArea.
+func (t *TextArea) SetDrawFunc(handler func(screen tcell.Screen, x, y, width, height int) (int, int, int, int)) *TextArea {
+	t.box.SetDrawFunc(handler)
+	return t
+}
+
+// ShowFocus sets whether this TextArea should show a focus indicator when focused.
+func (t *TextArea) ShowFocus(showFocus bool) *TextArea {
+	t.box.ShowFocus(showFocus)
+	return t
+}
+
+// GetMouseCapture returns the mouse capture function of this TextArea.
+func (t *TextArea) GetMouseCapture() func(action MouseAction, event *tcell.EventMouse) (MouseAction, *tcell.EventMouse) {
+	return t.box.GetMouseCapture()
+}
+
+// SetMouseCapture sets a mouse capture function for this TextArea.
+func (t *TextArea) SetMouseCapture(capture func(action MouseAction, event *tcell.EventMouse) (MouseAction, *tcell.EventMouse)) *TextArea {
+	t.box.SetMouseCapture(capture)
+	return t
+}
+
+// GetBackgroundColor returns the background color of this TextArea.
+func (t *TextArea) GetBackgroundColor() tcell.Color {
+	return t.box.GetBackgroundColor()
+}
+
+// SetBackgroundColor sets the background color of this TextArea.
+func (t *TextArea) SetBackgroundColor(color tcell.Color) *TextArea {
+	t.box.SetBackgroundColor(color)
+	return t
+}
+
+// GetBackgroundTransparent returns whether the background of this TextArea is transparent.
+func (t *TextArea) GetBackgroundTransparent() bool {
+	return t.box.GetBackgroundTransparent()
+}
+
+// SetBackgroundTransparent sets whether the background of this TextArea is transparent.
+func (t *TextArea) SetBackgroundTransparent(transparent bool) *TextArea {
+	t.box.SetBackgroundTransparent(transparent)
+	return t
+}
+
+// GetInputCapture returns the input capture function of this TextArea.
+func (t *TextArea) GetInputCapture() func(event *tcell.EventKey) *tcell.EventKey {
+	return t.box.GetInputCapture()
+}
+
+// SetInputCapture sets a custom input capture function for this TextArea.
+func (t *TextArea) SetInputCapture(capture func(event *tcell.EventKey) *tcell.EventKey) *TextArea {
+	t.box.SetInputCapture(capture)
+	return t
+}
+
+// GetPadding returns the padding of this TextArea.
+func (t *TextArea) GetPadding() (top, bottom, left, right int) {
+	return t.box.GetPadding()
+}
+
+// SetPadding sets the padding of this TextArea.
+func (t *TextArea) SetPadding(top, bottom, left, right int) *TextArea {
+	t.box.SetPadding(top, bottom, left, right)
+	return t
+}
+
+// InRect returns whether the given screen coordinates are within this TextArea.
+func (t *TextArea) InRect(x, y int) bool {
+	return t.box.InRect(x, y)
+}
+
+// GetInnerRect returns the inner rectangle of this TextArea.
+func (t *TextArea) GetInnerRect() (x, y, width, height int) {
+	return t.box.GetInnerRect()
+}
+
+// WrapInputHandler wraps the provided input handler function such that
+// input capture and other processing of the TextArea is preserved.
+func (t *TextArea) WrapInputHandler(inputHandler func(event *tcell.EventKey, setFocus func(p Widget))) func(event *tcell.EventKey, setFocus func(p Widget)) {
+	return t.box.WrapInputHandler(inputHandler)
+}
+
+// WrapMouseHandler wraps the provided mouse handler function such that
+// mouse capture and other processing of the TextArea is preserved.
+func (t *TextArea) WrapMouseHandler(mouseHandler func(action MouseAction, event *tcell.EventMouse, setFocus func(p Widget)) (consumed bool, capture Widget)) func(action MouseAction, event *tcell.EventMouse, setFocus func(p Widget)) (consumed bool, capture Widget) {
+	return t.box.WrapMouseHandler(mouseHandler)
+}
+
+// GetRect returns the rectangle occupied by this TextArea.
+func (t *TextArea) GetRect() (x, y, width, height int) {
+	return t.box.GetRect()
+}
+
+// SetRect sets the rectangle occupied by this TextArea.
+func (t *TextArea) SetRect(x, y, width, height int) {
+	t.box.SetRect(x, y, width, height)
+}
+
+// GetVisible returns whether this TextArea is visible.
+func (t *TextArea) GetVisible() bool {
+	return t.box.GetVisible()
+}
+
+// SetVisible sets whether this TextArea is visible.
+func (t *TextArea) SetVisible(visible bool) {
+	t.box.SetVisible(visible)
+}
+
+// Focus is called when this TextArea receives focus.
+func (t *TextArea) Focus(delegate func(p Widget)) {
+	t.box.Focus(delegate)
+}
+
+// HasFocus returns whether this TextArea has focus.
+func (t *TextArea) HasFocus() bool {
+	return t.box.HasFocus()
+}
+
+// GetFocusable returns the focusable primitive of this TextArea.
+func (t *TextArea) GetFocusable() Focusable {
+	return t.box.GetFocusable()
+}
+
+// Blur is called when this TextArea loses focus.
+func (t *TextArea) Blur() {
+	t.box.Blur()
+}
+
+////////////////////////////////// <API> ////////////////////////////////////
+
+// SetText sets the current text of the text area. The cursor is moved to the
+// end of the text and the undo history is cleared.
+func (t *TextArea) SetText(text string) *TextArea {
+	t.mu.Lock()
+	t.text = []rune(text)
+	t.cursor = len(t.text)
+	t.anchor = t.cursor
+	t.column = -1
+	t.undoStack = nil
+	t.redoStack = nil
+	t.typing = false
+	t.follow = true
+	if t.changed != nil {
+		t.mu.Unlock()
+		t.changed(text)
+	} else {
+		t.mu.Unlock()
+	}
+	return t
+}
+
+// GetText returns the current text of the text area.
+func (t *TextArea) GetText() (text string) {
+	t.get(func(t *TextArea) { text = string(t.text) })
+	return
+}
+
+// SetLabel sets the text to be displayed before the input area.
+func (t *TextArea) SetLabel(label string) *TextArea {
+	return t.set(func(t *TextArea) { t.label = []byte(label) })
+}
+
+// GetLabel returns the text to be displayed before the input area.
+func (t *TextArea) GetLabel() (label string) {
+	t.get(func(t *TextArea) { label = string(t.label) })
+	return
+}
+
+// SetLabelWidth sets the screen width of the label. A value of 0 will cause the
+// primitive to use the width of the label string.
+func (t *TextArea) SetLabelWidth(width int) *TextArea {
+	return t.set(func(t *TextArea) { t.labelWidth = width })
+}
+
+// SetPlaceholder sets the text to be displayed when the text is empty.
+func (t *TextArea) SetPlaceholder(text string) *TextArea {
+	return t.set(func(t *TextArea) { t.placeholder = []byte(text) })
+}
+
+// SetLabelColor sets the color of the label.
+func (t *TextArea) SetLabelColor(color tcell.Color) *TextArea {
+	return t.set(func(t *TextArea) { t.labelColor = color })
+}
+
+// SetLabelColorFocused sets the color of the label when focused.
+func (t *TextArea) SetLabelColorFocused(color tcell.Color) *TextArea {
+	return t.set(func(t *TextArea) { t.labelColorFocused = color })
+}
+
+// SetFieldBackgroundColor sets the background color of the input area.
+func (t *TextArea) SetFieldBackgroundColor(color tcell.Color) *TextArea {
+	return t.set(func(t *TextArea) { t.fieldBackgroundColor = color })
+}
+
+// SetFieldBackgroundColorFocused sets the background color of the input area
+// when focused.
+func (t *TextArea) SetFieldBackgroundColorFocused(color tcell.Color) *TextArea {
+	return t.set(func(t *TextArea) { t.fieldBackgroundColorFocused = color })
+}
+
+// SetFieldTextColor sets the text color of the input area.
+func (t *TextArea) SetFieldTextColor(color tcell.Color) *TextArea {
+	return t.set(func(t *TextArea) { t.fieldTextColor = color })
+}
+
+// SetFieldTextColorFocused sets the text color of the input area when focused.
+func (t *TextArea) SetFieldTextColorFocused(color tcell.Color) *TextArea {
+	return t.set(func(t *TextArea) { t.fieldTextColorFocused = color })
+}
+
+// SetPlaceholderTextColor sets the text color of placeholder text.
+func (t *TextArea) SetPlaceholderTextColor(color tcell.Color) *TextArea {
+	return t.set(func(t *TextArea) { t.placeholderTextColor = color })
+}
+
+// SetPlaceholderTextColorFocused sets the text color of placeholder text when
+// focused.
+func (t *TextArea) SetPlaceholderTextColorFocused(color tcell.Color) *TextArea {
+	return t.set(func(t *TextArea) { t.placeholderTextColorFocused = color })
+}
+
+// SetFieldWidth sets the screen width of the input area. A value of 0 means
+// extend as much as possible.
+func (t *TextArea) SetFieldWidth(width int) *TextArea {
+	return t.set(func(t *TextArea) { t.fieldWidth = width })
+}
+
+// GetFieldWidth returns this primitive's field width.
+func (t *TextArea) GetFieldWidth() (width int) {
+	t.get(func(t *TextArea) { width = t.fieldWidth })
+	return
+}
+
+// SetFieldHeight sets the number of lines the input area occupies when the
+// text area is hosted in a Form. The default is 3. Outside of a Form, the
+// input area fills the text area's rectangle.
+func (t *TextArea) SetFieldHeight(height int) *TextArea {
+	return t.set(func(t *TextArea) { t.fieldHeight = height })
+}
+
+// GetFieldHeight returns the height of the field.
+func (t *TextArea) GetFieldHeight() (height int) {
+	t.get(func(t *TextArea) { height = t.fieldHeight })
+	return
+}
+
+// SetMaxLength sets the maximum number of characters of the text. Typed or
+// pasted text which exceeds the limit is cut off. A value of 0 means no limit.
+func (t *TextArea) SetMaxLength(maxLength int) *TextArea {
+	return t.set(func(t *TextArea) { t.maxLength = maxLength })
+}
+
+// GetMaxLength returns the maximum number of characters of the text.
+func (t *TextArea) GetMaxLength() (maxLength int) {
+	t.get(func(t *TextArea) { maxLength = t.maxLength })
+	return
+}
+
+// SetWrap sets whether lines longer than the width of the input area are
+// wrapped onto the next line. If disabled, the text scrolls horizontally.
+func (t *TextArea) SetWrap(wrap bool) *TextArea {
+	return t.set(func(t *TextArea) { t.wrap = wrap })
+}
+
+// SetWordWrap sets whether wrapped lines are broken at word boundaries. If
+// disabled, lines are broken at the last character which fits.
+func (t *TextArea) SetWordWrap(wordWrap bool) *TextArea {
+	return t.set(func(t *TextArea) { t.wordWrap = wordWrap })
+}
+
+// SetClipboard sets the clipboard used to copy, cut and paste. The system
+// clipboard is used by default.
+func (t *TextArea) SetClipboard(clipboard Clipboard) *TextArea {
+	return t.set(func(t *TextArea) { t.clipboard = clipboard })
+}
+
+// GetCursor returns the cursor position as a character index into the text.
+func (t *TextArea) GetCursor() (cursor int) {
+	t.get(func(t *TextArea) { cursor = t.cursor })
+	return
+}
+
+// SetCursor moves the cursor to the given character index into the text and
+// clears the selection.
+func (t *TextArea) SetCursor(cursor int) *TextArea {
+	return t.set(func(t *TextArea) {
+		t.moveTo(cursor, false)
+	})
+}
+
+// Select selects the text between the given character indices. The cursor is
+// placed at the end index.
+func (t *TextArea) Select(start, end int) *TextArea {
+	return t.set(func(t *TextArea) {
+		t.moveTo(start, false)
+		t.moveTo(end, true)
+	})
+}
+
+// GetSelection returns the selected text and its start and end character
+// indices. The text is empty if nothing is selected.
+func (t *TextArea) GetSelection() (text string, start, end int) {
+	t.get(func(t *TextArea) {
+		start, end = t.selection()
+		text = string(t.text[start:end])
+	})
+	return
+}
+
+// Undo reverts the last change of the text. It returns false if there is
+// nothing to undo.
+func (t *TextArea) Undo() bool {
+	return t.restore(&t.undoStack, &t.redoStack)
+}
+
+// Redo reapplies the last change reverted by Undo(). It returns false if there
+// is nothing to redo.
+func (t *TextArea) Redo() bool {
+	return t.restore(&t.redoStack, &t.undoStack)
+}
+
+// SetChangedFunc sets a handler which is called whenever the text of the text
+// area has changed. It receives the current text (after the change).
+func (t *TextArea) SetChangedFunc(handler func(text string)) *TextArea {
+	return t.set(func(t *TextArea) { t.changed = handler })
+}
+
+// SetDoneFunc sets a handler which is called when the user leaves the text
+// area. The callback function is provided with the key that was pressed, which
+// is one of the following:
+//
+//   - KeyEscape: Abort text input.
+//   - KeyTab: Move to the next field.
+//   - KeyBacktab: Move to the previous field.
+func (t *TextArea) SetDoneFunc(handler func(key tcell.Key)) *TextArea {
+	return t.set(func(t *TextArea) { t.done = handler })
+}
+
+// SetFinishedFunc sets a callback invoked when the user leaves this form item.
+func (t *TextArea) SetFinishedFunc(handler func(key tcell.Key)) *TextArea {
+	return t.set(func(t *TextArea) { t.finished = handler })
+}
+
+// restore replaces the text with the last state of the "from" stack and pushes
+// the current state onto the "to" stack.
+func (t *TextArea) restore(from, to *[]textAreaState) bool {
+	t.mu.Lock()
+	if len(*from) == 0 {
+		t.mu.Unlock()
+		return false
+	}
+	state := (*from)[len(*from)-1]
+	*from = (*from)[:len(*from)-1]
+	*to = append(*to, textAreaState{text: t.text, cursor: t.cursor})
+	t.text = state.text
+	t.moveTo(state.cursor, false)
+	t.typing = false
+	text, changed := string(t.text), t.changed
+	t.mu.Unlock()
+	if changed != nil {
+		changed(text)
+	}
+	return true
+}
+
+// moveTo moves the cursor to the given character index. The selection is
+// extended if "selecting" is true and cleared otherwise.
+func (t *TextArea) moveTo(cursor int, selecting bool) {
+	if cursor < 0 {
+		cursor = 0
+	} else if cursor > len(t.text) {
+		cursor = len(t.text)
+	}
+	t.cursor = cursor
+	if !selecting {
+		t.anchor = cursor
+	} else if t.anchor > len(t.text) {
+		t.anchor = len(t.text)
+	}
+	t.column = -1
+	t.follow = true
+}
+
+// selection returns the start and end character indices of the selection.
+func (t *TextArea) selection() (start, end int) {
+	start, end = t.anchor, t.cursor
+	if start > end {
+		start, end = end, start
+	}
+	return
+}
+
+// replace replaces the text between the given character indices, which
+// includes the selection, with the given text and places the cursor after it.
+// The new text is cut off at the maximum length. If "merge" is true, the change
+// is undone together with the previous one. It returns false if the text was
+// not changed.
+func (t *TextArea) replace(start, end int, text []rune, merge bool) bool {
+	if t.maxLength > 0 {
+		if room := t.maxLength - len(t.text) + end - start; room < len(text) {
+			if room < 0 {
+				room = 0
+			}
+			text = text[:room]
+		}
+	}
+	if start == end && len(text) == 0 {
+		return false
+	}
+	if !merge || len(t.undoStack) == 0 {
+		t.undoStack = append(t.undoStack, textAreaState{text: t.text, cursor: t.cursor})
+	}
+	t.redoStack = nil
+	newText := make([]rune, 0, len(t.text)-end+start+len(text))
+	newText = append(newText, t.text[:start]...)
+	newText = append(newText, text...)
+	t.text = append(newText, t.text[end:]...)
+	t.moveTo(start+len(text), false)
+	return true
+}
+
+// layout breaks the text into the lines shown on screen.
+func (t *TextArea) layout() (lines []textAreaLine) {
+	for start := 0; start <= len(t.text); {
+		end := start
+		for end < len(t.text) && t.text[end] != '\n' {
+			end++
+		}
+		lineStart, lineWidth, space := start, 0, -1
+		for index := start; t.wrapWidth > 0 && index < end; index++ {
+			width := textAreaRuneWidth(t.text[index])
+			if lineWidth+width > t.wrapWidth && index > lineStart {
+				lineEnd := index
+				if t.wordWrap && space > lineStart {
+					lineEnd = space
+				}
+				lines = append(lines, textAreaLine{start: lineStart, end: lineEnd})
+				lineStart, space = lineEnd, -1
+				lineWidth = textAreaStringWidth(t.text[lineStart:index])
+			}
+			lineWidth += width
+			if unicode.IsSpace(t.text[index]) {
+				space = index + 1
+			}
+		}
+		lines = append(lines, textAreaLine{start: lineStart, end: end})
+		start = end + 1
+	}
+	return
+}
+
+// lineAt returns the index of the line which contains the given character
+// index. An index at which a line is wrapped belongs to the following line.
+func (t *TextArea) lineAt(lines []textAreaLine, cursor int) int {
+	for index := len(lines) - 1; index > 0; index-- {
+		if lines[index].start <= cursor {
+			return index
+		}
+	}
+	return 0
+}
+
+// lineEnd returns the last character index the cursor may be placed at on the
+// given line. On wrapped lines, this is before the last character.
+func (t *TextArea) lineEnd(lines []textAreaLine, line int) int {
+	end := lines[line].end
+	if line+1 < len(lines) && lines[line+1].start == end && end > lines[line].start {
+		end--
+	}
+	return end
+}
+
+// positionAt returns the character index shown at the given screen column of
+// the given line.
+func (t *TextArea) positionAt(lines []textAreaLine, line, column int) int {
+	end := t.lineEnd(lines, line)
+	var width int
+	for index := lines[line].start; index < end; index++ {
+		width += textAreaRuneWidth(t.text[index])
+		if width > column {
+			return index
+		}
+	}
+	return end
+}
+
+// moveLine moves the cursor up (negative) or down (positive) by the given
+// number of lines, keeping its screen column.
+func (t *TextArea) moveLine(delta int, selecting bool) {
+	lines := t.layout()
+	line := t.lineAt(lines, t.cursor)
+	column := t.column
+	if column < 0 {
+		column = textAreaStringWidth(t.text[lines[line].start:t.cursor])
+	}
+	line += delta
+	if line < 0 {
+		t.moveTo(0, selecting)
+		return
+	} else if line >= len(lines) {
+		t.moveTo(len(t.text), selecting)
+		return
+	}
+	t.moveTo(t.positionAt(lines, line, column), selecting)
+	t.column = column
+}
+
+// wordLeft returns the character index of the beginning of the word before
+// the cursor.
+func (t *TextArea) wordLeft() int {
+	index := t.cursor
+	for index > 0 && !isWordRune(t.text[index-1]) {
+		index--
+	}
+	for index > 0 && isWordRune(t.text[index-1]) {
+		index--
+	}
+	return index
+}
+
+// wordRight returns the character index of the end of the word after the
+// cursor.
+func (t *TextArea) wordRight() int {
+	index := t.cursor
+	for index < len(t.text) && !isWordRune(t.text[index]) {
+		index++
+	}
+	for index < len(t.text) && isWordRune(t.text[index]) {
+		index++
+	}
+	return index
+}
+
+// isWordRune returns whether the rune is part of a word.
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// textAreaRuneWidth returns the screen width of a rune in a TextArea. Tabs are
+// shown as a single space.
+func textAreaRuneWidth(r rune) int {
+	if r == '\t' {
+		return 1
+	}
+	return runewidth.RuneWidth(r)
+}
+
+// textAreaStringWidth returns the screen width of runes in a TextArea.
+func textAreaStringWidth(text []rune) (width int) {
+	for _, r := range text {
+		width += textAreaRuneWidth(r)
+	}
+	return
+}
+
+// Draw draws this primitive onto the screen.
+func (t *TextArea) Draw(screen tcell.Screen) {
+	if !t.GetVisible() {
+		return
+	}
+
+	t.box.Draw(screen)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// Select colors
+	labelColor := t.labelColor
+	fieldBackgroundColor := t.fieldBackgroundColor
+	fieldTextColor := t.fieldTextColor
+	placeholderTextColor := t.placeholderTextColor
+	hasFocus := t.GetFocusable().HasFocus()
+	if hasFocus {
+		if t.labelColorFocused != ColorUnset {
+			labelColor = t.labelColorFocused
+		}
+		if t.fieldBackgroundColorFocused != ColorUnset {
+			fieldBackgroundColor = t.fieldBackgroundColorFocused
+		}
+		if t.fieldTextColorFocused != ColorUnset {
+			fieldTextColor = t.fieldTextColorFocused
+		}
+		if t.placeholderTextColorFocused != ColorUnset {
+			placeholderTextColor = t.placeholderTextColorFocused
+		}
+	}
+
+	// Prepare
+	x, y, width, height := t.GetInnerRect()
+	rightLimit := x + width
+	if height < 1 || rightLimit <= x {
+		return
+	}
+
+	// Draw label.
+	if t.labelWidth > 0 {
+		labelWidth := t.labelWidth
+		if labelWidth > rightLimit-x {
+			labelWidth = rightLimit - x
+		}
+		Print(screen, t.label, x, y, labelWidth, AlignLeft, labelColor)
+		x += labelWidth
+	} else {
+		_, drawnWidth := Print(screen, t.label, x, y, rightLimit-x, AlignLeft, labelColor)
+		x += drawnWidth
+	}
+
+	// Draw input area.
+	fieldWidth := t.fieldWidth
+	if fieldWidth == 0 {
+		fieldWidth = math.MaxInt32
+	}
+	if rightLimit-x < fieldWidth {
+		fieldWidth = rightLimit - x
+	}
+	t.fieldX, t.fieldY, t.pageHeight = x, y, height
+	fieldStyle := tcell.StyleDefault.Background(fieldBackgroundColor)
+	for row := 0; row < height; row++ {
+		for index := 0; index < fieldWidth; index++ {
+			screen.SetContent(x+index, y+row, ' ', nil, fieldStyle)
+		}
+	}
+
+	// Break the text into lines, keeping one column free for the cursor.
+	t.wrapWidth = 0
+	if t.wrap {
+		t.wrapWidth = fieldWidth - 1
+		if t.wrapWidth < 1 {
+			t.wrapWidth = 1
+		}
+	}
+	lines := t.layout()
+	cursorLine := t.lineAt(lines, t.cursor)
+	cursorColumn := textAreaStringWidth(t.text[lines[cursorLine].start:t.cursor])
+
+	// Scroll the cursor into view.
+	if t.follow {
+		if cursorLine < t.rowOffset {
+			t.rowOffset = cursorLine
+		} else if cursorLine >= t.rowOffset+height {
+			t.rowOffset = cursorLine - height + 1
+		}
+		if cursorColumn < t.columnOffset {
+			t.columnOffset = cursorColumn
+		} else if cursorColumn >= t.columnOffset+fieldWidth {
+			t.columnOffset = cursorColumn - fieldWidth + 1
+		}
+		t.follow = false
+	}
+	if t.rowOffset > len(lines)-height {
+		t.rowOffset = len(lines) - height
+	}
+	if t.rowOffset < 0 {
+		t.rowOffset = 0
+	}
+	if t.wrap {
+		t.columnOffset = 0
+	}
+
+	// Text.
+	if len(t.text) == 0 && len(t.placeholder) > 0 {
+		Print(screen, EscapeBytes(t.placeholder), x, y, fieldWidth, AlignLeft, placeholderTextColor)
+	} else {
+		textStyle := fieldStyle.Foreground(fieldTextColor)
+		selectedStyle := tcell.StyleDefault.Background(fieldTextColor).Foreground(fieldBackgroundColor)
+		start, end := t.selection()
+		for row := 0; row < height && t.rowOffset+row < len(lines); row++ {
+			line := lines[t.rowOffset+row]
+			var column int
+			for index := line.start; index < line.end; index++ {
+				r := t.text[index]
+				runeWidth := textAreaRuneWidth(r)
+				if column+runeWidth > t.columnOffset+fieldWidth {
+					break
+				}
+				if column >= t.columnOffset && runeWidth > 0 {
+					style := textStyle
+					if index >= start && index < end {
+						style = selectedStyle
+					}
+					if r == '\t' {
+						r = ' '
+					}
+					screen.SetContent(x+column-t.columnOffset, y+row, r, nil, style)
+				}
+				column += runeWidth
+			}
+
+			// Show selected line breaks.
+			if line.end >= start && line.end < end && t.text[line.end] == '\n' && column >= t.columnOffset && column < t.columnOffset+fieldWidth {
+				screen.SetContent(x+column-t.columnOffset, y+row, ' ', nil, selectedStyle)
+			}
+		}
+	}
+
+	// Set cursor.
+	if row, column := cursorLine-t.rowOffset, cursorColumn-t.columnOffset; hasFocus && row < height && column < fieldWidth {
+		screen.ShowCursor(x+column, y+row)
+	}
+}
+
+// InputHandler returns the handler for this primitive.
+func (t *TextArea) InputHandler() func(event *tcell.EventKey, setFocus func(p Widget)) {
+	return t.WrapInputHandler(func(event *tcell.EventKey, setFocus func(p Widget)) {
+		// Undo and redo notify about changes themselves.
+		if HitShortcut(event, Keys.TextAreaUndo) {
+			t.Undo()
+			return
+		} else if HitShortcut(event, Keys.TextAreaRedo) {
+			t.Redo()
+			return
+		}
+
+		t.mu.Lock()
+
+		typing := t.typing
+		t.typing = false
+		selecting := event.Modifiers()&tcell.ModShift != 0
+		word := event.Modifiers()&(tcell.ModAlt|tcell.ModCtrl) != 0
+		start, end := t.selection()
+		hasSelection := start != end
+
+		// Delete the selection or, if there is none, the given range.
+		remove := func(from, to int) bool {
+			if hasSelection {
+				from, to = start, end
+			}
+			if from < 0 {
+				from = 0
+			}
+			if to > len(t.text) {
+				to = len(t.text)
+			}
+			return t.replace(from, to, nil, false)
+		}
+
+		// Finish up.
+		finish := func(key tcell.Key) {
+			done, finished := t.done, t.finished
+			t.mu.Unlock()
+			if done != nil {
+				done(key)
+			}
+			if finished != nil {
+				finished(key)
+			}
+		}
+
+		// Process key event.
+		var modified bool
+		switch key := event.Key(); {
+		case HitShortcut(event, Keys.TextAreaCopy):
+			if hasSelection && t.clipboard != nil {
+				t.clipboard.WriteAll(string(t.text[start:end]))
+			}
+		case HitShortcut(event, Keys.TextAreaCut):
+			if hasSelection && t.clipboard != nil {
+				t.clipboard.WriteAll(string(t.text[start:end]))
+				modified = remove(start, end)
+			}
+		case HitShortcut(event, Keys.TextAreaPaste):
+			if t.clipboard != nil {
+				if text, err := t.clipboard.ReadAll(); err == nil {
+					text = strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(text)
+					modified = t.replace(start, end, []rune(text), false)
+				}
+			}
+		case key == tcell.KeyRune: // Regular character.
+			r := event.Rune()
+			modified = t.replace(start, end, []rune{r}, typing && !hasSelection && !unicode.IsSpace(r))
+			t.typing = modified
+		case key == tcell.KeyEnter:
+			modified = t.replace(start, end, []rune{'\n'}, false)
+		case key == tcell.KeyBackspace || key == tcell.KeyBackspace2: // Delete character before the cursor.
+			modified = remove(t.cursor-1, t.cursor)
+		case key == tcell.KeyDelete: // Delete character after the cursor.
+			modified = remove(t.cursor, t.cursor+1)
+		case key == tcell.KeyCtrlK: // Delete until the end of the line.
+			lineEnd := t.cursor
+			for lineEnd < len(t.text) && t.text[lineEnd] != '\n' {
+				lineEnd++
+			}
+			if lineEnd == t.cursor {
+				lineEnd++
+			}
+			modified = remove(t.cursor, lineEnd)
+		case key == tcell.KeyCtrlU: // Delete from the beginning of the line.
+			lineStart := t.cursor
+			for lineStart > 0 && t.text[lineStart-1] != '\n' {
+				lineStart--
+			}
+			modified = remove(lineStart, t.cursor)
+		case key == tcell.KeyCtrlW: // Delete last word.
+			modified = remove(t.wordLeft(), t.cursor)
+		case key == tcell.KeyLeft:
+			if hasSelection && !selecting {
+				t.moveTo(start, false)
+			} else if word {
+				t.moveTo(t.wordLeft(), selecting)
+			} else {
+				t.moveTo(t.cursor-1, selecting)
+			}
+		case key == tcell.KeyRight:
+			if hasSelection && !selecting {
+				t.moveTo(end, false)
+			} else if word {
+				t.moveTo(t.wordRight(), selecting)
+			} else {
+				t.moveTo(t.cursor+1, selecting)
+			}
+		case key == tcell.KeyUp:
+			t.moveLine(-1, selecting)
+		case key == tcell.KeyDown:
+			t.moveLine(1, selecting)
+		case key == tcell.KeyPgUp:
+			t.moveLine(-t.pageHeight, selecting)
+		case key == tcell.KeyPgDn:
+			t.moveLine(t.pageHeight, selecting)
+		case key == tcell.KeyHome && event.Modifiers()&tcell.ModCtrl != 0:
+			t.moveTo(0, selecting)
+		case key == tcell.KeyEnd && event.Modifiers()&tcell.ModCtrl != 0:
+			t.moveTo(len(t.text), selecting)
+		case key == tcell.KeyHome || key == tcell.KeyCtrlA:
+			lines := t.layout()
+			t.moveTo(lines[t.lineAt(lines, t.cursor)].start, selecting)
+		case key == tcell.KeyEnd || key == tcell.KeyCtrlE:
+			lines := t.layout()
+			t.moveTo(t.lineEnd(lines, t.lineAt(lines, t.cursor)), selecting)
+		case key == tcell.KeyTab || key == tcell.KeyBacktab || key == tcell.KeyEscape: // We're done.
+			finish(key)
+			return
+		}
+
+		text, changed := string(t.text), t.changed
+		t.mu.Unlock()
+
+		if modified && changed != nil {
+			changed(text)
+		}
+	})
+}
+
+// MouseHandler returns the mouse handler for this primitive.
+func (t *TextArea) MouseHandler() func(action MouseAction, event *tcell.EventMouse, setFocus func(p Widget)) (consumed bool, capture Widget) {
+	return t.WrapMouseHandler(func(action MouseAction, event *tcell.EventMouse, setFocus func(p Widget)) (consumed bool, capture Widget) {
+		x, y := event.Position()
+
+		t.mu.Lock()
+		if !t.InRect(x, y) && !t.dragging {
+			t.mu.Unlock()
+			return false, nil
+		}
+
+		// Process mouse event.
+		var focus bool
+		switch action {
+		case MouseLeftDown:
+			if x >= t.fieldX {
+				t.moveTo(t.positionAtScreen(x, y), event.Modifiers()&tcell.ModShift != 0)
+				t.dragging = true
+				capture = t
+			}
+			focus, consumed = true, true
+		case MouseMove:
+			if t.dragging {
+				t.moveTo(t.positionAtScreen(x, y), true)
+				consumed, capture = true, t
+			}
+		case MouseLeftUp:
+			if t.dragging {
+				t.dragging = false
+				consumed = true
+			}
+		case MouseLeftClick:
+			focus, consumed = true, true
+		case MouseScrollUp:
+			if t.rowOffset > 0 {
+				t.rowOffset--
+			}
+			consumed = true
+		case MouseScrollDown:
+			t.rowOffset++
+			consumed = true
+		}
+		t.mu.Unlock()
+
+		if focus {
+			setFocus(t)
+		}
+		return
+	})
+}
+
+// positionAtScreen returns the character index shown at the given screen
+// position.
+func (t *TextArea) positionAtScreen(x, y int) int {
+	lines := t.layout()
+	line := t.rowOffset + y - t.fieldY
+	if line < 0 {
+		return 0
+	} else if line >= len(lines) {
+		return len(t.text)
+	}
+	column := x - t.fieldX + t.columnOffset
+	if column < 0 {
+		column = 0
+	}
+	return t.positionAt(lines, line, column)
+}
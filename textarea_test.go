@@ -0,0 +1,172 @@
+package cui
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+type testClipboard struct {
+	text string
+}
+
+func (c *testClipboard) ReadAll() (string, error) {
+	return c.text, nil
+}
+
+func (c *testClipboard) WriteAll(text string) error {
+	c.text = text
+	return nil
+}
+
+func TestTextArea(t *testing.T) {
+	t.Parallel()
+
+	var changes int
+	clipboard := &testClipboard{}
+	a := NewTextArea().SetClipboard(clipboard).SetChangedFunc(func(text string) { changes++ })
+	a.SetRect(0, 0, 11, 3)
+
+	handler := a.InputHandler()
+	press := func(key tcell.Key, r rune, mod tcell.ModMask) {
+		handler(tcell.NewEventKey(key, r, mod), func(p Widget) {})
+	}
+	typeText := func(text string) {
+		for _, r := range text {
+			press(tcell.KeyRune, r, tcell.ModNone)
+		}
+	}
+
+	typeText("hello world")
+	press(tcell.KeyEnter, 0, tcell.ModNone)
+	typeText("again")
+	if text := a.GetText(); text != "hello world\nagain" {
+		t.Fatalf("failed to type text: expected %q, got %q", "hello world\nagain", text)
+	}
+	if changes != 17 {
+		t.Errorf("failed to notify changes: expected 17, got %d", changes)
+	}
+
+	// Long lines are wrapped at word boundaries
+	screen := tcell.NewSimulationScreen("UTF-8")
+	if err := screen.Init(); err != nil {
+		t.Fatalf("failed to initialize screen: %s", err)
+	}
+	screen.SetSize(11, 3)
+	a.Draw(screen)
+	for row, expected := range []string{"hello ", "world", "again"} {
+		for column, r := range expected {
+			if c, _, _, _ := screen.GetContent(column, row); c != r {
+				t.Errorf("failed to wrap line %d: expected %c at %d, got %c", row, r, column, c)
+			}
+		}
+	}
+
+	// Moving up keeps the column
+	press(tcell.KeyUp, 0, tcell.ModNone)
+	if cursor := a.GetCursor(); cursor != 11 {
+		t.Errorf("failed to move up: expected 11, got %d", cursor)
+	}
+	press(tcell.KeyHome, 0, tcell.ModNone)
+	if cursor := a.GetCursor(); cursor != 6 {
+		t.Errorf("failed to move to line start: expected 6, got %d", cursor)
+	}
+
+	// Select, copy and paste
+	press(tcell.KeyRight, 0, tcell.ModShift|tcell.ModCtrl)
+	if text, start, end := a.GetSelection(); text != "world" || start != 6 || end != 11 {
+		t.Errorf("failed to select word: expected world 6-11, got %s %d-%d", text, start, end)
+	}
+	press(tcell.KeyRune, 'c', tcell.ModAlt)
+	if clipboard.text != "world" {
+		t.Errorf("failed to copy selection: expected world, got %s", clipboard.text)
+	}
+	press(tcell.KeyEnd, 0, tcell.ModCtrl)
+	press(tcell.KeyCtrlV, 0, tcell.ModCtrl)
+	if text := a.GetText(); text != "hello world\nagainworld" {
+		t.Errorf("failed to paste: expected %q, got %q", "hello world\nagainworld", text)
+	}
+	for i := 0; i < 5; i++ {
+		press(tcell.KeyLeft, 0, tcell.ModShift)
+	}
+	press(tcell.KeyCtrlX, 0, tcell.ModCtrl)
+	if text := a.GetText(); text != "hello world\nagain" || clipboard.text != "world" {
+		t.Errorf("failed to cut: expected %q, got %q", "hello world\nagain", text)
+	}
+
+	// Undo reverts pastes, cuts and words typed
+	press(tcell.KeyCtrlZ, 0, tcell.ModCtrl)
+	if text := a.GetText(); text != "hello world\nagainworld" {
+		t.Errorf("failed to undo cut: expected %q, got %q", "hello world\nagainworld", text)
+	}
+	a.Undo()
+	a.Undo()
+	if text := a.GetText(); text != "hello world\n" {
+		t.Errorf("failed to undo typing: expected %q, got %q", "hello world\n", text)
+	}
+	press(tcell.KeyCtrlY, 0, tcell.ModCtrl)
+	if text := a.GetText(); text != "hello world\nagain" {
+		t.Errorf("failed to redo: expected %q, got %q", "hello world\nagain", text)
+	}
+
+	// The maximum length cuts off typed and pasted text
+	a.SetText("").SetMaxLength(4)
+	typeText("abc")
+	clipboard.text = "xyz"
+	press(tcell.KeyCtrlV, 0, tcell.ModCtrl)
+	typeText("d")
+	if text := a.GetText(); text != "abcx" {
+		t.Errorf("failed to limit length: expected abcx, got %s", text)
+	}
+
+	// Tab leaves the text area
+	var done tcell.Key
+	a.SetDoneFunc(func(key tcell.Key) { done = key })
+	press(tcell.KeyTab, 0, tcell.ModNone)
+	if done != tcell.KeyTab {
+		t.Errorf("failed to finish: expected %v, got %v", tcell.KeyTab, done)
+	}
+}
+
+func TestTextAreaForm(t *testing.T) {
+	t.Parallel()
+
+	f := NewForm().
+		AddTextArea("Note", "first\nsecond\nthird", 0, 3, 0, nil).
+		AddInputField("Name", "", 0, nil, nil)
+
+	screen := tcell.NewSimulationScreen("UTF-8")
+	if err := screen.Init(); err != nil {
+		t.Fatalf("failed to initialize screen: %s", err)
+	}
+	screen.SetSize(30, 10)
+	f.SetRect(0, 0, 30, 10)
+	f.Draw(screen)
+
+	// The text area occupies its field height and the next item follows it
+	for row, expected := range []rune{'f', 's', 't'} {
+		if r, _, _, _ := screen.GetContent(6, 1+row); r != expected {
+			t.Errorf("failed to draw line %d: expected %c, got %c", row, expected, r)
+		}
+	}
+	if r, _, _, _ := screen.GetContent(1, 5); r != 'N' {
+		t.Errorf("failed to place next item: expected N, got %c", r)
+	}
+
+	// Enter inserts a line break, Tab moves to the next item
+	var focus Widget
+	f.Focus(func(p Widget) { focus = p })
+	area := f.GetFormItem(0).(*TextArea)
+	if focus != area {
+		t.Fatalf("failed to focus text area: expected text area, got %T", focus)
+	}
+	handler := area.InputHandler()
+	handler(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone), func(p Widget) {})
+	handler(tcell.NewEventKey(tcell.KeyTab, 0, tcell.ModNone), func(p Widget) {})
+	if text := area.GetText(); text != "first\nsecond\nthird\n" {
+		t.Errorf("failed to insert line break: expected %q, got %q", "first\nsecond\nthird\n", text)
+	}
+	if focus != f.GetFormItem(1) {
+		t.Errorf("failed to move to next item: expected name input, got %T", focus)
+	}
+}
@@ -1,11 +1,17 @@
 package cui
 
 import (
+	"fmt"
 	"sync"
+	"time"
 
 	"github.com/gdamore/tcell/v2"
 )
 
+// treeSpinnerFrame is the interval at which the spinner of nodes whose
+// children are being loaded is animated.
+const treeSpinnerFrame = 100 * time.Millisecond
+
 // Tree navigation events.
 const (
 	treeNone int = iota
@@ -46,6 +52,14 @@ type TreeNode struct {
 	// An optional function which is called when the user selects this node.
 	selected func()
 
+	// Whether the children are loaded by the tree's loader when this node is
+	// expanded for the first time.
+	lazy bool
+
+	// The load state of lazy nodes. It is guarded by the tree's mutex.
+	loaded, loading bool
+	loadErr         error
+
 	// Temporary member variables.
 	parent    *TreeNode // The parent node (nil for the root).
 	level     int       // The hierarchy level (0 for the root, 1 for its children, and so on).
@@ -235,6 +249,17 @@ func (n *TreeNode) CollapseAll() {
 	})
 }
 
+// SetLazy sets whether the children of this node are loaded by the loader of
+// the tree (see Tree.SetLoader()) when the node is expanded for the first
+// time. Lazy nodes are usually collapsed initially.
+func (n *TreeNode) SetLazy(lazy bool) *TreeNode {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.lazy = lazy
+	return n
+}
+
 // IsExpanded returns whether the child nodes of this node are visible.
 func (n *TreeNode) IsExpanded() bool {
 	n.mu.RLock()
@@ -300,6 +325,9 @@ func (n *TreeNode) SetIndent(indent int) *TreeNode {
 //   - G, end: Move (the selection) to the bottom.
 //   - Ctrl-F, page down: Move (the selection) down by one page.
 //   - Ctrl-B, page up: Move (the selection) up by one page.
+//   - /: Filter the nodes if enabled with SetFilterable().
+//   - Space, Shift+Up, Shift+Down, Ctrl+A: Select several nodes if enabled
+//     with SetMultiSelect().
 //
 // Selected nodes can trigger the "selected" callback when the user hits Enter.
 //
@@ -308,6 +336,11 @@ func (n *TreeNode) SetIndent(indent int) *TreeNode {
 // displayed is 0, i.e. the root node. You can call SetTopLevel() to hide
 // levels.
 //
+// The children of lazy nodes (see TreeNode.SetLazy()) are loaded by the loader
+// set with SetLoader() when the node is expanded for the first time. While
+// they are loaded, a spinner is shown in their place. If loading fails, the
+// error is shown instead and selecting it loads the children again.
+//
 // If graphics are turned on (see SetGraphics()), lines indicate the tree's
 // hierarchy. Alternative (or additionally), you can set different prefixes
 // using SetPrefixes() for different levels, for example to display hierarchical
@@ -367,6 +400,64 @@ type Tree struct {
 	// The visible nodes, top-down, as set by process().
 	nodes []*TreeNode
 
+	// Whether the user may filter the nodes.
+	filterable bool
+
+	// Whether the nodes are being filtered.
+	filtering bool
+
+	// The filter query.
+	query []rune
+
+	// The node selected before filtering.
+	unfilteredNode *TreeNode
+
+	// The positions of the matched characters of the matching nodes, and the
+	// nodes shown while filtering: matching nodes and their ancestors. Both are
+	// nil if all nodes are shown.
+	matches map[*TreeNode][]int
+	shown   map[*TreeNode]bool
+
+	// The application through which loaded children are applied and an
+	// optional function which loads the children of lazy nodes.
+	app    *App
+	loader func(node *TreeNode) ([]*TreeNode, error)
+
+	// The spinner shown while children are loaded, and the number of nodes
+	// whose children are being loaded.
+	spinner *Spinner
+	loads   int
+
+	// The rows shown in place of the children of nodes which are being loaded
+	// or failed to load.
+	placeholders map[*TreeNode]*TreeNode
+
+	// Whether the user may select several nodes.
+	multiSelect bool
+
+	// The nodes selected by the user if multi-selection is enabled.
+	selection map[*TreeNode]bool
+
+	// The node at which ranges selected with the Shift key start, and the
+	// selection before the range was selected.
+	anchor         *TreeNode
+	rangeSelection map[*TreeNode]bool
+
+	// An optional function which is called when the user has changed the
+	// selected nodes.
+	selectionChanged func(nodes []*TreeNode)
+
+	// Whether the user may drag nodes with the mouse.
+	draggable bool
+
+	// Optional functions which decide whether a payload may be dropped onto a
+	// node and drop it. Without them, nodes dragged from this tree are moved.
+	acceptDrop func(payload *DragPayload, node *TreeNode) bool
+	drop       func(payload *DragPayload, node *TreeNode)
+
+	// The node onto which a dragged payload would be dropped.
+	dropNode *TreeNode
+
 	mu sync.RWMutex
 }
 
@@ -378,6 +469,7 @@ func NewTreeView() *Tree {
 		graphics:            true,
 		graphicsColor:       Styles.GraphicsColor,
 		scrollBarColor:      Styles.ScrollBarColor,
+		spinner:             NewSpinner(),
 	}
 }
 
@@ -805,6 +897,614 @@ func (t *Tree) GetRowCount() int {
 	return len(t.nodes)
 }
 
+// SetFilterable sets whether the user may filter the nodes. If enabled,
+// pressing one of the Keys.Filter shortcuts ("/" by default) opens a query line
+// at the bottom of the tree. Only nodes whose text fuzzy-matches the query and
+// their ancestors are shown, expanded, with the matched characters highlighted.
+// The best match is selected. Enter ends filtering, expands the ancestors of
+// the current node and selects it, Escape ends filtering and restores the
+// previous selection.
+func (t *Tree) SetFilterable(filterable bool) *Tree {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.filterable = filterable
+	if !filterable {
+		t.endFilter(true)
+	}
+	return t
+}
+
+// SetFilter filters the nodes with the given query as if the user had typed
+// it, see SetFilterable().
+func (t *Tree) SetFilter(query string) *Tree {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.filtering {
+		t.startFilter()
+	}
+	t.query = []rune(query)
+	t.applyFilter()
+	return t
+}
+
+// GetFilter returns the filter query and whether the nodes are being filtered.
+func (t *Tree) GetFilter() (query string, filtering bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return string(t.query), t.filtering
+}
+
+// ClearFilter ends filtering and shows all nodes. The current node remains
+// selected and its ancestors are expanded.
+func (t *Tree) ClearFilter() *Tree {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.endFilter(true)
+	return t
+}
+
+// startFilter starts filtering the nodes.
+func (t *Tree) startFilter() {
+	t.filtering = true
+	t.query = nil
+	t.unfilteredNode = t.currentNode
+	t.applyFilter()
+}
+
+// applyFilter determines the nodes matching the query and selects the best
+// match.
+func (t *Tree) applyFilter() {
+	t.matches, t.shown = nil, nil
+	if len(t.query) == 0 || t.root == nil {
+		t.currentNode = t.unfilteredNode
+		return
+	}
+
+	t.matches = make(map[*TreeNode][]int)
+	t.shown = make(map[*TreeNode]bool)
+	query := string(t.query)
+	var (
+		best      *TreeNode
+		bestScore int
+	)
+	t.root.walk(func(node, parent *TreeNode) bool {
+		node.parent = parent
+		score, positions, ok := FuzzyMatch(query, string(StripTags([]byte(node.text), true, false)))
+		if !ok {
+			return true
+		}
+		t.matches[node] = positions
+		for shown := node; shown != nil && !t.shown[shown]; shown = shown.parent {
+			t.shown[shown] = true
+		}
+		if node.selectable && (best == nil || score > bestScore) {
+			best, bestScore = node, score
+		}
+		return true
+	})
+	if best != nil {
+		t.currentNode = best
+	}
+}
+
+// endFilter ends filtering and shows all nodes. If "keep" is true, the current
+// node remains selected and its ancestors are expanded. Otherwise, the
+// selection before filtering is restored.
+func (t *Tree) endFilter(keep bool) {
+	if !t.filtering {
+		return
+	}
+	if keep && t.currentNode != nil {
+		for ancestor := t.currentNode.parent; ancestor != nil; ancestor = ancestor.parent {
+			ancestor.expanded = true
+		}
+	} else {
+		t.currentNode = t.unfilteredNode
+	}
+	t.filtering, t.query, t.unfilteredNode = false, nil, nil
+	t.matches, t.shown = nil, nil
+}
+
+// SetMultiSelect sets whether the user may select several nodes. If enabled,
+// the following keys change the selected nodes:
+//
+//   - Space: Toggle the selection of the current node
+//   - Shift+Up, Shift+Down: Select the visible nodes between the current node
+//     and the node last toggled or moved to
+//   - Ctrl+A: Select all visible nodes
+//
+// Clicking a node with the Ctrl key pressed toggles its selection, clicking it
+// with the Shift key pressed selects a range of nodes. Disabling
+// multi-selection clears the selected nodes.
+func (t *Tree) SetMultiSelect(multiSelect bool) *Tree {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.multiSelect = multiSelect
+	if !multiSelect {
+		t.selection, t.anchor, t.rangeSelection = nil, nil, nil
+	}
+	return t
+}
+
+// SetNodeSelected sets whether the given node is selected.
+func (t *Tree) SetNodeSelected(node *TreeNode, selected bool) *Tree {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.selectNode(node, selected)
+	t.anchor, t.rangeSelection = node, nil
+	return t
+}
+
+// IsNodeSelected returns whether the given node is selected.
+func (t *Tree) IsNodeSelected(node *TreeNode) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.selection[node]
+}
+
+// GetSelectedNodes returns the selected nodes in the order of the tree,
+// including selected nodes which are collapsed or filtered out.
+func (t *Tree) GetSelectedNodes() []*TreeNode {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.selectedNodes()
+}
+
+// ClearSelection deselects all nodes.
+func (t *Tree) ClearSelection() *Tree {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.selection, t.anchor, t.rangeSelection = nil, nil, nil
+	return t
+}
+
+// SetSelectedNodesChangedFunc sets a function which is called when the user
+// changes the selected nodes (see SetMultiSelect()). The function receives the
+// selected nodes in the order of the tree.
+func (t *Tree) SetSelectedNodesChangedFunc(handler func(nodes []*TreeNode)) *Tree {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.selectionChanged = handler
+	return t
+}
+
+// selectedNodes returns the selected nodes in the order of the tree.
+func (t *Tree) selectedNodes() (nodes []*TreeNode) {
+	if t.root == nil || len(t.selection) == 0 {
+		return nil
+	}
+	t.root.walk(func(node, parent *TreeNode) bool {
+		if t.selection[node] {
+			nodes = append(nodes, node)
+		}
+		return true
+	})
+	return
+}
+
+// selectNode selects or deselects a node.
+func (t *Tree) selectNode(node *TreeNode, selected bool) {
+	if !selected {
+		delete(t.selection, node)
+		return
+	}
+	if t.selection == nil {
+		t.selection = make(map[*TreeNode]bool)
+	}
+	t.selection[node] = true
+}
+
+// multiSelectable returns whether a node may be selected with
+// multi-selection.
+func (t *Tree) multiSelectable(node *TreeNode) bool {
+	return node != nil && node.selectable && !t.isPlaceholder(node)
+}
+
+// selectRange selects the visible nodes between the anchor and the current
+// node in addition to the nodes selected before the range.
+func (t *Tree) selectRange() {
+	if t.rangeSelection == nil {
+		t.rangeSelection = make(map[*TreeNode]bool)
+		for node := range t.selection {
+			t.rangeSelection[node] = true
+		}
+	}
+	from, to := -1, -1
+	for index, node := range t.nodes {
+		if node == t.anchor {
+			from = index
+		}
+		if node == t.currentNode {
+			to = index
+		}
+	}
+	if from < 0 {
+		from = to
+	}
+	if from > to {
+		from, to = to, from
+	}
+
+	t.selection = make(map[*TreeNode]bool)
+	for node := range t.rangeSelection {
+		t.selection[node] = true
+	}
+	for index := from; index >= 0 && index <= to; index++ {
+		if node := t.nodes[index]; t.multiSelectable(node) {
+			t.selection[node] = true
+		}
+	}
+}
+
+// notifySelection calls the handler of changed selections. Must be called
+// with the tree locked.
+func (t *Tree) notifySelection() {
+	if t.selectionChanged == nil {
+		return
+	}
+	nodes := t.selectedNodes()
+	t.mu.Unlock()
+	t.selectionChanged(nodes)
+	t.mu.Lock()
+}
+
+// handleMultiSelect changes the selected nodes according to the key event and
+// returns whether the event was handled. Must be called with the tree locked.
+func (t *Tree) handleMultiSelect(event *tcell.EventKey) bool {
+	switch {
+	case HitShortcut(event, Keys.SelectToggle):
+		if node := t.currentNode; t.multiSelectable(node) {
+			t.selectNode(node, !t.selection[node])
+			t.anchor, t.rangeSelection = node, nil
+			t.notifySelection()
+		}
+	case HitShortcut(event, Keys.SelectAll):
+		for _, node := range t.nodes {
+			if t.multiSelectable(node) {
+				t.selectNode(node, true)
+			}
+		}
+		t.rangeSelection = nil
+		t.notifySelection()
+	case HitShortcut(event, Keys.SelectUp, Keys.SelectDown):
+		if t.anchor == nil {
+			t.anchor = t.currentNode
+		}
+		if HitShortcut(event, Keys.SelectUp) {
+			t.movement = treeUp
+		} else {
+			t.movement = treeDown
+		}
+		t.process()
+		t.selectRange()
+		t.notifySelection()
+	default:
+		return false
+	}
+	return true
+}
+
+// SetDraggable sets whether the user may drag nodes with the mouse. The
+// payload holds the dragged node, or the selected nodes if the dragged node is
+// one of them (see SetMultiSelect()). The root node cannot be dragged. Unless
+// a drop function is set with SetDropFunc(), dropping the nodes onto another
+// node moves them to the end of its children.
+func (t *Tree) SetDraggable(draggable bool) *Tree {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.draggable = draggable
+	return t
+}
+
+// SetDropFunc sets the functions which decide whether a payload dragged with
+// the mouse may be dropped onto the given node and drop it. Without them, the
+// tree accepts the nodes dragged from itself and moves them.
+func (t *Tree) SetDropFunc(accept func(payload *DragPayload, node *TreeNode) bool, drop func(payload *DragPayload, node *TreeNode)) *Tree {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.acceptDrop, t.drop = accept, drop
+	return t
+}
+
+// nodeAt returns the node shown at the given screen position, or nil.
+func (t *Tree) nodeAt(x, y int) *TreeNode {
+	if !t.InRect(x, y) {
+		return nil
+	}
+	_, rectY, _, height := t.GetInnerRect()
+	if t.filtering {
+		height--
+	}
+	row := y - rectY
+	if row < 0 || row >= height || t.offsetY+row >= len(t.nodes) {
+		return nil
+	}
+	return t.nodes[t.offsetY+row]
+}
+
+// DragStart returns the payload of the node at the given screen position. It
+// implements DragSource.
+func (t *Tree) DragStart(x, y int) *DragPayload {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.draggable || t.filtering {
+		return nil
+	}
+	node := t.nodeAt(x, y)
+	if !t.multiSelectable(node) || node.parent == nil {
+		return nil
+	}
+	nodes := []*TreeNode{node}
+	if t.selection[node] {
+		nodes = nil
+		for _, selected := range t.selectedNodes() {
+			if selected.parent != nil {
+				nodes = append(nodes, selected)
+			}
+		}
+	}
+	label := string(StripTags([]byte(node.text), true, false))
+	if len(nodes) > 1 {
+		label = fmt.Sprintf("%d nodes", len(nodes))
+	}
+	return &DragPayload{Kind: DragKindTreeNodes, Data: nodes, Label: label}
+}
+
+// DragEnd is called when dragging nodes of the tree has ended. It implements
+// DragSource.
+func (t *Tree) DragEnd(payload *DragPayload, dropped bool) {}
+
+// acceptsDrop returns whether the payload may be dropped onto the node. Must
+// be called with the tree locked.
+func (t *Tree) acceptsDrop(payload *DragPayload, node *TreeNode) bool {
+	if node == nil || t.isPlaceholder(node) {
+		return false
+	}
+	if accept := t.acceptDrop; accept != nil {
+		t.mu.Unlock()
+		defer t.mu.Lock()
+		return accept(payload, node)
+	}
+	nodes, ok := payload.Data.([]*TreeNode)
+	if payload.Source != t || payload.Kind != DragKindTreeNodes || !ok {
+		return false
+	}
+
+	// Nodes cannot be moved into themselves.
+	for ancestor := node; ancestor != nil; ancestor = ancestor.parent {
+		for _, dragged := range nodes {
+			if dragged == ancestor {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// DragOver shows the node onto which the payload would be dropped. It
+// implements DropTarget.
+func (t *Tree) DragOver(payload *DragPayload, x, y int) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.dropNode = nil
+	if node := t.nodeAt(x, y); !t.filtering && t.acceptsDrop(payload, node) {
+		t.dropNode = node
+	}
+	return t.dropNode != nil
+}
+
+// DragLeave removes what was shown by DragOver(). It implements DropTarget.
+func (t *Tree) DragLeave() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.dropNode = nil
+}
+
+// Drop drops the payload onto the node at the given screen position. It
+// implements DropTarget.
+func (t *Tree) Drop(payload *DragPayload, x, y int) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	node := t.nodeAt(x, y)
+	if t.filtering || !t.acceptsDrop(payload, node) {
+		return false
+	}
+	if drop := t.drop; drop != nil {
+		t.mu.Unlock()
+		drop(payload, node)
+		t.mu.Lock()
+	} else if nodes, ok := payload.Data.([]*TreeNode); ok && t.acceptDrop == nil {
+		for _, dragged := range nodes {
+			t.moveNode(dragged, node)
+		}
+		node.mu.Lock()
+		node.expanded = true
+		node.mu.Unlock()
+	}
+	return true
+}
+
+// moveNode moves a node to the end of the children of another node.
+func (t *Tree) moveNode(node, parent *TreeNode) {
+	if previous := node.parent; previous != nil {
+		previous.mu.Lock()
+		for index, child := range previous.children {
+			if child == node {
+				previous.children = append(previous.children[:index:index], previous.children[index+1:]...)
+				break
+			}
+		}
+		previous.mu.Unlock()
+	}
+	parent.mu.Lock()
+	parent.children = append(parent.children, node)
+	parent.mu.Unlock()
+	node.parent = parent
+}
+
+// SetLoader sets the function which loads the children of lazy nodes (see
+// TreeNode.SetLazy()) when they are expanded for the first time. The function
+// is called on its own goroutine. Its results are applied in the event loop of
+// the given application, which is then redrawn. If the application is nil, the
+// results are applied directly and shown the next time the tree is drawn.
+func (t *Tree) SetLoader(app *App, loader func(node *TreeNode) ([]*TreeNode, error)) *Tree {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.app = app
+	t.loader = loader
+	return t
+}
+
+// SetLoadingSpinnerStyle sets the style of the spinner shown while the
+// children of lazy nodes are loaded.
+func (t *Tree) SetLoadingSpinnerStyle(style SpinnerStyle) *Tree {
+	t.spinner.SetStyle(style)
+	return t
+}
+
+// Reload discards the children of a lazy node. They are loaded again when the
+// node is expanded, or right away if it is already expanded.
+func (t *Tree) Reload(node *TreeNode) *Tree {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.reload(node)
+	return t
+}
+
+// reload discards the children of a lazy node.
+func (t *Tree) reload(node *TreeNode) {
+	if !node.lazy || node.loading {
+		return
+	}
+	if placeholder := t.placeholders[node]; placeholder != nil && t.currentNode == placeholder {
+		t.currentNode = node
+	}
+	node.mu.Lock()
+	node.children = nil
+	node.mu.Unlock()
+	node.loaded, node.loadErr = false, nil
+}
+
+// load loads the children of a lazy node on a separate goroutine.
+func (t *Tree) load(node *TreeNode) {
+	node.loading, node.loadErr = true, nil
+	t.loads++
+	if t.loads == 1 {
+		go t.animate()
+	}
+
+	app, loader := t.app, t.loader
+	go func() {
+		children, err := loader(node)
+		finish := func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+
+			t.finishLoad(node, children, err)
+		}
+		if app != nil {
+			app.QueueUpdateDraw(finish)
+		} else {
+			finish()
+		}
+	}()
+}
+
+// finishLoad applies the result of loading the children of a lazy node.
+func (t *Tree) finishLoad(node *TreeNode, children []*TreeNode, err error) {
+	node.loading = false
+	t.loads--
+	if err != nil {
+		node.loadErr = err
+		return
+	}
+
+	node.mu.Lock()
+	node.children = children
+	node.mu.Unlock()
+	node.loaded = true
+	if placeholder := t.placeholders[node]; placeholder != nil && t.currentNode == placeholder {
+		t.currentNode = node
+	}
+	delete(t.placeholders, node)
+}
+
+// animate advances the loading spinner until all children are loaded.
+func (t *Tree) animate() {
+	ticker := time.NewTicker(treeSpinnerFrame)
+	defer ticker.Stop()
+	for range ticker.C {
+		t.mu.RLock()
+		loads, app := t.loads, t.app
+		t.mu.RUnlock()
+		if loads == 0 {
+			return
+		}
+		t.spinner.Pulse()
+		if app != nil {
+			app.QueueUpdateDraw(func() {})
+		}
+	}
+}
+
+// placeholder returns the row shown in place of the children of a node which
+// are being loaded or failed to load.
+func (t *Tree) placeholder(node *TreeNode) *TreeNode {
+	if t.placeholders == nil {
+		t.placeholders = make(map[*TreeNode]*TreeNode)
+	}
+	placeholder := t.placeholders[node]
+	if placeholder == nil {
+		placeholder = NewTreeNode("")
+		t.placeholders[node] = placeholder
+	}
+	if node.loading {
+		var frame string
+		t.spinner.get(func(s *Spinner) { frame = s.frame() })
+		placeholder.text = frame + " Loading"
+		placeholder.color = Styles.ContrastSecondaryTextColor
+		placeholder.selectable = false
+	} else {
+		placeholder.text = Escape(node.loadErr.Error()) + " (select to retry)"
+		placeholder.color = Styles.TreeLoadErrorColor
+		placeholder.selectable = true
+	}
+	return placeholder
+}
+
+// isPlaceholder returns whether the node is shown in place of the children of
+// its parent.
+func (t *Tree) isPlaceholder(node *TreeNode) bool {
+	return node.parent != nil && t.placeholders[node.parent] == node
+}
+
+// nodeCount returns the number of nodes in the tree.
+func (t *Tree) nodeCount() (count int) {
+	t.root.walk(func(node, parent *TreeNode) bool {
+		count++
+		return true
+	})
+	return
+}
+
 // Transform modifies the current selection.
 func (t *Tree) Transform(tr Transformation) {
 	t.mu.Lock()
@@ -832,6 +1532,9 @@ func (t *Tree) Transform(tr Transformation) {
 // pending selection actions.
 func (t *Tree) process() {
 	_, _, _, height := t.GetInnerRect()
+	if t.filtering {
+		height--
+	}
 
 	// Determine visible nodes and their placement.
 	var graphicsOffset, maxTextX int
@@ -841,7 +1544,7 @@ func (t *Tree) process() {
 	if t.graphics {
 		graphicsOffset = 1
 	}
-	t.root.walk(func(node, parent *TreeNode) bool {
+	place := func(node, parent *TreeNode) {
 		// Set node attributes.
 		node.parent = parent
 		if parent == nil {
@@ -880,9 +1583,26 @@ func (t *Tree) process() {
 
 			t.nodes = append(t.nodes, node)
 		}
+	}
+	t.root.walk(func(node, parent *TreeNode) bool {
+		// Skip nodes which don't match the filter.
+		if t.shown != nil && !t.shown[node] {
+			return false
+		}
+		place(node, parent)
 
-		// Recurse if desired.
-		return node.expanded
+		// Load the children of lazy nodes when they are first expanded.
+		if node.expanded && node.lazy && t.loader != nil && t.shown == nil {
+			if !node.loaded && !node.loading && node.loadErr == nil {
+				t.load(node)
+			}
+			if node.loading || node.loadErr != nil {
+				place(t.placeholder(node), node)
+			}
+		}
+
+		// Recurse if desired. Ancestors of matching nodes are always expanded.
+		return node.expanded || t.shown != nil
 	})
 
 	// Post-process positions.
@@ -1019,6 +1739,15 @@ func (t *Tree) Draw(screen tcell.Screen) {
 
 	// Scroll the tree.
 	x, y, width, height := t.GetInnerRect()
+	if t.filtering && height > 0 {
+		height--
+		total := t.nodeCount()
+		count := total
+		if t.matches != nil {
+			count = len(t.matches)
+		}
+		drawFilterQuery(screen, x, y+height, width, t.query, count, total, t.box.hasFocus)
+	}
 	switch t.movement {
 	case treeUp:
 		t.offsetY--
@@ -1117,8 +1846,19 @@ func (t *Tree) Draw(screen tcell.Screen) {
 						backgroundColor = *t.selectedBackgroundColor
 					}
 					style = tcell.StyleDefault.Background(backgroundColor).Foreground(foregroundColor)
+				} else if t.selection[node] {
+					style = style.Background(Styles.MultiSelectBackgroundColor)
+				}
+				text := []byte(node.text)
+				if positions := t.matches[node]; len(positions) > 0 {
+					text = highlightMatches(StripTags(text, true, false), positions)
 				}
-				PrintStyle(screen, []byte(node.text), x+node.textX+prefixWidth, posY, width-node.textX-prefixWidth, AlignLeft, style)
+				PrintStyle(screen, text, x+node.textX+prefixWidth, posY, width-node.textX-prefixWidth, AlignLeft, style)
+			}
+
+			// Show the node onto which a dragged payload would be dropped.
+			if node == t.dropNode {
+				dropFeedback(screen, x+node.textX, posY, width-node.textX)
 			}
 		}
 
@@ -1155,8 +1895,43 @@ func (t *Tree) InputHandler() func(event *tcell.EventKey, setFocus func(p Widget
 		t.mu.Lock()
 		defer t.mu.Unlock()
 
+		// Edit the filter query.
+		if t.filtering {
+			previousNode := t.currentNode
+
+			action := handleFilterKey(event, &t.query)
+			switch action {
+			case filterChanged:
+				t.applyFilter()
+			case filterCancel:
+				t.endFilter(false)
+			case filterAccept:
+				t.endFilter(true)
+			}
+
+			if action == filterChanged || action == filterCancel {
+				if t.currentNode != previousNode && t.currentNode != nil && t.changed != nil {
+					t.mu.Unlock()
+					t.changed(t.currentNode)
+					t.mu.Lock()
+				}
+				t.process()
+				return
+			}
+		} else if t.filterable && HitShortcut(event, Keys.Filter) {
+			t.startFilter()
+			t.process()
+			return
+		}
+
+		// Change the selected nodes.
+		if t.multiSelect && t.handleMultiSelect(event) {
+			return
+		}
+
 		// Because the tree is flattened into a list only at drawing time, we also
 		// postpone the (selection) movement to drawing time.
+		previousNode := t.currentNode
 		if HitShortcut(event, Keys.Cancel, Keys.MovePreviousField, Keys.MoveNextField) {
 			if t.done != nil {
 				t.mu.Unlock()
@@ -1176,12 +1951,22 @@ func (t *Tree) InputHandler() func(event *tcell.EventKey, setFocus func(p Widget
 		} else if HitShortcut(event, Keys.MoveNextPage) {
 			t.movement = treePageDown
 		} else if HitShortcut(event, Keys.Select, Keys.Select2) {
-			t.mu.Unlock()
-			selectNode()
-			t.mu.Lock()
+			if t.currentNode != nil && t.isPlaceholder(t.currentNode) {
+				// Retry loading the children.
+				t.reload(t.currentNode.parent)
+			} else {
+				t.mu.Unlock()
+				selectNode()
+				t.mu.Lock()
+			}
 		}
 
 		t.process()
+
+		// Ranges are selected from the node moved to last.
+		if t.currentNode != previousNode {
+			t.anchor, t.rangeSelection = t.currentNode, nil
+		}
 	})
 }
 
@@ -1193,13 +1978,53 @@ func (t *Tree) MouseHandler() func(action MouseAction, event *tcell.EventMouse,
 			return false, nil
 		}
 
+		// Report the tree as drag source or drop target.
+		if action == MouseDragStart || action == MouseDragOver {
+			t.mu.RLock()
+			defer t.mu.RUnlock()
+			if t.draggable || action == MouseDragOver && t.acceptDrop != nil {
+				return true, t
+			}
+			return false, nil
+		}
+
 		switch action {
 		case MouseLeftClick:
 			_, rectY, _, _ := t.GetInnerRect()
 			y -= rectY
 			if y >= 0 && y < len(t.nodes) {
 				node := t.nodes[y]
-				if node.selectable {
+				t.mu.Lock()
+				previousNode, selectNode := t.currentNode, node.selectable
+				switch {
+				case t.isPlaceholder(node):
+					// Retry loading the children.
+					t.reload(node.parent)
+					selectNode = false
+				case selectNode && t.multiSelect && event.Modifiers()&(tcell.ModShift|tcell.ModCtrl) != 0:
+					// Select a range or toggle the node.
+					t.currentNode = node
+					if event.Modifiers()&tcell.ModShift != 0 {
+						if t.anchor == nil {
+							t.anchor = previousNode
+						}
+						t.selectRange()
+					} else {
+						t.selectNode(node, !t.selection[node])
+						t.anchor, t.rangeSelection = node, nil
+					}
+					t.notifySelection()
+					if previousNode != node && t.changed != nil {
+						t.mu.Unlock()
+						t.changed(node)
+						t.mu.Lock()
+					}
+					selectNode = false
+				case selectNode:
+					t.anchor, t.rangeSelection = node, nil
+				}
+				t.mu.Unlock()
+				if selectNode {
 					if t.currentNode != node && t.changed != nil {
 						t.changed(node)
 					}
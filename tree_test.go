@@ -1,7 +1,12 @@
 package cui
 
 import (
+	"errors"
+	"fmt"
 	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
 )
 
 const (
@@ -63,3 +68,192 @@ func TestTreeView(t *testing.T) {
 		t.Errorf("failed to initialize Tree: incorrect row count: expected 1, got %d", tr.GetRowCount())
 	}
 }
+
+func TestTreeFilter(t *testing.T) {
+	t.Parallel()
+
+	root := NewTreeNode("root")
+	src := NewTreeNode("src").SetExpanded(false)
+	main := NewTreeNode("main.go")
+	src.AddChild(NewTreeNode("util.go")).AddChild(main)
+	docs := NewTreeNode("docs").AddChild(NewTreeNode("readme.md"))
+	root.AddChild(src).AddChild(docs)
+
+	tr := NewTreeView().SetRoot(root).SetCurrentNode(docs).SetFilterable(true)
+	tr.SetRect(0, 0, 20, 10)
+	handler := tr.InputHandler()
+	press := func(key tcell.Key, r rune) {
+		handler(tcell.NewEventKey(key, r, tcell.ModNone), func(p Widget) {})
+	}
+
+	// Matching nodes are shown with their ancestors, even if collapsed
+	press(tcell.KeyRune, '/')
+	for _, r := range "mgo" {
+		press(tcell.KeyRune, r)
+	}
+	if rows := tr.GetRowCount(); rows != 3 {
+		t.Errorf("failed to filter nodes: expected 3 rows, got %d", rows)
+	}
+	if node := tr.GetCurrentNode(); node != main {
+		t.Errorf("failed to select match: expected main.go, got %s", node.GetText())
+	}
+	if src.IsExpanded() {
+		t.Errorf("failed to keep node state: expected collapsed node")
+	}
+
+	// Escape restores the tree and the previous selection
+	press(tcell.KeyEscape, 0)
+	if rows := tr.GetRowCount(); rows != 4 {
+		t.Errorf("failed to restore nodes: expected 4 rows, got %d", rows)
+	}
+	if node := tr.GetCurrentNode(); node != docs {
+		t.Errorf("failed to restore selection: expected docs, got %s", node.GetText())
+	}
+
+	// Enter keeps the match and expands its ancestors
+	var selected *TreeNode
+	tr.SetSelectedFunc(func(node *TreeNode) { selected = node })
+	tr.SetFilter("main")
+	press(tcell.KeyEnter, 0)
+	if selected != main {
+		t.Errorf("failed to select match: expected main.go, got %v", selected)
+	}
+	if !src.IsExpanded() {
+		t.Errorf("failed to expand ancestors: expected expanded node")
+	}
+	if rows := tr.GetRowCount(); rows != 6 {
+		t.Errorf("failed to show nodes: expected 6 rows, got %d", rows)
+	}
+}
+
+func TestTreeLoader(t *testing.T) {
+	t.Parallel()
+
+	root := NewTreeNode("root")
+	lazy := NewTreeNode("lazy").SetLazy(true).SetExpanded(false)
+	root.AddChild(lazy)
+
+	results := make(chan error)
+	tr := NewTreeView().SetRoot(root).SetCurrentNode(lazy).SetLoader(nil, func(node *TreeNode) ([]*TreeNode, error) {
+		if err := <-results; err != nil {
+			return nil, err
+		}
+		return []*TreeNode{NewTreeNode("a"), NewTreeNode("b")}, nil
+	})
+	tr.SetRect(0, 0, 40, 10)
+
+	screen := tcell.NewSimulationScreen("UTF-8")
+	if err := screen.Init(); err != nil {
+		t.Fatalf("failed to initialize screen: %s", err)
+	}
+	screen.SetSize(40, 10)
+	waitFor := func(rows int, text string) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			tr.Draw(screen)
+			if tr.GetRowCount() == rows && tr.nodes[2].GetText() == text {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("failed to load children: expected %d rows with %q, got %d rows", rows, text, tr.GetRowCount())
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	handler := tr.InputHandler()
+	press := func(key tcell.Key) {
+		handler(tcell.NewEventKey(key, 0, tcell.ModNone), func(p Widget) {})
+	}
+
+	// Expanding the node shows a placeholder until its children are loaded
+	tr.Draw(screen)
+	lazy.SetExpanded(true)
+	tr.Draw(screen)
+	if rows := tr.GetRowCount(); rows != 3 || tr.nodes[2].selectable {
+		t.Fatalf("failed to show loading placeholder: expected 3 rows, got %d", rows)
+	}
+
+	// Errors are shown in place of the children
+	results <- errors.New("unreachable")
+	waitFor(3, "unreachable (select to retry)")
+
+	// Selecting the error loads the children again
+	press(tcell.KeyDown)
+	if node := tr.GetCurrentNode(); node != tr.nodes[2] {
+		t.Fatalf("failed to move to error: expected placeholder, got %s", node.GetText())
+	}
+	press(tcell.KeyEnter)
+	if node := tr.GetCurrentNode(); node != lazy {
+		t.Errorf("failed to retry: expected lazy, got %s", node.GetText())
+	}
+	results <- nil
+	waitFor(4, "a")
+	if children := lazy.GetChildren(); len(children) != 2 {
+		t.Errorf("failed to set children: expected 2, got %d", len(children))
+	}
+
+	// Loaded children are kept when the node is collapsed
+	lazy.SetExpanded(false)
+	tr.Draw(screen)
+	lazy.SetExpanded(true)
+	tr.Draw(screen)
+	if rows := tr.GetRowCount(); rows != 4 {
+		t.Errorf("failed to keep children: expected 4 rows, got %d", rows)
+	}
+}
+
+func TestTreeMultiSelect(t *testing.T) {
+	t.Parallel()
+
+	root := NewTreeNode("root")
+	a, b, c := NewTreeNode("a"), NewTreeNode("b"), NewTreeNode("c")
+	hidden := NewTreeNode("hidden")
+	root.AddChild(a).AddChild(b.AddChild(hidden).SetExpanded(false)).AddChild(c)
+
+	tr := NewTreeView().SetRoot(root).SetCurrentNode(a).SetMultiSelect(true)
+	tr.SetRect(0, 0, 20, 10)
+
+	var selected []string
+	tr.SetSelectedNodesChangedFunc(func(nodes []*TreeNode) {
+		selected = selected[:0]
+		for _, node := range nodes {
+			selected = append(selected, node.GetText())
+		}
+	})
+	handler := tr.InputHandler()
+	press := func(key tcell.Key, r rune, mod tcell.ModMask) {
+		handler(tcell.NewEventKey(key, r, mod), func(p Widget) {})
+	}
+	press(tcell.KeyHome, 0, tcell.ModNone)
+	press(tcell.KeyDown, 0, tcell.ModNone)
+
+	// Shift+Down selects the visible nodes between the anchor and the current node
+	press(tcell.KeyDown, 0, tcell.ModShift)
+	press(tcell.KeyDown, 0, tcell.ModShift)
+	if got := fmt.Sprint(selected); got != "[a b c]" {
+		t.Errorf("failed to select range: expected [a b c], got %s", got)
+	}
+	if node := tr.GetCurrentNode(); node != c {
+		t.Errorf("failed to move with range: expected c, got %s", node.GetText())
+	}
+
+	// Space toggles the current node
+	press(tcell.KeyRune, ' ', tcell.ModNone)
+	if got := fmt.Sprint(selected); got != "[a b]" {
+		t.Errorf("failed to toggle node: expected [a b], got %s", got)
+	}
+
+	// Selected nodes stay selected when collapsed
+	tr.SetNodeSelected(hidden, true)
+	if nodes := tr.GetSelectedNodes(); len(nodes) != 3 || nodes[2] != hidden {
+		t.Errorf("failed to select collapsed node: expected 3 nodes, got %d", len(nodes))
+	}
+
+	// Ctrl+A selects all visible nodes
+	tr.ClearSelection()
+	press(tcell.KeyCtrlA, 0, tcell.ModCtrl)
+	if got := fmt.Sprint(selected); got != "[root a b c]" {
+		t.Errorf("failed to select all: expected [root a b c], got %s", got)
+	}
+}
@@ -0,0 +1,446 @@
+package cui
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+	"golang.org/x/net/websocket"
+)
+
+// The default size of a browser terminal until the browser reports its size.
+const (
+	webDefaultWidth  = 80
+	webDefaultHeight = 24
+)
+
+// The largest size of a terminal reported by a remote client. Larger sizes
+// are clamped so that clients cannot make the server allocate huge screens.
+const (
+	maxRemoteWidth  = 1000
+	maxRemoteHeight = 1000
+)
+
+// clampRemoteSize limits a terminal size reported by a remote client.
+func clampRemoteSize(width, height int) (int, int) {
+	return min(width, maxRemoteWidth), min(height, maxRemoteHeight)
+}
+
+//go:embed web.html
+var webPage []byte
+
+// ServeWeb returns an HTTP handler which serves a browser terminal. Plain
+// requests receive a small HTML page which connects back to the same URL
+// using a WebSocket. For each WebSocket connection the handler function is
+// called and the returned application is run on a screen which is streamed
+// to the browser. Key, mouse, paste and resize events of the browser are
+// forwarded to the application. The application is stopped when the browser
+// disconnects and the connection is closed when the application stops.
+//
+// The returned handler may be used with any http.Server, including the HTTP
+// and WebSocket multiplexing of service.Server:
+//
+//	service.NewServer(service.Config{
+//	    TCP:  8080,
+//	    HTTP: &http.Server{Handler: cui.ServeWeb(func(r *http.Request) *cui.App {
+//	        return cui.New().SetRoot(cui.NewTextView().SetText("hello "+r.RemoteAddr), true)
+//	    })},
+//	})
+//
+// When service.Config.WS is set, WebSocket upgrades are routed to that server
+// instead, so it must use the same handler.
+func ServeWeb(handler func(r *http.Request) *App) http.Handler {
+	ws := websocket.Server{
+		Handshake: checkWebOrigin,
+		Handler: func(conn *websocket.Conn) {
+			defer conn.Close()
+			_ = RunWeb(conn, handler(conn.Request()))
+		},
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+			ws.ServeHTTP(w, r)
+			return
+		}
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(webPage)
+	})
+}
+
+// checkWebOrigin rejects WebSocket connections initiated by pages of other
+// origins.
+func checkWebOrigin(config *websocket.Config, r *http.Request) error {
+	origin, err := websocket.Origin(config, r)
+	if err != nil {
+		return err
+	}
+	if origin != nil && origin.Host != r.Host {
+		return errors.New("cross-origin websocket connection")
+	}
+	return nil
+}
+
+// RunWeb runs the application on a screen which is streamed over the provided
+// WebSocket connection. The initial screen size is taken from the "cols" and
+// "rows" query parameters of the connection's request. Sizes are limited to
+// 1000x1000 cells. It returns when the application is stopped or the
+// connection is closed. A nil application is ignored.
+func RunWeb(conn *websocket.Conn, app *App) error {
+	if app == nil {
+		return nil
+	}
+	width, height := webDefaultWidth, webDefaultHeight
+	if r := conn.Request(); r != nil {
+		if w, err := strconv.Atoi(r.URL.Query().Get("cols")); err == nil && w > 0 {
+			width = w
+		}
+		if h, err := strconv.Atoi(r.URL.Query().Get("rows")); err == nil && h > 0 {
+			height = h
+		}
+		width, height = clampRemoteSize(width, height)
+	}
+
+	screen := &webScreen{
+		SimulationScreen: tcell.NewSimulationScreen("UTF-8"),
+		changed:          make(chan struct{}, 1),
+	}
+	if err := screen.Init(); err != nil {
+		return err
+	}
+	screen.SetSize(width, height)
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		screen.receive(conn)
+	}()
+	go screen.send(conn, closed)
+	return runScreen(app, screen, closed)
+}
+
+// webMessage is a message exchanged with the browser terminal.
+type webMessage struct {
+	Type string `json:"type"`
+
+	// Frames sent to the browser.
+	Width  int            `json:"w,omitempty"`
+	Height int            `json:"h,omitempty"`
+	Cursor []int          `json:"cursor,omitempty"`
+	Styles [][3]string    `json:"styles,omitempty"`
+	Rows   [][]webSegment `json:"rows,omitempty"`
+
+	// Events received from the browser.
+	Key     string `json:"key,omitempty"`
+	Text    string `json:"text,omitempty"`
+	X       int    `json:"x,omitempty"`
+	Y       int    `json:"y,omitempty"`
+	Buttons int    `json:"buttons,omitempty"`
+	Wheel   int    `json:"wheel,omitempty"`
+	Shift   bool   `json:"shift,omitempty"`
+	Ctrl    bool   `json:"ctrl,omitempty"`
+	Alt     bool   `json:"alt,omitempty"`
+	Meta    bool   `json:"meta,omitempty"`
+}
+
+// webSegment is a run of text of a row drawn in one style. It is encoded as
+// a [text, style index] pair.
+type webSegment [2]interface{}
+
+// webScreen is a simulation screen which notifies about changes whenever it
+// is shown.
+type webScreen struct {
+	tcell.SimulationScreen
+
+	// The contents of the simulation screen are not copied and must not be
+	// read while the screen is updated.
+	mu      sync.Mutex
+	fini    bool
+	changed chan struct{}
+}
+
+// Fini finalizes the screen. No more frames are sent afterwards.
+func (s *webScreen) Fini() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fini = true
+	s.SimulationScreen.Fini()
+}
+
+// Show shows the screen and schedules a frame to be sent to the browser.
+func (s *webScreen) Show() {
+	s.mu.Lock()
+	s.SimulationScreen.Show()
+	s.mu.Unlock()
+	s.notify()
+}
+
+// Sync syncs the screen and schedules a frame to be sent to the browser.
+func (s *webScreen) Sync() {
+	s.mu.Lock()
+	s.SimulationScreen.Sync()
+	s.mu.Unlock()
+	s.notify()
+}
+
+func (s *webScreen) notify() {
+	select {
+	case s.changed <- struct{}{}:
+	default:
+	}
+}
+
+// send sends a frame to the browser whenever the screen changed until the
+// connection is closed.
+func (s *webScreen) send(conn *websocket.Conn, closed <-chan struct{}) {
+	var last string
+	for {
+		select {
+		case <-s.changed:
+		case <-closed:
+			return
+		}
+		s.mu.Lock()
+		if s.fini {
+			s.mu.Unlock()
+			return
+		}
+		frame, err := json.Marshal(s.frame())
+		s.mu.Unlock()
+		if err != nil || string(frame) == last {
+			continue
+		}
+		last = string(frame)
+		if err := websocket.Message.Send(conn, last); err != nil {
+			conn.Close()
+			return
+		}
+	}
+}
+
+// frame returns the current contents of the screen.
+func (s *webScreen) frame() *webMessage {
+	cells, width, height := s.GetContents()
+	frame := &webMessage{Type: "frame", Width: width, Height: height, Rows: make([][]webSegment, height)}
+	if x, y, visible := s.GetCursor(); visible {
+		frame.Cursor = []int{x, y}
+	}
+	styles := make(map[[3]string]int)
+	for y := 0; y < height; y++ {
+		var (
+			text  strings.Builder
+			style = -1
+		)
+		flush := func() {
+			if text.Len() > 0 {
+				frame.Rows[y] = append(frame.Rows[y], webSegment{text.String(), style})
+				text.Reset()
+			}
+		}
+		for x := 0; x < width; x++ {
+			cell := cells[y*width+x]
+			key := webStyle(cell.Style)
+			index, ok := styles[key]
+			if !ok {
+				index = len(frame.Styles)
+				styles[key] = index
+				frame.Styles = append(frame.Styles, key)
+			}
+			if index != style {
+				flush()
+				style = index
+			}
+			if len(cell.Runes) == 0 || cell.Runes[0] < ' ' {
+				text.WriteByte(' ')
+				continue
+			}
+			text.WriteString(string(cell.Runes))
+			if runewidth.RuneWidth(cell.Runes[0]) == 2 {
+				x++ // Wide runes cover the following cell.
+			}
+		}
+		flush()
+	}
+	return frame
+}
+
+// webStyle returns the foreground and background colors as CSS colors and
+// the space separated attribute names of a style.
+func webStyle(style tcell.Style) [3]string {
+	fg, bg, attr := style.Decompose()
+	var attrs []string
+	for _, a := range []struct {
+		mask tcell.AttrMask
+		name string
+	}{
+		{tcell.AttrBold, "bold"},
+		{tcell.AttrDim, "dim"},
+		{tcell.AttrItalic, "italic"},
+		{tcell.AttrUnderline, "underline"},
+		{tcell.AttrStrikeThrough, "strikethrough"},
+		{tcell.AttrBlink, "blink"},
+		{tcell.AttrReverse, "reverse"},
+	} {
+		if attr&a.mask != 0 {
+			attrs = append(attrs, a.name)
+		}
+	}
+	return [3]string{webColor(fg), webColor(bg), strings.Join(attrs, " ")}
+}
+
+// webColor returns the CSS color of a color or an empty string for the
+// default color.
+func webColor(c tcell.Color) string {
+	hex := c.Hex()
+	if hex < 0 {
+		return ""
+	}
+	return fmt.Sprintf("#%06x", hex)
+}
+
+// receive forwards the events of the browser to the screen until the
+// connection is closed.
+func (s *webScreen) receive(conn *websocket.Conn) {
+	for {
+		var data []byte
+		if err := websocket.Message.Receive(conn, &data); err != nil {
+			return
+		}
+		var msg webMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			continue // Ignore malformed messages.
+		}
+		s.handle(&msg)
+	}
+}
+
+// handle forwards a single browser event to the screen.
+func (s *webScreen) handle(msg *webMessage) {
+	var mod tcell.ModMask
+	if msg.Shift {
+		mod |= tcell.ModShift
+	}
+	if msg.Ctrl {
+		mod |= tcell.ModCtrl
+	}
+	if msg.Alt {
+		mod |= tcell.ModAlt
+	}
+	if msg.Meta {
+		mod |= tcell.ModMeta
+	}
+
+	switch msg.Type {
+	case "key":
+		if key, ch, ok := webKey(msg.Key, mod); ok {
+			s.InjectKey(key, ch, mod)
+		}
+	case "paste":
+		s.PostEventWait(tcell.NewEventPaste(true))
+		for _, r := range msg.Text {
+			switch r {
+			case '\r':
+			case '\n':
+				s.InjectKey(tcell.KeyEnter, '\r', tcell.ModNone)
+			default:
+				s.InjectKey(tcell.KeyRune, r, tcell.ModNone)
+			}
+		}
+		s.PostEventWait(tcell.NewEventPaste(false))
+	case "mouse":
+		var buttons tcell.ButtonMask
+		if msg.Buttons&1 != 0 {
+			buttons |= tcell.Button1
+		}
+		if msg.Buttons&2 != 0 {
+			buttons |= tcell.Button2
+		}
+		if msg.Buttons&4 != 0 {
+			buttons |= tcell.Button3
+		}
+		if msg.Wheel < 0 {
+			buttons |= tcell.WheelUp
+		} else if msg.Wheel > 0 {
+			buttons |= tcell.WheelDown
+		}
+		s.InjectMouse(msg.X, msg.Y, buttons, mod)
+	case "resize":
+		if msg.Width <= 0 || msg.Height <= 0 {
+			return
+		}
+		width, height := clampRemoteSize(msg.Width, msg.Height)
+		s.mu.Lock()
+		if w, h := s.Size(); s.fini || w == width && h == height {
+			s.mu.Unlock()
+			return
+		}
+		s.SetSize(width, height)
+		s.mu.Unlock()
+		s.PostEventWait(tcell.NewEventResize(width, height))
+	}
+}
+
+// webKeys maps the names of browser keys to keys.
+var webKeys = map[string]tcell.Key{
+	"Enter":      tcell.KeyEnter,
+	"Tab":        tcell.KeyTab,
+	"Backspace":  tcell.KeyBackspace2,
+	"Escape":     tcell.KeyEscape,
+	"ArrowUp":    tcell.KeyUp,
+	"ArrowDown":  tcell.KeyDown,
+	"ArrowLeft":  tcell.KeyLeft,
+	"ArrowRight": tcell.KeyRight,
+	"Home":       tcell.KeyHome,
+	"End":        tcell.KeyEnd,
+	"PageUp":     tcell.KeyPgUp,
+	"PageDown":   tcell.KeyPgDn,
+	"Insert":     tcell.KeyInsert,
+	"Delete":     tcell.KeyDelete,
+	"F1":         tcell.KeyF1,
+	"F2":         tcell.KeyF2,
+	"F3":         tcell.KeyF3,
+	"F4":         tcell.KeyF4,
+	"F5":         tcell.KeyF5,
+	"F6":         tcell.KeyF6,
+	"F7":         tcell.KeyF7,
+	"F8":         tcell.KeyF8,
+	"F9":         tcell.KeyF9,
+	"F10":        tcell.KeyF10,
+	"F11":        tcell.KeyF11,
+	"F12":        tcell.KeyF12,
+}
+
+// webKey returns the key and rune of a browser key name. Control characters
+// are returned for letters typed with the control modifier.
+func webKey(name string, mod tcell.ModMask) (tcell.Key, rune, bool) {
+	if key, ok := webKeys[name]; ok {
+		if key == tcell.KeyTab && mod&tcell.ModShift != 0 {
+			return tcell.KeyBacktab, 0, true
+		}
+		return key, rune(key), true
+	}
+	r, size := utf8.DecodeRuneInString(name)
+	if r == utf8.RuneError || size != len(name) {
+		return 0, 0, false
+	}
+	if mod&tcell.ModCtrl != 0 {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return tcell.KeyCtrlA + tcell.Key(r-'a'), r - 'a' + 1, true
+		case r >= 'A' && r <= 'Z':
+			return tcell.KeyCtrlA + tcell.Key(r-'A'), r - 'A' + 1, true
+		}
+	}
+	return tcell.KeyRune, r, true
+}
@@ -0,0 +1,209 @@
+package cui
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"golang.org/x/net/websocket"
+)
+
+// webClient is a test client standing in for the browser terminal.
+type webClient struct {
+	t    *testing.T
+	conn *websocket.Conn
+}
+
+func (c *webClient) send(msg string) {
+	c.t.Helper()
+	if err := websocket.Message.Send(c.conn, msg); err != nil {
+		c.t.Fatalf("failed to send %s: %s", msg, err)
+	}
+}
+
+// wait receives frames until the screen contains the provided text.
+func (c *webClient) wait(s string) *webMessage {
+	c.t.Helper()
+	c.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		var frame webMessage
+		if err := websocket.JSON.Receive(c.conn, &frame); err != nil {
+			c.t.Fatalf("failed to receive %q: %s", s, err)
+		}
+		var lines []string
+		for _, row := range frame.Rows {
+			var line strings.Builder
+			for _, segment := range row {
+				line.WriteString(segment[0].(string))
+			}
+			lines = append(lines, line.String())
+		}
+		if strings.Contains(strings.Join(lines, "\n"), s) {
+			return &frame
+		}
+	}
+}
+
+func TestServeWeb(t *testing.T) {
+	t.Parallel()
+
+	stopped := make(chan struct{}, 1)
+	keys := make(chan *tcell.EventKey, 10)
+	mice := make(chan *tcell.EventMouse, 10)
+	handler := ServeWeb(func(r *http.Request) *App {
+		text := NewTextView()
+		text.SetText("hello " + r.URL.Query().Get("user"))
+		text.SetTextColor(tcell.ColorRed)
+		app := New()
+		app.EnableMouse(true)
+		app.SetRoot(text, true)
+		app.SetAfterResizeFunc(func(width, height int) {
+			text.SetText(fmt.Sprintf("size %d %d", width, height))
+		})
+		app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+			keys <- event
+			return event
+		})
+		app.SetMouseCapture(func(event *tcell.EventMouse, action MouseAction) (*tcell.EventMouse, MouseAction) {
+			mice <- event
+			return event, action
+		})
+		return app
+	})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r)
+		if r.Header.Get("Upgrade") != "" {
+			stopped <- struct{}{}
+		}
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("failed to get page: %s", err)
+	}
+	page, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(page), "new WebSocket") {
+		t.Errorf("failed to serve page: got %s", page)
+	}
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+	if _, err := websocket.Dial(wsURL, "", "http://example.com"); err == nil {
+		t.Errorf("expected cross-origin connection to be rejected")
+	}
+
+	conn, err := websocket.Dial(wsURL+"/?user=joe&cols=40&rows=10", "", server.URL)
+	if err != nil {
+		t.Fatalf("failed to connect: %s", err)
+	}
+	defer conn.Close()
+	c := &webClient{t: t, conn: conn}
+
+	frame := c.wait("hello joe")
+	if frame.Width != 40 || frame.Height != 10 {
+		t.Errorf("failed to apply initial size: expected 40x10, got %dx%d", frame.Width, frame.Height)
+	}
+	if style := frame.Styles[int(frame.Rows[0][0][1].(float64))]; style[0] != "#ff0000" {
+		t.Errorf("failed to encode style: got %v", style)
+	}
+
+	c.send(`{"type": "key", "key": "a"}`)
+	c.send(`{"type": "key", "key": "ArrowUp", "shift": true}`)
+	for _, expected := range []string{"Rune[a]", "Shift+Up"} {
+		if got := (<-keys).Name(); got != expected {
+			t.Errorf("failed to forward key: expected %s, got %s", expected, got)
+		}
+	}
+	c.send(`{"type": "paste", "text": "x\ny"}`)
+	for _, expected := range []string{"Rune[x]", "Enter", "Rune[y]"} {
+		if got := (<-keys).Name(); got != expected {
+			t.Errorf("failed to forward paste: expected %s, got %s", expected, got)
+		}
+	}
+	c.send(`{"type": "mouse", "x": 3, "y": 2, "buttons": 1}`)
+	if event := <-mice; event.Buttons() != tcell.Button1 {
+		t.Errorf("failed to forward mouse: got %v", event.Buttons())
+	} else if x, y := event.Position(); x != 3 || y != 2 {
+		t.Errorf("failed to forward mouse position: expected 3,2, got %d,%d", x, y)
+	}
+
+	c.send(`not json`)
+	c.send(`{"type": "resize", "w": 50, "h": 12}`)
+	if frame := c.wait("size 50 12"); frame.Width != 50 || frame.Height != 12 {
+		t.Errorf("failed to resize: expected 50x12, got %dx%d", frame.Width, frame.Height)
+	}
+	c.send(`{"type": "resize", "w": 100000, "h": 12}`)
+	if frame := c.wait("size 1000 12"); frame.Width != 1000 || frame.Height != 12 {
+		t.Errorf("failed to clamp size: expected 1000x12, got %dx%d", frame.Width, frame.Height)
+	}
+
+	// Ctrl-C stops the application and closes the connection.
+	c.send(`{"type": "key", "key": "c", "ctrl": true}`)
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected application to be stopped")
+	}
+	var msg string
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		if err := websocket.Message.Receive(conn, &msg); err != nil {
+			if err != io.EOF {
+				t.Errorf("expected connection to be closed: got %s", err)
+			}
+			break
+		}
+	}
+
+	// Closing the connection stops the application.
+	conn, err = websocket.Dial(wsURL+"/?cols=100000&rows=5", "", server.URL)
+	if err != nil {
+		t.Fatalf("failed to connect: %s", err)
+	}
+	c = &webClient{t: t, conn: conn}
+	if frame := c.wait("hello"); frame.Width != 1000 || frame.Height != 5 {
+		t.Errorf("failed to clamp initial size: expected 1000x5, got %dx%d", frame.Width, frame.Height)
+	}
+	conn.Close()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Errorf("expected application to be stopped on disconnect")
+	}
+}
+
+func TestWebKey(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range []struct {
+		name string
+		mod  tcell.ModMask
+		key  tcell.Key
+		ch   rune
+	}{
+		{"a", tcell.ModNone, tcell.KeyRune, 'a'},
+		{"ü", tcell.ModNone, tcell.KeyRune, 'ü'},
+		{"Enter", tcell.ModNone, tcell.KeyEnter, rune(tcell.KeyEnter)},
+		{"Tab", tcell.ModShift, tcell.KeyBacktab, 0},
+		{"c", tcell.ModCtrl, tcell.KeyCtrlC, 3},
+	} {
+		key, ch, ok := webKey(test.name, test.mod)
+		if !ok || key != test.key || ch != test.ch {
+			t.Errorf("failed to map key %s: expected %d/%q, got %d/%q", test.name, test.key, test.ch, key, ch)
+		}
+	}
+	if _, _, ok := webKey("Unidentified", tcell.ModNone); ok {
+		t.Errorf("expected unknown key to be ignored")
+	}
+	var msg webMessage
+	if err := json.Unmarshal([]byte(`{"type":"resize","w":1,"h":2}`), &msg); err != nil || msg.Width != 1 || msg.Height != 2 {
+		t.Errorf("failed to decode message: got %+v (%v)", msg, err)
+	}
+}